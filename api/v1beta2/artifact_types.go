@@ -42,10 +42,26 @@ type Artifact struct {
 	// +optional
 	Revision string `json:"revision"`
 
-	// Checksum is the SHA256 checksum of the Artifact file.
+	// Checksum is the SHA256 checksum of the Artifact file. Checksums of other
+	// algorithms are prefixed with the algorithm, e.g. 'sha512:<checksum>'.
 	// +optional
 	Checksum string `json:"checksum"`
 
+	// ContentChecksum is the SHA256 checksum of the uncompressed content the
+	// Artifact was archived from, excluding modification times. It is used to
+	// reuse the Artifact for new revisions with identical content. Only set
+	// for Artifacts archived from a directory.
+	// +optional
+	ContentChecksum string `json:"contentChecksum,omitempty"`
+
+	// Digest is the digest of the upstream content the Artifact was produced
+	// from, in the format '<algorithm>:<hex>', e.g. the digest of the OCI
+	// manifest. Unlike the Revision, it can be used as is to pin the upstream
+	// content. Only set for Artifacts of an OCIRepository, and of a HelmChart
+	// pinned to the digest of a chart in an OCI repository.
+	// +optional
+	Digest string `json:"digest,omitempty"`
+
 	// LastUpdateTime is the timestamp corresponding to the last update of the
 	// Artifact.
 	// +required
@@ -55,6 +71,11 @@ type Artifact struct {
 	// +optional
 	Size *int64 `json:"size,omitempty"`
 
+	// FileCount is the number of files included in the Artifact, if it is an
+	// archive of a directory.
+	// +optional
+	FileCount *int64 `json:"fileCount,omitempty"`
+
 	// Metadata holds upstream information such as OCI annotations.
 	// +optional
 	Metadata map[string]string `json:"metadata,omitempty"`
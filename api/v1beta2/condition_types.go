@@ -60,6 +60,26 @@ const (
 	// This is a "negative polarity" or "abnormal-true" type, and is only
 	// present on the resource if it is True.
 	StorageOperationFailedCondition string = "StorageOperationFailed"
+
+	// ValidatedCondition indicates the outcome of a validation of the
+	// configuration of a Source, without producing an Artifact.
+	// If True, the validation succeeded. If False, it failed.
+	// This Condition is only present on the resource while a validation is
+	// requested.
+	ValidatedCondition string = "Validated"
+
+	// TagListIncompleteCondition indicates the list of tags of an upstream
+	// Source may be incomplete, for example due to broken pagination of the
+	// tag listing by the registry. The selected revision may then be stale.
+	// This is a "negative polarity" or "abnormal-true" type, and is only
+	// present on the resource if it is True.
+	TagListIncompleteCondition string = "TagListIncomplete"
+
+	// CredentialExpiringCondition indicates the registry credentials of a
+	// Source expire within the credential expiry window of the controller.
+	// This is a "negative polarity" or "abnormal-true" type, and is only
+	// present on the resource if it is True.
+	CredentialExpiringCondition string = "CredentialExpiring"
 )
 
 // Reasons are provided as utility, and not part of the declarative API.
@@ -95,6 +115,10 @@ const (
 	// ArchiveOperationFailedReason signals a failure in archive operation.
 	ArchiveOperationFailedReason string = "ArchiveOperationFailed"
 
+	// ArchiveTruncatedReason signals that an archive ended unexpectedly,
+	// e.g. because its download was interrupted.
+	ArchiveTruncatedReason string = "ArchiveTruncated"
+
 	// SymlinkUpdateFailedReason signals a failure in updating a symlink.
 	SymlinkUpdateFailedReason string = "SymlinkUpdateFailed"
 
@@ -104,4 +128,12 @@ const (
 
 	// CacheOperationFailedReason signals a failure in cache operation.
 	CacheOperationFailedReason string = "CacheOperationFailed"
+
+	// IndexCacheEvictedReason signals that the index of a HelmRepository was
+	// evicted from the in-memory cache on request.
+	IndexCacheEvictedReason string = "IndexCacheEvicted"
+
+	// ArtifactChecksumMismatchReason signals that the file of an Artifact in
+	// storage does not match its recorded checksum.
+	ArtifactChecksumMismatchReason string = "ArtifactChecksumMismatch"
 )
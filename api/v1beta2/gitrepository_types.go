@@ -202,6 +202,12 @@ type GitRepositoryStatus struct {
 	// +optional
 	Conditions []metav1.Condition `json:"conditions,omitempty"`
 
+	// ConsecutiveFailures is the number of consecutive failed reconciliations
+	// with the FetchFailed condition set to True. It is reset when a
+	// reconciliation does not fail to fetch.
+	// +optional
+	ConsecutiveFailures int64 `json:"consecutiveFailures,omitempty"`
+
 	// URL is the dynamic fetch link for the latest Artifact.
 	// It is provided on a "best effort" basis, and using the precise
 	// GitRepositoryStatus.Artifact data is recommended.
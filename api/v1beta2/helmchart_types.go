@@ -20,6 +20,7 @@ import (
 	"time"
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
 
 	"github.com/fluxcd/pkg/apis/acl"
 	"github.com/fluxcd/pkg/apis/meta"
@@ -28,6 +29,15 @@ import (
 // HelmChartKind is the string representation of a HelmChart.
 const HelmChartKind = "HelmChart"
 
+// HelmChartDependencyRepositoryIndexKey is the key used for indexing HelmChart
+// objects by the names of the HelmRepositories their dependencies were
+// resolved from, see HelmChartStatus.ObservedDependencyRevisions.
+const HelmChartDependencyRepositoryIndexKey = ".metadata.dependencyRepository"
+
+// HelmChartValuesFromIndexKey is the key used for indexing HelmChart objects
+// by the names of the ConfigMaps referenced in their ValuesFrom.
+const HelmChartValuesFromIndexKey = ".spec.valuesFrom"
+
 // HelmChartSpec specifies the desired state of a Helm chart.
 type HelmChartSpec struct {
 	// Chart is the name or path the Helm chart is available at in the
@@ -36,11 +46,20 @@ type HelmChartSpec struct {
 	Chart string `json:"chart"`
 
 	// Version is the chart version semver expression, ignored for charts from
-	// GitRepository and Bucket sources. Defaults to latest when omitted.
+	// GitRepository sources, and Bucket sources unless the chart is resolved
+	// from an index.yaml in the Bucket. Defaults to latest when omitted.
 	// +kubebuilder:default:=*
 	// +optional
 	Version string `json:"version,omitempty"`
 
+	// IgnorePrerelease filters out the prerelease chart versions matching the
+	// Version expression, unless they are a prerelease of a version explicitly
+	// referenced by a prerelease in the expression. For example, '>=1.0.0-0'
+	// matches '1.0.0-rc.1' but not '2.0.0-rc.1'. Ignored for charts from
+	// GitRepository and Bucket sources.
+	// +optional
+	IgnorePrerelease bool `json:"ignorePrerelease,omitempty"`
+
 	// SourceRef is the reference to the Source the chart is available at.
 	// +required
 	SourceRef LocalHelmChartSourceReference `json:"sourceRef"`
@@ -60,6 +79,19 @@ type HelmChartSpec struct {
 	// +optional
 	ReconcileStrategy string `json:"reconcileStrategy,omitempty"`
 
+	// AppVersionFromRevision sets the appVersion of the packaged chart to the
+	// revision of the source, shortened to 12 characters. This field is only
+	// supported when using GitRepository or Bucket sources.
+	// +optional
+	AppVersionFromRevision bool `json:"appVersionFromRevision,omitempty"`
+
+	// SkipDependencyUpdate packages the chart with the dependencies vendored in
+	// its charts/ directory, without resolving the dependencies listed in its
+	// Chart.yaml. The build fails if any of these dependencies is missing.
+	// This field is only supported when using GitRepository or Bucket sources.
+	// +optional
+	SkipDependencyUpdate bool `json:"skipDependencyUpdate,omitempty"`
+
 	// ValuesFiles is an alternative list of values files to use as the chart
 	// values (values.yaml is not included by default), expected to be a
 	// relative path in the SourceRef.
@@ -76,6 +108,32 @@ type HelmChartSpec struct {
 	// +deprecated
 	ValuesFile string `json:"valuesFile,omitempty"`
 
+	// IgnoreMissingValuesFiles controls whether to silently ignore missing
+	// values files rather than failing.
+	// +optional
+	IgnoreMissingValuesFiles bool `json:"ignoreMissingValuesFiles,omitempty"`
+
+	// ValuesFrom is a list of references to ConfigMaps in the same namespace,
+	// holding values to merge into the chart values. They are merged in the
+	// order of this list, after the ValuesFiles items.
+	// +optional
+	ValuesFrom []ValuesReference `json:"valuesFrom,omitempty"`
+
+	// ValuesInline holds values to merge into the chart values, after the
+	// ValuesFiles items and the ValuesFrom references, which gives them the
+	// highest precedence.
+	// +kubebuilder:pruning:PreserveUnknownFields
+	// +optional
+	ValuesInline *runtime.RawExtension `json:"valuesInline,omitempty"`
+
+	// HistoryLimit is the number of previous Artifacts to retain in the
+	// Storage next to the current Artifact, for example to allow rolling
+	// back to them. Defaults to 0, which retains previous Artifacts only
+	// according to the retention configuration of the controller.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	HistoryLimit int `json:"historyLimit,omitempty"`
+
 	// Suspend tells the controller to suspend the reconciliation of this
 	// source.
 	// +optional
@@ -90,10 +148,22 @@ type HelmChartSpec struct {
 	// Verify contains the secret name containing the trusted public keys
 	// used to verify the signature and specifies which provider to use to check
 	// whether OCI image is authentic.
-	// This field is only supported when using HelmRepository source with spec.type 'oci'.
-	// Chart dependencies, which are not bundled in the umbrella chart artifact, are not verified.
+	// This field is only supported when using HelmRepository source, or
+	// with VerifyDependencies. The 'cosign' provider is supported with
+	// spec.type 'oci', the 'pgp' provider for the other types.
+	// Chart dependencies, which are not bundled in the umbrella chart artifact,
+	// are not verified unless VerifyDependencies is set.
 	// +optional
 	Verify *OCIRepositoryVerification `json:"verify,omitempty"`
+
+	// VerifyDependencies enables the verification of the remote dependencies
+	// of a chart built from a GitRepository or Bucket source, using the
+	// provider and keys of Verify. The dependencies from an OCI registry are
+	// verified with the 'cosign' provider, the others with the 'pgp'
+	// provider. The build fails if any dependency is unsigned or fails the
+	// verification.
+	// +optional
+	VerifyDependencies bool `json:"verifyDependencies,omitempty"`
 }
 
 const (
@@ -122,6 +192,59 @@ type LocalHelmChartSourceReference struct {
 	Name string `json:"name"`
 }
 
+// ValuesReference contains a reference to a resource containing Helm values,
+// and optionally the key they can be found at.
+type ValuesReference struct {
+	// Kind of the values referent, the only valid value is ('ConfigMap'), as
+	// the values are packaged in the chart Artifact.
+	// +kubebuilder:validation:Enum=ConfigMap
+	// +required
+	Kind string `json:"kind"`
+
+	// Name of the values referent. Should reside in the same namespace as the
+	// referring resource.
+	// +kubebuilder:validation:MinLength=1
+	// +kubebuilder:validation:MaxLength=253
+	// +required
+	Name string `json:"name"`
+
+	// ValuesKey is the data key where the values.yaml can be found at in the
+	// referenced resource. Defaults to 'values.yaml' when omitted.
+	// +kubebuilder:validation:MaxLength=253
+	// +kubebuilder:validation:Pattern=`^[\-._a-zA-Z0-9]+$`
+	// +optional
+	ValuesKey string `json:"valuesKey,omitempty"`
+}
+
+// GetValuesKey returns the defined ValuesKey, or the default ('values.yaml').
+func (in ValuesReference) GetValuesKey() string {
+	if in.ValuesKey == "" {
+		return "values.yaml"
+	}
+	return in.ValuesKey
+}
+
+// HelmChartDependency describes a dependency of a Helm chart which was
+// resolved while building the chart.
+type HelmChartDependency struct {
+	// Name of the dependency, or its alias if set.
+	// +required
+	Name string `json:"name"`
+
+	// Version of the dependency chart.
+	// +required
+	Version string `json:"version"`
+
+	// Repository the dependency was resolved from, as declared in the
+	// chart.
+	// +optional
+	Repository string `json:"repository,omitempty"`
+
+	// Digest is the OCI digest the dependency was pinned to, if any.
+	// +optional
+	Digest string `json:"digest,omitempty"`
+}
+
 // HelmChartStatus records the observed state of the HelmChart.
 type HelmChartStatus struct {
 	// ObservedGeneration is the last observed generation of the HelmChart
@@ -139,10 +262,49 @@ type HelmChartStatus struct {
 	// +optional
 	ObservedChartName string `json:"observedChartName,omitempty"`
 
+	// ObservedChartVersion is the version of the chart of the current
+	// Artifact, as resolved from the HelmChartSpec.Version and including any
+	// build metadata.
+	// +optional
+	ObservedChartVersion string `json:"observedChartVersion,omitempty"`
+
+	// AppVersion is the appVersion of the chart of the current Artifact.
+	// +optional
+	AppVersion string `json:"appVersion,omitempty"`
+
+	// ObservedChartDigest is the digest of the last built chart version as
+	// published in the index of the HelmRepository. It is used to detect
+	// changes to the chart independent of changes to the repository index.
+	// +optional
+	ObservedChartDigest string `json:"observedChartDigest,omitempty"`
+
+	// ResolvedDependencies is the list of dependencies resolved while
+	// building the chart of the current Artifact.
+	// +optional
+	ResolvedDependencies []HelmChartDependency `json:"resolvedDependencies,omitempty"`
+
+	// ObservedDependencyRevisions maps the names of the HelmRepositories the
+	// remote dependencies of the chart of the current Artifact were resolved
+	// from to the revision of their Artifact at the time of the build.
+	// +optional
+	ObservedDependencyRevisions map[string]string `json:"observedDependencyRevisions,omitempty"`
+
+	// ObservedValuesFiles are the observed value files of the last successful
+	// reconciliation. It matches the chart in the last successfully reconciled
+	// artifact.
+	// +optional
+	ObservedValuesFiles []string `json:"observedValuesFiles,omitempty"`
+
 	// Conditions holds the conditions for the HelmChart.
 	// +optional
 	Conditions []metav1.Condition `json:"conditions,omitempty"`
 
+	// ConsecutiveFailures is the number of consecutive failed reconciliations
+	// with the FetchFailed condition set to True. It is reset when a
+	// reconciliation does not fail to fetch.
+	// +optional
+	ConsecutiveFailures int64 `json:"consecutiveFailures,omitempty"`
+
 	// URL is the dynamic fetch link for the latest Artifact.
 	// It is provided on a "best effort" basis, and using the precise
 	// BucketStatus.Artifact data is recommended.
@@ -153,6 +315,12 @@ type HelmChartStatus struct {
 	// +optional
 	Artifact *Artifact `json:"artifact,omitempty"`
 
+	// History holds the previous Artifacts retained in the Storage according
+	// to the HistoryLimit, ordered by their LastUpdateTime, most recent
+	// first.
+	// +optional
+	History []Artifact `json:"history,omitempty"`
+
 	meta.ReconcileRequestStatus `json:",inline"`
 }
 
@@ -164,6 +332,10 @@ const (
 	// ChartPackageSucceededReason signals that the package of the Helm
 	// chart succeeded.
 	ChartPackageSucceededReason string = "ChartPackageSucceeded"
+
+	// ValuesFromFailedReason signals that the values of a ValuesReference
+	// could not be fetched or parsed.
+	ValuesFromFailedReason string = "ValuesFromFailed"
 )
 
 // GetConditions returns the status conditions of the object.
@@ -212,6 +384,9 @@ func (in *HelmChart) GetValuesFiles() []string {
 // +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp",description=""
 // +kubebuilder:printcolumn:name="Ready",type="string",JSONPath=".status.conditions[?(@.type==\"Ready\")].status",description=""
 // +kubebuilder:printcolumn:name="Status",type="string",JSONPath=".status.conditions[?(@.type==\"Ready\")].message",description=""
+// +kubebuilder:printcolumn:name="Size",type=integer,JSONPath=`.status.artifact.size`,priority=1
+// +kubebuilder:printcolumn:name="Chart Version",type=string,JSONPath=`.status.observedChartVersion`,priority=1
+// +kubebuilder:printcolumn:name="App Version",type=string,JSONPath=`.status.appVersion`,priority=1
 
 // HelmChart is the Schema for the helmcharts API.
 type HelmChart struct {
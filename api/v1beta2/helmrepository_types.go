@@ -36,6 +36,11 @@ const (
 	HelmRepositoryTypeDefault = "default"
 	// HelmRepositoryTypeOCI is the type for an OCI repository.
 	HelmRepositoryTypeOCI = "oci"
+	// IndexCacheEvictionAnnotation is the annotation that can be set on a
+	// HelmRepository or a HelmChart to a new value, e.g. the current time, to
+	// evict the index of the HelmRepository from the in-memory cache of the
+	// controller on the next reconcile.
+	IndexCacheEvictionAnnotation = "source.toolkit.fluxcd.io/evict-index-cache"
 )
 
 // HelmRepositorySpec specifies the required configuration to produce an
@@ -55,6 +60,19 @@ type HelmRepositorySpec struct {
 	// +optional
 	SecretRef *meta.LocalObjectReference `json:"secretRef,omitempty"`
 
+	// CertSecretRef can be given the name of a Secret containing
+	// either or both of
+	//
+	//  - a PEM-encoded client certificate (`certFile` or `tls.crt`) and
+	//  private key (`keyFile` or `tls.key`);
+	//  - a PEM-encoded CA certificate (`caFile` or `ca.crt`)
+	//
+	// and whichever are supplied, will be used for connecting to the
+	// registry. This field is only taken into account if the .spec.type
+	// field is set to 'oci'.
+	// +optional
+	CertSecretRef *meta.LocalObjectReference `json:"certSecretRef,omitempty"`
+
 	// PassCredentials allows the credentials from the SecretRef to be passed
 	// on to a host that does not match the host as defined in URL.
 	// This may be required if the host of the advertised chart URLs in the
@@ -98,9 +116,10 @@ type HelmRepositorySpec struct {
 
 	// Provider used for authentication, can be 'aws', 'azure', 'gcp' or 'generic'.
 	// This field is optional, and only taken into account if the .spec.type field is set to 'oci'.
-	// When not specified, defaults to 'generic'.
+	// When not specified, the provider configured on the namespace through the
+	// OCIProviderAnnotation is used, or 'generic' if the namespace does not
+	// configure one.
 	// +kubebuilder:validation:Enum=generic;aws;azure;gcp
-	// +kubebuilder:default:=generic
 	// +optional
 	Provider string `json:"provider,omitempty"`
 }
@@ -116,6 +135,12 @@ type HelmRepositoryStatus struct {
 	// +optional
 	Conditions []metav1.Condition `json:"conditions,omitempty"`
 
+	// ConsecutiveFailures is the number of consecutive failed reconciliations
+	// with the FetchFailed condition set to True. It is reset when a
+	// reconciliation does not fail to fetch.
+	// +optional
+	ConsecutiveFailures int64 `json:"consecutiveFailures,omitempty"`
+
 	// URL is the dynamic fetch link for the latest Artifact.
 	// It is provided on a "best effort" basis, and using the precise
 	// HelmRepositoryStatus.Artifact data is recommended.
@@ -126,6 +151,18 @@ type HelmRepositoryStatus struct {
 	// +optional
 	Artifact *Artifact `json:"artifact,omitempty"`
 
+	// IndexETag is the ETag of the index of the Artifact, as served by the
+	// repository. It is sent in the If-None-Match header of the next request
+	// for the index, to not download an unchanged index.
+	// +optional
+	IndexETag string `json:"indexETag,omitempty"`
+
+	// IndexLastModified is the Last-Modified time of the index of the
+	// Artifact, as served by the repository. It is sent in the
+	// If-Modified-Since header of the next request for the index.
+	// +optional
+	IndexLastModified string `json:"indexLastModified,omitempty"`
+
 	meta.ReconcileRequestStatus `json:",inline"`
 }
 
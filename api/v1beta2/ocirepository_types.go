@@ -19,6 +19,7 @@ package v1beta2
 import (
 	"time"
 
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	"github.com/fluxcd/pkg/apis/meta"
@@ -46,11 +47,61 @@ const (
 	// Managed Identity or Shared Key.
 	AzureOCIProvider string = "azure"
 
+	// OCIProviderAnnotation is the annotation that can be set on a Namespace
+	// to configure the default OCI authentication provider for objects in
+	// that namespace which do not specify a provider themselves.
+	OCIProviderAnnotation string = "source.toolkit.fluxcd.io/oci-provider"
+
+	// OCIValidateAnnotation is the annotation that can be set to "true" on an
+	// OCIRepository to validate its URL, credentials and verification
+	// configuration next to its reconciliation, recording the outcome in the
+	// Validated Condition.
+	OCIValidateAnnotation string = "source.toolkit.fluxcd.io/validate"
+
 	// OCILayerExtract defines the operation type for extracting the content from an OCI artifact layer.
 	OCILayerExtract = "extract"
 
 	// OCILayerCopy defines the operation type for copying the content from an OCI artifact layer.
 	OCILayerCopy = "copy"
+
+	// OCIArtifactModTimeStored sets the modification time of the stored artifact
+	// file to the time at which it was stored.
+	OCIArtifactModTimeStored = "stored"
+
+	// OCIArtifactModTimeCreated sets the modification time of the stored artifact
+	// file, and of the files in its tarball, to the creation time of the
+	// upstream OCI artifact.
+	OCIArtifactModTimeCreated = "created"
+
+	// OCIArtifactCompressionGzip stores the extracted layer content as a gzip
+	// compressed tarball.
+	OCIArtifactCompressionGzip = "gzip"
+
+	// OCIArtifactCompressionNone stores the extracted layer content as an
+	// uncompressed tarball.
+	OCIArtifactCompressionNone = "none"
+
+	// OCIAttestationSLSAProvenance is the in-toto predicate type of a SLSA
+	// provenance attestation, and the default predicate type of an
+	// OCIAttestationVerification.
+	OCIAttestationSLSAProvenance = "https://slsa.dev/provenance/v0.2"
+
+	// OCILayerMediaTypeMetadataKey is the Artifact metadata key recording
+	// the media type of the layer persisted to storage by the 'copy'
+	// operation. Consumers use it to determine whether the Artifact is a
+	// tarball.
+	OCILayerMediaTypeMetadataKey = "source.toolkit.fluxcd.io/layer-media-type"
+
+	// OCIContentTypeMetadataKey is the Artifact metadata key recording the
+	// content type of the Artifact file, as detected by the controller, e.g.
+	// 'application/tar+gzip' or the media type of a Helm chart. Consumers use
+	// it to handle the Artifact without guessing from its extension.
+	OCIContentTypeMetadataKey = "source.toolkit.fluxcd.io/content-type"
+
+	// OCIModTimeMetadataKey is the Artifact metadata key recording the
+	// creation time of the upstream OCI artifact as an RFC 3339 timestamp,
+	// when the 'created' ArtifactModTime is used.
+	OCIModTimeMetadataKey = "source.toolkit.fluxcd.io/mod-time"
 )
 
 // OCIRepositorySpec defines the desired state of OCIRepository
@@ -67,14 +118,22 @@ type OCIRepositorySpec struct {
 	Reference *OCIRepositoryRef `json:"ref,omitempty"`
 
 	// LayerSelector specifies which layer should be extracted from the OCI artifact.
-	// When not specified, the first layer found in the artifact is selected.
+	// When not specified, the single layer of the artifact, or else all its
+	// tar+gzip layers, are extracted.
 	// +optional
 	LayerSelector *OCILayerSelector `json:"layerSelector,omitempty"`
 
+	// Platform specifies the platform of the manifest to pull when the OCI
+	// reference points to an image index. When not specified, the default
+	// platform of the registry client (linux/amd64) is used.
+	// +optional
+	Platform *OCIPlatform `json:"platform,omitempty"`
+
 	// The provider used for authentication, can be 'aws', 'azure', 'gcp' or 'generic'.
-	// When not specified, defaults to 'generic'.
+	// When not specified, the provider configured on the namespace through the
+	// OCIProviderAnnotation is used, or 'generic' if the namespace does not
+	// configure one.
 	// +kubebuilder:validation:Enum=generic;aws;azure;gcp
-	// +kubebuilder:default:=generic
 	// +optional
 	Provider string `json:"provider,omitempty"`
 
@@ -90,9 +149,24 @@ type OCIRepositorySpec struct {
 	// +optional
 	Verify *OCIRepositoryVerification `json:"verify,omitempty"`
 
+	// VerificationRetry specifies the exponential backoff used to retry a
+	// failed verification of the OCI Artifact. When not specified, the
+	// backoff configured for the controller is used.
+	// +optional
+	VerificationRetry *OCIRetryBackoff `json:"verificationRetry,omitempty"`
+
+	// RequireSBOM specifies the Software Bill of Materials which must refer
+	// to the OCI Artifact for it to be accepted. The SBOM is discovered
+	// through the OCI referrers of the artifact.
+	// +optional
+	RequireSBOM *OCISBOMRequirement `json:"requireSBOM,omitempty"`
+
 	// ServiceAccountName is the name of the Kubernetes ServiceAccount used to authenticate
 	// the image pull if the service account has attached pull secrets. For more information:
 	// https://kubernetes.io/docs/tasks/configure-pod-container/configure-service-account/#add-imagepullsecrets-to-a-service-account
+	// With a cloud Provider, the ServiceAccount token is exchanged for
+	// registry credentials when the ServiceAccount is configured for the
+	// workload identity of the provider.
 	// +optional
 	ServiceAccountName string `json:"serviceAccountName,omitempty"`
 
@@ -106,7 +180,9 @@ type OCIRepositorySpec struct {
 	//  and whichever are supplied, will be used for connecting to the
 	//  registry. The client cert and key are useful if you are
 	//  authenticating with a certificate; the CA cert is useful if
-	//  you are using a self-signed server certificate.
+	//  you are using a self-signed server certificate. The TLS server
+	//  name (`serverName`) overrides the host of the URL in the SNI
+	//  extension and the verification of the server certificate.
 	// +optional
 	CertSecretRef *meta.LocalObjectReference `json:"certSecretRef,omitempty"`
 
@@ -123,12 +199,84 @@ type OCIRepositorySpec struct {
 	// +optional
 	Timeout *metav1.Duration `json:"timeout,omitempty"`
 
+	// Timeouts specifies separate timeouts for the listing, pulling and
+	// verification operations of a reconciliation, which default to the
+	// timeout.
+	// +optional
+	Timeouts *OCIRepositoryTimeouts `json:"timeouts,omitempty"`
+
 	// Ignore overrides the set of excluded patterns in the .sourceignore format
 	// (which is the same as .gitignore). If not provided, a default will be used,
 	// consult the documentation for your version to find out what those are.
 	// +optional
 	Ignore *string `json:"ignore,omitempty"`
 
+	// Include restricts the files in the artifact to the ones matching the
+	// set of patterns in the .sourceignore format (which is the same as
+	// .gitignore), before the excluded patterns of Ignore are applied.
+	// If not provided, all files are included.
+	// +optional
+	Include *string `json:"include,omitempty"`
+
+	// ExpectedPaths is a list of glob patterns, in the format of Go's
+	// path.Match, of the file paths allowed in the extracted layer content.
+	// A pattern matching a directory allows all files within it. When
+	// specified, the reconciliation fails and no artifact is stored if the
+	// content contains a file which does not match any of the patterns.
+	// Only taken into account when the layer operation is 'extract'.
+	// +kubebuilder:validation:MaxItems=100
+	// +optional
+	ExpectedPaths []string `json:"expectedPaths,omitempty"`
+
+	// MetadataKeys is a list of the keys of the OCI artifact manifest
+	// annotations which are copied to the metadata of the Artifact. When
+	// omitted, all the annotations are copied.
+	// +kubebuilder:validation:MaxItems=100
+	// +optional
+	MetadataKeys []string `json:"metadataKeys,omitempty"`
+
+	// MaxSize is the maximum size of the OCI artifact layer, and of the total
+	// content extracted from it. When exceeded, the reconciliation fails and
+	// no artifact is stored.
+	// +optional
+	MaxSize *resource.Quantity `json:"maxSize,omitempty"`
+
+	// ArtifactModTime determines the modification time of the stored artifact
+	// file. With 'stored', the time at which the artifact is stored is used.
+	// With 'created', the 'org.opencontainers.image.created' annotation of the
+	// OCI artifact is used for the file and the files in its tarball, or the
+	// Unix epoch if the annotation is absent, and recorded in the
+	// 'source.toolkit.fluxcd.io/mod-time' artifact metadata, resulting in
+	// identical artifacts for identical content.
+	// Defaults to 'stored'.
+	// +kubebuilder:validation:Enum=stored;created
+	// +optional
+	ArtifactModTime string `json:"artifactModTime,omitempty"`
+
+	// ArtifactCompression determines the compression of the tarball stored
+	// as artifact for the extracted layer content. With 'none', the content
+	// is stored as an uncompressed tarball with the '.tar' extension, for
+	// example to avoid compressing already compressed files twice. Defaults
+	// to 'gzip'. Not taken into account when the layer operation is 'copy'.
+	// +kubebuilder:validation:Enum=gzip;none
+	// +optional
+	ArtifactCompression string `json:"artifactCompression,omitempty"`
+
+	// HistoryLimit is the number of previous Artifacts to retain in the
+	// Storage next to the current Artifact, for example to allow rolling
+	// back to them. Defaults to 0, which retains previous Artifacts only
+	// according to the retention configuration of the controller.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	HistoryLimit int `json:"historyLimit,omitempty"`
+
+	// Mirror defines a secondary registry every pulled artifact is copied
+	// to, for example as a backup for disaster recovery. A failure to copy
+	// the artifact is reported in an event, and does not fail the
+	// reconciliation.
+	// +optional
+	Mirror *OCIRepositoryMirror `json:"mirror,omitempty"`
+
 	// Insecure allows connecting to a non-TLS HTTP container registry.
 	// +optional
 	Insecure bool `json:"insecure,omitempty"`
@@ -138,6 +286,23 @@ type OCIRepositorySpec struct {
 	Suspend bool `json:"suspend,omitempty"`
 }
 
+// OCIRepositoryMirror defines the secondary registry the pulled artifacts
+// of an OCIRepository are copied to.
+type OCIRepositoryMirror struct {
+	// URL is the OCI repository the artifacts are copied to, in the format
+	// 'oci://<host>/<org>/<repo>'. The artifacts are copied with the tag
+	// they were pulled with, or by digest if they were pulled by digest.
+	// +kubebuilder:validation:Pattern="^oci://.*$"
+	// +required
+	URL string `json:"url"`
+
+	// SecretRef contains the name of the Secret with the login credentials
+	// of the mirror registry, in the format of an image pull secret of type
+	// 'kubernetes.io/dockerconfigjson'.
+	// +optional
+	SecretRef *meta.LocalObjectReference `json:"secretRef,omitempty"`
+}
+
 // OCIRepositoryRef defines the image reference for the OCIRepository's URL
 type OCIRepositoryRef struct {
 	// Digest is the image digest to pull, takes precedence over SemVer.
@@ -150,9 +315,30 @@ type OCIRepositoryRef struct {
 	// +optional
 	SemVer string `json:"semver,omitempty"`
 
+	// SemVerIgnorePrerelease filters out the prerelease tags matching the
+	// SemVer range, unless they are a prerelease of a version explicitly
+	// referenced by a prerelease in the range. For example, '>=1.0.0-0'
+	// matches '1.0.0-rc.1' but not '2.0.0-rc.1'.
+	// +optional
+	SemVerIgnorePrerelease bool `json:"semverIgnorePrerelease,omitempty"`
+
 	// Tag is the image tag to pull, defaults to latest.
 	// +optional
 	Tag string `json:"tag,omitempty"`
+
+	// RequireImmutable fails the reconciliation when the reference resolves
+	// to a mutable tag, i.e. when neither a Digest nor a SemVer range is
+	// specified. The tag selected by a SemVer range is resolved to the digest
+	// of the artifact, which is verified and pulled by that digest.
+	// +optional
+	RequireImmutable bool `json:"requireImmutable,omitempty"`
+
+	// VerifyDigest is the expected digest of the artifact the reference
+	// resolves to, in the format 'sha256:<HASH>'. When set, the reconciliation
+	// fails if the Tag or SemVer range resolves to a different digest, which
+	// detects a tag being repointed to other content.
+	// +optional
+	VerifyDigest string `json:"verifyDigest,omitempty"`
 }
 
 // OCILayerSelector specifies which layer should be extracted from an OCI Artifact
@@ -170,12 +356,58 @@ type OCILayerSelector struct {
 	// +kubebuilder:validation:Enum=extract;copy
 	// +optional
 	Operation string `json:"operation,omitempty"`
+
+	// Path specifies the path, or path.Match pattern, of the files or
+	// directories to extract from the selected layer. When set, only the
+	// matching files, and the content of the matching directories, are
+	// extracted. It is ignored when the operation is set to 'copy'.
+	// +optional
+	Path string `json:"path,omitempty"`
+
+	// Extension specifies the file extension of the Artifact persisted to
+	// storage when the operation is set to 'copy', e.g. 'json' or 'zip'.
+	// When omitted, the extension is derived from the media type of the
+	// selected layer, defaulting to 'tar.gz'. It is ignored when the
+	// operation is set to 'extract'.
+	// +kubebuilder:validation:Pattern="^[a-zA-Z0-9]+(\\.[a-zA-Z0-9]+)*$"
+	// +optional
+	Extension string `json:"extension,omitempty"`
+
+	// ConfigMediaType specifies the media type of the config of the OCI
+	// Artifact manifest. When set, artifacts whose config descriptor has a
+	// different media type are rejected.
+	// +optional
+	ConfigMediaType string `json:"configMediaType,omitempty"`
+
+	// RejectContainerImages rejects the OCI Artifacts whose manifest
+	// describes a container image, i.e. whose config descriptor has the media
+	// type of a Docker or OCI image configuration. This prevents consuming a
+	// runtime image as a source by accident.
+	// +optional
+	RejectContainerImages bool `json:"rejectContainerImages,omitempty"`
+}
+
+// OCIPlatform describes the platform of an OCI artifact manifest in an
+// image index.
+type OCIPlatform struct {
+	// OS is the operating system of the platform.
+	OS string `json:"os"`
+
+	// Architecture is the CPU architecture of the platform.
+	Architecture string `json:"architecture"`
+
+	// Variant is the variant of the CPU architecture.
+	// +optional
+	Variant string `json:"variant,omitempty"`
 }
 
 // OCIRepositoryVerification verifies the authenticity of an OCI Artifact
 type OCIRepositoryVerification struct {
 	// Provider specifies the technology used to sign the OCI Artifact.
-	// +kubebuilder:validation:Enum=cosign
+	// The 'pgp' provider verifies the provenance file of a Helm chart, and
+	// is only supported by HelmCharts from a HelmRepository which is not
+	// of type 'oci'.
+	// +kubebuilder:validation:Enum=cosign;pgp
 	// +kubebuilder:default:=cosign
 	Provider string `json:"provider"`
 
@@ -183,6 +415,143 @@ type OCIRepositoryVerification struct {
 	// trusted public keys.
 	// +optional
 	SecretRef *meta.LocalObjectReference `json:"secretRef,omitempty"`
+
+	// Interval at which the signature of an unchanged artifact is verified
+	// again, for example to detect revoked certificates. When omitted, the
+	// signature is only verified when the artifact or the spec changes.
+	// Only supported for OCIRepository, a HelmChart verifies the signature
+	// of its chart at every reconciliation.
+	// +kubebuilder:validation:Type=string
+	// +kubebuilder:validation:Pattern="^([0-9]+(\\.[0-9]+)?(ms|s|m|h))+$"
+	// +optional
+	Interval *metav1.Duration `json:"interval,omitempty"`
+
+	// Attestation specifies the in-toto attestation the OCI Artifact is
+	// required to have in addition to its signature. The attestation is
+	// verified with the same keys, or the same keyless method, as the
+	// signature. Only supported for OCIRepository.
+	// +optional
+	Attestation *OCIAttestationVerification `json:"attestation,omitempty"`
+
+	// Rekor specifies the Rekor transparency log used by the keyless
+	// verification, instead of the public Rekor instance.
+	// +optional
+	Rekor *OCIRekorVerification `json:"rekor,omitempty"`
+
+	// Fulcio specifies the Fulcio certificate authority trusted by the
+	// keyless verification, instead of the public Fulcio instance.
+	// +optional
+	Fulcio *OCIFulcioVerification `json:"fulcio,omitempty"`
+}
+
+// OCIRekorVerification specifies a Rekor transparency log.
+type OCIRekorVerification struct {
+	// URL of the Rekor instance.
+	// +kubebuilder:validation:Pattern="^https?://.*$"
+	// +required
+	URL string `json:"url"`
+}
+
+// OCIFulcioVerification specifies a Fulcio certificate authority.
+type OCIFulcioVerification struct {
+	// URL of the Fulcio instance, from which the root and intermediate
+	// certificates are fetched when no TrustedRootSecretRef is specified.
+	// +kubebuilder:validation:Pattern="^https://.*$"
+	// +optional
+	URL string `json:"url,omitempty"`
+
+	// TrustedRootSecretRef specifies the Kubernetes Secret containing the
+	// PEM encoded root and intermediate certificates of the Fulcio instance
+	// in the 'ca.crt' key.
+	// +optional
+	TrustedRootSecretRef *meta.LocalObjectReference `json:"trustedRootSecretRef,omitempty"`
+}
+
+// OCIAttestationVerification specifies the in-toto attestation an OCI
+// Artifact is required to have, and the policy it has to satisfy.
+type OCIAttestationVerification struct {
+	// PredicateType is the in-toto predicate type of the attestation.
+	// +kubebuilder:default:="https://slsa.dev/provenance/v0.2"
+	// +optional
+	PredicateType string `json:"predicateType,omitempty"`
+
+	// BuilderID is the ID of the builder which is required to have produced
+	// the OCI Artifact according to its SLSA provenance attestation. When
+	// omitted, an artifact produced by any builder is accepted.
+	// +optional
+	BuilderID string `json:"builderID,omitempty"`
+}
+
+// OCIRetryBackoff specifies an exponential backoff, starting at Base and
+// doubling on every consecutive failure until Max is reached.
+type OCIRetryBackoff struct {
+	// Base is the delay before the first retry.
+	// +kubebuilder:validation:Type=string
+	// +kubebuilder:validation:Pattern="^([0-9]+(\\.[0-9]+)?(ms|s|m|h))+$"
+	// +optional
+	Base *metav1.Duration `json:"base,omitempty"`
+
+	// Max is the maximum delay between retries.
+	// +kubebuilder:validation:Type=string
+	// +kubebuilder:validation:Pattern="^([0-9]+(\\.[0-9]+)?(ms|s|m|h))+$"
+	// +optional
+	Max *metav1.Duration `json:"max,omitempty"`
+}
+
+// OCIVerificationRetry records the consecutive failed verifications of a
+// revision, and the time at which its verification is retried.
+type OCIVerificationRetry struct {
+	// Revision is the revision of the artifact which failed the verification.
+	// +required
+	Revision string `json:"revision"`
+
+	// Failures is the number of consecutive failed verifications of the
+	// revision.
+	// +required
+	Failures int `json:"failures"`
+
+	// NextAttemptTime is the time of the next verification attempt.
+	// +required
+	NextAttemptTime metav1.Time `json:"nextAttemptTime"`
+}
+
+// OCIRepositoryTimeouts specifies the timeouts of the remote operations of an
+// OCIRepository reconciliation.
+type OCIRepositoryTimeouts struct {
+	// List is the timeout for resolving the artifact reference, including the
+	// listing of the tags of the repository.
+	// +kubebuilder:validation:Type=string
+	// +kubebuilder:validation:Pattern="^([0-9]+(\\.[0-9]+)?(ms|s|m))+$"
+	// +optional
+	List *metav1.Duration `json:"list,omitempty"`
+
+	// Pull is the timeout for pulling the artifact, including the download
+	// of its layers.
+	// +kubebuilder:validation:Type=string
+	// +kubebuilder:validation:Pattern="^([0-9]+(\\.[0-9]+)?(ms|s|m))+$"
+	// +optional
+	Pull *metav1.Duration `json:"pull,omitempty"`
+
+	// Verify is the timeout for verifying the signature of the artifact, and
+	// validating its SBOM.
+	// +kubebuilder:validation:Type=string
+	// +kubebuilder:validation:Pattern="^([0-9]+(\\.[0-9]+)?(ms|s|m))+$"
+	// +optional
+	Verify *metav1.Duration `json:"verify,omitempty"`
+}
+
+// OCISBOMRequirement specifies the Software Bill of Materials required for an
+// OCI Artifact.
+type OCISBOMRequirement struct {
+	// Format is the format of the SBOM JSON document.
+	// +kubebuilder:validation:Enum=spdx;cyclonedx
+	// +required
+	Format string `json:"format"`
+
+	// RequiredFields is a list of top level fields which must be present
+	// and not empty in the SBOM document.
+	// +optional
+	RequiredFields []string `json:"requiredFields,omitempty"`
 }
 
 // OCIRepositoryStatus defines the observed state of OCIRepository
@@ -195,6 +564,12 @@ type OCIRepositoryStatus struct {
 	// +optional
 	Conditions []metav1.Condition `json:"conditions,omitempty"`
 
+	// ConsecutiveFailures is the number of consecutive failed reconciliations
+	// with the FetchFailed condition set to True. It is reset when a
+	// reconciliation does not fail to fetch.
+	// +optional
+	ConsecutiveFailures int64 `json:"consecutiveFailures,omitempty"`
+
 	// URL is the download link for the artifact output of the last OCI Repository sync.
 	// +optional
 	URL string `json:"url,omitempty"`
@@ -203,6 +578,12 @@ type OCIRepositoryStatus struct {
 	// +optional
 	Artifact *Artifact `json:"artifact,omitempty"`
 
+	// History holds the previous Artifacts retained in the Storage according
+	// to the HistoryLimit, ordered by their LastUpdateTime, most recent
+	// first.
+	// +optional
+	History []Artifact `json:"history,omitempty"`
+
 	// ContentConfigChecksum is a checksum of all the configurations related to
 	// the content of the source artifact:
 	//  - .spec.ignore
@@ -222,11 +603,72 @@ type OCIRepositoryStatus struct {
 	// +optional
 	ObservedIgnore *string `json:"observedIgnore,omitempty"`
 
+	// ObservedInclude is the observed inclusion patterns used for
+	// constructing the source artifact.
+	// +optional
+	ObservedInclude *string `json:"observedInclude,omitempty"`
+
 	// ObservedLayerSelector is the observed layer selector used for constructing
 	// the source artifact.
 	// +optional
 	ObservedLayerSelector *OCILayerSelector `json:"observedLayerSelector,omitempty"`
 
+	// ObservedExpectedPaths is the observed list of allowed file path patterns
+	// used for constructing the source artifact.
+	// +optional
+	ObservedExpectedPaths []string `json:"observedExpectedPaths,omitempty"`
+
+	// ObservedMetadataKeys is the observed list of manifest annotation keys
+	// used for constructing the source artifact.
+	// +optional
+	ObservedMetadataKeys []string `json:"observedMetadataKeys,omitempty"`
+
+	// ObservedArtifactCompression is the observed compression of the tarball
+	// used for constructing the source artifact.
+	// +optional
+	ObservedArtifactCompression string `json:"observedArtifactCompression,omitempty"`
+
+	// ObservedArtifactModTime is the observed modification time strategy
+	// used for constructing the source artifact.
+	// +optional
+	ObservedArtifactModTime string `json:"observedArtifactModTime,omitempty"`
+
+	// ObservedPlatform is the platform of the manifest resolved from the
+	// image index the OCIRepository refers to. It is empty when the
+	// reference points to a single manifest.
+	// +optional
+	ObservedPlatform *OCIPlatform `json:"observedPlatform,omitempty"`
+
+	// ObservedEndpoint is the registry host which served the artifact the
+	// current Artifact was produced from.
+	// +optional
+	ObservedEndpoint string `json:"observedEndpoint,omitempty"`
+
+	// LastAttemptTime is the time of the last attempt to fetch the artifact
+	// from the registry, whether it succeeded or not.
+	// +optional
+	LastAttemptTime *metav1.Time `json:"lastAttemptTime,omitempty"`
+
+	// LastVerificationTime is the time of the last successful verification
+	// of the signature of the artifact.
+	// +optional
+	LastVerificationTime *metav1.Time `json:"lastVerificationTime,omitempty"`
+
+	// VerificationRetry holds the state of the exponential backoff retrying
+	// a failed verification of the artifact.
+	// +optional
+	VerificationRetry *OCIVerificationRetry `json:"verificationRetry,omitempty"`
+
+	// SBOMDigest is the digest of the manifest of the Software Bill of
+	// Materials validated for the artifact.
+	// +optional
+	SBOMDigest string `json:"sbomDigest,omitempty"`
+
+	// MirroredDigest is the digest of the last artifact copied to the
+	// mirror registry of the spec.
+	// +optional
+	MirroredDigest string `json:"mirroredDigest,omitempty"`
+
 	meta.ReconcileRequestStatus `json:",inline"`
 }
 
@@ -234,8 +676,41 @@ const (
 	// OCIPullFailedReason signals that a pull operation failed.
 	OCIPullFailedReason string = "OCIArtifactPullFailed"
 
+	// OCINotFoundReason signals that a pull operation failed because the
+	// repository or artifact does not exist in the registry.
+	OCINotFoundReason string = "OCIArtifactNotFound"
+
+	// OCINetworkErrorReason signals that a pull operation failed because the
+	// registry could not be reached.
+	OCINetworkErrorReason string = "OCIRegistryNetworkError"
+
 	// OCILayerOperationFailedReason signals that an OCI layer operation failed.
 	OCILayerOperationFailedReason string = "OCIArtifactLayerOperationFailed"
+
+	// OCIUnexpectedPathsReason signals that the content of an OCI artifact
+	// contains files which are not allowed by the expected paths.
+	OCIUnexpectedPathsReason string = "OCIArtifactUnexpectedPaths"
+
+	// OCISBOMValidationFailedReason signals that the required Software Bill
+	// of Materials of an OCI artifact is absent or invalid.
+	OCISBOMValidationFailedReason string = "OCIArtifactSBOMValidationFailed"
+
+	// OCIConfigMediaTypeMismatchReason signals that the media type of the
+	// config of an OCI artifact does not match the layer selector.
+	OCIConfigMediaTypeMismatchReason string = "OCIArtifactConfigMediaTypeMismatch"
+
+	// OCITagListTruncatedReason signals that the list of tags of an OCI
+	// repository may have been truncated by the registry.
+	OCITagListTruncatedReason string = "OCIArtifactTagListTruncated"
+
+	// OCIMutableReferenceReason signals that the reference of an OCI
+	// repository resolves to a mutable tag while an immutable reference is
+	// required.
+	OCIMutableReferenceReason string = "OCIArtifactMutableReference"
+
+	// OCIDigestMismatchReason signals that the reference of an OCI repository
+	// resolved to a digest which differs from the expected digest.
+	OCIDigestMismatchReason string = "OCIArtifactDigestMismatch"
 )
 
 // GetConditions returns the status conditions of the object.
@@ -269,6 +744,86 @@ func (in *OCIRepository) GetLayerMediaType() string {
 	return in.Spec.LayerSelector.MediaType
 }
 
+// GetConfigMediaType returns the config media type layer selector if found
+// in spec.
+func (in *OCIRepository) GetConfigMediaType() string {
+	if in.Spec.LayerSelector == nil {
+		return ""
+	}
+
+	return in.Spec.LayerSelector.ConfigMediaType
+}
+
+// GetRejectContainerImages returns if the layer selector in spec rejects
+// container images.
+func (in *OCIRepository) GetRejectContainerImages() bool {
+	if in.Spec.LayerSelector == nil {
+		return false
+	}
+
+	return in.Spec.LayerSelector.RejectContainerImages
+}
+
+// GetListTimeout returns the timeout for resolving the artifact reference
+// (defaults to the timeout).
+func (in *OCIRepository) GetListTimeout() time.Duration {
+	if in.Spec.Timeouts == nil || in.Spec.Timeouts.List == nil {
+		return in.Spec.Timeout.Duration
+	}
+
+	return in.Spec.Timeouts.List.Duration
+}
+
+// GetPullTimeout returns the timeout for pulling the artifact (defaults to the
+// timeout).
+func (in *OCIRepository) GetPullTimeout() time.Duration {
+	if in.Spec.Timeouts == nil || in.Spec.Timeouts.Pull == nil {
+		return in.Spec.Timeout.Duration
+	}
+
+	return in.Spec.Timeouts.Pull.Duration
+}
+
+// GetVerifyTimeout returns the timeout for verifying the artifact (defaults to
+// the timeout).
+func (in *OCIRepository) GetVerifyTimeout() time.Duration {
+	if in.Spec.Timeouts == nil || in.Spec.Timeouts.Verify == nil {
+		return in.Spec.Timeout.Duration
+	}
+
+	return in.Spec.Timeouts.Verify.Duration
+}
+
+// GetMaxSize returns the maximum size in bytes of the OCI artifact layer,
+// or zero if no limit is set.
+func (in *OCIRepository) GetMaxSize() int64 {
+	if in.Spec.MaxSize == nil {
+		return 0
+	}
+
+	return in.Spec.MaxSize.Value()
+}
+
+// GetArtifactModTime returns the artifact modification time strategy
+// (defaults to stored).
+func (in *OCIRepository) GetArtifactModTime() string {
+	if in.Spec.ArtifactModTime == "" {
+		return OCIArtifactModTimeStored
+	}
+
+	return in.Spec.ArtifactModTime
+}
+
+// GetArtifactCompression returns the compression of the artifact tarball
+// (defaults to gzip).
+func (in *OCIRepository) GetArtifactCompression() string {
+	if in.Spec.ArtifactCompression == "" {
+		return OCIArtifactCompressionGzip
+	}
+
+	return in.Spec.ArtifactCompression
+}
+
 // GetLayerOperation returns the layer selector operation (defaults to extract).
 func (in *OCIRepository) GetLayerOperation() string {
 	if in.Spec.LayerSelector == nil || in.Spec.LayerSelector.Operation == "" {
@@ -278,6 +833,16 @@ func (in *OCIRepository) GetLayerOperation() string {
 	return in.Spec.LayerSelector.Operation
 }
 
+// GetPredicateType returns the in-toto predicate type of the attestation
+// (defaults to SLSA provenance).
+func (in *OCIAttestationVerification) GetPredicateType() string {
+	if in.PredicateType == "" {
+		return OCIAttestationSLSAProvenance
+	}
+
+	return in.PredicateType
+}
+
 // +genclient
 // +genclient:Namespaced
 // +kubebuilder:storageversion
@@ -287,6 +852,7 @@ func (in *OCIRepository) GetLayerOperation() string {
 // +kubebuilder:printcolumn:name="URL",type=string,JSONPath=`.spec.url`
 // +kubebuilder:printcolumn:name="Ready",type="string",JSONPath=".status.conditions[?(@.type==\"Ready\")].status",description=""
 // +kubebuilder:printcolumn:name="Status",type="string",JSONPath=".status.conditions[?(@.type==\"Ready\")].message",description=""
+// +kubebuilder:printcolumn:name="Size",type=integer,JSONPath=`.status.artifact.size`,priority=1
 // +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp",description=""
 
 // OCIRepository is the Schema for the ocirepositories API
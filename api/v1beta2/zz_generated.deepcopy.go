@@ -24,6 +24,7 @@ package v1beta2
 import (
 	"github.com/fluxcd/pkg/apis/acl"
 	"github.com/fluxcd/pkg/apis/meta"
+	"k8s.io/apimachinery/pkg/api/resource"
 	"k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 )
@@ -37,6 +38,11 @@ func (in *Artifact) DeepCopyInto(out *Artifact) {
 		*out = new(int64)
 		**out = **in
 	}
+	if in.FileCount != nil {
+		in, out := &in.FileCount, &out.FileCount
+		*out = new(int64)
+		**out = **in
+	}
 	if in.Metadata != nil {
 		in, out := &in.Metadata, &out.Metadata
 		*out = make(map[string]string, len(*in))
@@ -417,6 +423,21 @@ func (in *HelmChart) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HelmChartDependency) DeepCopyInto(out *HelmChartDependency) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HelmChartDependency.
+func (in *HelmChartDependency) DeepCopy() *HelmChartDependency {
+	if in == nil {
+		return nil
+	}
+	out := new(HelmChartDependency)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *HelmChartList) DeepCopyInto(out *HelmChartList) {
 	*out = *in
@@ -459,6 +480,16 @@ func (in *HelmChartSpec) DeepCopyInto(out *HelmChartSpec) {
 		*out = make([]string, len(*in))
 		copy(*out, *in)
 	}
+	if in.ValuesFrom != nil {
+		in, out := &in.ValuesFrom, &out.ValuesFrom
+		*out = make([]ValuesReference, len(*in))
+		copy(*out, *in)
+	}
+	if in.ValuesInline != nil {
+		in, out := &in.ValuesInline, &out.ValuesInline
+		*out = new(runtime.RawExtension)
+		(*in).DeepCopyInto(*out)
+	}
 	if in.AccessFrom != nil {
 		in, out := &in.AccessFrom, &out.AccessFrom
 		*out = new(acl.AccessFrom)
@@ -484,6 +515,23 @@ func (in *HelmChartSpec) DeepCopy() *HelmChartSpec {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *HelmChartStatus) DeepCopyInto(out *HelmChartStatus) {
 	*out = *in
+	if in.ResolvedDependencies != nil {
+		in, out := &in.ResolvedDependencies, &out.ResolvedDependencies
+		*out = make([]HelmChartDependency, len(*in))
+		copy(*out, *in)
+	}
+	if in.ObservedDependencyRevisions != nil {
+		in, out := &in.ObservedDependencyRevisions, &out.ObservedDependencyRevisions
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.ObservedValuesFiles != nil {
+		in, out := &in.ObservedValuesFiles, &out.ObservedValuesFiles
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 	if in.Conditions != nil {
 		in, out := &in.Conditions, &out.Conditions
 		*out = make([]v1.Condition, len(*in))
@@ -496,6 +544,13 @@ func (in *HelmChartStatus) DeepCopyInto(out *HelmChartStatus) {
 		*out = new(Artifact)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.History != nil {
+		in, out := &in.History, &out.History
+		*out = make([]Artifact, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 	out.ReconcileRequestStatus = in.ReconcileRequestStatus
 }
 
@@ -576,6 +631,11 @@ func (in *HelmRepositorySpec) DeepCopyInto(out *HelmRepositorySpec) {
 		*out = new(meta.LocalObjectReference)
 		**out = **in
 	}
+	if in.CertSecretRef != nil {
+		in, out := &in.CertSecretRef, &out.CertSecretRef
+		*out = new(meta.LocalObjectReference)
+		**out = **in
+	}
 	out.Interval = in.Interval
 	if in.Timeout != nil {
 		in, out := &in.Timeout, &out.Timeout
@@ -642,6 +702,41 @@ func (in *LocalHelmChartSourceReference) DeepCopy() *LocalHelmChartSourceReferen
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OCIAttestationVerification) DeepCopyInto(out *OCIAttestationVerification) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OCIAttestationVerification.
+func (in *OCIAttestationVerification) DeepCopy() *OCIAttestationVerification {
+	if in == nil {
+		return nil
+	}
+	out := new(OCIAttestationVerification)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OCIFulcioVerification) DeepCopyInto(out *OCIFulcioVerification) {
+	*out = *in
+	if in.TrustedRootSecretRef != nil {
+		in, out := &in.TrustedRootSecretRef, &out.TrustedRootSecretRef
+		*out = new(meta.LocalObjectReference)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OCIFulcioVerification.
+func (in *OCIFulcioVerification) DeepCopy() *OCIFulcioVerification {
+	if in == nil {
+		return nil
+	}
+	out := new(OCIFulcioVerification)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *OCILayerSelector) DeepCopyInto(out *OCILayerSelector) {
 	*out = *in
@@ -657,6 +752,36 @@ func (in *OCILayerSelector) DeepCopy() *OCILayerSelector {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OCIPlatform) DeepCopyInto(out *OCIPlatform) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OCIPlatform.
+func (in *OCIPlatform) DeepCopy() *OCIPlatform {
+	if in == nil {
+		return nil
+	}
+	out := new(OCIPlatform)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OCIRekorVerification) DeepCopyInto(out *OCIRekorVerification) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OCIRekorVerification.
+func (in *OCIRekorVerification) DeepCopy() *OCIRekorVerification {
+	if in == nil {
+		return nil
+	}
+	out := new(OCIRekorVerification)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *OCIRepository) DeepCopyInto(out *OCIRepository) {
 	*out = *in
@@ -716,6 +841,26 @@ func (in *OCIRepositoryList) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OCIRepositoryMirror) DeepCopyInto(out *OCIRepositoryMirror) {
+	*out = *in
+	if in.SecretRef != nil {
+		in, out := &in.SecretRef, &out.SecretRef
+		*out = new(meta.LocalObjectReference)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OCIRepositoryMirror.
+func (in *OCIRepositoryMirror) DeepCopy() *OCIRepositoryMirror {
+	if in == nil {
+		return nil
+	}
+	out := new(OCIRepositoryMirror)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *OCIRepositoryRef) DeepCopyInto(out *OCIRepositoryRef) {
 	*out = *in
@@ -744,6 +889,11 @@ func (in *OCIRepositorySpec) DeepCopyInto(out *OCIRepositorySpec) {
 		*out = new(OCILayerSelector)
 		**out = **in
 	}
+	if in.Platform != nil {
+		in, out := &in.Platform, &out.Platform
+		*out = new(OCIPlatform)
+		**out = **in
+	}
 	if in.SecretRef != nil {
 		in, out := &in.SecretRef, &out.SecretRef
 		*out = new(meta.LocalObjectReference)
@@ -754,6 +904,16 @@ func (in *OCIRepositorySpec) DeepCopyInto(out *OCIRepositorySpec) {
 		*out = new(OCIRepositoryVerification)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.VerificationRetry != nil {
+		in, out := &in.VerificationRetry, &out.VerificationRetry
+		*out = new(OCIRetryBackoff)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.RequireSBOM != nil {
+		in, out := &in.RequireSBOM, &out.RequireSBOM
+		*out = new(OCISBOMRequirement)
+		(*in).DeepCopyInto(*out)
+	}
 	if in.CertSecretRef != nil {
 		in, out := &in.CertSecretRef, &out.CertSecretRef
 		*out = new(meta.LocalObjectReference)
@@ -765,11 +925,41 @@ func (in *OCIRepositorySpec) DeepCopyInto(out *OCIRepositorySpec) {
 		*out = new(v1.Duration)
 		**out = **in
 	}
+	if in.Timeouts != nil {
+		in, out := &in.Timeouts, &out.Timeouts
+		*out = new(OCIRepositoryTimeouts)
+		(*in).DeepCopyInto(*out)
+	}
 	if in.Ignore != nil {
 		in, out := &in.Ignore, &out.Ignore
 		*out = new(string)
 		**out = **in
 	}
+	if in.Include != nil {
+		in, out := &in.Include, &out.Include
+		*out = new(string)
+		**out = **in
+	}
+	if in.ExpectedPaths != nil {
+		in, out := &in.ExpectedPaths, &out.ExpectedPaths
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.MetadataKeys != nil {
+		in, out := &in.MetadataKeys, &out.MetadataKeys
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.MaxSize != nil {
+		in, out := &in.MaxSize, &out.MaxSize
+		x := (*in).DeepCopy()
+		*out = &x
+	}
+	if in.Mirror != nil {
+		in, out := &in.Mirror, &out.Mirror
+		*out = new(OCIRepositoryMirror)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OCIRepositorySpec.
@@ -797,16 +987,56 @@ func (in *OCIRepositoryStatus) DeepCopyInto(out *OCIRepositoryStatus) {
 		*out = new(Artifact)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.History != nil {
+		in, out := &in.History, &out.History
+		*out = make([]Artifact, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 	if in.ObservedIgnore != nil {
 		in, out := &in.ObservedIgnore, &out.ObservedIgnore
 		*out = new(string)
 		**out = **in
 	}
+	if in.ObservedInclude != nil {
+		in, out := &in.ObservedInclude, &out.ObservedInclude
+		*out = new(string)
+		**out = **in
+	}
 	if in.ObservedLayerSelector != nil {
 		in, out := &in.ObservedLayerSelector, &out.ObservedLayerSelector
 		*out = new(OCILayerSelector)
 		**out = **in
 	}
+	if in.ObservedExpectedPaths != nil {
+		in, out := &in.ObservedExpectedPaths, &out.ObservedExpectedPaths
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.ObservedMetadataKeys != nil {
+		in, out := &in.ObservedMetadataKeys, &out.ObservedMetadataKeys
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.ObservedPlatform != nil {
+		in, out := &in.ObservedPlatform, &out.ObservedPlatform
+		*out = new(OCIPlatform)
+		**out = **in
+	}
+	if in.LastAttemptTime != nil {
+		in, out := &in.LastAttemptTime, &out.LastAttemptTime
+		*out = (*in).DeepCopy()
+	}
+	if in.LastVerificationTime != nil {
+		in, out := &in.LastVerificationTime, &out.LastVerificationTime
+		*out = (*in).DeepCopy()
+	}
+	if in.VerificationRetry != nil {
+		in, out := &in.VerificationRetry, &out.VerificationRetry
+		*out = new(OCIVerificationRetry)
+		(*in).DeepCopyInto(*out)
+	}
 	out.ReconcileRequestStatus = in.ReconcileRequestStatus
 }
 
@@ -820,6 +1050,36 @@ func (in *OCIRepositoryStatus) DeepCopy() *OCIRepositoryStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OCIRepositoryTimeouts) DeepCopyInto(out *OCIRepositoryTimeouts) {
+	*out = *in
+	if in.List != nil {
+		in, out := &in.List, &out.List
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	if in.Pull != nil {
+		in, out := &in.Pull, &out.Pull
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	if in.Verify != nil {
+		in, out := &in.Verify, &out.Verify
+		*out = new(v1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OCIRepositoryTimeouts.
+func (in *OCIRepositoryTimeouts) DeepCopy() *OCIRepositoryTimeouts {
+	if in == nil {
+		return nil
+	}
+	out := new(OCIRepositoryTimeouts)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *OCIRepositoryVerification) DeepCopyInto(out *OCIRepositoryVerification) {
 	*out = *in
@@ -828,6 +1088,26 @@ func (in *OCIRepositoryVerification) DeepCopyInto(out *OCIRepositoryVerification
 		*out = new(meta.LocalObjectReference)
 		**out = **in
 	}
+	if in.Interval != nil {
+		in, out := &in.Interval, &out.Interval
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+	if in.Attestation != nil {
+		in, out := &in.Attestation, &out.Attestation
+		*out = new(OCIAttestationVerification)
+		**out = **in
+	}
+	if in.Rekor != nil {
+		in, out := &in.Rekor, &out.Rekor
+		*out = new(OCIRekorVerification)
+		**out = **in
+	}
+	if in.Fulcio != nil {
+		in, out := &in.Fulcio, &out.Fulcio
+		*out = new(OCIFulcioVerification)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OCIRepositoryVerification.
@@ -839,3 +1119,79 @@ func (in *OCIRepositoryVerification) DeepCopy() *OCIRepositoryVerification {
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OCIRetryBackoff) DeepCopyInto(out *OCIRetryBackoff) {
+	*out = *in
+	if in.Base != nil {
+		in, out := &in.Base, &out.Base
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	if in.Max != nil {
+		in, out := &in.Max, &out.Max
+		*out = new(v1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OCIRetryBackoff.
+func (in *OCIRetryBackoff) DeepCopy() *OCIRetryBackoff {
+	if in == nil {
+		return nil
+	}
+	out := new(OCIRetryBackoff)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OCISBOMRequirement) DeepCopyInto(out *OCISBOMRequirement) {
+	*out = *in
+	if in.RequiredFields != nil {
+		in, out := &in.RequiredFields, &out.RequiredFields
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OCISBOMRequirement.
+func (in *OCISBOMRequirement) DeepCopy() *OCISBOMRequirement {
+	if in == nil {
+		return nil
+	}
+	out := new(OCISBOMRequirement)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OCIVerificationRetry) DeepCopyInto(out *OCIVerificationRetry) {
+	*out = *in
+	in.NextAttemptTime.DeepCopyInto(&out.NextAttemptTime)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OCIVerificationRetry.
+func (in *OCIVerificationRetry) DeepCopy() *OCIVerificationRetry {
+	if in == nil {
+		return nil
+	}
+	out := new(OCIVerificationRetry)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ValuesReference) DeepCopyInto(out *ValuesReference) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ValuesReference.
+func (in *ValuesReference) DeepCopy() *ValuesReference {
+	if in == nil {
+		return nil
+	}
+	out := new(ValuesReference)
+	in.DeepCopyInto(out)
+	return out
+}
@@ -16,7 +16,12 @@ limitations under the License.
 
 package controllers
 
-import sourcev1 "github.com/fluxcd/source-controller/api/v1beta2"
+import (
+	"sort"
+	"strings"
+
+	sourcev1 "github.com/fluxcd/source-controller/api/v1beta2"
+)
 
 type artifactSet []*sourcev1.Artifact
 
@@ -59,3 +64,69 @@ OUTER:
 
 	return false
 }
+
+// checksumAlgorithm returns the algorithm of the given artifact checksum,
+// which is ChecksumSHA256 for checksums without an algorithm prefix.
+func checksumAlgorithm(checksum string) string {
+	if algorithm, _, ok := strings.Cut(checksum, ":"); ok {
+		return algorithm
+	}
+	return ChecksumSHA256
+}
+
+// artifactChanged returns true if the updated artifact differs from the
+// current one. Checksums of different algorithms can not be compared, which
+// is the case when the checksum algorithm of the Storage is changed, and the
+// revisions are compared instead so that re-archiving the same revision with
+// another algorithm is not reported as a new artifact.
+func artifactChanged(current, updated *sourcev1.Artifact) bool {
+	if current == nil || updated == nil {
+		return current != updated
+	}
+	if checksumAlgorithm(current.Checksum) != checksumAlgorithm(updated.Checksum) {
+		return !current.HasRevision(updated.Revision)
+	}
+	return current.Checksum != updated.Checksum
+}
+
+// latestArtifacts returns the limit most recent artifacts by their
+// LastUpdateTime, the most recent first.
+func latestArtifacts(artifacts []sourcev1.Artifact, limit int) []sourcev1.Artifact {
+	if limit <= 0 || len(artifacts) == 0 {
+		return nil
+	}
+	sorted := make([]sourcev1.Artifact, len(artifacts))
+	copy(sorted, artifacts)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].LastUpdateTime.After(sorted[j].LastUpdateTime.Time)
+	})
+	if len(sorted) > limit {
+		sorted = sorted[:limit]
+	}
+	return sorted
+}
+
+// artifactHistory returns the history of previous artifacts after the
+// previous artifact has been superseded by the current one. The history does
+// not contain the current artifact, nor multiple artifacts with the same
+// path, and is limited to the limit most recent artifacts by their
+// LastUpdateTime.
+func artifactHistory(history []sourcev1.Artifact, previous, current *sourcev1.Artifact, limit int) []sourcev1.Artifact {
+	if limit <= 0 {
+		return nil
+	}
+	var artifacts []sourcev1.Artifact
+	if previous != nil && (current == nil || previous.Path != current.Path) {
+		artifacts = append(artifacts, *previous.DeepCopy())
+	}
+	for _, a := range history {
+		if current != nil && a.Path == current.Path {
+			continue
+		}
+		if previous != nil && a.Path == previous.Path {
+			continue
+		}
+		artifacts = append(artifacts, a)
+	}
+	return latestArtifacts(artifacts, limit)
+}
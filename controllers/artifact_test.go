@@ -18,6 +18,12 @@ package controllers
 
 import (
 	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	sourcev1 "github.com/fluxcd/source-controller/api/v1beta2"
 )
 
 func Test_artifactSet_Diff(t *testing.T) {
@@ -122,3 +128,122 @@ func Test_artifactSet_Diff(t *testing.T) {
 		})
 	}
 }
+
+func Test_artifactChanged(t *testing.T) {
+	sha512Checksum := ChecksumSHA512 + ":ee26b0dd4af7e749aa1a8ee3c10ae9923f618980772e473f8819a5d4940e0db2"
+
+	tests := []struct {
+		name     string
+		current  *sourcev1.Artifact
+		updated  *sourcev1.Artifact
+		expected bool
+	}{
+		{
+			name:     "no current artifact",
+			updated:  &sourcev1.Artifact{Revision: "foo", Checksum: "abc"},
+			expected: true,
+		},
+		{
+			name:     "same checksum",
+			current:  &sourcev1.Artifact{Revision: "foo", Checksum: "abc"},
+			updated:  &sourcev1.Artifact{Revision: "foo", Checksum: "abc"},
+			expected: false,
+		},
+		{
+			name:     "different checksum",
+			current:  &sourcev1.Artifact{Revision: "foo", Checksum: "abc"},
+			updated:  &sourcev1.Artifact{Revision: "foo", Checksum: "def"},
+			expected: true,
+		},
+		{
+			name:     "different algorithm, same revision",
+			current:  &sourcev1.Artifact{Revision: "foo", Checksum: "abc"},
+			updated:  &sourcev1.Artifact{Revision: "foo", Checksum: sha512Checksum},
+			expected: false,
+		},
+		{
+			name:     "different algorithm, different revision",
+			current:  &sourcev1.Artifact{Revision: "foo", Checksum: sha512Checksum},
+			updated:  &sourcev1.Artifact{Revision: "bar", Checksum: "abc"},
+			expected: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := artifactChanged(tt.current, tt.updated)
+			if result != tt.expected {
+				t.Errorf("artifactChanged() result = %v, wantResult %v", result, tt.expected)
+			}
+		})
+	}
+}
+
+func Test_artifactHistory(t *testing.T) {
+	artifactAt := func(path string, minutes int) sourcev1.Artifact {
+		return sourcev1.Artifact{
+			Path:           path,
+			LastUpdateTime: metav1.NewTime(time.Date(2022, 10, 1, 12, minutes, 0, 0, time.UTC)),
+		}
+	}
+	artifactPtr := func(a sourcev1.Artifact) *sourcev1.Artifact {
+		return &a
+	}
+
+	tests := []struct {
+		name      string
+		history   []sourcev1.Artifact
+		previous  *sourcev1.Artifact
+		current   *sourcev1.Artifact
+		limit     int
+		wantPaths []string
+	}{
+		{
+			name:     "limit of 0 results in no history",
+			history:  []sourcev1.Artifact{artifactAt("a", 1)},
+			previous: &sourcev1.Artifact{Path: "b"},
+			current:  &sourcev1.Artifact{Path: "c"},
+		},
+		{
+			name:      "previous artifact is added",
+			history:   []sourcev1.Artifact{artifactAt("a", 1)},
+			previous:  artifactPtr(artifactAt("b", 2)),
+			current:   &sourcev1.Artifact{Path: "c"},
+			limit:     5,
+			wantPaths: []string{"b", "a"},
+		},
+		{
+			name:      "ordered by last update time and limited",
+			history:   []sourcev1.Artifact{artifactAt("a", 3), artifactAt("b", 1), artifactAt("c", 2)},
+			current:   &sourcev1.Artifact{Path: "d"},
+			limit:     2,
+			wantPaths: []string{"a", "c"},
+		},
+		{
+			name:      "previous artifact with the path of the current is not added",
+			history:   []sourcev1.Artifact{artifactAt("a", 1)},
+			previous:  artifactPtr(artifactAt("b", 2)),
+			current:   &sourcev1.Artifact{Path: "b"},
+			limit:     5,
+			wantPaths: []string{"a"},
+		},
+		{
+			name:      "previous artifact replaces an artifact with the same path",
+			history:   []sourcev1.Artifact{artifactAt("a", 3), artifactAt("b", 1)},
+			previous:  artifactPtr(artifactAt("b", 4)),
+			current:   &sourcev1.Artifact{Path: "c"},
+			limit:     5,
+			wantPaths: []string{"b", "a"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			var paths []string
+			for _, a := range artifactHistory(tt.history, tt.previous, tt.current, tt.limit) {
+				paths = append(paths, a.Path)
+			}
+			g.Expect(paths).To(Equal(tt.wantPaths))
+		})
+	}
+}
@@ -121,12 +121,20 @@ type BucketReconciler struct {
 	Storage        *Storage
 	ControllerName string
 
+	// FailureThreshold is the number of consecutive reconciliations failing
+	// to fetch, after which the object is marked as stalled. Zero disables it.
+	FailureThreshold int64
+
 	patchOptions []patch.Option
 }
 
 type BucketReconcilerOptions struct {
 	MaxConcurrentReconciles int
 	RateLimiter             ratelimiter.RateLimiter
+
+	// MaxConcurrentReconcilesPerNamespace limits the number of concurrent
+	// reconciles of the objects in a namespace. Zero means no limit.
+	MaxConcurrentReconcilesPerNamespace int
 }
 
 // BucketProvider is an interface for fetching objects from a storage provider
@@ -247,7 +255,7 @@ func (r *BucketReconciler) SetupWithManagerAndOptions(mgr ctrl.Manager, opts Buc
 			RateLimiter:             opts.RateLimiter,
 			RecoverPanic:            true,
 		}).
-		Complete(r)
+		Complete(withNamespaceLimit(r, opts.MaxConcurrentReconcilesPerNamespace))
 }
 
 func (r *BucketReconciler) Reconcile(ctx context.Context, req ctrl.Request) (result ctrl.Result, retErr error) {
@@ -284,6 +292,7 @@ func (r *BucketReconciler) Reconcile(ctx context.Context, req ctrl.Request) (res
 			),
 			summarize.WithResultBuilder(sreconcile.AlwaysRequeueResultBuilder{RequeueAfter: obj.GetRequeueAfter()}),
 			summarize.WithPatchFieldOwner(r.ControllerName),
+			summarize.WithFailureThreshold(r.FailureThreshold, sourcev1.FetchFailedCondition),
 		}
 		result, retErr = summarizeHelper.SummarizeAndPatch(ctx, obj, summarizeOpts...)
 
@@ -405,15 +414,10 @@ func (r *BucketReconciler) notify(ctx context.Context, oldObj, newObj *sourcev1.
 			fmt.Sprintf("%s/%s", sourcev1.GroupVersion.Group, eventv1.MetaChecksumKey): newObj.Status.Artifact.Checksum,
 		}
 
-		var oldChecksum string
-		if oldObj.GetArtifact() != nil {
-			oldChecksum = oldObj.GetArtifact().Checksum
-		}
-
 		message := fmt.Sprintf("stored artifact with %d fetched files from '%s' bucket", index.Len(), newObj.Spec.BucketName)
 
 		// Notify on new artifact and failure recovery.
-		if oldChecksum != newObj.GetArtifact().Checksum {
+		if artifactChanged(oldObj.GetArtifact(), newObj.GetArtifact()) {
 			r.AnnotatedEventf(newObj, annotations, corev1.EventTypeNormal,
 				"NewArtifact", message)
 			ctrl.LoggerFrom(ctx).Info(message)
@@ -641,7 +645,7 @@ func (r *BucketReconciler) reconcileArtifact(ctx context.Context, sp *patch.Seri
 		conditions.MarkTrue(obj, sourcev1.StorageOperationFailedCondition, e.Reason, e.Err.Error())
 		return sreconcile.ResultEmpty, e
 	}
-	unlock, err := r.Storage.Lock(artifact)
+	unlock, err := r.Storage.LockWithContext(ctx, artifact)
 	if err != nil {
 		return sreconcile.ResultEmpty, &serror.Event{
 			Err:    fmt.Errorf("failed to acquire lock for artifact: %w", err),
@@ -650,16 +654,30 @@ func (r *BucketReconciler) reconcileArtifact(ctx context.Context, sp *patch.Seri
 	}
 	defer unlock()
 
-	// Archive directory to storage
-	if err := r.Storage.Archive(&artifact, dir, nil); err != nil {
+	// Reuse the current artifact if the content of the new revision is
+	// identical, to not cause the consumers to reconcile the same content
+	reused, err := r.Storage.ReuseArtifact(&artifact, obj.GetArtifact(), dir, nil)
+	if err != nil {
 		e := &serror.Event{
-			Err:    fmt.Errorf("unable to archive artifact to storage: %s", err),
+			Err:    fmt.Errorf("failed to compute the checksum of the artifact content: %w", err),
 			Reason: sourcev1.ArchiveOperationFailedReason,
 		}
 		conditions.MarkTrue(obj, sourcev1.StorageOperationFailedCondition, e.Reason, e.Err.Error())
 		return sreconcile.ResultEmpty, e
 	}
 
+	// Archive directory to storage
+	if !reused {
+		if err := r.Storage.Archive(&artifact, dir, nil); err != nil {
+			e := &serror.Event{
+				Err:    fmt.Errorf("unable to archive artifact to storage: %s", err),
+				Reason: sourcev1.ArchiveOperationFailedReason,
+			}
+			conditions.MarkTrue(obj, sourcev1.StorageOperationFailedCondition, e.Reason, e.Err.Error())
+			return sreconcile.ResultEmpty, e
+		}
+	}
+
 	// Record it on the object
 	obj.Status.Artifact = artifact.DeepCopy()
 	obj.Status.ObservedIgnore = obj.Spec.Ignore
@@ -126,6 +126,10 @@ type GitRepositoryReconciler struct {
 	Storage        *Storage
 	ControllerName string
 
+	// FailureThreshold is the number of consecutive reconciliations failing
+	// to fetch, after which the object is marked as stalled. Zero disables it.
+	FailureThreshold int64
+
 	requeueDependency time.Duration
 	features          map[string]bool
 
@@ -136,6 +140,10 @@ type GitRepositoryReconcilerOptions struct {
 	MaxConcurrentReconciles   int
 	DependencyRequeueInterval time.Duration
 	RateLimiter               ratelimiter.RateLimiter
+
+	// MaxConcurrentReconcilesPerNamespace limits the number of concurrent
+	// reconciles of the objects in a namespace. Zero means no limit.
+	MaxConcurrentReconcilesPerNamespace int
 }
 
 // gitRepositoryReconcileFunc is the function type for all the
@@ -164,7 +172,7 @@ func (r *GitRepositoryReconciler) SetupWithManagerAndOptions(mgr ctrl.Manager, o
 			RateLimiter:             opts.RateLimiter,
 			RecoverPanic:            true,
 		}).
-		Complete(r)
+		Complete(withNamespaceLimit(r, opts.MaxConcurrentReconcilesPerNamespace))
 }
 
 func (r *GitRepositoryReconciler) Reconcile(ctx context.Context, req ctrl.Request) (result ctrl.Result, retErr error) {
@@ -202,6 +210,7 @@ func (r *GitRepositoryReconciler) Reconcile(ctx context.Context, req ctrl.Reques
 			),
 			summarize.WithResultBuilder(sreconcile.AlwaysRequeueResultBuilder{RequeueAfter: obj.GetRequeueAfter()}),
 			summarize.WithPatchFieldOwner(r.ControllerName),
+			summarize.WithFailureThreshold(r.FailureThreshold, sourcev1.FetchFailedCondition),
 		}
 		result, retErr = summarizeHelper.SummarizeAndPatch(ctx, obj, summarizeOpts...)
 
@@ -327,11 +336,6 @@ func (r *GitRepositoryReconciler) notify(ctx context.Context, oldObj, newObj *so
 			fmt.Sprintf("%s/%s", sourcev1.GroupVersion.Group, eventv1.MetaChecksumKey): newObj.Status.Artifact.Checksum,
 		}
 
-		var oldChecksum string
-		if oldObj.GetArtifact() != nil {
-			oldChecksum = oldObj.GetArtifact().Checksum
-		}
-
 		// A partial commit due to no-op clone doesn't contain the commit
 		// message information. Have separate message for it.
 		var message string
@@ -342,7 +346,7 @@ func (r *GitRepositoryReconciler) notify(ctx context.Context, oldObj, newObj *so
 		}
 
 		// Notify on new artifact and failure recovery.
-		if oldChecksum != newObj.GetArtifact().Checksum {
+		if artifactChanged(oldObj.GetArtifact(), newObj.GetArtifact()) {
 			r.AnnotatedEventf(newObj, annotations, corev1.EventTypeNormal,
 				"NewArtifact", message)
 			ctrl.LoggerFrom(ctx).Info(message)
@@ -657,7 +661,7 @@ func (r *GitRepositoryReconciler) reconcileArtifact(ctx context.Context, sp *pat
 		conditions.MarkTrue(obj, sourcev1.StorageOperationFailedCondition, e.Reason, e.Err.Error())
 		return sreconcile.ResultEmpty, e
 	}
-	unlock, err := r.Storage.Lock(artifact)
+	unlock, err := r.Storage.LockWithContext(ctx, artifact)
 	if err != nil {
 		return sreconcile.ResultEmpty, serror.NewGeneric(
 			fmt.Errorf("failed to acquire lock for artifact: %w", err),
@@ -679,16 +683,31 @@ func (r *GitRepositoryReconciler) reconcileArtifact(ctx context.Context, sp *pat
 		ps = append(ps, sourceignore.ReadPatterns(strings.NewReader(*obj.Spec.Ignore), ignoreDomain)...)
 	}
 
-	// Archive directory to storage
-	if err := r.Storage.Archive(&artifact, dir, SourceIgnoreFilter(ps, ignoreDomain)); err != nil {
+	// Reuse the current artifact if the content of the new revision is
+	// identical, to not cause the consumers to reconcile the same content
+	filter := SourceIgnoreFilter(ps, ignoreDomain)
+	reused, err := r.Storage.ReuseArtifact(&artifact, obj.GetArtifact(), dir, filter)
+	if err != nil {
 		e := serror.NewGeneric(
-			fmt.Errorf("unable to archive artifact to storage: %w", err),
+			fmt.Errorf("failed to compute the checksum of the artifact content: %w", err),
 			sourcev1.ArchiveOperationFailedReason,
 		)
 		conditions.MarkTrue(obj, sourcev1.StorageOperationFailedCondition, e.Reason, e.Err.Error())
 		return sreconcile.ResultEmpty, e
 	}
 
+	// Archive directory to storage
+	if !reused {
+		if err := r.Storage.Archive(&artifact, dir, filter); err != nil {
+			e := serror.NewGeneric(
+				fmt.Errorf("unable to archive artifact to storage: %w", err),
+				sourcev1.ArchiveOperationFailedReason,
+			)
+			conditions.MarkTrue(obj, sourcev1.StorageOperationFailedCondition, e.Reason, e.Err.Error())
+			return sreconcile.ResultEmpty, e
+		}
+	}
+
 	// Record the observations on the object.
 	obj.Status.Artifact = artifact.DeepCopy()
 	obj.Status.IncludedArtifacts = *includes
@@ -1015,7 +1034,7 @@ func gitContentConfigChanged(obj *sourcev1.GitRepository, includes *artifactSet)
 		if observedInclArtifact.Revision != currentIncl.Revision {
 			return true
 		}
-		if observedInclArtifact.Checksum != currentIncl.Checksum {
+		if artifactChanged(observedInclArtifact, currentIncl) {
 			return true
 		}
 	}
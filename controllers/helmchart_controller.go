@@ -18,16 +18,20 @@ package controllers
 
 import (
 	"context"
+	"crypto/sha256"
 	"crypto/tls"
 	"errors"
 	"fmt"
 	"net/url"
 	"os"
 	"path/filepath"
+	"sort"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
+	securejoin "github.com/cyphar/filepath-securejoin"
 	eventv1 "github.com/fluxcd/pkg/apis/event/v1beta1"
 	soci "github.com/fluxcd/source-controller/internal/oci"
 	"github.com/google/go-containerregistry/pkg/authn"
@@ -51,6 +55,7 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/ratelimiter"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 	"sigs.k8s.io/controller-runtime/pkg/source"
+	"sigs.k8s.io/yaml"
 
 	"github.com/fluxcd/pkg/apis/meta"
 	"github.com/fluxcd/pkg/oci"
@@ -59,15 +64,17 @@ import (
 	"github.com/fluxcd/pkg/runtime/patch"
 	"github.com/fluxcd/pkg/runtime/predicates"
 	rreconcile "github.com/fluxcd/pkg/runtime/reconcile"
-	"github.com/fluxcd/pkg/untar"
+	"github.com/fluxcd/pkg/runtime/transform"
 
 	sourcev1 "github.com/fluxcd/source-controller/api/v1beta2"
+	"github.com/fluxcd/source-controller/internal/archive"
 	"github.com/fluxcd/source-controller/internal/cache"
 	serror "github.com/fluxcd/source-controller/internal/error"
 	"github.com/fluxcd/source-controller/internal/helm/chart"
 	"github.com/fluxcd/source-controller/internal/helm/getter"
 	"github.com/fluxcd/source-controller/internal/helm/registry"
 	"github.com/fluxcd/source-controller/internal/helm/repository"
+	intpredicates "github.com/fluxcd/source-controller/internal/predicates"
 	sreconcile "github.com/fluxcd/source-controller/internal/reconcile"
 	"github.com/fluxcd/source-controller/internal/reconcile/summarize"
 	"github.com/fluxcd/source-controller/internal/util"
@@ -119,6 +126,7 @@ var helmChartFailConditions = []string{
 // +kubebuilder:rbac:groups=source.toolkit.fluxcd.io,resources=helmcharts/status,verbs=get;update;patch
 // +kubebuilder:rbac:groups=source.toolkit.fluxcd.io,resources=helmcharts/finalizers,verbs=get;create;update;patch;delete
 // +kubebuilder:rbac:groups="",resources=events,verbs=create;patch
+// +kubebuilder:rbac:groups="",resources=configmaps,verbs=get;list;watch
 
 // HelmChartReconciler reconciles a HelmChart object
 type HelmChartReconciler struct {
@@ -131,10 +139,63 @@ type HelmChartReconciler struct {
 	Getters                 helmgetter.Providers
 	ControllerName          string
 
+	// FailureThreshold is the number of consecutive reconciliations failing
+	// to fetch, after which the object is marked as stalled. Zero disables it.
+	FailureThreshold int64
+
+	// IntervalJitterPercentage is the maximum percentage of the interval by
+	// which the requeue after a successful reconciliation is increased or
+	// decreased, to spread the reconciliations of objects with the same
+	// interval. The jitter is deterministic per object. Zero disables it.
+	IntervalJitterPercentage int
+
 	Cache *cache.Cache
 	TTL   time.Duration
 	*cache.CacheRecorder
 
+	// SourceMaxSize is the maximum total size in bytes of the files extracted
+	// from a GitRepository or Bucket Artifact to build a chart from.
+	// Zero means no limit.
+	SourceMaxSize int64
+	// SourceMaxFiles is the maximum number of entries extracted from a
+	// GitRepository or Bucket Artifact to build a chart from.
+	// Zero means no limit.
+	SourceMaxFiles int
+	// BuildMemoryBudget is the maximum total size in bytes of the chart and
+	// values files loaded in memory to build a chart from a GitRepository or
+	// Bucket Artifact. Zero means no limit.
+	BuildMemoryBudget int64
+
+	// UserAgent overrides the User-Agent of the requests to the Helm
+	// repositories when set.
+	UserAgent string
+
+	// RegistryRecorder records the duration and bytes downloaded of the
+	// pulls of charts from OCI registries.
+	RegistryRecorder *soci.RegistryRecorder
+
+	// DisableRekorLookups disables the lookups in the Rekor transparency log
+	// of the keyless verification, for air-gapped environments.
+	DisableRekorLookups bool
+
+	// DisableKeylessVerification rejects the verification of objects without
+	// a secret reference with public keys, instead of verifying them with the
+	// keyless method, for environments which require explicit keys.
+	DisableKeylessVerification bool
+
+	// AllowedRegistryDomains restricts the registries from which charts and
+	// their dependencies can be pulled when they are stored in an OCI
+	// registry. An empty list allows any registry.
+	AllowedRegistryDomains soci.DomainAllowlist
+
+	// garbageCollectOptions configures the garbage collection of the
+	// artifacts of the objects.
+	garbageCollectOptions GarbageCollectOptions
+
+	// indexCacheEvictions records the handled requests to evict the index
+	// of the repository from the cache.
+	indexCacheEvictions indexCacheEvictions
+
 	patchOptions []patch.Option
 }
 
@@ -145,6 +206,15 @@ func (r *HelmChartReconciler) SetupWithManager(mgr ctrl.Manager) error {
 type HelmChartReconcilerOptions struct {
 	MaxConcurrentReconciles int
 	RateLimiter             ratelimiter.RateLimiter
+
+	// MaxConcurrentReconcilesPerNamespace limits the number of concurrent
+	// reconciles of the objects in a namespace. Zero means no limit.
+	MaxConcurrentReconcilesPerNamespace int
+
+	// GarbageCollection configures the timeout of the garbage collection of
+	// the artifacts of the objects, and overrides the retention settings of
+	// the Storage for them.
+	GarbageCollection GarbageCollectOptions
 }
 
 // helmChartReconcileFunc is the function type for all the v1beta2.HelmChart
@@ -154,6 +224,7 @@ type helmChartReconcileFunc func(ctx context.Context, sp *patch.SerialPatcher, o
 
 func (r *HelmChartReconciler) SetupWithManagerAndOptions(mgr ctrl.Manager, opts HelmChartReconcilerOptions) error {
 	r.patchOptions = getPatchOptions(helmChartReadyCondition.Owned, r.ControllerName)
+	r.garbageCollectOptions = opts.GarbageCollection
 
 	if err := mgr.GetCache().IndexField(context.TODO(), &sourcev1.HelmRepository{}, sourcev1.HelmRepositoryURLIndexKey,
 		r.indexHelmRepositoryByURL); err != nil {
@@ -163,10 +234,19 @@ func (r *HelmChartReconciler) SetupWithManagerAndOptions(mgr ctrl.Manager, opts
 		r.indexHelmChartBySource); err != nil {
 		return fmt.Errorf("failed setting index fields: %w", err)
 	}
+	if err := mgr.GetCache().IndexField(context.TODO(), &sourcev1.HelmChart{}, sourcev1.HelmChartDependencyRepositoryIndexKey,
+		r.indexHelmChartByDependencyRepository); err != nil {
+		return fmt.Errorf("failed setting index fields: %w", err)
+	}
+	if err := mgr.GetCache().IndexField(context.TODO(), &sourcev1.HelmChart{}, sourcev1.HelmChartValuesFromIndexKey,
+		r.indexHelmChartByValuesFrom); err != nil {
+		return fmt.Errorf("failed setting index fields: %w", err)
+	}
 
 	return ctrl.NewControllerManagedBy(mgr).
 		For(&sourcev1.HelmChart{}, builder.WithPredicates(
-			predicate.Or(predicate.GenerationChangedPredicate{}, predicates.ReconcileRequestedPredicate{}),
+			predicate.Or(predicate.GenerationChangedPredicate{}, predicates.ReconcileRequestedPredicate{},
+				intpredicates.AnnotationChangedPredicate{Annotation: sourcev1.IndexCacheEvictionAnnotation}),
 		)).
 		Watches(
 			&source.Kind{Type: &sourcev1.HelmRepository{}},
@@ -183,12 +263,17 @@ func (r *HelmChartReconciler) SetupWithManagerAndOptions(mgr ctrl.Manager, opts
 			handler.EnqueueRequestsFromMapFunc(r.requestsForBucketChange),
 			builder.WithPredicates(SourceRevisionChangePredicate{}),
 		).
+		Watches(
+			&source.Kind{Type: &corev1.ConfigMap{}},
+			handler.EnqueueRequestsFromMapFunc(r.requestsForValuesFromChange),
+			builder.WithPredicates(predicate.ResourceVersionChangedPredicate{}),
+		).
 		WithOptions(controller.Options{
 			MaxConcurrentReconciles: opts.MaxConcurrentReconciles,
 			RateLimiter:             opts.RateLimiter,
 			RecoverPanic:            true,
 		}).
-		Complete(r)
+		Complete(withNamespaceLimit(r, opts.MaxConcurrentReconcilesPerNamespace))
 }
 
 func (r *HelmChartReconciler) Reconcile(ctx context.Context, req ctrl.Request) (result ctrl.Result, retErr error) {
@@ -224,8 +309,11 @@ func (r *HelmChartReconciler) Reconcile(ctx context.Context, req ctrl.Request) (
 				summarize.RecordContextualError,
 				summarize.RecordReconcileReq,
 			),
-			summarize.WithResultBuilder(sreconcile.AlwaysRequeueResultBuilder{RequeueAfter: obj.GetRequeueAfter()}),
+			summarize.WithResultBuilder(sreconcile.AlwaysRequeueResultBuilder{
+				RequeueAfter: sreconcile.JitterInterval(obj.GetRequeueAfter(), r.IntervalJitterPercentage, string(obj.GetUID())),
+			}),
 			summarize.WithPatchFieldOwner(r.ControllerName),
+			summarize.WithFailureThreshold(r.FailureThreshold, sourcev1.FetchFailedCondition),
 		}
 		result, retErr = summarizeHelper.SummarizeAndPatch(ctx, obj, summarizeOpts...)
 
@@ -329,14 +417,12 @@ func (r *HelmChartReconciler) notify(ctx context.Context, oldObj, newObj *source
 			fmt.Sprintf("%s/%s", sourcev1.GroupVersion.Group, eventv1.MetaRevisionKey): newObj.Status.Artifact.Revision,
 			fmt.Sprintf("%s/%s", sourcev1.GroupVersion.Group, eventv1.MetaChecksumKey): newObj.Status.Artifact.Checksum,
 		}
-
-		var oldChecksum string
-		if oldObj.GetArtifact() != nil {
-			oldChecksum = oldObj.GetArtifact().Checksum
+		if summary := build.SummaryJSON(); summary != "" {
+			annotations[fmt.Sprintf("%s/%s", sourcev1.GroupVersion.Group, chartBuildSummaryMetadataKey)] = summary
 		}
 
 		// Notify on new artifact and failure recovery.
-		if oldChecksum != newObj.GetArtifact().Checksum {
+		if artifactChanged(oldObj.GetArtifact(), newObj.GetArtifact()) {
 			r.AnnotatedEventf(newObj, annotations, corev1.EventTypeNormal,
 				reasonForBuild(build), build.Summary())
 			ctrl.LoggerFrom(ctx).Info(build.Summary())
@@ -405,6 +491,17 @@ func (r *HelmChartReconciler) reconcileSource(ctx context.Context, sp *patch.Ser
 		conditions.Delete(obj, sourcev1.SourceVerifiedCondition)
 	}
 
+	// Reject the verification options which only OCIRepositories support,
+	// as they would otherwise be silently ignored
+	if err := unsupportedVerificationError(obj); err != nil {
+		e := &serror.Stalling{
+			Err:    err,
+			Reason: sourcev1.VerificationError,
+		}
+		conditions.MarkFalse(obj, sourcev1.SourceVerifiedCondition, e.Reason, e.Err.Error())
+		return sreconcile.ResultEmpty, e
+	}
+
 	// Retrieve the source
 	s, err := r.getSource(ctx, obj)
 	if err != nil {
@@ -451,9 +548,26 @@ func (r *HelmChartReconciler) reconcileSource(ctx context.Context, sp *patch.Ser
 		// The reason this is a done conditionally, is because if we have a cached one in storage,
 		// we can not recover this information (and put it in a condition). Which would result in
 		// a sudden (partial) disappearance of observed state.
-		// TODO(hidde): include specific name/version information?
 		if depNum := build.ResolvedDependencies; build.Complete() && depNum > 0 {
-			r.Eventf(obj, eventv1.EventTypeTrace, "ResolvedDependencies", "resolved %d chart dependencies", depNum)
+			msg := fmt.Sprintf("resolved %d chart dependencies", depNum)
+			if len(build.Dependencies) > 0 {
+				deps := make([]string, 0, len(build.Dependencies))
+				for _, dep := range build.Dependencies {
+					deps = append(deps, dep.String())
+				}
+				msg += fmt.Sprintf(": %s", strings.Join(deps, ", "))
+			}
+			if len(build.PinnedDependencies) > 0 {
+				msg += fmt.Sprintf(" (pinned: %s)", strings.Join(build.PinnedDependencies, ", "))
+			}
+			r.Event(obj, eventv1.EventTypeTrace, "ResolvedDependencies", msg)
+		}
+
+		// Warn about issues with the chart which did not fail the build, but
+		// may cause it to break on newer clusters.
+		if build.Complete() && len(build.Warnings) > 0 {
+			r.Eventf(obj, corev1.EventTypeWarning, chartBuildWarningReason, "chart build produced %d warning(s): %s",
+				len(build.Warnings), chartBuildWarningsMessage(build.Warnings))
 		}
 
 		// Handle any build error
@@ -495,6 +609,7 @@ func (r *HelmChartReconciler) buildFromHelmRepository(ctx context.Context, obj *
 	repo *sourcev1.HelmRepository, b *chart.Build) (sreconcile.Result, error) {
 	var (
 		tlsConfig     *tls.Config
+		bearerToken   string
 		authenticator authn.Authenticator
 		keychain      authn.Keychain
 	)
@@ -509,6 +624,9 @@ func (r *HelmChartReconciler) buildFromHelmRepository(ctx context.Context, obj *
 		helmgetter.WithTimeout(repo.Spec.Timeout.Duration),
 		helmgetter.WithPassCredentialsAll(repo.Spec.PassCredentials),
 	}
+	if r.UserAgent != "" {
+		clientOpts = append(clientOpts, helmgetter.WithUserAgent(r.UserAgent))
+	}
 	if secret, err := r.getHelmRepositorySecret(ctx, repo); secret != nil || err != nil {
 		if err != nil {
 			e := &serror.Event{
@@ -521,7 +639,7 @@ func (r *HelmChartReconciler) buildFromHelmRepository(ctx context.Context, obj *
 		}
 
 		// Build client options from secret
-		opts, tls, err := r.clientOptionsFromSecret(secret, normalizedURL)
+		opts, tls, token, err := r.clientOptionsFromSecret(secret, normalizedURL)
 		if err != nil {
 			e := &serror.Event{
 				Err:    err,
@@ -533,6 +651,7 @@ func (r *HelmChartReconciler) buildFromHelmRepository(ctx context.Context, obj *
 		}
 		clientOpts = append(clientOpts, opts...)
 		tlsConfig = tls
+		bearerToken = token
 
 		// Build registryClient options from secret
 		keychain, err = registry.LoginOptionFromSecret(normalizedURL, *secret)
@@ -545,18 +664,29 @@ func (r *HelmChartReconciler) buildFromHelmRepository(ctx context.Context, obj *
 			// Requeue as content of secret might change
 			return sreconcile.ResultEmpty, e
 		}
-	} else if repo.Spec.Provider != sourcev1.GenericOCIProvider && repo.Spec.Type == sourcev1.HelmRepositoryTypeOCI {
-		auth, authErr := oidcAuth(ctxTimeout, repo.Spec.URL, repo.Spec.Provider)
-		if authErr != nil && !errors.Is(authErr, oci.ErrUnconfiguredProvider) {
+	} else if repo.Spec.Type == sourcev1.HelmRepositoryTypeOCI {
+		provider, err := ociProvider(ctx, r.Client, repo.GetNamespace(), repo.Spec.Provider)
+		if err != nil {
 			e := &serror.Event{
-				Err:    fmt.Errorf("failed to get credential from %s: %w", repo.Spec.Provider, authErr),
+				Err:    fmt.Errorf("failed to determine provider: %w", err),
 				Reason: sourcev1.AuthenticationFailedReason,
 			}
 			conditions.MarkTrue(obj, sourcev1.FetchFailedCondition, e.Reason, e.Err.Error())
 			return sreconcile.ResultEmpty, e
 		}
-		if auth != nil {
-			authenticator = auth
+		if provider != sourcev1.GenericOCIProvider {
+			auth, authErr := oidcAuth(ctxTimeout, repo.Spec.URL, provider)
+			if authErr != nil && !errors.Is(authErr, oci.ErrUnconfiguredProvider) {
+				e := &serror.Event{
+					Err:    fmt.Errorf("failed to get credential from %s: %w", provider, authErr),
+					Reason: sourcev1.AuthenticationFailedReason,
+				}
+				conditions.MarkTrue(obj, sourcev1.FetchFailedCondition, e.Reason, e.Err.Error())
+				return sreconcile.ResultEmpty, e
+			}
+			if auth != nil {
+				authenticator = auth
+			}
 		}
 	}
 
@@ -578,34 +708,70 @@ func (r *HelmChartReconciler) buildFromHelmRepository(ctx context.Context, obj *
 			err := fmt.Errorf("invalid OCI registry URL: %s", normalizedURL)
 			return chartRepoConfigErrorReturn(err, obj)
 		}
+		if err := checkRegistryDomain(r.AllowedRegistryDomains, normalizedURL); err != nil {
+			e := &serror.Stalling{
+				Err:    err,
+				Reason: sourcev1.URLInvalidReason,
+			}
+			conditions.MarkTrue(obj, sourcev1.FetchFailedCondition, e.Reason, e.Err.Error())
+			return sreconcile.ResultEmpty, e
+		}
 
 		// with this function call, we create a temporary file to store the credentials if needed.
 		// this is needed because otherwise the credentials are stored in ~/.docker/config.json.
 		// TODO@souleb: remove this once the registry move to Oras v2
 		// or rework to enable reusing credentials to avoid the unneccessary handshake operations
-		registryClient, credentialsFile, err := r.RegistryClientGenerator(loginOpt != nil)
+		registryTLSConfig, err := tlsConfigFromCertSecret(ctx, r.Client, repo)
 		if err != nil {
 			e := &serror.Event{
-				Err:    fmt.Errorf("failed to construct Helm client: %w", err),
-				Reason: meta.FailedReason,
+				Err:    err,
+				Reason: sourcev1.AuthenticationFailedReason,
 			}
 			conditions.MarkTrue(obj, sourcev1.FetchFailedCondition, e.Reason, e.Err.Error())
 			return sreconcile.ResultEmpty, e
 		}
 
-		if credentialsFile != "" {
-			defer func() {
-				if err := os.Remove(credentialsFile); err != nil {
-					r.eventLogf(ctx, obj, corev1.EventTypeWarning, meta.FailedReason,
-						"failed to delete temporary credentials file: %s", err)
+		// The Helm registry client does not allow configuring its transport,
+		// a custom TLS configuration requires a remote client instead
+		var (
+			registryClient repository.RegistryClient
+			remoteClient   *registry.RemoteClient
+		)
+		if registryTLSConfig != nil {
+			remoteClient = registry.NewRemoteClient(ctxTimeout, registryTLSConfig, authenticator, keychain)
+			registryClient = remoteClient
+		} else {
+			helmClient, credentialsFile, err := r.RegistryClientGenerator(loginOpt != nil)
+			if err != nil {
+				e := &serror.Event{
+					Err:    fmt.Errorf("failed to construct Helm client: %w", err),
+					Reason: meta.FailedReason,
 				}
-			}()
+				conditions.MarkTrue(obj, sourcev1.FetchFailedCondition, e.Reason, e.Err.Error())
+				return sreconcile.ResultEmpty, e
+			}
+
+			if credentialsFile != "" {
+				defer func() {
+					if err := os.Remove(credentialsFile); err != nil {
+						r.eventLogf(ctx, obj, corev1.EventTypeWarning, meta.FailedReason,
+							"failed to delete temporary credentials file: %s", err)
+					}
+				}()
+			}
+
+			// Tell the chart repository to use the OCI client with the configured getter
+			clientOpts = append(clientOpts, helmgetter.WithRegistryClient(helmClient))
+			registryClient = helmClient
 		}
 
 		var verifiers []soci.Verifier
 		if obj.Spec.Verify != nil {
 			provider := obj.Spec.Verify.Provider
-			verifiers, err = r.makeVerifiers(ctx, obj, authenticator, keychain)
+			if provider != "cosign" {
+				return unsupportedVerificationProviderReturn(obj, sourcev1.HelmRepositoryTypeOCI)
+			}
+			verifiers, err = r.makeVerifiers(ctx, obj, authenticator, keychain, registryTLSConfig)
 			if err != nil {
 				if obj.Spec.Verify.SecretRef == nil {
 					provider = fmt.Sprintf("%s keyless", provider)
@@ -615,17 +781,42 @@ func (r *HelmChartReconciler) buildFromHelmRepository(ctx context.Context, obj *
 					Reason: sourcev1.VerificationError,
 				}
 				conditions.MarkFalse(obj, sourcev1.SourceVerifiedCondition, e.Reason, e.Err.Error())
+				if errors.Is(err, errKeylessVerificationDisabled) {
+					return sreconcile.ResultEmpty, &serror.Stalling{Err: e.Err, Reason: e.Reason}
+				}
 				return sreconcile.ResultEmpty, e
 			}
 		}
 
-		// Tell the chart repository to use the OCI client with the configured getter
-		clientOpts = append(clientOpts, helmgetter.WithRegistryClient(registryClient))
-		ociChartRepo, err := repository.NewOCIChartRepository(normalizedURL,
+		// Configure the remote options used to ensure the artifact is a Helm
+		// chart before it is downloaded
+		remoteOpts := []remote.Option{remote.WithContext(ctxTimeout)}
+		if authenticator != nil {
+			remoteOpts = append(remoteOpts, remote.WithAuth(authenticator))
+		} else if keychain != nil {
+			remoteOpts = append(remoteOpts, remote.WithAuthFromKeychain(keychain))
+		}
+		if registryTLSConfig != nil {
+			remoteOpts = append(remoteOpts, remote.WithTransport(registry.NewTLSTransport(registryTLSConfig)))
+		}
+
+		ociChartRepoOpts := []repository.OCIChartRepositoryOption{
 			repository.WithOCIGetter(r.Getters),
 			repository.WithOCIGetterOptions(clientOpts),
 			repository.WithOCIRegistryClient(registryClient),
-			repository.WithVerifiers(verifiers))
+			repository.WithVerifiers(verifiers),
+			repository.WithOCIRemoteOptions(remoteOpts...),
+			repository.WithOCIPullRecorder(func(start time.Time, bytes int64) {
+				r.recordPull(obj, start, bytes)
+			}),
+		}
+		if obj.Spec.IgnorePrerelease {
+			ociChartRepoOpts = append(ociChartRepoOpts, repository.WithOCIIgnorePrerelease())
+		}
+		if remoteClient != nil {
+			ociChartRepoOpts = append(ociChartRepoOpts, repository.WithOCIClient(remoteClient))
+		}
+		ociChartRepo, err := repository.NewOCIChartRepository(normalizedURL, ociChartRepoOpts...)
 		if err != nil {
 			return chartRepoConfigErrorReturn(err, obj)
 		}
@@ -645,10 +836,43 @@ func (r *HelmChartReconciler) buildFromHelmRepository(ctx context.Context, obj *
 			}
 		}
 	default:
-		httpChartRepo, err := repository.NewChartRepository(normalizedURL, r.Storage.LocalPath(*repo.GetArtifact()), r.Getters, tlsConfig, clientOpts,
+		// Evict the cached index of the repository if requested, to load it
+		// again from the Artifact of the repository
+		if r.Cache != nil && r.indexCacheEvictions.requested(obj) {
+			r.Cache.Delete(r.Storage.LocalPath(*repo.GetArtifact()))
+			r.IncCacheEvents(cache.CacheEventTypeEviction, obj.Name, obj.Namespace)
+			r.eventLogf(ctx, obj, eventv1.EventTypeTrace, sourcev1.IndexCacheEvictedReason,
+				"evicted index of HelmRepository '%s' from cache", repo.Name)
+		}
+		chartRepoOpts := []repository.ChartRepositoryOption{
 			repository.WithMemoryCache(r.Storage.LocalPath(*repo.GetArtifact()), r.Cache, r.TTL, func(event string) {
 				r.IncCacheEvents(event, obj.Name, obj.Namespace)
-			}))
+			}),
+		}
+		if obj.Spec.Verify != nil {
+			if obj.Spec.Verify.Provider != "pgp" {
+				return unsupportedVerificationProviderReturn(obj, sourcev1.HelmRepositoryTypeDefault)
+			}
+			keyring, err := r.makeKeyring(ctx, obj)
+			if err != nil {
+				e := &serror.Event{
+					Err:    fmt.Errorf("failed to verify the provenance using provider '%s': %w", obj.Spec.Verify.Provider, err),
+					Reason: sourcev1.VerificationError,
+				}
+				conditions.MarkFalse(obj, sourcev1.SourceVerifiedCondition, e.Reason, e.Err.Error())
+				return sreconcile.ResultEmpty, e
+			}
+			chartRepoOpts = append(chartRepoOpts, repository.WithProvenanceKeyring(keyring))
+		}
+		if obj.Spec.IgnorePrerelease {
+			chartRepoOpts = append(chartRepoOpts, repository.WithIgnorePrerelease())
+		}
+		if bearerToken != "" {
+			chartRepoOpts = append(chartRepoOpts, repository.WithBearerToken(bearerToken, repo.Spec.PassCredentials))
+		}
+
+		httpChartRepo, err := repository.NewChartRepository(normalizedURL, r.Storage.LocalPath(*repo.GetArtifact()), r.Getters, tlsConfig, clientOpts,
+			chartRepoOpts...)
 		if err != nil {
 			return chartRepoConfigErrorReturn(err, obj)
 		}
@@ -679,8 +903,9 @@ func (r *HelmChartReconciler) buildFromHelmRepository(ctx context.Context, obj *
 	// Construct the chart builder with scoped configuration
 	cb := chart.NewRemoteBuilder(chartRepo)
 	opts := chart.BuildOptions{
-		ValuesFiles: obj.GetValuesFiles(),
-		Force:       obj.Generation != obj.Status.ObservedGeneration,
+		ValuesFiles:              obj.GetValuesFiles(),
+		IgnoreMissingValuesFiles: obj.Spec.IgnoreMissingValuesFiles,
+		Force:                    obj.Generation != obj.Status.ObservedGeneration,
 		// The remote builder will not attempt to download the chart if
 		// an artifact exists with the same name and version and `Force` is false.
 		// It will however try to verify the chart if `obj.Spec.Verify` is set, at every reconciliation.
@@ -688,6 +913,10 @@ func (r *HelmChartReconciler) buildFromHelmRepository(ctx context.Context, obj *
 	}
 	if artifact := obj.GetArtifact(); artifact != nil {
 		opts.CachedChart = r.Storage.LocalPath(*artifact)
+		// The chart is only rebuilt if the digest of the resolved chart
+		// version in the index changed, and not for every new revision of
+		// the index.
+		opts.CachedChartDigest = obj.Status.ObservedChartDigest
 	}
 
 	// Set the VersionMetadata to the object's Generation if ValuesFiles is defined
@@ -695,6 +924,11 @@ func (r *HelmChartReconciler) buildFromHelmRepository(ctx context.Context, obj *
 	if len(opts.GetValuesFiles()) > 0 {
 		opts.VersionMetadata = strconv.FormatInt(obj.Generation, 10)
 	}
+	// Merge the values from the ValuesFrom references, and append their
+	// checksum to the VersionMetadata
+	if err := r.setValuesFrom(ctx, obj, &opts); err != nil {
+		return valuesFromErrorReturn(err, obj)
+	}
 
 	// Build the chart
 	ref := chart.RemoteReference{Name: obj.Spec.Chart, Version: obj.Spec.Version}
@@ -746,12 +980,25 @@ func (r *HelmChartReconciler) buildFromTarballArtifact(ctx context.Context, obj
 		conditions.MarkTrue(obj, sourcev1.FetchFailedCondition, e.Reason, e.Err.Error())
 		return sreconcile.ResultEmpty, e
 	}
-	if _, err = untar.Untar(f, sourceDir); err != nil {
+	limits := archive.Limits{MaxSize: r.SourceMaxSize, MaxFiles: r.SourceMaxFiles}
+	if _, err = archive.Untar(f, sourceDir, limits); err != nil {
 		_ = f.Close()
-		return sreconcile.ResultEmpty, &serror.Event{
+		// Discard the partially extracted source, to never build a chart from it
+		_ = os.RemoveAll(sourceDir)
+		e := &serror.Event{
 			Err:    fmt.Errorf("artifact untar error: %w", err),
 			Reason: meta.FailedReason,
 		}
+		var limitErr *archive.LimitExceededError
+		var unsafeErr *archive.UnsafePathError
+		switch {
+		case errors.Is(err, archive.ErrTruncated):
+			e.Reason = sourcev1.ArchiveTruncatedReason
+			conditions.MarkTrue(obj, sourcev1.FetchFailedCondition, e.Reason, e.Err.Error())
+		case errors.As(err, &limitErr), errors.As(err, &unsafeErr):
+			conditions.MarkTrue(obj, sourcev1.FetchFailedCondition, e.Reason, e.Err.Error())
+		}
+		return sreconcile.ResultEmpty, e
 	}
 	if err = f.Close(); err != nil {
 		return sreconcile.ResultEmpty, &serror.Event{
@@ -760,10 +1007,16 @@ func (r *HelmChartReconciler) buildFromTarballArtifact(ctx context.Context, obj
 		}
 	}
 
-	// Setup dependency manager
-	dm := chart.NewDependencyManager(
-		chart.WithDownloaderCallback(r.namespacedChartRepositoryCallback(ctx, obj.GetName(), obj.GetNamespace())),
-	)
+	// Setup dependency manager, recording the revisions of the repositories
+	// the dependencies are resolved from
+	depRevisions := &dependencyRevisionRecorder{}
+	dmOpts := []chart.DependencyManagerOption{
+		chart.WithDownloaderCallback(r.namespacedChartRepositoryCallback(ctx, obj, depRevisions)),
+	}
+	if obj.Spec.Verify != nil && obj.Spec.VerifyDependencies {
+		dmOpts = append(dmOpts, chart.WithDependencyVerification(true))
+	}
+	dm := chart.NewDependencyManager(dmOpts...)
 	defer func() {
 		err := dm.Clear()
 		if err != nil {
@@ -772,10 +1025,15 @@ func (r *HelmChartReconciler) buildFromTarballArtifact(ctx context.Context, obj
 		}
 	}()
 
-	// Configure builder options, including any previously cached chart
+	// Configure builder options, including any previously cached chart.
+	// The cached chart is not used when a repository the dependencies were
+	// resolved from has changed, as the dependencies may resolve differently.
 	opts := chart.BuildOptions{
-		ValuesFiles: obj.GetValuesFiles(),
-		Force:       obj.Generation != obj.Status.ObservedGeneration,
+		ValuesFiles:              obj.GetValuesFiles(),
+		IgnoreMissingValuesFiles: obj.Spec.IgnoreMissingValuesFiles,
+		Force:                    obj.Generation != obj.Status.ObservedGeneration || r.dependencyRevisionsDrifted(ctx, obj),
+		SkipDependencyUpdate:     obj.Spec.SkipDependencyUpdate,
+		MemoryBudget:             r.BuildMemoryBudget,
 	}
 	if artifact := obj.Status.Artifact; artifact != nil {
 		opts.CachedChart = r.Storage.LocalPath(*artifact)
@@ -783,27 +1041,12 @@ func (r *HelmChartReconciler) buildFromTarballArtifact(ctx context.Context, obj
 
 	// Configure revision metadata for chart build if we should react to revision changes
 	if obj.Spec.ReconcileStrategy == sourcev1.ReconcileStrategyRevision {
-		rev := source.Revision
-		if obj.Spec.SourceRef.Kind == sourcev1.GitRepositoryKind {
-			// Split the reference by the `/` delimiter which may be present,
-			// and take the last entry which contains the SHA.
-			split := strings.Split(source.Revision, "/")
-			rev = split[len(split)-1]
-		}
-		if kind := obj.Spec.SourceRef.Kind; kind == sourcev1.GitRepositoryKind || kind == sourcev1.BucketKind {
-			// The SemVer from the metadata is at times used in e.g. the label metadata for a resource
-			// in a chart, which has a limited length of 63 characters.
-			// To not fill most of this space with a full length SHA hex (40 characters for SHA-1, and
-			// even more for SHA-2 for a chart from a Bucket), we shorten this to the first 12
-			// characters taken from the hex.
-			// For SHA-1, this has proven to be unique in the Linux kernel with over 875.000 commits
-			// (http://git-scm.com/book/en/v2/Git-Tools-Revision-Selection#Short-SHA-1).
-			// Note that for a collision to be problematic, it would need to happen right after the
-			// previous SHA for the artifact, which is highly unlikely, if not virtually impossible.
-			// Ref: https://en.wikipedia.org/wiki/Birthday_attack
-			rev = rev[0:12]
-		}
-		opts.VersionMetadata = rev
+		opts.VersionMetadata = shortSourceRevision(obj.Spec.SourceRef.Kind, source.Revision)
+	}
+	// Set the appVersion of the chart to the source revision if instructed,
+	// this documents the origin of the chart in the chart itself
+	if obj.Spec.AppVersionFromRevision {
+		opts.AppVersion = shortSourceRevision(obj.Spec.SourceRef.Kind, source.Revision)
 	}
 	// Set the VersionMetadata to the object's Generation if ValuesFiles is defined,
 	// this ensures changes can be noticed by the Artifact consumer
@@ -813,21 +1056,205 @@ func (r *HelmChartReconciler) buildFromTarballArtifact(ctx context.Context, obj
 		}
 		opts.VersionMetadata += strconv.FormatInt(obj.Generation, 10)
 	}
+	// Merge the values from the ValuesFrom references, and append their
+	// checksum to the VersionMetadata
+	if err := r.setValuesFrom(ctx, obj, &opts); err != nil {
+		return valuesFromErrorReturn(err, obj)
+	}
+
+	// Resolve the chart by name and version from the chart repository index
+	// of a Bucket, unless the chart is a path in the Bucket
+	chartPath := obj.Spec.Chart
+	if obj.Spec.SourceRef.Kind == sourcev1.BucketKind {
+		indexedPath, err := chart.ResolveIndexedChart(sourceDir, obj.Spec.Chart, obj.Spec.Version)
+		if err != nil {
+			return sreconcile.ResultEmpty, &chart.BuildError{Reason: chart.ErrChartReference, Err: err}
+		}
+		if indexedPath != "" {
+			chartPath = indexedPath
+		}
+	}
+
+	// Surface a chart path pointing into an empty directory, which is most
+	// likely a Git submodule of which the content was not fetched
+	if dir := emptyChartPathDir(sourceDir, chartPath); dir != "" {
+		err := fmt.Errorf("chart path '%s' points into the empty directory '%s' of the source artifact", chartPath, dir)
+		if obj.Spec.SourceRef.Kind == sourcev1.GitRepositoryKind && !r.sourceRecursesSubmodules(ctx, obj) {
+			err = fmt.Errorf("%w: if it is a Git submodule, enable '.spec.recurseSubmodules' of GitRepository '%s' to include its content",
+				err, obj.Spec.SourceRef.Name)
+		}
+		return sreconcile.ResultEmpty, &chart.BuildError{Reason: chart.ErrChartReference, Err: err}
+	}
 
 	// Build chart
 	cb := chart.NewLocalBuilder(dm)
 	build, err := cb.Build(ctx, chart.LocalReference{
 		WorkDir: sourceDir,
-		Path:    obj.Spec.Chart,
+		Path:    chartPath,
 	}, util.TempPathForObj("", ".tgz", obj), opts)
 	if err != nil {
 		return sreconcile.ResultEmpty, err
 	}
 
 	*b = *build
+	b.DependencyRevisions = depRevisions.revisions
 	return sreconcile.ResultSuccess, nil
 }
 
+// emptyChartPathDir returns the directory of the given chart path in the
+// given source directory which is empty, either the chart path itself or the
+// closest parent directory of it which exists. It returns an empty string if
+// that directory is not empty, or if the chart path is a file.
+func emptyChartPathDir(sourceDir, chartPath string) string {
+	p := filepath.Clean(filepath.FromSlash(chartPath))
+	for p != "." && p != ".." && p != string(filepath.Separator) {
+		absPath, err := securejoin.SecureJoin(sourceDir, p)
+		if err != nil {
+			return ""
+		}
+		fi, err := os.Stat(absPath)
+		if err != nil {
+			p = filepath.Dir(p)
+			continue
+		}
+		if !fi.IsDir() {
+			return ""
+		}
+		if entries, err := os.ReadDir(absPath); err != nil || len(entries) > 0 {
+			return ""
+		}
+		return filepath.ToSlash(p)
+	}
+	return ""
+}
+
+// sourceRecursesSubmodules returns true if the GitRepository referenced by
+// the given object has the recursion of submodules enabled. It returns false
+// if the GitRepository can not be retrieved.
+func (r *HelmChartReconciler) sourceRecursesSubmodules(ctx context.Context, obj *sourcev1.HelmChart) bool {
+	s, err := r.getSource(ctx, obj)
+	if err != nil {
+		return false
+	}
+	repo, ok := s.(*sourcev1.GitRepository)
+	return ok && repo.Spec.RecurseSubmodules
+}
+
+// setValuesFrom merges the values from the ValuesFrom references of the
+// given object, followed by its ValuesInline, into the values of the given
+// chart.BuildOptions. A checksum of the merged values is appended to the
+// VersionMetadata, this ensures changes to the referenced resources or the
+// inline values can be noticed by the Artifact consumer.
+func (r *HelmChartReconciler) setValuesFrom(ctx context.Context, obj *sourcev1.HelmChart, opts *chart.BuildOptions) error {
+	inline := obj.Spec.ValuesInline != nil && len(obj.Spec.ValuesInline.Raw) > 0
+	if len(obj.Spec.ValuesFrom) == 0 && !inline {
+		return nil
+	}
+
+	values, err := r.getValuesFrom(ctx, obj)
+	if err != nil {
+		return err
+	}
+	if inline {
+		inlineValues := make(map[string]interface{})
+		if err := yaml.Unmarshal(obj.Spec.ValuesInline.Raw, &inlineValues); err != nil {
+			return fmt.Errorf("unmarshaling inline values failed: %w", err)
+		}
+		values = transform.MergeMaps(values, inlineValues)
+	}
+
+	// The keys of maps are sorted during marshaling, which makes the
+	// checksum stable
+	b, err := yaml.Marshal(values)
+	if err != nil {
+		return fmt.Errorf("failed to marshal values: %w", err)
+	}
+	if opts.VersionMetadata != "" {
+		opts.VersionMetadata += "."
+	}
+	opts.VersionMetadata += fmt.Sprintf("%x", sha256.Sum256(b))[:12]
+
+	opts.Values = values
+	opts.ValuesFrom = make([]string, 0, len(obj.Spec.ValuesFrom)+1)
+	for _, ref := range obj.Spec.ValuesFrom {
+		opts.ValuesFrom = append(opts.ValuesFrom, fmt.Sprintf("%s/%s", ref.Kind, ref.Name))
+	}
+	if inline {
+		opts.ValuesFrom = append(opts.ValuesFrom, "spec.valuesInline")
+	}
+	return nil
+}
+
+// getValuesFrom fetches the values from the ValuesFrom references of the
+// given object, and returns them merged in the order of the references.
+func (r *HelmChartReconciler) getValuesFrom(ctx context.Context, obj *sourcev1.HelmChart) (map[string]interface{}, error) {
+	merged := make(map[string]interface{})
+	for _, ref := range obj.Spec.ValuesFrom {
+		name := types.NamespacedName{
+			Namespace: obj.GetNamespace(),
+			Name:      ref.Name,
+		}
+		key := ref.GetValuesKey()
+
+		var data []byte
+		switch ref.Kind {
+		case "ConfigMap":
+			var cm corev1.ConfigMap
+			if err := r.Client.Get(ctx, name, &cm); err != nil {
+				return nil, fmt.Errorf("failed to get ConfigMap '%s': %w", name, err)
+			}
+			v, ok := cm.Data[key]
+			if !ok {
+				return nil, fmt.Errorf("missing key '%s' in ConfigMap '%s'", key, name)
+			}
+			data = []byte(v)
+		default:
+			// The values are packaged in the chart Artifact, which is served
+			// without authentication, and must therefore not come from a
+			// Secret
+			return nil, &serror.Stalling{
+				Err:    fmt.Errorf("unsupported values reference kind '%s', only ConfigMaps are supported", ref.Kind),
+				Reason: sourcev1.ValuesFromFailedReason,
+			}
+		}
+
+		values := make(map[string]interface{})
+		if err := yaml.Unmarshal(data, &values); err != nil {
+			return nil, fmt.Errorf("unmarshaling values from key '%s' in %s '%s' failed: %w", key, ref.Kind, name, err)
+		}
+		merged = transform.MergeMaps(merged, values)
+	}
+	return merged, nil
+}
+
+// shortSourceRevision returns the given revision of a source of the given
+// kind in a short format, suitable for use in the metadata of a chart.
+func shortSourceRevision(kind, revision string) string {
+	rev := revision
+	if kind == sourcev1.GitRepositoryKind {
+		// Split the reference by the `/` delimiter which may be present,
+		// and take the last entry which contains the SHA.
+		split := strings.Split(revision, "/")
+		rev = split[len(split)-1]
+	}
+	if kind == sourcev1.GitRepositoryKind || kind == sourcev1.BucketKind {
+		// The SemVer from the metadata is at times used in e.g. the label metadata for a resource
+		// in a chart, which has a limited length of 63 characters.
+		// To not fill most of this space with a full length SHA hex (40 characters for SHA-1, and
+		// even more for SHA-2 for a chart from a Bucket), we shorten this to the first 12
+		// characters taken from the hex.
+		// For SHA-1, this has proven to be unique in the Linux kernel with over 875.000 commits
+		// (http://git-scm.com/book/en/v2/Git-Tools-Revision-Selection#Short-SHA-1).
+		// Note that for a collision to be problematic, it would need to happen right after the
+		// previous SHA for the artifact, which is highly unlikely, if not virtually impossible.
+		// Ref: https://en.wikipedia.org/wiki/Birthday_attack
+		if len(rev) > 12 {
+			rev = rev[0:12]
+		}
+	}
+	return rev
+}
+
 // reconcileArtifact archives a new Artifact to the Storage, if the current
 // (Status) data on the object does not match the given.
 //
@@ -845,17 +1272,26 @@ func (r *HelmChartReconciler) reconcileArtifact(ctx context.Context, sp *patch.S
 
 	// Set the ArtifactInStorageCondition if there's no drift.
 	defer func() {
-		if obj.Status.ObservedChartName == b.Name && obj.GetArtifact().HasRevision(b.Version) {
+		if !chartBuildDrifted(obj, b) {
 			conditions.Delete(obj, sourcev1.ArtifactOutdatedCondition)
 			conditions.MarkTrue(obj, sourcev1.ArtifactInStorageCondition, reasonForBuild(b), b.Summary())
 		}
 	}()
 
 	// Create artifact from build data
-	artifact := r.Storage.NewArtifactFor(obj.Kind, obj.GetObjectMeta(), b.Version, fmt.Sprintf("%s-%s.tgz", b.Name, b.Version))
+	artifact := r.Storage.NewArtifactFor(obj.Kind, obj.GetObjectMeta(), chartArtifactRevision(obj, b), fmt.Sprintf("%s-%s.tgz", b.Name, b.Version))
+	artifact.Digest = pinnedChartDigest(obj)
 
 	// Return early if the build path equals the current artifact path
 	if curArtifact := obj.GetArtifact(); curArtifact != nil && r.Storage.LocalPath(*curArtifact) == b.Path {
+		// Record the digest and versions for artifacts built before they were
+		// observed, and the digest the chart is pinned to for artifacts
+		// stored before it was pinned
+		curArtifact.Revision = artifact.Revision
+		curArtifact.Digest = artifact.Digest
+		obj.Status.ObservedChartDigest = b.Digest
+		obj.Status.ObservedChartVersion = b.Version
+		obj.Status.AppVersion = chartAppVersion(b)
 		r.eventLogf(ctx, obj, eventv1.EventTypeTrace, sourcev1.ArtifactUpToDateReason, "artifact up-to-date with remote revision: '%s'", artifact.Revision)
 		return sreconcile.ResultSuccess, nil
 	}
@@ -863,6 +1299,21 @@ func (r *HelmChartReconciler) reconcileArtifact(ctx context.Context, sp *patch.S
 	// Garbage collect chart build once persisted to storage
 	defer os.Remove(b.Path)
 
+	// Reuse the current artifact if the chart was packaged again with the
+	// same version and content, e.g. after a change of the object which does
+	// not affect the chart, as the packaged files then only differ by their
+	// modification times. The checksum is computed on a "best effort" basis,
+	// the chart is stored again if it can not be computed
+	contentChecksum, _ := r.Storage.TarballContentChecksum(b.Path)
+	if curArtifact := obj.GetArtifact(); curArtifact != nil && contentChecksum != "" &&
+		curArtifact.ContentChecksum == contentChecksum && curArtifact.Path == artifact.Path &&
+		curArtifact.HasRevision(artifact.Revision) && r.Storage.ArtifactExist(*curArtifact) {
+		observeChartArtifact(obj, b)
+		r.eventLogf(ctx, obj, eventv1.EventTypeTrace, sourcev1.ArtifactUpToDateReason,
+			"artifact up-to-date with the content of version: '%s'", artifact.Revision)
+		return sreconcile.ResultSuccess, nil
+	}
+
 	// Ensure artifact directory exists and acquire lock
 	if err := r.Storage.MkdirAll(artifact); err != nil {
 		e := &serror.Event{
@@ -872,7 +1323,7 @@ func (r *HelmChartReconciler) reconcileArtifact(ctx context.Context, sp *patch.S
 		conditions.MarkTrue(obj, sourcev1.StorageOperationFailedCondition, e.Reason, e.Err.Error())
 		return sreconcile.ResultEmpty, e
 	}
-	unlock, err := r.Storage.Lock(artifact)
+	unlock, err := r.Storage.LockWithContext(ctx, artifact)
 	if err != nil {
 		e := &serror.Event{
 			Err:    fmt.Errorf("failed to acquire lock for artifact: %w", err),
@@ -893,9 +1344,12 @@ func (r *HelmChartReconciler) reconcileArtifact(ctx context.Context, sp *patch.S
 		return sreconcile.ResultEmpty, e
 	}
 
-	// Record it on the object
+	artifact.ContentChecksum = contentChecksum
+
+	// Record it on the object, retaining the previous Artifact in the history
+	obj.Status.History = artifactHistory(obj.Status.History, obj.GetArtifact(), &artifact, obj.Spec.HistoryLimit)
 	obj.Status.Artifact = artifact.DeepCopy()
-	obj.Status.ObservedChartName = b.Name
+	observeChartArtifact(obj, b)
 
 	// Update symlink on a "best effort" basis
 	symURL, err := r.Storage.Symlink(artifact, "latest.tar.gz")
@@ -910,6 +1364,51 @@ func (r *HelmChartReconciler) reconcileArtifact(ctx context.Context, sp *patch.S
 	return sreconcile.ResultSuccess, nil
 }
 
+// observeChartArtifact records the observations on the chart of the given
+// build, which the Artifact of the object holds, on the object.
+func observeChartArtifact(obj *sourcev1.HelmChart, b *chart.Build) {
+	obj.Status.ObservedChartName = b.Name
+	obj.Status.ObservedChartVersion = b.Version
+	obj.Status.AppVersion = chartAppVersion(b)
+	obj.Status.ObservedChartDigest = b.Digest
+	obj.Status.ResolvedDependencies = resolvedDependencies(b.Dependencies)
+	obj.Status.ObservedDependencyRevisions = b.DependencyRevisions
+	obj.Status.ObservedValuesFiles = b.ValuesFiles
+}
+
+// chartAppVersion returns the appVersion of the chart of the given build,
+// which is the appVersion it was built with if set, or the appVersion in the
+// metadata of the packaged chart otherwise. It returns an empty string if the
+// metadata can not be loaded.
+func chartAppVersion(b *chart.Build) string {
+	if b.AppVersion != "" {
+		return b.AppVersion
+	}
+	meta, err := chart.LoadChartMetadataFromArchive(b.Path)
+	if err != nil {
+		return ""
+	}
+	return meta.AppVersion
+}
+
+// resolvedDependencies returns the given dependencies resolved by the chart
+// builder as a list of v1beta2.HelmChartDependency, or nil if there are none.
+func resolvedDependencies(deps []chart.ResolvedDependency) []sourcev1.HelmChartDependency {
+	if len(deps) == 0 {
+		return nil
+	}
+	res := make([]sourcev1.HelmChartDependency, 0, len(deps))
+	for _, dep := range deps {
+		res = append(res, sourcev1.HelmChartDependency{
+			Name:       dep.Name,
+			Version:    dep.Version,
+			Repository: dep.Repository,
+			Digest:     dep.Digest,
+		})
+	}
+	return res
+}
+
 // getSource returns the v1beta1.Source for the given object, or an error describing why the source could not be
 // returned.
 func (r *HelmChartReconciler) getSource(ctx context.Context, obj *sourcev1.HelmChart) (sourcev1.Source, error) {
@@ -956,6 +1455,7 @@ func (r *HelmChartReconciler) reconcileDelete(ctx context.Context, obj *sourcev1
 
 	// Remove our finalizer from the list
 	controllerutil.RemoveFinalizer(obj, sourcev1.SourceFinalizer)
+	r.indexCacheEvictions.forget(obj)
 
 	// Stop reconciliation as the object is being deleted
 	return sreconcile.ResultEmpty, nil
@@ -963,7 +1463,8 @@ func (r *HelmChartReconciler) reconcileDelete(ctx context.Context, obj *sourcev1
 
 // garbageCollect performs a garbage collection for the given object.
 //
-// It removes all but the current Artifact from the Storage, unless the
+// It removes all but the current Artifact and the number of previous
+// Artifacts configured by the history limit from the Storage, unless the
 // deletion timestamp on the object is set. Which will result in the
 // removal of all Artifacts for the objects.
 func (r *HelmChartReconciler) garbageCollect(ctx context.Context, obj *sourcev1.HelmChart) error {
@@ -978,33 +1479,53 @@ func (r *HelmChartReconciler) garbageCollect(ctx context.Context, obj *sourcev1.
 				"garbage collected artifacts for deleted resource")
 		}
 		obj.Status.Artifact = nil
+		obj.Status.History = nil
 		return nil
 	}
 	if obj.GetArtifact() != nil {
-		delFiles, err := r.Storage.GarbageCollect(ctx, *obj.GetArtifact(), time.Second*5)
+		delFiles, retained, err := r.Storage.GarbageCollectWithOptions(ctx, *obj.GetArtifact(), obj.Status.History,
+			obj.Spec.HistoryLimit, r.garbageCollectOptions)
 		if err != nil {
 			return &serror.Event{
 				Err:    fmt.Errorf("garbage collection of artifacts failed: %w", err),
 				Reason: "GarbageCollectionFailed",
 			}
 		}
+		// Forget the previous Artifacts which are no longer in the Storage.
+		var history []sourcev1.Artifact
+		for _, a := range artifactHistory(obj.Status.History, nil, obj.GetArtifact(), obj.Spec.HistoryLimit) {
+			if r.Storage.ArtifactExist(a) {
+				history = append(history, a)
+			}
+		}
+		obj.Status.History = history
 		if len(delFiles) > 0 {
 			r.eventLogf(ctx, obj, eventv1.EventTypeTrace, "GarbageCollectionSucceeded",
-				fmt.Sprintf("garbage collected %d artifacts", len(delFiles)))
+				fmt.Sprintf("garbage collected %d artifacts, retained %d", len(delFiles), retained))
 			return nil
 		}
 	}
 	return nil
 }
 
-// namespacedChartRepositoryCallback returns a chart.GetChartDownloaderCallback scoped to the given namespace.
+// namespacedChartRepositoryCallback returns a chart.GetChartDownloaderCallback scoped to the namespace of the given object.
 // The returned callback returns a repository.Downloader configured with the retrieved v1beta1.HelmRepository,
 // or a shim with defaults if no object could be found.
+// If the object verifies its dependencies, the repository.Downloader is configured with the verifiers or the
+// keyring of the verification provider matching the type of the repository.
 // The callback returns an object with a state, so the caller has to do the necessary cleanup.
-func (r *HelmChartReconciler) namespacedChartRepositoryCallback(ctx context.Context, name, namespace string) chart.GetChartDownloaderCallback {
+// The revisions of the retrieved v1beta2.HelmRepository objects are recorded to the given
+// dependencyRevisionRecorder, if not nil.
+func (r *HelmChartReconciler) namespacedChartRepositoryCallback(ctx context.Context, obj *sourcev1.HelmChart, revisions *dependencyRevisionRecorder) chart.GetChartDownloaderCallback {
+	name, namespace := obj.GetName(), obj.GetNamespace()
+	verifyProvider := ""
+	if obj.Spec.Verify != nil && obj.Spec.VerifyDependencies {
+		verifyProvider = obj.Spec.Verify.Provider
+	}
 	return func(url string) (repository.Downloader, error) {
 		var (
 			tlsConfig     *tls.Config
+			bearerToken   string
 			authenticator authn.Authenticator
 			keychain      authn.Keychain
 		)
@@ -1021,6 +1542,8 @@ func (r *HelmChartReconciler) namespacedChartRepositoryCallback(ctx context.Cont
 					Timeout: &metav1.Duration{Duration: 60 * time.Second},
 				},
 			}
+		} else {
+			revisions.record(repo)
 		}
 
 		// Used to login with the repository declared provider
@@ -1032,18 +1555,22 @@ func (r *HelmChartReconciler) namespacedChartRepositoryCallback(ctx context.Cont
 			helmgetter.WithTimeout(repo.Spec.Timeout.Duration),
 			helmgetter.WithPassCredentialsAll(repo.Spec.PassCredentials),
 		}
+		if r.UserAgent != "" {
+			clientOpts = append(clientOpts, helmgetter.WithUserAgent(r.UserAgent))
+		}
 		if secret, err := r.getHelmRepositorySecret(ctx, repo); secret != nil || err != nil {
 			if err != nil {
 				return nil, err
 			}
 
 			// Build client options from secret
-			opts, tls, err := r.clientOptionsFromSecret(secret, normalizedURL)
+			opts, tls, token, err := r.clientOptionsFromSecret(secret, normalizedURL)
 			if err != nil {
 				return nil, err
 			}
 			clientOpts = append(clientOpts, opts...)
 			tlsConfig = tls
+			bearerToken = token
 
 			// Build registryClient options from secret
 			keychain, err = registry.LoginOptionFromSecret(normalizedURL, *secret)
@@ -1051,13 +1578,19 @@ func (r *HelmChartReconciler) namespacedChartRepositoryCallback(ctx context.Cont
 				return nil, fmt.Errorf("failed to create login options for HelmRepository '%s': %w", repo.Name, err)
 			}
 
-		} else if repo.Spec.Provider != sourcev1.GenericOCIProvider && repo.Spec.Type == sourcev1.HelmRepositoryTypeOCI {
-			auth, authErr := oidcAuth(ctxTimeout, repo.Spec.URL, repo.Spec.Provider)
-			if authErr != nil && !errors.Is(authErr, oci.ErrUnconfiguredProvider) {
-				return nil, fmt.Errorf("failed to get credential from %s: %w", repo.Spec.Provider, authErr)
+		} else if repo.Spec.Type == sourcev1.HelmRepositoryTypeOCI {
+			provider, err := ociProvider(ctx, r.Client, repo.GetNamespace(), repo.Spec.Provider)
+			if err != nil {
+				return nil, err
 			}
-			if auth != nil {
-				authenticator = auth
+			if provider != sourcev1.GenericOCIProvider {
+				auth, authErr := oidcAuth(ctxTimeout, repo.Spec.URL, provider)
+				if authErr != nil && !errors.Is(authErr, oci.ErrUnconfiguredProvider) {
+					return nil, fmt.Errorf("failed to get credential from %s: %w", provider, authErr)
+				}
+				if auth != nil {
+					authenticator = auth
+				}
 			}
 		}
 
@@ -1068,18 +1601,50 @@ func (r *HelmChartReconciler) namespacedChartRepositoryCallback(ctx context.Cont
 
 		var chartRepo repository.Downloader
 		if helmreg.IsOCI(normalizedURL) {
-			registryClient, credentialsFile, err := r.RegistryClientGenerator(loginOpt != nil)
+			if err := checkRegistryDomain(r.AllowedRegistryDomains, normalizedURL); err != nil {
+				return nil, fmt.Errorf("failed to pull dependency from '%s': %w", url, err)
+			}
+
+			registryTLSConfig, err := tlsConfigFromCertSecret(ctx, r.Client, repo)
 			if err != nil {
-				return nil, fmt.Errorf("failed to create registry client for HelmRepository '%s': %w", repo.Name, err)
+				return nil, fmt.Errorf("failed to configure TLS for HelmRepository '%s': %w", repo.Name, err)
+			}
+
+			var verifiers []soci.Verifier
+			if verifyProvider == "cosign" {
+				if verifiers, err = r.makeVerifiers(ctx, obj, authenticator, keychain, registryTLSConfig); err != nil {
+					return nil, fmt.Errorf("failed to create verifiers for HelmRepository '%s': %w", repo.Name, err)
+				}
+			}
+
+			ociChartRepoOpts := []repository.OCIChartRepositoryOption{
+				repository.WithOCIGetter(r.Getters),
+				repository.WithVerifiers(verifiers),
+			}
+			if registryTLSConfig != nil {
+				// The Helm registry client does not allow configuring its
+				// transport, a custom TLS configuration requires a remote
+				// client instead. It outlives this callback, and thereby the
+				// timeout context.
+				remoteClient := registry.NewRemoteClient(ctx, registryTLSConfig, authenticator, keychain)
+				ociChartRepoOpts = append(ociChartRepoOpts,
+					repository.WithOCIRegistryClient(remoteClient),
+					repository.WithOCIClient(remoteClient))
+			} else {
+				registryClient, credentialsFile, err := r.RegistryClientGenerator(loginOpt != nil)
+				if err != nil {
+					return nil, fmt.Errorf("failed to create registry client for HelmRepository '%s': %w", repo.Name, err)
+				}
+				// Tell the chart repository to use the OCI client with the configured getter
+				clientOpts = append(clientOpts, helmgetter.WithRegistryClient(registryClient))
+				ociChartRepoOpts = append(ociChartRepoOpts,
+					repository.WithOCIGetterOptions(clientOpts),
+					repository.WithOCIRegistryClient(registryClient),
+					repository.WithCredentialsFile(credentialsFile))
 			}
 
 			var errs []error
-			// Tell the chart repository to use the OCI client with the configured getter
-			clientOpts = append(clientOpts, helmgetter.WithRegistryClient(registryClient))
-			ociChartRepo, err := repository.NewOCIChartRepository(normalizedURL, repository.WithOCIGetter(r.Getters),
-				repository.WithOCIGetterOptions(clientOpts),
-				repository.WithOCIRegistryClient(registryClient),
-				repository.WithCredentialsFile(credentialsFile))
+			ociChartRepo, err := repository.NewOCIChartRepository(normalizedURL, ociChartRepoOpts...)
 			if err != nil {
 				errs = append(errs, fmt.Errorf("failed to create OCI chart repository for HelmRepository '%s': %w", repo.Name, err))
 				// clean up the credentialsFile
@@ -1105,7 +1670,20 @@ func (r *HelmChartReconciler) namespacedChartRepositoryCallback(ctx context.Cont
 
 			chartRepo = ociChartRepo
 		} else {
-			httpChartRepo, err := repository.NewChartRepository(normalizedURL, "", r.Getters, tlsConfig, clientOpts)
+			var chartRepoOpts []repository.ChartRepositoryOption
+			if verifyProvider == "pgp" {
+				keyring, err := r.makeKeyring(ctx, obj)
+				if err != nil {
+					return nil, fmt.Errorf("failed to create keyring for HelmRepository '%s': %w", repo.Name, err)
+				}
+				chartRepoOpts = append(chartRepoOpts, repository.WithProvenanceKeyring(keyring))
+			}
+			if bearerToken != "" {
+				chartRepoOpts = append(chartRepoOpts, repository.WithBearerToken(bearerToken, repo.Spec.PassCredentials))
+			}
+
+			httpChartRepo, err := repository.NewChartRepository(normalizedURL, "", r.Getters, tlsConfig, clientOpts,
+				chartRepoOpts...)
 			if err != nil {
 				return nil, err
 			}
@@ -1127,35 +1705,50 @@ func (r *HelmChartReconciler) namespacedChartRepositoryCallback(ctx context.Cont
 	}
 }
 
+// resolveDependencyRepository returns the HelmRepository in the given
+// namespace of which the normalized URL matches the given dependency URL.
+// For OCI URLs, only HelmRepositories of type OCI are taken into account.
 func (r *HelmChartReconciler) resolveDependencyRepository(ctx context.Context, url string, namespace string) (*sourcev1.HelmRepository, error) {
+	normalizedURL := repository.NormalizeURL(url)
 	listOpts := []client.ListOption{
 		client.InNamespace(namespace),
-		client.MatchingFields{sourcev1.HelmRepositoryURLIndexKey: url},
-		client.Limit(1),
+		client.MatchingFields{sourcev1.HelmRepositoryURLIndexKey: normalizedURL},
 	}
 	var list sourcev1.HelmRepositoryList
 	err := r.Client.List(ctx, &list, listOpts...)
 	if err != nil {
 		return nil, fmt.Errorf("unable to retrieve HelmRepositoryList: %w", err)
 	}
-	if len(list.Items) > 0 {
-		return &list.Items[0], nil
+	isOCI := helmreg.IsOCI(normalizedURL)
+	for i := range list.Items {
+		if isOCI && list.Items[i].Spec.Type != sourcev1.HelmRepositoryTypeOCI {
+			continue
+		}
+		return &list.Items[i], nil
 	}
 	return nil, fmt.Errorf("no HelmRepository found for '%s' in '%s' namespace", url, namespace)
 }
 
-func (r *HelmChartReconciler) clientOptionsFromSecret(secret *corev1.Secret, normalizedURL string) ([]helmgetter.Option, *tls.Config, error) {
+// clientOptionsFromSecret returns the Helm getter options, the TLS client
+// config and the bearer token for the repository with the given normalized
+// URL from the given Secret.
+func (r *HelmChartReconciler) clientOptionsFromSecret(secret *corev1.Secret, normalizedURL string) ([]helmgetter.Option, *tls.Config, string, error) {
 	opts, err := getter.ClientOptionsFromSecret(*secret)
 	if err != nil {
-		return nil, nil, fmt.Errorf("failed to configure Helm client with secret data: %w", err)
+		return nil, nil, "", fmt.Errorf("failed to configure Helm client with secret data: %w", err)
+	}
+
+	bearerToken, err := getter.BearerTokenFromSecret(*secret)
+	if err != nil {
+		return nil, nil, "", fmt.Errorf("failed to configure Helm client with secret data: %w", err)
 	}
 
 	tlsConfig, err := getter.TLSClientConfigFromSecret(*secret, normalizedURL)
 	if err != nil {
-		return nil, nil, fmt.Errorf("failed to create TLS client config with secret data: %w", err)
+		return nil, nil, "", fmt.Errorf("failed to create TLS client config with secret data: %w", err)
 	}
 
-	return opts, tlsConfig, nil
+	return opts, tlsConfig, bearerToken, nil
 }
 
 func (r *HelmChartReconciler) getHelmRepositorySecret(ctx context.Context, repository *sourcev1.HelmRepository) (*corev1.Secret, error) {
@@ -1194,6 +1787,53 @@ func (r *HelmChartReconciler) indexHelmChartBySource(o client.Object) []string {
 	return []string{fmt.Sprintf("%s/%s", hc.Spec.SourceRef.Kind, hc.Spec.SourceRef.Name)}
 }
 
+func (r *HelmChartReconciler) indexHelmChartByDependencyRepository(o client.Object) []string {
+	hc, ok := o.(*sourcev1.HelmChart)
+	if !ok {
+		panic(fmt.Sprintf("Expected a HelmChart, got %T", o))
+	}
+	names := make([]string, 0, len(hc.Status.ObservedDependencyRevisions))
+	for name := range hc.Status.ObservedDependencyRevisions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (r *HelmChartReconciler) indexHelmChartByValuesFrom(o client.Object) []string {
+	hc, ok := o.(*sourcev1.HelmChart)
+	if !ok {
+		panic(fmt.Sprintf("Expected a HelmChart, got %T", o))
+	}
+	var names []string
+	for _, ref := range hc.Spec.ValuesFrom {
+		if ref.Kind == "ConfigMap" {
+			names = append(names, ref.Name)
+		}
+	}
+	return names
+}
+
+func (r *HelmChartReconciler) requestsForValuesFromChange(o client.Object) []reconcile.Request {
+	cm, ok := o.(*corev1.ConfigMap)
+	if !ok {
+		panic(fmt.Sprintf("Expected a ConfigMap, got %T", o))
+	}
+
+	var list sourcev1.HelmChartList
+	if err := r.List(context.TODO(), &list, client.InNamespace(cm.Namespace), client.MatchingFields{
+		sourcev1.HelmChartValuesFromIndexKey: cm.Name,
+	}); err != nil {
+		return nil
+	}
+
+	reqs := make([]reconcile.Request, 0, len(list.Items))
+	for _, i := range list.Items {
+		reqs = append(reqs, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(&i)})
+	}
+	return reqs
+}
+
 func (r *HelmChartReconciler) requestsForHelmRepositoryChange(o client.Object) []reconcile.Request {
 	repo, ok := o.(*sourcev1.HelmRepository)
 	if !ok {
@@ -1218,6 +1858,19 @@ func (r *HelmChartReconciler) requestsForHelmRepositoryChange(o client.Object) [
 			reqs = append(reqs, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(&i)})
 		}
 	}
+
+	// Rebuild the charts with dependencies resolved from the repository
+	var dependents sourcev1.HelmChartList
+	if err := r.List(ctx, &dependents, client.InNamespace(repo.Namespace), client.MatchingFields{
+		sourcev1.HelmChartDependencyRepositoryIndexKey: repo.Name,
+	}); err != nil {
+		return reqs
+	}
+	for _, i := range dependents.Items {
+		if rev, ok := i.Status.ObservedDependencyRevisions[repo.Name]; ok && rev != repo.GetArtifact().Revision {
+			reqs = append(reqs, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(&i)})
+		}
+	}
 	return reqs
 }
 
@@ -1291,10 +1944,120 @@ func (r *HelmChartReconciler) eventLogf(ctx context.Context, obj runtime.Object,
 	r.Eventf(obj, eventType, reason, msg)
 }
 
+// dependencyRevisionRecorder records the revisions of the HelmRepositories
+// the dependencies of a chart are resolved from. It is safe for concurrent
+// use by the DependencyManager.
+type dependencyRevisionRecorder struct {
+	mu        sync.Mutex
+	revisions map[string]string
+}
+
+// record records the revision of the Artifact of the given HelmRepository,
+// if it has one.
+func (d *dependencyRevisionRecorder) record(repo *sourcev1.HelmRepository) {
+	if d == nil || repo.GetArtifact() == nil {
+		return
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.revisions == nil {
+		d.revisions = make(map[string]string)
+	}
+	d.revisions[repo.Name] = repo.GetArtifact().Revision
+}
+
+// dependencyRevisionsDrifted returns true if the Artifact revision of any of
+// the HelmRepositories the dependencies of the chart of the current Artifact
+// of the object were resolved from differs from the observed revision.
+// HelmRepositories which can not be retrieved are ignored.
+func (r *HelmChartReconciler) dependencyRevisionsDrifted(ctx context.Context, obj *sourcev1.HelmChart) bool {
+	for name, revision := range obj.Status.ObservedDependencyRevisions {
+		var repo sourcev1.HelmRepository
+		if err := r.Get(ctx, types.NamespacedName{Namespace: obj.GetNamespace(), Name: name}, &repo); err != nil {
+			continue
+		}
+		if artifact := repo.GetArtifact(); artifact != nil && artifact.Revision != revision {
+			return true
+		}
+	}
+	return false
+}
+
+// chartArtifactRevision returns the Artifact revision of the given build of
+// the object, which is the version of the chart followed by the digest the
+// chart is pinned to, if any, e.g. '1.0.0@sha256:<hex>'.
+func chartArtifactRevision(obj *sourcev1.HelmChart, build *chart.Build) string {
+	if digest := pinnedChartDigest(obj); digest != "" {
+		return build.Version + "@" + digest
+	}
+	return build.Version
+}
+
+// pinnedChartDigest returns the digest the chart of the object is pinned to
+// in a HelmRepository, or an empty string if it is not pinned.
+func pinnedChartDigest(obj *sourcev1.HelmChart) string {
+	if obj.Spec.SourceRef.Kind != sourcev1.HelmRepositoryKind {
+		return ""
+	}
+	_, digest := repository.SplitVersionDigest(obj.Spec.Version)
+	return digest
+}
+
+// chartBuildDrifted returns true if the given build differs from the chart
+// the current Artifact of the object was built from, by name, version or
+// digest of the chart version in the repository index, or by the digest the
+// chart is pinned to.
+func chartBuildDrifted(obj *sourcev1.HelmChart, build *chart.Build) bool {
+	if build.Name != obj.Status.ObservedChartName || !obj.GetArtifact().HasRevision(chartArtifactRevision(obj, build)) {
+		return true
+	}
+	return build.Digest != "" && obj.Status.ObservedChartDigest != "" && build.Digest != obj.Status.ObservedChartDigest
+}
+
+// chartBuildWarningReason is the event reason used to surface the warnings
+// of a chart build.
+const chartBuildWarningReason = "ChartBuildWarning"
+
+// maxChartBuildWarningsLength is the maximum length of the warnings listed in
+// the event of a chart build, to stay within the size limit of an event.
+const maxChartBuildWarningsLength = 768
+
+// chartBuildWarningsMessage joins the given warnings for the event of a chart
+// build, up to maxChartBuildWarningsLength. The warnings which do not fit are
+// counted instead.
+func chartBuildWarningsMessage(warnings []string) string {
+	var b strings.Builder
+	listed := 0
+	for _, w := range warnings {
+		sep := ""
+		if listed > 0 {
+			sep = "; "
+		}
+		if b.Len()+len(sep)+len(w) > maxChartBuildWarningsLength {
+			// Truncate a single warning which does not fit
+			if listed == 0 {
+				b.WriteString(w[:maxChartBuildWarningsLength] + "...")
+				listed++
+			}
+			break
+		}
+		b.WriteString(sep + w)
+		listed++
+	}
+	if rest := len(warnings) - listed; rest > 0 {
+		fmt.Fprintf(&b, " (and %d more)", rest)
+	}
+	return b.String()
+}
+
+// chartBuildSummaryMetadataKey is the key of the event metadata holding the
+// JSON summary of the chart build, see chart.Build.SummaryJSON.
+const chartBuildSummaryMetadataKey = "build-summary"
+
 // observeChartBuild records the observation on the given given build and error on the object.
 func observeChartBuild(ctx context.Context, sp *patch.SerialPatcher, pOpts []patch.Option, obj *sourcev1.HelmChart, build *chart.Build, err error) {
 	if build.HasMetadata() {
-		if build.Name != obj.Status.ObservedChartName || !obj.GetArtifact().HasRevision(build.Version) {
+		if chartBuildDrifted(obj, build) {
 			if obj.GetArtifact() != nil {
 				conditions.MarkTrue(obj, sourcev1.ArtifactOutdatedCondition, "NewChart", build.Summary())
 			}
@@ -1325,7 +2088,8 @@ func observeChartBuild(ctx context.Context, sp *patch.SerialPatcher, pOpts []pat
 		}
 
 		switch buildErr.Reason {
-		case chart.ErrChartMetadataPatch, chart.ErrValuesFilesMerge, chart.ErrDependencyBuild, chart.ErrChartPackage:
+		case chart.ErrChartMetadataPatch, chart.ErrValuesFilesMerge, chart.ErrDependencyBuild, chart.ErrChartPackage,
+			chart.ErrMemoryBudget:
 			conditions.Delete(obj, sourcev1.FetchFailedCondition)
 			conditions.MarkTrue(obj, sourcev1.BuildFailedCondition, buildErr.Reason.Reason, buildErr.Error())
 		case chart.ErrChartVerification:
@@ -1350,6 +2114,23 @@ func reasonForBuild(build *chart.Build) string {
 	return sourcev1.ChartPullSucceededReason
 }
 
+// valuesFromErrorReturn marks the given object with a FetchFailed Condition
+// for the given error returned by setValuesFrom, and returns it as a Stalling
+// error when it can not be recovered from by a retry.
+func valuesFromErrorReturn(err error, obj *sourcev1.HelmChart) (sreconcile.Result, error) {
+	var stalling *serror.Stalling
+	if errors.As(err, &stalling) {
+		conditions.MarkTrue(obj, sourcev1.FetchFailedCondition, stalling.Reason, stalling.Err.Error())
+		return sreconcile.ResultEmpty, stalling
+	}
+	e := &serror.Event{
+		Err:    err,
+		Reason: sourcev1.ValuesFromFailedReason,
+	}
+	conditions.MarkTrue(obj, sourcev1.FetchFailedCondition, e.Reason, e.Err.Error())
+	return sreconcile.ResultEmpty, e
+}
+
 func chartRepoConfigErrorReturn(err error, obj *sourcev1.HelmChart) (sreconcile.Result, error) {
 	switch err.(type) {
 	case *url.Error:
@@ -1370,7 +2151,7 @@ func chartRepoConfigErrorReturn(err error, obj *sourcev1.HelmChart) (sreconcile.
 }
 
 // makeVerifiers returns a list of verifiers for the given chart.
-func (r *HelmChartReconciler) makeVerifiers(ctx context.Context, obj *sourcev1.HelmChart, auth authn.Authenticator, keychain authn.Keychain) ([]soci.Verifier, error) {
+func (r *HelmChartReconciler) makeVerifiers(ctx context.Context, obj *sourcev1.HelmChart, auth authn.Authenticator, keychain authn.Keychain, tlsConfig *tls.Config) ([]soci.Verifier, error) {
 	var verifiers []soci.Verifier
 	verifyOpts := []remote.Option{}
 	if auth != nil {
@@ -1378,6 +2159,9 @@ func (r *HelmChartReconciler) makeVerifiers(ctx context.Context, obj *sourcev1.H
 	} else {
 		verifyOpts = append(verifyOpts, remote.WithAuthFromKeychain(keychain))
 	}
+	if tlsConfig != nil {
+		verifyOpts = append(verifyOpts, remote.WithTransport(registry.NewTLSTransport(tlsConfig)))
+	}
 
 	switch obj.Spec.Verify.Provider {
 	case "cosign":
@@ -1415,7 +2199,14 @@ func (r *HelmChartReconciler) makeVerifiers(ctx context.Context, obj *sourcev1.H
 		}
 
 		// if no secret is provided, add a keyless verifier
-		verifier, err := soci.NewCosignVerifier(ctx, defaultCosignOciOpts...)
+		if r.DisableKeylessVerification {
+			return nil, errKeylessVerificationDisabled
+		}
+		keylessOpts, err := cosignKeylessOptions(ctx, r, obj.Namespace, obj.Spec.Verify, r.DisableRekorLookups)
+		if err != nil {
+			return nil, err
+		}
+		verifier, err := soci.NewCosignVerifier(ctx, append(defaultCosignOciOpts, keylessOpts...)...)
 		if err != nil {
 			return nil, err
 		}
@@ -1425,3 +2216,78 @@ func (r *HelmChartReconciler) makeVerifiers(ctx context.Context, obj *sourcev1.H
 		return nil, fmt.Errorf("unsupported verification provider: %s", obj.Spec.Verify.Provider)
 	}
 }
+
+// unsupportedVerificationError returns an error if the verification spec of
+// the given object sets a field of the OCIRepositoryVerification type which
+// is only supported by OCIRepositories.
+func unsupportedVerificationError(obj *sourcev1.HelmChart) error {
+	if obj.Spec.Verify == nil {
+		return nil
+	}
+	if obj.Spec.Verify.Attestation != nil {
+		return errors.New("'.spec.verify.attestation' is not supported for HelmCharts")
+	}
+	if obj.Spec.Verify.Interval != nil {
+		return errors.New("'.spec.verify.interval' is not supported for HelmCharts, the signature is verified at every reconciliation")
+	}
+	return nil
+}
+
+// unsupportedVerificationProviderReturn marks the given object as failing
+// the verification with a provider which is not supported for a
+// HelmRepository of the given type, and returns a Stalling error as this can
+// only be solved by a change of the spec.
+func unsupportedVerificationProviderReturn(obj *sourcev1.HelmChart, repositoryType string) (sreconcile.Result, error) {
+	e := &serror.Stalling{
+		Err: fmt.Errorf("unsupported verification provider for a HelmRepository of type '%s': %s",
+			repositoryType, obj.Spec.Verify.Provider),
+		Reason: sourcev1.VerificationError,
+	}
+	conditions.MarkFalse(obj, sourcev1.SourceVerifiedCondition, e.Reason, e.Err.Error())
+	return sreconcile.ResultEmpty, e
+}
+
+// makeKeyring returns the PGP public keyring used to verify the provenance of
+// the chart of the given object, which is made of the keyrings with a '.gpg'
+// suffix in the Secret referenced by the verification spec.
+func (r *HelmChartReconciler) makeKeyring(ctx context.Context, obj *sourcev1.HelmChart) ([]byte, error) {
+	if obj.Spec.Verify.SecretRef == nil {
+		return nil, fmt.Errorf("a secret reference is required to verify the provenance")
+	}
+
+	secretName := types.NamespacedName{
+		Namespace: obj.Namespace,
+		Name:      obj.Spec.Verify.SecretRef.Name,
+	}
+	var secret corev1.Secret
+	if err := r.Get(ctx, secretName, &secret); err != nil {
+		return nil, err
+	}
+
+	keys := make([]string, 0, len(secret.Data))
+	for k := range secret.Data {
+		if strings.HasSuffix(k, ".gpg") {
+			keys = append(keys, k)
+		}
+	}
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("no keyrings found in secret '%s'", secretName)
+	}
+	sort.Strings(keys)
+
+	var keyring []byte
+	for _, k := range keys {
+		keyring = append(keyring, secret.Data[k]...)
+	}
+	return keyring, nil
+}
+
+// recordPull records the duration and the bytes downloaded by the pull of the
+// chart of the given object from an OCI registry with the RegistryRecorder,
+// if configured.
+func (r *HelmChartReconciler) recordPull(obj *sourcev1.HelmChart, start time.Time, bytes int64) {
+	if r.RegistryRecorder == nil {
+		return
+	}
+	r.RegistryRecorder.RecordPull(sourcev1.HelmChartKind, obj.GetName(), obj.GetNamespace(), start, bytes)
+}
@@ -17,7 +17,9 @@ limitations under the License.
 package controllers
 
 import (
+	"archive/tar"
 	"bytes"
+	"compress/gzip"
 	"context"
 	"encoding/base64"
 	"errors"
@@ -43,12 +45,15 @@ import (
 	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/tools/record"
 	kstatus "sigs.k8s.io/cli-utils/pkg/kstatus/status"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 	fakeclient "sigs.k8s.io/controller-runtime/pkg/client/fake"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
 	"github.com/fluxcd/pkg/apis/meta"
 	"github.com/fluxcd/pkg/helmtestserver"
@@ -713,6 +718,54 @@ func TestHelmChartReconciler_reconcileSource(t *testing.T) {
 	}
 }
 
+func TestHelmChartReconciler_reconcileSource_unsupportedVerification(t *testing.T) {
+	tests := []struct {
+		name    string
+		verify  *sourcev1.OCIRepositoryVerification
+		wantErr string
+	}{
+		{
+			name:    "attestation",
+			verify:  &sourcev1.OCIRepositoryVerification{Provider: "cosign", Attestation: &sourcev1.OCIAttestationVerification{}},
+			wantErr: "'.spec.verify.attestation' is not supported for HelmCharts",
+		},
+		{
+			name:    "interval",
+			verify:  &sourcev1.OCIRepositoryVerification{Provider: "cosign", Interval: &metav1.Duration{Duration: time.Hour}},
+			wantErr: "'.spec.verify.interval' is not supported for HelmCharts, the signature is verified at every reconciliation",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			obj := &sourcev1.HelmChart{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "chart",
+					Namespace: "default",
+				},
+				Spec: sourcev1.HelmChartSpec{
+					Verify: tt.verify,
+				},
+			}
+
+			r := &HelmChartReconciler{
+				EventRecorder: record.NewFakeRecorder(32),
+			}
+			var b chart.Build
+			got, err := r.reconcileSource(context.TODO(), nil, obj, &b)
+			g.Expect(got).To(Equal(sreconcile.ResultEmpty))
+			g.Expect(err).To(HaveOccurred())
+			var stalling *serror.Stalling
+			g.Expect(errors.As(err, &stalling)).To(BeTrue())
+			g.Expect(err.Error()).To(Equal(tt.wantErr))
+			g.Expect(obj.Status.Conditions).To(conditions.MatchConditions([]metav1.Condition{
+				*conditions.FalseCondition(sourcev1.SourceVerifiedCondition, sourcev1.VerificationError, tt.wantErr),
+			}))
+		})
+	}
+}
+
 func TestHelmChartReconciler_buildFromHelmRepository(t *testing.T) {
 	g := NewWithT(t)
 
@@ -732,6 +785,9 @@ func TestHelmChartReconciler_buildFromHelmRepository(t *testing.T) {
 	}
 	g.Expect(serverFactory.GenerateIndex()).To(Succeed())
 
+	keyring, err := os.ReadFile("../internal/helm/testdata/pubring.gpg")
+	g.Expect(err).NotTo(HaveOccurred())
+
 	type options struct {
 		username string
 		password string
@@ -903,6 +959,71 @@ func TestHelmChartReconciler_buildFromHelmRepository(t *testing.T) {
 			want:    sreconcile.ResultEmpty,
 			wantErr: &chart.BuildError{Err: errors.New("failed to get chart version for remote reference")},
 		},
+		{
+			name: "Stalling on unsupported verification provider",
+			beforeFunc: func(obj *sourcev1.HelmChart, _ *sourcev1.HelmRepository) {
+				obj.Spec.Chart = chartName
+				obj.Spec.Verify = &sourcev1.OCIRepositoryVerification{
+					Provider: "cosign",
+				}
+			},
+			want:    sreconcile.ResultEmpty,
+			wantErr: &serror.Stalling{Err: errors.New("unsupported verification provider for a HelmRepository of type 'default': cosign")},
+			assertFunc: func(g *WithT, obj *sourcev1.HelmChart, build chart.Build) {
+				g.Expect(build.Complete()).To(BeFalse())
+
+				g.Expect(obj.Status.Conditions).To(conditions.MatchConditions([]metav1.Condition{
+					*conditions.FalseCondition(sourcev1.SourceVerifiedCondition, sourcev1.VerificationError, "unsupported verification provider for a HelmRepository of type 'default': cosign"),
+				}))
+			},
+		},
+		{
+			name: "Event on secret without keyrings",
+			secret: &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "keyring",
+				},
+				Data: map[string][]byte{
+					"cosign.pub": []byte("foo"),
+				},
+			},
+			beforeFunc: func(obj *sourcev1.HelmChart, _ *sourcev1.HelmRepository) {
+				obj.Spec.Chart = chartName
+				obj.Spec.Verify = &sourcev1.OCIRepositoryVerification{
+					Provider:  "pgp",
+					SecretRef: &meta.LocalObjectReference{Name: "keyring"},
+				}
+			},
+			want:    sreconcile.ResultEmpty,
+			wantErr: &serror.Event{Err: errors.New("no keyrings found in secret '/keyring'")},
+			assertFunc: func(g *WithT, obj *sourcev1.HelmChart, build chart.Build) {
+				g.Expect(build.Complete()).To(BeFalse())
+
+				g.Expect(obj.Status.Conditions).To(conditions.MatchConditions([]metav1.Condition{
+					*conditions.FalseCondition(sourcev1.SourceVerifiedCondition, sourcev1.VerificationError, "failed to verify the provenance using provider 'pgp'"),
+				}))
+			},
+		},
+		{
+			name: "BuildError on missing provenance file",
+			secret: &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "keyring",
+				},
+				Data: map[string][]byte{
+					"pubring.gpg": keyring,
+				},
+			},
+			beforeFunc: func(obj *sourcev1.HelmChart, _ *sourcev1.HelmRepository) {
+				obj.Spec.Chart = chartName
+				obj.Spec.Verify = &sourcev1.OCIRepositoryVerification{
+					Provider:  "pgp",
+					SecretRef: &meta.LocalObjectReference{Name: "keyring"},
+				}
+			},
+			want:    sreconcile.ResultEmpty,
+			wantErr: &chart.BuildError{Err: errors.New("failed to download provenance file")},
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -1144,6 +1265,24 @@ func TestHelmChartReconciler_buildFromOCIHelmRepository(t *testing.T) {
 				}))
 			},
 		},
+		{
+			name: "Stalling on unsupported verification provider",
+			beforeFunc: func(obj *sourcev1.HelmChart, _ *sourcev1.HelmRepository) {
+				obj.Spec.Chart = metadata.Name
+				obj.Spec.Verify = &sourcev1.OCIRepositoryVerification{
+					Provider: "pgp",
+				}
+			},
+			want:    sreconcile.ResultEmpty,
+			wantErr: &serror.Stalling{Err: errors.New("unsupported verification provider for a HelmRepository of type 'oci': pgp")},
+			assertFunc: func(g *WithT, obj *sourcev1.HelmChart, build chart.Build) {
+				g.Expect(build.Complete()).To(BeFalse())
+
+				g.Expect(obj.Status.Conditions).To(conditions.MatchConditions([]metav1.Condition{
+					*conditions.FalseCondition(sourcev1.SourceVerifiedCondition, sourcev1.VerificationError, "unsupported verification provider for a HelmRepository of type 'oci': pgp"),
+				}))
+			},
+		},
 		{
 			name: "BuildError on temporary build error",
 			beforeFunc: func(obj *sourcev1.HelmChart, _ *sourcev1.HelmRepository) {
@@ -1237,14 +1376,83 @@ func TestHelmChartReconciler_buildFromTarballArtifact(t *testing.T) {
 	}
 	g.Expect(storage.CopyFromPath(cachedArtifact, "testdata/charts/helmchart-0.1.0.tgz")).To(Succeed())
 
+	hugeFileDir := t.TempDir()
+	g.Expect(os.WriteFile(filepath.Join(hugeFileDir, "huge.bin"), make([]byte, 2<<20), 0o600)).To(Succeed())
+	hugeFileArtifact := &sourcev1.Artifact{
+		Revision: "mock-ref/huge",
+		Path:     "huge.tgz",
+	}
+	g.Expect(storage.Archive(hugeFileArtifact, hugeFileDir, nil)).To(Succeed())
+
+	tinyFilesDir := t.TempDir()
+	for i := 0; i < 200; i++ {
+		g.Expect(os.WriteFile(filepath.Join(tinyFilesDir, fmt.Sprintf("file-%d", i)), []byte("a"), 0o600)).To(Succeed())
+	}
+	tinyFilesArtifact := &sourcev1.Artifact{
+		Revision: "mock-ref/tiny",
+		Path:     "tiny.tgz",
+	}
+	g.Expect(storage.Archive(tinyFilesArtifact, tinyFilesDir, nil)).To(Succeed())
+
+	indexDir := t.TempDir()
+	g.Expect(os.MkdirAll(filepath.Join(indexDir, "charts"), 0o700)).To(Succeed())
+	chartData, err := os.ReadFile("testdata/charts/helmchart-0.1.0.tgz")
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(os.WriteFile(filepath.Join(indexDir, "charts", "helmchart-0.1.0.tgz"), chartData, 0o600)).To(Succeed())
+	g.Expect(os.WriteFile(filepath.Join(indexDir, "index.yaml"), []byte(`apiVersion: v1
+entries:
+  helmchart:
+  - name: helmchart
+    version: 0.1.0
+    urls:
+    - charts/helmchart-0.1.0.tgz
+`), 0o600)).To(Succeed())
+	indexArtifact := &sourcev1.Artifact{
+		Revision: "index.yaml/abcdefg12345678",
+		Path:     "index.tgz",
+	}
+	g.Expect(storage.Archive(indexArtifact, indexDir, nil)).To(Succeed())
+
+	chartsTarball, err := os.ReadFile(storage.LocalPath(*chartsArtifact))
+	g.Expect(err).ToNot(HaveOccurred())
+	truncatedArtifact := &sourcev1.Artifact{
+		Revision: "mock-ref/truncated",
+		Path:     "truncated.tgz",
+	}
+	g.Expect(os.WriteFile(storage.LocalPath(*truncatedArtifact), chartsTarball[:len(chartsTarball)/2], 0o600)).To(Succeed())
+
+	submoduleDir := t.TempDir()
+	g.Expect(os.WriteFile(filepath.Join(submoduleDir, "README.md"), []byte("# README"), 0o600)).To(Succeed())
+	g.Expect(os.MkdirAll(filepath.Join(submoduleDir, "vendor", "charts"), 0o700)).To(Succeed())
+	submoduleArtifact := &sourcev1.Artifact{
+		Revision: "main/abcdefg12345678",
+		Path:     "submodule.tgz",
+	}
+	g.Expect(storage.Archive(submoduleArtifact, submoduleDir, nil)).To(Succeed())
+
+	var unsafeTarball bytes.Buffer
+	zw := gzip.NewWriter(&unsafeTarball)
+	tw := tar.NewWriter(zw)
+	g.Expect(tw.WriteHeader(&tar.Header{Name: "../../etc/passwd", Mode: 0o644, Typeflag: tar.TypeReg})).To(Succeed())
+	g.Expect(tw.Close()).To(Succeed())
+	g.Expect(zw.Close()).To(Succeed())
+	unsafeArtifact := &sourcev1.Artifact{
+		Revision: "mock-ref/unsafe",
+		Path:     "unsafe.tgz",
+	}
+	g.Expect(os.WriteFile(storage.LocalPath(*unsafeArtifact), unsafeTarball.Bytes(), 0o600)).To(Succeed())
+
 	tests := []struct {
-		name       string
-		source     sourcev1.Artifact
-		beforeFunc func(obj *sourcev1.HelmChart)
-		want       sreconcile.Result
-		wantErr    error
-		assertFunc func(g *WithT, build chart.Build)
-		cleanFunc  func(g *WithT, build *chart.Build)
+		name             string
+		source           sourcev1.Artifact
+		sourceMaxSize    int64
+		sourceMaxFiles   int
+		beforeFunc       func(obj *sourcev1.HelmChart)
+		want             sreconcile.Result
+		wantErr          error
+		assertFunc       func(g *WithT, build chart.Build)
+		assertConditions []metav1.Condition
+		cleanFunc        func(g *WithT, build *chart.Build)
 	}{
 		{
 			name:   "Resolves chart dependencies and builds",
@@ -1363,6 +1571,117 @@ func TestHelmChartReconciler_buildFromTarballArtifact(t *testing.T) {
 				g.Expect(build.Complete()).To(BeFalse())
 			},
 		},
+		{
+			name:   "Truncated source artifact",
+			source: *truncatedArtifact.DeepCopy(),
+			beforeFunc: func(obj *sourcev1.HelmChart) {
+				obj.Spec.Chart = "testdata/charts/helmchart"
+			},
+			want:    sreconcile.ResultEmpty,
+			wantErr: &serror.Event{Err: errors.New("artifact untar error: truncated tarball")},
+			assertFunc: func(g *WithT, build chart.Build) {
+				g.Expect(build.Complete()).To(BeFalse())
+			},
+			assertConditions: []metav1.Condition{
+				*conditions.TrueCondition(sourcev1.FetchFailedCondition, sourcev1.ArchiveTruncatedReason, "truncated tarball"),
+			},
+		},
+		{
+			name:   "Chart path in an empty Git submodule directory",
+			source: *submoduleArtifact.DeepCopy(),
+			beforeFunc: func(obj *sourcev1.HelmChart) {
+				obj.Spec.Chart = "vendor/charts/podinfo"
+				obj.Spec.SourceRef = sourcev1.LocalHelmChartSourceReference{
+					Kind: sourcev1.GitRepositoryKind,
+					Name: "podinfo",
+				}
+			},
+			want: sreconcile.ResultEmpty,
+			wantErr: &chart.BuildError{Err: errors.New("chart path 'vendor/charts/podinfo' points into the empty directory 'vendor/charts' of the source artifact: " +
+				"if it is a Git submodule, enable '.spec.recurseSubmodules' of GitRepository 'podinfo' to include its content")},
+			assertFunc: func(g *WithT, build chart.Build) {
+				g.Expect(build.Complete()).To(BeFalse())
+			},
+		},
+		{
+			name:   "Source artifact with entry outside of the directory",
+			source: *unsafeArtifact.DeepCopy(),
+			beforeFunc: func(obj *sourcev1.HelmChart) {
+				obj.Spec.Chart = "testdata/charts/helmchart"
+			},
+			want:    sreconcile.ResultEmpty,
+			wantErr: &serror.Event{Err: errors.New("artifact untar error: unsafe entry '../../etc/passwd' in tarball: path outside of the target directory")},
+			assertFunc: func(g *WithT, build chart.Build) {
+				g.Expect(build.Complete()).To(BeFalse())
+			},
+			assertConditions: []metav1.Condition{
+				*conditions.TrueCondition(sourcev1.FetchFailedCondition, meta.FailedReason, "unsafe entry '../../etc/passwd' in tarball"),
+			},
+		},
+		{
+			name:          "Source with a single huge file exceeds size limit",
+			source:        *hugeFileArtifact.DeepCopy(),
+			sourceMaxSize: 1 << 20,
+			beforeFunc: func(obj *sourcev1.HelmChart) {
+				obj.Spec.Chart = "huge.bin"
+			},
+			want:    sreconcile.ResultEmpty,
+			wantErr: &serror.Event{Err: errors.New("artifact untar error: size limit of 1048576 exceeded")},
+			assertFunc: func(g *WithT, build chart.Build) {
+				g.Expect(build.Complete()).To(BeFalse())
+			},
+			assertConditions: []metav1.Condition{
+				*conditions.TrueCondition(sourcev1.FetchFailedCondition, meta.FailedReason, "size limit of 1048576 exceeded"),
+			},
+		},
+		{
+			name:   "Chart from the index of a Bucket",
+			source: *indexArtifact.DeepCopy(),
+			beforeFunc: func(obj *sourcev1.HelmChart) {
+				obj.Spec.Chart = "helmchart"
+				obj.Spec.Version = "0.1.x"
+				obj.Spec.SourceRef.Kind = sourcev1.BucketKind
+			},
+			want: sreconcile.ResultSuccess,
+			assertFunc: func(g *WithT, build chart.Build) {
+				g.Expect(build.Name).To(Equal("helmchart"))
+				g.Expect(build.Version).To(Equal("0.1.0"))
+				g.Expect(build.Path).To(BeARegularFile())
+			},
+			cleanFunc: func(g *WithT, build *chart.Build) {
+				g.Expect(os.Remove(build.Path)).To(Succeed())
+			},
+		},
+		{
+			name:   "Chart missing from the index of a Bucket",
+			source: *indexArtifact.DeepCopy(),
+			beforeFunc: func(obj *sourcev1.HelmChart) {
+				obj.Spec.Chart = "helmchart"
+				obj.Spec.Version = "2.x"
+				obj.Spec.SourceRef.Kind = sourcev1.BucketKind
+			},
+			want:    sreconcile.ResultEmpty,
+			wantErr: &chart.BuildError{Err: errors.New("failed to find chart 'helmchart' with version '2.x' in index 'index.yaml'")},
+			assertFunc: func(g *WithT, build chart.Build) {
+				g.Expect(build.Complete()).To(BeFalse())
+			},
+		},
+		{
+			name:           "Source with many tiny files exceeds file count limit",
+			source:         *tinyFilesArtifact.DeepCopy(),
+			sourceMaxFiles: 100,
+			beforeFunc: func(obj *sourcev1.HelmChart) {
+				obj.Spec.Chart = "file-0"
+			},
+			want:    sreconcile.ResultEmpty,
+			wantErr: &serror.Event{Err: errors.New("artifact untar error: file count limit of 100 exceeded")},
+			assertFunc: func(g *WithT, build chart.Build) {
+				g.Expect(build.Complete()).To(BeFalse())
+			},
+			assertConditions: []metav1.Condition{
+				*conditions.TrueCondition(sourcev1.FetchFailedCondition, meta.FailedReason, "file count limit of 100 exceeded"),
+			},
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -1374,6 +1693,8 @@ func TestHelmChartReconciler_buildFromTarballArtifact(t *testing.T) {
 				Storage:                 storage,
 				Getters:                 testGetters,
 				RegistryClientGenerator: registry.ClientGenerator,
+				SourceMaxSize:           tt.sourceMaxSize,
+				SourceMaxFiles:          tt.sourceMaxFiles,
 				patchOptions:            getPatchOptions(helmChartReadyCondition.Owned, "sc"),
 			}
 
@@ -1401,6 +1722,10 @@ func TestHelmChartReconciler_buildFromTarballArtifact(t *testing.T) {
 			}
 			g.Expect(got).To(Equal(tt.want))
 
+			if tt.assertConditions != nil {
+				g.Expect(obj.Status.Conditions).To(conditions.MatchConditions(tt.assertConditions))
+			}
+
 			if tt.assertFunc != nil {
 				tt.assertFunc(g, b)
 			}
@@ -1408,6 +1733,179 @@ func TestHelmChartReconciler_buildFromTarballArtifact(t *testing.T) {
 	}
 }
 
+func Test_emptyChartPathDir(t *testing.T) {
+	dir := t.TempDir()
+	g := NewWithT(t)
+	g.Expect(os.MkdirAll(filepath.Join(dir, "submodule"), 0o700)).To(Succeed())
+	g.Expect(os.MkdirAll(filepath.Join(dir, "charts", "podinfo"), 0o700)).To(Succeed())
+	g.Expect(os.WriteFile(filepath.Join(dir, "charts", "podinfo", "Chart.yaml"), []byte("name: podinfo"), 0o600)).To(Succeed())
+	g.Expect(os.WriteFile(filepath.Join(dir, "podinfo.tgz"), []byte("chart"), 0o600)).To(Succeed())
+
+	tests := []struct {
+		chartPath string
+		want      string
+	}{
+		{chartPath: "submodule", want: "submodule"},
+		{chartPath: "./submodule/charts/podinfo", want: "submodule"},
+		{chartPath: "charts/podinfo", want: ""},
+		{chartPath: "charts/non-existing", want: ""},
+		{chartPath: "podinfo.tgz", want: ""},
+		{chartPath: "non-existing/podinfo", want: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.chartPath, func(t *testing.T) {
+			g := NewWithT(t)
+
+			g.Expect(emptyChartPathDir(dir, tt.chartPath)).To(Equal(tt.want))
+		})
+	}
+}
+
+func TestHelmChartReconciler_buildFromTarballArtifact_privateOCIDependency(t *testing.T) {
+	g := NewWithT(t)
+
+	// Upload the dependency chart to the private registry
+	chartData, err := ioutil.ReadFile("testdata/charts/helmchart-0.1.0.tgz")
+	g.Expect(err).ToNot(HaveOccurred())
+	metadata, err := loadTestChartToOCI(chartData, "testdata/charts/helmchart-0.1.0.tgz", testRegistryServer)
+	g.Expect(err).ToNot(HaveOccurred())
+
+	depURL := fmt.Sprintf("oci://%s/testrepo", testRegistryServer.registryHost)
+
+	// Create a chart with a dependency on the private OCI chart
+	chartDir := t.TempDir()
+	g.Expect(os.MkdirAll(filepath.Join(chartDir, "parent"), 0o700)).To(Succeed())
+	g.Expect(os.WriteFile(filepath.Join(chartDir, "parent", "Chart.yaml"), []byte(fmt.Sprintf(`apiVersion: v2
+name: parent
+version: 0.1.0
+dependencies:
+  - name: %s
+    version: %q
+    repository: %q
+`, metadata.Name, metadata.Version, depURL)), 0o600)).To(Succeed())
+
+	storage, err := NewStorage(t.TempDir(), "example.com", retentionTTL, retentionRecords)
+	g.Expect(err).ToNot(HaveOccurred())
+	artifact := &sourcev1.Artifact{
+		Revision: "mock-ref/abcdefg12345678",
+		Path:     "parent.tgz",
+	}
+	g.Expect(storage.Archive(artifact, chartDir, nil)).To(Succeed())
+
+	tests := []struct {
+		name     string
+		repoURL  string
+		username string
+		password string
+		wantErr  string
+	}{
+		{
+			name:     "OCI HelmRepository with secretRef",
+			repoURL:  depURL,
+			username: testRegistryUsername,
+			password: testRegistryPassword,
+		},
+		{
+			name:     "OCI HelmRepository with trailing slash and secretRef",
+			repoURL:  depURL + "/",
+			username: testRegistryUsername,
+			password: testRegistryPassword,
+		},
+		{
+			name:     "OCI HelmRepository with invalid secretRef",
+			repoURL:  depURL,
+			username: "wrong-user",
+			password: "wrong-pass",
+			wantErr:  "failed to login to OCI chart repository",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			ns, err := testEnv.CreateNamespace(ctx, "helmchart-oci-dep")
+			g.Expect(err).ToNot(HaveOccurred())
+			defer func() { g.Expect(testEnv.Delete(ctx, ns)).To(Succeed()) }()
+
+			r := &HelmChartReconciler{
+				Client:                  testEnv,
+				EventRecorder:           record.NewFakeRecorder(32),
+				Storage:                 storage,
+				Getters:                 testGetters,
+				RegistryClientGenerator: registry.ClientGenerator,
+				patchOptions:            getPatchOptions(helmChartReadyCondition.Owned, "sc"),
+			}
+
+			secret := &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{
+					GenerateName: "oci-dep-auth-",
+					Namespace:    ns.Name,
+				},
+				Type: corev1.SecretTypeDockerConfigJson,
+				Data: map[string][]byte{
+					".dockerconfigjson": []byte(fmt.Sprintf(`{"auths": {%q: {"username": %q, "password": %q}}}`,
+						testRegistryServer.registryHost, tt.username, tt.password)),
+				},
+			}
+			g.Expect(testEnv.Create(ctx, secret)).To(Succeed())
+
+			repo := &sourcev1.HelmRepository{
+				ObjectMeta: metav1.ObjectMeta{
+					GenerateName: "oci-dep-",
+					Namespace:    ns.Name,
+				},
+				Spec: sourcev1.HelmRepositorySpec{
+					URL:       tt.repoURL,
+					Type:      sourcev1.HelmRepositoryTypeOCI,
+					SecretRef: &meta.LocalObjectReference{Name: secret.Name},
+					Interval:  metav1.Duration{Duration: interval},
+					Timeout:   &metav1.Duration{Duration: timeout},
+				},
+			}
+			g.Expect(testEnv.Create(ctx, repo)).To(Succeed())
+
+			// Wait for the HelmRepository to be indexed by its URL
+			g.Eventually(func() error {
+				_, err := r.resolveDependencyRepository(ctx, depURL, ns.Name)
+				return err
+			}, timeout).Should(Succeed())
+
+			obj := &sourcev1.HelmChart{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "oci-dep",
+					Namespace: ns.Name,
+				},
+				Spec: sourcev1.HelmChartSpec{
+					Chart: "parent",
+				},
+			}
+
+			var b chart.Build
+			defer func() {
+				if b.Path != "" {
+					_ = os.Remove(b.Path)
+				}
+			}()
+
+			_, err = r.buildFromTarballArtifact(ctx, obj, *artifact.DeepCopy(), &b)
+			if tt.wantErr != "" {
+				g.Expect(err).To(HaveOccurred())
+				g.Expect(err.Error()).To(ContainSubstring(tt.wantErr))
+				g.Expect(b.Complete()).To(BeFalse())
+				return
+			}
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(b.Name).To(Equal("parent"))
+			g.Expect(b.ResolvedDependencies).To(Equal(1))
+
+			c, err := secureloader.LoadFile(b.Path)
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(c.Dependencies()).To(HaveLen(1))
+			g.Expect(c.Dependencies()[0].Metadata.Name).To(Equal(metadata.Name))
+		})
+	}
+}
+
 func TestHelmChartReconciler_reconcileArtifact(t *testing.T) {
 	tests := []struct {
 		name             string
@@ -1441,12 +1939,35 @@ func TestHelmChartReconciler_reconcileArtifact(t *testing.T) {
 				t.Expect(obj.GetArtifact().Revision).To(Equal("0.1.0"))
 				t.Expect(obj.Status.URL).ToNot(BeEmpty())
 				t.Expect(obj.Status.ObservedChartName).To(Equal("helmchart"))
+				t.Expect(obj.Status.ObservedChartVersion).To(Equal("0.1.0"))
+				t.Expect(obj.Status.AppVersion).To(Equal("1.16.0"))
 			},
 			want: sreconcile.ResultSuccess,
 			assertConditions: []metav1.Condition{
 				*conditions.TrueCondition(sourcev1.ArtifactInStorageCondition, sourcev1.ChartPullSucceededReason, "pulled 'helmchart' chart with version '0.1.0'"),
 			},
 		},
+		{
+			name: "Copying artifact to storage from build records overridden appVersion",
+			build: func() *chart.Build {
+				b := mockChartBuild("helmchart", "0.1.0+abcdef", "testdata/charts/helmchart-0.1.0.tgz")
+				b.AppVersion = "abcdef"
+				return b
+			}(),
+			beforeFunc: func(obj *sourcev1.HelmChart) {
+				obj.Status.ObservedChartVersion = "0.0.1"
+				obj.Status.AppVersion = "1.0.0"
+			},
+			afterFunc: func(t *WithT, obj *sourcev1.HelmChart) {
+				t.Expect(obj.GetArtifact()).ToNot(BeNil())
+				t.Expect(obj.Status.ObservedChartVersion).To(Equal("0.1.0+abcdef"))
+				t.Expect(obj.Status.AppVersion).To(Equal("abcdef"))
+			},
+			want: sreconcile.ResultSuccess,
+			assertConditions: []metav1.Condition{
+				*conditions.TrueCondition(sourcev1.ArtifactInStorageCondition, sourcev1.ChartPullSucceededReason, "pulled 'helmchart' chart with version '0.1.0+abcdef' and appVersion 'abcdef'"),
+			},
+		},
 		{
 			name: "Up-to-date chart build does not persist artifact to storage",
 			build: &chart.Build{
@@ -1467,41 +1988,135 @@ func TestHelmChartReconciler_reconcileArtifact(t *testing.T) {
 			},
 		},
 		{
-			name: "Restores conditions in case artifact matches current chart build",
+			name: "Copying artifact to storage from build records resolved dependencies",
+			build: func() *chart.Build {
+				b := mockChartBuild("helmchart", "0.1.0", "testdata/charts/helmchart-0.1.0.tgz")
+				b.Dependencies = []chart.ResolvedDependency{
+					{Name: "common", Version: "1.0.0", Repository: "file://../common"},
+					{Name: "grafana", Version: "6.17.4", Repository: "oci://example.com/charts", Digest: "sha256:abc"},
+				}
+				return b
+			}(),
+			beforeFunc: func(obj *sourcev1.HelmChart) {
+				obj.Status.ResolvedDependencies = []sourcev1.HelmChartDependency{{Name: "stale", Version: "0.0.1"}}
+			},
+			afterFunc: func(t *WithT, obj *sourcev1.HelmChart) {
+				t.Expect(obj.GetArtifact()).ToNot(BeNil())
+				t.Expect(obj.Status.ResolvedDependencies).To(Equal([]sourcev1.HelmChartDependency{
+					{Name: "common", Version: "1.0.0", Repository: "file://../common"},
+					{Name: "grafana", Version: "6.17.4", Repository: "oci://example.com/charts", Digest: "sha256:abc"},
+				}))
+			},
+			want: sreconcile.ResultSuccess,
+			assertConditions: []metav1.Condition{
+				*conditions.TrueCondition(sourcev1.ArtifactInStorageCondition, sourcev1.ChartPullSucceededReason, "pulled 'helmchart' chart with version '0.1.0'"),
+			},
+		},
+		{
+			name: "Restores conditions in case artifact matches current chart build",
+			build: &chart.Build{
+				Name:     "helmchart",
+				Version:  "0.1.0",
+				Path:     filepath.Join(testStorage.BasePath, "testdata/charts/helmchart-0.1.0.tgz"),
+				Packaged: true,
+			},
+			beforeFunc: func(obj *sourcev1.HelmChart) {
+				obj.Status.ObservedChartName = "helmchart"
+				obj.Status.Artifact = &sourcev1.Artifact{
+					Revision: "0.1.0",
+					Path:     "testdata/charts/helmchart-0.1.0.tgz",
+				}
+			},
+			want: sreconcile.ResultSuccess,
+			afterFunc: func(t *WithT, obj *sourcev1.HelmChart) {
+				t.Expect(obj.Status.Artifact.Path).To(Equal("testdata/charts/helmchart-0.1.0.tgz"))
+				t.Expect(obj.Status.URL).To(BeEmpty())
+			},
+			assertConditions: []metav1.Condition{
+				*conditions.TrueCondition(sourcev1.ArtifactInStorageCondition, sourcev1.ChartPackageSucceededReason, "packaged 'helmchart' chart with version '0.1.0'"),
+			},
+		},
+		{
+			name:  "Removes ArtifactOutdatedCondition after creating new artifact",
+			build: mockChartBuild("helmchart", "0.1.0", "testdata/charts/helmchart-0.1.0.tgz"),
+			beforeFunc: func(obj *sourcev1.HelmChart) {
+				conditions.MarkTrue(obj, sourcev1.ArtifactOutdatedCondition, "Foo", "")
+			},
+			afterFunc: func(t *WithT, obj *sourcev1.HelmChart) {
+				t.Expect(obj.GetArtifact()).ToNot(BeNil())
+				t.Expect(obj.GetArtifact().Checksum).To(Equal("bbdf96023c912c393b49d5238e227576ed0d20d1bb145d7476d817b80e20c11a"))
+				t.Expect(obj.GetArtifact().Revision).To(Equal("0.1.0"))
+				t.Expect(obj.Status.URL).ToNot(BeEmpty())
+				t.Expect(obj.Status.ObservedChartName).To(Equal("helmchart"))
+			},
+			want: sreconcile.ResultSuccess,
+			assertConditions: []metav1.Condition{
+				*conditions.TrueCondition(sourcev1.ArtifactInStorageCondition, sourcev1.ChartPullSucceededReason, "pulled 'helmchart' chart with version '0.1.0'"),
+			},
+		},
+		{
+			name: "Records chart digest of new artifact",
+			build: func() *chart.Build {
+				b := mockChartBuild("helmchart", "0.1.0", "testdata/charts/helmchart-0.1.0.tgz")
+				b.Digest = "sha256:def"
+				return b
+			}(),
+			beforeFunc: func(obj *sourcev1.HelmChart) {
+				obj.Status.ObservedChartName = "helmchart"
+				obj.Status.ObservedChartDigest = "sha256:abc"
+				obj.Status.Artifact = &sourcev1.Artifact{
+					Revision: "0.1.0",
+					Path:     "testdata/charts/helmchart-0.1.0.tgz",
+				}
+			},
+			afterFunc: func(t *WithT, obj *sourcev1.HelmChart) {
+				t.Expect(obj.GetArtifact().Revision).To(Equal("0.1.0"))
+				t.Expect(obj.Status.ObservedChartDigest).To(Equal("sha256:def"))
+			},
+			want: sreconcile.ResultSuccess,
+			assertConditions: []metav1.Condition{
+				*conditions.TrueCondition(sourcev1.ArtifactInStorageCondition, sourcev1.ChartPullSucceededReason, "pulled 'helmchart' chart with version '0.1.0'"),
+			},
+		},
+		{
+			name:  "Records the digest the chart is pinned to in the revision",
+			build: mockChartBuild("helmchart", "0.1.0", "testdata/charts/helmchart-0.1.0.tgz"),
+			beforeFunc: func(obj *sourcev1.HelmChart) {
+				obj.Spec.SourceRef = sourcev1.LocalHelmChartSourceReference{Kind: sourcev1.HelmRepositoryKind, Name: "oci"}
+				obj.Spec.Version = "0.1.0@sha256:" + strings.Repeat("a", 64)
+			},
+			afterFunc: func(t *WithT, obj *sourcev1.HelmChart) {
+				t.Expect(obj.GetArtifact()).ToNot(BeNil())
+				t.Expect(obj.GetArtifact().Revision).To(Equal("0.1.0@sha256:" + strings.Repeat("a", 64)))
+				t.Expect(obj.GetArtifact().Digest).To(Equal("sha256:" + strings.Repeat("a", 64)))
+				t.Expect(obj.GetArtifact().Path).To(HaveSuffix("helmchart-0.1.0.tgz"))
+				t.Expect(obj.Status.ObservedChartVersion).To(Equal("0.1.0"))
+			},
+			want: sreconcile.ResultSuccess,
+			assertConditions: []metav1.Condition{
+				*conditions.TrueCondition(sourcev1.ArtifactInStorageCondition, sourcev1.ChartPullSucceededReason, "pulled 'helmchart' chart with version '0.1.0'"),
+			},
+		},
+		{
+			name: "Up-to-date chart build records the digest the chart is pinned to",
 			build: &chart.Build{
-				Name:     "helmchart",
-				Version:  "0.1.0",
-				Path:     filepath.Join(testStorage.BasePath, "testdata/charts/helmchart-0.1.0.tgz"),
-				Packaged: true,
+				Name:    "helmchart",
+				Version: "0.1.0",
+				Path:    filepath.Join(testStorage.BasePath, "testdata/charts/helmchart-0.1.0.tgz"),
 			},
 			beforeFunc: func(obj *sourcev1.HelmChart) {
+				obj.Spec.SourceRef = sourcev1.LocalHelmChartSourceReference{Kind: sourcev1.HelmRepositoryKind, Name: "oci"}
+				obj.Spec.Version = "0.1.0@sha256:" + strings.Repeat("a", 64)
 				obj.Status.ObservedChartName = "helmchart"
 				obj.Status.Artifact = &sourcev1.Artifact{
 					Revision: "0.1.0",
 					Path:     "testdata/charts/helmchart-0.1.0.tgz",
 				}
 			},
-			want: sreconcile.ResultSuccess,
 			afterFunc: func(t *WithT, obj *sourcev1.HelmChart) {
 				t.Expect(obj.Status.Artifact.Path).To(Equal("testdata/charts/helmchart-0.1.0.tgz"))
-				t.Expect(obj.Status.URL).To(BeEmpty())
-			},
-			assertConditions: []metav1.Condition{
-				*conditions.TrueCondition(sourcev1.ArtifactInStorageCondition, sourcev1.ChartPackageSucceededReason, "packaged 'helmchart' chart with version '0.1.0'"),
-			},
-		},
-		{
-			name:  "Removes ArtifactOutdatedCondition after creating new artifact",
-			build: mockChartBuild("helmchart", "0.1.0", "testdata/charts/helmchart-0.1.0.tgz"),
-			beforeFunc: func(obj *sourcev1.HelmChart) {
-				conditions.MarkTrue(obj, sourcev1.ArtifactOutdatedCondition, "Foo", "")
-			},
-			afterFunc: func(t *WithT, obj *sourcev1.HelmChart) {
-				t.Expect(obj.GetArtifact()).ToNot(BeNil())
-				t.Expect(obj.GetArtifact().Checksum).To(Equal("bbdf96023c912c393b49d5238e227576ed0d20d1bb145d7476d817b80e20c11a"))
-				t.Expect(obj.GetArtifact().Revision).To(Equal("0.1.0"))
-				t.Expect(obj.Status.URL).ToNot(BeEmpty())
-				t.Expect(obj.Status.ObservedChartName).To(Equal("helmchart"))
+				t.Expect(obj.Status.Artifact.Revision).To(Equal("0.1.0@sha256:" + strings.Repeat("a", 64)))
+				t.Expect(obj.Status.Artifact.Digest).To(Equal("sha256:" + strings.Repeat("a", 64)))
 			},
 			want: sreconcile.ResultSuccess,
 			assertConditions: []metav1.Condition{
@@ -1567,6 +2182,89 @@ func TestHelmChartReconciler_reconcileArtifact(t *testing.T) {
 	}
 }
 
+func TestHelmChartReconciler_reconcileArtifact_reuseContent(t *testing.T) {
+	g := NewWithT(t)
+
+	r := &HelmChartReconciler{
+		Client:        fakeclient.NewClientBuilder().WithScheme(testEnv.GetScheme()).Build(),
+		EventRecorder: record.NewFakeRecorder(32),
+		Storage:       testStorage,
+		patchOptions:  getPatchOptions(helmChartReadyCondition.Owned, "sc"),
+	}
+	obj := &sourcev1.HelmChart{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:       "reconcile-artifact-reuse",
+			Namespace:  "default",
+			Generation: 1,
+		},
+	}
+	g.Expect(r.Client.Create(context.TODO(), obj)).To(Succeed())
+	defer func() {
+		g.Expect(r.Client.Delete(context.TODO(), obj)).To(Succeed())
+	}()
+	sp := patch.NewSerialPatcher(obj, r.Client)
+
+	got, err := r.reconcileArtifact(ctx, sp, obj, mockChartBuild("helmchart", "0.1.0", "testdata/charts/helmchart-0.1.0.tgz"))
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(got).To(Equal(sreconcile.ResultSuccess))
+	current := obj.GetArtifact().DeepCopy()
+	g.Expect(current.ContentChecksum).ToNot(BeEmpty())
+
+	// Packaging the same chart again only changes the modification times,
+	// which reuses the current artifact
+	repackaged := filepath.Join(t.TempDir(), "helmchart-0.1.0.tgz")
+	g.Expect(retimeChartPackage("testdata/charts/helmchart-0.1.0.tgz", repackaged, time.Now().Add(time.Hour))).To(Succeed())
+	b := &chart.Build{Name: "helmchart", Version: "0.1.0", Path: repackaged, Packaged: true}
+	got, err = r.reconcileArtifact(ctx, sp, obj, b)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(got).To(Equal(sreconcile.ResultSuccess))
+	g.Expect(obj.GetArtifact()).To(Equal(current))
+	g.Expect(obj.Status.ObservedChartVersion).To(Equal("0.1.0"))
+	g.Expect(repackaged).ToNot(BeAnExistingFile())
+}
+
+// retimeChartPackage writes the chart package at src to dst, with the
+// modification times of all the entries set to the given time.
+func retimeChartPackage(src, dst string, modTime time.Time) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	gr, err := gzip.NewReader(in)
+	if err != nil {
+		return err
+	}
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+	gw := gzip.NewWriter(out)
+	tw := tar.NewWriter(gw)
+	tr := tar.NewReader(gr)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		hdr.ModTime = modTime
+		if err := tw.WriteHeader(hdr); err != nil {
+			return err
+		}
+		if _, err := io.Copy(tw, tr); err != nil {
+			return err
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	return gw.Close()
+}
+
 func TestHelmChartReconciler_getHelmRepositorySecret(t *testing.T) {
 	mock := &corev1.Secret{
 		TypeMeta: metav1.TypeMeta{
@@ -1644,6 +2342,166 @@ func TestHelmChartReconciler_getHelmRepositorySecret(t *testing.T) {
 	}
 }
 
+func TestHelmChartReconciler_setValuesFrom(t *testing.T) {
+	configMap := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "values",
+			Namespace: "foo",
+		},
+		Data: map[string]string{
+			"values.yaml": "replicaCount: 2\nimage:\n  tag: v1\n",
+			"invalid":     "replicaCount: [",
+		},
+	}
+	override := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "override",
+			Namespace: "foo",
+		},
+		Data: map[string]string{
+			"override.yaml": "image:\n  tag: v2\n",
+		},
+	}
+	clientBuilder := fake.NewClientBuilder()
+	clientBuilder.WithObjects(configMap, override)
+
+	r := &HelmChartReconciler{
+		Client:       clientBuilder.Build(),
+		patchOptions: getPatchOptions(helmChartReadyCondition.Owned, "sc"),
+	}
+
+	tests := []struct {
+		name               string
+		valuesFrom         []sourcev1.ValuesReference
+		valuesInline       string
+		versionMetadata    string
+		wantValues         map[string]interface{}
+		wantValuesFrom     []string
+		wantMetadataPrefix string
+		wantErr            string
+		wantStalling       bool
+	}{
+		{
+			name: "no references",
+		},
+		{
+			name: "merges references in order",
+			valuesFrom: []sourcev1.ValuesReference{
+				{Kind: "ConfigMap", Name: "values"},
+				{Kind: "ConfigMap", Name: "override", ValuesKey: "override.yaml"},
+			},
+			versionMetadata: "1",
+			wantValues: map[string]interface{}{
+				"replicaCount": float64(2),
+				"image":        map[string]interface{}{"tag": "v2"},
+			},
+			wantValuesFrom:     []string{"ConfigMap/values", "ConfigMap/override"},
+			wantMetadataPrefix: "1.",
+		},
+		{
+			name:         "inline values only",
+			valuesInline: `{"replicaCount": 3}`,
+			wantValues: map[string]interface{}{
+				"replicaCount": float64(3),
+			},
+			wantValuesFrom: []string{"spec.valuesInline"},
+		},
+		{
+			name: "inline values take precedence over references",
+			valuesFrom: []sourcev1.ValuesReference{
+				{Kind: "ConfigMap", Name: "values"},
+			},
+			valuesInline:    `{"image": {"tag": "v3"}}`,
+			versionMetadata: "1",
+			wantValues: map[string]interface{}{
+				"replicaCount": float64(2),
+				"image":        map[string]interface{}{"tag": "v3"},
+			},
+			wantValuesFrom:     []string{"ConfigMap/values", "spec.valuesInline"},
+			wantMetadataPrefix: "1.",
+		},
+		{
+			name:         "invalid inline values",
+			valuesInline: `["replicaCount"]`,
+			wantErr:      "unmarshaling inline values failed",
+		},
+		{
+			name: "missing resource",
+			valuesFrom: []sourcev1.ValuesReference{
+				{Kind: "ConfigMap", Name: "missing"},
+			},
+			wantErr: "failed to get ConfigMap 'foo/missing'",
+		},
+		{
+			name: "missing key",
+			valuesFrom: []sourcev1.ValuesReference{
+				{Kind: "ConfigMap", Name: "override"},
+			},
+			wantErr: "missing key 'values.yaml' in ConfigMap 'foo/override'",
+		},
+		{
+			name: "secret reference",
+			valuesFrom: []sourcev1.ValuesReference{
+				{Kind: "Secret", Name: "values"},
+			},
+			wantErr:      "unsupported values reference kind 'Secret'",
+			wantStalling: true,
+		},
+		{
+			name: "invalid values",
+			valuesFrom: []sourcev1.ValuesReference{
+				{Kind: "ConfigMap", Name: "values", ValuesKey: "invalid"},
+			},
+			wantErr: "unmarshaling values from key 'invalid' in ConfigMap 'foo/values' failed",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			obj := &sourcev1.HelmChart{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "chart",
+					Namespace: "foo",
+				},
+				Spec: sourcev1.HelmChartSpec{
+					ValuesFrom: tt.valuesFrom,
+				},
+			}
+			if tt.valuesInline != "" {
+				obj.Spec.ValuesInline = &runtime.RawExtension{Raw: []byte(tt.valuesInline)}
+			}
+			opts := chart.BuildOptions{VersionMetadata: tt.versionMetadata}
+
+			err := r.setValuesFrom(context.TODO(), obj, &opts)
+			if tt.wantErr != "" {
+				g.Expect(err).To(HaveOccurred())
+				g.Expect(err.Error()).To(ContainSubstring(tt.wantErr))
+
+				_, err = valuesFromErrorReturn(err, obj)
+				var stalling *serror.Stalling
+				g.Expect(errors.As(err, &stalling)).To(Equal(tt.wantStalling))
+				g.Expect(conditions.IsTrue(obj, sourcev1.FetchFailedCondition)).To(BeTrue())
+				return
+			}
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(opts.Values).To(Equal(tt.wantValues))
+			g.Expect(opts.ValuesFrom).To(Equal(tt.wantValuesFrom))
+			if len(tt.valuesFrom) == 0 && tt.valuesInline == "" {
+				g.Expect(opts.VersionMetadata).To(Equal(tt.versionMetadata))
+				return
+			}
+			g.Expect(opts.VersionMetadata).To(HavePrefix(tt.wantMetadataPrefix))
+			g.Expect(opts.VersionMetadata).To(HaveLen(len(tt.wantMetadataPrefix) + 12))
+
+			// The checksum is stable for the same values
+			again := chart.BuildOptions{VersionMetadata: tt.versionMetadata}
+			g.Expect(r.setValuesFrom(context.TODO(), obj, &again)).To(Succeed())
+			g.Expect(again.VersionMetadata).To(Equal(opts.VersionMetadata))
+		})
+	}
+}
+
 func TestHelmChartReconciler_getSource(t *testing.T) {
 	mocks := []client.Object{
 		&sourcev1.HelmRepository{
@@ -1782,6 +2640,183 @@ func TestHelmChartReconciler_getSource(t *testing.T) {
 	}
 }
 
+func Test_chartBuildWarningsMessage(t *testing.T) {
+	long := strings.Repeat("w", maxChartBuildWarningsLength)
+
+	tests := []struct {
+		name     string
+		warnings []string
+		want     string
+	}{
+		{
+			name:     "joins warnings",
+			warnings: []string{"a", "b"},
+			want:     "a; b",
+		},
+		{
+			name:     "counts warnings which do not fit",
+			warnings: []string{"a", long, "b"},
+			want:     "a (and 2 more)",
+		},
+		{
+			name:     "truncates first warning which does not fit",
+			warnings: []string{long + "w", "b"},
+			want:     long + "... (and 1 more)",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+			g.Expect(chartBuildWarningsMessage(tt.warnings)).To(Equal(tt.want))
+		})
+	}
+}
+
+func TestHelmChartReconciler_dependencyRevisionsDrifted(t *testing.T) {
+	repo := func(name, revision string) *sourcev1.HelmRepository {
+		r := &sourcev1.HelmRepository{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: "foo",
+			},
+		}
+		if revision != "" {
+			r.Status.Artifact = &sourcev1.Artifact{Revision: revision}
+		}
+		return r
+	}
+
+	r := &HelmChartReconciler{
+		Client: fake.NewClientBuilder().WithObjects(
+			repo("stable", "sha256:abc"),
+			repo("bitnami", "sha256:def"),
+			repo("unready", ""),
+		).Build(),
+	}
+
+	tests := []struct {
+		name      string
+		revisions map[string]string
+		want      bool
+	}{
+		{
+			name: "no dependency revisions",
+		},
+		{
+			name:      "unchanged revisions",
+			revisions: map[string]string{"stable": "sha256:abc", "bitnami": "sha256:def"},
+		},
+		{
+			name:      "changed revision",
+			revisions: map[string]string{"stable": "sha256:abc", "bitnami": "sha256:old"},
+			want:      true,
+		},
+		{
+			name:      "repository without artifact",
+			revisions: map[string]string{"unready": "sha256:old"},
+		},
+		{
+			name:      "missing repository",
+			revisions: map[string]string{"deleted": "sha256:old"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			obj := &sourcev1.HelmChart{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "chart",
+					Namespace: "foo",
+				},
+			}
+			obj.Status.ObservedDependencyRevisions = tt.revisions
+
+			g.Expect(r.dependencyRevisionsDrifted(context.TODO(), obj)).To(Equal(tt.want))
+		})
+	}
+}
+
+func TestHelmChartReconciler_indexHelmChartByDependencyRepository(t *testing.T) {
+	g := NewWithT(t)
+
+	obj := &sourcev1.HelmChart{}
+	r := &HelmChartReconciler{}
+	g.Expect(r.indexHelmChartByDependencyRepository(obj)).To(BeEmpty())
+
+	obj.Status.ObservedDependencyRevisions = map[string]string{"stable": "sha256:abc", "bitnami": "sha256:def"}
+	g.Expect(r.indexHelmChartByDependencyRepository(obj)).To(Equal([]string{"bitnami", "stable"}))
+}
+
+func TestHelmChartReconciler_requestsForValuesFromChange(t *testing.T) {
+	g := NewWithT(t)
+
+	r := &HelmChartReconciler{
+		Client: testEnv,
+	}
+	spec := sourcev1.HelmChartSpec{
+		Chart: "podinfo",
+		SourceRef: sourcev1.LocalHelmChartSourceReference{
+			Kind: sourcev1.HelmRepositoryKind,
+			Name: "podinfo",
+		},
+		Interval: metav1.Duration{Duration: interval},
+		ValuesFrom: []sourcev1.ValuesReference{
+			{Kind: "ConfigMap", Name: "values"},
+			{Kind: "ConfigMap", Name: "override"},
+		},
+	}
+	g.Expect(r.indexHelmChartByValuesFrom(&sourcev1.HelmChart{Spec: spec})).To(Equal([]string{"values", "override"}))
+
+	ns, err := testEnv.CreateNamespace(ctx, "helmchart-values-from")
+	g.Expect(err).ToNot(HaveOccurred())
+	defer func() { g.Expect(testEnv.Delete(ctx, ns)).To(Succeed()) }()
+
+	referencing := &sourcev1.HelmChart{
+		ObjectMeta: metav1.ObjectMeta{Name: "referencing", Namespace: ns.Name},
+		Spec:       spec,
+	}
+	unrelated := &sourcev1.HelmChart{
+		ObjectMeta: metav1.ObjectMeta{Name: "unrelated", Namespace: ns.Name},
+		Spec:       spec,
+	}
+	unrelated.Spec.ValuesFrom = nil
+	g.Expect(testEnv.Create(ctx, referencing)).To(Succeed())
+	g.Expect(testEnv.Create(ctx, unrelated)).To(Succeed())
+	defer func() {
+		g.Expect(testEnv.Delete(ctx, referencing)).To(Succeed())
+		g.Expect(testEnv.Delete(ctx, unrelated)).To(Succeed())
+	}()
+
+	cm := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "override", Namespace: ns.Name},
+	}
+	g.Eventually(func() []reconcile.Request {
+		return r.requestsForValuesFromChange(cm)
+	}, timeout).Should(Equal([]reconcile.Request{
+		{NamespacedName: types.NamespacedName{Name: "referencing", Namespace: ns.Name}},
+	}))
+
+	cm.Namespace = "default"
+	g.Expect(r.requestsForValuesFromChange(cm)).To(BeEmpty())
+}
+
+func Test_dependencyRevisionRecorder(t *testing.T) {
+	g := NewWithT(t)
+
+	var nilRecorder *dependencyRevisionRecorder
+	nilRecorder.record(&sourcev1.HelmRepository{})
+
+	d := &dependencyRevisionRecorder{}
+	d.record(&sourcev1.HelmRepository{ObjectMeta: metav1.ObjectMeta{Name: "unready"}})
+	g.Expect(d.revisions).To(BeNil())
+
+	d.record(&sourcev1.HelmRepository{
+		ObjectMeta: metav1.ObjectMeta{Name: "stable"},
+		Status:     sourcev1.HelmRepositoryStatus{Artifact: &sourcev1.Artifact{Revision: "sha256:abc"}},
+	})
+	g.Expect(d.revisions).To(Equal(map[string]string{"stable": "sha256:abc"}))
+}
 func TestHelmChartReconciler_reconcileDelete(t *testing.T) {
 	g := NewWithT(t)
 
@@ -2420,6 +3455,7 @@ func TestHelmChartReconciler_reconcileSourceFromOCI_verifySignature(t *testing.T
 	tests := []struct {
 		name             string
 		shouldSign       bool
+		disableKeyless   bool
 		beforeFunc       func(obj *sourcev1.HelmChart)
 		want             sreconcile.Result
 		wantErr          bool
@@ -2461,6 +3497,23 @@ func TestHelmChartReconciler_reconcileSourceFromOCI_verifySignature(t *testing.T
 				*conditions.FalseCondition(sourcev1.SourceVerifiedCondition, sourcev1.VerificationError, "chart verification error: failed to verify <url>: no matching signatures:"),
 			},
 		},
+		{
+			name:           "keyless verification is rejected when disabled",
+			disableKeyless: true,
+			beforeFunc: func(obj *sourcev1.HelmChart) {
+				obj.Spec.Chart = metadata.Name
+				obj.Spec.Version = metadata.Version
+				obj.Spec.Verify = &sourcev1.OCIRepositoryVerification{
+					Provider: "cosign",
+				}
+			},
+			want:       sreconcile.ResultEmpty,
+			wantErr:    true,
+			wantErrMsg: "keyless verification is disabled by the controller policy",
+			assertConditions: []metav1.Condition{
+				*conditions.FalseCondition(sourcev1.SourceVerifiedCondition, sourcev1.VerificationError, "failed to verify the signature using provider 'cosign keyless': keyless verification is disabled by the controller policy"),
+			},
+		},
 		{
 			name:       "signed charts should pass verification",
 			shouldSign: true,
@@ -2532,12 +3585,13 @@ func TestHelmChartReconciler_reconcileSourceFromOCI_verifySignature(t *testing.T
 			clientBuilder.WithObjects(repository, secret)
 
 			r := &HelmChartReconciler{
-				Client:                  clientBuilder.Build(),
-				EventRecorder:           record.NewFakeRecorder(32),
-				Getters:                 testGetters,
-				Storage:                 storage,
-				RegistryClientGenerator: registry.ClientGenerator,
-				patchOptions:            getPatchOptions(helmChartReadyCondition.Owned, "sc"),
+				Client:                     clientBuilder.Build(),
+				EventRecorder:              record.NewFakeRecorder(32),
+				Getters:                    testGetters,
+				Storage:                    storage,
+				RegistryClientGenerator:    registry.ClientGenerator,
+				DisableKeylessVerification: tt.disableKeyless,
+				patchOptions:               getPatchOptions(helmChartReadyCondition.Owned, "sc"),
 			}
 
 			obj := &sourcev1.HelmChart{
@@ -2647,3 +3701,49 @@ func loadTestChartToOCI(chartData []byte, chartPath string, server *registryClie
 
 	return metadata, nil
 }
+
+func Test_shortSourceRevision(t *testing.T) {
+	tests := []struct {
+		name     string
+		kind     string
+		revision string
+		want     string
+	}{
+		{
+			name:     "GitRepository revision with branch",
+			kind:     sourcev1.GitRepositoryKind,
+			revision: "main/5394cb7f48332b2de7c17dd8b8384bbc84b7e738",
+			want:     "5394cb7f4833",
+		},
+		{
+			name:     "Bucket revision",
+			kind:     sourcev1.BucketKind,
+			revision: "d2a3ac9d54c6cb5d38c5c1e6c33ab1de8d0c4b8a8b76e5bd56bc9a1b3eb4c9d0",
+			want:     "d2a3ac9d54c6",
+		},
+		{
+			name:     "revision shorter than the short format",
+			kind:     sourcev1.GitRepositoryKind,
+			revision: "main/5394cb7",
+			want:     "5394cb7",
+		},
+		{
+			name:     "empty revision",
+			kind:     sourcev1.BucketKind,
+			revision: "",
+			want:     "",
+		},
+		{
+			name:     "HelmRepository revision",
+			kind:     sourcev1.HelmRepositoryKind,
+			revision: "6.1.5",
+			want:     "6.1.5",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+			g.Expect(shortSourceRevision(tt.kind, tt.revision)).To(Equal(tt.want))
+		})
+	}
+}
@@ -22,6 +22,7 @@ import (
 	"errors"
 	"fmt"
 	"net/url"
+	"os"
 	"time"
 
 	"github.com/docker/go-units"
@@ -107,16 +108,32 @@ type HelmRepositoryReconciler struct {
 	Storage        *Storage
 	ControllerName string
 
+	// FailureThreshold is the number of consecutive reconciliations failing
+	// to fetch, after which the object is marked as stalled. Zero disables it.
+	FailureThreshold int64
+
 	Cache *cache.Cache
 	TTL   time.Duration
 	*cache.CacheRecorder
 
+	// UserAgent overrides the User-Agent of the requests to the Helm
+	// repositories when set.
+	UserAgent string
+
+	// indexCacheEvictions records the handled requests to evict the index
+	// from the cache.
+	indexCacheEvictions indexCacheEvictions
+
 	patchOptions []patch.Option
 }
 
 type HelmRepositoryReconcilerOptions struct {
 	MaxConcurrentReconciles int
 	RateLimiter             ratelimiter.RateLimiter
+
+	// MaxConcurrentReconcilesPerNamespace limits the number of concurrent
+	// reconciles of the objects in a namespace. Zero means no limit.
+	MaxConcurrentReconcilesPerNamespace int
 }
 
 // helmRepositoryReconcileFunc is the function type for all the
@@ -140,7 +157,8 @@ func (r *HelmRepositoryReconciler) SetupWithManagerAndOptions(mgr ctrl.Manager,
 					intpredicates.HelmRepositoryTypePredicate{RepositoryType: sourcev1.HelmRepositoryTypeDefault},
 					intpredicates.HelmRepositoryTypePredicate{RepositoryType: ""},
 				),
-				predicate.Or(predicate.GenerationChangedPredicate{}, predicates.ReconcileRequestedPredicate{}),
+				predicate.Or(predicate.GenerationChangedPredicate{}, predicates.ReconcileRequestedPredicate{},
+					intpredicates.AnnotationChangedPredicate{Annotation: sourcev1.IndexCacheEvictionAnnotation}),
 			),
 		).
 		WithOptions(controller.Options{
@@ -148,7 +166,7 @@ func (r *HelmRepositoryReconciler) SetupWithManagerAndOptions(mgr ctrl.Manager,
 			RateLimiter:             opts.RateLimiter,
 			RecoverPanic:            true,
 		}).
-		Complete(r)
+		Complete(withNamespaceLimit(r, opts.MaxConcurrentReconcilesPerNamespace))
 }
 
 func (r *HelmRepositoryReconciler) Reconcile(ctx context.Context, req ctrl.Request) (result ctrl.Result, retErr error) {
@@ -185,6 +203,7 @@ func (r *HelmRepositoryReconciler) Reconcile(ctx context.Context, req ctrl.Reque
 			),
 			summarize.WithResultBuilder(sreconcile.AlwaysRequeueResultBuilder{RequeueAfter: obj.GetRequeueAfter()}),
 			summarize.WithPatchFieldOwner(r.ControllerName),
+			summarize.WithFailureThreshold(r.FailureThreshold, sourcev1.FetchFailedCondition),
 		}
 		result, retErr = summarizeHelper.SummarizeAndPatch(ctx, obj, summarizeOpts...)
 
@@ -215,6 +234,15 @@ func (r *HelmRepositoryReconciler) Reconcile(ctx context.Context, req ctrl.Reque
 		return
 	}
 
+	// Evict the cached index if requested, the index is cached again after
+	// it has been fetched
+	if r.Cache != nil && obj.GetArtifact() != nil && r.indexCacheEvictions.requested(obj) {
+		r.Cache.Delete(r.Storage.LocalPath(*obj.GetArtifact()))
+		r.IncCacheEvents(cache.CacheEventTypeEviction, obj.GetName(), obj.GetNamespace())
+		r.eventLogf(ctx, obj, eventv1.EventTypeTrace, sourcev1.IndexCacheEvictedReason,
+			"evicted index of revision '%s' from cache", obj.GetArtifact().Revision)
+	}
+
 	// Reconcile actual object
 	reconcilers := []helmRepositoryReconcileFunc{
 		r.reconcileStorage,
@@ -296,15 +324,10 @@ func (r *HelmRepositoryReconciler) notify(ctx context.Context, oldObj, newObj *s
 			humanReadableSize = fmt.Sprintf("size %s", units.HumanSize(float64(*size)))
 		}
 
-		var oldChecksum string
-		if oldObj.GetArtifact() != nil {
-			oldChecksum = oldObj.GetArtifact().Checksum
-		}
-
 		message := fmt.Sprintf("stored fetched index of %s from '%s'", humanReadableSize, chartRepo.URL)
 
 		// Notify on new artifact and failure recovery.
-		if oldChecksum != newObj.GetArtifact().Checksum {
+		if artifactChanged(oldObj.GetArtifact(), newObj.GetArtifact()) {
 			r.AnnotatedEventf(newObj, annotations, corev1.EventTypeNormal,
 				"NewArtifact", message)
 			ctrl.LoggerFrom(ctx).Info(message)
@@ -378,6 +401,7 @@ func (r *HelmRepositoryReconciler) reconcileStorage(ctx context.Context, sp *pat
 func (r *HelmRepositoryReconciler) reconcileSource(ctx context.Context, sp *patch.SerialPatcher,
 	obj *sourcev1.HelmRepository, artifact *sourcev1.Artifact, chartRepo *repository.ChartRepository) (sreconcile.Result, error) {
 	var tlsConfig *tls.Config
+	var chartRepoOpts []repository.ChartRepositoryOption
 
 	// Configure Helm client to access repository
 	clientOpts := []helmgetter.Option{
@@ -385,6 +409,9 @@ func (r *HelmRepositoryReconciler) reconcileSource(ctx context.Context, sp *patc
 		helmgetter.WithURL(obj.Spec.URL),
 		helmgetter.WithPassCredentialsAll(obj.Spec.PassCredentials),
 	}
+	if r.UserAgent != "" {
+		clientOpts = append(clientOpts, helmgetter.WithUserAgent(r.UserAgent))
+	}
 
 	// Configure any authentication related options
 	if obj.Spec.SecretRef != nil {
@@ -416,6 +443,20 @@ func (r *HelmRepositoryReconciler) reconcileSource(ctx context.Context, sp *patc
 		}
 		clientOpts = append(clientOpts, opts...)
 
+		bearerToken, err := getter.BearerTokenFromSecret(secret)
+		if err != nil {
+			e := &serror.Event{
+				Err:    fmt.Errorf("failed to configure Helm client with secret data: %w", err),
+				Reason: sourcev1.AuthenticationFailedReason,
+			}
+			conditions.MarkTrue(obj, sourcev1.FetchFailedCondition, e.Reason, e.Err.Error())
+			// Return err as the content of the secret may change.
+			return sreconcile.ResultEmpty, e
+		}
+		if bearerToken != "" {
+			chartRepoOpts = append(chartRepoOpts, repository.WithBearerToken(bearerToken, obj.Spec.PassCredentials))
+		}
+
 		tlsConfig, err = getter.TLSClientConfigFromSecret(secret, obj.Spec.URL)
 		if err != nil {
 			e := &serror.Event{
@@ -429,7 +470,7 @@ func (r *HelmRepositoryReconciler) reconcileSource(ctx context.Context, sp *patc
 	}
 
 	// Construct Helm chart repository with options and download index
-	newChartRepo, err := repository.NewChartRepository(obj.Spec.URL, "", r.Getters, tlsConfig, clientOpts)
+	newChartRepo, err := repository.NewChartRepository(obj.Spec.URL, "", r.Getters, tlsConfig, clientOpts, chartRepoOpts...)
 	if err != nil {
 		switch err.(type) {
 		case *url.Error:
@@ -449,8 +490,25 @@ func (r *HelmRepositoryReconciler) reconcileSource(ctx context.Context, sp *patc
 		}
 	}
 
+	// Make a conditional request for the index if the index of the stored
+	// Artifact was downloaded with the current spec.
+	if obj.Status.ObservedGeneration == obj.Generation && obj.GetArtifact() != nil &&
+		r.Storage.ArtifactExist(*obj.GetArtifact()) {
+		newChartRepo.IndexValidators = repository.IndexValidators{
+			ETag:         obj.Status.IndexETag,
+			LastModified: obj.Status.IndexLastModified,
+		}
+	}
+
 	// Fetch the repository index from remote.
 	checksum, err := newChartRepo.CacheIndex()
+	if errors.Is(err, repository.ErrIndexNotModified) {
+		// The stored Artifact is up-to-date with the index.
+		*chartRepo = *newChartRepo
+		*artifact = *obj.GetArtifact()
+		conditions.Delete(obj, sourcev1.FetchFailedCondition)
+		return sreconcile.ResultSuccess, nil
+	}
 	if err != nil {
 		e := &serror.Event{
 			Err:    fmt.Errorf("failed to fetch Helm repository index: %w", err),
@@ -462,6 +520,23 @@ func (r *HelmRepositoryReconciler) reconcileSource(ctx context.Context, sp *patc
 	}
 	*chartRepo = *newChartRepo
 
+	// The checksum of the cached index is computed with SHA256, while the
+	// checksum of the stored Artifact is computed with the algorithm of the
+	// Storage.
+	if r.Storage.ChecksumAlgorithm == ChecksumSHA512 {
+		f, err := os.Open(chartRepo.CachePath)
+		if err != nil {
+			e := &serror.Event{
+				Err:    fmt.Errorf("failed to open cached Helm repository index: %w", err),
+				Reason: sourcev1.ReadOperationFailedReason,
+			}
+			conditions.MarkTrue(obj, sourcev1.FetchFailedCondition, e.Reason, e.Err.Error())
+			return sreconcile.ResultEmpty, e
+		}
+		checksum = r.Storage.Checksum(f)
+		f.Close()
+	}
+
 	// Short-circuit based on the fetched index being an exact match to the
 	// stored Artifact. This prevents having to unmarshal the YAML to calculate
 	// the (stable) revision, which is a memory expensive operation.
@@ -535,6 +610,10 @@ func (r *HelmRepositoryReconciler) reconcileArtifact(ctx context.Context, sp *pa
 	}()
 
 	if obj.GetArtifact().HasRevision(artifact.Revision) && obj.GetArtifact().HasChecksum(artifact.Checksum) {
+		// Record the validators of the unchanged index, which may have changed
+		obj.Status.IndexETag = chartRepo.IndexValidators.ETag
+		obj.Status.IndexLastModified = chartRepo.IndexValidators.LastModified
+
 		r.eventLogf(ctx, obj, eventv1.EventTypeTrace, sourcev1.ArtifactUpToDateReason, "artifact up-to-date with remote revision: '%s'", artifact.Revision)
 		return sreconcile.ResultSuccess, nil
 	}
@@ -550,7 +629,7 @@ func (r *HelmRepositoryReconciler) reconcileArtifact(ctx context.Context, sp *pa
 	}
 
 	// Acquire lock.
-	unlock, err := r.Storage.Lock(*artifact)
+	unlock, err := r.Storage.LockWithContext(ctx, *artifact)
 	if err != nil {
 		return sreconcile.ResultEmpty, &serror.Event{
 			Err:    fmt.Errorf("failed to acquire lock for artifact: %w", err),
@@ -571,6 +650,8 @@ func (r *HelmRepositoryReconciler) reconcileArtifact(ctx context.Context, sp *pa
 
 	// Record it on the object.
 	obj.Status.Artifact = artifact.DeepCopy()
+	obj.Status.IndexETag = chartRepo.IndexValidators.ETag
+	obj.Status.IndexLastModified = chartRepo.IndexValidators.LastModified
 
 	// Update index symlink.
 	indexURL, err := r.Storage.Symlink(*artifact, "index.yaml")
@@ -618,6 +699,7 @@ func (r *HelmRepositoryReconciler) reconcileDelete(ctx context.Context, obj *sou
 	// Remove our finalizer from the list if we are deleting the object
 	if !obj.DeletionTimestamp.IsZero() {
 		controllerutil.RemoveFinalizer(obj, sourcev1.SourceFinalizer)
+		r.indexCacheEvictions.forget(obj)
 	}
 
 	// Stop reconciliation as the object is being deleted
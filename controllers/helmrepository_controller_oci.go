@@ -18,6 +18,7 @@ package controllers
 
 import (
 	"context"
+	"crypto/tls"
 	"errors"
 	"fmt"
 	"net/url"
@@ -50,13 +51,16 @@ import (
 
 	"github.com/fluxcd/source-controller/api/v1beta2"
 	sourcev1 "github.com/fluxcd/source-controller/api/v1beta2"
+	"github.com/fluxcd/source-controller/internal/helm/getter"
 	"github.com/fluxcd/source-controller/internal/helm/registry"
 	"github.com/fluxcd/source-controller/internal/helm/repository"
 	"github.com/fluxcd/source-controller/internal/object"
+	soci "github.com/fluxcd/source-controller/internal/oci"
 	intpredicates "github.com/fluxcd/source-controller/internal/predicates"
 )
 
 var helmRepositoryOCIOwnedConditions = []string{
+	sourcev1.CredentialExpiringCondition,
 	meta.ReadyCondition,
 	meta.ReconcilingCondition,
 	meta.StalledCondition,
@@ -81,6 +85,15 @@ type HelmRepositoryOCIReconciler struct {
 	ControllerName          string
 	RegistryClientGenerator RegistryClientGeneratorFunc
 
+	// CredentialExpiryWindow is the duration before the expiry of the
+	// registry credentials within which a warning event is emitted. Zero
+	// disables it.
+	CredentialExpiryWindow time.Duration
+
+	// AllowedRegistryDomains restricts the registries which are accepted as
+	// the URL of a HelmRepository. An empty list allows any registry.
+	AllowedRegistryDomains soci.DomainAllowlist
+
 	patchOptions []patch.Option
 }
 
@@ -110,7 +123,7 @@ func (r *HelmRepositoryOCIReconciler) SetupWithManagerAndOptions(mgr ctrl.Manage
 			RateLimiter:             opts.RateLimiter,
 			RecoverPanic:            true,
 		}).
-		Complete(r)
+		Complete(withNamespaceLimit(r, opts.MaxConcurrentReconcilesPerNamespace))
 }
 
 func (r *HelmRepositoryOCIReconciler) Reconcile(ctx context.Context, req ctrl.Request) (result ctrl.Result, retErr error) {
@@ -287,6 +300,15 @@ func (r *HelmRepositoryOCIReconciler) reconcile(ctx context.Context, sp *patch.S
 		result, retErr = ctrl.Result{}, nil
 		return
 	}
+
+	// Ensure that the registry is allowed before continuing.
+	if err := checkRegistryDomain(r.AllowedRegistryDomains, obj.Spec.URL); err != nil {
+		conditions.MarkStalled(obj, sourcev1.URLInvalidReason, err.Error())
+		conditions.MarkFalse(obj, meta.ReadyCondition, sourcev1.URLInvalidReason, err.Error())
+		ctrl.LoggerFrom(ctx).Error(err, "reconciliation stalled")
+		result, retErr = ctrl.Result{}, nil
+		return
+	}
 	conditions.Delete(obj, meta.StalledCondition)
 
 	var (
@@ -302,16 +324,25 @@ func (r *HelmRepositoryOCIReconciler) reconcile(ctx context.Context, sp *patch.S
 			result, retErr = ctrl.Result{}, err
 			return
 		}
-	} else if obj.Spec.Provider != sourcev1.GenericOCIProvider && obj.Spec.Type == sourcev1.HelmRepositoryTypeOCI {
-		auth, authErr := oidcAuth(ctxTimeout, obj.Spec.URL, obj.Spec.Provider)
-		if authErr != nil && !errors.Is(authErr, oci.ErrUnconfiguredProvider) {
-			e := fmt.Errorf("failed to get credential from %s: %w", obj.Spec.Provider, authErr)
+	} else if obj.Spec.Type == sourcev1.HelmRepositoryTypeOCI {
+		provider, err := ociProvider(ctx, r.Client, obj.GetNamespace(), obj.Spec.Provider)
+		if err != nil {
+			e := fmt.Errorf("failed to determine provider: %w", err)
 			conditions.MarkFalse(obj, meta.ReadyCondition, sourcev1.AuthenticationFailedReason, e.Error())
 			result, retErr = ctrl.Result{}, e
 			return
 		}
-		if auth != nil {
-			authenticator = auth
+		if provider != sourcev1.GenericOCIProvider {
+			auth, authErr := oidcAuth(ctxTimeout, obj.Spec.URL, provider)
+			if authErr != nil && !errors.Is(authErr, oci.ErrUnconfiguredProvider) {
+				e := fmt.Errorf("failed to get credential from %s: %w", provider, authErr)
+				conditions.MarkFalse(obj, meta.ReadyCondition, sourcev1.AuthenticationFailedReason, e.Error())
+				result, retErr = ctrl.Result{}, e
+				return
+			}
+			if auth != nil {
+				authenticator = auth
+			}
 		}
 	}
 
@@ -322,21 +353,43 @@ func (r *HelmRepositoryOCIReconciler) reconcile(ctx context.Context, sp *patch.S
 		return
 	}
 
-	// Create registry client and login if needed.
-	registryClient, file, err := r.RegistryClientGenerator(loginOpt != nil)
+	// Warn about registry credentials which are about to expire
+	if observeCredentialExpiry(obj, credentialExpiryWarning(obj.Spec.URL, keychain, authenticator, r.CredentialExpiryWindow)) {
+		r.eventLogf(ctx, obj, corev1.EventTypeWarning, credentialExpiringReason,
+			conditions.GetMessage(obj, sourcev1.CredentialExpiringCondition))
+	}
+
+	// Configure the TLS client configuration used to connect to the registry.
+	tlsConfig, err := tlsConfigFromCertSecret(ctx, r.Client, obj)
 	if err != nil {
-		e := fmt.Errorf("failed to create registry client: %w", err)
-		conditions.MarkFalse(obj, meta.ReadyCondition, meta.FailedReason, e.Error())
-		result, retErr = ctrl.Result{}, e
+		conditions.MarkFalse(obj, meta.ReadyCondition, sourcev1.AuthenticationFailedReason, err.Error())
+		result, retErr = ctrl.Result{}, err
 		return
 	}
-	if file != "" {
-		defer func() {
-			if err := os.Remove(file); err != nil {
-				r.eventLogf(ctx, obj, corev1.EventTypeWarning, meta.FailedReason,
-					"failed to delete temporary credentials file: %s", err)
-			}
-		}()
+
+	// Create registry client and login if needed. The Helm registry client
+	// does not allow configuring its transport, a custom TLS configuration
+	// requires a remote client instead.
+	var registryClient repository.RegistryClient
+	if tlsConfig != nil {
+		registryClient = registry.NewRemoteClient(ctxTimeout, tlsConfig, authenticator, keychain)
+	} else {
+		helmClient, file, err := r.RegistryClientGenerator(loginOpt != nil)
+		if err != nil {
+			e := fmt.Errorf("failed to create registry client: %w", err)
+			conditions.MarkFalse(obj, meta.ReadyCondition, meta.FailedReason, e.Error())
+			result, retErr = ctrl.Result{}, e
+			return
+		}
+		if file != "" {
+			defer func() {
+				if err := os.Remove(file); err != nil {
+					r.eventLogf(ctx, obj, corev1.EventTypeWarning, meta.FailedReason,
+						"failed to delete temporary credentials file: %s", err)
+				}
+			}()
+		}
+		registryClient = helmClient
 	}
 
 	chartRepo, err := repository.NewOCIChartRepository(obj.Spec.URL, repository.WithOCIRegistryClient(registryClient))
@@ -414,6 +467,32 @@ func authFromSecret(ctx context.Context, client client.Client, obj *sourcev1.Hel
 	return keychain, nil
 }
 
+// tlsConfigFromCertSecret returns a TLS client configuration for the given
+// HelmRepository. If the HelmRepository does not specify a certSecretRef, a nil
+// configuration is returned.
+func tlsConfigFromCertSecret(ctx context.Context, client client.Client, obj *sourcev1.HelmRepository) (*tls.Config, error) {
+	if obj.Spec.CertSecretRef == nil {
+		return nil, nil
+	}
+
+	// Attempt to retrieve secret.
+	name := types.NamespacedName{
+		Namespace: obj.GetNamespace(),
+		Name:      obj.Spec.CertSecretRef.Name,
+	}
+	var secret corev1.Secret
+	if err := client.Get(ctx, name, &secret); err != nil {
+		return nil, fmt.Errorf("failed to get secret '%s': %w", name.String(), err)
+	}
+
+	// Construct TLS client configuration.
+	tlsConfig, err := getter.TLSClientConfigFromSecret(secret, obj.Spec.URL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to construct TLS client configuration from secret data: %w", err)
+	}
+	return tlsConfig, nil
+}
+
 // makeLoginOption returns a registry login option for the given HelmRepository.
 // If the HelmRepository does not specify a secretRef, a nil login option is returned.
 func makeLoginOption(auth authn.Authenticator, keychain authn.Keychain, registryURL string) (helmreg.LoginOption, error) {
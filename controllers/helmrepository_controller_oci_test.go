@@ -169,8 +169,10 @@ func TestHelmRepositoryOCIReconciler_authStrategy(t *testing.T) {
 		url              string
 		registryOpts     registryOptions
 		secretOpts       secretOptions
+		certSecret       *corev1.Secret
 		provider         string
 		providerImg      string
+		allowedDomains   []string
 		want             ctrl.Result
 		wantErr          bool
 		assertConditions []metav1.Condition
@@ -212,6 +214,46 @@ func TestHelmRepositoryOCIReconciler_authStrategy(t *testing.T) {
 				*conditions.FalseCondition(meta.ReadyCondition, sourcev1.AuthenticationFailedReason, "failed to login to registry"),
 			},
 		},
+		{
+			name: "HTTPS with basic auth secret and certSecretRef",
+			want: ctrl.Result{RequeueAfter: interval},
+			registryOpts: registryOptions{
+				withBasicAuth: true,
+				withTLS:       true,
+			},
+			secretOpts: secretOptions{
+				username: testRegistryUsername,
+				password: testRegistryPassword,
+			},
+			certSecret: &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "certs",
+				},
+				Data: map[string][]byte{
+					"ca.crt": tlsCA,
+				},
+			},
+			assertConditions: []metav1.Condition{
+				*conditions.TrueCondition(meta.ReadyCondition, meta.SucceededReason, "Helm repository is ready"),
+			},
+		},
+		{
+			name:    "with malformed certSecretRef",
+			want:    ctrl.Result{},
+			wantErr: true,
+			certSecret: &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "malformed-certs",
+				},
+				Data: map[string][]byte{
+					"ca.crt": []byte("invalid"),
+				},
+			},
+			assertConditions: []metav1.Condition{
+				*conditions.TrueCondition(meta.ReconcilingCondition, meta.ProgressingWithRetryReason, "processing object: new generation"),
+				*conditions.FalseCondition(meta.ReadyCondition, sourcev1.AuthenticationFailedReason, "failed to construct TLS client configuration"),
+			},
+		},
 		{
 			name:        "with contextual login provider",
 			wantErr:     true,
@@ -237,6 +279,15 @@ func TestHelmRepositoryOCIReconciler_authStrategy(t *testing.T) {
 				*conditions.TrueCondition(meta.ReadyCondition, meta.SucceededReason, "Helm repository is ready"),
 			},
 		},
+		{
+			name:           "registry domain not allowed",
+			want:           ctrl.Result{},
+			allowedDomains: []string{"*.example.com"},
+			assertConditions: []metav1.Condition{
+				*conditions.TrueCondition(meta.StalledCondition, sourcev1.URLInvalidReason, "is not allowed by the policy of allowed registry domains '*.example.com'"),
+				*conditions.FalseCondition(meta.ReadyCondition, sourcev1.URLInvalidReason, "is not allowed by the policy of allowed registry domains '*.example.com'"),
+			},
+		},
 	}
 
 	for _, tt := range tests {
@@ -291,11 +342,19 @@ func TestHelmRepositoryOCIReconciler_authStrategy(t *testing.T) {
 				}
 			}
 
+			if tt.certSecret != nil {
+				builder.WithObjects(tt.certSecret)
+				obj.Spec.CertSecretRef = &meta.LocalObjectReference{
+					Name: tt.certSecret.Name,
+				}
+			}
+
 			r := &HelmRepositoryOCIReconciler{
 				Client:                  builder.Build(),
 				EventRecorder:           record.NewFakeRecorder(32),
 				Getters:                 testGetters,
 				RegistryClientGenerator: registry.ClientGenerator,
+				AllowedRegistryDomains:  tt.allowedDomains,
 				patchOptions:            getPatchOptions(helmRepositoryOCIOwnedConditions, "sc"),
 			}
 
@@ -307,16 +307,17 @@ func TestHelmRepositoryReconciler_reconcileSource(t *testing.T) {
 	}
 
 	tests := []struct {
-		name             string
-		protocol         string
-		server           options
-		url              string
-		secret           *corev1.Secret
-		beforeFunc       func(t *WithT, obj *sourcev1.HelmRepository, checksum string)
-		afterFunc        func(t *WithT, obj *sourcev1.HelmRepository, artifact sourcev1.Artifact, chartRepo repository.ChartRepository)
-		want             sreconcile.Result
-		wantErr          bool
-		assertConditions []metav1.Condition
+		name              string
+		protocol          string
+		server            options
+		url               string
+		secret            *corev1.Secret
+		checksumAlgorithm string
+		beforeFunc        func(t *WithT, obj *sourcev1.HelmRepository, checksum string)
+		afterFunc         func(t *WithT, obj *sourcev1.HelmRepository, artifact sourcev1.Artifact, chartRepo repository.ChartRepository)
+		want              sreconcile.Result
+		wantErr           bool
+		assertConditions  []metav1.Condition
 	}{
 		{
 			name:     "HTTPS with secretRef pointing to CA cert but public repo URL succeeds",
@@ -571,6 +572,59 @@ func TestHelmRepositoryReconciler_reconcileSource(t *testing.T) {
 			},
 			want: sreconcile.ResultSuccess,
 		},
+		{
+			name:              "cached index with same SHA512 checksum",
+			protocol:          "http",
+			checksumAlgorithm: ChecksumSHA512,
+			beforeFunc: func(t *WithT, obj *sourcev1.HelmRepository, checksum string) {
+				obj.Status.Artifact = &sourcev1.Artifact{
+					Revision: "revision",
+					Checksum: checksum,
+				}
+				conditions.MarkReconciling(obj, meta.ProgressingReason, "foo")
+				conditions.MarkUnknown(obj, meta.ReadyCondition, "foo", "bar")
+			},
+			assertConditions: []metav1.Condition{
+				*conditions.TrueCondition(meta.ReconcilingCondition, meta.ProgressingReason, "foo"),
+				*conditions.UnknownCondition(meta.ReadyCondition, "foo", "bar"),
+			},
+			afterFunc: func(t *WithT, obj *sourcev1.HelmRepository, artifact sourcev1.Artifact, chartRepo repository.ChartRepository) {
+				// The index is not loaded, as the checksum of the stored
+				// Artifact is compared with the same algorithm.
+				t.Expect(chartRepo.Checksum).To(BeEmpty())
+				t.Expect(artifact.Checksum).To(HavePrefix(ChecksumSHA512 + ":"))
+				t.Expect(artifact.Revision).To(Equal("revision"))
+			},
+			want: sreconcile.ResultSuccess,
+		},
+		{
+			name:     "unchanged index is not downloaded again",
+			protocol: "http",
+			beforeFunc: func(t *WithT, obj *sourcev1.HelmRepository, checksum string) {
+				obj.Status.ObservedGeneration = obj.Generation
+				obj.Status.Artifact = &sourcev1.Artifact{
+					Path:     "helmrepository/default/not-modified/index-" + checksum + ".yaml",
+					Revision: checksum,
+					Checksum: checksum,
+				}
+				t.Expect(testStorage.MkdirAll(*obj.Status.Artifact)).To(Succeed())
+				t.Expect(os.WriteFile(testStorage.LocalPath(*obj.Status.Artifact), []byte("index"), 0o600)).To(Succeed())
+				obj.Status.IndexLastModified = time.Now().Add(time.Hour).UTC().Format(http.TimeFormat)
+				conditions.MarkReconciling(obj, meta.ProgressingReason, "foo")
+				conditions.MarkUnknown(obj, meta.ReadyCondition, "foo", "bar")
+			},
+			assertConditions: []metav1.Condition{
+				*conditions.TrueCondition(meta.ReconcilingCondition, meta.ProgressingReason, "foo"),
+				*conditions.UnknownCondition(meta.ReadyCondition, "foo", "bar"),
+			},
+			afterFunc: func(t *WithT, obj *sourcev1.HelmRepository, artifact sourcev1.Artifact, chartRepo repository.ChartRepository) {
+				// The stored Artifact is reused without downloading the index.
+				t.Expect(chartRepo.CachePath).To(BeEmpty())
+				t.Expect(chartRepo.IndexValidators.LastModified).To(Equal(obj.Status.IndexLastModified))
+				t.Expect(artifact).To(Equal(*obj.Status.Artifact))
+			},
+			want: sreconcile.ResultSuccess,
+		},
 		{
 			name:     "cached index with different checksum",
 			protocol: "http",
@@ -706,10 +760,21 @@ func TestHelmRepositoryReconciler_reconcileSource(t *testing.T) {
 
 			// NOTE: checksum will be empty in beforeFunc for invalid repo
 			// configurations as the client can't get the repo.
+			storage := *testStorage
+			if tt.checksumAlgorithm != "" {
+				storage.ChecksumAlgorithm = tt.checksumAlgorithm
+			}
+
 			var indexChecksum string
 			if validSecret {
 				indexChecksum, err = newChartRepo.CacheIndex()
 				g.Expect(err).ToNot(HaveOccurred())
+				if storage.ChecksumAlgorithm == ChecksumSHA512 {
+					f, err := os.Open(newChartRepo.CachePath)
+					g.Expect(err).ToNot(HaveOccurred())
+					indexChecksum = storage.Checksum(f)
+					f.Close()
+				}
 			}
 
 			if tt.beforeFunc != nil {
@@ -719,7 +784,7 @@ func TestHelmRepositoryReconciler_reconcileSource(t *testing.T) {
 			r := &HelmRepositoryReconciler{
 				EventRecorder: record.NewFakeRecorder(32),
 				Client:        builder.Build(),
-				Storage:       testStorage,
+				Storage:       &storage,
 				Getters:       testGetters,
 				patchOptions:  getPatchOptions(helmRepositoryReadyCondition.Owned, "sc"),
 			}
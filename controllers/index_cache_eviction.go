@@ -0,0 +1,62 @@
+/*
+Copyright 2022 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"sync"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	sourcev1 "github.com/fluxcd/source-controller/api/v1beta2"
+)
+
+// indexCacheEvictions records the values of the
+// sourcev1.IndexCacheEvictionAnnotation of objects which were handled, to
+// evict the cached index once per value. Like the cache itself, the record is
+// kept in memory, and is lost together with the cached indexes on a restart.
+type indexCacheEvictions struct {
+	mu      sync.Mutex
+	handled map[types.UID]string
+}
+
+// requested returns true if the eviction annotation of the given object is
+// set to a value which was not handled yet, and records it as handled.
+func (e *indexCacheEvictions) requested(obj metav1.Object) bool {
+	val := obj.GetAnnotations()[sourcev1.IndexCacheEvictionAnnotation]
+	if val == "" {
+		return false
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.handled == nil {
+		e.handled = make(map[types.UID]string)
+	}
+	if e.handled[obj.GetUID()] == val {
+		return false
+	}
+	e.handled[obj.GetUID()] = val
+	return true
+}
+
+// forget removes the record of the given object, e.g. when it is deleted.
+func (e *indexCacheEvictions) forget(obj metav1.Object) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	delete(e.handled, obj.GetUID())
+}
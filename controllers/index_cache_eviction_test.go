@@ -0,0 +1,60 @@
+/*
+Copyright 2022 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	sourcev1 "github.com/fluxcd/source-controller/api/v1beta2"
+)
+
+func TestIndexCacheEvictions_requested(t *testing.T) {
+	g := NewWithT(t)
+
+	obj := &sourcev1.HelmChart{
+		ObjectMeta: metav1.ObjectMeta{Name: "chart", Namespace: "default", UID: "uid"},
+	}
+	setAnnotation := func(val string) {
+		obj.SetAnnotations(map[string]string{sourcev1.IndexCacheEvictionAnnotation: val})
+	}
+
+	var e indexCacheEvictions
+	g.Expect(e.requested(obj)).To(BeFalse())
+
+	// Every value is handled once
+	setAnnotation("2022-12-01T10:00:00Z")
+	g.Expect(e.requested(obj)).To(BeTrue())
+	g.Expect(e.requested(obj)).To(BeFalse())
+	setAnnotation("2022-12-01T11:00:00Z")
+	g.Expect(e.requested(obj)).To(BeTrue())
+	g.Expect(e.requested(obj)).To(BeFalse())
+
+	// Objects are tracked separately
+	other := obj.DeepCopy()
+	other.UID = "other-uid"
+	g.Expect(e.requested(other)).To(BeTrue())
+
+	setAnnotation("")
+	g.Expect(e.requested(obj)).To(BeFalse())
+
+	e.forget(other)
+	g.Expect(e.handled).ToNot(HaveKey(other.UID))
+	g.Expect(e.requested(other)).To(BeTrue())
+}
@@ -0,0 +1,112 @@
+/*
+Copyright 2022 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/util/workqueue"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"github.com/fluxcd/pkg/runtime/logger"
+)
+
+const (
+	// namespaceLimitBaseDelay is the delay after which a request which
+	// exceeded the concurrency limit of its namespace is first retried.
+	namespaceLimitBaseDelay = time.Second
+	// namespaceLimitMaxDelay is the maximum delay after which a request
+	// which repeatedly exceeded the concurrency limit of its namespace is
+	// retried.
+	namespaceLimitMaxDelay = time.Minute
+	// namespaceLimitJitter is the maximum factor by which the retry delay
+	// is randomly extended, which spreads the retries of the requests of a
+	// namespace over time.
+	namespaceLimitJitter = 0.5
+)
+
+// namespaceLimiter is a reconcile.Reconciler which limits the number of
+// concurrent reconciles of the objects in a namespace, so that a namespace
+// with many objects can not occupy all the workers of a controller. Requests
+// exceeding the limit of their namespace are requeued after a jittered delay,
+// which grows exponentially for each request while its namespace stays at the
+// limit, and frees the worker for the objects of other namespaces without
+// polling the namespace at a fixed interval.
+type namespaceLimiter struct {
+	reconcile.Reconciler
+
+	limit   int
+	backoff workqueue.RateLimiter
+
+	mu       sync.Mutex
+	inFlight map[string]int
+}
+
+// withNamespaceLimit returns the given reconcile.Reconciler wrapped in a
+// namespaceLimiter with the given limit, or the reconcile.Reconciler itself
+// if the limit is not positive.
+func withNamespaceLimit(r reconcile.Reconciler, limit int) reconcile.Reconciler {
+	if limit <= 0 {
+		return r
+	}
+	return &namespaceLimiter{
+		Reconciler: r,
+		limit:      limit,
+		backoff:    workqueue.NewItemExponentialFailureRateLimiter(namespaceLimitBaseDelay, namespaceLimitMaxDelay),
+		inFlight:   make(map[string]int),
+	}
+}
+
+// Reconcile reconciles the request with the wrapped reconcile.Reconciler if
+// the namespace of the request is below its concurrency limit, or requeues
+// it with backoff otherwise.
+func (l *namespaceLimiter) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	if !l.acquire(req.Namespace) {
+		delay := wait.Jitter(l.backoff.When(req), namespaceLimitJitter)
+		ctrl.LoggerFrom(ctx).V(logger.DebugLevel).Info("concurrency limit of namespace reached, requeueing",
+			"limit", l.limit, "requeueAfter", delay)
+		return ctrl.Result{RequeueAfter: delay}, nil
+	}
+	l.backoff.Forget(req)
+	defer l.release(req.Namespace)
+	return l.Reconciler.Reconcile(ctx, req)
+}
+
+// acquire reserves a reconcile of the given namespace, and returns false if
+// the namespace is at its limit.
+func (l *namespaceLimiter) acquire(namespace string) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.inFlight[namespace] >= l.limit {
+		return false
+	}
+	l.inFlight[namespace]++
+	return true
+}
+
+// release releases a reconcile of the given namespace reserved with acquire.
+func (l *namespaceLimiter) release(namespace string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.inFlight[namespace]--; l.inFlight[namespace] <= 0 {
+		delete(l.inFlight, namespace)
+	}
+}
@@ -0,0 +1,86 @@
+/*
+Copyright 2022 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+func TestNamespaceLimiter_Reconcile(t *testing.T) {
+	g := NewWithT(t)
+
+	started := make(chan string)
+	release := make(chan struct{})
+	inner := reconcile.Func(func(_ context.Context, req ctrl.Request) (ctrl.Result, error) {
+		started <- req.Namespace
+		<-release
+		return ctrl.Result{}, nil
+	})
+
+	g.Expect(withNamespaceLimit(inner, 0)).To(BeAssignableToTypeOf(inner))
+
+	r := withNamespaceLimit(inner, 1)
+	request := func(namespace string) ctrl.Request {
+		return ctrl.Request{NamespacedName: types.NamespacedName{Namespace: namespace, Name: "obj"}}
+	}
+	done := make(chan ctrl.Result, 2)
+	reconcileAsync := func(namespace string) {
+		go func() {
+			result, _ := r.Reconcile(context.TODO(), request(namespace))
+			done <- result
+		}()
+		g.Expect(<-started).To(Equal(namespace))
+	}
+
+	// A second request of the same namespace is requeued with a jittered
+	// backoff while the first is in flight, but those of other namespaces
+	// are not
+	reconcileAsync("tenant-a")
+	waiting := ctrl.Request{NamespacedName: types.NamespacedName{Namespace: "tenant-a", Name: "waiting"}}
+	for _, delay := range []time.Duration{namespaceLimitBaseDelay, 2 * namespaceLimitBaseDelay, 4 * namespaceLimitBaseDelay} {
+		result, err := r.Reconcile(context.TODO(), waiting)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(result.RequeueAfter).To(BeNumerically(">=", delay))
+		g.Expect(result.RequeueAfter).To(BeNumerically("<=", time.Duration(float64(delay)*(1+namespaceLimitJitter))))
+	}
+	g.Expect(r.(*namespaceLimiter).backoff.NumRequeues(waiting)).To(Equal(3))
+	reconcileAsync("tenant-b")
+
+	release <- struct{}{}
+	release <- struct{}{}
+	g.Expect((<-done).RequeueAfter).To(BeZero())
+	g.Expect((<-done).RequeueAfter).To(BeZero())
+
+	// The waiting request is admitted after the in flight request finished,
+	// which resets its backoff
+	go func() {
+		result, _ := r.Reconcile(context.TODO(), waiting)
+		done <- result
+	}()
+	g.Expect(<-started).To(Equal("tenant-a"))
+	close(release)
+	g.Expect((<-done).RequeueAfter).To(BeZero())
+	g.Expect(r.(*namespaceLimiter).backoff.NumRequeues(waiting)).To(BeZero())
+	g.Expect(r.(*namespaceLimiter).inFlight).To(BeEmpty())
+}
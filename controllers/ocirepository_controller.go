@@ -17,17 +17,24 @@ limitations under the License.
 package controllers
 
 import (
+	"bytes"
 	"context"
 	"crypto/tls"
 	"crypto/x509"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
+	"io/fs"
+	"math"
+	"net"
 	"net/http"
 	"os"
+	"path"
 	"path/filepath"
 	"sort"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/Masterminds/semver/v3"
@@ -38,10 +45,21 @@ import (
 	"github.com/google/go-containerregistry/pkg/name"
 	gcrv1 "github.com/google/go-containerregistry/pkg/v1"
 	"github.com/google/go-containerregistry/pkg/v1/remote"
+	gcrtypes "github.com/google/go-containerregistry/pkg/v1/types"
+	"golang.org/x/sync/errgroup"
+	"golang.org/x/sync/semaphore"
+	"golang.org/x/time/rate"
+	"helm.sh/helm/v3/pkg/chartutil"
+	helmreg "helm.sh/helm/v3/pkg/registry"
+	authenticationv1 "k8s.io/api/authentication/v1"
 	corev1 "k8s.io/api/core/v1"
+	apierrs "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/client-go/kubernetes"
 	kuberecorder "k8s.io/client-go/tools/record"
 	"k8s.io/utils/pointer"
 
@@ -63,16 +81,22 @@ import (
 	"github.com/fluxcd/pkg/runtime/predicates"
 	rreconcile "github.com/fluxcd/pkg/runtime/reconcile"
 	"github.com/fluxcd/pkg/sourceignore"
-	"github.com/fluxcd/pkg/untar"
 	"github.com/fluxcd/pkg/version"
 
 	sourcev1 "github.com/fluxcd/source-controller/api/v1beta2"
+	"github.com/fluxcd/source-controller/internal/archive"
 	serror "github.com/fluxcd/source-controller/internal/error"
+	"github.com/fluxcd/source-controller/internal/features"
 	sreconcile "github.com/fluxcd/source-controller/internal/reconcile"
 	"github.com/fluxcd/source-controller/internal/reconcile/summarize"
 	"github.com/fluxcd/source-controller/internal/util"
 )
 
+// maxConcurrentOCILayerFetches is the upper bound on the goroutines used to
+// fetch the layers of an OCI artifact in parallel. The layers are typically
+// fetched from the same registry host, which is why this is kept small.
+const maxConcurrentOCILayerFetches = 4
+
 // ociRepositoryReadyCondition contains the information required to summarize a
 // v1beta2.OCIRepository Ready Condition.
 var ociRepositoryReadyCondition = summarize.Conditions{
@@ -83,6 +107,9 @@ var ociRepositoryReadyCondition = summarize.Conditions{
 		sourcev1.ArtifactOutdatedCondition,
 		sourcev1.ArtifactInStorageCondition,
 		sourcev1.SourceVerifiedCondition,
+		sourcev1.ValidatedCondition,
+		sourcev1.TagListIncompleteCondition,
+		sourcev1.CredentialExpiringCondition,
 		meta.ReadyCondition,
 		meta.ReconcilingCondition,
 		meta.StalledCondition,
@@ -130,10 +157,93 @@ type OCIRepositoryReconciler struct {
 	helper.Metrics
 	kuberecorder.EventRecorder
 
-	Storage           *Storage
-	ControllerName    string
+	Storage        *Storage
+	ControllerName string
+
+	// FailureThreshold is the number of consecutive reconciliations failing
+	// to fetch, after which the object is marked as stalled. Zero disables it.
+	FailureThreshold int64
+
+	// IntervalJitterPercentage is the maximum percentage of the interval by
+	// which the requeue after a successful reconciliation is increased or
+	// decreased, to spread the reconciliations of objects with the same
+	// interval. The jitter is deterministic per object. Zero disables it.
+	IntervalJitterPercentage int
+
+	// CredentialExpiryWindow is the duration before the expiry of the
+	// registry credentials within which a warning event is emitted. Zero
+	// disables it.
+	CredentialExpiryWindow time.Duration
+
 	requeueDependency time.Duration
 
+	// verificationRetryBase and verificationRetryMax configure the default
+	// exponential backoff for retrying failed verifications.
+	verificationRetryBase time.Duration
+	verificationRetryMax  time.Duration
+
+	// garbageCollectOptions configures the garbage collection of the
+	// artifacts of the objects.
+	garbageCollectOptions GarbageCollectOptions
+
+	// features is the map of feature gates, and their state.
+	features map[string]bool
+
+	// RegistryRecorder records the outcome and duration of the pull and
+	// verify operations against the registries, and the duration and bytes
+	// downloaded of the pulls of artifacts.
+	RegistryRecorder *soci.RegistryRecorder
+
+	// AllowedRegistryDomains restricts the registries from which artifacts
+	// are pulled. An empty list allows any registry.
+	AllowedRegistryDomains soci.DomainAllowlist
+
+	// DefaultServiceAccountPullSecrets enables the use of the image pull
+	// secrets of the default service account of the namespace, for objects
+	// which do not configure any credentials.
+	DefaultServiceAccountPullSecrets bool
+
+	// Mirrors configures the mirrors of registries, from which artifacts are
+	// pulled before falling back to the upstream registry.
+	Mirrors *soci.Mirrors
+
+	// UserAgent overrides the User-Agent of the requests to the registries
+	// when set.
+	UserAgent string
+
+	// RegistryRetries is the number of times the idempotent requests to a
+	// registry failing with a server error are retried within a
+	// reconciliation, zero disables the retries.
+	RegistryRetries int
+
+	// RegistryRetryBackoff is the delay before the first retry of a request to
+	// a registry, which is doubled for every further retry.
+	RegistryRetryBackoff time.Duration
+
+	// DisableRekorLookups disables the lookups in the Rekor transparency log
+	// of the keyless verification, for air-gapped environments.
+	DisableRekorLookups bool
+
+	// DisableKeylessVerification rejects the verification of objects without
+	// a secret reference with public keys, instead of verifying them with the
+	// keyless method, for environments which require explicit keys.
+	DisableKeylessVerification bool
+
+	// MirrorRateLimiter limits the rate at which artifacts are copied to the
+	// mirror registries configured in the spec of the objects, shared by all
+	// objects. The copies are not limited when nil.
+	MirrorRateLimiter *rate.Limiter
+
+	// TokenClient requests the tokens of the service accounts of objects
+	// using a cloud provider, which are exchanged for registry credentials
+	// when the service account is configured for workload identity. The
+	// workload identity of service accounts is not used when nil.
+	TokenClient kubernetes.Interface
+
+	// workloadIdentity returns the WorkloadIdentity of a cloud provider,
+	// defaulting to soci.NewWorkloadIdentity.
+	workloadIdentity func(provider string, transport http.RoundTripper) (soci.WorkloadIdentity, error)
+
 	patchOptions []patch.Option
 }
 
@@ -141,6 +251,24 @@ type OCIRepositoryReconcilerOptions struct {
 	MaxConcurrentReconciles   int
 	DependencyRequeueInterval time.Duration
 	RateLimiter               ratelimiter.RateLimiter
+
+	// MaxConcurrentReconcilesPerNamespace limits the number of concurrent
+	// reconciles of the objects in a namespace. Zero means no limit.
+	MaxConcurrentReconcilesPerNamespace int
+
+	// VerificationRetryBase is the delay before retrying a failed
+	// verification, doubled on every consecutive failure. Zero disables the
+	// backoff, in which case failed verifications are retried at the
+	// interval of the object.
+	VerificationRetryBase time.Duration
+	// VerificationRetryMax is the maximum delay before retrying a failed
+	// verification. Zero means no maximum.
+	VerificationRetryMax time.Duration
+
+	// GarbageCollection configures the timeout of the garbage collection of
+	// the artifacts of the objects, and overrides the retention settings of
+	// the Storage for them.
+	GarbageCollection GarbageCollectOptions
 }
 
 // SetupWithManager sets up the controller with the Manager.
@@ -152,6 +280,13 @@ func (r *OCIRepositoryReconciler) SetupWithManagerAndOptions(mgr ctrl.Manager, o
 	r.patchOptions = getPatchOptions(ociRepositoryReadyCondition.Owned, r.ControllerName)
 
 	r.requeueDependency = opts.DependencyRequeueInterval
+	r.verificationRetryBase = opts.VerificationRetryBase
+	r.verificationRetryMax = opts.VerificationRetryMax
+	r.garbageCollectOptions = opts.GarbageCollection
+
+	if r.features == nil {
+		r.features = features.FeatureGates()
+	}
 
 	return ctrl.NewControllerManagedBy(mgr).
 		For(&sourcev1.OCIRepository{}, builder.WithPredicates(
@@ -162,13 +297,14 @@ func (r *OCIRepositoryReconciler) SetupWithManagerAndOptions(mgr ctrl.Manager, o
 			RateLimiter:             opts.RateLimiter,
 			RecoverPanic:            true,
 		}).
-		Complete(r)
+		Complete(withNamespaceLimit(r, opts.MaxConcurrentReconcilesPerNamespace))
 }
 
 // +kubebuilder:rbac:groups=source.toolkit.fluxcd.io,resources=ocirepositories,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=source.toolkit.fluxcd.io,resources=ocirepositories/status,verbs=get;update;patch
 // +kubebuilder:rbac:groups=source.toolkit.fluxcd.io,resources=ocirepositories/finalizers,verbs=get;create;update;patch;delete
 // +kubebuilder:rbac:groups="",resources=events,verbs=create;patch
+// +kubebuilder:rbac:groups="",resources=namespaces,verbs=get;list;watch
 
 func (r *OCIRepositoryReconciler) Reconcile(ctx context.Context, req ctrl.Request) (result ctrl.Result, retErr error) {
 	start := time.Now()
@@ -203,8 +339,11 @@ func (r *OCIRepositoryReconciler) Reconcile(ctx context.Context, req ctrl.Reques
 				summarize.ErrorActionHandler,
 				summarize.RecordReconcileReq,
 			),
-			summarize.WithResultBuilder(sreconcile.AlwaysRequeueResultBuilder{RequeueAfter: obj.GetRequeueAfter()}),
+			summarize.WithResultBuilder(sreconcile.AlwaysRequeueResultBuilder{
+				RequeueAfter: sreconcile.JitterInterval(obj.GetRequeueAfter(), r.IntervalJitterPercentage, string(obj.GetUID())),
+			}),
 			summarize.WithPatchFieldOwner(r.ControllerName),
+			summarize.WithFailureThreshold(r.FailureThreshold, sourcev1.FetchFailedCondition),
 		}
 		result, retErr = summarizeHelper.SummarizeAndPatch(ctx, obj, summarizeOpts...)
 
@@ -233,6 +372,14 @@ func (r *OCIRepositoryReconciler) Reconcile(ctx context.Context, req ctrl.Reques
 		return
 	}
 
+	// Validate the configuration if requested, next to the reconciliation
+	// of the object
+	if obj.GetAnnotations()[sourcev1.OCIValidateAnnotation] == "true" {
+		r.reconcileValidation(ctx, obj)
+	} else {
+		conditions.Delete(obj, sourcev1.ValidatedCondition)
+	}
+
 	// Reconcile actual object
 	reconcilers := []ociRepositoryReconcileFunc{
 		r.reconcileStorage,
@@ -243,6 +390,79 @@ func (r *OCIRepositoryReconciler) Reconcile(ctx context.Context, req ctrl.Reques
 	return
 }
 
+// reconcileValidation validates the configuration of the given object by
+// resolving the credentials, transport, artifact URL and revision, and by
+// verifying the signature of the artifact if configured. It stops before the
+// artifact is pulled.
+//
+// The outcome is only recorded in the sourcev1.ValidatedCondition and as an
+// event. It does not affect the other conditions of the object, which are
+// the result of the regular reconciliation that follows.
+func (r *OCIRepositoryReconciler) reconcileValidation(ctx context.Context, obj *sourcev1.OCIRepository) {
+	ctxTimeout, cancel := context.WithTimeout(ctx, obj.Spec.Timeout.Duration)
+	defer cancel()
+
+	revision, reason, err := r.validate(ctxTimeout, obj)
+	if err != nil {
+		conditions.MarkFalse(obj, sourcev1.ValidatedCondition, reason, "validation failed: %s", err)
+		r.eventLogf(ctx, obj, corev1.EventTypeWarning, reason, "validation failed: %s", err)
+		return
+	}
+
+	conditions.MarkTrue(obj, sourcev1.ValidatedCondition, meta.SucceededReason, "validated revision '%s'", revision)
+	r.eventLogf(ctx, obj, corev1.EventTypeNormal, meta.SucceededReason, "validated revision '%s' for '%s'", revision, obj.Spec.URL)
+}
+
+// validate runs the remote operations of reconcileSource up to the
+// verification of the artifact. It returns the revision of the artifact, or
+// the reason and error of the first failing operation.
+func (r *OCIRepositoryReconciler) validate(ctx context.Context, obj *sourcev1.OCIRepository) (string, string, error) {
+	opts, err := r.remoteOptionsFor(ctx, obj)
+	if err != nil {
+		return "", sourcev1.AuthenticationFailedReason, err
+	}
+
+	url, _, err := r.getArtifactURL(obj, opts)
+	if err != nil {
+		if _, ok := err.(invalidOCIURLError); ok {
+			return "", sourcev1.URLInvalidReason, fmt.Errorf("URL validation failed for '%s': %w", obj.Spec.URL, err)
+		}
+		return "", sourcev1.ReadOperationFailedReason, fmt.Errorf("failed to determine the artifact tag for '%s': %w", obj.Spec.URL, err)
+	}
+	if err := immutableReferenceError(obj, url); err != nil {
+		return "", sourcev1.OCIMutableReferenceReason, err
+	}
+
+	revision, digest, err := r.getRevision(url, opts.craneOpts)
+	if err != nil {
+		return "", pullFailureReason(err), fmt.Errorf("failed to determine artifact digest: %w", err)
+	}
+	// The revision of a digest reference is resolved without a registry
+	// round-trip, ensure the artifact exists
+	if obj.Spec.Reference != nil && obj.Spec.Reference.Digest != "" {
+		if _, err := crane.Head(url, opts.craneOpts...); err != nil {
+			return revision, pullFailureReason(err), fmt.Errorf("failed to fetch artifact manifest: %w", err)
+		}
+	}
+	if err := digestMismatchError(obj, url, digest); err != nil {
+		return revision, sourcev1.OCIDigestMismatchReason, err
+	}
+
+	if obj.Spec.Verify != nil {
+		if obj.Spec.Insecure {
+			return revision, sourcev1.VerificationError, fmt.Errorf("cosign does not support insecure registries")
+		}
+		if err := r.verifySignature(ctx, obj, url, opts.verifyOpts...); err != nil {
+			provider := obj.Spec.Verify.Provider
+			if obj.Spec.Verify.SecretRef == nil {
+				provider = fmt.Sprintf("%s keyless", provider)
+			}
+			return revision, sourcev1.VerificationError, fmt.Errorf("failed to verify the signature using provider '%s': %w", provider, err)
+		}
+	}
+	return revision, "", nil
+}
+
 // reconcile iterates through the ociRepositoryReconcileFunc tasks for the
 // object. It returns early on the first call that returns
 // reconcile.ResultRequeue, or produces an error.
@@ -321,8 +541,6 @@ func (r *OCIRepositoryReconciler) reconcile(ctx context.Context, sp *patch.Seria
 // If this fails, it records v1beta2.FetchFailedCondition=True on the object and returns early.
 func (r *OCIRepositoryReconciler) reconcileSource(ctx context.Context, sp *patch.SerialPatcher,
 	obj *sourcev1.OCIRepository, metadata *sourcev1.Artifact, dir string) (sreconcile.Result, error) {
-	var auth authn.Authenticator
-
 	ctxTimeout, cancel := context.WithTimeout(ctx, obj.Spec.Timeout.Duration)
 	defer cancel()
 
@@ -334,45 +552,45 @@ func (r *OCIRepositoryReconciler) reconcileSource(ctx context.Context, sp *patch
 		conditions.Delete(obj, sourcev1.SourceVerifiedCondition)
 	}
 
-	// Generate the registry credential keychain either from static credentials or using cloud OIDC
-	keychain, err := r.keychain(ctx, obj)
-	if err != nil {
-		e := serror.NewGeneric(
-			fmt.Errorf("failed to get credential: %w", err),
-			sourcev1.AuthenticationFailedReason,
+	// Reject the verification providers which only HelmCharts support
+	if obj.Spec.Verify != nil && obj.Spec.Verify.Provider != "cosign" {
+		e := serror.NewStalling(
+			fmt.Errorf("unsupported verification provider for an OCIRepository: %s", obj.Spec.Verify.Provider),
+			sourcev1.VerificationError,
 		)
-		conditions.MarkTrue(obj, sourcev1.FetchFailedCondition, e.Reason, e.Err.Error())
+		conditions.MarkFalse(obj, sourcev1.SourceVerifiedCondition, e.Reason, e.Err.Error())
 		return sreconcile.ResultEmpty, e
 	}
 
-	if _, ok := keychain.(soci.Anonymous); obj.Spec.Provider != sourcev1.GenericOCIProvider && ok {
-		var authErr error
-		auth, authErr = oidcAuth(ctxTimeout, obj.Spec.URL, obj.Spec.Provider)
-		if authErr != nil && !errors.Is(authErr, oci.ErrUnconfiguredProvider) {
-			e := serror.NewGeneric(
-				fmt.Errorf("failed to get credential from %s: %w", obj.Spec.Provider, authErr),
-				sourcev1.AuthenticationFailedReason,
-			)
-			conditions.MarkTrue(obj, sourcev1.FetchFailedCondition, e.Reason, e.Err.Error())
-			return sreconcile.ResultEmpty, e
-		}
-	}
+	// Record the attempt to fetch the artifact, the outcome of which is
+	// reflected in the FetchFailed condition and the consecutive failures.
+	obj.Status.LastAttemptTime = &metav1.Time{Time: time.Now()}
 
-	// Generate the transport for remote operations
-	transport, err := r.transport(ctx, obj)
+	// Generate the options for remote operations from the credentials and transport
+	opts, err := r.remoteOptionsFor(ctxTimeout, obj)
 	if err != nil {
-		e := serror.NewGeneric(
-			fmt.Errorf("failed to generate transport for '%s': %w", obj.Spec.URL, err),
-			sourcev1.AuthenticationFailedReason,
-		)
+		e := serror.NewGeneric(err, sourcev1.AuthenticationFailedReason)
 		conditions.MarkTrue(obj, sourcev1.FetchFailedCondition, e.Reason, e.Err.Error())
 		return sreconcile.ResultEmpty, e
 	}
 
-	opts := makeRemoteOptions(ctx, obj, transport, keychain, auth)
+	// Warn about reference fields which are ignored in favour of another. As
+	// this only changes with the spec, the warning is limited to new
+	// generations which have not been observed yet
+	if obj.Generation != obj.Status.ObservedGeneration {
+		if msg := ambiguousReferenceWarning(obj.Spec.Reference); msg != "" {
+			r.eventLogf(ctx, obj, corev1.EventTypeWarning, ambiguousReferenceReason, msg)
+		}
+	}
+
+	// Bound the resolution of the artifact reference by the list timeout, so
+	// that slow listing, pulling or verification operations do not starve
+	// each other
+	listCtx, cancelList := context.WithTimeout(ctx, obj.GetListTimeout())
+	defer cancelList()
 
 	// Determine which artifact revision to pull
-	url, err := r.getArtifactURL(obj, opts.craneOpts)
+	url, truncated, err := r.getArtifactURL(obj, opts.withContext(listCtx))
 	if err != nil {
 		if _, ok := err.(invalidOCIURLError); ok {
 			e := serror.NewStalling(
@@ -386,25 +604,51 @@ func (r *OCIRepositoryReconciler) reconcileSource(ctx context.Context, sp *patch
 			fmt.Errorf("failed to determine the artifact tag for '%s': %w", obj.Spec.URL, err),
 			sourcev1.ReadOperationFailedReason)
 		conditions.MarkTrue(obj, sourcev1.FetchFailedCondition, e.Reason, e.Err.Error())
+		return sreconcile.ResultEmpty, r.throttled(ctx, obj, opts, e)
+	}
+
+	// Warn about a tag selected from a list of tags which may be incomplete
+	if truncated {
+		conditions.MarkTrue(obj, sourcev1.TagListIncompleteCondition, sourcev1.OCITagListTruncatedReason,
+			"the registry returned a full page of %d tags without a link to the next page for '%s', the tag list may be incomplete and '%s' may not be the latest match",
+			tagListPageSize, obj.Spec.URL, url)
+	} else {
+		conditions.Delete(obj, sourcev1.TagListIncompleteCondition)
+	}
+
+	// Enforce the reference policy before pulling anything
+	if err := immutableReferenceError(obj, url); err != nil {
+		e := serror.NewStalling(err, sourcev1.OCIMutableReferenceReason)
+		conditions.MarkTrue(obj, sourcev1.FetchFailedCondition, e.Reason, e.Err.Error())
 		return sreconcile.ResultEmpty, e
 	}
 
-	// Get the upstream revision from the artifact digest
-	revision, err := r.getRevision(url, opts.craneOpts)
+	// Get the upstream revision from the artifact digest. Tags are always
+	// resolved using the upstream registry, as a mirror may serve an
+	// outdated artifact for a tag, e.g. a pull-through cache which does not
+	// revalidate its tags.
+	revision, digest, err := r.getRevision(url, opts.withContext(listCtx).craneOpts)
 	if err != nil {
 		e := serror.NewGeneric(
 			fmt.Errorf("failed to determine artifact digest: %w", err),
-			sourcev1.OCIPullFailedReason,
+			pullFailureReason(err),
 		)
 		conditions.MarkTrue(obj, sourcev1.FetchFailedCondition, e.Reason, e.Err.Error())
+		return sreconcile.ResultEmpty, r.throttled(ctx, obj, opts, e)
+	}
+
+	// Cross-check the resolved digest before pulling anything
+	if err := digestMismatchError(obj, url, digest); err != nil {
+		e := serror.NewGeneric(err, sourcev1.OCIDigestMismatchReason)
+		conditions.MarkTrue(obj, sourcev1.FetchFailedCondition, e.Reason, e.Err.Error())
 		return sreconcile.ResultEmpty, e
 	}
-	metaArtifact := &sourcev1.Artifact{Revision: revision}
+	metaArtifact := &sourcev1.Artifact{Revision: revision, Digest: digest}
 	metaArtifact.DeepCopyInto(metadata)
 
 	// Mark observations about the revision on the object
 	defer func() {
-		if !obj.GetArtifact().HasRevision(revision) {
+		if !r.equivalentRevision(obj, revision) {
 			message := fmt.Sprintf("new revision '%s' for '%s'", revision, url)
 			if obj.GetArtifact() != nil {
 				conditions.MarkTrue(obj, sourcev1.ArtifactOutdatedCondition, "NewRevision", message)
@@ -421,12 +665,16 @@ func (r *OCIRepositoryReconciler) reconcileSource(ctx context.Context, sp *patch
 	// - the upstream digest differs from the one in storage (revision drift)
 	// - the OCIRepository spec has changed (generation drift)
 	// - the previous reconciliation resulted in a failed artifact verification (retry with exponential backoff)
+	// - the verification interval has elapsed since the last successful verification
 	if obj.Spec.Verify == nil {
 		// Remove old observations if verification was disabled
 		conditions.Delete(obj, sourcev1.SourceVerifiedCondition)
-	} else if !obj.GetArtifact().HasRevision(revision) ||
+		obj.Status.LastVerificationTime = nil
+		obj.Status.VerificationRetry = nil
+	} else if !r.equivalentRevision(obj, revision) ||
 		conditions.GetObservedGeneration(obj, sourcev1.SourceVerifiedCondition) != obj.Generation ||
-		conditions.IsFalse(obj, sourcev1.SourceVerifiedCondition) {
+		conditions.IsFalse(obj, sourcev1.SourceVerifiedCondition) ||
+		verificationDue(obj, time.Now()) {
 
 		// Insecure is not supported for verification
 		if obj.Spec.Insecure {
@@ -438,7 +686,41 @@ func (r *OCIRepositoryReconciler) reconcileSource(ctx context.Context, sp *patch
 			return sreconcile.ResultEmpty, e
 		}
 
-		err := r.verifySignature(ctx, obj, url, opts.verifyOpts...)
+		// Only back off between the failed verifications of the same revision
+		// and spec
+		base, max := r.verificationRetryBackoff(obj)
+		retry := obj.Status.VerificationRetry
+		if base <= 0 || (retry != nil && (retry.Revision != revision ||
+			conditions.GetObservedGeneration(obj, sourcev1.SourceVerifiedCondition) != obj.Generation)) {
+			retry = nil
+		}
+		obj.Status.VerificationRetry = retry
+
+		// Wait for the next attempt of a failed verification, which is
+		// recorded in the status to survive restarts of the controller
+		if retry != nil {
+			if wait := time.Until(retry.NextAttemptTime.Time); wait > 0 {
+				return sreconcile.ResultEmpty, &serror.Waiting{
+					Err: fmt.Errorf("%s, next retry in %s at %s",
+						conditions.GetMessage(obj, sourcev1.SourceVerifiedCondition),
+						wait.Round(time.Second), retry.NextAttemptTime.Format(time.RFC3339)),
+					Reason:       sourcev1.VerificationError,
+					RequeueAfter: wait,
+					Config: serror.Config{
+						Event: serror.EventTypeNone,
+					},
+				}
+			}
+		}
+
+		// Verify the artifact by the resolved digest, which is the one
+		// pulled, instead of resolving the tag again
+		verifyStart := time.Now()
+		verifyURL, err := digestReference(url, digest)
+		if err == nil {
+			err = r.verifySignature(ctx, obj, verifyURL, opts.verifyOpts...)
+		}
+		r.recordOperation(soci.OperationVerify, url, verifyStart, err)
 		if err != nil {
 			provider := obj.Spec.Verify.Provider
 			if obj.Spec.Verify.SecretRef == nil {
@@ -449,29 +731,199 @@ func (r *OCIRepositoryReconciler) reconcileSource(ctx context.Context, sp *patch
 				sourcev1.VerificationError,
 			)
 			conditions.MarkFalse(obj, sourcev1.SourceVerifiedCondition, e.Reason, e.Err.Error())
+
+			// Retrying does not help until the spec changes
+			if errors.Is(err, errKeylessVerificationDisabled) {
+				return sreconcile.ResultEmpty, serror.NewStalling(e.Err, e.Reason)
+			}
+
+			// Honor the delay requested by a registry rate limiting the
+			// verification requests
+			if opts.throttle.RetryAfter() > 0 {
+				return sreconcile.ResultEmpty, r.throttled(ctx, obj, opts, e)
+			}
+
+			// Back off exponentially between retries, to not hammer the
+			// registry on transient errors.
+			if base > 0 {
+				failures := 1
+				if retry != nil {
+					failures = retry.Failures + 1
+				}
+				delay := verificationRetryDelay(failures, base, max)
+				next := metav1.NewTime(time.Now().Add(delay))
+				obj.Status.VerificationRetry = &sourcev1.OCIVerificationRetry{
+					Revision:        revision,
+					Failures:        failures,
+					NextAttemptTime: next,
+				}
+				return sreconcile.ResultEmpty, &serror.Waiting{
+					Err: fmt.Errorf("%w, next retry in %s at %s",
+						e.Err, delay, next.Format(time.RFC3339)),
+					Reason:       e.Reason,
+					RequeueAfter: delay,
+					Config: serror.Config{
+						Event: corev1.EventTypeWarning,
+						Log:   true,
+					},
+				}
+			}
 			return sreconcile.ResultEmpty, e
 		}
+		obj.Status.VerificationRetry = nil
+
+		obj.Status.LastVerificationTime = &metav1.Time{Time: time.Now()}
+		if attestation := obj.Spec.Verify.Attestation; attestation != nil {
+			conditions.MarkTrue(obj, sourcev1.SourceVerifiedCondition, meta.SucceededReason,
+				"verified signature and '%s' attestation of revision %s", attestation.GetPredicateType(), revision)
+		} else {
+			conditions.MarkTrue(obj, sourcev1.SourceVerifiedCondition, meta.SucceededReason, "verified signature of revision %s", revision)
+		}
+	}
 
-		conditions.MarkTrue(obj, sourcev1.SourceVerifiedCondition, meta.SucceededReason, "verified signature of revision %s", revision)
+	// Validate the SBOM of the artifact if:
+	// - the upstream digest differs from the one in storage (revision drift)
+	// - the OCIRepository spec has changed (generation drift)
+	// - no SBOM was validated before
+	if obj.Spec.RequireSBOM == nil {
+		// Remove old observations if the SBOM is no longer required
+		obj.Status.SBOMDigest = ""
+	} else if !r.equivalentRevision(obj, revision) ||
+		obj.Status.ObservedGeneration != obj.Generation ||
+		obj.Status.SBOMDigest == "" {
+
+		sbomCtx, cancelSBOM := context.WithTimeout(ctx, obj.GetVerifyTimeout())
+		sbomDigest, err := r.validateSBOM(obj, url, revision, opts.withContext(sbomCtx).craneOpts)
+		cancelSBOM()
+		if err != nil {
+			obj.Status.SBOMDigest = ""
+			e := serror.NewGeneric(
+				fmt.Errorf("failed to validate the %s SBOM of revision %s: %w", obj.Spec.RequireSBOM.Format, revision, err),
+				sourcev1.OCISBOMValidationFailedReason,
+			)
+			conditions.MarkTrue(obj, sourcev1.FetchFailedCondition, e.Reason, e.Err.Error())
+			return sreconcile.ResultEmpty, r.throttled(ctx, obj, opts, e)
+		}
+		obj.Status.SBOMDigest = sbomDigest
 	}
 
-	// Skip pulling if the artifact revision and the source configuration has
+	// Skip pulling if the artifact digest and the source configuration has
 	// not changed.
-	if obj.GetArtifact().HasRevision(revision) && !ociContentConfigChanged(obj) {
+	if r.equivalentRevision(obj, revision) && !ociContentConfigChanged(obj) {
+		// Retry copying the artifact to the mirror registry, until the
+		// current digest is copied with the current spec
+		if obj.Status.MirroredDigest != digest || obj.Status.ObservedGeneration != obj.Generation {
+			r.mirrorArtifact(ctx, obj, url, digest, opts)
+		}
 		conditions.Delete(obj, sourcev1.FetchFailedCondition)
 		return sreconcile.ResultSuccess, nil
 	}
 
-	// Pull artifact from the remote container registry
-	img, err := crane.Pull(url, opts.craneOpts...)
+	// Prefer the mirror of the registry if configured to pull the artifact
+	// by its resolved digest, falling back to the upstream registry if the
+	// mirror fails to serve the artifact. The verification and the SBOM
+	// validation above always use the upstream registry.
+	upstreamURL, upstreamOpts := url, opts
+	if mirrorURL, ok := r.Mirrors.Mirror(url); ok {
+		url, opts = mirrorURL, opts.forMirror()
+	}
+
+	// Bound the pull of the artifact, including the download of its layers,
+	// by the pull timeout
+	pullCtx, cancelPull := context.WithTimeout(ctx, obj.GetPullTimeout())
+	defer cancelPull()
+	opts, upstreamOpts = opts.withContext(pullCtx), upstreamOpts.withContext(pullCtx)
+
+	// Ensure an image index contains a manifest for the configured platform
+	var platformOpts []crane.Option
+	if platform := obj.Spec.Platform; platform != nil {
+		indexURL, err := digestReference(url, digest)
+		var found bool
+		if err == nil {
+			found, err = r.hasPlatform(indexURL, platform, opts.craneOpts)
+		}
+		if err != nil {
+			e := serror.NewGeneric(
+				fmt.Errorf("failed to get manifest of '%s': %w", obj.Spec.URL, err),
+				pullFailureReason(err),
+			)
+			conditions.MarkTrue(obj, sourcev1.FetchFailedCondition, e.Reason, e.Err.Error())
+			return sreconcile.ResultEmpty, r.throttled(ctx, obj, opts, e)
+		}
+		if !found {
+			e := serror.NewGeneric(
+				fmt.Errorf("no manifest found for platform '%s' in image index of '%s'", platformString(platform), obj.Spec.URL),
+				sourcev1.OCILayerOperationFailedReason,
+			)
+			conditions.MarkTrue(obj, sourcev1.FetchFailedCondition, e.Reason, e.Err.Error())
+			return sreconcile.ResultEmpty, e
+		}
+		platformOpts = append(platformOpts, crane.WithPlatform(&gcrv1.Platform{
+			OS:           platform.OS,
+			Architecture: platform.Architecture,
+			Variant:      platform.Variant,
+		}))
+	}
+
+	// Record the duration and the bytes downloaded by the pull, once the
+	// layer contents are persisted
+	pullStart, bytesBefore := time.Now(), opts.transfer.BytesRead()
+
+	// Pull artifact from the remote container registry by the resolved
+	// digest, refreshing the credentials once if they expired
+	pull := func() (img gcrv1.Image, err error) {
+		pullURL, err := digestReference(url, digest)
+		if err != nil {
+			return nil, err
+		}
+		pullOpts := append(append([]crane.Option{}, opts.craneOpts...), platformOpts...)
+		pullStart := time.Now()
+		err = soci.RetryOnUnauthorized(opts.auth, func() (err error) {
+			img, err = crane.Pull(pullURL, pullOpts...)
+			return
+		})
+		r.recordOperation(soci.OperationPull, url, pullStart, err)
+		return
+	}
+	img, err := pull()
+	if err != nil && url != upstreamURL {
+		ctrl.LoggerFrom(ctx).Info(fmt.Sprintf("failed to pull artifact from mirror '%s', falling back to upstream", registryHost(url)),
+			"error", err.Error())
+		url, opts = upstreamURL, upstreamOpts
+		img, err = pull()
+	}
 	if err != nil {
 		e := serror.NewGeneric(
 			fmt.Errorf("failed to pull artifact from '%s': %w", obj.Spec.URL, err),
+			pullFailureReason(err),
+		)
+		conditions.MarkTrue(obj, sourcev1.FetchFailedCondition, e.Reason, e.Err.Error())
+		return sreconcile.ResultEmpty, r.throttled(ctx, obj, opts, e)
+	}
+
+	// Record the registry host which served the artifact, and announce it
+	// if the registry is mirrored
+	obj.Status.ObservedEndpoint = registryHost(url)
+	if _, ok := r.Mirrors.Mirror(upstreamURL); ok {
+		endpoint := "mirror"
+		if url == upstreamURL {
+			endpoint = "upstream registry"
+		}
+		r.eventLogf(ctx, obj, corev1.EventTypeNormal, artifactEndpointReason,
+			"pulled revision '%s' from %s '%s'", revision, endpoint, obj.Status.ObservedEndpoint)
+	}
+
+	// Record the platform of the manifest resolved from an image index
+	platform, err := r.resolvePlatform(url, revision, img, opts.craneOpts)
+	if err != nil {
+		e := serror.NewGeneric(
+			fmt.Errorf("failed to determine the platform of the artifact: %w", err),
 			sourcev1.OCIPullFailedReason,
 		)
 		conditions.MarkTrue(obj, sourcev1.FetchFailedCondition, e.Reason, e.Err.Error())
-		return sreconcile.ResultEmpty, e
+		return sreconcile.ResultEmpty, r.throttled(ctx, obj, opts, e)
 	}
+	obj.Status.ObservedPlatform = platform
 
 	// Copy the OCI annotations to the internal artifact metadata
 	manifest, err := img.Manifest()
@@ -483,27 +935,197 @@ func (r *OCIRepositoryReconciler) reconcileSource(ctx context.Context, sp *patch
 		conditions.MarkTrue(obj, sourcev1.FetchFailedCondition, e.Reason, e.Err.Error())
 		return sreconcile.ResultEmpty, e
 	}
+
+	// Reject artifacts of an unexpected kind, which retrying does not fix
+	// until the spec or the artifact changes
+	if err := checkConfigMediaType(obj, manifest); err != nil {
+		e := serror.NewStalling(err, sourcev1.OCIConfigMediaTypeMismatchReason)
+		conditions.MarkTrue(obj, sourcev1.FetchFailedCondition, e.Reason, e.Err.Error())
+		return sreconcile.ResultEmpty, e
+	}
+	if err := checkContainerImage(obj, manifest); err != nil {
+		e := serror.NewGeneric(err, sourcev1.OCILayerOperationFailedReason)
+		conditions.MarkTrue(obj, sourcev1.FetchFailedCondition, e.Reason, e.Err.Error())
+		return sreconcile.ResultEmpty, e
+	}
 	metadata.Metadata = manifest.Annotations
 
-	// Extract the compressed content from the selected layer
-	blob, err := r.selectLayer(obj, img)
+	// Describe the layers of the artifact, to help diagnose the selection
+	r.eventLogf(ctx, obj, eventv1.EventTypeTrace, artifactLayersReason,
+		"artifact has %d layer(s): %s", len(manifest.Layers), summarizeLayers(manifest.Layers, nil))
+
+	// Fetch the selected layers in parallel if instructed, this is only
+	// supported when extracting the layers
+	parallelFetch := obj.GetLayerOperation() == sourcev1.OCILayerExtract && r.features[features.ParallelOCILayerFetch]
+
+	// Extract the compressed content from the selected layer(s), refreshing
+	// the credentials once if they expired during the pull
+	var blob io.ReadCloser
+	var blobDigest gcrv1.Hash
+	var blobMediaType string
+	var layerFiles []layerFile
+	var layers []gcrv1.Layer
+	// Without a layer selector, multiple layers may be extracted
+	streamLayers := !parallelFetch && obj.GetLayerOperation() == sourcev1.OCILayerExtract && obj.GetLayerMediaType() == ""
+	if parallelFetch {
+		// The layers are downloaded outside the working directory, as
+		// its content is archived
+		layersDir, dirErr := util.TempDirForObj("", obj)
+		if dirErr != nil {
+			e := serror.NewGeneric(
+				fmt.Errorf("failed to create temporary directory for layers: %w", dirErr),
+				sourcev1.DirCreationFailedReason,
+			)
+			conditions.MarkTrue(obj, sourcev1.StorageOperationFailedCondition, e.Reason, e.Err.Error())
+			return sreconcile.ResultEmpty, e
+		}
+		defer func() {
+			if err := os.RemoveAll(layersDir); err != nil {
+				ctrl.LoggerFrom(ctx).Error(err, "failed to remove temporary directory for layers")
+			}
+		}()
+
+		err = soci.RetryOnUnauthorized(opts.auth, func() (err error) {
+			layerFiles, err = r.fetchLayers(ctx, obj, img, layersDir)
+			return
+		})
+	} else if streamLayers {
+		err = soci.RetryOnUnauthorized(opts.auth, func() (err error) {
+			layers, err = r.selectLayers(obj, img)
+			return
+		})
+	} else {
+		err = soci.RetryOnUnauthorized(opts.auth, func() (err error) {
+			blob, blobDigest, blobMediaType, err = r.selectLayer(obj, img)
+			return
+		})
+	}
 	if err != nil {
 		e := serror.NewGeneric(err, sourcev1.OCILayerOperationFailedReason)
 		conditions.MarkTrue(obj, sourcev1.FetchFailedCondition, e.Reason, e.Err.Error())
-		return sreconcile.ResultEmpty, e
+		return sreconcile.ResultEmpty, r.throttled(ctx, obj, opts, e)
+	}
+	selected := []gcrv1.Hash{blobDigest}
+	switch {
+	case parallelFetch:
+		selected = make([]gcrv1.Hash, 0, len(layerFiles))
+		for _, f := range layerFiles {
+			selected = append(selected, f.digest)
+		}
+	case streamLayers:
+		selected = make([]gcrv1.Hash, 0, len(layers))
+		for i, l := range layers {
+			digest, err := l.Digest()
+			if err != nil {
+				e := serror.NewGeneric(
+					fmt.Errorf("failed to determine the digest of layer[%v] from artifact: %w", i, err),
+					sourcev1.OCILayerOperationFailedReason,
+				)
+				conditions.MarkTrue(obj, sourcev1.FetchFailedCondition, e.Reason, e.Err.Error())
+				return sreconcile.ResultEmpty, e
+			}
+			selected = append(selected, digest)
+		}
 	}
+	r.eventLogf(ctx, obj, eventv1.EventTypeTrace, artifactLayersReason,
+		"selected %d layer(s) %s: %s", len(selected), layerSelectionReason(obj, manifest),
+		summarizeLayers(manifest.Layers, selected))
 
 	// Persist layer content to storage using the specified operation
 	switch obj.GetLayerOperation() {
 	case sourcev1.OCILayerExtract:
-		if _, err = untar.Untar(blob, dir); err != nil {
+		if parallelFetch {
+			err = untarLayerFiles(layerFiles, dir, archive.Limits{MaxSize: obj.GetMaxSize()})
+		} else if streamLayers {
+			err = untarLayers(layers, dir, archive.Limits{MaxSize: obj.GetMaxSize()})
+		} else {
+			var compression archive.Compression
+			if compression, err = archive.CompressionForMediaType(blobMediaType); err == nil {
+				_, err = archive.UntarCompressed(blob, dir, compression, archive.Limits{MaxSize: obj.GetMaxSize()})
+			}
+		}
+		if err != nil {
+			// Discard the partially extracted content, to never archive it
+			if rmErr := emptyDir(dir); rmErr != nil {
+				ctrl.LoggerFrom(ctx).Error(rmErr, "failed to discard partially extracted layer contents")
+			}
+			reason := sourcev1.OCILayerOperationFailedReason
+			if errors.Is(err, archive.ErrTruncated) {
+				reason = sourcev1.ArchiveTruncatedReason
+			}
 			e := serror.NewGeneric(
 				fmt.Errorf("failed to extract layer contents from artifact: %w", err),
-				sourcev1.OCILayerOperationFailedReason,
+				reason,
 			)
 			conditions.MarkTrue(obj, sourcev1.FetchFailedCondition, e.Reason, e.Err.Error())
 			return sreconcile.ResultEmpty, e
 		}
+
+		// Ensure the extracted content of a Helm chart is a chart, as the
+		// Artifact is then consumed as a packaged chart
+		if manifest.Config.MediaType == helmreg.ConfigMediaType &&
+			(obj.GetLayerMediaType() == "" || obj.GetLayerMediaType() == helmreg.ChartLayerMediaType) {
+			if err := verifyHelmChart(dir); err != nil {
+				e := serror.NewGeneric(
+					fmt.Errorf("failed to extract Helm chart from artifact: %w", err),
+					sourcev1.OCILayerOperationFailedReason,
+				)
+				conditions.MarkTrue(obj, sourcev1.FetchFailedCondition, e.Reason, e.Err.Error())
+				return sreconcile.ResultEmpty, e
+			}
+		}
+
+		// The gzip compressed tarballs selected from a multi-layer artifact
+		// in the absence of a layer selector may all be empty, in which case
+		// a layer selector is needed to select the content
+		if obj.GetLayerMediaType() == "" && len(manifest.Layers) > 1 {
+			entries, err := os.ReadDir(dir)
+			if err == nil && len(entries) == 0 {
+				err = fmt.Errorf("the %d gzip compressed tarball(s) in artifact are empty: "+
+					"set '.spec.layerSelector.mediaType' to select the layer", len(selected))
+			}
+			if err != nil {
+				e := serror.NewGeneric(
+					fmt.Errorf("failed to extract layer contents from artifact: %w", err),
+					sourcev1.OCILayerOperationFailedReason,
+				)
+				conditions.MarkTrue(obj, sourcev1.FetchFailedCondition, e.Reason, e.Err.Error())
+				return sreconcile.ResultEmpty, e
+			}
+		}
+
+		// Reduce the extracted content to the selected path
+		if ls := obj.Spec.LayerSelector; ls != nil && ls.Path != "" {
+			if err := selectPath(dir, ls.Path); err != nil {
+				e := serror.NewGeneric(
+					fmt.Errorf("failed to select path from layer contents: %w", err),
+					sourcev1.OCILayerOperationFailedReason,
+				)
+				conditions.MarkTrue(obj, sourcev1.FetchFailedCondition, e.Reason, e.Err.Error())
+				return sreconcile.ResultEmpty, e
+			}
+		}
+
+		// Ensure the extracted content only contains the expected paths
+		if len(obj.Spec.ExpectedPaths) > 0 {
+			unexpected, err := unexpectedPaths(dir, obj.Spec.ExpectedPaths)
+			if err != nil {
+				e := serror.NewGeneric(
+					fmt.Errorf("failed to verify expected paths: %w", err),
+					sourcev1.OCILayerOperationFailedReason,
+				)
+				conditions.MarkTrue(obj, sourcev1.FetchFailedCondition, e.Reason, e.Err.Error())
+				return sreconcile.ResultEmpty, e
+			}
+			if len(unexpected) > 0 {
+				e := serror.NewGeneric(
+					fmt.Errorf("artifact contains paths not matching the expected paths: %s", formatPaths(unexpected)),
+					sourcev1.OCIUnexpectedPathsReason,
+				)
+				conditions.MarkTrue(obj, sourcev1.FetchFailedCondition, e.Reason, e.Err.Error())
+				return sreconcile.ResultEmpty, e
+			}
+		}
 	case sourcev1.OCILayerCopy:
 		metadata.Path = fmt.Sprintf("%s.tgz", r.digestFromRevision(metadata.Revision))
 		file, err := os.Create(filepath.Join(dir, metadata.Path))
@@ -518,6 +1140,9 @@ func (r *OCIRepositoryReconciler) reconcileSource(ctx context.Context, sp *patch
 		defer file.Close()
 
 		_, err = io.Copy(file, blob)
+		if err == nil {
+			err = file.Close()
+		}
 		if err != nil {
 			e := serror.NewGeneric(
 				fmt.Errorf("failed to copy layer from artifact: %w", err),
@@ -526,6 +1151,25 @@ func (r *OCIRepositoryReconciler) reconcileSource(ctx context.Context, sp *patch
 			conditions.MarkTrue(obj, sourcev1.FetchFailedCondition, e.Reason, e.Err.Error())
 			return sreconcile.ResultEmpty, e
 		}
+
+		// Guard against truncated or corrupted copies of the layer
+		if err := verifyFileDigest(filepath.Join(dir, metadata.Path), blobDigest); err != nil {
+			e := serror.NewGeneric(
+				fmt.Errorf("failed to verify the copied layer: %w", err),
+				sourcev1.OCILayerOperationFailedReason,
+			)
+			conditions.MarkTrue(obj, sourcev1.FetchFailedCondition, e.Reason, e.Err.Error())
+			return sreconcile.ResultEmpty, e
+		}
+
+		// Record the media type of the layer, as the copied content is not
+		// necessarily a tarball
+		annotations := make(map[string]string, len(metadata.Metadata)+1)
+		for k, v := range metadata.Metadata {
+			annotations[k] = v
+		}
+		annotations[sourcev1.OCILayerMediaTypeMetadataKey] = blobMediaType
+		metadata.Metadata = annotations
 	default:
 		e := serror.NewGeneric(
 			fmt.Errorf("unsupported layer operation: %s", obj.GetLayerOperation()),
@@ -534,21 +1178,59 @@ func (r *OCIRepositoryReconciler) reconcileSource(ctx context.Context, sp *patch
 		conditions.MarkTrue(obj, sourcev1.FetchFailedCondition, e.Reason, e.Err.Error())
 		return sreconcile.ResultEmpty, e
 	}
+	r.recordPull(obj, pullStart, opts.transfer.BytesRead()-bytesBefore)
+
+	// Copy the pulled artifact to the mirror registry, if configured
+	r.mirrorArtifact(ctx, obj, url, digest, opts)
 
 	conditions.Delete(obj, sourcev1.FetchFailedCondition)
 	return sreconcile.ResultSuccess, nil
 }
 
-// selectLayer finds the matching layer and returns its compressed contents.
-// If no layer selector was provided, we pick the first layer from the OCI artifact.
-func (r *OCIRepositoryReconciler) selectLayer(obj *sourcev1.OCIRepository, image gcrv1.Image) (io.ReadCloser, error) {
+// checkConfigMediaType returns an error if the media type of the config of
+// the given manifest does not match the config media type of the layer
+// selector of the object.
+func checkConfigMediaType(obj *sourcev1.OCIRepository, manifest *gcrv1.Manifest) error {
+	want := obj.GetConfigMediaType()
+	if want == "" {
+		return nil
+	}
+	if got := string(manifest.Config.MediaType); got != want {
+		return fmt.Errorf("artifact config media type '%s' does not match the expected media type '%s'", got, want)
+	}
+	return nil
+}
+
+// checkContainerImage returns an error if the given manifest describes a
+// container image, while the layer selector of the object rejects them.
+// Container images are recognized by the media type of their config, or by
+// a Docker manifest which can only describe images.
+func checkContainerImage(obj *sourcev1.OCIRepository, manifest *gcrv1.Manifest) error {
+	if !obj.GetRejectContainerImages() {
+		return nil
+	}
+	switch {
+	case manifest.Config.MediaType == gcrtypes.OCIConfigJSON,
+		manifest.Config.MediaType == gcrtypes.DockerConfigJSON,
+		manifest.MediaType == gcrtypes.DockerManifestSchema2:
+		return fmt.Errorf("artifact is a container image with manifest media type '%s' and config media type '%s', "+
+			"but container images are rejected by the layer selector; push the content as an OCI artifact instead, "+
+			"e.g. with 'flux push artifact'", manifest.MediaType, manifest.Config.MediaType)
+	}
+	return nil
+}
+
+// selectLayer finds the matching layer and returns its compressed contents,
+// digest and media type. If no layer selector was provided, we pick the first
+// layer from the OCI artifact.
+func (r *OCIRepositoryReconciler) selectLayer(obj *sourcev1.OCIRepository, image gcrv1.Image) (io.ReadCloser, gcrv1.Hash, string, error) {
 	layers, err := image.Layers()
 	if err != nil {
-		return nil, fmt.Errorf("failed to parse artifact layers: %w", err)
+		return nil, gcrv1.Hash{}, "", fmt.Errorf("failed to parse artifact layers: %w", err)
 	}
 
 	if len(layers) < 1 {
-		return nil, fmt.Errorf("no layers found in artifact")
+		return nil, gcrv1.Hash{}, "", fmt.Errorf("no layers found in artifact")
 	}
 
 	var layer gcrv1.Layer
@@ -558,7 +1240,7 @@ func (r *OCIRepositoryReconciler) selectLayer(obj *sourcev1.OCIRepository, image
 		for i, l := range layers {
 			md, err := l.MediaType()
 			if err != nil {
-				return nil, fmt.Errorf("failed to determine the media type of layer[%v] from artifact: %w", i, err)
+				return nil, gcrv1.Hash{}, "", fmt.Errorf("failed to determine the media type of layer[%v] from artifact: %w", i, err)
 			}
 			if string(md) == obj.GetLayerMediaType() {
 				layer = layers[i]
@@ -567,64 +1249,628 @@ func (r *OCIRepositoryReconciler) selectLayer(obj *sourcev1.OCIRepository, image
 			}
 		}
 		if !found {
-			return nil, fmt.Errorf("failed to find layer with media type '%s' in artifact", obj.GetLayerMediaType())
+			return nil, gcrv1.Hash{}, "", fmt.Errorf("failed to find layer with media type '%s' in artifact", obj.GetLayerMediaType())
 		}
 	default:
-		layer = layers[0]
+		candidates, err := defaultLayers(layers)
+		if err != nil {
+			return nil, gcrv1.Hash{}, "", err
+		}
+		if len(candidates) > 1 {
+			return nil, gcrv1.Hash{}, "", fmt.Errorf("failed to select a layer from the %d gzip compressed tarballs in artifact: "+
+				"set '.spec.layerSelector.mediaType' to select the layer", len(candidates))
+		}
+		layer = candidates[0]
 	}
 
-	blob, err := layer.Compressed()
+	maxSize := obj.GetMaxSize()
+	if maxSize > 0 {
+		size, err := layer.Size()
+		if err != nil {
+			return nil, gcrv1.Hash{}, "", fmt.Errorf("failed to determine the size of the layer from artifact: %w", err)
+		}
+		if size > maxSize {
+			return nil, gcrv1.Hash{}, "", fmt.Errorf("layer size %d exceeds the maximum size of %d bytes", size, maxSize)
+		}
+	}
+
+	mediaType, err := layer.MediaType()
 	if err != nil {
-		return nil, fmt.Errorf("failed to extract the first layer from artifact: %w", err)
+		return nil, gcrv1.Hash{}, "", fmt.Errorf("failed to determine the media type of the layer from artifact: %w", err)
 	}
 
-	return blob, nil
-}
+	digest, err := layer.Digest()
+	if err != nil {
+		return nil, gcrv1.Hash{}, "", fmt.Errorf("failed to determine the digest of the layer from artifact: %w", err)
+	}
 
-// getRevision fetches the upstream digest and returns the revision in the format `<tag>/<digest>`
-func (r *OCIRepositoryReconciler) getRevision(url string, options []crane.Option) (string, error) {
-	ref, err := name.ParseReference(url)
+	blob, err := layer.Compressed()
 	if err != nil {
-		return "", err
+		return nil, gcrv1.Hash{}, "", fmt.Errorf("failed to extract the first layer from artifact: %w", err)
 	}
 
-	repoTag := ""
-	repoName := strings.TrimPrefix(url, ref.Context().RegistryStr())
-	if s := strings.Split(repoName, ":"); len(s) == 2 && !strings.Contains(repoName, "@") {
-		repoTag = s[1]
+	// Guard against the actual layer content exceeding the maximum size,
+	// as the size in the descriptor is not to be trusted
+	if maxSize > 0 {
+		blob = archive.LimitReadCloser(blob, maxSize)
 	}
 
-	if repoTag == "" && !strings.Contains(repoName, "@") {
-		repoTag = "latest"
+	return blob, digest, string(mediaType), nil
+}
+
+// defaultLayers returns the layers to select from the given layers in the
+// absence of a layer selector. An artifact with a single layer has its layer
+// selected, and an artifact pushed by Helm its chart. Otherwise, the tarballs
+// compressed with gzip are selected in the order of the artifact manifest, as
+// pushed by e.g. 'flux push artifact' next to other content. If no such layer
+// is found, it returns an error asking for a layer selector instead of
+// guessing.
+func defaultLayers(layers []gcrv1.Layer) ([]gcrv1.Layer, error) {
+	if len(layers) == 1 {
+		return layers, nil
 	}
 
-	digest, err := crane.Digest(url, options...)
+	// Select the chart of an artifact pushed by Helm, and not its provenance
+	charts, err := helmChartLayers(layers)
 	if err != nil {
-		return "", err
+		return nil, err
+	}
+	if len(charts) == 1 {
+		return charts, nil
 	}
 
-	digestHash, err := gcrv1.NewHash(digest)
-	if err != nil {
-		return "", err
+	var candidates []gcrv1.Layer
+	for i, l := range layers {
+		md, err := l.MediaType()
+		if err != nil {
+			return nil, fmt.Errorf("failed to determine the media type of layer[%v] from artifact: %w", i, err)
+		}
+		if strings.HasSuffix(string(md), "tar+gzip") || strings.HasSuffix(string(md), "tar.gzip") {
+			candidates = append(candidates, l)
+		}
 	}
 
-	revision := digestHash.Hex
-	if repoTag != "" {
-		revision = fmt.Sprintf("%s/%s", repoTag, digestHash.Hex)
+	if len(candidates) == 0 {
+		return nil, fmt.Errorf("failed to select a layer from the %d layers in artifact, found no gzip compressed tarballs: "+
+			"set '.spec.layerSelector.mediaType' to select the layer", len(layers))
 	}
-	return revision, nil
+	return candidates, nil
 }
 
-// digestFromRevision extract the digest from the revision string
-func (r *OCIRepositoryReconciler) digestFromRevision(revision string) string {
-	parts := strings.Split(revision, "/")
-	return parts[len(parts)-1]
+// helmChartLayers returns the layers of the given layers with the media type
+// of the content of a Helm chart.
+func helmChartLayers(layers []gcrv1.Layer) ([]gcrv1.Layer, error) {
+	var charts []gcrv1.Layer
+	for i, l := range layers {
+		md, err := l.MediaType()
+		if err != nil {
+			return nil, fmt.Errorf("failed to determine the media type of layer[%v] from artifact: %w", i, err)
+		}
+		if string(md) == helmreg.ChartLayerMediaType {
+			charts = append(charts, l)
+		}
+	}
+	return charts, nil
 }
 
-// verifySignature verifies the authenticity of the given image reference url. First, it tries using a key
-// if a secret with a valid public key is provided. If not, it falls back to a keyless approach for verification.
-func (r *OCIRepositoryReconciler) verifySignature(ctx context.Context, obj *sourcev1.OCIRepository, url string, opt ...remote.Option) error {
-	ctxTimeout, cancel := context.WithTimeout(ctx, obj.Spec.Timeout.Duration)
+// verifyHelmChart returns an error if the given dir with the extracted
+// content of a Helm chart layer does not consist of a single chart
+// directory, as packaged by Helm.
+func verifyHelmChart(dir string) error {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+	if len(entries) != 1 || !entries[0].IsDir() {
+		return fmt.Errorf("expected a single chart directory, found %d entries", len(entries))
+	}
+	fi, err := os.Lstat(filepath.Join(dir, entries[0].Name(), chartutil.ChartfileName))
+	if err != nil || !fi.Mode().IsRegular() {
+		return fmt.Errorf("chart directory '%s' does not contain a %s", entries[0].Name(), chartutil.ChartfileName)
+	}
+	return nil
+}
+
+// selectLayers finds all the layers matching the layer selector, in the order
+// of the artifact manifest. If no layer selector was provided, the layer
+// selected by selectLayer is selected. The total size of the selected layers
+// must not exceed the maximum size of the object.
+func (r *OCIRepositoryReconciler) selectLayers(obj *sourcev1.OCIRepository, image gcrv1.Image) ([]gcrv1.Layer, error) {
+	layers, err := image.Layers()
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse artifact layers: %w", err)
+	}
+
+	if len(layers) < 1 {
+		return nil, fmt.Errorf("no layers found in artifact")
+	}
+
+	var selected []gcrv1.Layer
+	if obj.GetLayerMediaType() == "" {
+		if selected, err = defaultLayers(layers); err != nil {
+			return nil, err
+		}
+	} else {
+		for i, l := range layers {
+			md, err := l.MediaType()
+			if err != nil {
+				return nil, fmt.Errorf("failed to determine the media type of layer[%v] from artifact: %w", i, err)
+			}
+			if string(md) == obj.GetLayerMediaType() {
+				selected = append(selected, l)
+			}
+		}
+	}
+
+	if len(selected) == 0 {
+		return nil, fmt.Errorf("failed to find layer with media type '%s' in artifact", obj.GetLayerMediaType())
+	}
+
+	if maxSize := obj.GetMaxSize(); maxSize > 0 {
+		var total int64
+		for i, l := range selected {
+			size, err := l.Size()
+			if err != nil {
+				return nil, fmt.Errorf("failed to determine the size of layer[%v] from artifact: %w", i, err)
+			}
+			total += size
+		}
+		if total > maxSize {
+			return nil, fmt.Errorf("total size %d of the %d selected layer(s) exceeds the maximum size of %d bytes",
+				total, len(selected), maxSize)
+		}
+	}
+	return selected, nil
+}
+
+// layerFile is the compressed content of a layer stored in a file.
+type layerFile struct {
+	// path is the path of the file.
+	path string
+	// digest is the digest of the layer.
+	digest gcrv1.Hash
+	// compression is the compression of the layer content.
+	compression archive.Compression
+}
+
+// fetchLayers fetches the compressed contents of the selected layers of the
+// given image, and stores them into tempDir. It downloads in parallel, but
+// limited to the maxConcurrentOCILayerFetches. The digest of the contents is
+// verified while downloading. It returns the downloaded layer files, in the
+// order of the artifact manifest.
+func (r *OCIRepositoryReconciler) fetchLayers(ctx context.Context, obj *sourcev1.OCIRepository, image gcrv1.Image, tempDir string) ([]layerFile, error) {
+	layers, err := r.selectLayers(obj, image)
+	if err != nil {
+		return nil, err
+	}
+
+	// Determine the compression of all the layers before fetching any
+	files := make([]layerFile, len(layers))
+	for i, layer := range layers {
+		mediaType, err := layer.MediaType()
+		if err != nil {
+			return nil, fmt.Errorf("failed to determine the media type of layer[%v] from artifact: %w", i, err)
+		}
+		if files[i].compression, err = archive.CompressionForMediaType(string(mediaType)); err != nil {
+			return nil, fmt.Errorf("failed to determine the compression of layer[%v] from artifact: %w", i, err)
+		}
+		if files[i].digest, err = layer.Digest(); err != nil {
+			return nil, fmt.Errorf("failed to determine the digest of layer[%v] from artifact: %w", i, err)
+		}
+	}
+
+	// The maximum size applies to the total of the layers, which share the
+	// budget while they are fetched
+	budget := newSizeBudget(obj.GetMaxSize())
+
+	group, groupCtx := errgroup.WithContext(ctx)
+	group.Go(func() error {
+		sem := semaphore.NewWeighted(maxConcurrentOCILayerFetches)
+		for i, layer := range layers {
+			i, layer := i, layer
+			if err := sem.Acquire(groupCtx, 1); err != nil {
+				return err
+			}
+			group.Go(func() error {
+				defer sem.Release(1)
+				p := filepath.Join(tempDir, fmt.Sprintf("layer-%d", i))
+				if err := fetchLayer(layer, p, budget); err != nil {
+					return fmt.Errorf("failed to fetch layer[%v] from artifact: %w", i, err)
+				}
+				files[i].path = p
+				return nil
+			})
+		}
+		return nil
+	})
+	if err := group.Wait(); err != nil {
+		return nil, err
+	}
+	return files, nil
+}
+
+// fetchLayer writes the compressed contents of the given layer to the file at
+// path p. The digest of the contents is verified by the layer implementation
+// while it is read to completion.
+func fetchLayer(layer gcrv1.Layer, p string, budget *sizeBudget) error {
+	blob, err := layer.Compressed()
+	if err != nil {
+		return err
+	}
+	defer blob.Close()
+
+	// Guard against the actual layer contents exceeding the maximum size,
+	// as the size in the descriptor is not to be trusted
+	blob = budget.limit(blob)
+
+	f, err := os.Create(p)
+	if err != nil {
+		return err
+	}
+	if _, err = io.Copy(f, blob); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// verifyFileDigest verifies the sha256 digest of the file at the given path
+// matches the given digest.
+func verifyFileDigest(p string, digest gcrv1.Hash) error {
+	f, err := os.Open(p)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	got, _, err := gcrv1.SHA256(f)
+	if err != nil {
+		return fmt.Errorf("failed to calculate digest of '%s': %w", filepath.Base(p), err)
+	}
+	if got != digest {
+		return fmt.Errorf("digest mismatch, expected '%s' but got '%s'", digest, got)
+	}
+	return nil
+}
+
+// emptyDir removes the content of the given directory, but not the directory
+// itself.
+func emptyDir(dir string) error {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		if err := os.RemoveAll(filepath.Join(dir, entry.Name())); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// untarLayerFiles extracts the given layer files into dir, in the order of the
+// given files. The content of a layer overwrites the content of the layers
+// extracted before it. The MaxSize of the given limits applies to the total
+// size of the extracted content.
+func untarLayerFiles(files []layerFile, dir string, limits archive.Limits) error {
+	return untarEach(len(files), func(i int) (io.ReadCloser, archive.Compression, error) {
+		f, err := os.Open(files[i].path)
+		return f, files[i].compression, err
+	}, dir, limits)
+}
+
+// untarLayers extracts the compressed contents of the given layers into dir
+// while they are downloaded, in the order of the given layers. The MaxSize of
+// the given limits applies to the total size of both the compressed and the
+// extracted content.
+func untarLayers(layers []gcrv1.Layer, dir string, limits archive.Limits) error {
+	budget := newSizeBudget(limits.MaxSize)
+	return untarEach(len(layers), func(i int) (io.ReadCloser, archive.Compression, error) {
+		mediaType, err := layers[i].MediaType()
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to determine the media type of layer[%v] from artifact: %w", i, err)
+		}
+		compression, err := archive.CompressionForMediaType(string(mediaType))
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to determine the compression of layer[%v] from artifact: %w", i, err)
+		}
+		blob, err := layers[i].Compressed()
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to fetch layer[%v] from artifact: %w", i, err)
+		}
+		return budget.limit(blob), compression, nil
+	}, dir, limits)
+}
+
+// untarEach extracts the n compressed tarballs opened by the given function
+// into dir, in order. The MaxSize of the given limits applies to the total
+// size of the extracted content.
+func untarEach(n int, open func(i int) (io.ReadCloser, archive.Compression, error), dir string, limits archive.Limits) error {
+	maxSize := limits.MaxSize
+	for i := 0; i < n; i++ {
+		if maxSize > 0 && i > 0 {
+			size, err := dirSize(dir)
+			if err != nil {
+				return err
+			}
+			if size >= maxSize {
+				return &archive.LimitExceededError{Limit: "size", Max: maxSize}
+			}
+			limits.MaxSize = maxSize - size
+		}
+
+		rc, compression, err := open(i)
+		if err != nil {
+			return err
+		}
+		_, err = archive.UntarCompressed(rc, dir, compression, limits)
+		rc.Close()
+		if err != nil {
+			var limitErr *archive.LimitExceededError
+			if errors.As(err, &limitErr) {
+				return &archive.LimitExceededError{Limit: limitErr.Limit, Max: maxSize}
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+// dirSize returns the total size of the regular files in the given directory.
+func dirSize(dir string) (int64, error) {
+	var size int64
+	err := filepath.WalkDir(dir, func(_ string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		fi, err := d.Info()
+		if err != nil {
+			return err
+		}
+		size += fi.Size()
+		return nil
+	})
+	return size, err
+}
+
+// sizeBudget is a maximum size in bytes shared by concurrent readers.
+type sizeBudget struct {
+	max       int64
+	remaining int64
+}
+
+// newSizeBudget returns a sizeBudget of the given maximum size, which is
+// unlimited if the size is zero.
+func newSizeBudget(max int64) *sizeBudget {
+	return &sizeBudget{max: max, remaining: max}
+}
+
+// limit returns an io.ReadCloser that reads from rc, and charges the bytes it
+// reads to the budget. Once the budget is exceeded, it returns an
+// archive.LimitExceededError.
+func (b *sizeBudget) limit(rc io.ReadCloser) io.ReadCloser {
+	if b.max <= 0 {
+		return rc
+	}
+	return &budgetReadCloser{rc: rc, budget: b}
+}
+
+type budgetReadCloser struct {
+	rc     io.ReadCloser
+	budget *sizeBudget
+}
+
+func (r *budgetReadCloser) Read(p []byte) (int, error) {
+	n, err := r.rc.Read(p)
+	if atomic.AddInt64(&r.budget.remaining, -int64(n)) < 0 {
+		return n, &archive.LimitExceededError{Limit: "size", Max: r.budget.max}
+	}
+	return n, err
+}
+
+func (r *budgetReadCloser) Close() error {
+	return r.rc.Close()
+}
+
+// getRevision fetches the upstream digest and returns the revision in the format `<tag>/<digest>`,
+// and the full digest in the format `<algorithm>:<hex>`. For a digest reference, the revision is
+// the digest of the reference, which is not fetched.
+func (r *OCIRepositoryReconciler) getRevision(url string, options []crane.Option) (string, string, error) {
+	ref, err := name.ParseReference(url)
+	if err != nil {
+		return "", "", err
+	}
+
+	// The digest of a digest reference is the revision, without a registry
+	// round-trip: the existence of the artifact is verified when it is
+	// pulled, which is skipped when the artifact in storage is up-to-date
+	if d, ok := ref.(name.Digest); ok {
+		digestHash, err := gcrv1.NewHash(d.DigestStr())
+		if err != nil {
+			return "", "", err
+		}
+		return digestHash.Hex, digestHash.String(), nil
+	}
+
+	repoTag := tagFromReference(ref)
+
+	digest, err := crane.Digest(url, options...)
+	if err != nil {
+		return "", "", err
+	}
+
+	digestHash, err := gcrv1.NewHash(digest)
+	if err != nil {
+		return "", "", err
+	}
+
+	revision := digestHash.Hex
+	if repoTag != "" {
+		revision = fmt.Sprintf("%s/%s", repoTag, digestHash.Hex)
+	}
+	return revision, digestHash.String(), nil
+}
+
+// tagFromReference returns the tag of the given reference, which defaults to
+// 'latest' if the reference has no explicit tag, or an empty string if it is a
+// reference by digest. The tag is taken from the parsed reference, as the
+// registry host of the URL may contain a port, e.g. 'localhost:5000' or
+// '[::1]:5000'.
+func tagFromReference(ref name.Reference) string {
+	if t, ok := ref.(name.Tag); ok {
+		return t.TagStr()
+	}
+	return ""
+}
+
+// resolvePlatform returns the platform of the given image if it has been
+// resolved from the image index the URL refers to, or nil if the URL refers
+// to a single manifest.
+func (r *OCIRepositoryReconciler) resolvePlatform(url, revision string, img gcrv1.Image, options []crane.Option) (*sourcev1.OCIPlatform, error) {
+	digest, err := img.Digest()
+	if err != nil {
+		return nil, err
+	}
+
+	// The digest of a single manifest equals the upstream digest
+	if digest.Hex == r.digestFromRevision(revision) {
+		return nil, nil
+	}
+
+	raw, err := crane.Manifest(url, options...)
+	if err != nil {
+		return nil, err
+	}
+	index, err := gcrv1.ParseIndexManifest(bytes.NewReader(raw))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse image index: %w", err)
+	}
+	for _, desc := range index.Manifests {
+		if desc.Digest != digest || desc.Platform == nil {
+			continue
+		}
+		return &sourcev1.OCIPlatform{
+			OS:           desc.Platform.OS,
+			Architecture: desc.Platform.Architecture,
+			Variant:      desc.Platform.Variant,
+		}, nil
+	}
+	return nil, fmt.Errorf("no platform found for manifest '%s' in image index", digest)
+}
+
+// hasPlatform returns true if the given url points to a single manifest, or
+// to an image index containing a manifest for the given platform. A variant
+// is only compared if specified.
+func (r *OCIRepositoryReconciler) hasPlatform(url string, platform *sourcev1.OCIPlatform, options []crane.Option) (bool, error) {
+	raw, err := crane.Manifest(url, options...)
+	if err != nil {
+		return false, err
+	}
+	index, err := gcrv1.ParseIndexManifest(bytes.NewReader(raw))
+	if err != nil {
+		return false, fmt.Errorf("failed to parse manifest: %w", err)
+	}
+	if !index.MediaType.IsIndex() && len(index.Manifests) == 0 {
+		return true, nil
+	}
+	for _, desc := range index.Manifests {
+		if desc.Platform == nil {
+			continue
+		}
+		if desc.Platform.OS == platform.OS &&
+			desc.Platform.Architecture == platform.Architecture &&
+			(platform.Variant == "" || desc.Platform.Variant == platform.Variant) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// platformString returns the given platform in the 'os/arch[/variant]'
+// format.
+func platformString(p *sourcev1.OCIPlatform) string {
+	if p.Variant != "" {
+		return fmt.Sprintf("%s/%s/%s", p.OS, p.Architecture, p.Variant)
+	}
+	return fmt.Sprintf("%s/%s", p.OS, p.Architecture)
+}
+
+// equivalentRevision returns true if the current artifact of the object has
+// the digest of the given revision. A reference which changes to an
+// equivalent one, e.g. from a tag to the digest it resolves to, changes the
+// format of the revision from '<tag>/<hex>' to '<hex>', while the content is
+// identical. The artifact is therefore neither pulled again, nor is its
+// revision changed, to not notify consumers of a new revision.
+func (r *OCIRepositoryReconciler) equivalentRevision(obj *sourcev1.OCIRepository, revision string) bool {
+	current := obj.GetArtifact()
+	if current == nil {
+		return false
+	}
+	return current.Revision == revision || r.digestFromRevision(current.Revision) == r.digestFromRevision(revision)
+}
+
+// digestFromRevision extract the digest from the revision string
+func (r *OCIRepositoryReconciler) digestFromRevision(revision string) string {
+	parts := strings.Split(revision, "/")
+	return parts[len(parts)-1]
+}
+
+// validateSBOM discovers the SBOM required by the object through the
+// referrers of the given artifact revision, and validates its document.
+// It returns the digest of the manifest of the validated SBOM.
+func (r *OCIRepositoryReconciler) validateSBOM(obj *sourcev1.OCIRepository, url, revision string, options []crane.Option) (string, error) {
+	ref, err := name.ParseReference(url)
+	if err != nil {
+		return "", err
+	}
+	subject := ref.Context().Digest("sha256:" + r.digestFromRevision(revision))
+
+	sbom, err := soci.FetchSBOM(subject, obj.Spec.RequireSBOM.Format, options...)
+	if err != nil {
+		return "", err
+	}
+	if err := soci.ValidateSBOM(sbom.Document, obj.Spec.RequireSBOM.Format, obj.Spec.RequireSBOM.RequiredFields); err != nil {
+		return "", fmt.Errorf("invalid SBOM '%s': %w", sbom.Digest, err)
+	}
+	return sbom.Digest.String(), nil
+}
+
+// verificationRetryBackoff returns the base and max delay of the exponential
+// backoff for retrying a failed verification of the object. The values set
+// on the object take precedence over the ones configured for the reconciler.
+func (r *OCIRepositoryReconciler) verificationRetryBackoff(obj *sourcev1.OCIRepository) (base, max time.Duration) {
+	base, max = r.verificationRetryBase, r.verificationRetryMax
+	if retry := obj.Spec.VerificationRetry; retry != nil {
+		if retry.Base != nil {
+			base = retry.Base.Duration
+		}
+		if retry.Max != nil {
+			max = retry.Max.Duration
+		}
+	}
+	return
+}
+
+// verificationDue returns true if the verification interval of the given
+// object is set, and has elapsed at the given time since the last successful
+// verification of its signature.
+func verificationDue(obj *sourcev1.OCIRepository, now time.Time) bool {
+	if obj.Spec.Verify == nil || obj.Spec.Verify.Interval == nil {
+		return false
+	}
+	if obj.Status.LastVerificationTime == nil {
+		return true
+	}
+	return !now.Before(obj.Status.LastVerificationTime.Add(obj.Spec.Verify.Interval.Duration))
+}
+
+// verifySignature verifies the authenticity of the given image reference url. First, it tries using a key
+// if a secret with a valid public key is provided. If not, it falls back to a keyless approach for verification.
+func (r *OCIRepositoryReconciler) verifySignature(ctx context.Context, obj *sourcev1.OCIRepository, url string, opt ...remote.Option) error {
+	ctxTimeout, cancel := context.WithTimeout(ctx, obj.GetVerifyTimeout())
 	defer cancel()
 
 	provider := obj.Spec.Verify.Provider
@@ -652,6 +1898,7 @@ func (r *OCIRepositoryReconciler) verifySignature(ctx context.Context, obj *sour
 			}
 
 			signatureVerified := false
+			var attestationErr error
 			for k, data := range pubSecret.Data {
 				// search for public keys in the secret
 				if strings.HasSuffix(k, ".pub") {
@@ -666,6 +1913,11 @@ func (r *OCIRepositoryReconciler) verifySignature(ctx context.Context, obj *sour
 					}
 
 					if signatures != nil {
+						// the attestation has to be signed with the same key
+						// as the signature
+						if attestationErr = verifyAttestation(ctxTimeout, obj, verifier, ref); attestationErr != nil {
+							continue
+						}
 						signatureVerified = true
 						break
 					}
@@ -673,6 +1925,9 @@ func (r *OCIRepositoryReconciler) verifySignature(ctx context.Context, obj *sour
 			}
 
 			if !signatureVerified {
+				if attestationErr != nil {
+					return fmt.Errorf("no matching attestations were found for '%s': %w", url, attestationErr)
+				}
 				return fmt.Errorf("no matching signatures were found for '%s'", url)
 			}
 
@@ -680,8 +1935,15 @@ func (r *OCIRepositoryReconciler) verifySignature(ctx context.Context, obj *sour
 		}
 
 		// if no secret is provided, try keyless verification
+		if r.DisableKeylessVerification {
+			return errKeylessVerificationDisabled
+		}
 		ctrl.LoggerFrom(ctx).Info("no secret reference is provided, trying to verify the image using keyless method")
-		verifier, err := soci.NewCosignVerifier(ctxTimeout, defaultCosignOciOpts...)
+		keylessOpts, err := cosignKeylessOptions(ctxTimeout, r, obj.Namespace, obj.Spec.Verify, r.DisableRekorLookups)
+		if err != nil {
+			return err
+		}
+		verifier, err := soci.NewCosignVerifier(ctxTimeout, append(defaultCosignOciOpts, keylessOpts...)...)
 		if err != nil {
 			return err
 		}
@@ -691,16 +1953,157 @@ func (r *OCIRepositoryReconciler) verifySignature(ctx context.Context, obj *sour
 			return err
 		}
 
-		if len(signatures) > 0 {
-			return nil
+		if len(signatures) == 0 {
+			return fmt.Errorf("no matching signatures were found for '%s'", url)
 		}
 
-		return fmt.Errorf("no matching signatures were found for '%s'", url)
+		if err := verifyAttestation(ctxTimeout, obj, verifier, ref); err != nil {
+			return fmt.Errorf("no matching attestations were found for '%s': %w", url, err)
+		}
+
+		return nil
+	}
+
+	return nil
+}
+
+// errKeylessVerificationDisabled is returned when the keyless verification
+// of an object without a secret reference is attempted, while it is disabled
+// for the controller.
+var errKeylessVerificationDisabled = errors.New("keyless verification is disabled by the controller policy, " +
+	"a '.spec.verify.secretRef' with public keys is required")
+
+// fulcioTrustedRootKey is the key of the Secret referenced by the Fulcio
+// verification spec holding the trusted root and intermediate certificates.
+const fulcioTrustedRootKey = "ca.crt"
+
+// cosignKeylessOptions returns the soci.Options configuring the Rekor and
+// Fulcio instances of the keyless verification with the given spec. The
+// trusted root certificates of Fulcio are read from the referenced Secret in
+// the given namespace.
+func cosignKeylessOptions(ctx context.Context, c client.Reader, namespace string,
+	verify *sourcev1.OCIRepositoryVerification, disableRekorLookups bool) ([]soci.Options, error) {
+	opts := []soci.Options{soci.WithOffline(disableRekorLookups)}
+	if verify.Rekor != nil {
+		opts = append(opts, soci.WithRekorURL(verify.Rekor.URL))
+	}
+	if fulcio := verify.Fulcio; fulcio != nil {
+		if fulcio.URL != "" {
+			opts = append(opts, soci.WithFulcioURL(fulcio.URL))
+		}
+		if secretRef := fulcio.TrustedRootSecretRef; secretRef != nil {
+			secretName := types.NamespacedName{
+				Namespace: namespace,
+				Name:      secretRef.Name,
+			}
+			var secret corev1.Secret
+			if err := c.Get(ctx, secretName, &secret); err != nil {
+				return nil, fmt.Errorf("failed to get Fulcio trusted root secret '%s': %w", secretName, err)
+			}
+			roots, ok := secret.Data[fulcioTrustedRootKey]
+			if !ok || len(roots) == 0 {
+				return nil, fmt.Errorf("'%s' not found in Fulcio trusted root secret '%s'", fulcioTrustedRootKey, secretName)
+			}
+			opts = append(opts, soci.WithFulcioRoots(roots))
+		}
+	}
+	return opts, nil
+}
+
+// verifyAttestation verifies the in-toto attestations of the given image
+// reference with the verifier, and evaluates them against the attestation
+// policy of the OCIRepository. It is a no-op if no attestation is required.
+func verifyAttestation(ctx context.Context, obj *sourcev1.OCIRepository, verifier *soci.CosignVerifier, ref name.Reference) error {
+	attestation := obj.Spec.Verify.Attestation
+	if attestation == nil {
+		return nil
+	}
+
+	attestations, _, err := verifier.VerifyImageAttestations(ctx, ref)
+	if err != nil {
+		return err
 	}
 
+	return soci.MatchAttestations(attestations, soci.AttestationPolicy{
+		PredicateType: attestation.GetPredicateType(),
+		BuilderID:     attestation.BuilderID,
+	})
+}
+
+// recordOperation records the given operation against the registry of the
+// given artifact URL with the RegistryRecorder, if configured.
+func (r *OCIRepositoryReconciler) recordOperation(operation, url string, start time.Time, err error) {
+	if r.RegistryRecorder == nil {
+		return
+	}
+	r.RegistryRecorder.RecordOperation(operation, registryHost(url), start, err)
+}
+
+// recordPull records the duration and the bytes downloaded by the pull of the
+// artifact of the given object with the RegistryRecorder, if configured.
+func (r *OCIRepositoryReconciler) recordPull(obj *sourcev1.OCIRepository, start time.Time, bytes int64) {
+	if r.RegistryRecorder == nil {
+		return
+	}
+	r.RegistryRecorder.RecordPull(sourcev1.OCIRepositoryKind, obj.GetName(), obj.GetNamespace(), start, bytes)
+}
+
+// checkRegistryDomain returns an error if the registry of the given OCI URL,
+// with or without the "oci://" prefix, is not allowed by the given allowed
+// registry domains. It guards all the pulls from OCI registries, including
+// those of HelmCharts and their dependencies, so the policy can not be
+// bypassed. A URL of which the registry can not be determined is denied.
+func checkRegistryDomain(domains soci.DomainAllowlist, ociURL string) error {
+	if len(domains) == 0 {
+		return nil
+	}
+	host, err := ociRegistryHost(ociURL)
+	if err != nil {
+		return fmt.Errorf("failed to determine the registry of '%s' to check it against the policy of allowed registry domains '%s': %w",
+			ociURL, domains, err)
+	}
+	if !domains.Allows(host) {
+		return fmt.Errorf("registry '%s' is not allowed by the policy of allowed registry domains '%s'", host, domains)
+	}
 	return nil
 }
 
+// ociRegistryHost returns the registry host of the given OCI URL, with or
+// without the "oci://" prefix, which may refer to a repository or only to a
+// registry, like the URL of a HelmRepository.
+func ociRegistryHost(ociURL string) (string, error) {
+	s := strings.TrimSuffix(strings.TrimPrefix(ociURL, sourcev1.OCIRepositoryPrefix), "/")
+	if s == "" {
+		return "", errors.New("empty URL")
+	}
+	if !strings.Contains(s, "/") {
+		reg, err := name.NewRegistry(s)
+		if err != nil {
+			return "", err
+		}
+		return reg.RegistryStr(), nil
+	}
+	ref, err := name.ParseReference(s)
+	if err != nil {
+		return "", err
+	}
+	return ref.Context().RegistryStr(), nil
+}
+
+// artifactEndpointReason is the event reason used to announce the endpoint
+// which served the artifact when the registry is mirrored.
+const artifactEndpointReason = "ArtifactEndpoint"
+
+// registryHost returns the registry host of the given artifact URL, or an
+// empty string if the URL can not be parsed.
+func registryHost(url string) string {
+	ref, err := name.ParseReference(url)
+	if err != nil {
+		return ""
+	}
+	return ref.Context().RegistryStr()
+}
+
 // parseRepositoryURL validates and extracts the repository URL.
 func (r *OCIRepositoryReconciler) parseRepositoryURL(obj *sourcev1.OCIRepository) (string, error) {
 	if !strings.HasPrefix(obj.Spec.URL, sourcev1.OCIRepositoryPrefix) {
@@ -713,54 +2116,176 @@ func (r *OCIRepositoryReconciler) parseRepositoryURL(obj *sourcev1.OCIRepository
 		return "", err
 	}
 
-	imageName := strings.TrimPrefix(url, ref.Context().RegistryStr())
-	if s := strings.Split(imageName, ":"); len(s) > 1 {
-		return "", fmt.Errorf("URL must not contain a tag; remove ':%s'", s[1])
+	if err := checkRegistryDomain(r.AllowedRegistryDomains, url); err != nil {
+		return "", err
+	}
+
+	// The registry host may contain a port, which must not be mistaken for a
+	// tag, hence the type of the parsed reference is inspected
+	switch ref := ref.(type) {
+	case name.Digest:
+		return "", fmt.Errorf("URL must not contain a digest; remove '@%s'", ref.DigestStr())
+	case name.Tag:
+		if strings.HasSuffix(url, ":"+ref.TagStr()) {
+			return "", fmt.Errorf("URL must not contain a tag; remove ':%s'", ref.TagStr())
+		}
 	}
 
 	return ref.Context().Name(), nil
 }
 
 // getArtifactURL determines which tag or digest should be used and returns the OCI artifact FQN.
-func (r *OCIRepositoryReconciler) getArtifactURL(obj *sourcev1.OCIRepository, options []crane.Option) (string, error) {
+// It also returns true if the tag was selected by a SemVer range from a list
+// of tags which may have been truncated by the registry, see listTags.
+func (r *OCIRepositoryReconciler) getArtifactURL(obj *sourcev1.OCIRepository, opts remoteOptions) (string, bool, error) {
 	url, err := r.parseRepositoryURL(obj)
 	if err != nil {
-		return "", invalidOCIURLError{err}
+		return "", false, invalidOCIURLError{err}
 	}
 
 	if obj.Spec.Reference != nil {
 		if obj.Spec.Reference.Digest != "" {
-			return fmt.Sprintf("%s@%s", url, obj.Spec.Reference.Digest), nil
+			return fmt.Sprintf("%s@%s", url, obj.Spec.Reference.Digest), false, nil
 		}
 
 		if obj.Spec.Reference.SemVer != "" {
-			tag, err := r.getTagBySemver(url, obj.Spec.Reference.SemVer, options)
+			tag, truncated, err := r.getTagBySemver(url, obj.Spec.Reference.SemVer,
+				obj.Spec.Reference.SemVerIgnorePrerelease, opts)
 			if err != nil {
-				return "", err
+				return "", false, err
 			}
-			return fmt.Sprintf("%s:%s", url, tag), nil
+			return fmt.Sprintf("%s:%s", url, tag), truncated, nil
 		}
 
 		if obj.Spec.Reference.Tag != "" {
-			return fmt.Sprintf("%s:%s", url, obj.Spec.Reference.Tag), nil
+			return fmt.Sprintf("%s:%s", url, obj.Spec.Reference.Tag), false, nil
 		}
 	}
 
-	return url, nil
+	return url, false, nil
 }
 
-// getTagBySemver call the remote container registry, fetches all the tags from the repository,
-// and returns the latest tag according to the semver expression.
-func (r *OCIRepositoryReconciler) getTagBySemver(url, exp string, options []crane.Option) (string, error) {
-	tags, err := crane.ListTags(url, options...)
+// immutableReferenceError returns an error if the reference of the object
+// requires an immutable reference, but the given artifact URL resolved from it
+// refers to a mutable tag. Digests and tags selected by a SemVer range are
+// considered immutable, as the selected tag is resolved to a digest once, and
+// the artifact is verified and pulled by that digest.
+func immutableReferenceError(obj *sourcev1.OCIRepository, url string) error {
+	ref := obj.Spec.Reference
+	if ref == nil || !ref.RequireImmutable || ref.Digest != "" || ref.SemVer != "" {
+		return nil
+	}
+
+	parsed, err := name.ParseReference(url)
+	if err != nil {
+		return err
+	}
+	if tag, ok := parsed.(name.Tag); ok {
+		return fmt.Errorf("reference policy requires an immutable reference, but '%s' uses the mutable tag '%s'; "+
+			"set '.spec.ref.digest' or '.spec.ref.semver' instead", parsed.Context().Name(), tag.TagStr())
+	}
+	return nil
+}
+
+// digestMismatchError returns an error if the reference of the object has an
+// expected digest, and the given digest the artifact URL resolved to differs
+// from it. As the digest of an artifact is derived from its content, a
+// mismatch means the tag now refers to other content than expected.
+func digestMismatchError(obj *sourcev1.OCIRepository, url, digest string) error {
+	ref := obj.Spec.Reference
+	if ref == nil || ref.VerifyDigest == "" {
+		return nil
+	}
+
+	expected, err := gcrv1.NewHash(ref.VerifyDigest)
+	if err != nil {
+		return fmt.Errorf("invalid expected digest '%s': %w", ref.VerifyDigest, err)
+	}
+	if digest != expected.String() {
+		return fmt.Errorf("digest mismatch for '%s': expected '%s' but the reference resolved to '%s', "+
+			"the tag may have been repointed", url, expected, digest)
+	}
+	return nil
+}
+
+// digestReference returns the given OCI reference pinned to the given
+// digest. Pulling the pinned reference ensures the pulled artifact is the one
+// of which the revision was determined, even if the tag was moved in between,
+// or the mirror of the registry serves a different artifact for the tag.
+func digestReference(url, digest string) (string, error) {
+	ref, err := name.ParseReference(url)
 	if err != nil {
 		return "", err
 	}
+	return ref.Context().Digest(digest).String(), nil
+}
+
+// pullFailureReason returns the reason for the given error of a pull
+// operation, distinguishing registries rejecting the credentials, missing
+// artifacts and unreachable registries from other failures.
+func pullFailureReason(err error) string {
+	switch {
+	case soci.IsAuthenticationError(err):
+		return sourcev1.AuthenticationFailedReason
+	case soci.IsNotFound(err):
+		return sourcev1.OCINotFoundReason
+	case soci.IsNetworkError(err):
+		return sourcev1.OCINetworkErrorReason
+	default:
+		return sourcev1.OCIPullFailedReason
+	}
+}
+
+// ambiguousReferenceReason is the event reason used to warn about a reference
+// with multiple fields set, of which only one is used.
+const ambiguousReferenceReason = "AmbiguousReference"
+
+// ambiguousReferenceWarning returns a warning message if more than one of the
+// Digest, SemVer and Tag of the given reference are set, naming the field
+// which takes precedence and the ones which are ignored. It returns an empty
+// string otherwise.
+func ambiguousReferenceWarning(ref *sourcev1.OCIRepositoryRef) string {
+	if ref == nil {
+		return ""
+	}
+
+	var set []string
+	if ref.Digest != "" {
+		set = append(set, "'.spec.ref.digest'")
+	}
+	if ref.SemVer != "" {
+		set = append(set, "'.spec.ref.semver'")
+	}
+	if ref.Tag != "" {
+		set = append(set, "'.spec.ref.tag'")
+	}
+	if len(set) < 2 {
+		return ""
+	}
+	verb := "is"
+	if len(set) > 2 {
+		verb = "are"
+	}
+	return fmt.Sprintf("multiple references are specified, %s takes precedence and %s %s ignored",
+		set[0], strings.Join(set[1:], " and "), verb)
+}
+
+// getTagBySemver call the remote container registry, fetches all the tags from the repository,
+// and returns the latest tag according to the semver expression. It also returns true if the
+// list of tags may have been truncated by the registry, see listTags.
+// If ignorePrerelease is true, the prerelease tags which are not explicitly
+// referenced by the expression are ignored, see util.PrereleaseFilter.
+func (r *OCIRepositoryReconciler) getTagBySemver(url, exp string, ignorePrerelease bool, opts remoteOptions) (string, bool, error) {
+	tags, truncated, err := listTags(url, opts)
+	if err != nil {
+		return "", false, err
+	}
 
 	constraint, err := semver.NewConstraint(exp)
 	if err != nil {
-		return "", fmt.Errorf("semver '%s' parse error: %w", exp, err)
+		return "", false, fmt.Errorf("semver '%s' parse error: %w", exp, err)
 	}
+	filter := util.NewPrereleaseFilter(exp)
 
 	var matchingVersions []*semver.Version
 	for _, t := range tags {
@@ -769,17 +2294,203 @@ func (r *OCIRepositoryReconciler) getTagBySemver(url, exp string, options []cran
 			continue
 		}
 
+		if ignorePrerelease && !filter.Allow(v) {
+			continue
+		}
 		if constraint.Check(v) {
 			matchingVersions = append(matchingVersions, v)
 		}
 	}
 
 	if len(matchingVersions) == 0 {
-		return "", fmt.Errorf("no match found for semver: %s", exp)
+		return "", false, fmt.Errorf("no match found for semver: %s, available tags: %s", exp, summarizeTags(tags))
+	}
+
+	return latestVersion(matchingVersions).Original(), truncated, nil
+}
+
+// maxSummarizedTags is the maximum number of tags listed by summarizeTags.
+const maxSummarizedTags = 10
+
+// summarizeTags returns a human-readable list of at most maxSummarizedTags of
+// the given tags, to help diagnose a semver expression which does not match
+// any tag. The tags which are a valid version are listed first from the
+// highest to the lowest version, followed by the other tags in their original
+// order.
+func summarizeTags(tags []string) string {
+	if len(tags) == 0 {
+		return "none"
+	}
+
+	var versions []*semver.Version
+	var others []string
+	for _, t := range tags {
+		if v, err := version.ParseVersion(t); err == nil {
+			versions = append(versions, v)
+			continue
+		}
+		others = append(others, t)
+	}
+	sort.SliceStable(versions, func(i, j int) bool {
+		return versions[i].GreaterThan(versions[j])
+	})
+
+	sorted := make([]string, 0, len(tags))
+	for _, v := range versions {
+		sorted = append(sorted, v.Original())
 	}
+	sorted = append(sorted, others...)
 
-	sort.Sort(sort.Reverse(semver.Collection(matchingVersions)))
-	return matchingVersions[0].Original(), nil
+	if len(sorted) > maxSummarizedTags {
+		return fmt.Sprintf("%s (and %d more)", strings.Join(sorted[:maxSummarizedTags], ", "), len(sorted)-maxSummarizedTags)
+	}
+	return strings.Join(sorted, ", ")
+}
+
+// tagListPageSize is the number of tags requested per page when listing the
+// tags of a repository.
+const tagListPageSize = 1000
+
+// listTags lists the tags of the given repository in pages of tagListPageSize,
+// following the pagination links returned by the registry. The listing is
+// bounded by the context of the given options.
+// It returns true if the list may have been truncated, which is the case when
+// the last page returned by the registry is full without a link to the next
+// page: a registry with broken pagination does not link to the next page.
+func listTags(url string, opts remoteOptions) ([]string, bool, error) {
+	pages := &tagPageTransport{RoundTripper: remote.DefaultTransport, pageSize: tagListPageSize}
+	if opts.transfer != nil {
+		pages.RoundTripper = opts.transfer
+	}
+	options := append(append([]crane.Option{}, opts.craneOpts...), crane.WithTransport(pages), func(o *crane.Options) {
+		o.Remote = append(o.Remote, remote.WithPageSize(tagListPageSize))
+	})
+	tags, err := crane.ListTags(url, options...)
+	if err != nil {
+		return nil, false, err
+	}
+	return tags, pages.truncated, nil
+}
+
+// tagPageTransport is an http.RoundTripper which inspects the pages of a tag
+// listing, and records whether the last page was full without a link to the
+// next page.
+type tagPageTransport struct {
+	http.RoundTripper
+	pageSize  int
+	truncated bool
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *tagPageTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.RoundTripper.RoundTrip(req)
+	if err != nil || resp.StatusCode != http.StatusOK || !strings.HasSuffix(req.URL.Path, "/tags/list") {
+		return resp, err
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+
+	var page struct {
+		Tags []string `json:"tags"`
+	}
+	if err := json.Unmarshal(body, &page); err == nil {
+		t.truncated = len(page.Tags) == t.pageSize && resp.Header.Get("Link") == ""
+	}
+	return resp, nil
+}
+
+// latestVersion returns the highest of the given versions. Build metadata is
+// ignored by semver precedence, versions which only differ in their build
+// metadata are ordered with compareBuildMetadata to make the pick
+// deterministic, and by their original tag as a last resort.
+func latestVersion(versions []*semver.Version) *semver.Version {
+	var latest *semver.Version
+	for _, v := range versions {
+		if latest == nil {
+			latest = v
+			continue
+		}
+		switch c := v.Compare(latest); {
+		case c > 0:
+			latest = v
+		case c == 0:
+			if m := compareBuildMetadata(v.Metadata(), latest.Metadata()); m > 0 ||
+				m == 0 && v.Original() > latest.Original() {
+				latest = v
+			}
+		}
+	}
+	return latest
+}
+
+// compareBuildMetadata compares the given build metadata by their dot
+// separated identifiers, like semver does for pre-release versions.
+// Numeric identifiers are compared numerically, and have a lower precedence
+// than alphanumeric identifiers, which are compared lexically. A larger set of
+// identifiers has a higher precedence if all the preceding identifiers are
+// equal, and an empty build metadata has the lowest precedence.
+func compareBuildMetadata(a, b string) int {
+	if a == b {
+		return 0
+	}
+	if a == "" {
+		return -1
+	}
+	if b == "" {
+		return 1
+	}
+	as, bs := strings.Split(a, "."), strings.Split(b, ".")
+	for i := 0; i < len(as) && i < len(bs); i++ {
+		if c := compareBuildIdentifier(as[i], bs[i]); c != 0 {
+			return c
+		}
+	}
+	switch {
+	case len(as) > len(bs):
+		return 1
+	case len(as) < len(bs):
+		return -1
+	}
+	return 0
+}
+
+// compareBuildIdentifier compares a single build metadata identifier.
+func compareBuildIdentifier(a, b string) int {
+	an, bn := isNumeric(a), isNumeric(b)
+	switch {
+	case an && bn:
+		a, b = strings.TrimLeft(a, "0"), strings.TrimLeft(b, "0")
+		if len(a) != len(b) {
+			if len(a) > len(b) {
+				return 1
+			}
+			return -1
+		}
+		return strings.Compare(a, b)
+	case an:
+		return -1
+	case bn:
+		return 1
+	}
+	return strings.Compare(a, b)
+}
+
+// isNumeric returns if the given string only consists of ASCII digits.
+func isNumeric(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
 }
 
 // keychain generates the credential keychain based on the resource
@@ -806,32 +2517,69 @@ func (r *OCIRepositoryReconciler) keychain(ctx context.Context, obj *sourcev1.OC
 		}
 	}
 
+	// fall back to the default service account of the namespace, if enabled
+	// and no credentials are configured
+	fromDefaultServiceAccount := false
+	if len(pullSecretNames) == 0 && obj.Spec.ServiceAccountName == "" && r.DefaultServiceAccountPullSecrets {
+		serviceAccount := corev1.ServiceAccount{}
+		err := r.Get(ctx, types.NamespacedName{Namespace: obj.Namespace, Name: defaultServiceAccountName}, &serviceAccount)
+		if err != nil && !apierrs.IsNotFound(err) {
+			return nil, err
+		}
+		for _, ips := range serviceAccount.ImagePullSecrets {
+			pullSecretNames.Insert(ips.Name)
+		}
+		fromDefaultServiceAccount = len(pullSecretNames) > 0
+	}
+
 	// if no pullsecrets available return an AnonymousKeychain
 	if len(pullSecretNames) == 0 {
 		return soci.Anonymous{}, nil
 	}
 
 	// lookup image pull secrets
-	imagePullSecrets := make([]corev1.Secret, len(pullSecretNames))
-	for i, imagePullSecretName := range pullSecretNames.List() {
+	imagePullSecrets := make([]corev1.Secret, 0, len(pullSecretNames))
+	for _, imagePullSecretName := range pullSecretNames.List() {
 		imagePullSecret := corev1.Secret{}
 		err := r.Get(ctx, types.NamespacedName{Namespace: obj.Namespace, Name: imagePullSecretName}, &imagePullSecret)
 		if err != nil {
 			r.eventLogf(ctx, obj, eventv1.EventTypeTrace, sourcev1.AuthenticationFailedReason,
 				"auth secret '%s' not found", imagePullSecretName)
+			// Like the kubelet, ignore the missing pull secrets of the
+			// default service account
+			if fromDefaultServiceAccount && apierrs.IsNotFound(err) {
+				continue
+			}
 			return nil, err
 		}
-		imagePullSecrets[i] = imagePullSecret
+		imagePullSecrets = append(imagePullSecrets, imagePullSecret)
 	}
 
-	return k8schain.NewFromPullSecrets(ctx, imagePullSecrets)
+	keychain, err := k8schain.NewFromPullSecrets(ctx, imagePullSecrets)
+	if err != nil || !fromDefaultServiceAccount {
+		return keychain, err
+	}
+	return defaultServiceAccountKeychain{keychain}, nil
+}
+
+// defaultServiceAccountName is the name of the service account of a namespace
+// which is used by pods which do not specify one.
+const defaultServiceAccountName = "default"
+
+// defaultServiceAccountKeychain is the keychain of the image pull secrets of
+// the default service account of a namespace. Unlike credentials configured
+// on the object, it does not take precedence over the credentials of a cloud
+// provider.
+type defaultServiceAccountKeychain struct {
+	authn.Keychain
 }
 
 // transport clones the default transport from remote and when a certSecretRef is specified,
 // the returned transport will include the TLS client and/or CA certificates.
-func (r *OCIRepositoryReconciler) transport(ctx context.Context, obj *sourcev1.OCIRepository) (http.RoundTripper, error) {
+// The transport records the delay requested by a registry which rate limits the requests.
+func (r *OCIRepositoryReconciler) transport(ctx context.Context, obj *sourcev1.OCIRepository) (*soci.ThrottlingTransport, error) {
 	if obj.Spec.CertSecretRef == nil || obj.Spec.CertSecretRef.Name == "" {
-		return nil, nil
+		return soci.NewThrottlingTransport(nil, ctrl.LoggerFrom(ctx)), nil
 	}
 
 	certSecretName := types.NamespacedName{
@@ -859,36 +2607,249 @@ func (r *OCIRepositoryReconciler) transport(ctx context.Context, obj *sourcev1.O
 		}
 	}
 
-	if caCert, ok := certSecret.Data[oci.CACert]; ok {
-		syscerts, err := x509.SystemCertPool()
+	if caCert, ok := certSecret.Data[oci.CACert]; ok {
+		syscerts, err := x509.SystemCertPool()
+		if err != nil {
+			return nil, err
+		}
+		syscerts.AppendCertsFromPEM(caCert)
+		tlsConfig.RootCAs = syscerts
+	}
+
+	// Override the server name for registries behind a proxy which expects
+	// another SNI, the certificate is verified against the server name
+	if serverName, ok := certSecret.Data[tlsServerNameKey]; ok {
+		if err := validateTLSServerName(string(serverName)); err != nil {
+			return nil, fmt.Errorf("invalid '%s' in secret '%s': %w", tlsServerNameKey, certSecretName, err)
+		}
+		tlsConfig.ServerName = string(serverName)
+	}
+	return soci.NewThrottlingTransport(transport, ctrl.LoggerFrom(ctx)), nil
+}
+
+// tlsServerNameKey is the key of the TLS server name in the secret of the
+// certSecretRef.
+const tlsServerNameKey = "serverName"
+
+// validateTLSServerName returns an error if the given name is not a DNS name
+// or an IP address, e.g. because it includes a scheme or port.
+func validateTLSServerName(name string) error {
+	if net.ParseIP(name) != nil {
+		return nil
+	}
+	if errs := validation.IsDNS1123Subdomain(name); len(errs) > 0 {
+		return fmt.Errorf("'%s' is not a valid DNS name or IP address: %s", name, strings.Join(errs, ", "))
+	}
+	return nil
+}
+
+// oidcAuth generates the OIDC credential authenticator based on the specified cloud provider.
+func oidcAuth(ctx context.Context, url, provider string) (authn.Authenticator, error) {
+	u := strings.TrimPrefix(url, sourcev1.OCIRepositoryPrefix)
+	ref, err := name.ParseReference(u)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse URL '%s': %w", u, err)
+	}
+
+	return login.NewManager().Login(ctx, u, ref, providerOptions(provider))
+}
+
+// providerOptions returns the login options enabling the automatic login of
+// the given cloud provider.
+func providerOptions(provider string) login.ProviderOptions {
+	opts := login.ProviderOptions{}
+	switch provider {
+	case sourcev1.AmazonOCIProvider:
+		opts.AwsAutoLogin = true
+	case sourcev1.AzureOCIProvider:
+		opts.AzureAutoLogin = true
+	case sourcev1.GoogleOCIProvider:
+		opts.GcpAutoLogin = true
+	}
+	return opts
+}
+
+// autoLoginEnabled returns true if the login options enable the automatic
+// login to the registry of the given reference.
+func autoLoginEnabled(opts login.ProviderOptions, url string, ref name.Reference) bool {
+	switch login.ImageRegistryProvider(url, ref) {
+	case oci.ProviderAWS:
+		return opts.AwsAutoLogin
+	case oci.ProviderAzure:
+		return opts.AzureAutoLogin
+	case oci.ProviderGCP:
+		return opts.GcpAutoLogin
+	}
+	return false
+}
+
+// workloadIdentityTokenExpiration is the expiration of the service account
+// tokens requested to be exchanged for registry credentials.
+const workloadIdentityTokenExpiration int64 = 600
+
+// workloadIdentityAuth exchanges a token of the service account of the object
+// for the registry credentials of the given cloud provider, sending the
+// exchanges with the given transport. A nil authenticator is returned if the
+// object does not specify a service account, if the registry does not belong
+// to the provider, or if the service account is not configured for the
+// workload identity of the provider.
+func (r *OCIRepositoryReconciler) workloadIdentityAuth(ctx context.Context, obj *sourcev1.OCIRepository, provider string,
+	transport http.RoundTripper) (authn.Authenticator, error) {
+	if obj.Spec.ServiceAccountName == "" || r.TokenClient == nil {
+		return nil, nil
+	}
+
+	u := strings.TrimPrefix(obj.Spec.URL, sourcev1.OCIRepositoryPrefix)
+	ref, err := name.ParseReference(u)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse URL '%s': %w", u, err)
+	}
+	if !autoLoginEnabled(providerOptions(provider), u, ref) {
+		return nil, nil
+	}
+
+	newWorkloadIdentity := r.workloadIdentity
+	if newWorkloadIdentity == nil {
+		newWorkloadIdentity = soci.NewWorkloadIdentity
+	}
+	wi, err := newWorkloadIdentity(provider, transport)
+	if err != nil {
+		return nil, err
+	}
+
+	serviceAccount := corev1.ServiceAccount{}
+	err = r.Get(ctx, types.NamespacedName{Namespace: obj.Namespace, Name: obj.Spec.ServiceAccountName}, &serviceAccount)
+	if err != nil {
+		return nil, err
+	}
+	audience, ok := wi.Audience(serviceAccount)
+	if !ok {
+		return nil, nil
+	}
+
+	tokenRequest := &authenticationv1.TokenRequest{
+		Spec: authenticationv1.TokenRequestSpec{
+			Audiences:         []string{audience},
+			ExpirationSeconds: pointer.Int64(workloadIdentityTokenExpiration),
+		},
+	}
+	tokenRequest, err = r.TokenClient.CoreV1().ServiceAccounts(obj.Namespace).
+		CreateToken(ctx, serviceAccount.Name, tokenRequest, metav1.CreateOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to request token: %w", err)
+	}
+	return wi.Login(ctx, ref, serviceAccount, tokenRequest.Status.Token)
+}
+
+// registryRateLimitedReason is the event reason used to warn about a
+// registry rate limiting the remote operations.
+const registryRateLimitedReason = "RateLimited"
+
+// throttled returns a Waiting error which requeues the object after the delay
+// requested by the registry, if it rate limited the remote operations which
+// resulted in the given error, and emits a warning event about it. The delay
+// is reset, so that later failures are not mistaken for rate limiting.
+// Otherwise, it returns the given error as is.
+func (r *OCIRepositoryReconciler) throttled(ctx context.Context, obj *sourcev1.OCIRepository, opts remoteOptions, e *serror.Generic) error {
+	delay := opts.throttle.TakeRetryAfter()
+	if delay <= 0 {
+		return e
+	}
+	err := fmt.Errorf("%w, registry rate limit exceeded, next retry in %s at %s",
+		e.Err, delay, time.Now().Add(delay).Format(time.RFC3339))
+	r.eventLogf(ctx, obj, corev1.EventTypeWarning, registryRateLimitedReason, "%s", err.Error())
+
+	// The event is recorded above with its own reason
+	return &serror.Waiting{
+		Err:          err,
+		Reason:       e.Reason,
+		RequeueAfter: delay,
+		Config: serror.Config{
+			Event: serror.EventTypeNone,
+		},
+	}
+}
+
+// credentialExpiringReason is the event reason used to warn about registry
+// credentials which are about to expire.
+const credentialExpiringReason = "CredentialExpiring"
+
+// observeCredentialExpiry records the given credential expiry warning in the
+// CredentialExpiringCondition of the object, or removes the Condition if the
+// warning is empty. It returns true if the credentials started to expire, to
+// only warn about them once until they are renewed.
+func observeCredentialExpiry(obj conditions.Setter, msg string) bool {
+	if msg == "" {
+		conditions.Delete(obj, sourcev1.CredentialExpiringCondition)
+		return false
+	}
+	expiring := conditions.IsTrue(obj, sourcev1.CredentialExpiringCondition)
+	conditions.MarkTrue(obj, sourcev1.CredentialExpiringCondition, credentialExpiringReason, msg)
+	return !expiring
+}
+
+// credentialExpiryWarning returns a warning message if the registry
+// credentials for the given URL expire within the given window, or an empty
+// string otherwise. The authenticator takes precedence over the keychain.
+func credentialExpiryWarning(url string, keychain authn.Keychain, auth authn.Authenticator, window time.Duration) string {
+	if window <= 0 {
+		return ""
+	}
+	if auth == nil && keychain != nil {
+		ref, err := name.ParseReference(strings.TrimPrefix(url, sourcev1.OCIRepositoryPrefix))
 		if err != nil {
-			return nil, err
+			return ""
 		}
-		syscerts.AppendCertsFromPEM(caCert)
-		tlsConfig.RootCAs = syscerts
+		if auth, err = keychain.Resolve(ref.Context()); err != nil {
+			return ""
+		}
+	}
+	if auth == nil {
+		return ""
+	}
+
+	expiry, err := soci.CredentialExpiry(auth)
+	if err != nil || expiry.IsZero() {
+		return ""
+	}
+	remaining := time.Until(expiry)
+	switch {
+	case remaining <= 0:
+		return fmt.Sprintf("registry credential expired at %s", expiry.UTC().Format(time.RFC3339))
+	case remaining <= window:
+		return fmt.Sprintf("registry credential expires in %s", remaining.Round(time.Second))
+	default:
+		return ""
 	}
-	return transport, nil
 }
 
-// oidcAuth generates the OIDC credential authenticator based on the specified cloud provider.
-func oidcAuth(ctx context.Context, url, provider string) (authn.Authenticator, error) {
-	u := strings.TrimPrefix(url, sourcev1.OCIRepositoryPrefix)
-	ref, err := name.ParseReference(u)
-	if err != nil {
-		return nil, fmt.Errorf("failed to parse URL '%s': %w", u, err)
+// ociProvider returns the OCI authentication provider to use for an object in
+// the given namespace. A provider set on the object, including
+// sourcev1.GenericOCIProvider, takes precedence over the default configured
+// on the namespace using sourcev1.OCIProviderAnnotation. When neither is set,
+// sourcev1.GenericOCIProvider is returned.
+func ociProvider(ctx context.Context, c client.Reader, namespace, provider string) (string, error) {
+	if provider != "" {
+		return provider, nil
 	}
 
-	opts := login.ProviderOptions{}
-	switch provider {
-	case sourcev1.AmazonOCIProvider:
-		opts.AwsAutoLogin = true
-	case sourcev1.AzureOCIProvider:
-		opts.AzureAutoLogin = true
-	case sourcev1.GoogleOCIProvider:
-		opts.GcpAutoLogin = true
+	ns := &corev1.Namespace{}
+	if err := c.Get(ctx, types.NamespacedName{Name: namespace}, ns); err != nil {
+		if apierrs.IsNotFound(err) {
+			return sourcev1.GenericOCIProvider, nil
+		}
+		return "", fmt.Errorf("failed to get namespace '%s': %w", namespace, err)
 	}
 
-	return login.NewManager().Login(ctx, u, ref, opts)
+	switch p := ns.GetAnnotations()[sourcev1.OCIProviderAnnotation]; p {
+	case "":
+		return sourcev1.GenericOCIProvider, nil
+	case sourcev1.GenericOCIProvider, sourcev1.AmazonOCIProvider, sourcev1.AzureOCIProvider, sourcev1.GoogleOCIProvider:
+		return p, nil
+	default:
+		return "", fmt.Errorf("invalid '%s' annotation on namespace '%s': unsupported provider '%s'",
+			sourcev1.OCIProviderAnnotation, namespace, p)
+	}
 }
 
 // reconcileStorage ensures the current state of the storage matches the
@@ -951,14 +2912,15 @@ func (r *OCIRepositoryReconciler) reconcileStorage(ctx context.Context, sp *patc
 func (r *OCIRepositoryReconciler) reconcileArtifact(ctx context.Context, sp *patch.SerialPatcher,
 	obj *sourcev1.OCIRepository, metadata *sourcev1.Artifact, dir string) (sreconcile.Result, error) {
 	revision := metadata.Revision
+	ext := artifactExtension(obj, metadata.Metadata)
 
 	// Create artifact
 	artifact := r.Storage.NewArtifactFor(obj.Kind, obj, revision,
-		fmt.Sprintf("%s.tar.gz", r.digestFromRevision(revision)))
+		fmt.Sprintf("%s.%s", r.digestFromRevision(revision), ext))
 
 	// Set the ArtifactInStorageCondition if there's no drift.
 	defer func() {
-		if obj.GetArtifact().HasRevision(artifact.Revision) && !ociContentConfigChanged(obj) {
+		if r.equivalentRevision(obj, artifact.Revision) && !ociContentConfigChanged(obj) {
 			conditions.Delete(obj, sourcev1.ArtifactOutdatedCondition)
 			conditions.MarkTrue(obj, sourcev1.ArtifactInStorageCondition, meta.SucceededReason,
 				"stored artifact for digest '%s'", artifact.Revision)
@@ -966,7 +2928,11 @@ func (r *OCIRepositoryReconciler) reconcileArtifact(ctx context.Context, sp *pat
 	}()
 
 	// The artifact is up-to-date
-	if obj.GetArtifact().HasRevision(artifact.Revision) && !ociContentConfigChanged(obj) {
+	if r.equivalentRevision(obj, artifact.Revision) && !ociContentConfigChanged(obj) {
+		// Record the digest for artifacts stored before it was observed
+		if obj.Status.Artifact.Digest == "" {
+			obj.Status.Artifact.Digest = metadata.Digest
+		}
 		r.eventLogf(ctx, obj, eventv1.EventTypeTrace, sourcev1.ArtifactUpToDateReason,
 			"artifact up-to-date with remote revision: '%s'", artifact.Revision)
 		return sreconcile.ResultSuccess, nil
@@ -998,7 +2964,7 @@ func (r *OCIRepositoryReconciler) reconcileArtifact(ctx context.Context, sp *pat
 		conditions.MarkTrue(obj, sourcev1.StorageOperationFailedCondition, e.Reason, e.Err.Error())
 		return sreconcile.ResultEmpty, e
 	}
-	unlock, err := r.Storage.Lock(artifact)
+	unlock, err := r.Storage.LockWithContext(ctx, artifact)
 	if err != nil {
 		return sreconcile.ResultEmpty, serror.NewGeneric(
 			fmt.Errorf("failed to acquire lock for artifact: %w", err),
@@ -1007,6 +2973,15 @@ func (r *OCIRepositoryReconciler) reconcileArtifact(ctx context.Context, sp *pat
 	}
 	defer unlock()
 
+	// With the 'created' modification time, the artifact is stored with the
+	// creation time of the upstream OCI artifact to make it reproducible
+	var modTime time.Time
+	var modTimeMetadata string
+	if obj.GetArtifactModTime() == sourcev1.OCIArtifactModTimeCreated {
+		modTime = ociCreatedTime(metadata.Metadata)
+		modTimeMetadata = modTime.UTC().Format(time.RFC3339)
+	}
+
 	switch obj.GetLayerOperation() {
 	case sourcev1.OCILayerCopy:
 		if err = r.Storage.CopyFromPath(&artifact, filepath.Join(dir, metadata.Path)); err != nil {
@@ -1031,25 +3006,106 @@ func (r *OCIRepositoryReconciler) reconcileArtifact(ctx context.Context, sp *pat
 			ps = append(ps, sourceignore.ReadPatterns(strings.NewReader(*obj.Spec.Ignore), ignoreDomain)...)
 		}
 
-		if err := r.Storage.Archive(&artifact, dir, SourceIgnoreFilter(ps, ignoreDomain)); err != nil {
+		// Restrict the archive to the included files before excluding the ignored ones.
+		filter := SourceIgnoreFilter(ps, ignoreDomain)
+		if obj.Spec.Include != nil {
+			filter = SourceIncludeFilter(sourceignore.ReadPatterns(strings.NewReader(*obj.Spec.Include), ignoreDomain),
+				ignoreDomain, filter)
+		}
+
+		// Exclude the ignore files which were only retained outside of the
+		// selected path for their rules to apply.
+		if ls := obj.Spec.LayerSelector; ls != nil && ls.Path != "" {
+			filter = selectedPathFilter(dir, ls.Path, filter)
+		}
+
+		// Reuse the current artifact if the content of the new revision is
+		// identical, to not cause the consumers to reconcile the same content.
+		// As the modification times are part of the tarball, this requires
+		// the current artifact to have been archived with the same one.
+		var reused bool
+		if current := obj.GetArtifact(); current == nil || current.Metadata[sourcev1.OCIModTimeMetadataKey] == modTimeMetadata {
+			reused, err = r.Storage.ReuseArtifact(&artifact, current, dir, filter)
+			if err != nil {
+				e := serror.NewGeneric(
+					fmt.Errorf("failed to compute the checksum of the artifact content: %w", err),
+					sourcev1.ArchiveOperationFailedReason,
+				)
+				conditions.MarkTrue(obj, sourcev1.StorageOperationFailedCondition, e.Reason, e.Err.Error())
+				return sreconcile.ResultEmpty, e
+			}
+		}
+		if !reused {
+			compress := obj.GetArtifactCompression() != sourcev1.OCIArtifactCompressionNone
+			archive := r.Storage.Archive
+			if !compress {
+				archive = r.Storage.ArchiveUncompressed
+			}
+			if !modTime.IsZero() {
+				archive = func(artifact *sourcev1.Artifact, dir string, filter ArchiveFileFilter) error {
+					return r.Storage.ArchiveWithModTime(artifact, dir, filter, compress, modTime)
+				}
+			}
+			if err := archive(&artifact, dir, filter); err != nil {
+				e := serror.NewGeneric(
+					fmt.Errorf("unable to archive artifact to storage: %s", err),
+					sourcev1.ArchiveOperationFailedReason,
+				)
+				conditions.MarkTrue(obj, sourcev1.StorageOperationFailedCondition, e.Reason, e.Err.Error())
+				return sreconcile.ResultEmpty, e
+			}
+		}
+	}
+
+	// Record the content type of the artifact file on a "best effort" basis
+	if contentType, err := r.artifactContentType(obj, artifact, dir, metadata.Metadata); err != nil {
+		r.eventLogf(ctx, obj, eventv1.EventTypeTrace, sourcev1.ArchiveOperationFailedReason,
+			"failed to detect artifact content type: %s", err)
+	} else {
+		annotations := make(map[string]string, len(metadata.Metadata)+1)
+		for k, v := range metadata.Metadata {
+			annotations[k] = v
+		}
+		annotations[sourcev1.OCIContentTypeMetadataKey] = contentType
+		metadata.Metadata = annotations
+	}
+
+	// Set the deterministic modification time on the stored file, which is
+	// not set yet for a copied layer or reused artifact, and record it
+	if !modTime.IsZero() {
+		if err := r.Storage.Chtimes(artifact, modTime); err != nil {
 			e := serror.NewGeneric(
-				fmt.Errorf("unable to archive artifact to storage: %s", err),
+				fmt.Errorf("failed to set artifact modification time: %w", err),
 				sourcev1.ArchiveOperationFailedReason,
 			)
 			conditions.MarkTrue(obj, sourcev1.StorageOperationFailedCondition, e.Reason, e.Err.Error())
 			return sreconcile.ResultEmpty, e
 		}
+		annotations := make(map[string]string, len(metadata.Metadata)+1)
+		for k, v := range metadata.Metadata {
+			annotations[k] = v
+		}
+		annotations[sourcev1.OCIModTimeMetadataKey] = modTimeMetadata
+		metadata.Metadata = annotations
 	}
 
-	// Record the observations on the object.
+	// Record the observations on the object, retaining the previous Artifact
+	// in the history.
+	obj.Status.History = artifactHistory(obj.Status.History, obj.GetArtifact(), &artifact, obj.Spec.HistoryLimit)
 	obj.Status.Artifact = artifact.DeepCopy()
-	obj.Status.Artifact.Metadata = metadata.Metadata
+	obj.Status.Artifact.Metadata = filterMetadata(metadata.Metadata, obj.Spec.MetadataKeys)
+	obj.Status.Artifact.Digest = metadata.Digest
 	obj.Status.ContentConfigChecksum = "" // To be removed in the next API version.
 	obj.Status.ObservedIgnore = obj.Spec.Ignore
+	obj.Status.ObservedInclude = obj.Spec.Include
 	obj.Status.ObservedLayerSelector = obj.Spec.LayerSelector
+	obj.Status.ObservedExpectedPaths = obj.Spec.ExpectedPaths
+	obj.Status.ObservedMetadataKeys = obj.Spec.MetadataKeys
+	obj.Status.ObservedArtifactCompression = obj.Spec.ArtifactCompression
+	obj.Status.ObservedArtifactModTime = obj.Spec.ArtifactModTime
 
 	// Update symlink on a "best effort" basis
-	url, err := r.Storage.Symlink(artifact, "latest.tar.gz")
+	url, err := r.Storage.Symlink(artifact, "latest."+ext)
 	if err != nil {
 		r.eventLogf(ctx, obj, eventv1.EventTypeTrace, sourcev1.SymlinkUpdateFailedReason,
 			"failed to update status URL symlink: %s", err)
@@ -1080,7 +3136,8 @@ func (r *OCIRepositoryReconciler) reconcileDelete(ctx context.Context, obj *sour
 
 // garbageCollect performs a garbage collection for the given object.
 //
-// It removes all but the current Artifact from the Storage, unless the
+// It removes all but the current Artifact and the number of previous
+// Artifacts configured by the history limit from the Storage, unless the
 // deletion timestamp on the object is set. Which will result in the
 // removal of all Artifacts for the objects.
 func (r *OCIRepositoryReconciler) garbageCollect(ctx context.Context, obj *sourcev1.OCIRepository) error {
@@ -1095,19 +3152,29 @@ func (r *OCIRepositoryReconciler) garbageCollect(ctx context.Context, obj *sourc
 				"garbage collected artifacts for deleted resource")
 		}
 		obj.Status.Artifact = nil
+		obj.Status.History = nil
 		return nil
 	}
 	if obj.GetArtifact() != nil {
-		delFiles, err := r.Storage.GarbageCollect(ctx, *obj.GetArtifact(), time.Second*5)
+		delFiles, retained, err := r.Storage.GarbageCollectWithOptions(ctx, *obj.GetArtifact(), obj.Status.History,
+			obj.Spec.HistoryLimit, r.garbageCollectOptions)
 		if err != nil {
 			return serror.NewGeneric(
 				fmt.Errorf("garbage collection of artifacts failed: %w", err),
 				"GarbageCollectionFailed",
 			)
 		}
+		// Forget the previous Artifacts which are no longer in the Storage.
+		var history []sourcev1.Artifact
+		for _, a := range artifactHistory(obj.Status.History, nil, obj.GetArtifact(), obj.Spec.HistoryLimit) {
+			if r.Storage.ArtifactExist(a) {
+				history = append(history, a)
+			}
+		}
+		obj.Status.History = history
 		if len(delFiles) > 0 {
 			r.eventLogf(ctx, obj, eventv1.EventTypeTrace, "GarbageCollectionSucceeded",
-				fmt.Sprintf("garbage collected %d artifacts", len(delFiles)))
+				fmt.Sprintf("garbage collected %d artifacts, retained %d", len(delFiles), retained))
 			return nil
 		}
 	}
@@ -1140,11 +3207,6 @@ func (r *OCIRepositoryReconciler) notify(ctx context.Context, oldObj, newObj *so
 			fmt.Sprintf("%s/%s", sourcev1.GroupVersion.Group, eventv1.MetaChecksumKey): newObj.Status.Artifact.Checksum,
 		}
 
-		var oldChecksum string
-		if oldObj.GetArtifact() != nil {
-			oldChecksum = oldObj.GetArtifact().Checksum
-		}
-
 		message := fmt.Sprintf("stored artifact with revision '%s' from '%s'", newObj.Status.Artifact.Revision, newObj.Spec.URL)
 
 		// enrich message with upstream annotations if found
@@ -1162,7 +3224,7 @@ func (r *OCIRepositoryReconciler) notify(ctx context.Context, oldObj, newObj *so
 		}
 
 		// Notify on new artifact and failure recovery.
-		if oldChecksum != newObj.GetArtifact().Checksum {
+		if artifactChanged(oldObj.GetArtifact(), newObj.GetArtifact()) {
 			r.AnnotatedEventf(newObj, annotations, corev1.EventTypeNormal,
 				"NewArtifact", message)
 			ctrl.LoggerFrom(ctx).Info(message)
@@ -1191,6 +3253,94 @@ func craneOptions(ctx context.Context, insecure bool) []crane.Option {
 	return options
 }
 
+// remoteOptionsFor generates the registry credentials and transport of the
+// given object, and returns the options for remote operations on its
+// artifact. The context is used for the lifetime of the remote operations.
+func (r *OCIRepositoryReconciler) remoteOptionsFor(ctx context.Context, obj *sourcev1.OCIRepository) (remoteOptions, error) {
+	var auth authn.Authenticator
+
+	// Generate the registry credential keychain either from static credentials or using cloud OIDC
+	keychain, err := r.keychain(ctx, obj)
+	if err != nil {
+		return remoteOptions{}, fmt.Errorf("failed to get credential: %w", err)
+	}
+
+	// Generate the transport for remote operations
+	transport, err := r.transport(ctx, obj)
+	if err != nil {
+		return remoteOptions{}, fmt.Errorf("failed to generate transport for '%s': %w", obj.Spec.URL, err)
+	}
+
+	// Retry the requests failing with a server error, and count the bytes
+	// downloaded by the remote operations
+	retrying := soci.NewRetryingTransport(transport, r.RegistryRetries, r.RegistryRetryBackoff, ctrl.LoggerFrom(ctx))
+	counter := soci.NewCountingTransport(retrying)
+
+	// Determine the provider, falling back to the namespace default when the
+	// object does not set one. The provider is only used without static
+	// credentials, in which case the namespace is not looked up.
+	provider := sourcev1.GenericOCIProvider
+	_, anonymous := keychain.(soci.Anonymous)
+	_, defaultServiceAccount := keychain.(defaultServiceAccountKeychain)
+	if anonymous || defaultServiceAccount {
+		provider, err = ociProvider(ctx, r.Client, obj.GetNamespace(), obj.Spec.Provider)
+		if err != nil {
+			return remoteOptions{}, fmt.Errorf("failed to determine provider: %w", err)
+		}
+	}
+	if provider != sourcev1.GenericOCIProvider {
+		login := func() (authn.Authenticator, error) {
+			return oidcAuth(ctx, obj.Spec.URL, provider)
+		}
+
+		// Prefer the workload identity of the service account of the object
+		// over the cloud identity of the controller
+		var authErr error
+		auth, authErr = r.workloadIdentityAuth(ctx, obj, provider, retrying)
+		if authErr != nil {
+			return remoteOptions{}, fmt.Errorf("failed to get credential from %s for service account '%s': %w",
+				provider, obj.Spec.ServiceAccountName, authErr)
+		}
+		if auth != nil {
+			login = func() (authn.Authenticator, error) {
+				return r.workloadIdentityAuth(ctx, obj, provider, retrying)
+			}
+		} else {
+			auth, authErr = login()
+			if authErr != nil && !errors.Is(authErr, oci.ErrUnconfiguredProvider) {
+				return remoteOptions{}, fmt.Errorf("failed to get credential from %s: %w", provider, authErr)
+			}
+		}
+		if auth != nil {
+			// Allow refreshing the cloud credentials when they expire before
+			// the artifact is pulled
+			auth = soci.NewRefreshingAuthenticator(auth, login)
+		}
+	}
+
+	// Warn about registry credentials which are about to expire
+	if observeCredentialExpiry(obj, credentialExpiryWarning(obj.Spec.URL, keychain, auth, r.CredentialExpiryWindow)) {
+		r.eventLogf(ctx, obj, corev1.EventTypeWarning, credentialExpiringReason,
+			conditions.GetMessage(obj, sourcev1.CredentialExpiringCondition))
+	}
+
+	o := makeRemoteOptions(ctx, obj, counter, keychain, auth).withUserAgent(r.UserAgent)
+	o.throttle = transport
+	o.transfer = counter
+	o.push = retrying
+
+	// The credentials of a cloud provider are only valid for the upstream
+	// registry, the credentials for a mirror are resolved from the keychain
+	if r.Mirrors != nil {
+		mirror := makeRemoteOptions(ctx, obj, counter, keychain, nil).withUserAgent(r.UserAgent)
+		mirror.throttle = transport
+		mirror.transfer = counter
+		mirror.push = retrying
+		o.mirror = &mirror
+	}
+	return o, nil
+}
+
 // makeRemoteOptions returns a remoteOptions struct with the authentication and transport options set.
 // The returned struct can be used to interact with a remote registry using go-containerregistry based libraries.
 func makeRemoteOptions(ctxTimeout context.Context, obj *sourcev1.OCIRepository, transport http.RoundTripper,
@@ -1198,6 +3348,7 @@ func makeRemoteOptions(ctxTimeout context.Context, obj *sourcev1.OCIRepository,
 	o := remoteOptions{
 		craneOpts:  craneOptions(ctxTimeout, obj.Spec.Insecure),
 		verifyOpts: []remote.Option{},
+		auth:       auth,
 	}
 
 	if transport != nil {
@@ -1224,6 +3375,53 @@ func makeRemoteOptions(ctxTimeout context.Context, obj *sourcev1.OCIRepository,
 type remoteOptions struct {
 	craneOpts  []crane.Option
 	verifyOpts []remote.Option
+	// auth is the authenticator used for the remote operations, if any.
+	auth authn.Authenticator
+	// throttle is the transport of the remote operations, which records the
+	// delay requested by a registry rate limiting the requests.
+	throttle *soci.ThrottlingTransport
+	// transfer is the transport of the remote operations, which counts the
+	// bytes downloaded from the registry.
+	transfer *soci.CountingTransport
+	// push is the transport of the remote operations writing to another
+	// registry, which retries the requests like transfer without counting
+	// the bytes.
+	push http.RoundTripper
+	// mirror contains the options to interact with a mirror of the registry,
+	// if any.
+	mirror *remoteOptions
+}
+
+// withUserAgent returns the options with the User-Agent of the remote
+// operations overridden, if the given user agent is not empty.
+func (o remoteOptions) withUserAgent(userAgent string) remoteOptions {
+	if userAgent == "" {
+		return o
+	}
+	o.craneOpts = append(o.craneOpts, crane.WithUserAgent(userAgent))
+	o.verifyOpts = append(o.verifyOpts, remote.WithUserAgent(userAgent))
+	return o
+}
+
+// withContext returns the options with the context of the remote operations,
+// and of the operations on the mirror, overridden by the given context.
+func (o remoteOptions) withContext(ctx context.Context) remoteOptions {
+	o.craneOpts = append(append([]crane.Option{}, o.craneOpts...), crane.WithContext(ctx))
+	o.verifyOpts = append(append([]remote.Option{}, o.verifyOpts...), remote.WithContext(ctx))
+	if o.mirror != nil {
+		mirror := o.mirror.withContext(ctx)
+		o.mirror = &mirror
+	}
+	return o
+}
+
+// forMirror returns the options to interact with a mirror of the registry,
+// falling back to the options of the registry if none are set.
+func (o remoteOptions) forMirror() remoteOptions {
+	if o.mirror != nil {
+		return *o.mirror
+	}
+	return o
 }
 
 // ociContentConfigChanged evaluates the current spec with the observations
@@ -1234,13 +3432,63 @@ func ociContentConfigChanged(obj *sourcev1.OCIRepository) bool {
 		return true
 	}
 
+	if !pointer.StringEqual(obj.Spec.Include, obj.Status.ObservedInclude) {
+		return true
+	}
+
 	if !layerSelectorEqual(obj.Spec.LayerSelector, obj.Status.ObservedLayerSelector) {
 		return true
 	}
 
+	if !stringSliceEqual(obj.Spec.ExpectedPaths, obj.Status.ObservedExpectedPaths) {
+		return true
+	}
+
+	if !stringSliceEqual(obj.Spec.MetadataKeys, obj.Status.ObservedMetadataKeys) {
+		return true
+	}
+
+	// The compression is only taken into account for extracted content,
+	// and defaults to gzip for artifacts stored before it was observed
+	if obj.GetLayerOperation() != sourcev1.OCILayerCopy {
+		observed := obj.Status.ObservedArtifactCompression
+		if observed == "" {
+			observed = sourcev1.OCIArtifactCompressionGzip
+		}
+		if observed != obj.GetArtifactCompression() {
+			return true
+		}
+	}
+
+	// The modification time defaults to the time at which the artifact was
+	// stored for artifacts stored before it was observed
+	observedModTime := obj.Status.ObservedArtifactModTime
+	if observedModTime == "" {
+		observedModTime = sourcev1.OCIArtifactModTimeStored
+	}
+	if observedModTime != obj.GetArtifactModTime() {
+		return true
+	}
+
+	// The platform is only observed for artifacts resolved from an image index
+	if observed := obj.Status.ObservedPlatform; observed != nil {
+		want := defaultOCIPlatform
+		if obj.Spec.Platform != nil {
+			want = *obj.Spec.Platform
+		}
+		if observed.OS != want.OS || observed.Architecture != want.Architecture ||
+			(want.Variant != "" && observed.Variant != want.Variant) {
+			return true
+		}
+	}
+
 	return false
 }
 
+// defaultOCIPlatform is the platform resolved from an image index by the
+// registry client when no platform is specified.
+var defaultOCIPlatform = sourcev1.OCIPlatform{OS: "linux", Architecture: "amd64"}
+
 // Returns true if both arguments are nil or both arguments
 // dereference to the same value.
 // Based on k8s.io/utils/pointer/pointer.go pointer value equality.
@@ -1253,3 +3501,357 @@ func layerSelectorEqual(a, b *sourcev1.OCILayerSelector) bool {
 	}
 	return *a == *b
 }
+
+// layerMediaTypeExtensions maps the suffixes of layer media types to the
+// extension of the Artifact persisted by the copy operation. The suffixes are
+// matched in order, so that compressed tarballs take precedence over plain
+// compressed files.
+var layerMediaTypeExtensions = []struct {
+	suffix    string
+	extension string
+}{
+	{"tar+gzip", "tar.gz"},
+	{"tar.gzip", "tar.gz"},
+	{"tar+zstd", "tar.zst"},
+	{"tar", "tar"},
+	{"gzip", "gz"},
+	{"zstd", "zst"},
+	{"zip", "zip"},
+	{"json", "json"},
+	{"yaml", "yaml"},
+}
+
+// artifactExtension returns the file extension of the Artifact persisted to
+// storage for the given object and Artifact metadata. For the copy operation,
+// it is the extension of the layer selector or, when omitted, the extension
+// derived from the layer media type recorded in the metadata. It defaults to
+// 'tar.gz'.
+func artifactExtension(obj *sourcev1.OCIRepository, metadata map[string]string) string {
+	if obj.GetLayerOperation() != sourcev1.OCILayerCopy {
+		if obj.GetArtifactCompression() == sourcev1.OCIArtifactCompressionNone {
+			return "tar"
+		}
+		return "tar.gz"
+	}
+	if ext := obj.Spec.LayerSelector.Extension; ext != "" {
+		return ext
+	}
+
+	mediaType := strings.ToLower(metadata[sourcev1.OCILayerMediaTypeMetadataKey])
+	if i := strings.Index(mediaType, ";"); i >= 0 {
+		mediaType = mediaType[:i]
+	}
+	subtype := strings.TrimSpace(mediaType[strings.Index(mediaType, "/")+1:])
+	for _, m := range layerMediaTypeExtensions {
+		if subtype == m.suffix || strings.HasSuffix(subtype, "+"+m.suffix) || strings.HasSuffix(subtype, "."+m.suffix) {
+			return m.extension
+		}
+	}
+	return "tar.gz"
+}
+
+// ociCreatedAnnotation is the OCI annotation holding the creation time of
+// the artifact.
+const ociCreatedAnnotation = "org.opencontainers.image.created"
+
+// ociCreatedTime returns the time of the 'org.opencontainers.image.created'
+// annotation, or the Unix epoch if the annotation is absent or not a valid
+// RFC 3339 timestamp.
+func ociCreatedTime(annotations map[string]string) time.Time {
+	if v, ok := annotations[ociCreatedAnnotation]; ok {
+		if t, err := time.Parse(time.RFC3339, v); err == nil {
+			return t
+		}
+	}
+	return time.Unix(0, 0)
+}
+
+// filterMetadata returns the entries of the given Artifact metadata whose key
+// is in the given list of keys, or all the entries if the list is empty. The
+// layer media type recorded by the copy operation and the detected content
+// type are always retained.
+func filterMetadata(metadata map[string]string, keys []string) map[string]string {
+	if len(keys) == 0 || metadata == nil {
+		return metadata
+	}
+	filtered := make(map[string]string, len(keys)+1)
+	for _, k := range keys {
+		if v, ok := metadata[k]; ok {
+			filtered[k] = v
+		}
+	}
+	for _, k := range []string{sourcev1.OCILayerMediaTypeMetadataKey, sourcev1.OCIContentTypeMetadataKey, sourcev1.OCIModTimeMetadataKey} {
+		if v, ok := metadata[k]; ok {
+			filtered[k] = v
+		}
+	}
+	return filtered
+}
+
+// artifactContentType detects the content type of the file of the given
+// Artifact of the object. A gzip compressed tarball of a Helm chart, either
+// copied from a Helm chart layer or archived from the given directory with
+// the extracted chart, has the media type of a Helm chart.
+func (r *OCIRepositoryReconciler) artifactContentType(obj *sourcev1.OCIRepository, artifact sourcev1.Artifact,
+	dir string, metadata map[string]string) (string, error) {
+	f, err := os.Open(r.Storage.LocalPath(artifact))
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	contentType, err := archive.DetectContentType(f)
+	if err != nil || contentType != archive.ContentTypeTarGzip {
+		return contentType, err
+	}
+	switch obj.GetLayerOperation() {
+	case sourcev1.OCILayerCopy:
+		if metadata[sourcev1.OCILayerMediaTypeMetadataKey] == helmreg.ChartLayerMediaType {
+			return helmreg.ChartLayerMediaType, nil
+		}
+	default:
+		if verifyHelmChart(dir) == nil {
+			return helmreg.ChartLayerMediaType, nil
+		}
+	}
+	return contentType, nil
+}
+
+// stringSliceEqual returns true if both slices contain the same elements in
+// the same order. A nil slice is considered equal to an empty slice.
+func stringSliceEqual(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// maxReportedPaths is the maximum number of paths included in a message
+// by formatPaths.
+const maxReportedPaths = 10
+
+// unexpectedPaths walks the given root directory and returns the slash
+// separated paths, relative to the root, of the files that do not match any
+// of the given path.Match patterns. A file is considered to match a pattern
+// if the pattern matches the file path or any of its parent directories.
+func unexpectedPaths(root string, patterns []string) ([]string, error) {
+	cleaned := make([]string, 0, len(patterns))
+	for _, p := range patterns {
+		p = strings.TrimPrefix(p, "/")
+		if _, err := path.Match(p, ""); err != nil {
+			return nil, fmt.Errorf("invalid pattern '%s': %w", p, err)
+		}
+		cleaned = append(cleaned, p)
+	}
+
+	var unexpected []string
+	err := filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		rel, err := filepath.Rel(root, p)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		if !pathMatchesAny(rel, cleaned) {
+			unexpected = append(unexpected, rel)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return unexpected, nil
+}
+
+// selectPath removes the files in the given root directory which do not
+// match the given path.Match pattern, and the directories left empty. A file
+// is considered to match the pattern if the pattern matches the file path or
+// any of its parent directories. The ignore files are retained, so their
+// rules apply to the remaining files. It returns an error if nothing matches.
+func selectPath(root, pattern string) error {
+	pattern = strings.Trim(pattern, "/")
+	if _, err := path.Match(pattern, ""); err != nil {
+		return fmt.Errorf("invalid path '%s': %w", pattern, err)
+	}
+	patterns := []string{pattern}
+
+	var matched bool
+	var dirs []string
+	err := filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if p == root {
+			return nil
+		}
+		rel, err := filepath.Rel(root, p)
+		if err != nil {
+			return err
+		}
+		if pathMatchesAny(filepath.ToSlash(rel), patterns) {
+			matched = true
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if d.IsDir() {
+			dirs = append(dirs, p)
+			return nil
+		}
+		if d.Name() == sourceignore.IgnoreFile {
+			return nil
+		}
+		return os.Remove(p)
+	})
+	if err != nil {
+		return err
+	}
+	if !matched {
+		return fmt.Errorf("no files matching path '%s' found", pattern)
+	}
+
+	// Remove the directories left empty, starting with the deepest as the
+	// walk visits the parent directories first
+	for i := len(dirs) - 1; i >= 0; i-- {
+		entries, err := os.ReadDir(dirs[i])
+		if err != nil {
+			return err
+		}
+		if len(entries) == 0 {
+			if err := os.Remove(dirs[i]); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// selectedPathFilter returns an ArchiveFileFilter that filters out the ignore
+// files outside of the given selected path of the root directory, which
+// selectPath retains, before applying the given ArchiveFileFilter.
+func selectedPathFilter(root, pattern string, filter ArchiveFileFilter) ArchiveFileFilter {
+	patterns := []string{strings.Trim(pattern, "/")}
+	return func(p string, fi os.FileInfo) bool {
+		if !fi.IsDir() && fi.Name() == sourceignore.IgnoreFile {
+			rel, err := filepath.Rel(root, p)
+			if err == nil && !pathMatchesAny(filepath.ToSlash(rel), patterns) {
+				return true
+			}
+		}
+		return filter != nil && filter(p, fi)
+	}
+}
+
+// pathMatchesAny returns true if any of the patterns matches the given path,
+// or any of its parent directories.
+func pathMatchesAny(p string, patterns []string) bool {
+	for ; p != "." && p != "/"; p = path.Dir(p) {
+		for _, pattern := range patterns {
+			if ok, _ := path.Match(pattern, p); ok {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// artifactLayersReason is the reason of the trace events describing the
+// layers of an artifact and the selected ones.
+const artifactLayersReason = "ArtifactLayers"
+
+// maxSummarizedLayers is the maximum number of layers listed by
+// summarizeLayers.
+const maxSummarizedLayers = 10
+
+// summarizeLayers returns a human-readable list of at most
+// maxSummarizedLayers of the given layer descriptors, with their index, media
+// type and size. The layers with one of the given selected digests are
+// marked, the digests themselves are omitted to keep the list short.
+func summarizeLayers(layers []gcrv1.Descriptor, selected []gcrv1.Hash) string {
+	if len(layers) == 0 {
+		return "none"
+	}
+
+	summary := make([]string, 0, maxSummarizedLayers)
+	for i, l := range layers {
+		if i == maxSummarizedLayers {
+			break
+		}
+		s := fmt.Sprintf("layer[%d] '%s' (%d bytes", i, l.MediaType, l.Size)
+		for _, d := range selected {
+			if d == l.Digest {
+				s += ", selected"
+				break
+			}
+		}
+		summary = append(summary, s+")")
+	}
+	if len(layers) > maxSummarizedLayers {
+		return fmt.Sprintf("%s (and %d more)", strings.Join(summary, ", "), len(layers)-maxSummarizedLayers)
+	}
+	return strings.Join(summary, ", ")
+}
+
+// layerSelectionReason returns why the layers of the given manifest were
+// selected for the given object, mirroring the logic of selectLayer and
+// selectLayers.
+func layerSelectionReason(obj *sourcev1.OCIRepository, manifest *gcrv1.Manifest) string {
+	var charts int
+	for _, l := range manifest.Layers {
+		if string(l.MediaType) == helmreg.ChartLayerMediaType {
+			charts++
+		}
+	}
+	switch {
+	case obj.GetLayerMediaType() != "":
+		return fmt.Sprintf("matching the layer selector media type '%s'", obj.GetLayerMediaType())
+	case len(manifest.Layers) == 1:
+		return "as the artifact has a single layer"
+	case charts == 1:
+		return "with the Helm chart media type"
+	default:
+		return "as the gzip compressed tarballs of the artifact"
+	}
+}
+
+// formatPaths returns a comma separated list of the given paths, truncated
+// to maxReportedPaths entries.
+func formatPaths(paths []string) string {
+	if len(paths) <= maxReportedPaths {
+		return strings.Join(paths, ", ")
+	}
+	return fmt.Sprintf("%s and %d more", strings.Join(paths[:maxReportedPaths], ", "), len(paths)-maxReportedPaths)
+}
+
+// verificationRetryDelay returns the delay before retrying a verification
+// which failed the given number of consecutive times. The delay starts at
+// base and doubles on every consecutive failure, up to max. A zero max means
+// no maximum.
+func verificationRetryDelay(failures int, base, max time.Duration) time.Duration {
+	delay := base
+	for i := 1; i < failures; i++ {
+		// Guard against overflowing the duration.
+		if delay > math.MaxInt64/2 {
+			break
+		}
+		delay *= 2
+		if max > 0 && delay >= max {
+			break
+		}
+	}
+	if max > 0 && delay > max {
+		delay = max
+	}
+	return delay
+}
@@ -17,14 +17,22 @@ limitations under the License.
 package controllers
 
 import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
 	"crypto/rand"
 	"crypto/rsa"
 	"crypto/tls"
 	"crypto/x509"
 	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/json"
 	"encoding/pem"
 	"errors"
 	"fmt"
+	"io"
+	"io/fs"
 	"math/big"
 	"net"
 	"net/http"
@@ -37,18 +45,31 @@ import (
 	"testing"
 	"time"
 
+	"github.com/Masterminds/semver/v3"
 	"github.com/google/go-containerregistry/pkg/authn"
 	"github.com/google/go-containerregistry/pkg/crane"
+	"github.com/google/go-containerregistry/pkg/name"
 	"github.com/google/go-containerregistry/pkg/registry"
 	gcrv1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/empty"
 	"github.com/google/go-containerregistry/pkg/v1/mutate"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/google/go-containerregistry/pkg/v1/static"
+	gcrtypes "github.com/google/go-containerregistry/pkg/v1/types"
+	"github.com/klauspost/compress/zstd"
 	. "github.com/onsi/gomega"
 	coptions "github.com/sigstore/cosign/cmd/cosign/cli/options"
 	"github.com/sigstore/cosign/cmd/cosign/cli/sign"
 	"github.com/sigstore/cosign/pkg/cosign"
+	helmreg "helm.sh/helm/v3/pkg/registry"
+	authenticationv1 "k8s.io/api/authentication/v1"
 	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
 	"k8s.io/client-go/tools/record"
 	"k8s.io/utils/pointer"
 	kstatus "sigs.k8s.io/cli-utils/pkg/kstatus/status"
@@ -65,7 +86,9 @@ import (
 	"github.com/fluxcd/pkg/untar"
 
 	sourcev1 "github.com/fluxcd/source-controller/api/v1beta2"
+	"github.com/fluxcd/source-controller/internal/archive"
 	serror "github.com/fluxcd/source-controller/internal/error"
+	soci "github.com/fluxcd/source-controller/internal/oci"
 	sreconcile "github.com/fluxcd/source-controller/internal/reconcile"
 )
 
@@ -184,6 +207,11 @@ func TestOCIRepository_Reconcile(t *testing.T) {
 			g.Expect(obj.Status.Artifact.Metadata[oci.SourceAnnotation]).To(ContainSubstring("podinfo"))
 			g.Expect(obj.Status.Artifact.Metadata[oci.RevisionAnnotation]).To(ContainSubstring(tt.tag))
 
+			// Check if the media type of a copied layer is recorded
+			if tt.operation == sourcev1.OCILayerCopy {
+				g.Expect(obj.Status.Artifact.Metadata[sourcev1.OCILayerMediaTypeMetadataKey]).To(Equal(tt.mediaType))
+			}
+
 			// Check if the artifact storage path matches the expected file path
 			localPath := testStorage.LocalPath(*obj.Status.Artifact)
 			t.Logf("artifact local path: %s", localPath)
@@ -355,6 +383,107 @@ func TestOCIRepository_Reconcile_MediaType(t *testing.T) {
 	}
 }
 
+func TestOCIRepository_Reconcile_MaxSize(t *testing.T) {
+	g := NewWithT(t)
+
+	// Registry server with public images
+	tmpDir := t.TempDir()
+	regServer, err := setupRegistryServer(ctx, tmpDir, registryOptions{})
+	if err != nil {
+		g.Expect(err).ToNot(HaveOccurred())
+	}
+
+	podinfoVersions, err := pushMultiplePodinfoImages(regServer.registryHost, "6.1.4", "6.1.5", "6.1.6")
+	g.Expect(err).ToNot(HaveOccurred())
+
+	tests := []struct {
+		name      string
+		url       string
+		tag       string
+		maxSize   *resource.Quantity
+		operation string
+		wantErr   string
+	}{
+		{
+			name: "Works without max size",
+			url:  podinfoVersions["6.1.4"].url,
+			tag:  podinfoVersions["6.1.4"].tag,
+		},
+		{
+			name:    "Works with max size above the artifact size",
+			url:     podinfoVersions["6.1.5"].url,
+			tag:     podinfoVersions["6.1.5"].tag,
+			maxSize: resource.NewQuantity(10*1024*1024, resource.BinarySI),
+		},
+		{
+			name:      "Fails with max size below the layer size",
+			url:       podinfoVersions["6.1.6"].url,
+			tag:       podinfoVersions["6.1.6"].tag,
+			maxSize:   resource.NewQuantity(100, resource.DecimalSI),
+			operation: sourcev1.OCILayerCopy,
+			wantErr:   "exceeds the maximum size of 100 bytes",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			ns, err := testEnv.CreateNamespace(ctx, "ocirepository-maxsize-test")
+			g.Expect(err).ToNot(HaveOccurred())
+			defer func() { g.Expect(testEnv.Delete(ctx, ns)).To(Succeed()) }()
+
+			obj := &sourcev1.OCIRepository{
+				ObjectMeta: metav1.ObjectMeta{
+					GenerateName: "ocirepository-reconcile",
+					Namespace:    ns.Name,
+				},
+				Spec: sourcev1.OCIRepositorySpec{
+					URL:      tt.url,
+					Interval: metav1.Duration{Duration: 60 * time.Minute},
+					Reference: &sourcev1.OCIRepositoryRef{
+						Tag: tt.tag,
+					},
+					MaxSize: tt.maxSize,
+				},
+			}
+			if tt.operation != "" {
+				obj.Spec.LayerSelector = &sourcev1.OCILayerSelector{
+					Operation: tt.operation,
+				}
+			}
+
+			g.Expect(testEnv.Create(ctx, obj)).To(Succeed())
+
+			key := client.ObjectKey{Name: obj.Name, Namespace: obj.Namespace}
+
+			// Wait for the object to be reconciled
+			g.Eventually(func() bool {
+				if err := testEnv.Get(ctx, key, obj); err != nil {
+					return false
+				}
+				readyCondition := conditions.Get(obj, meta.ReadyCondition)
+				return readyCondition != nil && !conditions.IsUnknown(obj, meta.ReadyCondition)
+			}, timeout).Should(BeTrue())
+
+			g.Expect(conditions.IsReady(obj)).To(BeIdenticalTo(tt.wantErr == ""))
+			if tt.wantErr != "" {
+				g.Expect(conditions.Get(obj, sourcev1.FetchFailedCondition).Reason).To(Equal(sourcev1.OCILayerOperationFailedReason))
+				g.Expect(conditions.Get(obj, meta.ReadyCondition).Message).Should(ContainSubstring(tt.wantErr))
+			}
+
+			// Wait for the object to be deleted
+			g.Expect(testEnv.Delete(ctx, obj)).To(Succeed())
+			g.Eventually(func() bool {
+				if err := testEnv.Get(ctx, key, obj); err != nil {
+					return apierrors.IsNotFound(err)
+				}
+				return false
+			}, timeout).Should(BeTrue())
+		})
+	}
+}
+
 func TestOCIRepository_reconcileSource_authStrategy(t *testing.T) {
 	type secretOptions struct {
 		username      string
@@ -442,7 +571,7 @@ func TestOCIRepository_reconcileSource_authStrategy(t *testing.T) {
 			}),
 			},
 			assertConditions: []metav1.Condition{
-				*conditions.TrueCondition(sourcev1.FetchFailedCondition, sourcev1.OCIPullFailedReason, "failed to determine artifact digest"),
+				*conditions.TrueCondition(sourcev1.FetchFailedCondition, sourcev1.AuthenticationFailedReason, "failed to determine artifact digest"),
 			},
 		},
 		{
@@ -463,7 +592,7 @@ func TestOCIRepository_reconcileSource_authStrategy(t *testing.T) {
 				includeSecret: true,
 			},
 			assertConditions: []metav1.Condition{
-				*conditions.TrueCondition(sourcev1.FetchFailedCondition, sourcev1.OCIPullFailedReason, "UNAUTHORIZED"),
+				*conditions.TrueCondition(sourcev1.FetchFailedCondition, sourcev1.AuthenticationFailedReason, "UNAUTHORIZED"),
 			},
 		},
 		{
@@ -484,7 +613,7 @@ func TestOCIRepository_reconcileSource_authStrategy(t *testing.T) {
 				includeSA: true,
 			},
 			assertConditions: []metav1.Condition{
-				*conditions.TrueCondition(sourcev1.FetchFailedCondition, sourcev1.OCIPullFailedReason, "UNAUTHORIZED"),
+				*conditions.TrueCondition(sourcev1.FetchFailedCondition, sourcev1.AuthenticationFailedReason, "UNAUTHORIZED"),
 			},
 		},
 		{
@@ -673,7 +802,7 @@ func TestOCIRepository_reconcileSource_authStrategy(t *testing.T) {
 
 			opts := craneOptions(ctx, true)
 			opts = append(opts, crane.WithAuthFromKeychain(authn.DefaultKeychain))
-			repoURL, err := r.getArtifactURL(obj, opts)
+			repoURL, _, err := r.getArtifactURL(obj, remoteOptions{craneOpts: opts})
 			g.Expect(err).To(BeNil())
 
 			assertConditions := tt.assertConditions
@@ -852,6 +981,87 @@ func TestOCIRepository_CertSecret(t *testing.T) {
 	}
 }
 
+func TestOCIRepository_transport_serverName(t *testing.T) {
+	// The certificate of the test server is valid for 'example.com'
+	var gotServerName string
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	srv.TLS = &tls.Config{
+		GetConfigForClient: func(hello *tls.ClientHelloInfo) (*tls.Config, error) {
+			gotServerName = hello.ServerName
+			return nil, nil
+		},
+	}
+	srv.StartTLS()
+	defer srv.Close()
+	caCert := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: srv.Certificate().Raw})
+
+	tests := []struct {
+		name           string
+		serverName     string
+		wantTLSErr     bool
+		wantErr        string
+		wantServerName string
+	}{
+		{
+			name:           "server name matching the certificate",
+			serverName:     "example.com",
+			wantServerName: "example.com",
+		},
+		{
+			name:       "server name not matching the certificate",
+			serverName: "registry.example.org",
+			wantTLSErr: true,
+		},
+		{
+			name:       "server name with port",
+			serverName: "example.com:443",
+			wantErr:    "invalid 'serverName' in secret 'default/tls-certs': 'example.com:443' is not a valid DNS name or IP address",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+			gotServerName = ""
+
+			secret := &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Name: "tls-certs", Namespace: "default"},
+				Data: map[string][]byte{
+					oci.CACert:       caCert,
+					tlsServerNameKey: []byte(tt.serverName),
+				},
+			}
+			r := &OCIRepositoryReconciler{
+				Client: fakeclient.NewClientBuilder().WithScheme(testEnv.GetScheme()).WithObjects(secret).Build(),
+			}
+			obj := &sourcev1.OCIRepository{
+				ObjectMeta: metav1.ObjectMeta{Name: "podinfo", Namespace: "default"},
+				Spec: sourcev1.OCIRepositorySpec{
+					CertSecretRef: &meta.LocalObjectReference{Name: secret.Name},
+				},
+			}
+
+			transport, err := r.transport(ctx, obj)
+			if tt.wantErr != "" {
+				g.Expect(err).To(HaveOccurred())
+				g.Expect(err.Error()).To(ContainSubstring(tt.wantErr))
+				return
+			}
+			g.Expect(err).ToNot(HaveOccurred())
+
+			resp, err := (&http.Client{Transport: transport}).Get(srv.URL)
+			if tt.wantTLSErr {
+				// Verification is not disabled by the override
+				g.Expect(err).To(HaveOccurred())
+				g.Expect(err.Error()).To(ContainSubstring("certificate is valid for"))
+				return
+			}
+			g.Expect(err).ToNot(HaveOccurred())
+			resp.Body.Close()
+			g.Expect(gotServerName).To(Equal(tt.wantServerName))
+		})
+	}
+}
+
 func TestOCIRepository_reconcileSource_remoteReference(t *testing.T) {
 	g := NewWithT(t)
 
@@ -924,7 +1134,7 @@ func TestOCIRepository_reconcileSource_remoteReference(t *testing.T) {
 			want:    sreconcile.ResultEmpty,
 			wantErr: true,
 			assertConditions: []metav1.Condition{
-				*conditions.TrueCondition(sourcev1.FetchFailedCondition, sourcev1.OCIPullFailedReason, " MANIFEST_UNKNOWN"),
+				*conditions.TrueCondition(sourcev1.FetchFailedCondition, sourcev1.OCINotFoundReason, " MANIFEST_UNKNOWN"),
 			},
 		},
 		{
@@ -935,7 +1145,7 @@ func TestOCIRepository_reconcileSource_remoteReference(t *testing.T) {
 			want:    sreconcile.ResultEmpty,
 			wantErr: true,
 			assertConditions: []metav1.Condition{
-				*conditions.TrueCondition(sourcev1.FetchFailedCondition, sourcev1.ReadOperationFailedReason, "failed to determine the artifact tag for 'oci://%s/podinfo': no match found for semver: <= 6.1.0", server.registryHost),
+				*conditions.TrueCondition(sourcev1.FetchFailedCondition, sourcev1.ReadOperationFailedReason, "failed to determine the artifact tag for 'oci://%s/podinfo': no match found for semver: <= 6.1.0, available tags: 6.1.6, 6.1.5, 6.1.4, latest", server.registryHost),
 			},
 		},
 		{
@@ -1017,10 +1227,13 @@ func TestOCIRepository_reconcileSource_remoteReference(t *testing.T) {
 			got, err := r.reconcileSource(ctx, sp, obj, artifact, tmpDir)
 			if tt.wantErr {
 				g.Expect(err).To(HaveOccurred())
+				g.Expect(obj.Status.ObservedEndpoint).To(BeEmpty())
 			} else {
 				g.Expect(err).ToNot(HaveOccurred())
 				g.Expect(artifact.Revision).To(Equal(tt.wantRevision))
+				g.Expect(obj.Status.ObservedEndpoint).To(Equal(server.registryHost))
 			}
+			g.Expect(obj.Status.LastAttemptTime).ToNot(BeNil())
 
 			g.Expect(got).To(Equal(tt.want))
 			g.Expect(obj.Status.Conditions).To(conditions.MatchConditions(tt.assertConditions))
@@ -1048,6 +1261,8 @@ func TestOCIRepository_reconcileSource_verifyOCISourceSignature(t *testing.T) {
 		want             sreconcile.Result
 		wantErr          bool
 		wantErrMsg       string
+		wantRequeueAfter time.Duration
+		wantFailures     int
 		shouldSign       bool
 		keyless          bool
 		beforeFunc       func(obj *sourcev1.OCIRepository)
@@ -1082,6 +1297,104 @@ func TestOCIRepository_reconcileSource_verifyOCISourceSignature(t *testing.T) {
 				*conditions.FalseCondition(sourcev1.SourceVerifiedCondition, sourcev1.VerificationError, "failed to verify the signature using provider '<provider>': no matching signatures were found for '<url>'"),
 			},
 		},
+		{
+			name: "signed image without attestation should not pass attestation verification",
+			reference: &sourcev1.OCIRepositoryRef{
+				Tag: "6.1.4",
+			},
+			digest:     img4.digest.Hex,
+			shouldSign: true,
+			beforeFunc: func(obj *sourcev1.OCIRepository) {
+				obj.Spec.Verify.Attestation = &sourcev1.OCIAttestationVerification{}
+			},
+			wantErr:    true,
+			wantErrMsg: "failed to verify the signature using provider 'cosign': no matching attestations were found for '<url>'",
+			want:       sreconcile.ResultEmpty,
+			assertConditions: []metav1.Condition{
+				*conditions.TrueCondition(meta.ReconcilingCondition, meta.ProgressingReason, "building artifact: new revision '<digest>' for '<url>'"),
+				*conditions.UnknownCondition(meta.ReadyCondition, meta.ProgressingReason, "building artifact: new revision '<digest>' for '<url>'"),
+				*conditions.FalseCondition(sourcev1.SourceVerifiedCondition, sourcev1.VerificationError, "failed to verify the signature using provider '<provider>': no matching attestations were found for '<url>'"),
+			},
+		},
+		{
+			name: "unsigned image should not pass verification and retry with backoff",
+			reference: &sourcev1.OCIRepositoryRef{
+				Tag: "6.1.5",
+			},
+			digest: img5.digest.Hex,
+			beforeFunc: func(obj *sourcev1.OCIRepository) {
+				obj.Spec.VerificationRetry = &sourcev1.OCIRetryBackoff{
+					Base: &metav1.Duration{Duration: 10 * time.Second},
+					Max:  &metav1.Duration{Duration: time.Minute},
+				}
+			},
+			wantErr:          true,
+			wantErrMsg:       "failed to verify the signature using provider 'cosign': no matching signatures were found for '<url>', next retry in 10s at",
+			wantRequeueAfter: 10 * time.Second,
+			wantFailures:     1,
+			want:             sreconcile.ResultEmpty,
+			assertConditions: []metav1.Condition{
+				*conditions.TrueCondition(meta.ReconcilingCondition, meta.ProgressingReason, "building artifact: new revision '<digest>' for '<url>'"),
+				*conditions.UnknownCondition(meta.ReadyCondition, meta.ProgressingReason, "building artifact: new revision '<digest>' for '<url>'"),
+				*conditions.FalseCondition(sourcev1.SourceVerifiedCondition, sourcev1.VerificationError, "failed to verify the signature using provider '<provider>': no matching signatures were found for '<url>'"),
+			},
+		},
+		{
+			name: "failed verification should not be retried before the next attempt",
+			reference: &sourcev1.OCIRepositoryRef{
+				Tag: "6.1.5",
+			},
+			digest: img5.digest.Hex,
+			beforeFunc: func(obj *sourcev1.OCIRepository) {
+				obj.Spec.VerificationRetry = &sourcev1.OCIRetryBackoff{
+					Base: &metav1.Duration{Duration: 10 * time.Second},
+				}
+				obj.Status.VerificationRetry = &sourcev1.OCIVerificationRetry{
+					Revision:        fmt.Sprintf("6.1.5/%s", img5.digest.Hex),
+					Failures:        3,
+					NextAttemptTime: metav1.NewTime(time.Now().Add(time.Hour)),
+				}
+				conditions.MarkFalse(obj, sourcev1.SourceVerifiedCondition, sourcev1.VerificationError, "failed to verify the signature")
+			},
+			wantErr:          true,
+			wantErrMsg:       "failed to verify the signature, next retry in 1h0m0s at",
+			wantRequeueAfter: time.Hour,
+			wantFailures:     3,
+			want:             sreconcile.ResultEmpty,
+			assertConditions: []metav1.Condition{
+				*conditions.TrueCondition(meta.ReconcilingCondition, meta.ProgressingReason, "building artifact: new revision '<digest>' for '<url>'"),
+				*conditions.UnknownCondition(meta.ReadyCondition, meta.ProgressingReason, "building artifact: new revision '<digest>' for '<url>'"),
+				*conditions.FalseCondition(sourcev1.SourceVerifiedCondition, sourcev1.VerificationError, "failed to verify the signature"),
+			},
+		},
+		{
+			name: "failed verification of another revision should not delay the verification",
+			reference: &sourcev1.OCIRepositoryRef{
+				Tag: "6.1.5",
+			},
+			digest: img5.digest.Hex,
+			beforeFunc: func(obj *sourcev1.OCIRepository) {
+				obj.Spec.VerificationRetry = &sourcev1.OCIRetryBackoff{
+					Base: &metav1.Duration{Duration: 10 * time.Second},
+				}
+				obj.Status.VerificationRetry = &sourcev1.OCIVerificationRetry{
+					Revision:        fmt.Sprintf("6.1.4/%s", img4.digest.Hex),
+					Failures:        3,
+					NextAttemptTime: metav1.NewTime(time.Now().Add(time.Hour)),
+				}
+				conditions.MarkFalse(obj, sourcev1.SourceVerifiedCondition, sourcev1.VerificationError, "failed to verify the signature")
+			},
+			wantErr:          true,
+			wantErrMsg:       "failed to verify the signature using provider 'cosign': no matching signatures were found for '<url>', next retry in 10s at",
+			wantRequeueAfter: 10 * time.Second,
+			wantFailures:     1,
+			want:             sreconcile.ResultEmpty,
+			assertConditions: []metav1.Condition{
+				*conditions.TrueCondition(meta.ReconcilingCondition, meta.ProgressingReason, "building artifact: new revision '<digest>' for '<url>'"),
+				*conditions.UnknownCondition(meta.ReadyCondition, meta.ProgressingReason, "building artifact: new revision '<digest>' for '<url>'"),
+				*conditions.FalseCondition(sourcev1.SourceVerifiedCondition, sourcev1.VerificationError, "failed to verify the signature using provider '<provider>': no matching signatures were found for '<url>'"),
+			},
+		},
 		{
 			name: "unsigned image should not pass keyless verification",
 			reference: &sourcev1.OCIRepositoryRef{
@@ -1226,7 +1539,7 @@ func TestOCIRepository_reconcileSource_verifyOCISourceSignature(t *testing.T) {
 
 			opts := craneOptions(ctx, true)
 			opts = append(opts, crane.WithAuthFromKeychain(keychain))
-			artifactURL, err := r.getArtifactURL(obj, opts)
+			artifactURL, _, err := r.getArtifactURL(obj, remoteOptions{craneOpts: opts})
 			g.Expect(err).ToNot(HaveOccurred())
 
 			if tt.shouldSign {
@@ -1273,125 +1586,205 @@ func TestOCIRepository_reconcileSource_verifyOCISourceSignature(t *testing.T) {
 			} else {
 				g.Expect(err).ToNot(HaveOccurred())
 			}
+			var waitErr *serror.Waiting
+			if tt.wantRequeueAfter > 0 {
+				g.Expect(errors.As(err, &waitErr)).To(BeTrue())
+				g.Expect(waitErr.RequeueAfter).To(BeNumerically("~", tt.wantRequeueAfter, time.Second))
+			} else {
+				g.Expect(errors.As(err, &waitErr)).To(BeFalse())
+			}
+			if tt.wantFailures > 0 {
+				g.Expect(obj.Status.VerificationRetry).ToNot(BeNil())
+				g.Expect(obj.Status.VerificationRetry.Failures).To(Equal(tt.wantFailures))
+			} else {
+				g.Expect(obj.Status.VerificationRetry).To(BeNil())
+			}
 			g.Expect(got).To(Equal(tt.want))
 			g.Expect(obj.Status.Conditions).To(conditions.MatchConditions(tt.assertConditions))
 		})
 	}
 }
 
-func TestOCIRepository_reconcileSource_noop(t *testing.T) {
-	g := NewWithT(t)
+func TestOCIRepository_verificationRetryDelay(t *testing.T) {
+	tests := []struct {
+		name     string
+		base     time.Duration
+		max      time.Duration
+		failures int
+		want     []time.Duration
+	}{
+		{
+			name:     "doubles the delay on every failure",
+			base:     time.Second,
+			failures: 4,
+			want:     []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 8 * time.Second},
+		},
+		{
+			name:     "caps the delay at max",
+			base:     time.Second,
+			max:      5 * time.Second,
+			failures: 5,
+			want:     []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 5 * time.Second, 5 * time.Second},
+		},
+		{
+			name:     "base larger than max",
+			base:     time.Minute,
+			max:      time.Second,
+			failures: 2,
+			want:     []time.Duration{time.Second, time.Second},
+		},
+		{
+			name:     "does not overflow",
+			base:     time.Hour,
+			failures: 100,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
 
-	testRevision := "6.1.5/d1fc4595915714af2492dc4b66097de1e10f80150c8899907d8f8e61c6d6f67d"
+			var got []time.Duration
+			for i := 1; i <= tt.failures; i++ {
+				delay := verificationRetryDelay(i, tt.base, tt.max)
+				g.Expect(delay).To(BeNumerically(">", 0))
+				got = append(got, delay)
+			}
+			if tt.want != nil {
+				g.Expect(got).To(Equal(tt.want))
+			}
+		})
+	}
+}
+
+func TestOCIRepository_verificationDue(t *testing.T) {
+	now := time.Now()
+
+	tests := []struct {
+		name     string
+		verify   *sourcev1.OCIRepositoryVerification
+		lastTime *metav1.Time
+		want     bool
+	}{
+		{
+			name: "verification disabled",
+		},
+		{
+			name:     "no interval",
+			verify:   &sourcev1.OCIRepositoryVerification{Provider: "cosign"},
+			lastTime: &metav1.Time{Time: now.Add(-24 * time.Hour)},
+		},
+		{
+			name:   "never verified",
+			verify: &sourcev1.OCIRepositoryVerification{Provider: "cosign", Interval: &metav1.Duration{Duration: time.Hour}},
+			want:   true,
+		},
+		{
+			name:     "interval not elapsed",
+			verify:   &sourcev1.OCIRepositoryVerification{Provider: "cosign", Interval: &metav1.Duration{Duration: time.Hour}},
+			lastTime: &metav1.Time{Time: now.Add(-30 * time.Minute)},
+		},
+		{
+			name:     "interval elapsed",
+			verify:   &sourcev1.OCIRepositoryVerification{Provider: "cosign", Interval: &metav1.Duration{Duration: time.Hour}},
+			lastTime: &metav1.Time{Time: now.Add(-time.Hour)},
+			want:     true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			obj := &sourcev1.OCIRepository{
+				Spec: sourcev1.OCIRepositorySpec{
+					Verify: tt.verify,
+				},
+				Status: sourcev1.OCIRepositoryStatus{
+					LastVerificationTime: tt.lastTime,
+				},
+			}
+			g.Expect(verificationDue(obj, now)).To(Equal(tt.want))
+		})
+	}
+}
+
+func TestOCIRepository_reconcileSource_requireSBOM(t *testing.T) {
+	g := NewWithT(t)
 
 	tmpDir := t.TempDir()
 	server, err := setupRegistryServer(ctx, tmpDir, registryOptions{})
 	g.Expect(err).ToNot(HaveOccurred())
 
-	_, err = pushMultiplePodinfoImages(server.registryHost, "6.1.5")
+	podinfoVersions, err := pushMultiplePodinfoImages(server.registryHost, "6.1.4", "6.1.5", "6.1.6")
 	g.Expect(err).ToNot(HaveOccurred())
 
-	// NOTE: The following verifies if it was a noop run by checking the
-	// artifact metadata which is unknown unless the image is pulled.
+	// Attach an SPDX SBOM to 6.1.4, and a malformed one to 6.1.5
+	sbomDigest := pushSBOMReferrer(g, server.registryHost, podinfoVersions["6.1.4"].digest,
+		`{"spdxVersion": "SPDX-2.3", "SPDXID": "SPDXRef-DOCUMENT", "packages": [{"name": "podinfo"}]}`)
+	pushSBOMReferrer(g, server.registryHost, podinfoVersions["6.1.5"].digest, `{"SPDXID": "SPDXRef-DOCUMENT"}`)
 
 	tests := []struct {
-		name       string
-		beforeFunc func(obj *sourcev1.OCIRepository)
-		afterFunc  func(g *WithT, artifact *sourcev1.Artifact)
+		name             string
+		tag              string
+		requireSBOM      *sourcev1.OCISBOMRequirement
+		want             sreconcile.Result
+		wantErr          string
+		wantSBOMDigest   string
+		assertConditions []metav1.Condition
 	}{
 		{
-			name: "full reconcile - no existing artifact",
-			afterFunc: func(g *WithT, artifact *sourcev1.Artifact) {
-				g.Expect(artifact.Metadata).ToNot(BeEmpty())
+			name:           "valid SBOM",
+			tag:            "6.1.4",
+			requireSBOM:    &sourcev1.OCISBOMRequirement{Format: "spdx", RequiredFields: []string{"packages"}},
+			want:           sreconcile.ResultSuccess,
+			wantSBOMDigest: sbomDigest.String(),
+			assertConditions: []metav1.Condition{
+				*conditions.TrueCondition(meta.ReconcilingCondition, meta.ProgressingReason, "building artifact: new revision '<revision>' for '<url>'"),
+				*conditions.UnknownCondition(meta.ReadyCondition, meta.ProgressingReason, "building artifact: new revision '<revision>' for '<url>'"),
 			},
 		},
 		{
-			name: "noop - artifact revisions match",
-			beforeFunc: func(obj *sourcev1.OCIRepository) {
-				obj.Status.Artifact = &sourcev1.Artifact{
-					Revision: testRevision,
-				}
-			},
-			afterFunc: func(g *WithT, artifact *sourcev1.Artifact) {
-				g.Expect(artifact.Metadata).To(BeEmpty())
-			},
-		},
-		{
-			name: "full reconcile - same rev, unobserved ignore",
-			beforeFunc: func(obj *sourcev1.OCIRepository) {
-				obj.Status.ObservedIgnore = pointer.String("aaa")
-				obj.Status.Artifact = &sourcev1.Artifact{
-					Revision: testRevision,
-				}
-			},
-			afterFunc: func(g *WithT, artifact *sourcev1.Artifact) {
-				g.Expect(artifact.Metadata).ToNot(BeEmpty())
-			},
-		},
-		{
-			name: "noop - same rev, observed ignore",
-			beforeFunc: func(obj *sourcev1.OCIRepository) {
-				obj.Spec.Ignore = pointer.String("aaa")
-				obj.Status.ObservedIgnore = pointer.String("aaa")
-				obj.Status.Artifact = &sourcev1.Artifact{
-					Revision: testRevision,
-				}
-			},
-			afterFunc: func(g *WithT, artifact *sourcev1.Artifact) {
-				g.Expect(artifact.Metadata).To(BeEmpty())
+			name:        "SBOM missing required field",
+			tag:         "6.1.4",
+			requireSBOM: &sourcev1.OCISBOMRequirement{Format: "spdx", RequiredFields: []string{"relationships"}},
+			want:        sreconcile.ResultEmpty,
+			wantErr:     "missing required fields: relationships",
+			assertConditions: []metav1.Condition{
+				*conditions.TrueCondition(sourcev1.FetchFailedCondition, sourcev1.OCISBOMValidationFailedReason, "failed to validate the spdx SBOM of revision <revision>"),
+				*conditions.TrueCondition(meta.ReconcilingCondition, meta.ProgressingReason, "building artifact: new revision '<revision>' for '<url>'"),
+				*conditions.UnknownCondition(meta.ReadyCondition, meta.ProgressingReason, "building artifact: new revision '<revision>' for '<url>'"),
 			},
 		},
 		{
-			name: "full reconcile - same rev, unobserved layer selector",
-			beforeFunc: func(obj *sourcev1.OCIRepository) {
-				obj.Spec.LayerSelector = &sourcev1.OCILayerSelector{
-					MediaType: "application/vnd.docker.image.rootfs.diff.tar.gzip",
-					Operation: sourcev1.OCILayerCopy,
-				}
-				obj.Status.Artifact = &sourcev1.Artifact{
-					Revision: testRevision,
-				}
-			},
-			afterFunc: func(g *WithT, artifact *sourcev1.Artifact) {
-				g.Expect(artifact.Metadata).ToNot(BeEmpty())
+			name:        "malformed SBOM",
+			tag:         "6.1.5",
+			requireSBOM: &sourcev1.OCISBOMRequirement{Format: "spdx"},
+			want:        sreconcile.ResultEmpty,
+			wantErr:     "missing or invalid 'spdxVersion'",
+			assertConditions: []metav1.Condition{
+				*conditions.TrueCondition(sourcev1.FetchFailedCondition, sourcev1.OCISBOMValidationFailedReason, "failed to validate the spdx SBOM of revision <revision>"),
+				*conditions.TrueCondition(meta.ReconcilingCondition, meta.ProgressingReason, "building artifact: new revision '<revision>' for '<url>'"),
+				*conditions.UnknownCondition(meta.ReadyCondition, meta.ProgressingReason, "building artifact: new revision '<revision>' for '<url>'"),
 			},
 		},
 		{
-			name: "noop - same rev, observed layer selector",
-			beforeFunc: func(obj *sourcev1.OCIRepository) {
-				obj.Spec.LayerSelector = &sourcev1.OCILayerSelector{
-					MediaType: "application/vnd.docker.image.rootfs.diff.tar.gzip",
-					Operation: sourcev1.OCILayerCopy,
-				}
-				obj.Status.ObservedLayerSelector = &sourcev1.OCILayerSelector{
-					MediaType: "application/vnd.docker.image.rootfs.diff.tar.gzip",
-					Operation: sourcev1.OCILayerCopy,
-				}
-				obj.Status.Artifact = &sourcev1.Artifact{
-					Revision: testRevision,
-				}
-			},
-			afterFunc: func(g *WithT, artifact *sourcev1.Artifact) {
-				g.Expect(artifact.Metadata).To(BeEmpty())
+			name:        "absent SBOM",
+			tag:         "6.1.6",
+			requireSBOM: &sourcev1.OCISBOMRequirement{Format: "cyclonedx"},
+			want:        sreconcile.ResultEmpty,
+			wantErr:     soci.ErrSBOMNotFound.Error(),
+			assertConditions: []metav1.Condition{
+				*conditions.TrueCondition(sourcev1.FetchFailedCondition, sourcev1.OCISBOMValidationFailedReason, "failed to validate the cyclonedx SBOM of revision <revision>"),
+				*conditions.TrueCondition(meta.ReconcilingCondition, meta.ProgressingReason, "building artifact: new revision '<revision>' for '<url>'"),
+				*conditions.UnknownCondition(meta.ReadyCondition, meta.ProgressingReason, "building artifact: new revision '<revision>' for '<url>'"),
 			},
 		},
 		{
-			name: "full reconcile - same rev, observed layer selector changed",
-			beforeFunc: func(obj *sourcev1.OCIRepository) {
-				obj.Spec.LayerSelector = &sourcev1.OCILayerSelector{
-					MediaType: "application/vnd.docker.image.rootfs.diff.tar.gzip",
-					Operation: sourcev1.OCILayerExtract,
-				}
-				obj.Status.ObservedLayerSelector = &sourcev1.OCILayerSelector{
-					MediaType: "application/vnd.docker.image.rootfs.diff.tar.gzip",
-					Operation: sourcev1.OCILayerCopy,
-				}
-				obj.Status.Artifact = &sourcev1.Artifact{
-					Revision: testRevision,
-				}
-			},
-			afterFunc: func(g *WithT, artifact *sourcev1.Artifact) {
-				g.Expect(artifact.Metadata).ToNot(BeEmpty())
+			name: "SBOM not required",
+			tag:  "6.1.6",
+			want: sreconcile.ResultSuccess,
+			assertConditions: []metav1.Condition{
+				*conditions.TrueCondition(meta.ReconcilingCondition, meta.ProgressingReason, "building artifact: new revision '<revision>' for '<url>'"),
+				*conditions.UnknownCondition(meta.ReadyCondition, meta.ProgressingReason, "building artifact: new revision '<revision>' for '<url>'"),
 			},
 		},
 	}
@@ -1410,376 +1803,3814 @@ func TestOCIRepository_reconcileSource_noop(t *testing.T) {
 
 			obj := &sourcev1.OCIRepository{
 				ObjectMeta: metav1.ObjectMeta{
-					GenerateName: "noop-",
+					GenerateName: "require-sbom-",
 					Generation:   1,
 				},
 				Spec: sourcev1.OCIRepositorySpec{
-					URL:       fmt.Sprintf("oci://%s/podinfo", server.registryHost),
-					Reference: &sourcev1.OCIRepositoryRef{Tag: "6.1.5"},
-					Interval:  metav1.Duration{Duration: interval},
-					Timeout:   &metav1.Duration{Duration: timeout},
+					URL:         fmt.Sprintf("oci://%s/podinfo", server.registryHost),
+					Reference:   &sourcev1.OCIRepositoryRef{Tag: tt.tag},
+					RequireSBOM: tt.requireSBOM,
+					Interval:    metav1.Duration{Duration: interval},
+					Timeout:     &metav1.Duration{Duration: timeout},
 				},
 			}
 
-			if tt.beforeFunc != nil {
-				tt.beforeFunc(obj)
-			}
-
 			g.Expect(r.Client.Create(ctx, obj)).ToNot(HaveOccurred())
 			defer func() {
 				g.Expect(r.Client.Delete(ctx, obj)).ToNot(HaveOccurred())
 			}()
 
-			sp := patch.NewSerialPatcher(obj, r.Client)
+			revision := fmt.Sprintf("%s/%s", tt.tag, podinfoVersions[tt.tag].digest.Hex)
+			artifactURL := fmt.Sprintf("%s/podinfo:%s", server.registryHost, tt.tag)
+			for k := range tt.assertConditions {
+				tt.assertConditions[k].Message = strings.ReplaceAll(tt.assertConditions[k].Message, "<revision>", revision)
+				tt.assertConditions[k].Message = strings.ReplaceAll(tt.assertConditions[k].Message, "<url>", artifactURL)
+			}
 
-			artifact := &sourcev1.Artifact{}
-			tmpDir := t.TempDir()
-			got, err := r.reconcileSource(ctx, sp, obj, artifact, tmpDir)
-			g.Expect(err).ToNot(HaveOccurred())
-			g.Expect(got).To(Equal(sreconcile.ResultSuccess))
+			sp := patch.NewSerialPatcher(obj, r.Client)
 
-			if tt.afterFunc != nil {
-				tt.afterFunc(g, artifact)
+			got, err := r.reconcileSource(ctx, sp, obj, &sourcev1.Artifact{}, t.TempDir())
+			if tt.wantErr != "" {
+				g.Expect(err).To(HaveOccurred())
+				g.Expect(err.Error()).To(ContainSubstring(tt.wantErr))
+			} else {
+				g.Expect(err).ToNot(HaveOccurred())
 			}
+			g.Expect(got).To(Equal(tt.want))
+			g.Expect(obj.Status.SBOMDigest).To(Equal(tt.wantSBOMDigest))
+			g.Expect(obj.Status.Conditions).To(conditions.MatchConditions(tt.assertConditions))
 		})
 	}
 }
 
-func TestOCIRepository_reconcileArtifact(t *testing.T) {
+// pushSBOMReferrer pushes an SPDX SBOM with the given document as referrer
+// of the podinfo artifact with the given digest, using the referrers tag
+// schema. It returns the digest of the SBOM manifest.
+func pushSBOMReferrer(g *WithT, registryHost string, subject gcrv1.Hash, document string) gcrv1.Hash {
+	img, err := mutate.AppendLayers(empty.Image, static.NewLayer([]byte(document), soci.SPDXMediaType))
+	g.Expect(err).ToNot(HaveOccurred())
+	digest, err := img.Digest()
+	g.Expect(err).ToNot(HaveOccurred())
+
+	ref, err := name.NewTag(fmt.Sprintf("%s/podinfo:%s-%s", registryHost, subject.Algorithm, subject.Hex), name.Insecure)
+	g.Expect(err).ToNot(HaveOccurred())
+	idx := mutate.AppendManifests(empty.Index, mutate.IndexAddendum{Add: img})
+	g.Expect(remote.WriteIndex(ref, idx)).To(Succeed())
+
+	return digest
+}
+
+func TestOCIRepository_fetchLayers(t *testing.T) {
+	const mediaType = "application/vnd.cncf.flux.content.v1.tar+gzip"
+
+	type layer struct {
+		mediaType string
+		files     map[string]string
+	}
+
 	tests := []struct {
-		name             string
-		targetPath       string
-		artifact         *sourcev1.Artifact
-		beforeFunc       func(obj *sourcev1.OCIRepository)
-		want             sreconcile.Result
-		wantErr          bool
-		assertArtifact   *sourcev1.Artifact
-		assertPaths      []string
-		assertConditions []metav1.Condition
-		afterFunc        func(g *WithT, obj *sourcev1.OCIRepository)
+		name      string
+		layers    []layer
+		selector  *sourcev1.OCILayerSelector
+		maxSize   *int64
+		wantFiles map[string]string
+		wantErr   string
 	}{
 		{
-			name:       "Archiving Artifact creates correct files and condition",
-			targetPath: "testdata/oci/repository",
-			artifact: &sourcev1.Artifact{
-				Revision: "revision",
-			},
-			beforeFunc: func(obj *sourcev1.OCIRepository) {
-				conditions.MarkTrue(obj, sourcev1.ArtifactOutdatedCondition, "NewRevision", "new revision")
+			name: "extracts all selected layers in manifest order",
+			layers: []layer{
+				{mediaType: mediaType, files: map[string]string{"app.yaml": "v1", "a.yaml": "a"}},
+				{mediaType: mediaType, files: map[string]string{"app.yaml": "v2", "b.yaml": "b"}},
+				{mediaType: mediaType, files: map[string]string{"app.yaml": "v3", "c.yaml": "c"}},
+			},
+			selector:  &sourcev1.OCILayerSelector{MediaType: mediaType},
+			wantFiles: map[string]string{"app.yaml": "v3", "a.yaml": "a", "b.yaml": "b", "c.yaml": "c"},
+		},
+		{
+			name: "extracts the default layer without a selector",
+			layers: []layer{
+				{mediaType: mediaType, files: map[string]string{"app.yaml": "v1"}},
+				{mediaType: "application/vnd.example.config.v1.tar", files: map[string]string{"config.yaml": "config"}},
 			},
-			want: sreconcile.ResultSuccess,
-			assertPaths: []string{
-				"latest.tar.gz",
+			wantFiles: map[string]string{"app.yaml": "v1"},
+		},
+		{
+			name: "extracts all gzip compressed tarballs without a selector",
+			layers: []layer{
+				{mediaType: mediaType, files: map[string]string{"app.yaml": "v1", "a.yaml": "a"}},
+				{mediaType: "application/vnd.example.config.v1.tar", files: map[string]string{"config.yaml": "config"}},
+				{mediaType: "application/vnd.docker.image.rootfs.diff.tar.gzip", files: map[string]string{"app.yaml": "v2"}},
 			},
-			afterFunc: func(g *WithT, obj *sourcev1.OCIRepository) {
-				g.Expect(obj.Status.Artifact.Checksum).To(Equal("de37cb640bfe6c789f2b131416d259747d5757f7fe5e1d9d48f32d8c30af5934"))
+			wantFiles: map[string]string{"app.yaml": "v2", "a.yaml": "a"},
+		},
+		{
+			name: "extracts the non-empty gzip compressed tarball next to empty ones",
+			layers: []layer{
+				{mediaType: mediaType, files: map[string]string{}},
+				{mediaType: mediaType, files: map[string]string{"app.yaml": "v1"}},
 			},
-			assertConditions: []metav1.Condition{
-				*conditions.TrueCondition(sourcev1.ArtifactInStorageCondition, meta.SucceededReason, "stored artifact for digest"),
+			wantFiles: map[string]string{"app.yaml": "v1"},
+		},
+		{
+			name: "no gzip compressed tarballs without a selector",
+			layers: []layer{
+				{mediaType: "application/vnd.example.config.v1.tar", files: map[string]string{"config.yaml": "config"}},
+				{mediaType: "application/vnd.example.other.v1.tar", files: map[string]string{"other.yaml": "other"}},
 			},
+			wantErr: "set '.spec.layerSelector.mediaType' to select the layer",
 		},
 		{
-			name:       "Artifact with source ignore",
-			targetPath: "testdata/oci/repository",
-			artifact:   &sourcev1.Artifact{Revision: "revision"},
-			beforeFunc: func(obj *sourcev1.OCIRepository) {
-				obj.Spec.Ignore = pointer.String("foo.txt")
+			name: "extracts only layers with the selected media type",
+			layers: []layer{
+				{mediaType: mediaType, files: map[string]string{"app.yaml": "v1"}},
+				{mediaType: "application/vnd.example.other.tar+gzip", files: map[string]string{"app.yaml": "other", "other.yaml": "other"}},
+				{mediaType: mediaType, files: map[string]string{"app.yaml": "v2"}},
+			},
+			selector:  &sourcev1.OCILayerSelector{MediaType: mediaType},
+			wantFiles: map[string]string{"app.yaml": "v2"},
+		},
+		{
+			name: "no layers with the selected media type",
+			layers: []layer{
+				{mediaType: mediaType, files: map[string]string{"app.yaml": "v1"}},
 			},
-			want: sreconcile.ResultSuccess,
-			assertPaths: []string{
-				"latest.tar.gz",
+			selector: &sourcev1.OCILayerSelector{MediaType: "application/invalid.tar.gzip"},
+			wantErr:  "failed to find layer with media type 'application/invalid.tar.gzip'",
+		},
+		{
+			name: "extracts zstd compressed layers",
+			layers: []layer{
+				{mediaType: "application/vnd.oci.image.layer.v1.tar+zstd", files: map[string]string{"app.yaml": "v1", "a.yaml": "a"}},
+				{mediaType: "application/vnd.oci.image.layer.v1.tar+zstd", files: map[string]string{"app.yaml": "v2", "b.yaml": "b"}},
 			},
-			afterFunc: func(g *WithT, obj *sourcev1.OCIRepository) {
-				g.Expect(obj.Status.Artifact.Checksum).To(Equal("05aada03e3e3e96f5f85a8f31548d833974ce862be14942fb3313eef2df861ec"))
+			selector:  &sourcev1.OCILayerSelector{MediaType: "application/vnd.oci.image.layer.v1.tar+zstd"},
+			wantFiles: map[string]string{"app.yaml": "v2", "a.yaml": "a", "b.yaml": "b"},
+		},
+		{
+			name: "layer with unsupported compression",
+			layers: []layer{
+				{mediaType: "application/vnd.example.layer.tar+bzip2", files: map[string]string{"app.yaml": "v2"}},
 			},
-			assertConditions: []metav1.Condition{
-				*conditions.TrueCondition(sourcev1.ArtifactInStorageCondition, meta.SucceededReason, "stored artifact for digest"),
+			wantErr: "failed to determine the compression of layer[0] from artifact: unsupported media type 'application/vnd.example.layer.tar+bzip2': unsupported compression 'bzip2'",
+		},
+		{
+			name: "extracts only the chart of a Helm chart",
+			layers: []layer{
+				{mediaType: helmreg.ChartLayerMediaType, files: map[string]string{"podinfo/Chart.yaml": "name: podinfo"}},
+				{mediaType: helmreg.ProvLayerMediaType, files: map[string]string{"podinfo/Chart.yaml": "provenance"}},
 			},
+			wantFiles: map[string]string{"podinfo/Chart.yaml": "name: podinfo"},
 		},
 		{
-			name: "No status changes if artifact is already present",
-			artifact: &sourcev1.Artifact{
-				Revision: "revision",
+			name: "layer exceeds the maximum size",
+			layers: []layer{
+				{mediaType: mediaType, files: map[string]string{"app.yaml": "v1"}},
 			},
-			targetPath: "testdata/oci/repository",
-			want:       sreconcile.ResultSuccess,
-			beforeFunc: func(obj *sourcev1.OCIRepository) {
-				obj.Status.Artifact = &sourcev1.Artifact{
-					Revision: "revision",
+			maxSize: resource.NewQuantity(10, resource.BinarySI),
+			wantErr: "total size",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			img := empty.Image
+			for _, l := range tt.layers {
+				create := createTarGzLayer
+				if strings.HasSuffix(l.mediaType, "+zstd") {
+					create = createTarZstdLayer
 				}
-			},
-			assertArtifact: &sourcev1.Artifact{
-				Revision: "revision",
-			},
-			assertConditions: []metav1.Condition{
-				*conditions.TrueCondition(sourcev1.ArtifactInStorageCondition, meta.SucceededReason, "stored artifact for digest"),
-			},
+				b, err := create(l.files)
+				g.Expect(err).ToNot(HaveOccurred())
+				img, err = mutate.AppendLayers(img, static.NewLayer(b, gcrtypes.MediaType(l.mediaType)))
+				g.Expect(err).ToNot(HaveOccurred())
+			}
+
+			obj := &sourcev1.OCIRepository{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "fetch-layers",
+					Namespace: "default",
+				},
+				Spec: sourcev1.OCIRepositorySpec{
+					LayerSelector: tt.selector,
+					MaxSize:       tt.maxSize,
+				},
+			}
+
+			r := &OCIRepositoryReconciler{}
+			files, err := r.fetchLayers(ctx, obj, img, t.TempDir())
+			if tt.wantErr != "" {
+				g.Expect(err).To(HaveOccurred())
+				g.Expect(err.Error()).To(ContainSubstring(tt.wantErr))
+				return
+			}
+			g.Expect(err).ToNot(HaveOccurred())
+
+			dir := t.TempDir()
+			g.Expect(untarLayerFiles(files, dir, archive.Limits{})).To(Succeed())
+
+			// The layers extracted while they are downloaded yield the same
+			// content as the fetched layers
+			layers, err := r.selectLayers(obj, img)
+			g.Expect(err).ToNot(HaveOccurred())
+			streamed := t.TempDir()
+			g.Expect(untarLayers(layers, streamed, archive.Limits{})).To(Succeed())
+
+			for _, d := range []string{dir, streamed} {
+				for name, content := range tt.wantFiles {
+					b, err := os.ReadFile(filepath.Join(d, name))
+					g.Expect(err).ToNot(HaveOccurred())
+					g.Expect(string(b)).To(Equal(content))
+				}
+				entries, err := os.ReadDir(d)
+				g.Expect(err).ToNot(HaveOccurred())
+				g.Expect(entries).To(HaveLen(len(tt.wantFiles)))
+			}
+		})
+	}
+}
+
+func TestOCIRepository_fetchLayers_totalMaxSize(t *testing.T) {
+	const mediaType = "application/vnd.cncf.flux.content.v1.tar+gzip"
+	g := NewWithT(t)
+
+	img := empty.Image
+	var total, largest int64
+	for _, files := range []map[string]string{{"a.yaml": "a"}, {"b.yaml": "b"}} {
+		b, err := createTarGzLayer(files)
+		g.Expect(err).ToNot(HaveOccurred())
+		img, err = mutate.AppendLayers(img, static.NewLayer(b, gcrtypes.MediaType(mediaType)))
+		g.Expect(err).ToNot(HaveOccurred())
+		total += int64(len(b))
+		if int64(len(b)) > largest {
+			largest = int64(len(b))
+		}
+	}
+
+	// Each layer fits the maximum size, but not both of them
+	obj := &sourcev1.OCIRepository{
+		Spec: sourcev1.OCIRepositorySpec{
+			LayerSelector: &sourcev1.OCILayerSelector{MediaType: mediaType},
+			MaxSize:       resource.NewQuantity(largest+1, resource.BinarySI),
 		},
+	}
+	r := &OCIRepositoryReconciler{}
+	_, err := r.fetchLayers(ctx, obj, img, t.TempDir())
+	g.Expect(err).To(MatchError(fmt.Sprintf("total size %d of the 2 selected layer(s) exceeds the maximum size of %d bytes",
+		total, largest+1)))
+}
+
+func Test_sizeBudget(t *testing.T) {
+	g := NewWithT(t)
+
+	budget := newSizeBudget(10)
+	_, err := io.ReadAll(budget.limit(io.NopCloser(strings.NewReader("123456"))))
+	g.Expect(err).ToNot(HaveOccurred())
+	_, err = io.ReadAll(budget.limit(io.NopCloser(strings.NewReader("123456"))))
+	g.Expect(err).To(MatchError("size limit of 10 exceeded"))
+
+	// A zero budget is unlimited
+	budget = newSizeBudget(0)
+	b, err := io.ReadAll(budget.limit(io.NopCloser(strings.NewReader("123456"))))
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(b).To(HaveLen(6))
+}
+
+func Test_untarLayerFiles_totalMaxSize(t *testing.T) {
+	g := NewWithT(t)
+
+	tmpDir := t.TempDir()
+	var files []layerFile
+	for i, content := range []string{"12345", "67890"} {
+		b, err := createTarGzLayer(map[string]string{fmt.Sprintf("%d.txt", i): content})
+		g.Expect(err).ToNot(HaveOccurred())
+		p := filepath.Join(tmpDir, fmt.Sprintf("layer-%d", i))
+		g.Expect(os.WriteFile(p, b, 0o600)).To(Succeed())
+		files = append(files, layerFile{path: p, compression: archive.Gzip})
+	}
+
+	g.Expect(untarLayerFiles(files, t.TempDir(), archive.Limits{MaxSize: 10})).To(Succeed())
+	err := untarLayerFiles(files, t.TempDir(), archive.Limits{MaxSize: 8})
+	g.Expect(err).To(MatchError("size limit of 8 exceeded"))
+}
+
+// createTarGzLayer returns the gzip compressed tarball of the given files.
+func createTarGzLayer(files map[string]string) ([]byte, error) {
+	var buf bytes.Buffer
+	gw := gzip.NewWriter(&buf)
+	if err := writeTarFiles(gw, files); err != nil {
+		return nil, err
+	}
+	if err := gw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// createTarZstdLayer returns the zstd compressed tarball of the given files.
+func createTarZstdLayer(files map[string]string) ([]byte, error) {
+	var buf bytes.Buffer
+	zw, err := zstd.NewWriter(&buf)
+	if err != nil {
+		return nil, err
+	}
+	if err := writeTarFiles(zw, files); err != nil {
+		return nil, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// writeTarFiles writes the tarball of the given files to w.
+func writeTarFiles(w io.Writer, files map[string]string) error {
+	tw := tar.NewWriter(w)
+	for name, content := range files {
+		if err := tw.WriteHeader(&tar.Header{
+			Name: name,
+			Mode: 0o644,
+			Size: int64(len(content)),
+		}); err != nil {
+			return err
+		}
+		if _, err := tw.Write([]byte(content)); err != nil {
+			return err
+		}
+	}
+	return tw.Close()
+}
+
+func TestOCIRepository_verifyFileDigest(t *testing.T) {
+	content := []byte("podinfo layer content")
+	digest, _, err := gcrv1.SHA256(bytes.NewReader(content))
+	NewWithT(t).Expect(err).ToNot(HaveOccurred())
+
+	tests := []struct {
+		name    string
+		content []byte
+		wantErr string
+	}{
 		{
-			name:       "Artifact already present, unobserved ignore, rebuild artifact",
-			targetPath: "testdata/oci/repository",
-			artifact: &sourcev1.Artifact{
-				Revision: "revision",
+			name:    "matching digest",
+			content: content,
+		},
+		{
+			name:    "truncated copy",
+			content: content[:len(content)-1],
+			wantErr: fmt.Sprintf("digest mismatch, expected '%s'", digest),
+		},
+		{
+			name:    "corrupted copy",
+			content: []byte("podinfo layer c0ntent"),
+			wantErr: fmt.Sprintf("digest mismatch, expected '%s'", digest),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			p := filepath.Join(t.TempDir(), "layer.tgz")
+			g.Expect(os.WriteFile(p, tt.content, 0o600)).To(Succeed())
+
+			err := verifyFileDigest(p, digest)
+			if tt.wantErr != "" {
+				g.Expect(err).To(HaveOccurred())
+				g.Expect(err.Error()).To(ContainSubstring(tt.wantErr))
+				return
+			}
+			g.Expect(err).ToNot(HaveOccurred())
+		})
+	}
+}
+
+func TestOCIRepository_reconcileValidation(t *testing.T) {
+	g := NewWithT(t)
+
+	tmpDir := t.TempDir()
+	server, err := setupRegistryServer(ctx, tmpDir, registryOptions{})
+	g.Expect(err).ToNot(HaveOccurred())
+
+	podinfoVersions, err := pushMultiplePodinfoImages(server.registryHost, "6.1.5")
+	g.Expect(err).ToNot(HaveOccurred())
+	img5 := podinfoVersions["6.1.5"]
+
+	tests := []struct {
+		name             string
+		reference        *sourcev1.OCIRepositoryRef
+		url              string
+		assertConditions []metav1.Condition
+	}{
+		{
+			name:      "valid configuration",
+			reference: &sourcev1.OCIRepositoryRef{Tag: "6.1.5"},
+			assertConditions: []metav1.Condition{
+				*conditions.TrueCondition(sourcev1.ValidatedCondition, meta.SucceededReason, "validated revision '6.1.5/%s'", img5.digest.Hex),
+			},
+		},
+		{
+			name:      "unknown tag",
+			reference: &sourcev1.OCIRepositoryRef{Tag: "6.1.0"},
+			assertConditions: []metav1.Condition{
+				*conditions.FalseCondition(sourcev1.ValidatedCondition, sourcev1.OCINotFoundReason, "validation failed: failed to determine artifact digest"),
+			},
+		},
+		{
+			name:      "unknown digest",
+			reference: &sourcev1.OCIRepositoryRef{Digest: "sha256:6c6d8c0d8f5c4c4e0a1e6e5a7b0e8f3b0b4d3f7f6a0a3e8c4c9b7a2d8e6f1c3b"},
+			assertConditions: []metav1.Condition{
+				*conditions.FalseCondition(sourcev1.ValidatedCondition, sourcev1.OCINotFoundReason, "validation failed: failed to fetch artifact manifest"),
+			},
+		},
+		{
+			name: "invalid URL",
+			url:  "oci://ghcr.io/test/test:v1",
+			assertConditions: []metav1.Condition{
+				*conditions.FalseCondition(sourcev1.ValidatedCondition, sourcev1.URLInvalidReason, "validation failed: URL validation failed for 'oci://ghcr.io/test/test:v1'"),
+			},
+		},
+	}
+
+	r := &OCIRepositoryReconciler{
+		Client:        fakeclient.NewClientBuilder().WithScheme(testEnv.GetScheme()).Build(),
+		EventRecorder: record.NewFakeRecorder(32),
+		Storage:       testStorage,
+		patchOptions:  getPatchOptions(ociRepositoryReadyCondition.Owned, "sc"),
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			artifact := &sourcev1.Artifact{
+				Revision: "6.1.4/" + img5.digest.Hex,
+				Path:     "ocirepository/default/validate/6.1.4.tar.gz",
+			}
+			obj := &sourcev1.OCIRepository{
+				ObjectMeta: metav1.ObjectMeta{
+					GenerateName: "validate-",
+					Annotations: map[string]string{
+						sourcev1.OCIValidateAnnotation: "true",
+					},
+				},
+				Spec: sourcev1.OCIRepositorySpec{
+					URL:       fmt.Sprintf("oci://%s/podinfo", server.registryHost),
+					Reference: tt.reference,
+					Timeout:   &metav1.Duration{Duration: timeout},
+				},
+				Status: sourcev1.OCIRepositoryStatus{
+					Artifact: artifact.DeepCopy(),
+				},
+			}
+			if tt.url != "" {
+				obj.Spec.URL = tt.url
+			}
+
+			r.reconcileValidation(ctx, obj)
+			g.Expect(obj.Status.Conditions).To(conditions.MatchConditions(tt.assertConditions))
+			// The current artifact is left untouched
+			g.Expect(obj.Status.Artifact).To(Equal(artifact))
+		})
+	}
+}
+
+func TestOCIRepository_Reconcile_validation(t *testing.T) {
+	g := NewWithT(t)
+
+	tmpDir := t.TempDir()
+	server, err := setupRegistryServer(ctx, tmpDir, registryOptions{})
+	g.Expect(err).ToNot(HaveOccurred())
+
+	podinfoVersions, err := pushMultiplePodinfoImages(server.registryHost, "6.1.5")
+	g.Expect(err).ToNot(HaveOccurred())
+	img5 := podinfoVersions["6.1.5"]
+
+	ns, err := testEnv.CreateNamespace(ctx, "ocirepository-validation-test")
+	g.Expect(err).ToNot(HaveOccurred())
+	defer func() { g.Expect(testEnv.Delete(ctx, ns)).To(Succeed()) }()
+
+	obj := &sourcev1.OCIRepository{
+		ObjectMeta: metav1.ObjectMeta{
+			GenerateName: "ocirepository-validation",
+			Namespace:    ns.Name,
+			Annotations: map[string]string{
+				sourcev1.OCIValidateAnnotation: "true",
+			},
+		},
+		Spec: sourcev1.OCIRepositorySpec{
+			URL:       img5.url,
+			Interval:  metav1.Duration{Duration: 60 * time.Minute},
+			Reference: &sourcev1.OCIRepositoryRef{Tag: img5.tag},
+		},
+	}
+	g.Expect(testEnv.Create(ctx, obj)).To(Succeed())
+	defer func() { g.Expect(testEnv.Delete(ctx, obj)).To(Succeed()) }()
+
+	// The validation does not prevent the artifact from being pulled
+	waitForSourceReadyWithArtifact(ctx, g, obj)
+	g.Expect(obj.Status.Artifact.Revision).To(Equal(fmt.Sprintf("%s/%s", img5.tag, img5.digest.Hex)))
+	g.Expect(conditions.IsTrue(obj, sourcev1.ValidatedCondition)).To(BeTrue())
+}
+
+func TestOCIRepository_reconcileSource_unsupportedVerificationProvider(t *testing.T) {
+	g := NewWithT(t)
+
+	r := &OCIRepositoryReconciler{
+		EventRecorder: record.NewFakeRecorder(32),
+		Storage:       testStorage,
+	}
+	obj := &sourcev1.OCIRepository{
+		ObjectMeta: metav1.ObjectMeta{
+			GenerateName: "verify-pgp-",
+		},
+		Spec: sourcev1.OCIRepositorySpec{
+			URL:     "oci://ghcr.io/stefanprodan/manifests/podinfo",
+			Timeout: &metav1.Duration{Duration: timeout},
+			Verify: &sourcev1.OCIRepositoryVerification{
+				Provider: "pgp",
+			},
+		},
+	}
+
+	got, err := r.reconcileSource(ctx, nil, obj, &sourcev1.Artifact{}, t.TempDir())
+	g.Expect(got).To(Equal(sreconcile.ResultEmpty))
+	var stalling *serror.Stalling
+	g.Expect(errors.As(err, &stalling)).To(BeTrue())
+	g.Expect(obj.Status.Conditions).To(conditions.MatchConditions([]metav1.Condition{
+		*conditions.FalseCondition(sourcev1.SourceVerifiedCondition, sourcev1.VerificationError,
+			"unsupported verification provider for an OCIRepository: pgp"),
+	}))
+}
+
+func TestOCIRepository_reconcileSource_noop(t *testing.T) {
+	g := NewWithT(t)
+
+	testRevision := "6.1.5/d1fc4595915714af2492dc4b66097de1e10f80150c8899907d8f8e61c6d6f67d"
+
+	tmpDir := t.TempDir()
+	server, err := setupRegistryServer(ctx, tmpDir, registryOptions{})
+	g.Expect(err).ToNot(HaveOccurred())
+
+	_, err = pushMultiplePodinfoImages(server.registryHost, "6.1.5")
+	g.Expect(err).ToNot(HaveOccurred())
+
+	// NOTE: The following verifies if it was a noop run by checking the
+	// artifact metadata which is unknown unless the image is pulled.
+
+	tests := []struct {
+		name       string
+		beforeFunc func(obj *sourcev1.OCIRepository)
+		afterFunc  func(g *WithT, obj *sourcev1.OCIRepository, artifact *sourcev1.Artifact)
+	}{
+		{
+			name: "full reconcile - no existing artifact",
+			afterFunc: func(g *WithT, obj *sourcev1.OCIRepository, artifact *sourcev1.Artifact) {
+				g.Expect(artifact.Metadata).ToNot(BeEmpty())
 			},
+		},
+		{
+			name: "noop - artifact revisions match",
+			beforeFunc: func(obj *sourcev1.OCIRepository) {
+				obj.Status.Artifact = &sourcev1.Artifact{
+					Revision: testRevision,
+				}
+			},
+			afterFunc: func(g *WithT, obj *sourcev1.OCIRepository, artifact *sourcev1.Artifact) {
+				g.Expect(artifact.Metadata).To(BeEmpty())
+			},
+		},
+		{
+			name: "noop - tag switched to the equivalent digest",
+			beforeFunc: func(obj *sourcev1.OCIRepository) {
+				obj.Spec.Reference = &sourcev1.OCIRepositoryRef{
+					Digest: "sha256:d1fc4595915714af2492dc4b66097de1e10f80150c8899907d8f8e61c6d6f67d",
+				}
+				obj.Status.Artifact = &sourcev1.Artifact{
+					Revision: testRevision,
+				}
+			},
+			afterFunc: func(g *WithT, obj *sourcev1.OCIRepository, artifact *sourcev1.Artifact) {
+				g.Expect(artifact.Metadata).To(BeEmpty())
+				g.Expect(artifact.Revision).To(Equal("d1fc4595915714af2492dc4b66097de1e10f80150c8899907d8f8e61c6d6f67d"))
+				g.Expect(obj.Status.Artifact.Revision).To(Equal(testRevision))
+			},
+		},
+		{
+			name: "noop - digest switched to the equivalent tag",
+			beforeFunc: func(obj *sourcev1.OCIRepository) {
+				obj.Status.Artifact = &sourcev1.Artifact{
+					Revision: "d1fc4595915714af2492dc4b66097de1e10f80150c8899907d8f8e61c6d6f67d",
+				}
+			},
+			afterFunc: func(g *WithT, obj *sourcev1.OCIRepository, artifact *sourcev1.Artifact) {
+				g.Expect(artifact.Metadata).To(BeEmpty())
+				g.Expect(artifact.Revision).To(Equal(testRevision))
+				g.Expect(obj.Status.Artifact.Revision).To(Equal("d1fc4595915714af2492dc4b66097de1e10f80150c8899907d8f8e61c6d6f67d"))
+			},
+		},
+		{
+			name: "full reconcile - same rev, unobserved ignore",
+			beforeFunc: func(obj *sourcev1.OCIRepository) {
+				obj.Status.ObservedIgnore = pointer.String("aaa")
+				obj.Status.Artifact = &sourcev1.Artifact{
+					Revision: testRevision,
+				}
+			},
+			afterFunc: func(g *WithT, obj *sourcev1.OCIRepository, artifact *sourcev1.Artifact) {
+				g.Expect(artifact.Metadata).ToNot(BeEmpty())
+			},
+		},
+		{
+			name: "noop - same rev, observed ignore",
 			beforeFunc: func(obj *sourcev1.OCIRepository) {
-				obj.Status.Artifact = &sourcev1.Artifact{Revision: "revision"}
 				obj.Spec.Ignore = pointer.String("aaa")
+				obj.Status.ObservedIgnore = pointer.String("aaa")
+				obj.Status.Artifact = &sourcev1.Artifact{
+					Revision: testRevision,
+				}
 			},
-			want: sreconcile.ResultSuccess,
-			assertPaths: []string{
-				"latest.tar.gz",
+			afterFunc: func(g *WithT, obj *sourcev1.OCIRepository, artifact *sourcev1.Artifact) {
+				g.Expect(artifact.Metadata).To(BeEmpty())
 			},
-			afterFunc: func(g *WithT, obj *sourcev1.OCIRepository) {
-				g.Expect(*obj.Status.ObservedIgnore).To(Equal("aaa"))
+		},
+		{
+			name: "full reconcile - same rev, unobserved layer selector",
+			beforeFunc: func(obj *sourcev1.OCIRepository) {
+				obj.Spec.LayerSelector = &sourcev1.OCILayerSelector{
+					MediaType: "application/vnd.docker.image.rootfs.diff.tar.gzip",
+					Operation: sourcev1.OCILayerCopy,
+				}
+				obj.Status.Artifact = &sourcev1.Artifact{
+					Revision: testRevision,
+				}
 			},
-			assertConditions: []metav1.Condition{
-				*conditions.TrueCondition(sourcev1.ArtifactInStorageCondition, meta.SucceededReason, "stored artifact for digest"),
+			afterFunc: func(g *WithT, obj *sourcev1.OCIRepository, artifact *sourcev1.Artifact) {
+				g.Expect(artifact.Metadata).ToNot(BeEmpty())
+			},
+		},
+		{
+			name: "noop - same rev, observed layer selector",
+			beforeFunc: func(obj *sourcev1.OCIRepository) {
+				obj.Spec.LayerSelector = &sourcev1.OCILayerSelector{
+					MediaType: "application/vnd.docker.image.rootfs.diff.tar.gzip",
+					Operation: sourcev1.OCILayerCopy,
+				}
+				obj.Status.ObservedLayerSelector = &sourcev1.OCILayerSelector{
+					MediaType: "application/vnd.docker.image.rootfs.diff.tar.gzip",
+					Operation: sourcev1.OCILayerCopy,
+				}
+				obj.Status.Artifact = &sourcev1.Artifact{
+					Revision: testRevision,
+				}
+			},
+			afterFunc: func(g *WithT, obj *sourcev1.OCIRepository, artifact *sourcev1.Artifact) {
+				g.Expect(artifact.Metadata).To(BeEmpty())
+			},
+		},
+		{
+			name: "full reconcile - same rev, observed layer selector changed",
+			beforeFunc: func(obj *sourcev1.OCIRepository) {
+				obj.Spec.LayerSelector = &sourcev1.OCILayerSelector{
+					MediaType: "application/vnd.docker.image.rootfs.diff.tar.gzip",
+					Operation: sourcev1.OCILayerExtract,
+				}
+				obj.Status.ObservedLayerSelector = &sourcev1.OCILayerSelector{
+					MediaType: "application/vnd.docker.image.rootfs.diff.tar.gzip",
+					Operation: sourcev1.OCILayerCopy,
+				}
+				obj.Status.Artifact = &sourcev1.Artifact{
+					Revision: testRevision,
+				}
+			},
+			afterFunc: func(g *WithT, obj *sourcev1.OCIRepository, artifact *sourcev1.Artifact) {
+				g.Expect(artifact.Metadata).ToNot(BeEmpty())
 			},
 		},
+	}
+
+	builder := fakeclient.NewClientBuilder().WithScheme(testEnv.GetScheme())
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			recorder := record.NewFakeRecorder(32)
+			r := &OCIRepositoryReconciler{
+				Client:        builder.Build(),
+				EventRecorder: recorder,
+				Storage:       testStorage,
+				patchOptions:  getPatchOptions(ociRepositoryReadyCondition.Owned, "sc"),
+			}
+
+			obj := &sourcev1.OCIRepository{
+				ObjectMeta: metav1.ObjectMeta{
+					GenerateName: "noop-",
+					Generation:   1,
+				},
+				Spec: sourcev1.OCIRepositorySpec{
+					URL:       fmt.Sprintf("oci://%s/podinfo", server.registryHost),
+					Reference: &sourcev1.OCIRepositoryRef{Tag: "6.1.5"},
+					Interval:  metav1.Duration{Duration: interval},
+					Timeout:   &metav1.Duration{Duration: timeout},
+				},
+			}
+
+			if tt.beforeFunc != nil {
+				tt.beforeFunc(obj)
+			}
+
+			g.Expect(r.Client.Create(ctx, obj)).ToNot(HaveOccurred())
+			defer func() {
+				g.Expect(r.Client.Delete(ctx, obj)).ToNot(HaveOccurred())
+			}()
+
+			sp := patch.NewSerialPatcher(obj, r.Client)
+
+			artifact := &sourcev1.Artifact{}
+			tmpDir := t.TempDir()
+			got, err := r.reconcileSource(ctx, sp, obj, artifact, tmpDir)
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(got).To(Equal(sreconcile.ResultSuccess))
+
+			if tt.afterFunc != nil {
+				tt.afterFunc(g, obj, artifact)
+			}
+
+			// A noop reconcile does not observe a new revision
+			if obj.Status.Artifact != nil && artifact.Metadata == nil {
+				g.Expect(conditions.Has(obj, sourcev1.ArtifactOutdatedCondition)).To(BeFalse())
+				close(recorder.Events)
+				for e := range recorder.Events {
+					g.Expect(e).ToNot(ContainSubstring("new revision"))
+				}
+			}
+		})
+	}
+}
+
+func TestOCIRepository_reconcileSource_mirror(t *testing.T) {
+	g := NewWithT(t)
+
+	upstream, err := setupRegistryServer(ctx, t.TempDir(), registryOptions{})
+	g.Expect(err).ToNot(HaveOccurred())
+	podinfoVersions, err := pushMultiplePodinfoImages(upstream.registryHost, "6.1.5")
+	g.Expect(err).ToNot(HaveOccurred())
+
+	tests := []struct {
+		name         string
+		mirrorImages bool
+		staleMirror  bool
+		wantEvent    string
+	}{
+		{
+			name:         "pulls from the mirror",
+			mirrorImages: true,
+			wantEvent:    "from mirror",
+		},
+		{
+			name:      "falls back to the upstream registry",
+			wantEvent: "from upstream registry",
+		},
+		{
+			name:        "resolves the tag upstream when the mirror is outdated",
+			staleMirror: true,
+			wantEvent:   "from upstream registry",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			mirror, err := setupRegistryServer(ctx, t.TempDir(), registryOptions{})
+			g.Expect(err).ToNot(HaveOccurred())
+			wantEndpoint := upstream.registryHost
+			if tt.mirrorImages {
+				_, err = pushMultiplePodinfoImages(mirror.registryHost, "6.1.5")
+				g.Expect(err).ToNot(HaveOccurred())
+				wantEndpoint = mirror.registryHost
+			}
+			if tt.staleMirror {
+				// Serve different content under the same tag from the mirror.
+				_, err = pushMultiplePodinfoImages(mirror.registryHost, "6.1.6")
+				g.Expect(err).ToNot(HaveOccurred())
+				g.Expect(crane.Tag(fmt.Sprintf("%s/podinfo:6.1.6", mirror.registryHost), "6.1.5")).To(Succeed())
+			}
+
+			mirrors, err := soci.NewMirrors(map[string]string{upstream.registryHost: mirror.registryHost})
+			g.Expect(err).ToNot(HaveOccurred())
+
+			recorder := record.NewFakeRecorder(32)
+			r := &OCIRepositoryReconciler{
+				Client:        fakeclient.NewClientBuilder().WithScheme(testEnv.GetScheme()).Build(),
+				EventRecorder: recorder,
+				Storage:       testStorage,
+				Mirrors:       mirrors,
+				patchOptions:  getPatchOptions(ociRepositoryReadyCondition.Owned, "sc"),
+			}
+
+			obj := &sourcev1.OCIRepository{
+				ObjectMeta: metav1.ObjectMeta{
+					GenerateName: "mirror-",
+					Generation:   1,
+				},
+				Spec: sourcev1.OCIRepositorySpec{
+					URL:       fmt.Sprintf("oci://%s/podinfo", upstream.registryHost),
+					Reference: &sourcev1.OCIRepositoryRef{Tag: "6.1.5"},
+					Interval:  metav1.Duration{Duration: interval},
+					Timeout:   &metav1.Duration{Duration: timeout},
+				},
+			}
+			g.Expect(r.Client.Create(ctx, obj)).ToNot(HaveOccurred())
+			defer func() {
+				g.Expect(r.Client.Delete(ctx, obj)).ToNot(HaveOccurred())
+			}()
+
+			sp := patch.NewSerialPatcher(obj, r.Client)
+
+			artifact := &sourcev1.Artifact{}
+			got, err := r.reconcileSource(ctx, sp, obj, artifact, t.TempDir())
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(got).To(Equal(sreconcile.ResultSuccess))
+			g.Expect(obj.Status.ObservedEndpoint).To(Equal(wantEndpoint))
+			g.Expect(artifact.Revision).To(Equal(fmt.Sprintf("6.1.5/%s", podinfoVersions["6.1.5"].digest.Hex)))
+
+			var events []string
+			for len(recorder.Events) > 0 {
+				events = append(events, <-recorder.Events)
+			}
+			g.Expect(events).To(ContainElement(ContainSubstring(fmt.Sprintf("%s '%s'", tt.wantEvent, wantEndpoint))))
+		})
+	}
+}
+
+func TestOCIRepository_remoteOptions_userAgent(t *testing.T) {
+	tests := []struct {
+		name      string
+		userAgent string
+		want      string
+	}{
+		{
+			name: "default user agent",
+			want: oci.UserAgent,
+		},
+		{
+			name:      "overridden user agent",
+			userAgent: "example-waf-allowed/1.0",
+			want:      "example-waf-allowed/1.0",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			var userAgents []string
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				userAgents = append(userAgents, req.UserAgent())
+				w.WriteHeader(http.StatusNotFound)
+			}))
+			t.Cleanup(srv.Close)
+
+			o := remoteOptions{craneOpts: craneOptions(ctx, true)}.withUserAgent(tt.userAgent)
+			_, err := crane.Digest(strings.TrimPrefix(srv.URL, "http://")+"/podinfo:6.1.6", o.craneOpts...)
+			g.Expect(err).To(HaveOccurred())
+
+			g.Expect(userAgents).ToNot(BeEmpty())
+			for _, ua := range userAgents {
+				g.Expect(ua).To(HavePrefix(tt.want))
+			}
+		})
+	}
+}
+
+func TestOCIRepository_reconcileSource_rateLimited(t *testing.T) {
+	tests := []struct {
+		name             string
+		retryAfter       string
+		wantRequeueAfter time.Duration
+	}{
+		{
+			name:             "requeues after the requested delay",
+			retryAfter:       "30",
+			wantRequeueAfter: 30 * time.Second,
+		},
+		{
+			name:       "fails without a requested delay",
+			retryAfter: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				if tt.retryAfter != "" {
+					w.Header().Set("Retry-After", tt.retryAfter)
+				}
+				w.WriteHeader(http.StatusTooManyRequests)
+			}))
+			t.Cleanup(srv.Close)
+
+			recorder := record.NewFakeRecorder(32)
+			r := &OCIRepositoryReconciler{
+				Client:        fakeclient.NewClientBuilder().WithScheme(testEnv.GetScheme()).Build(),
+				EventRecorder: recorder,
+				Storage:       testStorage,
+				patchOptions:  getPatchOptions(ociRepositoryReadyCondition.Owned, "sc"),
+			}
+
+			obj := &sourcev1.OCIRepository{
+				ObjectMeta: metav1.ObjectMeta{
+					GenerateName: "rate-limited-",
+					Generation:   1,
+				},
+				Spec: sourcev1.OCIRepositorySpec{
+					URL:       fmt.Sprintf("oci://%s/podinfo", strings.TrimPrefix(srv.URL, "http://")),
+					Reference: &sourcev1.OCIRepositoryRef{Tag: "6.1.5"},
+					Insecure:  true,
+					Interval:  metav1.Duration{Duration: interval},
+					Timeout:   &metav1.Duration{Duration: timeout},
+				},
+			}
+			g.Expect(r.Client.Create(ctx, obj)).ToNot(HaveOccurred())
+			defer func() {
+				g.Expect(r.Client.Delete(ctx, obj)).ToNot(HaveOccurred())
+			}()
+
+			sp := patch.NewSerialPatcher(obj, r.Client)
+
+			got, err := r.reconcileSource(ctx, sp, obj, &sourcev1.Artifact{}, t.TempDir())
+			g.Expect(err).To(HaveOccurred())
+			g.Expect(got).To(Equal(sreconcile.ResultEmpty))
+			g.Expect(conditions.IsTrue(obj, sourcev1.FetchFailedCondition)).To(BeTrue())
+			g.Expect(conditions.GetReason(obj, sourcev1.FetchFailedCondition)).To(Equal(sourcev1.OCIPullFailedReason))
+
+			var waitErr *serror.Waiting
+			if tt.wantRequeueAfter > 0 {
+				g.Expect(errors.As(err, &waitErr)).To(BeTrue())
+				g.Expect(waitErr.RequeueAfter).To(Equal(tt.wantRequeueAfter))
+				g.Expect(err.Error()).To(ContainSubstring("registry rate limit exceeded"))
+				g.Expect(recorder.Events).To(Receive(HavePrefix(corev1.EventTypeWarning + " " + registryRateLimitedReason)))
+			} else {
+				g.Expect(errors.As(err, &waitErr)).To(BeFalse())
+			}
+		})
+	}
+}
+
+func TestOCIRepository_reconcileSource_truncatedLayer(t *testing.T) {
+	g := NewWithT(t)
+
+	tmpDir := t.TempDir()
+	server, err := setupRegistryServer(ctx, tmpDir, registryOptions{})
+	g.Expect(err).ToNot(HaveOccurred())
+
+	files := make(map[string]string, 100)
+	for i := 0; i < 100; i++ {
+		files[fmt.Sprintf("file-%d.yaml", i)] = fmt.Sprintf("index: %d", i)
+	}
+	b, err := createTarGzLayer(files)
+	g.Expect(err).ToNot(HaveOccurred())
+
+	// The layer is pushed with the digest of its truncated content, so it is
+	// pulled without any error
+	img, err := mutate.AppendLayers(empty.Image,
+		static.NewLayer(b[:len(b)/2], gcrtypes.MediaType("application/vnd.cncf.flux.content.v1.tar+gzip")))
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(crane.Push(img, fmt.Sprintf("%s/podinfo:truncated", server.registryHost))).To(Succeed())
+
+	r := &OCIRepositoryReconciler{
+		Client:        fakeclient.NewClientBuilder().WithScheme(testEnv.GetScheme()).Build(),
+		EventRecorder: record.NewFakeRecorder(32),
+		Storage:       testStorage,
+		patchOptions:  getPatchOptions(ociRepositoryReadyCondition.Owned, "sc"),
+	}
+
+	obj := &sourcev1.OCIRepository{
+		ObjectMeta: metav1.ObjectMeta{
+			GenerateName: "truncated-layer-",
+			Generation:   1,
+		},
+		Spec: sourcev1.OCIRepositorySpec{
+			URL:       fmt.Sprintf("oci://%s/podinfo", server.registryHost),
+			Reference: &sourcev1.OCIRepositoryRef{Tag: "truncated"},
+			Interval:  metav1.Duration{Duration: interval},
+			Timeout:   &metav1.Duration{Duration: timeout},
+		},
+	}
+	g.Expect(r.Client.Create(ctx, obj)).ToNot(HaveOccurred())
+	defer func() {
+		g.Expect(r.Client.Delete(ctx, obj)).ToNot(HaveOccurred())
+	}()
+
+	sp := patch.NewSerialPatcher(obj, r.Client)
+
+	dir := t.TempDir()
+	got, err := r.reconcileSource(ctx, sp, obj, &sourcev1.Artifact{}, dir)
+	g.Expect(err).To(HaveOccurred())
+	g.Expect(err.Error()).To(ContainSubstring("truncated tarball"))
+	g.Expect(got).To(Equal(sreconcile.ResultEmpty))
+	g.Expect(conditions.IsTrue(obj, sourcev1.FetchFailedCondition)).To(BeTrue())
+	g.Expect(conditions.GetReason(obj, sourcev1.FetchFailedCondition)).To(Equal(sourcev1.ArchiveTruncatedReason))
+
+	// The partially extracted content is discarded, and no artifact is stored
+	entries, err := os.ReadDir(dir)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(entries).To(BeEmpty())
+	g.Expect(obj.Status.Artifact).To(BeNil())
+}
+
+func TestOCIRepository_reconcileSource_configMediaTypeMismatch(t *testing.T) {
+	g := NewWithT(t)
+
+	tmpDir := t.TempDir()
+	server, err := setupRegistryServer(ctx, tmpDir, registryOptions{})
+	g.Expect(err).ToNot(HaveOccurred())
+
+	b, err := createTarGzLayer(map[string]string{"deployment.yaml": "kind: Deployment"})
+	g.Expect(err).ToNot(HaveOccurred())
+	img, err := mutate.AppendLayers(empty.Image,
+		static.NewLayer(b, gcrtypes.MediaType("application/vnd.cncf.flux.content.v1.tar+gzip")))
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(crane.Push(img, fmt.Sprintf("%s/podinfo:docker-config", server.registryHost))).To(Succeed())
+
+	r := &OCIRepositoryReconciler{
+		Client:        fakeclient.NewClientBuilder().WithScheme(testEnv.GetScheme()).Build(),
+		EventRecorder: record.NewFakeRecorder(32),
+		Storage:       testStorage,
+		patchOptions:  getPatchOptions(ociRepositoryReadyCondition.Owned, "sc"),
+	}
+
+	obj := &sourcev1.OCIRepository{
+		ObjectMeta: metav1.ObjectMeta{
+			GenerateName: "config-media-type-",
+			Generation:   1,
+		},
+		Spec: sourcev1.OCIRepositorySpec{
+			URL:       fmt.Sprintf("oci://%s/podinfo", server.registryHost),
+			Reference: &sourcev1.OCIRepositoryRef{Tag: "docker-config"},
+			LayerSelector: &sourcev1.OCILayerSelector{
+				ConfigMediaType: "application/vnd.cncf.flux.config.v1+json",
+			},
+			Interval: metav1.Duration{Duration: interval},
+			Timeout:  &metav1.Duration{Duration: timeout},
+		},
+	}
+	g.Expect(r.Client.Create(ctx, obj)).ToNot(HaveOccurred())
+	defer func() {
+		g.Expect(r.Client.Delete(ctx, obj)).ToNot(HaveOccurred())
+	}()
+
+	sp := patch.NewSerialPatcher(obj, r.Client)
+
+	got, err := r.reconcileSource(ctx, sp, obj, &sourcev1.Artifact{}, t.TempDir())
+	g.Expect(got).To(Equal(sreconcile.ResultEmpty))
+	var stalling *serror.Stalling
+	g.Expect(errors.As(err, &stalling)).To(BeTrue())
+	g.Expect(err.Error()).To(ContainSubstring("does not match the expected media type 'application/vnd.cncf.flux.config.v1+json'"))
+	g.Expect(conditions.IsTrue(obj, sourcev1.FetchFailedCondition)).To(BeTrue())
+	g.Expect(conditions.GetReason(obj, sourcev1.FetchFailedCondition)).To(Equal(sourcev1.OCIConfigMediaTypeMismatchReason))
+	g.Expect(obj.Status.Artifact).To(BeNil())
+}
+
+func TestOCIRepository_reconcileSource_emptyTarballs(t *testing.T) {
+	const mediaType = "application/vnd.cncf.flux.content.v1.tar+gzip"
+	g := NewWithT(t)
+
+	tmpDir := t.TempDir()
+	server, err := setupRegistryServer(ctx, tmpDir, registryOptions{})
+	g.Expect(err).ToNot(HaveOccurred())
+
+	// An empty gzip compressed tarball is larger than 0 bytes
+	b, err := createTarGzLayer(map[string]string{})
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(b).ToNot(BeEmpty())
+	img, err := mutate.AppendLayers(empty.Image,
+		static.NewLayer(b, gcrtypes.MediaType(mediaType)),
+		static.NewLayer(b, gcrtypes.MediaType(mediaType)))
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(crane.Push(img, fmt.Sprintf("%s/podinfo:empty", server.registryHost))).To(Succeed())
+
+	r := &OCIRepositoryReconciler{
+		Client:        fakeclient.NewClientBuilder().WithScheme(testEnv.GetScheme()).Build(),
+		EventRecorder: record.NewFakeRecorder(32),
+		Storage:       testStorage,
+		patchOptions:  getPatchOptions(ociRepositoryReadyCondition.Owned, "sc"),
+	}
+
+	obj := &sourcev1.OCIRepository{
+		ObjectMeta: metav1.ObjectMeta{
+			GenerateName: "empty-tarballs-",
+			Generation:   1,
+		},
+		Spec: sourcev1.OCIRepositorySpec{
+			URL:       fmt.Sprintf("oci://%s/podinfo", server.registryHost),
+			Reference: &sourcev1.OCIRepositoryRef{Tag: "empty"},
+			Interval:  metav1.Duration{Duration: interval},
+			Timeout:   &metav1.Duration{Duration: timeout},
+		},
+	}
+	g.Expect(r.Client.Create(ctx, obj)).ToNot(HaveOccurred())
+	defer func() {
+		g.Expect(r.Client.Delete(ctx, obj)).ToNot(HaveOccurred())
+	}()
+
+	sp := patch.NewSerialPatcher(obj, r.Client)
+
+	got, err := r.reconcileSource(ctx, sp, obj, &sourcev1.Artifact{}, t.TempDir())
+	g.Expect(got).To(Equal(sreconcile.ResultEmpty))
+	g.Expect(err).To(HaveOccurred())
+	g.Expect(err.Error()).To(ContainSubstring("the 2 gzip compressed tarball(s) in artifact are empty"))
+	g.Expect(conditions.IsTrue(obj, sourcev1.FetchFailedCondition)).To(BeTrue())
+	g.Expect(conditions.GetReason(obj, sourcev1.FetchFailedCondition)).To(Equal(sourcev1.OCILayerOperationFailedReason))
+}
+
+func TestOCIRepository_reconcileSource_timeouts(t *testing.T) {
+	g := NewWithT(t)
+
+	// A registry which does not respond in time
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		select {
+		case <-req.Context().Done():
+		case <-time.After(5 * time.Second):
+		}
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	t.Cleanup(srv.Close)
+
+	r := &OCIRepositoryReconciler{
+		Client:        fakeclient.NewClientBuilder().WithScheme(testEnv.GetScheme()).Build(),
+		EventRecorder: record.NewFakeRecorder(32),
+		Storage:       testStorage,
+		patchOptions:  getPatchOptions(ociRepositoryReadyCondition.Owned, "sc"),
+	}
+
+	obj := &sourcev1.OCIRepository{
+		ObjectMeta: metav1.ObjectMeta{
+			GenerateName: "timeouts-",
+			Generation:   1,
+		},
+		Spec: sourcev1.OCIRepositorySpec{
+			URL:       fmt.Sprintf("oci://%s/podinfo", strings.TrimPrefix(srv.URL, "http://")),
+			Reference: &sourcev1.OCIRepositoryRef{Tag: "6.1.5"},
+			Insecure:  true,
+			Interval:  metav1.Duration{Duration: interval},
+			Timeout:   &metav1.Duration{Duration: time.Minute},
+			Timeouts: &sourcev1.OCIRepositoryTimeouts{
+				List: &metav1.Duration{Duration: 100 * time.Millisecond},
+			},
+		},
+	}
+	g.Expect(r.Client.Create(ctx, obj)).ToNot(HaveOccurred())
+	defer func() {
+		g.Expect(r.Client.Delete(ctx, obj)).ToNot(HaveOccurred())
+	}()
+
+	sp := patch.NewSerialPatcher(obj, r.Client)
+
+	start := time.Now()
+	got, err := r.reconcileSource(ctx, sp, obj, &sourcev1.Artifact{}, t.TempDir())
+	g.Expect(err).To(HaveOccurred())
+	g.Expect(err.Error()).To(ContainSubstring("context deadline exceeded"))
+	g.Expect(got).To(Equal(sreconcile.ResultEmpty))
+	g.Expect(time.Since(start)).To(BeNumerically("<", 5*time.Second))
+	g.Expect(conditions.GetReason(obj, sourcev1.FetchFailedCondition)).To(Equal(sourcev1.OCIPullFailedReason))
+}
+
+func TestOCIRepository_timeouts(t *testing.T) {
+	g := NewWithT(t)
+
+	obj := &sourcev1.OCIRepository{
+		Spec: sourcev1.OCIRepositorySpec{
+			Timeout: &metav1.Duration{Duration: time.Minute},
+		},
+	}
+	g.Expect(obj.GetListTimeout()).To(Equal(time.Minute))
+	g.Expect(obj.GetPullTimeout()).To(Equal(time.Minute))
+	g.Expect(obj.GetVerifyTimeout()).To(Equal(time.Minute))
+
+	obj.Spec.Timeouts = &sourcev1.OCIRepositoryTimeouts{
+		Pull:   &metav1.Duration{Duration: 5 * time.Minute},
+		Verify: &metav1.Duration{Duration: 2 * time.Minute},
+	}
+	g.Expect(obj.GetListTimeout()).To(Equal(time.Minute))
+	g.Expect(obj.GetPullTimeout()).To(Equal(5 * time.Minute))
+	g.Expect(obj.GetVerifyTimeout()).To(Equal(2 * time.Minute))
+}
+
+func TestOCIRepository_reconcileArtifact(t *testing.T) {
+	tests := []struct {
+		name             string
+		targetPath       string
+		artifact         *sourcev1.Artifact
+		beforeFunc       func(obj *sourcev1.OCIRepository)
+		want             sreconcile.Result
+		wantErr          bool
+		assertArtifact   *sourcev1.Artifact
+		assertPaths      []string
+		assertConditions []metav1.Condition
+		afterFunc        func(g *WithT, obj *sourcev1.OCIRepository)
+	}{
+		{
+			name:       "Archiving Artifact creates correct files and condition",
+			targetPath: "testdata/oci/repository",
+			artifact: &sourcev1.Artifact{
+				Revision: "revision",
+				Digest:   "sha256:d1fc4595915714af2492dc4b66097de1e10f80150c8899907d8f8e61c6d6f67d",
+			},
+			beforeFunc: func(obj *sourcev1.OCIRepository) {
+				conditions.MarkTrue(obj, sourcev1.ArtifactOutdatedCondition, "NewRevision", "new revision")
+			},
+			want: sreconcile.ResultSuccess,
+			assertPaths: []string{
+				"latest.tar.gz",
+			},
+			afterFunc: func(g *WithT, obj *sourcev1.OCIRepository) {
+				g.Expect(obj.Status.Artifact.Checksum).To(Equal("de37cb640bfe6c789f2b131416d259747d5757f7fe5e1d9d48f32d8c30af5934"))
+				g.Expect(obj.Status.Artifact.Digest).To(Equal("sha256:d1fc4595915714af2492dc4b66097de1e10f80150c8899907d8f8e61c6d6f67d"))
+			},
+			assertConditions: []metav1.Condition{
+				*conditions.TrueCondition(sourcev1.ArtifactInStorageCondition, meta.SucceededReason, "stored artifact for digest"),
+			},
+		},
+		{
+			name:       "Artifact with source ignore",
+			targetPath: "testdata/oci/repository",
+			artifact:   &sourcev1.Artifact{Revision: "revision"},
+			beforeFunc: func(obj *sourcev1.OCIRepository) {
+				obj.Spec.Ignore = pointer.String("foo.txt")
+			},
+			want: sreconcile.ResultSuccess,
+			assertPaths: []string{
+				"latest.tar.gz",
+			},
+			afterFunc: func(g *WithT, obj *sourcev1.OCIRepository) {
+				g.Expect(obj.Status.Artifact.Checksum).To(Equal("05aada03e3e3e96f5f85a8f31548d833974ce862be14942fb3313eef2df861ec"))
+			},
+			assertConditions: []metav1.Condition{
+				*conditions.TrueCondition(sourcev1.ArtifactInStorageCondition, meta.SucceededReason, "stored artifact for digest"),
+			},
+		},
+		{
+			name:       "Artifact with created modification time",
+			targetPath: "testdata/oci/repository",
+			artifact: &sourcev1.Artifact{
+				Revision: "revision",
+				Metadata: map[string]string{
+					ociCreatedAnnotation: "2022-10-01T12:00:00Z",
+				},
+			},
+			beforeFunc: func(obj *sourcev1.OCIRepository) {
+				obj.Spec.ArtifactModTime = sourcev1.OCIArtifactModTimeCreated
+			},
+			want: sreconcile.ResultSuccess,
+			assertPaths: []string{
+				"latest.tar.gz",
+			},
+			afterFunc: func(g *WithT, obj *sourcev1.OCIRepository) {
+				g.Expect(obj.GetArtifact().Metadata).To(HaveKeyWithValue(sourcev1.OCIModTimeMetadataKey, "2022-10-01T12:00:00Z"))
+				g.Expect(obj.Status.ObservedArtifactModTime).To(Equal(sourcev1.OCIArtifactModTimeCreated))
+				fi, err := os.Stat(testStorage.LocalPath(*obj.GetArtifact()))
+				g.Expect(err).ToNot(HaveOccurred())
+				g.Expect(fi.ModTime().UTC()).To(Equal(time.Date(2022, 10, 1, 12, 0, 0, 0, time.UTC)))
+			},
+			assertConditions: []metav1.Condition{
+				*conditions.TrueCondition(sourcev1.ArtifactInStorageCondition, meta.SucceededReason, "stored artifact for digest"),
+			},
+		},
+		{
+			name: "No status changes if artifact is already present",
+			artifact: &sourcev1.Artifact{
+				Revision: "revision",
+			},
+			targetPath: "testdata/oci/repository",
+			want:       sreconcile.ResultSuccess,
+			beforeFunc: func(obj *sourcev1.OCIRepository) {
+				obj.Status.Artifact = &sourcev1.Artifact{
+					Revision: "revision",
+				}
+			},
+			assertArtifact: &sourcev1.Artifact{
+				Revision: "revision",
+			},
+			assertConditions: []metav1.Condition{
+				*conditions.TrueCondition(sourcev1.ArtifactInStorageCondition, meta.SucceededReason, "stored artifact for digest"),
+			},
+		},
+		{
+			name:       "Records digest of artifact already present",
+			targetPath: "testdata/oci/repository",
+			artifact: &sourcev1.Artifact{
+				Revision: "revision",
+				Digest:   "sha256:d1fc4595915714af2492dc4b66097de1e10f80150c8899907d8f8e61c6d6f67d",
+			},
+			beforeFunc: func(obj *sourcev1.OCIRepository) {
+				obj.Status.Artifact = &sourcev1.Artifact{Revision: "revision"}
+			},
+			want: sreconcile.ResultSuccess,
+			assertArtifact: &sourcev1.Artifact{
+				Revision: "revision",
+			},
+			afterFunc: func(g *WithT, obj *sourcev1.OCIRepository) {
+				g.Expect(obj.Status.Artifact.Digest).To(Equal("sha256:d1fc4595915714af2492dc4b66097de1e10f80150c8899907d8f8e61c6d6f67d"))
+			},
+			assertConditions: []metav1.Condition{
+				*conditions.TrueCondition(sourcev1.ArtifactInStorageCondition, meta.SucceededReason, "stored artifact for digest"),
+			},
+		},
+		{
+			name:       "Keeps revision of equivalent artifact already present",
+			targetPath: "testdata/oci/repository",
+			artifact: &sourcev1.Artifact{
+				Revision: "d1fc4595915714af2492dc4b66097de1e10f80150c8899907d8f8e61c6d6f67d",
+			},
+			beforeFunc: func(obj *sourcev1.OCIRepository) {
+				obj.Status.Artifact = &sourcev1.Artifact{
+					Revision: "6.1.5/d1fc4595915714af2492dc4b66097de1e10f80150c8899907d8f8e61c6d6f67d",
+				}
+			},
+			want: sreconcile.ResultSuccess,
+			afterFunc: func(g *WithT, obj *sourcev1.OCIRepository) {
+				g.Expect(obj.Status.Artifact.Revision).To(Equal("6.1.5/d1fc4595915714af2492dc4b66097de1e10f80150c8899907d8f8e61c6d6f67d"))
+			},
+			assertConditions: []metav1.Condition{
+				*conditions.TrueCondition(sourcev1.ArtifactInStorageCondition, meta.SucceededReason, "stored artifact for digest"),
+			},
+		},
+		{
+			name:       "Artifact already present, unobserved ignore, rebuild artifact",
+			targetPath: "testdata/oci/repository",
+			artifact: &sourcev1.Artifact{
+				Revision: "revision",
+			},
+			beforeFunc: func(obj *sourcev1.OCIRepository) {
+				obj.Status.Artifact = &sourcev1.Artifact{Revision: "revision"}
+				obj.Spec.Ignore = pointer.String("aaa")
+			},
+			want: sreconcile.ResultSuccess,
+			assertPaths: []string{
+				"latest.tar.gz",
+			},
+			afterFunc: func(g *WithT, obj *sourcev1.OCIRepository) {
+				g.Expect(*obj.Status.ObservedIgnore).To(Equal("aaa"))
+			},
+			assertConditions: []metav1.Condition{
+				*conditions.TrueCondition(sourcev1.ArtifactInStorageCondition, meta.SucceededReason, "stored artifact for digest"),
+			},
+		},
+		{
+			name:       "Artifact already present, unobserved include, rebuild artifact",
+			targetPath: "testdata/oci/repository",
+			artifact: &sourcev1.Artifact{
+				Revision: "revision",
+			},
+			beforeFunc: func(obj *sourcev1.OCIRepository) {
+				obj.Status.Artifact = &sourcev1.Artifact{Revision: "revision"}
+				obj.Spec.Include = pointer.String("**/*.yaml")
+			},
+			want: sreconcile.ResultSuccess,
+			assertPaths: []string{
+				"latest.tar.gz",
+			},
+			afterFunc: func(g *WithT, obj *sourcev1.OCIRepository) {
+				g.Expect(*obj.Status.ObservedInclude).To(Equal("**/*.yaml"))
+			},
+			assertConditions: []metav1.Condition{
+				*conditions.TrueCondition(sourcev1.ArtifactInStorageCondition, meta.SucceededReason, "stored artifact for digest"),
+			},
+		},
+		{
+			name:       "Artifact already present, unobserved layer selector, rebuild artifact",
+			targetPath: "testdata/oci/repository",
+			artifact: &sourcev1.Artifact{
+				Revision: "revision",
+			},
+			beforeFunc: func(obj *sourcev1.OCIRepository) {
+				obj.Spec.LayerSelector = &sourcev1.OCILayerSelector{MediaType: "foo"}
+				obj.Status.Artifact = &sourcev1.Artifact{Revision: "revision"}
+			},
+			want: sreconcile.ResultSuccess,
+			assertPaths: []string{
+				"latest.tar.gz",
+			},
+			afterFunc: func(g *WithT, obj *sourcev1.OCIRepository) {
+				g.Expect(obj.Status.ObservedLayerSelector.MediaType).To(Equal("foo"))
+			},
+			assertConditions: []metav1.Condition{
+				*conditions.TrueCondition(sourcev1.ArtifactInStorageCondition, meta.SucceededReason, "stored artifact for digest"),
+			},
+		},
+		{
+			name:       "Artifact already present, observed layer selector changed, rebuild artifact",
+			targetPath: "testdata/oci/repository",
+			artifact: &sourcev1.Artifact{
+				Revision: "revision",
+				Digest:   "sha256:d1fc4595915714af2492dc4b66097de1e10f80150c8899907d8f8e61c6d6f67d",
+				Path:     "foo.txt",
+			},
+			beforeFunc: func(obj *sourcev1.OCIRepository) {
+				obj.Spec.LayerSelector = &sourcev1.OCILayerSelector{
+					MediaType: "foo",
+					Operation: sourcev1.OCILayerCopy,
+				}
+				obj.Status.Artifact = &sourcev1.Artifact{Revision: "revision"}
+			},
+			want: sreconcile.ResultSuccess,
+			assertPaths: []string{
+				"latest.tar.gz",
+			},
+			afterFunc: func(g *WithT, obj *sourcev1.OCIRepository) {
+				g.Expect(obj.Status.ObservedLayerSelector.MediaType).To(Equal("foo"))
+				g.Expect(obj.Status.ObservedLayerSelector.Operation).To(Equal(sourcev1.OCILayerCopy))
+				g.Expect(obj.Status.Artifact.Digest).To(Equal("sha256:d1fc4595915714af2492dc4b66097de1e10f80150c8899907d8f8e61c6d6f67d"))
+			},
+			assertConditions: []metav1.Condition{
+				*conditions.TrueCondition(sourcev1.ArtifactInStorageCondition, meta.SucceededReason, "stored artifact for digest"),
+			},
+		},
+		{
+			name:       "Uncompressed artifact",
+			targetPath: "testdata/oci/repository",
+			artifact: &sourcev1.Artifact{
+				Revision: "revision",
+			},
+			beforeFunc: func(obj *sourcev1.OCIRepository) {
+				obj.Spec.ArtifactCompression = sourcev1.OCIArtifactCompressionNone
+			},
+			want: sreconcile.ResultSuccess,
+			assertPaths: []string{
+				"latest.tar",
+			},
+			afterFunc: func(g *WithT, obj *sourcev1.OCIRepository) {
+				g.Expect(obj.Status.Artifact.Path).To(HaveSuffix("/revision.tar"))
+				g.Expect(obj.Status.ObservedArtifactCompression).To(Equal(sourcev1.OCIArtifactCompressionNone))
+			},
+			assertConditions: []metav1.Condition{
+				*conditions.TrueCondition(sourcev1.ArtifactInStorageCondition, meta.SucceededReason, "stored artifact for digest"),
+			},
+		},
+		{
+			name:       "Copied layer artifact named after the layer media type",
+			targetPath: "testdata/oci/repository",
+			artifact: &sourcev1.Artifact{
+				Revision: "revision",
+				Path:     "foo.txt",
+				Metadata: map[string]string{
+					sourcev1.OCILayerMediaTypeMetadataKey: "application/json",
+				},
+			},
+			beforeFunc: func(obj *sourcev1.OCIRepository) {
+				obj.Spec.LayerSelector = &sourcev1.OCILayerSelector{
+					MediaType: "application/json",
+					Operation: sourcev1.OCILayerCopy,
+				}
+			},
+			want: sreconcile.ResultSuccess,
+			assertPaths: []string{
+				"latest.json",
+			},
+			afterFunc: func(g *WithT, obj *sourcev1.OCIRepository) {
+				g.Expect(obj.Status.Artifact.Path).To(HaveSuffix("/revision.json"))
+				g.Expect(obj.Status.Artifact.Metadata).To(HaveKeyWithValue(sourcev1.OCILayerMediaTypeMetadataKey, "application/json"))
+			},
+			assertConditions: []metav1.Condition{
+				*conditions.TrueCondition(sourcev1.ArtifactInStorageCondition, meta.SucceededReason, "stored artifact for digest"),
+			},
+		},
+		{
+			name:       "Copied layer artifact named with the layer selector extension",
+			targetPath: "testdata/oci/repository",
+			artifact: &sourcev1.Artifact{
+				Revision: "revision",
+				Path:     "foo.txt",
+				Metadata: map[string]string{
+					sourcev1.OCILayerMediaTypeMetadataKey: "application/octet-stream",
+				},
+			},
+			beforeFunc: func(obj *sourcev1.OCIRepository) {
+				obj.Spec.LayerSelector = &sourcev1.OCILayerSelector{
+					Operation: sourcev1.OCILayerCopy,
+					Extension: "zip",
+				}
+			},
+			want: sreconcile.ResultSuccess,
+			assertPaths: []string{
+				"latest.zip",
+			},
+			afterFunc: func(g *WithT, obj *sourcev1.OCIRepository) {
+				g.Expect(obj.Status.Artifact.Path).To(HaveSuffix("/revision.zip"))
+			},
+			assertConditions: []metav1.Condition{
+				*conditions.TrueCondition(sourcev1.ArtifactInStorageCondition, meta.SucceededReason, "stored artifact for digest"),
+			},
+		},
+		{
+			name:       "Artifact already present, observed ignore and layer selector, up-to-date",
+			targetPath: "testdata/oci/repository",
+			artifact: &sourcev1.Artifact{
+				Revision: "revision",
+			},
+			beforeFunc: func(obj *sourcev1.OCIRepository) {
+				obj.Spec.Ignore = pointer.String("aaa")
+				obj.Spec.LayerSelector = &sourcev1.OCILayerSelector{MediaType: "foo"}
+				obj.Status.Artifact = &sourcev1.Artifact{Revision: "revision"}
+				obj.Status.ObservedIgnore = pointer.String("aaa")
+				obj.Status.ObservedLayerSelector = &sourcev1.OCILayerSelector{MediaType: "foo"}
+			},
+			want: sreconcile.ResultSuccess,
+			assertArtifact: &sourcev1.Artifact{
+				Revision: "revision",
+			},
+			assertConditions: []metav1.Condition{
+				*conditions.TrueCondition(sourcev1.ArtifactInStorageCondition, meta.SucceededReason, "stored artifact for digest"),
+			},
+		},
+		{
+			name:       "target path doesn't exist",
+			targetPath: "testdata/oci/non-existent",
+			want:       sreconcile.ResultEmpty,
+			wantErr:    true,
+			assertConditions: []metav1.Condition{
+				*conditions.TrueCondition(sourcev1.StorageOperationFailedCondition, sourcev1.StatOperationFailedReason, "failed to stat source path: "),
+			},
+		},
+		{
+			name:       "target path is a file",
+			targetPath: "testdata/oci/repository/foo.txt",
+			want:       sreconcile.ResultEmpty,
+			wantErr:    true,
+			assertConditions: []metav1.Condition{
+				*conditions.TrueCondition(sourcev1.StorageOperationFailedCondition, sourcev1.InvalidPathReason, "source path 'testdata/oci/repository/foo.txt' is not a directory"),
+			},
+		},
+	}
+
+	builder := fakeclient.NewClientBuilder().WithScheme(testEnv.GetScheme())
+
+	r := &OCIRepositoryReconciler{
+		Client:        builder.Build(),
+		EventRecorder: record.NewFakeRecorder(32),
+		Storage:       testStorage,
+		patchOptions:  getPatchOptions(ociRepositoryReadyCondition.Owned, "sc"),
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			resetChmod(tt.targetPath, 0o755, 0o644)
+
+			obj := &sourcev1.OCIRepository{
+				ObjectMeta: metav1.ObjectMeta{
+					GenerateName: "reconcile-artifact-",
+					Generation:   1,
+				},
+			}
+			if tt.beforeFunc != nil {
+				tt.beforeFunc(obj)
+			}
+
+			artifact := &sourcev1.Artifact{}
+			if tt.artifact != nil {
+				artifact = tt.artifact
+			}
+
+			g.Expect(r.Client.Create(ctx, obj)).ToNot(HaveOccurred())
+			defer func() {
+				g.Expect(r.Client.Delete(ctx, obj)).ToNot(HaveOccurred())
+			}()
+
+			sp := patch.NewSerialPatcher(obj, r.Client)
+
+			got, err := r.reconcileArtifact(ctx, sp, obj, artifact, tt.targetPath)
+			if tt.wantErr {
+				g.Expect(err).To(HaveOccurred())
+			} else {
+				g.Expect(err).ToNot(HaveOccurred())
+			}
+
+			g.Expect(got).To(Equal(tt.want))
+			g.Expect(obj.Status.Conditions).To(conditions.MatchConditions(tt.assertConditions))
+
+			if tt.assertArtifact != nil {
+				g.Expect(obj.Status.Artifact).To(MatchArtifact(tt.artifact))
+			}
+
+			if tt.afterFunc != nil {
+				tt.afterFunc(g, obj)
+			}
+
+			for _, path := range tt.assertPaths {
+				localPath := testStorage.LocalPath(*obj.GetArtifact())
+				path = filepath.Join(filepath.Dir(localPath), path)
+				_, err := os.Lstat(path)
+				g.Expect(err).ToNot(HaveOccurred())
+			}
+		})
+	}
+}
+
+func TestOCIRepository_reconcileArtifact_createdModTime(t *testing.T) {
+	g := NewWithT(t)
+
+	targetPath := "testdata/oci/repository"
+	resetChmod(targetPath, 0o755, 0o644)
+
+	r := &OCIRepositoryReconciler{
+		Client:        fakeclient.NewClientBuilder().WithScheme(testEnv.GetScheme()).Build(),
+		EventRecorder: record.NewFakeRecorder(32),
+		Storage:       testStorage,
+		patchOptions:  getPatchOptions(ociRepositoryReadyCondition.Owned, "sc"),
+	}
+
+	obj := &sourcev1.OCIRepository{
+		ObjectMeta: metav1.ObjectMeta{
+			GenerateName: "created-mod-time-",
+			Generation:   1,
+		},
+		Spec: sourcev1.OCIRepositorySpec{
+			ArtifactModTime: sourcev1.OCIArtifactModTimeCreated,
+		},
+	}
+	g.Expect(r.Client.Create(ctx, obj)).ToNot(HaveOccurred())
+	defer func() {
+		g.Expect(r.Client.Delete(ctx, obj)).ToNot(HaveOccurred())
+	}()
+
+	created := time.Date(2022, 10, 1, 12, 0, 0, 0, time.UTC)
+	var checksums []string
+	for i := 0; i < 2; i++ {
+		// Store the artifact again, as after the loss of the storage
+		obj.Status.Artifact = nil
+		metadata := &sourcev1.Artifact{
+			Revision: "revision",
+			Metadata: map[string]string{
+				ociCreatedAnnotation: created.Format(time.RFC3339),
+			},
+		}
+
+		sp := patch.NewSerialPatcher(obj, r.Client)
+		got, err := r.reconcileArtifact(ctx, sp, obj, metadata, targetPath)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(got).To(Equal(sreconcile.ResultSuccess))
+
+		localPath := testStorage.LocalPath(*obj.GetArtifact())
+		fi, err := os.Stat(localPath)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(fi.ModTime().UTC()).To(Equal(created))
+
+		// The files in the tarball have the same modification time
+		f, err := os.Open(localPath)
+		g.Expect(err).ToNot(HaveOccurred())
+		gr, err := gzip.NewReader(f)
+		g.Expect(err).ToNot(HaveOccurred())
+		tr := tar.NewReader(gr)
+		for {
+			hdr, err := tr.Next()
+			if err == io.EOF {
+				break
+			}
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(hdr.ModTime.UTC()).To(Equal(created))
+		}
+		f.Close()
+
+		checksums = append(checksums, obj.GetArtifact().Checksum)
+		g.Expect(os.Remove(localPath)).To(Succeed())
+	}
+	g.Expect(checksums[1]).To(Equal(checksums[0]))
+}
+
+func TestOCIRepository_artifactExtension(t *testing.T) {
+	tests := []struct {
+		name        string
+		selector    *sourcev1.OCILayerSelector
+		compression string
+		mediaType   string
+		want        string
+	}{
+		{
+			name:      "extract operation",
+			selector:  &sourcev1.OCILayerSelector{Extension: "zip"},
+			mediaType: "application/zip",
+			want:      "tar.gz",
+		},
+		{
+			name:        "extract operation without compression",
+			compression: sourcev1.OCIArtifactCompressionNone,
+			want:        "tar",
+		},
+		{
+			name:        "copy operation ignores compression",
+			selector:    &sourcev1.OCILayerSelector{Operation: sourcev1.OCILayerCopy},
+			compression: sourcev1.OCIArtifactCompressionNone,
+			mediaType:   "application/vnd.oci.image.layer.v1.tar+gzip",
+			want:        "tar.gz",
+		},
+		{
+			name:      "extension of the layer selector",
+			selector:  &sourcev1.OCILayerSelector{Operation: sourcev1.OCILayerCopy, Extension: "tar.xz"},
+			mediaType: "application/vnd.oci.image.layer.v1.tar+gzip",
+			want:      "tar.xz",
+		},
+		{
+			name:      "OCI gzip tarball",
+			selector:  &sourcev1.OCILayerSelector{Operation: sourcev1.OCILayerCopy},
+			mediaType: "application/vnd.oci.image.layer.v1.tar+gzip",
+			want:      "tar.gz",
+		},
+		{
+			name:      "Docker gzip tarball",
+			selector:  &sourcev1.OCILayerSelector{Operation: sourcev1.OCILayerCopy},
+			mediaType: "application/vnd.docker.image.rootfs.diff.tar.gzip",
+			want:      "tar.gz",
+		},
+		{
+			name:      "zstd tarball",
+			selector:  &sourcev1.OCILayerSelector{Operation: sourcev1.OCILayerCopy},
+			mediaType: "application/vnd.oci.image.layer.v1.tar+zstd",
+			want:      "tar.zst",
+		},
+		{
+			name:      "plain tarball",
+			selector:  &sourcev1.OCILayerSelector{Operation: sourcev1.OCILayerCopy},
+			mediaType: "application/vnd.oci.image.layer.v1.tar",
+			want:      "tar",
+		},
+		{
+			name:      "JSON suffix",
+			selector:  &sourcev1.OCILayerSelector{Operation: sourcev1.OCILayerCopy},
+			mediaType: "application/vnd.example.config.v1+json",
+			want:      "json",
+		},
+		{
+			name:      "zip with parameters",
+			selector:  &sourcev1.OCILayerSelector{Operation: sourcev1.OCILayerCopy},
+			mediaType: "Application/Zip; charset=binary",
+			want:      "zip",
+		},
+		{
+			name:      "gzip file",
+			selector:  &sourcev1.OCILayerSelector{Operation: sourcev1.OCILayerCopy},
+			mediaType: "application/gzip",
+			want:      "gz",
+		},
+		{
+			name:      "unknown media type",
+			selector:  &sourcev1.OCILayerSelector{Operation: sourcev1.OCILayerCopy},
+			mediaType: "application/octet-stream",
+			want:      "tar.gz",
+		},
+		{
+			name:     "no media type",
+			selector: &sourcev1.OCILayerSelector{Operation: sourcev1.OCILayerCopy},
+			want:     "tar.gz",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			obj := &sourcev1.OCIRepository{
+				Spec: sourcev1.OCIRepositorySpec{LayerSelector: tt.selector, ArtifactCompression: tt.compression},
+			}
+			var metadata map[string]string
+			if tt.mediaType != "" {
+				metadata = map[string]string{sourcev1.OCILayerMediaTypeMetadataKey: tt.mediaType}
+			}
+			g.Expect(artifactExtension(obj, metadata)).To(Equal(tt.want))
+		})
+	}
+}
+
+func TestOCIRepository_checkConfigMediaType(t *testing.T) {
+	const fluxConfigMediaType = "application/vnd.cncf.flux.config.v1+json"
+
+	tests := []struct {
+		name            string
+		selector        *sourcev1.OCILayerSelector
+		configMediaType gcrtypes.MediaType
+		wantErr         string
+	}{
+		{
+			name:            "no layer selector",
+			configMediaType: gcrtypes.DockerConfigJSON,
+		},
+		{
+			name:            "no config media type",
+			selector:        &sourcev1.OCILayerSelector{MediaType: "foo"},
+			configMediaType: gcrtypes.DockerConfigJSON,
+		},
+		{
+			name:            "matching config media type",
+			selector:        &sourcev1.OCILayerSelector{ConfigMediaType: fluxConfigMediaType},
+			configMediaType: fluxConfigMediaType,
+		},
+		{
+			name:            "config media type mismatch",
+			selector:        &sourcev1.OCILayerSelector{ConfigMediaType: fluxConfigMediaType},
+			configMediaType: gcrtypes.DockerConfigJSON,
+			wantErr:         "artifact config media type 'application/vnd.docker.container.image.v1+json' does not match the expected media type 'application/vnd.cncf.flux.config.v1+json'",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			obj := &sourcev1.OCIRepository{
+				Spec: sourcev1.OCIRepositorySpec{LayerSelector: tt.selector},
+			}
+			manifest := &gcrv1.Manifest{
+				Config: gcrv1.Descriptor{MediaType: tt.configMediaType},
+			}
+
+			err := checkConfigMediaType(obj, manifest)
+			if tt.wantErr != "" {
+				g.Expect(err).To(HaveOccurred())
+				g.Expect(err.Error()).To(Equal(tt.wantErr))
+				return
+			}
+			g.Expect(err).ToNot(HaveOccurred())
+		})
+	}
+}
+
+func TestOCIRepository_checkContainerImage(t *testing.T) {
+	const fluxConfigMediaType = "application/vnd.cncf.flux.config.v1+json"
+
+	tests := []struct {
+		name     string
+		selector *sourcev1.OCILayerSelector
+		manifest gcrv1.Manifest
+		wantErr  string
+	}{
+		{
+			name: "container images allowed",
+			manifest: gcrv1.Manifest{
+				MediaType: gcrtypes.OCIManifestSchema1,
+				Config:    gcrv1.Descriptor{MediaType: gcrtypes.OCIConfigJSON},
+			},
+		},
+		{
+			name:     "flux artifact",
+			selector: &sourcev1.OCILayerSelector{RejectContainerImages: true},
+			manifest: gcrv1.Manifest{
+				MediaType: gcrtypes.OCIManifestSchema1,
+				Config:    gcrv1.Descriptor{MediaType: fluxConfigMediaType},
+			},
+		},
+		{
+			name:     "OCI image",
+			selector: &sourcev1.OCILayerSelector{RejectContainerImages: true},
+			manifest: gcrv1.Manifest{
+				MediaType: gcrtypes.OCIManifestSchema1,
+				Config:    gcrv1.Descriptor{MediaType: gcrtypes.OCIConfigJSON},
+			},
+			wantErr: "artifact is a container image with manifest media type 'application/vnd.oci.image.manifest.v1+json' and config media type 'application/vnd.oci.image.config.v1+json'",
+		},
+		{
+			name:     "Docker image",
+			selector: &sourcev1.OCILayerSelector{RejectContainerImages: true},
+			manifest: gcrv1.Manifest{
+				MediaType: gcrtypes.DockerManifestSchema2,
+				Config:    gcrv1.Descriptor{MediaType: gcrtypes.DockerConfigJSON},
+			},
+			wantErr: "artifact is a container image with manifest media type 'application/vnd.docker.distribution.manifest.v2+json'",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			obj := &sourcev1.OCIRepository{
+				Spec: sourcev1.OCIRepositorySpec{LayerSelector: tt.selector},
+			}
+
+			err := checkContainerImage(obj, &tt.manifest)
+			if tt.wantErr != "" {
+				g.Expect(err).To(HaveOccurred())
+				g.Expect(err.Error()).To(ContainSubstring(tt.wantErr))
+				return
+			}
+			g.Expect(err).ToNot(HaveOccurred())
+		})
+	}
+}
+
+func TestOCIRepository_defaultLayers(t *testing.T) {
+	const (
+		fluxMediaType = "application/vnd.cncf.flux.content.v1.tar+gzip"
+		dockerLayer   = "application/vnd.docker.image.rootfs.diff.tar.gzip"
+	)
+	layer := func(content, mediaType string) gcrv1.Layer {
+		return static.NewLayer([]byte(content), gcrtypes.MediaType(mediaType))
+	}
+
+	tests := []struct {
+		name    string
+		layers  []gcrv1.Layer
+		want    []int
+		wantErr string
+	}{
+		{
+			name:   "single layer of any media type",
+			layers: []gcrv1.Layer{layer("config", "application/json")},
+			want:   []int{0},
+		},
+		{
+			name: "single tarball next to other content",
+			layers: []gcrv1.Layer{
+				layer("{}", "application/json"),
+				layer("content", fluxMediaType),
+			},
+			want: []int{1},
+		},
+		{
+			name: "Helm chart next to its provenance and other tarballs",
+			layers: []gcrv1.Layer{
+				layer("content", fluxMediaType),
+				layer("chart", helmreg.ChartLayerMediaType),
+				layer("provenance", helmreg.ProvLayerMediaType),
+			},
+			want: []int{1},
+		},
+		{
+			name: "multiple tarballs in manifest order",
+			layers: []gcrv1.Layer{
+				layer("content", fluxMediaType),
+				layer("{}", "application/json"),
+				layer("other", dockerLayer),
+			},
+			want: []int{0, 2},
+		},
+		{
+			name: "no tarballs",
+			layers: []gcrv1.Layer{
+				layer("{}", "application/json"),
+				layer("content", "text/plain"),
+			},
+			wantErr: "failed to select a layer from the 2 layers in artifact, found no gzip compressed tarballs",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			got, err := defaultLayers(tt.layers)
+			if tt.wantErr != "" {
+				g.Expect(err).To(HaveOccurred())
+				g.Expect(err.Error()).To(ContainSubstring(tt.wantErr))
+				g.Expect(err.Error()).To(ContainSubstring(".spec.layerSelector.mediaType"))
+				return
+			}
+			g.Expect(err).ToNot(HaveOccurred())
+			want := make([]gcrv1.Layer, 0, len(tt.want))
+			for _, i := range tt.want {
+				want = append(want, tt.layers[i])
+			}
+			g.Expect(got).To(Equal(want))
+		})
+	}
+}
+
+func TestOCIRepository_verifySignature_keylessDisabled(t *testing.T) {
+	g := NewWithT(t)
+
+	r := &OCIRepositoryReconciler{
+		Client:                     fakeclient.NewClientBuilder().WithScheme(testEnv.GetScheme()).Build(),
+		DisableKeylessVerification: true,
+	}
+	obj := &sourcev1.OCIRepository{
+		ObjectMeta: metav1.ObjectMeta{Name: "podinfo", Namespace: "default"},
+		Spec: sourcev1.OCIRepositorySpec{
+			URL: "oci://ghcr.io/stefanprodan/manifests/podinfo",
+			Verify: &sourcev1.OCIRepositoryVerification{
+				Provider: "cosign",
+			},
+		},
+	}
+
+	err := r.verifySignature(context.TODO(), obj, "ghcr.io/stefanprodan/manifests/podinfo:6.2.0")
+	g.Expect(err).To(MatchError(errKeylessVerificationDisabled))
+}
+
+func TestOCIRepository_cosignKeylessOptions(t *testing.T) {
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "fulcio-root", Namespace: "default"},
+		Data:       map[string][]byte{fulcioTrustedRootKey: []byte("roots")},
+	}
+	emptySecret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "empty", Namespace: "default"},
+	}
+
+	tests := []struct {
+		name     string
+		verify   *sourcev1.OCIRepositoryVerification
+		wantOpts int
+		wantErr  string
+	}{
+		{
+			name:     "public instances",
+			verify:   &sourcev1.OCIRepositoryVerification{Provider: "cosign"},
+			wantOpts: 1,
+		},
+		{
+			name: "private instances",
+			verify: &sourcev1.OCIRepositoryVerification{
+				Provider: "cosign",
+				Rekor:    &sourcev1.OCIRekorVerification{URL: "https://rekor.example.com"},
+				Fulcio: &sourcev1.OCIFulcioVerification{
+					URL:                  "https://fulcio.example.com",
+					TrustedRootSecretRef: &meta.LocalObjectReference{Name: "fulcio-root"},
+				},
+			},
+			wantOpts: 4,
+		},
+		{
+			name: "missing trusted root secret",
+			verify: &sourcev1.OCIRepositoryVerification{
+				Provider: "cosign",
+				Fulcio: &sourcev1.OCIFulcioVerification{
+					TrustedRootSecretRef: &meta.LocalObjectReference{Name: "missing"},
+				},
+			},
+			wantErr: "failed to get Fulcio trusted root secret 'default/missing'",
+		},
+		{
+			name: "missing trusted root key",
+			verify: &sourcev1.OCIRepositoryVerification{
+				Provider: "cosign",
+				Fulcio: &sourcev1.OCIFulcioVerification{
+					TrustedRootSecretRef: &meta.LocalObjectReference{Name: "empty"},
+				},
+			},
+			wantErr: "'ca.crt' not found in Fulcio trusted root secret 'default/empty'",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			c := fakeclient.NewClientBuilder().WithScheme(testEnv.GetScheme()).WithObjects(secret, emptySecret).Build()
+			opts, err := cosignKeylessOptions(context.TODO(), c, "default", tt.verify, false)
+			if tt.wantErr != "" {
+				g.Expect(err).To(HaveOccurred())
+				g.Expect(err.Error()).To(ContainSubstring(tt.wantErr))
+				return
+			}
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(opts).To(HaveLen(tt.wantOpts))
+		})
+	}
+}
+
+func TestOCIRepository_verifyHelmChart(t *testing.T) {
+	tests := []struct {
+		name    string
+		files   map[string]string
+		wantErr string
+	}{
+		{
+			name:  "chart directory",
+			files: map[string]string{"podinfo/Chart.yaml": "name: podinfo", "podinfo/values.yaml": ""},
+		},
+		{
+			name:    "multiple entries",
+			files:   map[string]string{"podinfo/Chart.yaml": "name: podinfo", "README.md": ""},
+			wantErr: "expected a single chart directory, found 2 entries",
+		},
+		{
+			name:    "directory without Chart.yaml",
+			files:   map[string]string{"podinfo/values.yaml": ""},
+			wantErr: "chart directory 'podinfo' does not contain a Chart.yaml",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			dir := t.TempDir()
+			for name, content := range tt.files {
+				g.Expect(os.MkdirAll(filepath.Dir(filepath.Join(dir, name)), 0o700)).To(Succeed())
+				g.Expect(os.WriteFile(filepath.Join(dir, name), []byte(content), 0o600)).To(Succeed())
+			}
+
+			err := verifyHelmChart(dir)
+			if tt.wantErr != "" {
+				g.Expect(err).To(HaveOccurred())
+				g.Expect(err.Error()).To(ContainSubstring(tt.wantErr))
+				return
+			}
+			g.Expect(err).ToNot(HaveOccurred())
+		})
+	}
+}
+
+func TestOCIRepository_getRevision_digestReference(t *testing.T) {
+	g := NewWithT(t)
+
+	const digest = "sha256:6c6d8c0d8f5c4c4e0a1e6e5a7b0e8f3b0b4d3f7f6a0a3e8c4c9b7a2d8e6f1c3b"
+
+	// The digest is not fetched from the (unreachable) registry
+	r := &OCIRepositoryReconciler{}
+	revision, gotDigest, err := r.getRevision("127.0.0.1:1/podinfo@"+digest, nil)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(revision).To(Equal(strings.TrimPrefix(digest, "sha256:")))
+	g.Expect(gotDigest).To(Equal(digest))
+
+	_, _, err = r.getRevision("127.0.0.1:1/podinfo@sha256:invalid", nil)
+	g.Expect(err).To(HaveOccurred())
+}
+
+func TestOCIRepository_resolvePlatform(t *testing.T) {
+	g := NewWithT(t)
+
+	tmpDir := t.TempDir()
+	regServer, err := setupRegistryServer(ctx, tmpDir, registryOptions{})
+	g.Expect(err).ToNot(HaveOccurred())
+
+	podinfoVersions, err := pushMultiplePodinfoImages(regServer.registryHost, "6.1.4")
+	g.Expect(err).ToNot(HaveOccurred())
+	imageURL := strings.TrimPrefix(podinfoVersions["6.1.4"].url, "oci://") + ":6.1.4"
+
+	// Push an image index referring to the podinfo image
+	img, err := crane.Pull(imageURL)
+	g.Expect(err).ToNot(HaveOccurred())
+	index := mutate.AppendManifests(empty.Index, mutate.IndexAddendum{
+		Add: img,
+		Descriptor: gcrv1.Descriptor{
+			Platform: &gcrv1.Platform{OS: "linux", Architecture: "amd64", Variant: "v3"},
+		},
+	})
+	indexURL := fmt.Sprintf("%s/podinfo-index:6.1.4", regServer.registryHost)
+	indexRef, err := name.ParseReference(indexURL)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(remote.WriteIndex(indexRef, index)).To(Succeed())
+
+	tests := []struct {
+		name string
+		url  string
+		want *sourcev1.OCIPlatform
+	}{
+		{
+			name: "single manifest",
+			url:  imageURL,
+			want: nil,
+		},
+		{
+			name: "image index",
+			url:  indexURL,
+			want: &sourcev1.OCIPlatform{OS: "linux", Architecture: "amd64", Variant: "v3"},
+		},
+	}
+
+	r := &OCIRepositoryReconciler{}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			revision, _, err := r.getRevision(tt.url, nil)
+			g.Expect(err).ToNot(HaveOccurred())
+			img, err := crane.Pull(tt.url)
+			g.Expect(err).ToNot(HaveOccurred())
+
+			got, err := r.resolvePlatform(tt.url, revision, img, nil)
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(got).To(Equal(tt.want))
+		})
+	}
+}
+
+func TestOCIRepository_hasPlatform(t *testing.T) {
+	g := NewWithT(t)
+
+	tmpDir := t.TempDir()
+	regServer, err := setupRegistryServer(ctx, tmpDir, registryOptions{})
+	g.Expect(err).ToNot(HaveOccurred())
+
+	podinfoVersions, err := pushMultiplePodinfoImages(regServer.registryHost, "6.1.4")
+	g.Expect(err).ToNot(HaveOccurred())
+	imageURL := strings.TrimPrefix(podinfoVersions["6.1.4"].url, "oci://") + ":6.1.4"
+
+	// Push an image index referring to the podinfo image for two platforms
+	img, err := crane.Pull(imageURL)
+	g.Expect(err).ToNot(HaveOccurred())
+	index := mutate.AppendManifests(empty.Index,
+		mutate.IndexAddendum{
+			Add: img,
+			Descriptor: gcrv1.Descriptor{
+				Platform: &gcrv1.Platform{OS: "linux", Architecture: "amd64"},
+			},
+		},
+		mutate.IndexAddendum{
+			Add: img,
+			Descriptor: gcrv1.Descriptor{
+				Platform: &gcrv1.Platform{OS: "linux", Architecture: "arm", Variant: "v7"},
+			},
+		},
+	)
+	indexURL := fmt.Sprintf("%s/podinfo-platforms:6.1.4", regServer.registryHost)
+	indexRef, err := name.ParseReference(indexURL)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(remote.WriteIndex(indexRef, index)).To(Succeed())
+
+	tests := []struct {
+		name     string
+		url      string
+		platform sourcev1.OCIPlatform
+		want     bool
+	}{
+		{
+			name:     "single manifest",
+			url:      imageURL,
+			platform: sourcev1.OCIPlatform{OS: "linux", Architecture: "arm64"},
+			want:     true,
+		},
+		{
+			name:     "matching platform",
+			url:      indexURL,
+			platform: sourcev1.OCIPlatform{OS: "linux", Architecture: "amd64"},
+			want:     true,
+		},
+		{
+			name:     "matching platform without variant",
+			url:      indexURL,
+			platform: sourcev1.OCIPlatform{OS: "linux", Architecture: "arm"},
+			want:     true,
+		},
+		{
+			name:     "different variant",
+			url:      indexURL,
+			platform: sourcev1.OCIPlatform{OS: "linux", Architecture: "arm", Variant: "v6"},
+			want:     false,
+		},
+		{
+			name:     "missing platform",
+			url:      indexURL,
+			platform: sourcev1.OCIPlatform{OS: "linux", Architecture: "arm64"},
+			want:     false,
+		},
+	}
+
+	r := &OCIRepositoryReconciler{}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			got, err := r.hasPlatform(tt.url, &tt.platform, nil)
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(got).To(Equal(tt.want))
+		})
+	}
+}
+
+func TestOCIRepository_getArtifactURL(t *testing.T) {
+	g := NewWithT(t)
+
+	tmpDir := t.TempDir()
+	server, err := setupRegistryServer(ctx, tmpDir, registryOptions{})
+	g.Expect(err).ToNot(HaveOccurred())
+
+	imgs, err := pushMultiplePodinfoImages(server.registryHost, "6.1.4", "6.1.5", "6.1.6")
+	g.Expect(err).ToNot(HaveOccurred())
+
+	tests := []struct {
+		name      string
+		url       string
+		reference *sourcev1.OCIRepositoryRef
+		wantErr   bool
+		want      string
+	}{
+		{
+			name: "valid url with no reference",
+			url:  "oci://ghcr.io/stefanprodan/charts",
+			want: "ghcr.io/stefanprodan/charts",
+		},
+		{
+			name: "valid url with tag reference",
+			url:  "oci://ghcr.io/stefanprodan/charts",
+			reference: &sourcev1.OCIRepositoryRef{
+				Tag: "6.1.6",
+			},
+			want: "ghcr.io/stefanprodan/charts:6.1.6",
+		},
+		{
+			name: "valid url with digest reference",
+			url:  "oci://ghcr.io/stefanprodan/charts",
+			reference: &sourcev1.OCIRepositoryRef{
+				Digest: imgs["6.1.6"].digest.Hex,
+			},
+			want: "ghcr.io/stefanprodan/charts@" + imgs["6.1.6"].digest.Hex,
+		},
+		{
+			name: "valid url with semver reference",
+			url:  fmt.Sprintf("oci://%s/podinfo", server.registryHost),
+			reference: &sourcev1.OCIRepositoryRef{
+				SemVer: ">= 6.1.6",
+			},
+			want: server.registryHost + "/podinfo:6.1.6",
+		},
+		{
+			name:    "invalid url without oci prefix",
+			url:     "ghcr.io/stefanprodan/charts",
+			wantErr: true,
+		},
+	}
+
+	builder := fakeclient.NewClientBuilder().WithScheme(testEnv.GetScheme())
+	r := &OCIRepositoryReconciler{
+		Client:        builder.Build(),
+		EventRecorder: record.NewFakeRecorder(32),
+		Storage:       testStorage,
+		patchOptions:  getPatchOptions(ociRepositoryReadyCondition.Owned, "sc"),
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			obj := &sourcev1.OCIRepository{
+				ObjectMeta: metav1.ObjectMeta{
+					GenerateName: "artifact-url-",
+				},
+				Spec: sourcev1.OCIRepositorySpec{
+					URL:      tt.url,
+					Interval: metav1.Duration{Duration: interval},
+					Timeout:  &metav1.Duration{Duration: timeout},
+				},
+			}
+
+			if tt.reference != nil {
+				obj.Spec.Reference = tt.reference
+			}
+
+			opts := craneOptions(ctx, true)
+			opts = append(opts, crane.WithAuthFromKeychain(authn.DefaultKeychain))
+			got, _, err := r.getArtifactURL(obj, remoteOptions{craneOpts: opts})
+			if tt.wantErr {
+				g.Expect(err).To(HaveOccurred())
+				return
+			}
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(got).To(Equal(tt.want))
+		})
+	}
+}
+
+func TestOCIRepository_immutableReferenceError(t *testing.T) {
+	tests := []struct {
+		name      string
+		url       string
+		reference *sourcev1.OCIRepositoryRef
+		wantErr   string
+	}{
+		{
+			name: "no policy",
+			url:  "ghcr.io/stefanprodan/charts:6.1.6",
+			reference: &sourcev1.OCIRepositoryRef{
+				Tag: "6.1.6",
+			},
+		},
+		{
+			name: "digest reference",
+			url:  "ghcr.io/stefanprodan/charts@sha256:6c6d8c0d8f5c4c4e0a1e6e5a7b0e8f3b0b4d3f7f6a0a3e8c4c9b7a2d8e6f1c3b",
+			reference: &sourcev1.OCIRepositoryRef{
+				Digest:           "sha256:6c6d8c0d8f5c4c4e0a1e6e5a7b0e8f3b0b4d3f7f6a0a3e8c4c9b7a2d8e6f1c3b",
+				RequireImmutable: true,
+			},
+		},
+		{
+			name: "semver reference",
+			url:  "ghcr.io/stefanprodan/charts:6.1.6",
+			reference: &sourcev1.OCIRepositoryRef{
+				SemVer:           ">= 6.1.0",
+				RequireImmutable: true,
+			},
+		},
+		{
+			name: "tag reference",
+			url:  "ghcr.io/stefanprodan/charts:6.1.6",
+			reference: &sourcev1.OCIRepositoryRef{
+				Tag:              "6.1.6",
+				RequireImmutable: true,
+			},
+			wantErr: "'ghcr.io/stefanprodan/charts' uses the mutable tag '6.1.6'",
+		},
+		{
+			name: "default latest tag",
+			url:  "ghcr.io/stefanprodan/charts",
+			reference: &sourcev1.OCIRepositoryRef{
+				RequireImmutable: true,
+			},
+			wantErr: "'ghcr.io/stefanprodan/charts' uses the mutable tag 'latest'",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			obj := &sourcev1.OCIRepository{
+				Spec: sourcev1.OCIRepositorySpec{
+					URL:       "oci://ghcr.io/stefanprodan/charts",
+					Reference: tt.reference,
+				},
+			}
+
+			err := immutableReferenceError(obj, tt.url)
+			if tt.wantErr != "" {
+				g.Expect(err).To(HaveOccurred())
+				g.Expect(err.Error()).To(ContainSubstring(tt.wantErr))
+				return
+			}
+			g.Expect(err).ToNot(HaveOccurred())
+		})
+	}
+}
+
+func TestOCIRepository_equivalentRevision(t *testing.T) {
+	const (
+		hex   = "d1fc4595915714af2492dc4b66097de1e10f80150c8899907d8f8e61c6d6f67d"
+		other = "0f1e2d3c4b5a69788796a5b4c3d2e1f00f1e2d3c4b5a69788796a5b4c3d2e1f0"
+	)
+
+	tests := []struct {
+		name     string
+		current  *sourcev1.Artifact
+		revision string
+		want     bool
+	}{
+		{
+			name:     "no artifact",
+			revision: "6.1.5/" + hex,
+			want:     false,
+		},
+		{
+			name:     "same revision",
+			current:  &sourcev1.Artifact{Revision: "6.1.5/" + hex},
+			revision: "6.1.5/" + hex,
+			want:     true,
+		},
+		{
+			name:     "tag to equivalent digest",
+			current:  &sourcev1.Artifact{Revision: "6.1.5/" + hex},
+			revision: hex,
+			want:     true,
+		},
+		{
+			name:     "digest to equivalent tag",
+			current:  &sourcev1.Artifact{Revision: hex},
+			revision: "6.1.5/" + hex,
+			want:     true,
+		},
+		{
+			name:     "tag to equivalent tag",
+			current:  &sourcev1.Artifact{Revision: "latest/" + hex},
+			revision: "6.1.5/" + hex,
+			want:     true,
+		},
+		{
+			name:     "tag to different digest",
+			current:  &sourcev1.Artifact{Revision: "6.1.5/" + hex},
+			revision: other,
+			want:     false,
+		},
+		{
+			name:     "same tag with different digest",
+			current:  &sourcev1.Artifact{Revision: "6.1.5/" + hex},
+			revision: "6.1.5/" + other,
+			want:     false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			r := &OCIRepositoryReconciler{}
+			obj := &sourcev1.OCIRepository{
+				Status: sourcev1.OCIRepositoryStatus{Artifact: tt.current},
+			}
+			g.Expect(r.equivalentRevision(obj, tt.revision)).To(Equal(tt.want))
+		})
+	}
+}
+
+func TestOCIRepository_digestMismatchError(t *testing.T) {
+	const (
+		url    = "ghcr.io/stefanprodan/charts:6.1.6"
+		digest = "sha256:6c6d8c0d8f5c4c4e0a1e6e5a7b0e8f3b0b4d3f7f6a0a3e8c4c9b7a2d8e6f1c3b"
+		other  = "sha256:0f1e2d3c4b5a69788796a5b4c3d2e1f00f1e2d3c4b5a69788796a5b4c3d2e1f0"
+	)
+
+	tests := []struct {
+		name      string
+		reference *sourcev1.OCIRepositoryRef
+		wantErr   string
+	}{
+		{
+			name:      "no reference",
+			reference: nil,
+		},
+		{
+			name: "no expected digest",
+			reference: &sourcev1.OCIRepositoryRef{
+				Tag: "6.1.6",
+			},
+		},
+		{
+			name: "matching digest",
+			reference: &sourcev1.OCIRepositoryRef{
+				Tag:          "6.1.6",
+				VerifyDigest: digest,
+			},
+		},
+		{
+			name: "mismatching digest",
+			reference: &sourcev1.OCIRepositoryRef{
+				SemVer:       ">= 6.1.0",
+				VerifyDigest: other,
+			},
+			wantErr: fmt.Sprintf("digest mismatch for '%s': expected '%s' but the reference resolved to '%s'", url, other, digest),
+		},
+		{
+			name: "invalid expected digest",
+			reference: &sourcev1.OCIRepositoryRef{
+				Tag:          "6.1.6",
+				VerifyDigest: "6c6d8c0d",
+			},
+			wantErr: "invalid expected digest '6c6d8c0d'",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			obj := &sourcev1.OCIRepository{
+				Spec: sourcev1.OCIRepositorySpec{
+					URL:       "oci://ghcr.io/stefanprodan/charts",
+					Reference: tt.reference,
+				},
+			}
+
+			err := digestMismatchError(obj, url, digest)
+			if tt.wantErr != "" {
+				g.Expect(err).To(HaveOccurred())
+				g.Expect(err.Error()).To(ContainSubstring(tt.wantErr))
+				return
+			}
+			g.Expect(err).ToNot(HaveOccurred())
+		})
+	}
+}
+
+func Test_digestReference(t *testing.T) {
+	const digest = "sha256:6c6d8c0d8f5c4c4e0a1e6e5a7b0e8f3b0b4d3f7f6a0a3e8c4c9b7a2d8e6f1c3b"
+
+	tests := []struct {
+		name    string
+		url     string
+		want    string
+		wantErr bool
+	}{
+		{
+			name: "tag reference",
+			url:  "ghcr.io/stefanprodan/charts:6.1.6",
+			want: "ghcr.io/stefanprodan/charts@" + digest,
+		},
+		{
+			name: "implicit latest tag",
+			url:  "localhost:5000/podinfo",
+			want: "localhost:5000/podinfo@" + digest,
+		},
+		{
+			name: "digest reference",
+			url:  "ghcr.io/stefanprodan/charts@sha256:0f1e2d3c4b5a69788796a5b4c3d2e1f00f1e2d3c4b5a69788796a5b4c3d2e1f0",
+			want: "ghcr.io/stefanprodan/charts@" + digest,
+		},
+		{
+			name:    "invalid reference",
+			url:     "ghcr.io/Stefanprodan/charts:6.1.6",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			got, err := digestReference(tt.url, digest)
+			if tt.wantErr {
+				g.Expect(err).To(HaveOccurred())
+				return
+			}
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(got).To(Equal(tt.want))
+		})
+	}
+}
+
+func TestOCIRepository_reconcileSource_ambiguousReference(t *testing.T) {
+	tests := []struct {
+		name               string
+		observedGeneration int64
+		wantEvent          bool
+	}{
+		{
+			name:               "warns for a new generation",
+			observedGeneration: 1,
+			wantEvent:          true,
+		},
+		{
+			name:               "does not warn again for an observed generation",
+			observedGeneration: 2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				w.WriteHeader(http.StatusNotFound)
+			}))
+			t.Cleanup(srv.Close)
+
+			recorder := record.NewFakeRecorder(32)
+			r := &OCIRepositoryReconciler{
+				Client:        fakeclient.NewClientBuilder().WithScheme(testEnv.GetScheme()).Build(),
+				EventRecorder: recorder,
+				Storage:       testStorage,
+				patchOptions:  getPatchOptions(ociRepositoryReadyCondition.Owned, "sc"),
+			}
+
+			obj := &sourcev1.OCIRepository{
+				ObjectMeta: metav1.ObjectMeta{
+					GenerateName: "ambiguous-reference-",
+					Generation:   2,
+				},
+				Spec: sourcev1.OCIRepositorySpec{
+					URL:       fmt.Sprintf("oci://%s/podinfo", strings.TrimPrefix(srv.URL, "http://")),
+					Reference: &sourcev1.OCIRepositoryRef{Tag: "6.1.5", SemVer: ">= 6.1.x"},
+					Insecure:  true,
+					Interval:  metav1.Duration{Duration: interval},
+					Timeout:   &metav1.Duration{Duration: timeout},
+				},
+			}
+			obj.Status.ObservedGeneration = tt.observedGeneration
+			g.Expect(r.Client.Create(ctx, obj)).ToNot(HaveOccurred())
+			defer func() {
+				g.Expect(r.Client.Delete(ctx, obj)).ToNot(HaveOccurred())
+			}()
+
+			sp := sreconcile.NewSerialPatcher(obj, r.Client, nil)
+
+			_, err := r.reconcileSource(ctx, sp, obj, &sourcev1.Artifact{}, t.TempDir())
+			g.Expect(err).To(HaveOccurred())
+
+			var warned bool
+			for len(recorder.Events) > 0 {
+				if strings.HasPrefix(<-recorder.Events, corev1.EventTypeWarning+" "+ambiguousReferenceReason) {
+					warned = true
+				}
+			}
+			g.Expect(warned).To(Equal(tt.wantEvent))
+		})
+	}
+}
+
+func TestOCIRepository_ambiguousReferenceWarning(t *testing.T) {
+	tests := []struct {
+		name string
+		ref  *sourcev1.OCIRepositoryRef
+		want string
+	}{
+		{
+			name: "no reference",
+		},
+		{
+			name: "single reference",
+			ref:  &sourcev1.OCIRepositoryRef{Tag: "6.1.6"},
+		},
+		{
+			name: "digest and tag",
+			ref:  &sourcev1.OCIRepositoryRef{Digest: "sha256:abc", Tag: "6.1.6"},
+			want: "multiple references are specified, '.spec.ref.digest' takes precedence and '.spec.ref.tag' is ignored",
+		},
+		{
+			name: "semver and tag",
+			ref:  &sourcev1.OCIRepositoryRef{SemVer: ">= 6.1.x-0", Tag: "6.1.6"},
+			want: "multiple references are specified, '.spec.ref.semver' takes precedence and '.spec.ref.tag' is ignored",
+		},
+		{
+			name: "digest, semver and tag",
+			ref:  &sourcev1.OCIRepositoryRef{Digest: "sha256:abc", SemVer: ">= 6.1.x-0", Tag: "6.1.6"},
+			want: "multiple references are specified, '.spec.ref.digest' takes precedence and '.spec.ref.semver' and '.spec.ref.tag' are ignored",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			g.Expect(ambiguousReferenceWarning(tt.ref)).To(Equal(tt.want))
+		})
+	}
+}
+
+func TestOCIRepository_pullFailureReason(t *testing.T) {
+	// A registry responding to the requests for manifests with the given
+	// status code
+	registry := func(t *testing.T, status int) string {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			if req.URL.Path == "/v2/" {
+				w.WriteHeader(http.StatusOK)
+				return
+			}
+			w.WriteHeader(status)
+		}))
+		t.Cleanup(srv.Close)
+		return strings.TrimPrefix(srv.URL, "http://")
+	}
+
+	// A registry which is no longer listening
+	closed := httptest.NewServer(http.NotFoundHandler())
+	closedHost := strings.TrimPrefix(closed.URL, "http://")
+	closed.Close()
+
+	tests := []struct {
+		name string
+		host func(t *testing.T) string
+		want string
+	}{
+		{
+			name: "unauthorized",
+			host: func(t *testing.T) string { return registry(t, http.StatusUnauthorized) },
+			want: sourcev1.AuthenticationFailedReason,
+		},
+		{
+			name: "forbidden",
+			host: func(t *testing.T) string { return registry(t, http.StatusForbidden) },
+			want: sourcev1.AuthenticationFailedReason,
+		},
+		{
+			name: "not found",
+			host: func(t *testing.T) string { return registry(t, http.StatusNotFound) },
+			want: sourcev1.OCINotFoundReason,
+		},
+		{
+			name: "unreachable registry",
+			host: func(t *testing.T) string { return closedHost },
+			want: sourcev1.OCINetworkErrorReason,
+		},
+		{
+			name: "bad request",
+			host: func(t *testing.T) string { return registry(t, http.StatusBadRequest) },
+			want: sourcev1.OCIPullFailedReason,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			_, err := crane.Digest(tt.host(t)+"/podinfo:6.1.6", crane.Insecure)
+			g.Expect(err).To(HaveOccurred())
+			g.Expect(pullFailureReason(err)).To(Equal(tt.want))
+		})
+	}
+}
+
+func TestOCIRepository_parseRepositoryURL(t *testing.T) {
+	tests := []struct {
+		name    string
+		url     string
+		want    string
+		wantErr string
+	}{
+		{
+			name: "registry with port",
+			url:  "oci://localhost:5000/org/repo",
+			want: "localhost:5000/org/repo",
+		},
+		{
+			name: "IPv6 registry with port",
+			url:  "oci://[::1]:5000/repo",
+			want: "[::1]:5000/repo",
+		},
+		{
+			name:    "registry with port and tag",
+			url:     "oci://localhost:5000/org/repo:v1.0.0",
+			wantErr: "URL must not contain a tag; remove ':v1.0.0'",
+		},
+		{
+			name:    "IPv6 registry with port and tag",
+			url:     "oci://[::1]:5000/repo:tag",
+			wantErr: "URL must not contain a tag; remove ':tag'",
+		},
+		{
+			name:    "registry with port and digest",
+			url:     "oci://localhost:5000/org/repo@sha256:e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855",
+			wantErr: "URL must not contain a digest; remove '@sha256:e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855'",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			r := &OCIRepositoryReconciler{}
+			obj := &sourcev1.OCIRepository{
+				Spec: sourcev1.OCIRepositorySpec{URL: tt.url},
+			}
+
+			got, err := r.parseRepositoryURL(obj)
+			if tt.wantErr != "" {
+				g.Expect(err).To(MatchError(tt.wantErr))
+				return
+			}
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(got).To(Equal(tt.want))
+		})
+	}
+}
+
+func TestOCIRepository_tagFromReference(t *testing.T) {
+	const digest = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+
+	tests := []struct {
+		name string
+		url  string
+		want string
+	}{
+		{
+			name: "IPv6 registry with port and tag",
+			url:  "[::1]:5000/repo:tag",
+			want: "tag",
+		},
+		{
+			name: "registry with port without tag",
+			url:  "localhost:5000/org/repo",
+			want: "latest",
+		},
+		{
+			name: "registry with port and digest",
+			url:  "localhost:5000/org/repo@sha256:" + digest,
+			want: "",
+		},
+		{
+			name: "registry with port, tag and digest",
+			url:  "localhost:5000/org/repo:v1.0.0@sha256:" + digest,
+			want: "",
+		},
+	}
+
+	r := &OCIRepositoryReconciler{}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			ref, err := name.ParseReference(tt.url)
+			g.Expect(err).ToNot(HaveOccurred())
+			tag := tagFromReference(ref)
+			g.Expect(tag).To(Equal(tt.want))
+
+			// The revision composed from the tag and digest must round-trip
+			revision := digest
+			if tag != "" {
+				revision = fmt.Sprintf("%s/%s", tag, digest)
+			}
+			g.Expect(r.digestFromRevision(revision)).To(Equal(digest))
+			g.Expect(strings.TrimSuffix(strings.TrimSuffix(revision, digest), "/")).To(Equal(tag))
+		})
+	}
+}
+
+func TestOCIRepository_parseRepositoryURL_allowedRegistryDomains(t *testing.T) {
+	tests := []struct {
+		name    string
+		url     string
+		domains []string
+		wantErr string
+	}{
+		{
+			name: "any registry without allowed domains",
+			url:  "oci://registry.example.com/org/repo",
+		},
+		{
+			name:    "allowed registry",
+			url:     "oci://ghcr.io/org/repo",
+			domains: []string{"ghcr.io"},
+		},
+		{
+			name:    "allowed subdomain",
+			url:     "oci://registry.eu.example.com/org/repo",
+			domains: []string{"ghcr.io", "*.example.com"},
+		},
+		{
+			name:    "registry not allowed",
+			url:     "oci://registry.example.com/org/repo",
+			domains: []string{"ghcr.io"},
+			wantErr: "registry 'registry.example.com' is not allowed by the policy of allowed registry domains 'ghcr.io'",
+		},
+		{
+			name:    "unparsable URL denied",
+			url:     "oci://registry.example.com/Org/repo",
+			domains: []string{"*.example.com"},
+			wantErr: "failed to determine the registry of 'oci://registry.example.com/Org/repo'",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			r := &OCIRepositoryReconciler{
+				AllowedRegistryDomains: tt.domains,
+			}
+			obj := &sourcev1.OCIRepository{
+				Spec: sourcev1.OCIRepositorySpec{URL: tt.url},
+			}
+
+			_, err := r.parseRepositoryURL(obj)
+			if tt.wantErr != "" {
+				g.Expect(err).To(HaveOccurred())
+				g.Expect(err.Error()).To(ContainSubstring(tt.wantErr))
+				return
+			}
+			g.Expect(err).ToNot(HaveOccurred())
+		})
+	}
+}
+
+func Test_checkRegistryDomain(t *testing.T) {
+	tests := []struct {
+		name    string
+		url     string
+		domains soci.DomainAllowlist
+		wantErr string
+	}{
+		{
+			name: "any registry without allowed domains",
+			url:  "oci://INVALID",
+		},
+		{
+			name:    "registry only URL",
+			url:     "oci://ghcr.io/",
+			domains: soci.DomainAllowlist{"ghcr.io"},
+		},
+		{
+			name:    "registry with port",
+			url:     "oci://registry.example.com:5000/charts",
+			domains: soci.DomainAllowlist{"*.example.com"},
+		},
+		{
+			name:    "registry not allowed",
+			url:     "oci://registry.example.com/charts",
+			domains: soci.DomainAllowlist{"ghcr.io"},
+			wantErr: "registry 'registry.example.com' is not allowed",
+		},
+		{
+			name:    "empty URL denied",
+			url:     "oci://",
+			domains: soci.DomainAllowlist{"ghcr.io"},
+			wantErr: "empty URL",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			err := checkRegistryDomain(tt.domains, tt.url)
+			if tt.wantErr != "" {
+				g.Expect(err).To(HaveOccurred())
+				g.Expect(err.Error()).To(ContainSubstring(tt.wantErr))
+				return
+			}
+			g.Expect(err).ToNot(HaveOccurred())
+		})
+	}
+}
+
+func TestOCIRepository_keychain_defaultServiceAccount(t *testing.T) {
+	const registryHost = "registry.example.com"
+
+	pullSecret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "pull-secret",
+			Namespace: "default",
+		},
+		Type: corev1.SecretTypeDockerConfigJson,
+		Data: map[string][]byte{
+			".dockerconfigjson": []byte(fmt.Sprintf(`{"auths": {%q: {"username": "default-sa", "password": "pass"}}}`, registryHost)),
+		},
+	}
+	defaultSA := func(secrets ...string) *corev1.ServiceAccount {
+		sa := &corev1.ServiceAccount{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "default",
+				Namespace: "default",
+			},
+		}
+		for _, s := range secrets {
+			sa.ImagePullSecrets = append(sa.ImagePullSecrets, corev1.LocalObjectReference{Name: s})
+		}
+		return sa
+	}
+
+	tests := []struct {
+		name         string
+		enabled      bool
+		objects      []client.Object
+		secretRef    *meta.LocalObjectReference
+		wantAnon     bool
+		wantFallback bool
+		wantUsername string
+	}{
+		{
+			name:     "disabled",
+			objects:  []client.Object{defaultSA(pullSecret.Name), pullSecret},
+			wantAnon: true,
+		},
+		{
+			name:         "pull secrets of the default service account",
+			enabled:      true,
+			objects:      []client.Object{defaultSA(pullSecret.Name), pullSecret},
+			wantFallback: true,
+			wantUsername: "default-sa",
+		},
+		{
+			name:     "default service account without pull secrets",
+			enabled:  true,
+			objects:  []client.Object{defaultSA()},
+			wantAnon: true,
+		},
+		{
+			name:     "no default service account",
+			enabled:  true,
+			wantAnon: true,
+		},
+		{
+			name:         "missing pull secrets are ignored",
+			enabled:      true,
+			objects:      []client.Object{defaultSA("missing", pullSecret.Name), pullSecret},
+			wantFallback: true,
+			wantUsername: "default-sa",
+		},
+		{
+			name:         "secret reference takes precedence",
+			enabled:      true,
+			objects:      []client.Object{defaultSA(), pullSecret},
+			secretRef:    &meta.LocalObjectReference{Name: pullSecret.Name},
+			wantUsername: "default-sa",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			r := &OCIRepositoryReconciler{
+				Client: fakeclient.NewClientBuilder().WithScheme(testEnv.GetScheme()).
+					WithObjects(tt.objects...).Build(),
+				EventRecorder:                    record.NewFakeRecorder(32),
+				DefaultServiceAccountPullSecrets: tt.enabled,
+			}
+			obj := &sourcev1.OCIRepository{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "keychain",
+					Namespace: "default",
+				},
+				Spec: sourcev1.OCIRepositorySpec{
+					URL:       "oci://" + registryHost + "/podinfo",
+					SecretRef: tt.secretRef,
+				},
+			}
+
+			keychain, err := r.keychain(ctx, obj)
+			g.Expect(err).ToNot(HaveOccurred())
+
+			_, anonymous := keychain.(soci.Anonymous)
+			g.Expect(anonymous).To(Equal(tt.wantAnon))
+			_, fallback := keychain.(defaultServiceAccountKeychain)
+			g.Expect(fallback).To(Equal(tt.wantFallback))
+
+			if tt.wantUsername != "" {
+				reg, err := name.NewRegistry(registryHost)
+				g.Expect(err).ToNot(HaveOccurred())
+				auth, err := keychain.Resolve(reg)
+				g.Expect(err).ToNot(HaveOccurred())
+				cfg, err := auth.Authorization()
+				g.Expect(err).ToNot(HaveOccurred())
+				g.Expect(cfg.Username).To(Equal(tt.wantUsername))
+			}
+		})
+	}
+}
+
+// mockWorkloadIdentity is a soci.WorkloadIdentity which accepts any token for
+// the configured audience, and returns basic credentials holding it.
+type mockWorkloadIdentity struct {
+	audience string
+}
+
+func (m mockWorkloadIdentity) Audience(serviceAccount corev1.ServiceAccount) (string, bool) {
+	if serviceAccount.Annotations["workload-identity"] != "true" {
+		return "", false
+	}
+	return m.audience, true
+}
+
+func (m mockWorkloadIdentity) Login(_ context.Context, ref name.Reference, _ corev1.ServiceAccount, token string) (authn.Authenticator, error) {
+	if ref.Context().RegistryStr() != "flux.azurecr.io" {
+		return nil, fmt.Errorf("unexpected registry '%s'", ref.Context().RegistryStr())
+	}
+	return &authn.Basic{Username: "exchanged", Password: token}, nil
+}
+
+func TestOCIRepository_workloadIdentityAuth(t *testing.T) {
+	serviceAccount := func(name string, annotations map[string]string) *corev1.ServiceAccount {
+		return &corev1.ServiceAccount{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        name,
+				Namespace:   "default",
+				Annotations: annotations,
+			},
+		}
+	}
+
+	tests := []struct {
+		name               string
+		url                string
+		serviceAccountName string
+		objects            []client.Object
+		tokenErr           error
+		wantAuth           authn.Authenticator
+		wantErr            string
+	}{
+		{
+			name: "no service account",
+		},
+		{
+			name:               "registry of another provider",
+			url:                "oci://registry.example.com/podinfo",
+			serviceAccountName: "flux",
+			objects:            []client.Object{serviceAccount("flux", map[string]string{"workload-identity": "true"})},
+		},
+		{
+			name:               "service account without workload identity",
+			serviceAccountName: "flux",
+			objects:            []client.Object{serviceAccount("flux", nil)},
+		},
+		{
+			name:               "exchanges service account token",
+			serviceAccountName: "flux",
+			objects:            []client.Object{serviceAccount("flux", map[string]string{"workload-identity": "true"})},
+			wantAuth:           &authn.Basic{Username: "exchanged", Password: "token-of-flux"},
+		},
+		{
+			name:               "missing service account",
+			serviceAccountName: "flux",
+			wantErr:            "not found",
+		},
+		{
+			name:               "token request failure",
+			serviceAccountName: "flux",
+			objects:            []client.Object{serviceAccount("flux", map[string]string{"workload-identity": "true"})},
+			tokenErr:           errors.New("forbidden"),
+			wantErr:            "failed to request token: forbidden",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			url := tt.url
+			if url == "" {
+				url = "oci://flux.azurecr.io/podinfo"
+			}
+			transport := soci.NewCountingTransport(http.DefaultTransport)
+
+			tokenClient := kubefake.NewSimpleClientset()
+			tokenClient.PrependReactor("create", "serviceaccounts", func(action k8stesting.Action) (bool, runtime.Object, error) {
+				if action.GetSubresource() != "token" {
+					return false, nil, nil
+				}
+				if tt.tokenErr != nil {
+					return true, nil, tt.tokenErr
+				}
+				tr := action.(k8stesting.CreateAction).GetObject().(*authenticationv1.TokenRequest)
+				g.Expect(tr.Spec.Audiences).To(Equal([]string{"registry.example.com/audience"}))
+				return true, &authenticationv1.TokenRequest{
+					Spec:   tr.Spec,
+					Status: authenticationv1.TokenRequestStatus{Token: "token-of-" + action.(k8stesting.CreateAction).GetName()},
+				}, nil
+			})
+
+			r := &OCIRepositoryReconciler{
+				Client: fakeclient.NewClientBuilder().WithScheme(testEnv.GetScheme()).
+					WithObjects(tt.objects...).Build(),
+				EventRecorder: record.NewFakeRecorder(32),
+				TokenClient:   tokenClient,
+				workloadIdentity: func(provider string, rt http.RoundTripper) (soci.WorkloadIdentity, error) {
+					g.Expect(provider).To(Equal(sourcev1.AzureOCIProvider))
+					g.Expect(rt).To(BeIdenticalTo(transport))
+					return mockWorkloadIdentity{audience: "registry.example.com/audience"}, nil
+				},
+			}
+			obj := &sourcev1.OCIRepository{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "workload-identity",
+					Namespace: "default",
+				},
+				Spec: sourcev1.OCIRepositorySpec{
+					URL:                url,
+					Provider:           sourcev1.AzureOCIProvider,
+					ServiceAccountName: tt.serviceAccountName,
+				},
+			}
+
+			auth, err := r.workloadIdentityAuth(ctx, obj, sourcev1.AzureOCIProvider, transport)
+			if tt.wantErr != "" {
+				g.Expect(err).To(HaveOccurred())
+				g.Expect(err.Error()).To(ContainSubstring(tt.wantErr))
+				return
+			}
+			g.Expect(err).ToNot(HaveOccurred())
+			if tt.wantAuth == nil {
+				g.Expect(auth).To(BeNil())
+				return
+			}
+			g.Expect(auth).To(Equal(tt.wantAuth))
+		})
+	}
+}
+
+func TestOCIRepository_listTags(t *testing.T) {
+	tests := []struct {
+		name          string
+		tags          int
+		paginate      bool
+		wantTruncated bool
+	}{
+		{
+			name: "no tags",
+		},
+		{
+			name: "partial page",
+			tags: tagListPageSize - 1,
+		},
+		{
+			name:          "full page without link to the next",
+			tags:          tagListPageSize,
+			wantTruncated: true,
+		},
+		{
+			name:     "pages linked to the next",
+			tags:     tagListPageSize + 1,
+			paginate: true,
+		},
+		{
+			name:          "full last page without link to the next",
+			tags:          2 * tagListPageSize,
+			paginate:      true,
+			wantTruncated: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			tags := make([]string, tt.tags)
+			for i := range tags {
+				tags[i] = fmt.Sprintf("1.0.%d", i)
+			}
+			// A registry which either paginates the tags, or ignores the
+			// pagination and never returns a link to the next page
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				if req.URL.Path == "/v2/podinfo/tags/list" {
+					g.Expect(req.URL.Query().Get("n")).To(Equal(fmt.Sprint(tagListPageSize)))
+					page := tags
+					if tt.paginate {
+						var start int
+						if last := req.URL.Query().Get("last"); last != "" {
+							for i, tag := range tags {
+								if tag == last {
+									start = i + 1
+								}
+							}
+						}
+						page = tags[start:]
+						if len(page) > tagListPageSize {
+							page = page[:tagListPageSize]
+							w.Header().Set("Link", fmt.Sprintf(`</v2/podinfo/tags/list?n=%d&last=%s>; rel="next"`,
+								tagListPageSize, page[len(page)-1]))
+						}
+					}
+					w.Header().Set("Content-Type", "application/json")
+					g.Expect(json.NewEncoder(w).Encode(map[string]interface{}{"name": "podinfo", "tags": page})).To(Succeed())
+					return
+				}
+				w.WriteHeader(http.StatusOK)
+			}))
+			t.Cleanup(srv.Close)
+
+			got, truncated, err := listTags(strings.TrimPrefix(srv.URL, "http://")+"/podinfo",
+				remoteOptions{craneOpts: []crane.Option{crane.Insecure}})
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(got).To(HaveLen(tt.tags))
+			g.Expect(truncated).To(Equal(tt.wantTruncated))
+		})
+	}
+}
+
+func TestOCIRepository_getTagBySemver(t *testing.T) {
+	tags := []string{"1.0.0-rc.1", "1.0.0", "1.1.0", "2.0.0-rc.1", "latest"}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.URL.Path == "/v2/podinfo/tags/list" {
+			w.Header().Set("Content-Type", "application/json")
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{"name": "podinfo", "tags": tags})
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	t.Cleanup(srv.Close)
+
+	tests := []struct {
+		name             string
+		semver           string
+		ignorePrerelease bool
+		want             string
+		wantErr          string
+	}{
+		{
+			name:   "stable range",
+			semver: ">=1.0.0",
+			want:   "1.1.0",
+		},
+		{
+			name:   "prerelease range",
+			semver: ">=1.0.0-0",
+			want:   "2.0.0-rc.1",
+		},
+		{
+			name:             "prerelease range ignoring prereleases",
+			semver:           ">=1.0.0-0",
+			ignorePrerelease: true,
+			want:             "1.1.0",
+		},
+		{
+			name:             "referenced prerelease",
+			semver:           ">=2.0.0-0",
+			ignorePrerelease: true,
+			want:             "2.0.0-rc.1",
+		},
+		{
+			name:             "no match ignoring prereleases",
+			semver:           ">=1.2.0-0",
+			ignorePrerelease: true,
+			wantErr:          "no match found for semver: >=1.2.0-0, available tags: 2.0.0-rc.1, 1.1.0, 1.0.0, 1.0.0-rc.1, latest",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			r := &OCIRepositoryReconciler{}
+			got, _, err := r.getTagBySemver(strings.TrimPrefix(srv.URL, "http://")+"/podinfo",
+				tt.semver, tt.ignorePrerelease, remoteOptions{craneOpts: []crane.Option{crane.Insecure}})
+			if tt.wantErr != "" {
+				g.Expect(err).To(HaveOccurred())
+				g.Expect(err.Error()).To(ContainSubstring(tt.wantErr))
+				return
+			}
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(got).To(Equal(tt.want))
+		})
+	}
+}
+
+func TestOCIRepository_summarizeLayers(t *testing.T) {
+	layer := func(i int, mediaType string) gcrv1.Descriptor {
+		return gcrv1.Descriptor{
+			MediaType: gcrtypes.MediaType(mediaType),
+			Size:      int64(100 * (i + 1)),
+			Digest:    gcrv1.Hash{Algorithm: "sha256", Hex: fmt.Sprintf("%064d", i)},
+		}
+	}
+	manyLayers := make([]gcrv1.Descriptor, maxSummarizedLayers+2)
+	for i := range manyLayers {
+		manyLayers[i] = layer(i, "application/vnd.cncf.flux.content.v1.tar+gzip")
+	}
+
+	tests := []struct {
+		name     string
+		layers   []gcrv1.Descriptor
+		selected []gcrv1.Hash
+		want     string
+	}{
+		{
+			name: "no layers",
+			want: "none",
+		},
+		{
+			name:     "selected layer",
+			layers:   []gcrv1.Descriptor{layer(0, helmreg.ConfigMediaType), layer(1, helmreg.ChartLayerMediaType)},
+			selected: []gcrv1.Hash{layer(1, "").Digest},
+			want: "layer[0] 'application/vnd.cncf.helm.config.v1+json' (100 bytes), " +
+				"layer[1] 'application/vnd.cncf.helm.chart.content.v1.tar+gzip' (200 bytes, selected)",
+		},
+		{
+			name:   "truncated",
+			layers: manyLayers,
+			want: "layer[0] 'application/vnd.cncf.flux.content.v1.tar+gzip' (100 bytes), " +
+				"layer[1] 'application/vnd.cncf.flux.content.v1.tar+gzip' (200 bytes), " +
+				"layer[2] 'application/vnd.cncf.flux.content.v1.tar+gzip' (300 bytes), " +
+				"layer[3] 'application/vnd.cncf.flux.content.v1.tar+gzip' (400 bytes), " +
+				"layer[4] 'application/vnd.cncf.flux.content.v1.tar+gzip' (500 bytes), " +
+				"layer[5] 'application/vnd.cncf.flux.content.v1.tar+gzip' (600 bytes), " +
+				"layer[6] 'application/vnd.cncf.flux.content.v1.tar+gzip' (700 bytes), " +
+				"layer[7] 'application/vnd.cncf.flux.content.v1.tar+gzip' (800 bytes), " +
+				"layer[8] 'application/vnd.cncf.flux.content.v1.tar+gzip' (900 bytes), " +
+				"layer[9] 'application/vnd.cncf.flux.content.v1.tar+gzip' (1000 bytes) (and 2 more)",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+			g.Expect(summarizeLayers(tt.layers, tt.selected)).To(Equal(tt.want))
+		})
+	}
+}
+
+func TestOCIRepository_layerSelectionReason(t *testing.T) {
+	layers := func(mediaTypes ...string) *gcrv1.Manifest {
+		m := &gcrv1.Manifest{}
+		for _, mt := range mediaTypes {
+			m.Layers = append(m.Layers, gcrv1.Descriptor{MediaType: gcrtypes.MediaType(mt)})
+		}
+		return m
+	}
+
+	tests := []struct {
+		name     string
+		selector *sourcev1.OCILayerSelector
+		manifest *gcrv1.Manifest
+		want     string
+	}{
+		{
+			name:     "media type selector",
+			selector: &sourcev1.OCILayerSelector{MediaType: "application/zip"},
+			manifest: layers("application/zip", "application/json"),
+			want:     "matching the layer selector media type 'application/zip'",
+		},
+		{
+			name:     "single layer",
+			manifest: layers("application/zip"),
+			want:     "as the artifact has a single layer",
+		},
+		{
+			name:     "Helm chart",
+			manifest: layers(helmreg.ChartLayerMediaType, helmreg.ProvLayerMediaType),
+			want:     "with the Helm chart media type",
+		},
+		{
+			name:     "gzip compressed tarballs",
+			manifest: layers("application/vnd.cncf.flux.content.v1.tar+gzip", "application/json"),
+			want:     "as the gzip compressed tarballs of the artifact",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			obj := &sourcev1.OCIRepository{Spec: sourcev1.OCIRepositorySpec{LayerSelector: tt.selector}}
+			g.Expect(layerSelectionReason(obj, tt.manifest)).To(Equal(tt.want))
+		})
+	}
+}
+
+func TestOCIRepository_summarizeTags(t *testing.T) {
+	manyTags := make([]string, maxSummarizedTags+5)
+	for i := range manyTags {
+		manyTags[i] = fmt.Sprintf("1.0.%d", i)
+	}
+
+	tests := []struct {
+		name string
+		tags []string
+		want string
+	}{
+		{
+			name: "no tags",
+			want: "none",
+		},
+		{
+			name: "versions before other tags",
+			tags: []string{"main", "v1.0.0", "latest", "v1.10.0", "v1.2.0"},
+			want: "v1.10.0, v1.2.0, v1.0.0, main, latest",
+		},
+		{
+			name: "truncated",
+			tags: manyTags,
+			want: "1.0.14, 1.0.13, 1.0.12, 1.0.11, 1.0.10, 1.0.9, 1.0.8, 1.0.7, 1.0.6, 1.0.5 (and 5 more)",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+			g.Expect(summarizeTags(tt.tags)).To(Equal(tt.want))
+		})
+	}
+}
+
+func TestOCIRepository_latestVersion(t *testing.T) {
+	tests := []struct {
+		name     string
+		versions []string
+		want     string
+	}{
+		{
+			name:     "highest precedence",
+			versions: []string{"1.0.0", "1.2.0", "1.1.0"},
+			want:     "1.2.0",
+		},
+		{
+			name:     "numeric build metadata",
+			versions: []string{"1.0.0+1", "1.0.0+10", "1.0.0+2"},
+			want:     "1.0.0+10",
+		},
+		{
+			name:     "numeric build metadata in any order",
+			versions: []string{"1.0.0+10", "1.0.0+2", "1.0.0+1"},
+			want:     "1.0.0+10",
+		},
+		{
+			name:     "build metadata over none",
+			versions: []string{"1.0.0+1", "1.0.0"},
+			want:     "1.0.0+1",
+		},
+		{
+			name:     "alphanumeric over numeric build metadata",
+			versions: []string{"1.0.0+build", "1.0.0+20"},
+			want:     "1.0.0+build",
+		},
+		{
+			name:     "more build identifiers",
+			versions: []string{"1.0.0+build.5", "1.0.0+build", "1.0.0+build.10"},
+			want:     "1.0.0+build.10",
+		},
+		{
+			name:     "precedence over build metadata",
+			versions: []string{"1.0.1", "1.0.0+10"},
+			want:     "1.0.1",
+		},
+		{
+			name:     "equal versions by original tag",
+			versions: []string{"1.0.0", "v1.0.0"},
+			want:     "v1.0.0",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			var versions []*semver.Version
+			for _, v := range tt.versions {
+				versions = append(versions, semver.MustParse(v))
+			}
+			g.Expect(latestVersion(versions).Original()).To(Equal(tt.want))
+		})
+	}
+}
+
+func TestOCIRepository_ociProvider(t *testing.T) {
+	tests := []struct {
+		name        string
+		annotations map[string]string
+		provider    string
+		noNamespace bool
+		want        string
+		wantErr     string
+	}{
+		{
+			name: "no provider and no namespace annotation",
+			want: sourcev1.GenericOCIProvider,
+		},
 		{
-			name:       "Artifact already present, unobserved layer selector, rebuild artifact",
-			targetPath: "testdata/oci/repository",
-			artifact: &sourcev1.Artifact{
-				Revision: "revision",
-			},
-			beforeFunc: func(obj *sourcev1.OCIRepository) {
-				obj.Spec.LayerSelector = &sourcev1.OCILayerSelector{MediaType: "foo"}
-				obj.Status.Artifact = &sourcev1.Artifact{Revision: "revision"}
-			},
-			want: sreconcile.ResultSuccess,
-			assertPaths: []string{
-				"latest.tar.gz",
-			},
-			afterFunc: func(g *WithT, obj *sourcev1.OCIRepository) {
-				g.Expect(obj.Status.ObservedLayerSelector.MediaType).To(Equal("foo"))
-			},
-			assertConditions: []metav1.Condition{
-				*conditions.TrueCondition(sourcev1.ArtifactInStorageCondition, meta.SucceededReason, "stored artifact for digest"),
-			},
+			name:        "no provider and no namespace",
+			noNamespace: true,
+			want:        sourcev1.GenericOCIProvider,
 		},
 		{
-			name:       "Artifact already present, observed layer selector changed, rebuild artifact",
-			targetPath: "testdata/oci/repository",
-			artifact: &sourcev1.Artifact{
-				Revision: "revision",
-				Path:     "foo.txt",
-			},
-			beforeFunc: func(obj *sourcev1.OCIRepository) {
-				obj.Spec.LayerSelector = &sourcev1.OCILayerSelector{
-					MediaType: "foo",
-					Operation: sourcev1.OCILayerCopy,
-				}
-				obj.Status.Artifact = &sourcev1.Artifact{Revision: "revision"}
-			},
-			want: sreconcile.ResultSuccess,
-			assertPaths: []string{
-				"latest.tar.gz",
-			},
-			afterFunc: func(g *WithT, obj *sourcev1.OCIRepository) {
-				g.Expect(obj.Status.ObservedLayerSelector.MediaType).To(Equal("foo"))
-				g.Expect(obj.Status.ObservedLayerSelector.Operation).To(Equal(sourcev1.OCILayerCopy))
-			},
-			assertConditions: []metav1.Condition{
-				*conditions.TrueCondition(sourcev1.ArtifactInStorageCondition, meta.SucceededReason, "stored artifact for digest"),
-			},
+			name:     "provider without namespace annotation",
+			provider: sourcev1.AzureOCIProvider,
+			want:     sourcev1.AzureOCIProvider,
 		},
 		{
-			name:       "Artifact already present, observed ignore and layer selector, up-to-date",
-			targetPath: "testdata/oci/repository",
-			artifact: &sourcev1.Artifact{
-				Revision: "revision",
-			},
-			beforeFunc: func(obj *sourcev1.OCIRepository) {
-				obj.Spec.Ignore = pointer.String("aaa")
-				obj.Spec.LayerSelector = &sourcev1.OCILayerSelector{MediaType: "foo"}
-				obj.Status.Artifact = &sourcev1.Artifact{Revision: "revision"}
-				obj.Status.ObservedIgnore = pointer.String("aaa")
-				obj.Status.ObservedLayerSelector = &sourcev1.OCILayerSelector{MediaType: "foo"}
-			},
-			want: sreconcile.ResultSuccess,
-			assertArtifact: &sourcev1.Artifact{
-				Revision: "revision",
+			name: "namespace annotation without provider",
+			annotations: map[string]string{
+				sourcev1.OCIProviderAnnotation: sourcev1.AmazonOCIProvider,
 			},
-			assertConditions: []metav1.Condition{
-				*conditions.TrueCondition(sourcev1.ArtifactInStorageCondition, meta.SucceededReason, "stored artifact for digest"),
+			want: sourcev1.AmazonOCIProvider,
+		},
+		{
+			name: "provider overrides namespace annotation",
+			annotations: map[string]string{
+				sourcev1.OCIProviderAnnotation: sourcev1.AmazonOCIProvider,
 			},
+			provider: sourcev1.GoogleOCIProvider,
+			want:     sourcev1.GoogleOCIProvider,
 		},
 		{
-			name:       "target path doesn't exist",
-			targetPath: "testdata/oci/non-existent",
-			want:       sreconcile.ResultEmpty,
-			wantErr:    true,
-			assertConditions: []metav1.Condition{
-				*conditions.TrueCondition(sourcev1.StorageOperationFailedCondition, sourcev1.StatOperationFailedReason, "failed to stat source path: "),
+			name: "generic provider overrides namespace annotation",
+			annotations: map[string]string{
+				sourcev1.OCIProviderAnnotation: sourcev1.AmazonOCIProvider,
 			},
+			provider: sourcev1.GenericOCIProvider,
+			want:     sourcev1.GenericOCIProvider,
 		},
 		{
-			name:       "target path is a file",
-			targetPath: "testdata/oci/repository/foo.txt",
-			want:       sreconcile.ResultEmpty,
-			wantErr:    true,
-			assertConditions: []metav1.Condition{
-				*conditions.TrueCondition(sourcev1.StorageOperationFailedCondition, sourcev1.InvalidPathReason, "source path 'testdata/oci/repository/foo.txt' is not a directory"),
+			name: "unsupported namespace annotation",
+			annotations: map[string]string{
+				sourcev1.OCIProviderAnnotation: "invalid",
 			},
+			wantErr: "unsupported provider 'invalid'",
 		},
 	}
 
-	builder := fakeclient.NewClientBuilder().WithScheme(testEnv.GetScheme())
-
-	r := &OCIRepositoryReconciler{
-		Client:        builder.Build(),
-		EventRecorder: record.NewFakeRecorder(32),
-		Storage:       testStorage,
-		patchOptions:  getPatchOptions(ociRepositoryReadyCondition.Owned, "sc"),
-	}
-
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			g := NewWithT(t)
 
-			resetChmod(tt.targetPath, 0o755, 0o644)
-
-			obj := &sourcev1.OCIRepository{
-				ObjectMeta: metav1.ObjectMeta{
-					GenerateName: "reconcile-artifact-",
-					Generation:   1,
-				},
-			}
-			if tt.beforeFunc != nil {
-				tt.beforeFunc(obj)
-			}
-
-			artifact := &sourcev1.Artifact{}
-			if tt.artifact != nil {
-				artifact = tt.artifact
+			builder := fakeclient.NewClientBuilder().WithScheme(testEnv.GetScheme())
+			if !tt.noNamespace {
+				builder.WithObjects(&corev1.Namespace{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:        "oci-provider",
+						Annotations: tt.annotations,
+					},
+				})
 			}
 
-			g.Expect(r.Client.Create(ctx, obj)).ToNot(HaveOccurred())
-			defer func() {
-				g.Expect(r.Client.Delete(ctx, obj)).ToNot(HaveOccurred())
-			}()
-
-			sp := patch.NewSerialPatcher(obj, r.Client)
-
-			got, err := r.reconcileArtifact(ctx, sp, obj, artifact, tt.targetPath)
-			if tt.wantErr {
+			got, err := ociProvider(ctx, builder.Build(), "oci-provider", tt.provider)
+			if tt.wantErr != "" {
 				g.Expect(err).To(HaveOccurred())
-			} else {
-				g.Expect(err).ToNot(HaveOccurred())
+				g.Expect(err.Error()).To(ContainSubstring(tt.wantErr))
+				return
 			}
-
+			g.Expect(err).ToNot(HaveOccurred())
 			g.Expect(got).To(Equal(tt.want))
-			g.Expect(obj.Status.Conditions).To(conditions.MatchConditions(tt.assertConditions))
-
-			if tt.assertArtifact != nil {
-				g.Expect(obj.Status.Artifact).To(MatchArtifact(tt.artifact))
-			}
-
-			if tt.afterFunc != nil {
-				tt.afterFunc(g, obj)
-			}
-
-			for _, path := range tt.assertPaths {
-				localPath := testStorage.LocalPath(*obj.GetArtifact())
-				path = filepath.Join(filepath.Dir(localPath), path)
-				_, err := os.Lstat(path)
-				g.Expect(err).ToNot(HaveOccurred())
-			}
 		})
 	}
 }
 
-func TestOCIRepository_getArtifactURL(t *testing.T) {
+func TestOCIRepository_remoteOptionsFor_namespaceProvider(t *testing.T) {
 	g := NewWithT(t)
 
-	tmpDir := t.TempDir()
-	server, err := setupRegistryServer(ctx, tmpDir, registryOptions{})
+	namespace := &corev1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "oci-provider",
+			Annotations: map[string]string{sourcev1.OCIProviderAnnotation: "invalid"},
+		},
+	}
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "auth",
+			Namespace: namespace.Name,
+		},
+		Type: corev1.SecretTypeDockerConfigJson,
+		Data: map[string][]byte{
+			corev1.DockerConfigJsonKey: []byte(`{"auths":{"ghcr.io":{"username":"user","password":"pass"}}}`),
+		},
+	}
+	r := &OCIRepositoryReconciler{
+		Client: fakeclient.NewClientBuilder().WithScheme(testEnv.GetScheme()).
+			WithObjects(namespace, secret).Build(),
+		EventRecorder: record.NewFakeRecorder(32),
+	}
+	obj := &sourcev1.OCIRepository{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "podinfo",
+			Namespace: namespace.Name,
+		},
+		Spec: sourcev1.OCIRepositorySpec{
+			URL: "oci://ghcr.io/stefanprodan/manifests/podinfo",
+		},
+	}
+
+	// Without static credentials, the provider of the namespace is used
+	_, err := r.remoteOptionsFor(ctx, obj)
+	g.Expect(err).To(HaveOccurred())
+	g.Expect(err.Error()).To(ContainSubstring("unsupported provider 'invalid'"))
+
+	// The generic provider set on the object takes precedence
+	obj.Spec.Provider = sourcev1.GenericOCIProvider
+	_, err = r.remoteOptionsFor(ctx, obj)
 	g.Expect(err).ToNot(HaveOccurred())
+	obj.Spec.Provider = ""
 
-	imgs, err := pushMultiplePodinfoImages(server.registryHost, "6.1.4", "6.1.5", "6.1.6")
+	// The namespace is not looked up for static credentials
+	obj.Spec.SecretRef = &meta.LocalObjectReference{Name: secret.Name}
+	_, err = r.remoteOptionsFor(ctx, obj)
 	g.Expect(err).ToNot(HaveOccurred())
+}
+
+func TestOCIRepository_observeCredentialExpiry(t *testing.T) {
+	g := NewWithT(t)
+
+	obj := &sourcev1.OCIRepository{}
+
+	// Warns when the credentials start to expire
+	g.Expect(observeCredentialExpiry(obj, "registry credential expires in 10m0s")).To(BeTrue())
+	g.Expect(conditions.IsTrue(obj, sourcev1.CredentialExpiringCondition)).To(BeTrue())
+
+	// Does not warn again while they are expiring
+	g.Expect(observeCredentialExpiry(obj, "registry credential expires in 5m0s")).To(BeFalse())
+	g.Expect(conditions.GetMessage(obj, sourcev1.CredentialExpiringCondition)).To(Equal("registry credential expires in 5m0s"))
+
+	// Warns again once renewed credentials start to expire
+	g.Expect(observeCredentialExpiry(obj, "")).To(BeFalse())
+	g.Expect(conditions.Has(obj, sourcev1.CredentialExpiringCondition)).To(BeFalse())
+	g.Expect(observeCredentialExpiry(obj, "registry credential expires in 10m0s")).To(BeTrue())
+}
+
+func TestOCIRepository_credentialExpiryWarning(t *testing.T) {
+	jwtExpiringIn := func(d time.Duration) string {
+		return strings.Join([]string{
+			base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"RS256","typ":"JWT"}`)),
+			base64.RawURLEncoding.EncodeToString([]byte(fmt.Sprintf(`{"exp":%d}`, time.Now().Add(d).Unix()))),
+			"signature",
+		}, ".")
+	}
 
 	tests := []struct {
-		name      string
-		url       string
-		reference *sourcev1.OCIRepositoryRef
-		wantErr   bool
-		want      string
+		name     string
+		keychain authn.Keychain
+		auth     authn.Authenticator
+		window   time.Duration
+		want     string
 	}{
 		{
-			name: "valid url with no reference",
-			url:  "oci://ghcr.io/stefanprodan/charts",
-			want: "ghcr.io/stefanprodan/charts",
+			name:   "disabled",
+			auth:   &authn.Basic{Username: "flux", Password: jwtExpiringIn(time.Minute)},
+			window: 0,
 		},
 		{
-			name: "valid url with tag reference",
-			url:  "oci://ghcr.io/stefanprodan/charts",
-			reference: &sourcev1.OCIRepositoryRef{
-				Tag: "6.1.6",
-			},
-			want: "ghcr.io/stefanprodan/charts:6.1.6",
+			name:   "unknown expiry",
+			auth:   &authn.Basic{Username: "flux", Password: "password"},
+			window: time.Hour,
 		},
 		{
-			name: "valid url with digest reference",
-			url:  "oci://ghcr.io/stefanprodan/charts",
-			reference: &sourcev1.OCIRepositoryRef{
-				Digest: imgs["6.1.6"].digest.Hex,
-			},
-			want: "ghcr.io/stefanprodan/charts@" + imgs["6.1.6"].digest.Hex,
+			name:   "expires after window",
+			auth:   &authn.Basic{Username: "flux", Password: jwtExpiringIn(2 * time.Hour)},
+			window: time.Hour,
 		},
 		{
-			name: "valid url with semver reference",
-			url:  fmt.Sprintf("oci://%s/podinfo", server.registryHost),
-			reference: &sourcev1.OCIRepositoryRef{
-				SemVer: ">= 6.1.6",
-			},
-			want: server.registryHost + "/podinfo:6.1.6",
+			name:   "expires within window",
+			auth:   &authn.Basic{Username: "flux", Password: jwtExpiringIn(30 * time.Minute)},
+			window: time.Hour,
+			want:   "registry credential expires in",
 		},
 		{
-			name:    "invalid url without oci prefix",
-			url:     "ghcr.io/stefanprodan/charts",
-			wantErr: true,
+			name:   "expired",
+			auth:   &authn.Basic{Username: "flux", Password: jwtExpiringIn(-time.Minute)},
+			window: time.Hour,
+			want:   "registry credential expired at",
+		},
+		{
+			name: "keychain expires within window",
+			keychain: authn.NewKeychainFromHelper(staticHelper{
+				username: "flux", secret: jwtExpiringIn(30 * time.Minute),
+			}),
+			window: time.Hour,
+			want:   "registry credential expires in",
+		},
+		{
+			name:     "anonymous keychain",
+			keychain: soci.Anonymous{},
+			window:   time.Hour,
 		},
 	}
-
-	builder := fakeclient.NewClientBuilder().WithScheme(testEnv.GetScheme())
-	r := &OCIRepositoryReconciler{
-		Client:        builder.Build(),
-		EventRecorder: record.NewFakeRecorder(32),
-		Storage:       testStorage,
-		patchOptions:  getPatchOptions(ociRepositoryReadyCondition.Owned, "sc"),
-	}
-
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			obj := &sourcev1.OCIRepository{
-				ObjectMeta: metav1.ObjectMeta{
-					GenerateName: "artifact-url-",
-				},
-				Spec: sourcev1.OCIRepositorySpec{
-					URL:      tt.url,
-					Interval: metav1.Duration{Duration: interval},
-					Timeout:  &metav1.Duration{Duration: timeout},
-				},
-			}
-
-			if tt.reference != nil {
-				obj.Spec.Reference = tt.reference
-			}
+			g := NewWithT(t)
 
-			opts := craneOptions(ctx, true)
-			opts = append(opts, crane.WithAuthFromKeychain(authn.DefaultKeychain))
-			got, err := r.getArtifactURL(obj, opts)
-			if tt.wantErr {
-				g.Expect(err).To(HaveOccurred())
+			got := credentialExpiryWarning("oci://ghcr.io/stefanprodan/manifests/podinfo", tt.keychain, tt.auth, tt.window)
+			if tt.want == "" {
+				g.Expect(got).To(BeEmpty())
 				return
 			}
-			g.Expect(err).ToNot(HaveOccurred())
-			g.Expect(got).To(Equal(tt.want))
+			g.Expect(got).To(HavePrefix(tt.want))
 		})
 	}
 }
 
+// staticHelper is an authn.Helper returning static credentials.
+type staticHelper struct {
+	username, secret string
+}
+
+func (h staticHelper) Get(string) (string, string, error) {
+	return h.username, h.secret, nil
+}
+
 func TestOCIRepository_stalled(t *testing.T) {
 	g := NewWithT(t)
 
@@ -2367,12 +6198,39 @@ func TestOCIContentConfigChanged(t *testing.T) {
 			want: false,
 		},
 		{
-			name: "different ignore, no layer selector",
+			name: "different ignore, no layer selector",
+			spec: sourcev1.OCIRepositorySpec{
+				Ignore: pointer.String("nnn"),
+			},
+			status: sourcev1.OCIRepositoryStatus{
+				ObservedIgnore: pointer.String("mmm"),
+			},
+			want: true,
+		},
+		{
+			name: "same include",
+			spec: sourcev1.OCIRepositorySpec{
+				Include: pointer.String("**/*.yaml"),
+			},
+			status: sourcev1.OCIRepositoryStatus{
+				ObservedInclude: pointer.String("**/*.yaml"),
+			},
+			want: false,
+		},
+		{
+			name: "different include",
+			spec: sourcev1.OCIRepositorySpec{
+				Include: pointer.String("**/*.yaml"),
+			},
+			status: sourcev1.OCIRepositoryStatus{
+				ObservedInclude: pointer.String("**/*.json"),
+			},
+			want: true,
+		},
+		{
+			name: "include added",
 			spec: sourcev1.OCIRepositorySpec{
-				Ignore: pointer.String("nnn"),
-			},
-			status: sourcev1.OCIRepositoryStatus{
-				ObservedIgnore: pointer.String("mmm"),
+				Include: pointer.String("**/*.yaml"),
 			},
 			want: true,
 		},
@@ -2462,6 +6320,116 @@ func TestOCIContentConfigChanged(t *testing.T) {
 			},
 			want: true,
 		},
+		{
+			name: "same expected paths",
+			spec: sourcev1.OCIRepositorySpec{
+				ExpectedPaths: []string{"foo/*", "bar"},
+			},
+			status: sourcev1.OCIRepositoryStatus{
+				ObservedExpectedPaths: []string{"foo/*", "bar"},
+			},
+			want: false,
+		},
+		{
+			name: "different expected paths",
+			spec: sourcev1.OCIRepositorySpec{
+				ExpectedPaths: []string{"foo/*"},
+			},
+			status: sourcev1.OCIRepositoryStatus{
+				ObservedExpectedPaths: []string{"foo/*", "bar"},
+			},
+			want: true,
+		},
+		{
+			name: "different metadata keys",
+			spec: sourcev1.OCIRepositorySpec{
+				MetadataKeys: []string{oci.SourceAnnotation},
+			},
+			want: true,
+		},
+		{
+			name: "default platform observed",
+			status: sourcev1.OCIRepositoryStatus{
+				ObservedPlatform: &sourcev1.OCIPlatform{OS: "linux", Architecture: "amd64", Variant: "v3"},
+			},
+			want: false,
+		},
+		{
+			name: "same platform",
+			spec: sourcev1.OCIRepositorySpec{
+				Platform: &sourcev1.OCIPlatform{OS: "linux", Architecture: "arm64"},
+			},
+			status: sourcev1.OCIRepositoryStatus{
+				ObservedPlatform: &sourcev1.OCIPlatform{OS: "linux", Architecture: "arm64", Variant: "v8"},
+			},
+			want: false,
+		},
+		{
+			name: "different platform",
+			spec: sourcev1.OCIRepositorySpec{
+				Platform: &sourcev1.OCIPlatform{OS: "linux", Architecture: "arm64"},
+			},
+			status: sourcev1.OCIRepositoryStatus{
+				ObservedPlatform: &sourcev1.OCIPlatform{OS: "linux", Architecture: "amd64"},
+			},
+			want: true,
+		},
+		{
+			name: "platform of single manifest not observed",
+			spec: sourcev1.OCIRepositorySpec{
+				Platform: &sourcev1.OCIPlatform{OS: "linux", Architecture: "arm64"},
+			},
+			want: false,
+		},
+		{
+			name: "gzip compression not observed",
+			spec: sourcev1.OCIRepositorySpec{
+				ArtifactCompression: sourcev1.OCIArtifactCompressionGzip,
+			},
+			want: false,
+		},
+		{
+			name: "different compression",
+			spec: sourcev1.OCIRepositorySpec{
+				ArtifactCompression: sourcev1.OCIArtifactCompressionNone,
+			},
+			status: sourcev1.OCIRepositoryStatus{
+				ObservedArtifactCompression: sourcev1.OCIArtifactCompressionGzip,
+			},
+			want: true,
+		},
+		{
+			name: "stored modification time not observed",
+			spec: sourcev1.OCIRepositorySpec{
+				ArtifactModTime: sourcev1.OCIArtifactModTimeStored,
+			},
+			want: false,
+		},
+		{
+			name: "different modification time",
+			spec: sourcev1.OCIRepositorySpec{
+				ArtifactModTime: sourcev1.OCIArtifactModTimeCreated,
+			},
+			status: sourcev1.OCIRepositoryStatus{
+				ObservedArtifactModTime: sourcev1.OCIArtifactModTimeStored,
+			},
+			want: true,
+		},
+		{
+			name: "different compression with copy operation",
+			spec: sourcev1.OCIRepositorySpec{
+				LayerSelector: &sourcev1.OCILayerSelector{
+					Operation: sourcev1.OCILayerCopy,
+				},
+				ArtifactCompression: sourcev1.OCIArtifactCompressionNone,
+			},
+			status: sourcev1.OCIRepositoryStatus{
+				ObservedLayerSelector: &sourcev1.OCILayerSelector{
+					Operation: sourcev1.OCILayerCopy,
+				},
+			},
+			want: false,
+		},
 	}
 
 	for _, tt := range tests {
@@ -2477,3 +6445,366 @@ func TestOCIContentConfigChanged(t *testing.T) {
 		})
 	}
 }
+
+func TestOCIRepository_unexpectedPaths(t *testing.T) {
+	files := []string{
+		"README.md",
+		"deploy/app.yaml",
+		"deploy/overlays/prod/kustomization.yaml",
+		"scripts/run.sh",
+	}
+
+	tests := []struct {
+		name     string
+		patterns []string
+		want     []string
+		wantErr  bool
+	}{
+		{
+			name:     "all files match",
+			patterns: []string{"*.md", "deploy", "scripts/*.sh"},
+		},
+		{
+			name:     "directory pattern allows nested files",
+			patterns: []string{"deploy/*", "*"},
+		},
+		{
+			name:     "unexpected files",
+			patterns: []string{"deploy/*.yaml", "README.md"},
+			want:     []string{"deploy/overlays/prod/kustomization.yaml", "scripts/run.sh"},
+		},
+		{
+			name:     "leading slash",
+			patterns: []string{"/README.md", "/deploy", "/scripts"},
+		},
+		{
+			name:     "invalid pattern",
+			patterns: []string{"[-]"},
+			wantErr:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			dir := t.TempDir()
+			for _, f := range files {
+				p := filepath.Join(dir, f)
+				g.Expect(os.MkdirAll(filepath.Dir(p), 0o750)).To(Succeed())
+				g.Expect(os.WriteFile(p, []byte(f), 0o640)).To(Succeed())
+			}
+
+			got, err := unexpectedPaths(dir, tt.patterns)
+			if tt.wantErr {
+				g.Expect(err).To(HaveOccurred())
+				return
+			}
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(got).To(Equal(tt.want))
+		})
+	}
+}
+
+func TestOCIRepository_selectPath(t *testing.T) {
+	files := []string{
+		".sourceignore",
+		"README.md",
+		"deploy/app.yaml",
+		"deploy/overlays/prod/kustomization.yaml",
+		"scripts/run.sh",
+	}
+
+	tests := []struct {
+		name    string
+		path    string
+		want    []string
+		wantErr string
+	}{
+		{
+			name: "single file",
+			path: "deploy/overlays/prod/kustomization.yaml",
+			want: []string{".sourceignore", "deploy/overlays/prod/kustomization.yaml"},
+		},
+		{
+			name: "directory subtree",
+			path: "deploy",
+			want: []string{".sourceignore", "deploy/app.yaml", "deploy/overlays/prod/kustomization.yaml"},
+		},
+		{
+			name: "pattern",
+			path: "*/*.sh",
+			want: []string{".sourceignore", "scripts/run.sh"},
+		},
+		{
+			name: "leading and trailing slash",
+			path: "/deploy/overlays/",
+			want: []string{".sourceignore", "deploy/overlays/prod/kustomization.yaml"},
+		},
+		{
+			name:    "no match",
+			path:    "charts",
+			wantErr: "no files matching path 'charts' found",
+		},
+		{
+			name:    "invalid pattern",
+			path:    "[-]",
+			wantErr: "invalid path '[-]'",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			dir := t.TempDir()
+			for _, f := range files {
+				p := filepath.Join(dir, f)
+				g.Expect(os.MkdirAll(filepath.Dir(p), 0o750)).To(Succeed())
+				g.Expect(os.WriteFile(p, []byte(f), 0o640)).To(Succeed())
+			}
+
+			err := selectPath(dir, tt.path)
+			if tt.wantErr != "" {
+				g.Expect(err).To(HaveOccurred())
+				g.Expect(err.Error()).To(ContainSubstring(tt.wantErr))
+				return
+			}
+			g.Expect(err).ToNot(HaveOccurred())
+
+			var got []string
+			var dirs int
+			g.Expect(filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+				if err != nil || p == dir {
+					return err
+				}
+				if d.IsDir() {
+					if entries, err := os.ReadDir(p); err == nil && len(entries) == 0 {
+						dirs++
+					}
+					return nil
+				}
+				rel, err := filepath.Rel(dir, p)
+				got = append(got, filepath.ToSlash(rel))
+				return err
+			})).To(Succeed())
+			g.Expect(got).To(Equal(tt.want))
+			g.Expect(dirs).To(BeZero(), "expected no empty directories to be left")
+		})
+	}
+}
+
+func TestOCIRepository_selectedPathFilter(t *testing.T) {
+	g := NewWithT(t)
+
+	dir := t.TempDir()
+	for _, f := range []string{".sourceignore", "deploy/.sourceignore", "deploy/app.yaml", "deploy/app.bak"} {
+		p := filepath.Join(dir, f)
+		g.Expect(os.MkdirAll(filepath.Dir(p), 0o750)).To(Succeed())
+		g.Expect(os.WriteFile(p, []byte(f), 0o640)).To(Succeed())
+	}
+
+	filter := selectedPathFilter(dir, "/deploy/", func(p string, fi os.FileInfo) bool {
+		return strings.HasSuffix(p, ".bak")
+	})
+
+	var got []string
+	g.Expect(filepath.Walk(dir, func(p string, fi os.FileInfo, err error) error {
+		if err != nil || fi.IsDir() || filter(p, fi) {
+			return err
+		}
+		rel, err := filepath.Rel(dir, p)
+		got = append(got, filepath.ToSlash(rel))
+		return err
+	})).To(Succeed())
+	g.Expect(got).To(Equal([]string{"deploy/.sourceignore", "deploy/app.yaml"}))
+}
+
+func TestOCIRepository_formatPaths(t *testing.T) {
+	g := NewWithT(t)
+
+	g.Expect(formatPaths([]string{"a", "b"})).To(Equal("a, b"))
+
+	var paths []string
+	for i := 0; i < maxReportedPaths+3; i++ {
+		paths = append(paths, fmt.Sprintf("file-%d", i))
+	}
+	got := formatPaths(paths)
+	g.Expect(got).To(HavePrefix("file-0, file-1"))
+	g.Expect(got).To(HaveSuffix("file-9 and 3 more"))
+}
+
+func TestOCIRepository_artifactContentType(t *testing.T) {
+	tests := []struct {
+		name         string
+		files        map[string]string
+		operation    string
+		metadata     map[string]string
+		uncompressed bool
+		want         string
+	}{
+		{
+			name:  "extracted content",
+			files: map[string]string{"deploy/app.yaml": "kind: ConfigMap"},
+			want:  archive.ContentTypeTarGzip,
+		},
+		{
+			name:         "uncompressed extracted content",
+			files:        map[string]string{"deploy/app.yaml": "kind: ConfigMap"},
+			uncompressed: true,
+			want:         archive.ContentTypeTar,
+		},
+		{
+			name:  "extracted Helm chart",
+			files: map[string]string{"podinfo/Chart.yaml": "name: podinfo", "podinfo/values.yaml": ""},
+			want:  helmreg.ChartLayerMediaType,
+		},
+		{
+			name:      "copied Helm chart layer",
+			files:     map[string]string{"podinfo/Chart.yaml": "name: podinfo"},
+			operation: sourcev1.OCILayerCopy,
+			metadata:  map[string]string{sourcev1.OCILayerMediaTypeMetadataKey: helmreg.ChartLayerMediaType},
+			want:      helmreg.ChartLayerMediaType,
+		},
+		{
+			name:      "copied layer",
+			files:     map[string]string{"podinfo/Chart.yaml": "name: podinfo"},
+			operation: sourcev1.OCILayerCopy,
+			metadata:  map[string]string{sourcev1.OCILayerMediaTypeMetadataKey: "application/vnd.cncf.flux.content.v1.tar+gzip"},
+			want:      archive.ContentTypeTarGzip,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			dir := t.TempDir()
+			for name, content := range tt.files {
+				g.Expect(os.MkdirAll(filepath.Dir(filepath.Join(dir, name)), 0o700)).To(Succeed())
+				g.Expect(os.WriteFile(filepath.Join(dir, name), []byte(content), 0o600)).To(Succeed())
+			}
+
+			obj := &sourcev1.OCIRepository{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "content-type",
+					Namespace: "default",
+				},
+			}
+			if tt.operation != "" {
+				obj.Spec.LayerSelector = &sourcev1.OCILayerSelector{Operation: tt.operation}
+			}
+
+			artifact := testStorage.NewArtifactFor(sourcev1.OCIRepositoryKind, obj, "revision", "content.tar.gz")
+			g.Expect(testStorage.MkdirAll(artifact)).To(Succeed())
+			defer testStorage.RemoveAll(artifact)
+			if tt.uncompressed {
+				g.Expect(testStorage.ArchiveUncompressed(&artifact, dir, nil)).To(Succeed())
+			} else {
+				g.Expect(testStorage.Archive(&artifact, dir, nil)).To(Succeed())
+			}
+
+			r := &OCIRepositoryReconciler{Storage: testStorage}
+			got, err := r.artifactContentType(obj, artifact, dir, tt.metadata)
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(got).To(Equal(tt.want))
+		})
+	}
+}
+
+func TestOCIRepository_filterMetadata(t *testing.T) {
+	metadata := map[string]string{
+		oci.SourceAnnotation:   "https://github.com/stefanprodan/podinfo",
+		oci.RevisionAnnotation: "6.1.6/SHA",
+		ociCreatedAnnotation:   "2022-10-01T12:00:00Z",
+	}
+
+	tests := []struct {
+		name     string
+		metadata map[string]string
+		keys     []string
+		want     map[string]string
+	}{
+		{
+			name:     "no keys",
+			metadata: metadata,
+			want:     metadata,
+		},
+		{
+			name:     "selected keys",
+			metadata: metadata,
+			keys:     []string{oci.SourceAnnotation, oci.RevisionAnnotation, "missing"},
+			want: map[string]string{
+				oci.SourceAnnotation:   "https://github.com/stefanprodan/podinfo",
+				oci.RevisionAnnotation: "6.1.6/SHA",
+			},
+		},
+		{
+			name: "layer media type is retained",
+			metadata: map[string]string{
+				oci.SourceAnnotation:                  "https://github.com/stefanprodan/podinfo",
+				sourcev1.OCILayerMediaTypeMetadataKey: "application/zip",
+			},
+			keys: []string{oci.RevisionAnnotation},
+			want: map[string]string{
+				sourcev1.OCILayerMediaTypeMetadataKey: "application/zip",
+			},
+		},
+		{
+			name: "modification time is retained",
+			metadata: map[string]string{
+				oci.SourceAnnotation:           "https://github.com/stefanprodan/podinfo",
+				sourcev1.OCIModTimeMetadataKey: "2022-10-01T12:00:00Z",
+			},
+			keys: []string{oci.RevisionAnnotation},
+			want: map[string]string{
+				sourcev1.OCIModTimeMetadataKey: "2022-10-01T12:00:00Z",
+			},
+		},
+		{
+			name: "no metadata",
+			keys: []string{oci.SourceAnnotation},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+			g.Expect(filterMetadata(tt.metadata, tt.keys)).To(Equal(tt.want))
+		})
+	}
+}
+
+func TestOCIRepository_ociCreatedTime(t *testing.T) {
+	tests := []struct {
+		name        string
+		annotations map[string]string
+		want        time.Time
+	}{
+		{
+			name: "created annotation",
+			annotations: map[string]string{
+				ociCreatedAnnotation: "2022-10-01T12:00:00Z",
+			},
+			want: time.Date(2022, 10, 1, 12, 0, 0, 0, time.UTC),
+		},
+		{
+			name:        "no annotations",
+			annotations: nil,
+			want:        time.Unix(0, 0),
+		},
+		{
+			name: "invalid created annotation",
+			annotations: map[string]string{
+				ociCreatedAnnotation: "yesterday",
+			},
+			want: time.Unix(0, 0),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+			g.Expect(ociCreatedTime(tt.annotations).Equal(tt.want)).To(BeTrue())
+		})
+	}
+}
@@ -0,0 +1,137 @@
+/*
+Copyright 2022 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/google/go-containerregistry/pkg/authn/k8schain"
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	sourcev1 "github.com/fluxcd/source-controller/api/v1beta2"
+)
+
+const (
+	// artifactMirroredReason is the event reason used to announce the copy
+	// of an artifact to the mirror registry of an OCIRepository.
+	artifactMirroredReason = "ArtifactMirrored"
+	// artifactMirrorFailedReason is the event reason used to warn about a
+	// failure to copy an artifact to the mirror registry of an OCIRepository.
+	artifactMirrorFailedReason = "ArtifactMirrorFailed"
+)
+
+// mirrorArtifact copies the artifact with the given digest, pulled from the
+// given URL, to the mirror registry in the spec of the given object, if any.
+// The outcome is reported in an event, as a failure to copy the artifact does
+// not fail the reconciliation. The digest of a copied artifact is recorded in
+// the status of the object, so that a failed copy can be retried.
+func (r *OCIRepositoryReconciler) mirrorArtifact(ctx context.Context, obj *sourcev1.OCIRepository, url, digest string, opts remoteOptions) {
+	if obj.Spec.Mirror == nil {
+		obj.Status.MirroredDigest = ""
+		return
+	}
+
+	mirrorCtx, cancel := context.WithTimeout(ctx, obj.GetPullTimeout())
+	defer cancel()
+	dst, err := r.copyToMirror(mirrorCtx, obj, url, digest, opts.withContext(mirrorCtx))
+	if err != nil {
+		r.eventLogf(ctx, obj, corev1.EventTypeWarning, artifactMirrorFailedReason,
+			"failed to copy artifact '%s' to mirror '%s': %s", digest, obj.Spec.Mirror.URL, err)
+		return
+	}
+	obj.Status.MirroredDigest = digest
+	r.eventLogf(ctx, obj, corev1.EventTypeNormal, artifactMirroredReason,
+		"copied artifact '%s' to mirror '%s'", digest, dst)
+}
+
+// copyToMirror copies the artifact with the given digest from the repository
+// of the given URL to the mirror registry of the given object, and returns
+// the reference it was copied to. The artifact is copied with the tag of the
+// given URL, or by digest if the URL has no tag. An image index is copied
+// with all the manifests it refers to.
+func (r *OCIRepositoryReconciler) copyToMirror(ctx context.Context, obj *sourcev1.OCIRepository, url, digest string, opts remoteOptions) (string, error) {
+	var nameOpts []name.Option
+	if obj.Spec.Insecure {
+		nameOpts = append(nameOpts, name.Insecure)
+	}
+	ref, err := name.ParseReference(url, nameOpts...)
+	if err != nil {
+		return "", err
+	}
+	src := ref.Context().Digest(digest)
+
+	repo, err := name.NewRepository(strings.TrimPrefix(obj.Spec.Mirror.URL, sourcev1.OCIRepositoryPrefix), nameOpts...)
+	if err != nil {
+		return "", fmt.Errorf("invalid mirror URL: %w", err)
+	}
+	var dst name.Reference = repo.Digest(digest)
+	if tag := tagFromReference(ref); tag != "" {
+		dst = repo.Tag(tag)
+	}
+
+	// Write with the transport of the pull, which honors the TLS
+	// configuration and the proxy of the object, and retries server errors
+	dstOpts := []remote.Option{remote.WithContext(ctx)}
+	if opts.push != nil {
+		dstOpts = append(dstOpts, remote.WithTransport(opts.push))
+	}
+	if r.UserAgent != "" {
+		dstOpts = append(dstOpts, remote.WithUserAgent(r.UserAgent))
+	}
+	if secretRef := obj.Spec.Mirror.SecretRef; secretRef != nil {
+		var secret corev1.Secret
+		if err := r.Get(ctx, types.NamespacedName{Namespace: obj.Namespace, Name: secretRef.Name}, &secret); err != nil {
+			return "", fmt.Errorf("failed to get secret '%s': %w", secretRef.Name, err)
+		}
+		keychain, err := k8schain.NewFromPullSecrets(ctx, []corev1.Secret{secret})
+		if err != nil {
+			return "", fmt.Errorf("failed to read credentials from secret '%s': %w", secretRef.Name, err)
+		}
+		dstOpts = append(dstOpts, remote.WithAuthFromKeychain(keychain))
+	}
+
+	// Wait for the rate limit, giving up when it would not be lifted before
+	// the timeout of the copy
+	if r.MirrorRateLimiter != nil {
+		if err := r.MirrorRateLimiter.Wait(ctx); err != nil {
+			return "", fmt.Errorf("mirror rate limit exceeded: %w", err)
+		}
+	}
+
+	desc, err := remote.Get(src, opts.verifyOpts...)
+	if err != nil {
+		return "", fmt.Errorf("failed to get artifact manifest: %w", err)
+	}
+	if desc.MediaType.IsIndex() {
+		idx, err := desc.ImageIndex()
+		if err != nil {
+			return "", err
+		}
+		err = remote.WriteIndex(dst, idx, dstOpts...)
+		return dst.String(), err
+	}
+	img, err := desc.Image()
+	if err != nil {
+		return "", err
+	}
+	return dst.String(), remote.Write(dst, img, dstOpts...)
+}
@@ -0,0 +1,144 @@
+/*
+Copyright 2022 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/google/go-containerregistry/pkg/crane"
+	. "github.com/onsi/gomega"
+	"golang.org/x/time/rate"
+	"k8s.io/client-go/tools/record"
+	fakeclient "sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	sourcev1 "github.com/fluxcd/source-controller/api/v1beta2"
+	soci "github.com/fluxcd/source-controller/internal/oci"
+)
+
+func TestOCIRepository_copyToMirror(t *testing.T) {
+	g := NewWithT(t)
+
+	server, err := setupRegistryServer(ctx, t.TempDir(), registryOptions{})
+	g.Expect(err).ToNot(HaveOccurred())
+	podinfoVersions, err := pushMultiplePodinfoImages(server.registryHost, "6.1.4")
+	g.Expect(err).ToNot(HaveOccurred())
+	img := podinfoVersions["6.1.4"]
+	url := strings.TrimPrefix(img.url, sourcev1.OCIRepositoryPrefix)
+
+	r := &OCIRepositoryReconciler{
+		Client:        fakeclient.NewClientBuilder().WithScheme(testEnv.GetScheme()).Build(),
+		EventRecorder: record.NewFakeRecorder(32),
+	}
+	obj := &sourcev1.OCIRepository{
+		Spec: sourcev1.OCIRepositorySpec{
+			URL: img.url,
+			Mirror: &sourcev1.OCIRepositoryMirror{
+				URL: fmt.Sprintf("oci://%s/backup/podinfo", server.registryHost),
+			},
+		},
+	}
+	opts := makeRemoteOptions(ctx, obj, nil, soci.Anonymous{}, nil)
+
+	// An artifact pulled by tag is copied with the tag
+	dst, err := r.copyToMirror(ctx, obj, url+":6.1.4", img.digest.String(), opts)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(dst).To(Equal(server.registryHost + "/backup/podinfo:6.1.4"))
+	g.Expect(crane.Digest(dst)).To(Equal(img.digest.String()))
+
+	// An artifact pulled by digest is copied by digest
+	dst, err = r.copyToMirror(ctx, obj, url+"@"+img.digest.String(), img.digest.String(), opts)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(dst).To(Equal(server.registryHost + "/backup/podinfo@" + img.digest.String()))
+
+	// The copies are given up when the rate limit is not lifted in time
+	r.MirrorRateLimiter = rate.NewLimiter(rate.Every(time.Hour), 1)
+	_, err = r.copyToMirror(ctx, obj, url+":6.1.4", img.digest.String(), opts)
+	g.Expect(err).ToNot(HaveOccurred())
+	timeoutCtx, cancel := context.WithTimeout(ctx, time.Second)
+	defer cancel()
+	_, err = r.copyToMirror(timeoutCtx, obj, url+":6.1.4", img.digest.String(), opts)
+	g.Expect(err).To(MatchError(ContainSubstring("mirror rate limit exceeded")))
+}
+
+func TestOCIRepository_mirrorArtifact(t *testing.T) {
+	g := NewWithT(t)
+
+	recorder := record.NewFakeRecorder(32)
+	r := &OCIRepositoryReconciler{
+		Client:        fakeclient.NewClientBuilder().WithScheme(testEnv.GetScheme()).Build(),
+		EventRecorder: recorder,
+	}
+	obj := &sourcev1.OCIRepository{
+		Spec: sourcev1.OCIRepositorySpec{
+			URL: "oci://ghcr.io/stefanprodan/manifests/podinfo",
+			Mirror: &sourcev1.OCIRepositoryMirror{
+				URL: "oci://registry.example.com/Backup/podinfo",
+			},
+		},
+	}
+	opts := makeRemoteOptions(ctx, obj, nil, soci.Anonymous{}, nil)
+
+	// A failure to copy the artifact is only reported in an event, and
+	// keeps the previously mirrored digest
+	obj.Status.MirroredDigest = "sha256:0f1e2d3c4b5a69788796a5b4c3d2e1f00f1e2d3c4b5a69788796a5b4c3d2e1f0"
+	r.mirrorArtifact(ctx, obj, "ghcr.io/stefanprodan/manifests/podinfo:6.1.4",
+		"sha256:6c6d8c0d8f5c4c4e0a1e6e5a7b0e8f3b0b4d3f7f6a0a3e8c4c9b7a2d8e6f1c3b", opts)
+	g.Expect(recorder.Events).To(Receive(ContainSubstring("Warning ArtifactMirrorFailed failed to copy artifact")))
+	g.Expect(obj.Status.MirroredDigest).To(Equal("sha256:0f1e2d3c4b5a69788796a5b4c3d2e1f00f1e2d3c4b5a69788796a5b4c3d2e1f0"))
+
+	// Nothing is copied without a mirror
+	obj.Spec.Mirror = nil
+	r.mirrorArtifact(ctx, obj, "ghcr.io/stefanprodan/manifests/podinfo:6.1.4",
+		"sha256:6c6d8c0d8f5c4c4e0a1e6e5a7b0e8f3b0b4d3f7f6a0a3e8c4c9b7a2d8e6f1c3b", opts)
+	g.Expect(recorder.Events).ToNot(Receive())
+	g.Expect(obj.Status.MirroredDigest).To(BeEmpty())
+}
+
+func TestOCIRepository_mirrorArtifact_recordsDigest(t *testing.T) {
+	g := NewWithT(t)
+
+	server, err := setupRegistryServer(ctx, t.TempDir(), registryOptions{})
+	g.Expect(err).ToNot(HaveOccurred())
+	podinfoVersions, err := pushMultiplePodinfoImages(server.registryHost, "6.1.4")
+	g.Expect(err).ToNot(HaveOccurred())
+	img := podinfoVersions["6.1.4"]
+
+	recorder := record.NewFakeRecorder(32)
+	r := &OCIRepositoryReconciler{
+		Client:        fakeclient.NewClientBuilder().WithScheme(testEnv.GetScheme()).Build(),
+		EventRecorder: recorder,
+	}
+	obj := &sourcev1.OCIRepository{
+		Spec: sourcev1.OCIRepositorySpec{
+			URL: img.url,
+			Mirror: &sourcev1.OCIRepositoryMirror{
+				URL: fmt.Sprintf("oci://%s/backup/podinfo", server.registryHost),
+			},
+		},
+	}
+	opts := makeRemoteOptions(ctx, obj, nil, soci.Anonymous{}, nil)
+	opts.push = soci.NewRetryingTransport(nil, 2, time.Millisecond, logr.Discard())
+
+	r.mirrorArtifact(ctx, obj, strings.TrimPrefix(img.url, sourcev1.OCIRepositoryPrefix)+":6.1.4", img.digest.String(), opts)
+	g.Expect(recorder.Events).To(Receive(ContainSubstring("Normal ArtifactMirrored copied artifact")))
+	g.Expect(obj.Status.MirroredDigest).To(Equal(img.digest.String()))
+}
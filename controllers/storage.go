@@ -21,12 +21,17 @@ import (
 	"compress/gzip"
 	"context"
 	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"errors"
 	"fmt"
 	"hash"
 	"io"
 	"io/fs"
+	"net/http"
 	"net/url"
 	"os"
+	"path"
 	"path/filepath"
 	"sort"
 	"strings"
@@ -47,6 +52,37 @@ import (
 
 const GarbageCountLimit = 1000
 
+// DefaultGarbageCollectTimeout is the default timeout of the garbage
+// collection of the artifacts of an object.
+const DefaultGarbageCollectTimeout = 5 * time.Second
+
+// storageProbePath is the path, relative to the Storage.BasePath, of the file
+// written by Storage.ReadyCheck.
+const storageProbePath = ".probe/ready"
+
+// storageProbeLockTimeout is the maximum duration Storage.ReadyCheck waits
+// for the lock of its probe file.
+const storageProbeLockTimeout = 5 * time.Second
+
+const (
+	// lockRetryBaseDelay is the delay before the second attempt of
+	// Storage.LockWithContext, which is doubled for every further attempt.
+	lockRetryBaseDelay = 100 * time.Millisecond
+	// lockRetryMaxDelay is the maximum delay between two attempts of
+	// Storage.LockWithContext.
+	lockRetryMaxDelay = 5 * time.Second
+)
+
+const (
+	// ChecksumSHA256 is the default checksum algorithm of artifacts. For
+	// backwards compatibility, SHA256 checksums are recorded without an
+	// algorithm prefix.
+	ChecksumSHA256 = "sha256"
+	// ChecksumSHA512 is the SHA512 checksum algorithm of artifacts, recorded
+	// as '<algorithm>:<checksum>'.
+	ChecksumSHA512 = "sha512"
+)
+
 // Storage manages artifacts
 type Storage struct {
 	// BasePath is the local directory path where the source artifacts are stored.
@@ -62,6 +98,40 @@ type Storage struct {
 	// ArtifactRetentionRecords is the maximum number of artifacts to be kept in
 	// storage after a garbage collection.
 	ArtifactRetentionRecords int `json:"artifactRetentionRecords"`
+
+	// ArtifactPrefix is an optional cluster or tenant identifier prepended to
+	// the paths of new artifacts, to keep them unique when the storage is
+	// shared across clusters.
+	ArtifactPrefix string `json:"artifactPrefix,omitempty"`
+
+	// ShardArtifacts enables the sharded layout of new artifacts, which
+	// stores them under a hash prefix directory to limit the number of
+	// entries per directory: '<shard>/<kind>/<namespace>/<name>/' instead of
+	// '<kind>/<namespace>/<name>/'. Artifacts in the other layout are still
+	// served, until they expire after the ArtifactRetentionTTL.
+	ShardArtifacts bool `json:"shardArtifacts,omitempty"`
+
+	// LockAttempts is the number of attempts made by LockWithContext to
+	// create the lock of an artifact when it fails, with an exponential
+	// backoff between them. A lock held by another process is not counted
+	// as a failed attempt. Values below 1 are treated as a single attempt.
+	LockAttempts int `json:"lockAttempts,omitempty"`
+
+	// LockTimeout is the maximum duration LockWithContext waits for the lock
+	// of an artifact, over all attempts. Zero means it only returns early
+	// when the context is done.
+	LockTimeout time.Duration `json:"lockTimeout,omitempty"`
+
+	// NormalizeFileModes normalizes the modes of the archived files and
+	// directories, so that identical content yields identical checksums
+	// regardless of the umask or file system of the source. Directories and
+	// executable files are archived with mode 0755, other files with 0644.
+	NormalizeFileModes bool `json:"normalizeFileModes,omitempty"`
+
+	// ChecksumAlgorithm is the algorithm of the checksums of new artifacts,
+	// ChecksumSHA256 if empty. Artifacts are always verified with the
+	// algorithm of their recorded checksum.
+	ChecksumAlgorithm string `json:"checksumAlgorithm,omitempty"`
 }
 
 // NewStorage creates the storage helper for a given path and hostname.
@@ -77,17 +147,111 @@ func NewStorage(basePath string, hostname string, artifactRetentionTTL time.Dura
 	}, nil
 }
 
-// NewArtifactFor returns a new v1beta1.Artifact.
+// NewArtifactFor returns a new v1beta1.Artifact. The path of the artifact is
+// prefixed with the ArtifactPrefix of the Storage, if set, and placed in the
+// sharded layout if ShardArtifacts is enabled.
 func (s *Storage) NewArtifactFor(kind string, metadata metav1.Object, revision, fileName string) sourcev1.Artifact {
-	path := sourcev1.ArtifactPath(kind, metadata.GetNamespace(), metadata.GetName(), fileName)
+	dir := sourcev1.ArtifactDir(kind, metadata.GetNamespace(), metadata.GetName())
+	if s.ShardArtifacts {
+		dir = path.Join(artifactShard(dir), dir)
+	}
 	artifact := sourcev1.Artifact{
-		Path:     path,
+		Path:     s.prefixPath(path.Join(dir, fileName)),
 		Revision: revision,
 	}
 	s.SetArtifactURL(&artifact)
 	return artifact
 }
 
+// artifactShard returns the hash prefix directory of the given artifact dir
+// in the sharded layout, which is the first byte of its SHA256 hash in hex.
+// As the shard can not be a lowercase kind, the directories of the two
+// layouts never overlap.
+func artifactShard(dir string) string {
+	sum := sha256.Sum256([]byte(dir))
+	return hex.EncodeToString(sum[:1])
+}
+
+// prefixPath prefixes the given path with the ArtifactPrefix, if set.
+func (s *Storage) prefixPath(p string) string {
+	if s.ArtifactPrefix != "" {
+		return path.Join(s.ArtifactPrefix, p)
+	}
+	return p
+}
+
+// otherLayoutDir returns the local directory of the object of the given
+// v1beta1.Artifact in the layout the artifact is not stored in, or an empty
+// string if the path of the artifact matches neither layout.
+func (s *Storage) otherLayoutDir(artifact sourcev1.Artifact) string {
+	p := artifact.Path
+	if s.ArtifactPrefix != "" {
+		if !strings.HasPrefix(p, s.ArtifactPrefix+"/") {
+			return ""
+		}
+		p = strings.TrimPrefix(p, s.ArtifactPrefix+"/")
+	}
+
+	var other string
+	parts := strings.Split(path.Dir(p), "/")
+	switch len(parts) {
+	case 3:
+		// '<kind>/<namespace>/<name>'
+		dir := path.Join(parts...)
+		other = path.Join(artifactShard(dir), dir)
+	case 4:
+		// '<shard>/<kind>/<namespace>/<name>'
+		dir := path.Join(parts[1:]...)
+		if parts[0] != artifactShard(dir) {
+			return ""
+		}
+		other = dir
+	default:
+		return ""
+	}
+
+	dir, err := securejoin.SecureJoin(s.BasePath, s.prefixPath(other))
+	if err != nil {
+		return ""
+	}
+	return dir
+}
+
+// unprefixedDirs returns the local directories of the object of the given
+// v1beta1.Artifact without the ArtifactPrefix of the Storage, in both storage
+// layouts. They hold the artifacts stored before the ArtifactPrefix was
+// configured, and are empty if it is not set.
+func (s *Storage) unprefixedDirs(artifact sourcev1.Artifact) []string {
+	if s.ArtifactPrefix == "" || !strings.HasPrefix(artifact.Path, s.ArtifactPrefix+"/") {
+		return nil
+	}
+	p := strings.TrimPrefix(artifact.Path, s.ArtifactPrefix+"/")
+
+	var dir string
+	parts := strings.Split(path.Dir(p), "/")
+	switch len(parts) {
+	case 3:
+		// '<kind>/<namespace>/<name>'
+		dir = path.Join(parts...)
+	case 4:
+		// '<shard>/<kind>/<namespace>/<name>'
+		dir = path.Join(parts[1:]...)
+		if parts[0] != artifactShard(dir) {
+			return nil
+		}
+	default:
+		return nil
+	}
+
+	var dirs []string
+	for _, d := range []string{dir, path.Join(artifactShard(dir), dir)} {
+		if local, err := securejoin.SecureJoin(s.BasePath, d); err == nil {
+			dirs = append(dirs, local)
+		}
+	}
+	return dirs
+}
+
 // SetArtifactURL sets the URL on the given v1beta1.Artifact.
 func (s Storage) SetArtifactURL(artifact *sourcev1.Artifact) {
 	if artifact.Path == "" {
@@ -116,16 +280,27 @@ func (s *Storage) MkdirAll(artifact sourcev1.Artifact) error {
 	return os.MkdirAll(dir, 0o700)
 }
 
-// RemoveAll calls os.RemoveAll for the given v1beta1.Artifact base dir.
+// RemoveAll calls os.RemoveAll for the given v1beta1.Artifact base dir, the
+// dir of the same object in the other storage layout, and the dirs of the
+// object without the ArtifactPrefix. It returns the first dir which existed.
 func (s *Storage) RemoveAll(artifact sourcev1.Artifact) (string, error) {
+	dirs := []string{filepath.Dir(s.LocalPath(artifact))}
+	if other := s.otherLayoutDir(artifact); other != "" {
+		dirs = append(dirs, other)
+	}
+	dirs = append(dirs, s.unprefixedDirs(artifact)...)
+
 	var deletedDir string
-	dir := filepath.Dir(s.LocalPath(artifact))
-	// Check if the dir exists.
-	_, err := os.Stat(dir)
-	if err == nil {
-		deletedDir = dir
+	for _, dir := range dirs {
+		// Check if the dir exists.
+		if _, err := os.Stat(dir); err == nil && deletedDir == "" {
+			deletedDir = dir
+		}
+		if err := os.RemoveAll(dir); err != nil {
+			return deletedDir, err
+		}
 	}
-	return deletedDir, os.RemoveAll(dir)
+	return deletedDir, nil
 }
 
 // RemoveAllButCurrent removes all files for the given v1beta1.Artifact base dir, excluding the current one.
@@ -165,13 +340,18 @@ func (s *Storage) RemoveAllButCurrent(artifact sourcev1.Artifact) ([]string, err
 func (s *Storage) getGarbageFiles(artifact sourcev1.Artifact, totalCountLimit, maxItemsToBeRetained int, ttl time.Duration) (garbageFiles []string, _ error) {
 	localPath := s.LocalPath(artifact)
 	dir := filepath.Dir(localPath)
-	artifactFilesWithCreatedTs := make(map[time.Time]string)
+	// artifactFiles contain all files with their created ts, which does not
+	// have to be unique.
+	type artifactFile struct {
+		path      string
+		createdAt time.Time
+	}
+	var artifactFiles []artifactFile
 	// sortedPaths contain all files sorted according to their created ts.
 	sortedPaths := []string{}
 	now := time.Now().UTC()
 	totalArtifactFiles := 0
 	var errors []string
-	creationTimestamps := []time.Time{}
 	_ = filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
 		if err != nil {
 			errors = append(errors, err.Error())
@@ -197,8 +377,7 @@ func (s *Storage) getGarbageFiles(artifact sourcev1.Artifact, totalCountLimit, m
 				garbageFiles = append(garbageFiles, path)
 			}
 			totalArtifactFiles += 1
-			artifactFilesWithCreatedTs[createdAt] = path
-			creationTimestamps = append(creationTimestamps, createdAt)
+			artifactFiles = append(artifactFiles, artifactFile{path: path, createdAt: createdAt})
 		}
 		return nil
 
@@ -213,14 +392,11 @@ func (s *Storage) getGarbageFiles(artifact sourcev1.Artifact, totalCountLimit, m
 		return garbageFiles, nil
 	}
 
-	// sort all timestamps in an ascending order.
-	sort.Slice(creationTimestamps, func(i, j int) bool { return creationTimestamps[i].Before(creationTimestamps[j]) })
-	for _, ts := range creationTimestamps {
-		path, ok := artifactFilesWithCreatedTs[ts]
-		if !ok {
-			return garbageFiles, fmt.Errorf("failed to fetch file for created ts: %v", ts)
-		}
-		sortedPaths = append(sortedPaths, path)
+	// sort all files by their timestamp in an ascending order, files with an
+	// equal timestamp keep their walking order.
+	sort.SliceStable(artifactFiles, func(i, j int) bool { return artifactFiles[i].createdAt.Before(artifactFiles[j].createdAt) })
+	for _, f := range artifactFiles {
+		sortedPaths = append(sortedPaths, f.path)
 	}
 
 	var collected int
@@ -250,14 +426,129 @@ func (s *Storage) getGarbageFiles(artifact sourcev1.Artifact, totalCountLimit, m
 // GarbageCollect removes all garabge files in the artifact dir according to the provided
 // retention options.
 func (s *Storage) GarbageCollect(ctx context.Context, artifact sourcev1.Artifact, timeout time.Duration) ([]string, error) {
-	delFilesChan := make(chan []string)
+	deleted, _, err := s.garbageCollect(ctx, artifact, func() ([]string, error) {
+		return s.getGarbageFiles(artifact, GarbageCountLimit, s.ArtifactRetentionRecords, s.ArtifactRetentionTTL)
+	}, s.ArtifactRetentionTTL, timeout)
+	return deleted, err
+}
+
+// GarbageCollectHistory removes all garbage files in the artifact dir, except for
+// the current artifact and the historyLimit most recent artifacts of the given
+// history by their LastUpdateTime. Files of artifacts not recorded in the history
+// are removed regardless of their modification time. A historyLimit of 0 results
+// in the behavior of GarbageCollect.
+// It returns the deleted files, and the number of artifacts retained in the dir.
+func (s *Storage) GarbageCollectHistory(ctx context.Context, artifact sourcev1.Artifact, history []sourcev1.Artifact,
+	historyLimit int, timeout time.Duration) ([]string, int, error) {
+	return s.GarbageCollectWithOptions(ctx, artifact, history, historyLimit, GarbageCollectOptions{Timeout: timeout})
+}
+
+// GarbageCollectOptions configures the garbage collection of the artifacts of
+// an object, overriding the retention settings of the Storage.
+//
+// Regardless of the retention, a single garbage collection only considers the
+// first GarbageCountLimit files in the artifact dir, any other files are left
+// for the next collection.
+type GarbageCollectOptions struct {
+	// Timeout is the maximum duration of the garbage collection, including
+	// the time spent waiting for the removal of files, defaults to
+	// DefaultGarbageCollectTimeout.
+	Timeout time.Duration
+	// RetentionTTL overrides the ArtifactRetentionTTL of the Storage when
+	// non-zero, including the grace period of the artifacts in the other
+	// storage layout or without the ArtifactPrefix when a history limit is
+	// given.
+	RetentionTTL time.Duration
+	// RetentionRecords overrides the ArtifactRetentionRecords of the Storage
+	// when non-zero.
+	RetentionRecords int
+}
+
+// GarbageCollectWithOptions removes the garbage files in the artifact dir in
+// the same way as GarbageCollectHistory, with the retention settings of the
+// Storage overridden by the given options. The retained history artifacts do
+// not expire, the RetentionTTL does however apply to the artifacts in the
+// other storage layout or without the ArtifactPrefix.
+func (s *Storage) GarbageCollectWithOptions(ctx context.Context, artifact sourcev1.Artifact, history []sourcev1.Artifact,
+	historyLimit int, opts GarbageCollectOptions) ([]string, int, error) {
+	timeout := opts.Timeout
+	if timeout <= 0 {
+		timeout = DefaultGarbageCollectTimeout
+	}
+	records, ttl := s.ArtifactRetentionRecords, s.ArtifactRetentionTTL
+	if opts.RetentionRecords > 0 {
+		records = opts.RetentionRecords
+	}
+	if opts.RetentionTTL > 0 {
+		ttl = opts.RetentionTTL
+	}
+
+	if historyLimit > 0 {
+		// Retained history artifacts do not expire.
+		return s.garbageCollect(ctx, artifact, func() ([]string, error) {
+			return s.getHistoryGarbageFiles(artifact, history, historyLimit, GarbageCountLimit)
+		}, ttl, timeout)
+	}
+	return s.garbageCollect(ctx, artifact, func() ([]string, error) {
+		return s.getGarbageFiles(artifact, GarbageCountLimit, records, ttl)
+	}, ttl, timeout)
+}
+
+// getHistoryGarbageFiles returns the files in the artifact dir, except for the
+// current artifact and the historyLimit most recent artifacts of the given
+// history by their LastUpdateTime.
+func (s *Storage) getHistoryGarbageFiles(artifact sourcev1.Artifact, history []sourcev1.Artifact,
+	historyLimit, totalCountLimit int) (garbageFiles []string, _ error) {
+	localPath := s.LocalPath(artifact)
+	retained := map[string]struct{}{
+		localPath: {},
+	}
+	for _, a := range latestArtifacts(history, historyLimit) {
+		retained[s.LocalPath(a)] = struct{}{}
+	}
+
+	totalArtifactFiles := 0
+	var errors []string
+	_ = filepath.WalkDir(filepath.Dir(localPath), func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			errors = append(errors, err.Error())
+			return nil
+		}
+		if totalArtifactFiles >= totalCountLimit {
+			return fmt.Errorf("reached file walking limit, already walked over: %d", totalArtifactFiles)
+		}
+		if d.IsDir() || d.Type()&os.ModeSymlink == os.ModeSymlink || filepath.Ext(path) == ".lock" {
+			return nil
+		}
+		totalArtifactFiles += 1
+		if _, ok := retained[path]; !ok {
+			garbageFiles = append(garbageFiles, path)
+		}
+		return nil
+	})
+	if len(errors) > 0 {
+		return nil, fmt.Errorf("can't walk over file: %s", strings.Join(errors, ","))
+	}
+	return garbageFiles, nil
+}
+
+// garbageCollect removes the files returned by getGarbageFiles, and the
+// artifacts of the object in the other storage layout or stored before the
+// ArtifactPrefix was configured, which are older than the given ttl.
+func (s *Storage) garbageCollect(ctx context.Context, artifact sourcev1.Artifact, getGarbageFiles func() ([]string, error),
+	ttl, timeout time.Duration) ([]string, int, error) {
+	type result struct {
+		deleted  []string
+		retained int
+	}
+	resultChan := make(chan result)
 	errChan := make(chan error)
 	// Abort if it takes more than the provided timeout duration.
 	ctx, cancel := context.WithTimeout(ctx, timeout)
 	defer cancel()
 
 	go func() {
-		garbageFiles, err := s.getGarbageFiles(artifact, GarbageCountLimit, s.ArtifactRetentionRecords, s.ArtifactRetentionTTL)
+		garbageFiles, err := getGarbageFiles()
 		if err != nil {
 			errChan <- err
 			return
@@ -282,25 +573,104 @@ func (s *Storage) GarbageCollect(ctx context.Context, artifact sourcev1.Artifact
 				}
 			}
 		}
+		// The artifacts of the object in the other storage layout are
+		// superseded by the current artifact, but may still be fetched by
+		// consumers which did not observe it yet.
+		if other := s.otherLayoutDir(artifact); other != "" {
+			files, err := s.expireLayoutDir(other, ttl)
+			if err != nil {
+				errors = append(errors, err)
+			}
+			deleted = append(deleted, files...)
+		}
+		// The same goes for the artifacts stored before the ArtifactPrefix
+		// was configured, which are not served under the prefix.
+		for _, dir := range s.unprefixedDirs(artifact) {
+			files, err := s.expireLayoutDir(dir, ttl)
+			if err != nil {
+				errors = append(errors, err)
+			}
+			deleted = append(deleted, files...)
+		}
 		if len(errors) > 0 {
 			errChan <- kerrors.NewAggregate(errors)
 			return
 		}
-		delFilesChan <- deleted
+		resultChan <- result{deleted: deleted, retained: countArtifactFiles(filepath.Dir(s.LocalPath(artifact)))}
 	}()
 
 	for {
 		select {
 		case <-ctx.Done():
-			return nil, ctx.Err()
-		case delFiles := <-delFilesChan:
-			return delFiles, nil
+			return nil, 0, ctx.Err()
+		case res := <-resultChan:
+			return res.deleted, res.retained, nil
 		case err := <-errChan:
-			return nil, err
+			return nil, 0, err
 		}
 	}
 }
 
+// expireLayoutDir removes the artifact files in the given artifact dir of an
+// object in the storage layout it is no longer stored in, which were last
+// modified longer than the given ttl ago, together with their lock files. The
+// dir is removed once no artifact files are left. It returns the removed
+// artifact files.
+func (s *Storage) expireLayoutDir(dir string, ttl time.Duration) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	expiresAt := time.Now().UTC().Add(-ttl)
+	var deleted []string
+	var remaining int
+	for _, e := range entries {
+		if e.IsDir() || e.Type()&os.ModeSymlink == os.ModeSymlink || filepath.Ext(e.Name()) == ".lock" {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil {
+			return deleted, err
+		}
+		if info.ModTime().After(expiresAt) {
+			remaining++
+			continue
+		}
+		file := filepath.Join(dir, e.Name())
+		if err := os.Remove(file); err != nil {
+			return deleted, err
+		}
+		deleted = append(deleted, file)
+		if err := os.Remove(file + ".lock"); err != nil && !os.IsNotExist(err) {
+			return deleted, err
+		}
+	}
+	if remaining > 0 {
+		return deleted, nil
+	}
+	return deleted, os.RemoveAll(dir)
+}
+
+// countArtifactFiles returns the number of artifact files in the given dir,
+// ignoring directories, symlinks and lock files.
+func countArtifactFiles(dir string) int {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return 0
+	}
+	var count int
+	for _, e := range entries {
+		if e.IsDir() || e.Type()&os.ModeSymlink == os.ModeSymlink || filepath.Ext(e.Name()) == ".lock" {
+			continue
+		}
+		count++
+	}
+	return count
+}
+
 func stringInSlice(a string, list []string) bool {
 	for _, b := range list {
 		if b == a {
@@ -311,7 +681,12 @@ func stringInSlice(a string, list []string) bool {
 }
 
 // ArtifactExist returns a boolean indicating whether the v1beta1.Artifact exists in storage and is a regular file.
+// An artifact stored outside the ArtifactPrefix of the Storage is considered absent, so that it is recreated under
+// the prefix.
 func (s *Storage) ArtifactExist(artifact sourcev1.Artifact) bool {
+	if s.ArtifactPrefix != "" && !strings.HasPrefix(artifact.Path, s.ArtifactPrefix+"/") {
+		return false
+	}
 	fi, err := os.Lstat(s.LocalPath(artifact))
 	if err != nil {
 		return false
@@ -319,6 +694,31 @@ func (s *Storage) ArtifactExist(artifact sourcev1.Artifact) bool {
 	return fi.Mode().IsRegular()
 }
 
+// VerifyArtifact recomputes the checksum of the file of the given v1beta1.Artifact in storage, and returns an error if
+// it can not be read or does not match the recorded Checksum. The checksum is recomputed with the algorithm of the
+// recorded Checksum. An Artifact without a recorded Checksum is not verified.
+func (s *Storage) VerifyArtifact(artifact sourcev1.Artifact) error {
+	if artifact.Checksum == "" {
+		return nil
+	}
+	f, err := os.Open(s.LocalPath(artifact))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	algorithm := checksumAlgorithm(artifact.Checksum)
+	h := newHash(algorithm)
+	if _, err := io.Copy(h, f); err != nil {
+		return fmt.Errorf("failed to read artifact '%s': %w", artifact.Path, err)
+	}
+	if checksum := formatChecksum(algorithm, h); checksum != artifact.Checksum {
+		return fmt.Errorf("computed checksum '%s' of artifact '%s' does not match recorded checksum '%s'",
+			checksum, artifact.Path, artifact.Checksum)
+	}
+	return nil
+}
+
 // ArchiveFileFilter must return true if a file should not be included in the archive after inspecting the given path
 // and/or os.FileInfo.
 type ArchiveFileFilter func(p string, fi os.FileInfo) bool
@@ -337,11 +737,47 @@ func SourceIgnoreFilter(ps []gitignore.Pattern, domain []string) ArchiveFileFilt
 	}
 }
 
+// SourceIncludeFilter returns an ArchiveFileFilter that filters out files not matching any of the given patterns,
+// before applying the given ArchiveFileFilter. Directories are not filtered out, to retain the structure of the
+// included files. The patterns are evaluated relative to the given domain.
+func SourceIncludeFilter(ps []gitignore.Pattern, domain []string, filter ArchiveFileFilter) ArchiveFileFilter {
+	matcher := sourceignore.NewMatcher(ps)
+	return func(p string, fi os.FileInfo) bool {
+		if !fi.IsDir() && !matcher.Match(strings.Split(p, string(filepath.Separator)), false) {
+			return true
+		}
+		return filter != nil && filter(p, fi)
+	}
+}
+
 // Archive atomically archives the given directory as a tarball to the given v1beta1.Artifact path, excluding
 // directories and any ArchiveFileFilter matches. While archiving, any environment specific data (for example,
 // the user and group name) is stripped from file headers.
 // If successful, it sets the checksum and last update time on the artifact.
-func (s *Storage) Archive(artifact *sourcev1.Artifact, dir string, filter ArchiveFileFilter) (err error) {
+func (s *Storage) Archive(artifact *sourcev1.Artifact, dir string, filter ArchiveFileFilter) error {
+	return s.archive(artifact, dir, filter, true, time.Time{})
+}
+
+// ArchiveUncompressed atomically archives the given directory as an
+// uncompressed tarball to the given v1beta1.Artifact path, in the same way as
+// Archive.
+func (s *Storage) ArchiveUncompressed(artifact *sourcev1.Artifact, dir string, filter ArchiveFileFilter) error {
+	return s.archive(artifact, dir, filter, false, time.Time{})
+}
+
+// ArchiveWithModTime atomically archives the given directory as a tarball to
+// the given v1beta1.Artifact path in the same way as Archive, or as
+// ArchiveUncompressed if compress is false, with the modification time of the
+// files in the tarball and of the tarball itself set to modTime. Archiving
+// identical content with the same modTime results in an identical file.
+func (s *Storage) ArchiveWithModTime(artifact *sourcev1.Artifact, dir string, filter ArchiveFileFilter, compress bool, modTime time.Time) error {
+	return s.archive(artifact, dir, filter, compress, modTime)
+}
+
+// archive archives the given directory as a tarball to the given
+// v1beta1.Artifact path, which is gzip compressed if compress is true. The
+// modification times are set to modTime if it is not zero.
+func (s *Storage) archive(artifact *sourcev1.Artifact, dir string, filter ArchiveFileFilter, compress bool, modTime time.Time) (err error) {
 	if f, err := os.Stat(dir); os.IsNotExist(err) || !f.IsDir() {
 		return fmt.Errorf("invalid dir path: %s", dir)
 	}
@@ -358,13 +794,88 @@ func (s *Storage) Archive(artifact *sourcev1.Artifact, dir string, filter Archiv
 		}
 	}()
 
-	h := newHash()
+	h := s.newHash()
+	ch := s.newHash()
 	sz := &writeCounter{}
 	mw := io.MultiWriter(h, tf, sz)
 
-	gw := gzip.NewWriter(mw)
-	tw := tar.NewWriter(gw)
-	if err := filepath.Walk(dir, func(p string, fi os.FileInfo, err error) error {
+	// The uncompressed tarball is also written to the content hash, which
+	// is used to detect revisions with identical content. The modification
+	// times are not part of the content, the content hash is therefore
+	// computed separately if they are set.
+	var cw io.Writer = ch
+	if !modTime.IsZero() {
+		cw = io.Discard
+	}
+	var gw *gzip.Writer
+	tw := tar.NewWriter(io.MultiWriter(mw, cw))
+	if compress {
+		gw = gzip.NewWriter(mw)
+		tw = tar.NewWriter(io.MultiWriter(gw, cw))
+	}
+	files, err := s.writeTar(tw, dir, filter, modTime)
+	if err != nil {
+		tw.Close()
+		if gw != nil {
+			gw.Close()
+		}
+		tf.Close()
+		return err
+	}
+
+	if err := tw.Close(); err != nil {
+		if gw != nil {
+			gw.Close()
+		}
+		tf.Close()
+		return err
+	}
+	if gw != nil {
+		if err := gw.Close(); err != nil {
+			tf.Close()
+			return err
+		}
+	}
+	if err := tf.Close(); err != nil {
+		return err
+	}
+
+	if err := os.Chmod(tmpName, 0o600); err != nil {
+		return err
+	}
+
+	contentChecksum := s.formatChecksum(ch)
+	if !modTime.IsZero() {
+		if contentChecksum, err = s.ContentChecksum(dir, filter); err != nil {
+			return err
+		}
+		if err = os.Chtimes(tmpName, modTime, modTime); err != nil {
+			return err
+		}
+	}
+
+	if err := sourcefs.RenameWithFallback(tmpName, localPath); err != nil {
+		return err
+	}
+
+	artifact.Checksum = s.formatChecksum(h)
+	artifact.ContentChecksum = contentChecksum
+	artifact.LastUpdateTime = metav1.Now()
+	artifact.Size = &sz.written
+	artifact.FileCount = &files
+
+	return nil
+}
+
+// writeTar writes the files of the given directory to the tar.Writer,
+// excluding directories and any ArchiveFileFilter matches, and returns the
+// number of regular files written. Any environment specific data is stripped
+// from the file headers, and the modification times are set to modTime, or
+// stripped if it is zero, which makes the written content deterministic. The
+// file modes are normalized if NormalizeFileModes is enabled.
+func (s *Storage) writeTar(tw *tar.Writer, dir string, filter ArchiveFileFilter, modTime time.Time) (int64, error) {
+	var files int64
+	err := filepath.Walk(dir, func(p string, fi os.FileInfo, err error) error {
 		if err != nil {
 			return err
 		}
@@ -401,9 +912,12 @@ func (s *Storage) Archive(artifact *sourcev1.Artifact, dir string, filter Archiv
 		header.Uid = 0
 		header.Uname = ""
 		header.Gname = ""
-		header.ModTime = time.Time{}
+		header.ModTime = modTime
 		header.AccessTime = time.Time{}
 		header.ChangeTime = time.Time{}
+		if s.NormalizeFileModes {
+			header.Mode = normalizedFileMode(fi.Mode())
+		}
 
 		if err := tw.WriteHeader(header); err != nil {
 			return err
@@ -421,40 +935,122 @@ func (s *Storage) Archive(artifact *sourcev1.Artifact, dir string, filter Archiv
 			f.Close()
 			return err
 		}
+		files++
 		return f.Close()
-	}); err != nil {
-		tw.Close()
-		gw.Close()
-		tf.Close()
-		return err
+	})
+	return files, err
+}
+
+// normalizedFileMode returns the normalized tar header mode for the given
+// file mode: 0755 for directories and files executable by anyone, and 0644
+// for other files.
+func normalizedFileMode(m os.FileMode) int64 {
+	if m.IsDir() || m&0o111 != 0 {
+		return 0o755
 	}
+	return 0o644
+}
 
+// ContentChecksum returns the checksum of the content of the given directory
+// as it is archived by Archive, excluding any ArchiveFileFilter matches. As
+// the modification times are not part of the archived content, it only
+// changes when the content of the files does.
+func (s *Storage) ContentChecksum(dir string, filter ArchiveFileFilter) (string, error) {
+	if f, err := os.Stat(dir); os.IsNotExist(err) || !f.IsDir() {
+		return "", fmt.Errorf("invalid dir path: %s", dir)
+	}
+
+	h := s.newHash()
+	tw := tar.NewWriter(h)
+	if _, err := s.writeTar(tw, dir, filter, time.Time{}); err != nil {
+		tw.Close()
+		return "", err
+	}
 	if err := tw.Close(); err != nil {
-		gw.Close()
-		tf.Close()
-		return err
+		return "", err
 	}
-	if err := gw.Close(); err != nil {
-		tf.Close()
-		return err
+	return s.formatChecksum(h), nil
+}
+
+// TarballContentChecksum returns the checksum of the content of the gzip
+// compressed tarball at the given path, computed like the ContentChecksum of
+// an archived directory: the modification times and ownership of the entries
+// are not part of the content. It is used to detect packaged Helm charts which
+// only differ from the current artifact by their modification times.
+func (s *Storage) TarballContentChecksum(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
 	}
-	if err := tf.Close(); err != nil {
-		return err
+	defer f.Close()
+	gr, err := gzip.NewReader(f)
+	if err != nil {
+		return "", err
 	}
+	defer gr.Close()
 
-	if err := os.Chmod(tmpName, 0o600); err != nil {
-		return err
+	h := s.newHash()
+	tw := tar.NewWriter(h)
+	tr := tar.NewReader(gr)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return "", err
+		}
+		if err := tw.WriteHeader(&tar.Header{
+			Typeflag: hdr.Typeflag,
+			Name:     hdr.Name,
+			Linkname: hdr.Linkname,
+			Size:     hdr.Size,
+			Mode:     hdr.Mode,
+		}); err != nil {
+			return "", err
+		}
+		if _, err := io.Copy(tw, tr); err != nil {
+			return "", err
+		}
 	}
+	if err := tw.Close(); err != nil {
+		return "", err
+	}
+	return s.formatChecksum(h), nil
+}
 
-	if err := sourcefs.RenameWithFallback(tmpName, localPath); err != nil {
-		return err
+// ReuseArtifact reuses the file of the current v1beta1.Artifact for the given
+// artifact, instead of archiving the given directory again, if the content of
+// the directory excluding any ArchiveFileFilter matches is identical to the
+// content the current artifact was archived from. The file is copied to the
+// path of the given artifact, which keeps its revision, path and URL, and
+// takes over the checksums of the current artifact.
+// It returns false if the current artifact can not be reused, for example
+// because its file does not exist in storage or is of a different format.
+func (s *Storage) ReuseArtifact(artifact *sourcev1.Artifact, current *sourcev1.Artifact, dir string, filter ArchiveFileFilter) (bool, error) {
+	if current == nil || current.ContentChecksum == "" ||
+		filepath.Ext(current.Path) != filepath.Ext(artifact.Path) || !s.ArtifactExist(*current) {
+		return false, nil
 	}
 
-	artifact.Checksum = fmt.Sprintf("%x", h.Sum(nil))
-	artifact.LastUpdateTime = metav1.Now()
-	artifact.Size = &sz.written
+	checksum, err := s.ContentChecksum(dir, filter)
+	if err != nil {
+		return false, err
+	}
+	if checksum != current.ContentChecksum {
+		return false, nil
+	}
 
-	return nil
+	if artifact.Path == current.Path {
+		artifact.Checksum = current.Checksum
+		artifact.LastUpdateTime = current.LastUpdateTime
+		artifact.Size = current.Size
+	} else if err := s.CopyFromPath(artifact, s.LocalPath(*current)); err != nil {
+		return false, err
+	}
+	artifact.ContentChecksum = current.ContentChecksum
+	artifact.FileCount = current.FileCount
+	return true, nil
 }
 
 // AtomicWriteFile atomically writes the io.Reader contents to the v1beta1.Artifact path.
@@ -472,7 +1068,7 @@ func (s *Storage) AtomicWriteFile(artifact *sourcev1.Artifact, reader io.Reader,
 		}
 	}()
 
-	h := newHash()
+	h := s.newHash()
 	sz := &writeCounter{}
 	mw := io.MultiWriter(h, tf, sz)
 
@@ -492,7 +1088,7 @@ func (s *Storage) AtomicWriteFile(artifact *sourcev1.Artifact, reader io.Reader,
 		return err
 	}
 
-	artifact.Checksum = fmt.Sprintf("%x", h.Sum(nil))
+	artifact.Checksum = s.formatChecksum(h)
 	artifact.LastUpdateTime = metav1.Now()
 	artifact.Size = &sz.written
 
@@ -514,7 +1110,7 @@ func (s *Storage) Copy(artifact *sourcev1.Artifact, reader io.Reader) (err error
 		}
 	}()
 
-	h := newHash()
+	h := s.newHash()
 	sz := &writeCounter{}
 	mw := io.MultiWriter(h, tf, sz)
 
@@ -530,7 +1126,7 @@ func (s *Storage) Copy(artifact *sourcev1.Artifact, reader io.Reader) (err error
 		return err
 	}
 
-	artifact.Checksum = fmt.Sprintf("%x", h.Sum(nil))
+	artifact.Checksum = s.formatChecksum(h)
 	artifact.LastUpdateTime = metav1.Now()
 	artifact.Size = &sz.written
 
@@ -538,7 +1134,8 @@ func (s *Storage) Copy(artifact *sourcev1.Artifact, reader io.Reader) (err error
 }
 
 // CopyFromPath atomically copies the contents of the given path to the path of the v1beta1.Artifact.
-// If successful, the checksum and last update time on the artifact is set.
+// If successful, the checksum and last update time on the artifact is set, and the file count if the contents
+// are a gzip compressed tarball.
 func (s *Storage) CopyFromPath(artifact *sourcev1.Artifact, path string) (err error) {
 	f, err := os.Open(path)
 	if err != nil {
@@ -549,8 +1146,44 @@ func (s *Storage) CopyFromPath(artifact *sourcev1.Artifact, path string) (err er
 			err = cerr
 		}
 	}()
-	err = s.Copy(artifact, f)
-	return err
+	if err = s.Copy(artifact, f); err != nil {
+		return err
+	}
+	artifact.FileCount = nil
+	if files, ok := tarballFileCount(path); ok {
+		artifact.FileCount = &files
+	}
+	return nil
+}
+
+// tarballFileCount returns the number of regular files in the gzip compressed
+// tarball at the given path, or false if the path is not such a tarball.
+func tarballFileCount(path string) (int64, bool) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, false
+	}
+	defer f.Close()
+	gr, err := gzip.NewReader(f)
+	if err != nil {
+		return 0, false
+	}
+	defer gr.Close()
+
+	var files int64
+	tr := tar.NewReader(gr)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return files, true
+		}
+		if err != nil {
+			return 0, false
+		}
+		if hdr.Typeflag == tar.TypeReg {
+			files++
+		}
+	}
 }
 
 // CopyToPath copies the contents in the (sub)path of the given artifact to the given path.
@@ -592,6 +1225,12 @@ func (s *Storage) CopyToPath(artifact *sourcev1.Artifact, subPath, toPath string
 	return nil
 }
 
+// Chtimes sets the access and modification time of the file of the given
+// v1beta1.Artifact to t.
+func (s *Storage) Chtimes(artifact sourcev1.Artifact, t time.Time) error {
+	return os.Chtimes(s.LocalPath(artifact), t, t)
+}
+
 // Symlink creates or updates a symbolic link for the given v1beta1.Artifact and returns the URL for the symlink.
 func (s *Storage) Symlink(artifact sourcev1.Artifact, linkName string) (string, error) {
 	localPath := s.LocalPath(artifact)
@@ -615,11 +1254,12 @@ func (s *Storage) Symlink(artifact sourcev1.Artifact, linkName string) (string,
 	return url, nil
 }
 
-// Checksum returns the SHA256 checksum for the data of the given io.Reader as a string.
+// Checksum returns the checksum for the data of the given io.Reader as a string, computed with the ChecksumAlgorithm
+// of the Storage.
 func (s *Storage) Checksum(reader io.Reader) string {
-	h := newHash()
+	h := s.newHash()
 	_, _ = io.Copy(h, reader)
-	return fmt.Sprintf("%x", h.Sum(nil))
+	return s.formatChecksum(h)
 }
 
 // Lock creates a file lock for the given v1beta1.Artifact.
@@ -629,6 +1269,76 @@ func (s *Storage) Lock(artifact sourcev1.Artifact) (unlock func(), err error) {
 	return mutex.Lock()
 }
 
+// LockWithContext creates a file lock for the given v1beta1.Artifact like
+// Lock, but without blocking on a lock held by another process or goroutine.
+// A held lock is polled with an exponential backoff until it is acquired, the
+// context is done or the LockTimeout expired. Failures to create the lock are
+// retried up to LockAttempts times.
+func (s *Storage) LockWithContext(ctx context.Context, artifact sourcev1.Artifact) (unlock func(), err error) {
+	if s.LockTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.LockTimeout)
+		defer cancel()
+	}
+
+	delay := lockRetryBaseDelay
+	for attempt := 1; ; {
+		var acquired bool
+		unlock, acquired, err = s.tryLock(artifact)
+		if acquired {
+			return unlock, nil
+		}
+		if err != nil {
+			if attempt >= s.LockAttempts {
+				return nil, fmt.Errorf("%w (%d attempt(s))", err, attempt)
+			}
+			attempt++
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			if err == nil {
+				err = errors.New("lock is held")
+			}
+			return nil, fmt.Errorf("waiting for lock: %w: %s", ctx.Err(), err)
+		case <-timer.C:
+		}
+		if delay *= 2; delay > lockRetryMaxDelay {
+			delay = lockRetryMaxDelay
+		}
+	}
+}
+
+// ReadyCheck verifies that artifacts can be stored, by creating the directory
+// of a probe file, acquiring its lock within storageProbeLockTimeout, and
+// writing it. It returns an error if the storage is unavailable, for example
+// because the volume is read-only or full. It implements the healthz.Checker
+// signature, for use as a readiness check of the manager.
+func (s *Storage) ReadyCheck(req *http.Request) error {
+	ctx := context.Background()
+	if req != nil {
+		ctx = req.Context()
+	}
+	ctx, cancel := context.WithTimeout(ctx, storageProbeLockTimeout)
+	defer cancel()
+
+	probe := sourcev1.Artifact{Path: storageProbePath}
+	if err := s.MkdirAll(probe); err != nil {
+		return fmt.Errorf("failed to create storage probe directory: %w", err)
+	}
+	unlock, err := s.LockWithContext(ctx, probe)
+	if err != nil {
+		return fmt.Errorf("failed to acquire lock for storage probe: %w", err)
+	}
+	defer unlock()
+	if err := s.AtomicWriteFile(&probe, strings.NewReader(time.Now().UTC().Format(time.RFC3339)), 0o600); err != nil {
+		return fmt.Errorf("failed to write storage probe: %w", err)
+	}
+	return nil
+}
+
 // LocalPath returns the secure local path of the given artifact (that is: relative to the Storage.BasePath).
 func (s *Storage) LocalPath(artifact sourcev1.Artifact) string {
 	if artifact.Path == "" {
@@ -641,11 +1351,35 @@ func (s *Storage) LocalPath(artifact sourcev1.Artifact) string {
 	return path
 }
 
-// newHash returns a new SHA256 hash.
-func newHash() hash.Hash {
+// newHash returns a new hash of the ChecksumAlgorithm of the Storage.
+func (s *Storage) newHash() hash.Hash {
+	return newHash(s.ChecksumAlgorithm)
+}
+
+// formatChecksum returns the checksum of the given hash of the
+// ChecksumAlgorithm of the Storage.
+func (s *Storage) formatChecksum(h hash.Hash) string {
+	return formatChecksum(s.ChecksumAlgorithm, h)
+}
+
+// newHash returns a new hash of the given checksum algorithm, defaulting to
+// SHA256.
+func newHash(algorithm string) hash.Hash {
+	if algorithm == ChecksumSHA512 {
+		return sha512.New()
+	}
 	return sha256.New()
 }
 
+// formatChecksum returns the checksum of the given hash of the given
+// algorithm, prefixed with the algorithm unless it is SHA256.
+func formatChecksum(algorithm string, h hash.Hash) string {
+	if algorithm == ChecksumSHA512 {
+		return fmt.Sprintf("%s:%x", ChecksumSHA512, h.Sum(nil))
+	}
+	return fmt.Sprintf("%x", h.Sum(nil))
+}
+
 // writecounter is an implementation of io.Writer that only records the number
 // of bytes written.
 type writeCounter struct {
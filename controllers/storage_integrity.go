@@ -0,0 +1,166 @@
+/*
+Copyright 2022 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	kerrors "k8s.io/apimachinery/pkg/util/errors"
+	kuberecorder "k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/fluxcd/pkg/apis/meta"
+
+	sourcev1 "github.com/fluxcd/source-controller/api/v1beta2"
+)
+
+// StorageIntegrityScanner periodically recomputes the checksums of the
+// current Artifacts of all sources in Storage. When the file of an Artifact
+// does not match its recorded checksum, e.g. because of bit-rot or a partial
+// write, the Artifact is removed from the status of the source and a
+// reconciliation is requested to rebuild it.
+type StorageIntegrityScanner struct {
+	client.Client
+	kuberecorder.EventRecorder
+
+	Storage *Storage
+	// Interval is the interval at which the Storage is scanned.
+	Interval time.Duration
+}
+
+// Start implements manager.Runnable, and scans the Storage at every Interval
+// until the given context is cancelled.
+func (s *StorageIntegrityScanner) Start(ctx context.Context) error {
+	log := ctrl.LoggerFrom(ctx).WithName("storage-integrity-scanner")
+
+	ticker := time.NewTicker(s.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			if err := s.Scan(ctx); err != nil {
+				log.Error(err, "storage integrity scan failed")
+			}
+		}
+	}
+}
+
+// NeedLeaderElection implements manager.LeaderElectionRunnable, as only the
+// leader writes to the Storage.
+func (s *StorageIntegrityScanner) NeedLeaderElection() bool {
+	return true
+}
+
+// Scan verifies the current Artifacts of all the sources once.
+func (s *StorageIntegrityScanner) Scan(ctx context.Context) error {
+	lists := []client.ObjectList{
+		&sourcev1.GitRepositoryList{},
+		&sourcev1.BucketList{},
+		&sourcev1.HelmRepositoryList{},
+		&sourcev1.HelmChartList{},
+		&sourcev1.OCIRepositoryList{},
+	}
+
+	var errs []error
+	for _, list := range lists {
+		if err := s.List(ctx, list); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		items, err := apimeta.ExtractList(list)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		for _, item := range items {
+			obj, ok := item.(sourcev1.Source)
+			if !ok {
+				continue
+			}
+			if err := s.verify(ctx, obj); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+	return kerrors.NewAggregate(errs)
+}
+
+// verify recomputes the checksum of the current Artifact of the given source.
+// On a mismatch, the Artifact is removed from its status, a reconciliation is
+// requested and a warning event is emitted.
+func (s *StorageIntegrityScanner) verify(ctx context.Context, obj sourcev1.Source) error {
+	artifact := obj.GetArtifact()
+	if artifact == nil || !s.Storage.ArtifactExist(*artifact) {
+		return nil
+	}
+
+	unlock, err := s.Storage.Lock(*artifact)
+	if err != nil {
+		return fmt.Errorf("failed to acquire lock for artifact '%s': %w", artifact.Path, err)
+	}
+	verifyErr := s.Storage.VerifyArtifact(*artifact)
+	unlock()
+	if verifyErr == nil {
+		return nil
+	}
+
+	cObj, ok := obj.(client.Object)
+	if !ok {
+		return nil
+	}
+	statusPatch := client.MergeFrom(cObj.DeepCopyObject().(client.Object))
+	switch o := obj.(type) {
+	case *sourcev1.GitRepository:
+		o.Status.Artifact = nil
+	case *sourcev1.Bucket:
+		o.Status.Artifact = nil
+	case *sourcev1.HelmRepository:
+		o.Status.Artifact = nil
+	case *sourcev1.HelmChart:
+		o.Status.Artifact = nil
+	case *sourcev1.OCIRepository:
+		o.Status.Artifact = nil
+	default:
+		return nil
+	}
+	if err := s.Status().Patch(ctx, cObj, statusPatch); err != nil {
+		return fmt.Errorf("failed to remove corrupted artifact from status of '%s/%s': %w",
+			cObj.GetNamespace(), cObj.GetName(), err)
+	}
+
+	patch := client.MergeFrom(cObj.DeepCopyObject().(client.Object))
+	annotations := cObj.GetAnnotations()
+	if annotations == nil {
+		annotations = make(map[string]string)
+	}
+	annotations[meta.ReconcileRequestAnnotation] = time.Now().Format(time.RFC3339Nano)
+	cObj.SetAnnotations(annotations)
+	if err := s.Patch(ctx, cObj, patch); err != nil {
+		return fmt.Errorf("failed to request reconciliation of '%s/%s': %w", cObj.GetNamespace(), cObj.GetName(), err)
+	}
+
+	s.Eventf(cObj, corev1.EventTypeWarning, sourcev1.ArtifactChecksumMismatchReason,
+		"artifact integrity check failed, rebuilding artifact: %s", verifyErr)
+	return nil
+}
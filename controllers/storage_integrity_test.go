@@ -0,0 +1,81 @@
+/*
+Copyright 2022 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	fakeclient "sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/fluxcd/pkg/apis/meta"
+
+	sourcev1 "github.com/fluxcd/source-controller/api/v1beta2"
+)
+
+func TestStorageIntegrityScanner_Scan(t *testing.T) {
+	g := NewWithT(t)
+
+	storage, err := NewStorage(t.TempDir(), "hostname", time.Minute, 2)
+	g.Expect(err).ToNot(HaveOccurred())
+
+	newArtifact := func(kind string, obj metav1.Object) *sourcev1.Artifact {
+		artifact := storage.NewArtifactFor(kind, obj, "1234", "1234.tar.gz")
+		g.Expect(storage.MkdirAll(artifact)).To(Succeed())
+		g.Expect(storage.AtomicWriteFile(&artifact, strings.NewReader("content"), 0o600)).To(Succeed())
+		return &artifact
+	}
+
+	intact := &sourcev1.GitRepository{
+		ObjectMeta: metav1.ObjectMeta{Name: "intact", Namespace: "default"},
+	}
+	intact.Status.Artifact = newArtifact(sourcev1.GitRepositoryKind, intact)
+
+	corrupted := &sourcev1.OCIRepository{
+		ObjectMeta: metav1.ObjectMeta{Name: "corrupted", Namespace: "default"},
+	}
+	corrupted.Status.Artifact = newArtifact(sourcev1.OCIRepositoryKind, corrupted)
+	g.Expect(os.WriteFile(storage.LocalPath(*corrupted.Status.Artifact), []byte("cont"), 0o600)).To(Succeed())
+
+	recorder := record.NewFakeRecorder(32)
+	s := &StorageIntegrityScanner{
+		Client:        fakeclient.NewClientBuilder().WithScheme(testEnv.GetScheme()).WithObjects(intact, corrupted).Build(),
+		EventRecorder: recorder,
+		Storage:       storage,
+	}
+	g.Expect(s.Scan(context.TODO())).To(Succeed())
+
+	gotIntact := &sourcev1.GitRepository{}
+	g.Expect(s.Get(context.TODO(), client.ObjectKeyFromObject(intact), gotIntact)).To(Succeed())
+	g.Expect(gotIntact.Status.Artifact).ToNot(BeNil())
+	g.Expect(gotIntact.GetAnnotations()).ToNot(HaveKey(meta.ReconcileRequestAnnotation))
+
+	gotCorrupted := &sourcev1.OCIRepository{}
+	g.Expect(s.Get(context.TODO(), client.ObjectKeyFromObject(corrupted), gotCorrupted)).To(Succeed())
+	g.Expect(gotCorrupted.Status.Artifact).To(BeNil())
+	g.Expect(gotCorrupted.GetAnnotations()).To(HaveKey(meta.ReconcileRequestAnnotation))
+
+	g.Expect(recorder.Events).To(HaveLen(1))
+	g.Expect(<-recorder.Events).To(HavePrefix("Warning " + sourcev1.ArtifactChecksumMismatchReason))
+}
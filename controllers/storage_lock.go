@@ -0,0 +1,50 @@
+//go:build darwin || dragonfly || freebsd || illumos || linux || netbsd || openbsd
+// +build darwin dragonfly freebsd illumos linux netbsd openbsd
+
+/*
+Copyright 2022 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"errors"
+	"os"
+	"syscall"
+
+	sourcev1 "github.com/fluxcd/source-controller/api/v1beta2"
+)
+
+// tryLock attempts to acquire the lock of the given v1beta1.Artifact without
+// blocking. It returns false if the lock is held by another process or
+// goroutine. The lock is an flock(2) on the same file as the one of Lock,
+// which makes the two mutually exclusive.
+func (s *Storage) tryLock(artifact sourcev1.Artifact) (unlock func(), acquired bool, err error) {
+	f, err := os.OpenFile(s.LocalPath(artifact)+".lock", os.O_RDWR|os.O_CREATE, 0o666)
+	if err != nil {
+		return nil, false, err
+	}
+	if err = syscall.Flock(int(f.Fd()), syscall.LOCK_EX|syscall.LOCK_NB); err != nil {
+		_ = f.Close()
+		if errors.Is(err, syscall.EWOULDBLOCK) {
+			return nil, false, nil
+		}
+		return nil, false, &os.PathError{Op: "flock", Path: f.Name(), Err: err}
+	}
+	return func() {
+		_ = syscall.Flock(int(f.Fd()), syscall.LOCK_UN)
+		_ = f.Close()
+	}, true, nil
+}
@@ -0,0 +1,33 @@
+//go:build !(darwin || dragonfly || freebsd || illumos || linux || netbsd || openbsd)
+// +build !darwin,!dragonfly,!freebsd,!illumos,!linux,!netbsd,!openbsd
+
+/*
+Copyright 2022 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	sourcev1 "github.com/fluxcd/source-controller/api/v1beta2"
+)
+
+// tryLock acquires the lock of the given v1beta1.Artifact. On platforms
+// without flock(2), it falls back to the blocking Lock.
+func (s *Storage) tryLock(artifact sourcev1.Artifact) (unlock func(), acquired bool, err error) {
+	if unlock, err = s.Lock(artifact); err != nil {
+		return nil, false, err
+	}
+	return unlock, true, nil
+}
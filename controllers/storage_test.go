@@ -18,10 +18,13 @@ package controllers
 
 import (
 	"archive/tar"
+	"bytes"
 	"compress/gzip"
 	"context"
+	"crypto/sha256"
 	"fmt"
 	"io"
+	"net/http"
 	"os"
 	"path/filepath"
 	"strings"
@@ -30,6 +33,7 @@ import (
 
 	"github.com/fluxcd/go-git/v5/plumbing/format/gitignore"
 	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	sourcev1 "github.com/fluxcd/source-controller/api/v1beta2"
 )
@@ -169,12 +173,13 @@ func TestStorage_Archive(t *testing.T) {
 	}
 
 	tests := []struct {
-		name     string
-		files    map[string][]byte
-		filter   ArchiveFileFilter
-		want     map[string][]byte
-		wantDirs []string
-		wantErr  bool
+		name          string
+		files         map[string][]byte
+		filter        ArchiveFileFilter
+		want          map[string][]byte
+		wantDirs      []string
+		wantFileCount int64
+		wantErr       bool
 	}{
 		{
 			name: "no filter",
@@ -189,6 +194,7 @@ func TestStorage_Archive(t *testing.T) {
 				"file.jpg":      []byte(`contents`),
 				"manifest.yaml": nil,
 			},
+			wantFileCount: 3,
 		},
 		{
 			name: "exclude VCS",
@@ -204,6 +210,7 @@ func TestStorage_Archive(t *testing.T) {
 				"!.git/config":  nil,
 				"manifest.yaml": nil,
 			},
+			wantFileCount: 1,
 		},
 		{
 			name: "custom",
@@ -233,7 +240,59 @@ func TestStorage_Archive(t *testing.T) {
 			wantDirs: []string{
 				"test",
 			},
-			wantErr: false,
+			wantFileCount: 1,
+			wantErr:       false,
+		},
+		{
+			name: "include",
+			files: map[string][]byte{
+				"README.md":             nil,
+				"apps/app.yaml":         nil,
+				"apps/staging/app.yaml": nil,
+				"apps/values.json":      nil,
+			},
+			filter: SourceIncludeFilter([]gitignore.Pattern{
+				gitignore.ParsePattern("**/*.yaml", nil),
+			}, nil, nil),
+			want: map[string][]byte{
+				"!README.md":            nil,
+				"apps/app.yaml":         nil,
+				"apps/staging/app.yaml": nil,
+				"!apps/values.json":     nil,
+			},
+			wantDirs: []string{
+				"apps",
+				"apps/staging",
+			},
+			wantFileCount: 2,
+		},
+		{
+			name: "include with ignore",
+			files: map[string][]byte{
+				".git/config":           nil,
+				"README.md":             nil,
+				"apps/app.yaml":         nil,
+				"apps/staging/app.yaml": nil,
+				"apps/values.json":      nil,
+			},
+			filter: SourceIncludeFilter([]gitignore.Pattern{
+				gitignore.ParsePattern("**/*.yaml", nil),
+			}, nil, SourceIgnoreFilter([]gitignore.Pattern{
+				gitignore.ParsePattern("staging", nil),
+			}, nil)),
+			want: map[string][]byte{
+				"!.git/config":           nil,
+				"!README.md":             nil,
+				"apps/app.yaml":          nil,
+				"!apps/staging/app.yaml": nil,
+				"!apps/values.json":      nil,
+			},
+			wantDirs: []string{
+				"apps",
+				"!apps/staging",
+				"!.git",
+			},
+			wantFileCount: 1,
 		},
 	}
 	for _, tt := range tests {
@@ -253,11 +312,84 @@ func TestStorage_Archive(t *testing.T) {
 			if err := storage.Archive(&artifact, dir, tt.filter); (err != nil) != tt.wantErr {
 				t.Errorf("Archive() error = %v, wantErr %v", err, tt.wantErr)
 			}
+			if tt.wantFileCount > 0 && (artifact.FileCount == nil || *artifact.FileCount != tt.wantFileCount) {
+				t.Errorf("Archive() file count = %v, want %d", artifact.FileCount, tt.wantFileCount)
+			}
 			matchFiles(t, storage, artifact, tt.want, tt.wantDirs)
 		})
 	}
 }
 
+func TestStorage_ArchiveUncompressed(t *testing.T) {
+	g := NewWithT(t)
+
+	storage, err := NewStorage(t.TempDir(), "hostname", time.Minute, 2)
+	g.Expect(err).ToNot(HaveOccurred())
+
+	dir := t.TempDir()
+	g.Expect(os.WriteFile(filepath.Join(dir, "manifest.yaml"), []byte("kind: ConfigMap"), 0o600)).To(Succeed())
+
+	artifact := sourcev1.Artifact{
+		Path: filepath.Join(randStringRunes(10), randStringRunes(10), randStringRunes(10)+".tar"),
+	}
+	g.Expect(storage.MkdirAll(artifact)).To(Succeed())
+	g.Expect(storage.ArchiveUncompressed(&artifact, dir, nil)).To(Succeed())
+	g.Expect(storage.ArtifactExist(artifact)).To(BeTrue())
+
+	b, err := os.ReadFile(storage.LocalPath(artifact))
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(artifact.Checksum).To(Equal(fmt.Sprintf("%x", sha256.Sum256(b))))
+	g.Expect(*artifact.Size).To(BeEquivalentTo(len(b)))
+	g.Expect(*artifact.FileCount).To(BeEquivalentTo(1))
+
+	// The tarball can be read without decompressing it
+	tr := tar.NewReader(bytes.NewReader(b))
+	header, err := tr.Next()
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(header.Name).To(Equal("manifest.yaml"))
+	content, err := io.ReadAll(tr)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(string(content)).To(Equal("kind: ConfigMap"))
+}
+
+func TestStorage_ArchiveWithModTime(t *testing.T) {
+	g := NewWithT(t)
+
+	storage, err := NewStorage(t.TempDir(), "hostname", time.Minute, 2)
+	g.Expect(err).ToNot(HaveOccurred())
+
+	dir := t.TempDir()
+	g.Expect(os.WriteFile(filepath.Join(dir, "manifest.yaml"), []byte("kind: ConfigMap"), 0o600)).To(Succeed())
+
+	modTime := time.Date(2022, 10, 1, 12, 0, 0, 0, time.UTC)
+	var checksums []string
+	for i := 0; i < 2; i++ {
+		artifact := sourcev1.Artifact{
+			Path: filepath.Join(randStringRunes(10), randStringRunes(10), randStringRunes(10)+".tar"),
+		}
+		g.Expect(storage.MkdirAll(artifact)).To(Succeed())
+		g.Expect(storage.ArchiveWithModTime(&artifact, dir, nil, false, modTime)).To(Succeed())
+
+		fi, err := os.Stat(storage.LocalPath(artifact))
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(fi.ModTime().UTC()).To(Equal(modTime))
+
+		b, err := os.ReadFile(storage.LocalPath(artifact))
+		g.Expect(err).ToNot(HaveOccurred())
+		header, err := tar.NewReader(bytes.NewReader(b)).Next()
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(header.ModTime.UTC()).To(Equal(modTime))
+
+		// The modification time is not part of the content
+		contentChecksum, err := storage.ContentChecksum(dir, nil)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(artifact.ContentChecksum).To(Equal(contentChecksum))
+
+		checksums = append(checksums, artifact.Checksum)
+	}
+	g.Expect(checksums[1]).To(Equal(checksums[0]))
+}
+
 func TestStorageRemoveAllButCurrent(t *testing.T) {
 	t.Run("bad directory in archive", func(t *testing.T) {
 		dir := t.TempDir()
@@ -355,6 +487,528 @@ func TestStorageRemoveAll(t *testing.T) {
 	}
 }
 
+func TestStorage_ArtifactPrefix(t *testing.T) {
+	g := NewWithT(t)
+	dir := t.TempDir()
+
+	obj := &metav1.ObjectMeta{Name: "podinfo", Namespace: "default"}
+
+	// Two clusters sharing the same storage
+	a, err := NewStorage(dir, "hostname", time.Minute, 2)
+	g.Expect(err).ToNot(HaveOccurred())
+	a.ArtifactPrefix = "cluster-a"
+	b, err := NewStorage(dir, "hostname", time.Minute, 2)
+	g.Expect(err).ToNot(HaveOccurred())
+	b.ArtifactPrefix = "cluster-b"
+
+	artifactA := a.NewArtifactFor(sourcev1.GitRepositoryKind, obj, "main/1234", "1234.tar.gz")
+	g.Expect(artifactA.Path).To(Equal("cluster-a/gitrepository/default/podinfo/1234.tar.gz"))
+	g.Expect(artifactA.URL).To(Equal("http://hostname/cluster-a/gitrepository/default/podinfo/1234.tar.gz"))
+	artifactB := b.NewArtifactFor(sourcev1.GitRepositoryKind, obj, "main/1234", "1234.tar.gz")
+	g.Expect(artifactB.Path).To(Equal("cluster-b/gitrepository/default/podinfo/1234.tar.gz"))
+
+	for _, tt := range []struct {
+		s        *Storage
+		artifact sourcev1.Artifact
+	}{{a, artifactA}, {b, artifactB}} {
+		g.Expect(tt.s.MkdirAll(tt.artifact)).To(Succeed())
+		g.Expect(tt.s.AtomicWriteFile(&tt.artifact, strings.NewReader("content"), 0o600)).To(Succeed())
+		g.Expect(tt.s.LocalPath(tt.artifact)).To(Equal(filepath.Join(dir, tt.artifact.Path)))
+		g.Expect(tt.s.ArtifactExist(tt.artifact)).To(BeTrue())
+	}
+
+	// Artifacts outside the prefix are considered absent
+	g.Expect(a.ArtifactExist(artifactB)).To(BeFalse())
+	unprefixed, err := NewStorage(dir, "hostname", time.Minute, 2)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(a.ArtifactExist(unprefixed.NewArtifactFor(sourcev1.GitRepositoryKind, obj, "main/1234", "1234.tar.gz"))).To(BeFalse())
+
+	// Garbage collection of a deleted object is scoped to the prefix
+	deleted, err := a.RemoveAll(a.NewArtifactFor(sourcev1.GitRepositoryKind, obj, "", "*"))
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(deleted).To(Equal(filepath.Join(dir, "cluster-a", "gitrepository", "default", "podinfo")))
+	g.Expect(a.ArtifactExist(artifactA)).To(BeFalse())
+	g.Expect(b.ArtifactExist(artifactB)).To(BeTrue())
+}
+
+func TestStorage_ArtifactPrefix_unprefixedArtifacts(t *testing.T) {
+	g := NewWithT(t)
+	dir := t.TempDir()
+
+	obj := &metav1.ObjectMeta{Name: "podinfo", Namespace: "default"}
+
+	unprefixed, err := NewStorage(dir, "hostname", time.Minute, 2)
+	g.Expect(err).ToNot(HaveOccurred())
+	sharded, err := NewStorage(dir, "hostname", time.Minute, 2)
+	g.Expect(err).ToNot(HaveOccurred())
+	sharded.ShardArtifacts = true
+	prefixed, err := NewStorage(dir, "hostname", time.Minute, 2)
+	g.Expect(err).ToNot(HaveOccurred())
+	prefixed.ArtifactPrefix = "cluster-a"
+
+	writeArtifact := func(s *Storage, revision string) sourcev1.Artifact {
+		artifact := s.NewArtifactFor(sourcev1.GitRepositoryKind, obj, revision, revision+".tar.gz")
+		g.Expect(s.MkdirAll(artifact)).To(Succeed())
+		g.Expect(s.AtomicWriteFile(&artifact, strings.NewReader(revision), 0o600)).To(Succeed())
+		return artifact
+	}
+
+	// Artifacts stored in both layouts before the prefix was configured
+	unprefixedArtifact := writeArtifact(unprefixed, "1234")
+	shardedArtifact := writeArtifact(sharded, "5678")
+	current := writeArtifact(prefixed, "9012")
+	g.Expect(prefixed.unprefixedDirs(current)).To(ConsistOf(
+		filepath.Dir(unprefixed.LocalPath(unprefixedArtifact)),
+		filepath.Dir(sharded.LocalPath(shardedArtifact)),
+	))
+	g.Expect(unprefixed.unprefixedDirs(unprefixedArtifact)).To(BeEmpty())
+
+	// They are retained until they expire
+	deleted, retained, err := prefixed.GarbageCollectHistory(context.TODO(), current, nil, 2, time.Second)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(deleted).To(BeEmpty())
+	g.Expect(retained).To(Equal(1))
+	g.Expect(unprefixed.ArtifactExist(unprefixedArtifact)).To(BeTrue())
+	g.Expect(sharded.ArtifactExist(shardedArtifact)).To(BeTrue())
+
+	expired := time.Now().Add(-2 * time.Minute)
+	for _, local := range []string{unprefixed.LocalPath(unprefixedArtifact), sharded.LocalPath(shardedArtifact)} {
+		g.Expect(os.Chtimes(local, expired, expired)).To(Succeed())
+	}
+	deleted, retained, err = prefixed.GarbageCollectHistory(context.TODO(), current, nil, 2, time.Second)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(deleted).To(ConsistOf(unprefixed.LocalPath(unprefixedArtifact), sharded.LocalPath(shardedArtifact)))
+	g.Expect(retained).To(Equal(1))
+	g.Expect(filepath.Dir(unprefixed.LocalPath(unprefixedArtifact))).ToNot(BeADirectory())
+	g.Expect(filepath.Dir(sharded.LocalPath(shardedArtifact))).ToNot(BeADirectory())
+	g.Expect(prefixed.ArtifactExist(current)).To(BeTrue())
+
+	// And removed with the object
+	unprefixedArtifact = writeArtifact(unprefixed, "1234")
+	deletedDir, err := prefixed.RemoveAll(prefixed.NewArtifactFor(sourcev1.GitRepositoryKind, obj, "", "*"))
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(deletedDir).To(Equal(filepath.Dir(prefixed.LocalPath(current))))
+	g.Expect(unprefixed.ArtifactExist(unprefixedArtifact)).To(BeFalse())
+	g.Expect(prefixed.ArtifactExist(current)).To(BeFalse())
+}
+
+func TestStorage_ShardArtifacts(t *testing.T) {
+	g := NewWithT(t)
+	dir := t.TempDir()
+
+	obj := &metav1.ObjectMeta{Name: "podinfo", Namespace: "default"}
+	shard := artifactShard("gitrepository/default/podinfo")
+
+	legacy, err := NewStorage(dir, "hostname", time.Minute, 2)
+	g.Expect(err).ToNot(HaveOccurred())
+	sharded, err := NewStorage(dir, "hostname", time.Minute, 2)
+	g.Expect(err).ToNot(HaveOccurred())
+	sharded.ShardArtifacts = true
+
+	writeArtifact := func(s *Storage, revision string) sourcev1.Artifact {
+		artifact := s.NewArtifactFor(sourcev1.GitRepositoryKind, obj, revision, revision+".tar.gz")
+		g.Expect(s.MkdirAll(artifact)).To(Succeed())
+		g.Expect(s.AtomicWriteFile(&artifact, strings.NewReader(revision), 0o600)).To(Succeed())
+		return artifact
+	}
+
+	legacyArtifact := writeArtifact(legacy, "1234")
+	g.Expect(legacyArtifact.Path).To(Equal("gitrepository/default/podinfo/1234.tar.gz"))
+
+	shardedArtifact := writeArtifact(sharded, "5678")
+	g.Expect(shardedArtifact.Path).To(Equal(shard + "/gitrepository/default/podinfo/5678.tar.gz"))
+	g.Expect(shardedArtifact.URL).To(Equal("http://hostname/" + shard + "/gitrepository/default/podinfo/5678.tar.gz"))
+	g.Expect(sharded.LocalPath(shardedArtifact)).To(Equal(filepath.Join(dir, shard, "gitrepository", "default", "podinfo", "5678.tar.gz")))
+
+	// Artifacts in the legacy layout are still served
+	g.Expect(sharded.ArtifactExist(legacyArtifact)).To(BeTrue())
+	g.Expect(sharded.LocalPath(legacyArtifact)).To(Equal(filepath.Join(dir, "gitrepository", "default", "podinfo", "1234.tar.gz")))
+
+	// Garbage collection of the current artifact retains the superseded
+	// artifacts in the legacy layout, until they expire
+	deleted, retained, err := sharded.GarbageCollectHistory(context.TODO(), shardedArtifact, nil, 2, time.Second)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(deleted).To(BeEmpty())
+	g.Expect(retained).To(Equal(1))
+	g.Expect(sharded.ArtifactExist(legacyArtifact)).To(BeTrue())
+
+	expired := time.Now().Add(-2 * time.Minute)
+	g.Expect(os.Chtimes(legacy.LocalPath(legacyArtifact), expired, expired)).To(Succeed())
+
+	// A retention TTL override also applies with a history limit
+	deleted, _, err = sharded.GarbageCollectWithOptions(context.TODO(), shardedArtifact, nil, 2,
+		GarbageCollectOptions{Timeout: time.Second, RetentionTTL: 10 * time.Minute})
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(deleted).To(BeEmpty())
+	g.Expect(sharded.ArtifactExist(legacyArtifact)).To(BeTrue())
+
+	deleted, retained, err = sharded.GarbageCollectHistory(context.TODO(), shardedArtifact, nil, 2, time.Second)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(deleted).To(ConsistOf(legacy.LocalPath(legacyArtifact)))
+	g.Expect(retained).To(Equal(1))
+	g.Expect(sharded.ArtifactExist(legacyArtifact)).To(BeFalse())
+	g.Expect(filepath.Dir(legacy.LocalPath(legacyArtifact))).ToNot(BeADirectory())
+	g.Expect(sharded.ArtifactExist(shardedArtifact)).To(BeTrue())
+
+	// And the other way around, when the sharded layout is disabled again
+	legacyArtifact = writeArtifact(legacy, "1234")
+	g.Expect(os.Chtimes(sharded.LocalPath(shardedArtifact), expired, expired)).To(Succeed())
+	deleted, _, err = legacy.GarbageCollectWithOptions(context.TODO(), legacyArtifact, nil, 0, GarbageCollectOptions{Timeout: time.Second})
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(deleted).To(ConsistOf(sharded.LocalPath(shardedArtifact)))
+	g.Expect(legacy.ArtifactExist(shardedArtifact)).To(BeFalse())
+
+	// Garbage collection of a deleted object covers both layouts
+	legacyArtifact = writeArtifact(legacy, "1234")
+	shardedArtifact = writeArtifact(sharded, "5678")
+	for _, s := range []*Storage{legacy, sharded} {
+		g.Expect(s.otherLayoutDir(s.NewArtifactFor(sourcev1.GitRepositoryKind, obj, "", "*"))).ToNot(BeEmpty())
+	}
+	_, err = sharded.RemoveAll(sharded.NewArtifactFor(sourcev1.GitRepositoryKind, obj, "", "*"))
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(sharded.ArtifactExist(legacyArtifact)).To(BeFalse())
+	g.Expect(sharded.ArtifactExist(shardedArtifact)).To(BeFalse())
+
+	// The layouts are scoped to the ArtifactPrefix
+	sharded.ArtifactPrefix = "cluster-a"
+	prefixed := sharded.NewArtifactFor(sourcev1.GitRepositoryKind, obj, "", "1234.tar.gz")
+	g.Expect(prefixed.Path).To(Equal("cluster-a/" + shard + "/gitrepository/default/podinfo/1234.tar.gz"))
+	g.Expect(sharded.otherLayoutDir(prefixed)).To(Equal(filepath.Join(dir, "cluster-a", "gitrepository", "default", "podinfo")))
+	g.Expect(sharded.otherLayoutDir(legacyArtifact)).To(BeEmpty())
+}
+
+func TestStorage_VerifyArtifact(t *testing.T) {
+	g := NewWithT(t)
+
+	s, err := NewStorage(t.TempDir(), "hostname", time.Minute, 2)
+	g.Expect(err).ToNot(HaveOccurred())
+
+	artifact := s.NewArtifactFor(sourcev1.GitRepositoryKind, &metav1.ObjectMeta{Name: "podinfo", Namespace: "default"},
+		"main/1234", "1234.tar.gz")
+	g.Expect(s.MkdirAll(artifact)).To(Succeed())
+	g.Expect(s.AtomicWriteFile(&artifact, strings.NewReader("content"), 0o600)).To(Succeed())
+	g.Expect(s.VerifyArtifact(artifact)).To(Succeed())
+
+	// A partially written file does not match the recorded checksum
+	g.Expect(os.WriteFile(s.LocalPath(artifact), []byte("cont"), 0o600)).To(Succeed())
+	err = s.VerifyArtifact(artifact)
+	g.Expect(err).To(HaveOccurred())
+	g.Expect(err.Error()).To(ContainSubstring("does not match recorded checksum '" + artifact.Checksum + "'"))
+
+	// An artifact without a recorded checksum is not verified
+	unrecorded := artifact.DeepCopy()
+	unrecorded.Checksum = ""
+	g.Expect(s.VerifyArtifact(*unrecorded)).To(Succeed())
+
+	// A missing file fails the verification
+	g.Expect(os.Remove(s.LocalPath(artifact))).To(Succeed())
+	g.Expect(s.VerifyArtifact(artifact)).ToNot(Succeed())
+}
+
+func TestStorage_ReuseArtifact(t *testing.T) {
+	g := NewWithT(t)
+
+	s, err := NewStorage(t.TempDir(), "hostname", time.Minute, 2)
+	g.Expect(err).ToNot(HaveOccurred())
+
+	dir := t.TempDir()
+	g.Expect(os.WriteFile(filepath.Join(dir, "deploy.yaml"), []byte("kind: Deployment"), 0o600)).To(Succeed())
+	g.Expect(os.WriteFile(filepath.Join(dir, "README.md"), []byte("# README"), 0o600)).To(Succeed())
+
+	obj := &metav1.ObjectMeta{Name: "podinfo", Namespace: "default"}
+	current := s.NewArtifactFor(sourcev1.OCIRepositoryKind, obj, "v1", "v1.tar.gz")
+	g.Expect(s.MkdirAll(current)).To(Succeed())
+	g.Expect(s.Archive(&current, dir, nil)).To(Succeed())
+	g.Expect(current.ContentChecksum).ToNot(BeEmpty())
+
+	// The modification times are not part of the content
+	future := time.Now().Add(time.Hour)
+	g.Expect(os.Chtimes(filepath.Join(dir, "deploy.yaml"), future, future)).To(Succeed())
+	g.Expect(s.ContentChecksum(dir, nil)).To(Equal(current.ContentChecksum))
+
+	// Identical content yields no new checksum, and reuses the current file
+	// at the path of the new revision
+	artifact := s.NewArtifactFor(sourcev1.OCIRepositoryKind, obj, "v2", "v2.tar.gz")
+	reused, err := s.ReuseArtifact(&artifact, &current, dir, nil)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(reused).To(BeTrue())
+	g.Expect(artifact.Revision).To(Equal("v2"))
+	g.Expect(artifact.Path).To(HaveSuffix("v2.tar.gz"))
+	g.Expect(artifact.URL).To(HaveSuffix("v2.tar.gz"))
+	g.Expect(artifact.Checksum).To(Equal(current.Checksum))
+	g.Expect(artifact.ContentChecksum).To(Equal(current.ContentChecksum))
+	g.Expect(s.ArtifactExist(artifact)).To(BeTrue())
+	g.Expect(s.VerifyArtifact(artifact)).To(Succeed())
+
+	// Archiving the identical content again yields the same checksums
+	archived := s.NewArtifactFor(sourcev1.OCIRepositoryKind, obj, "v2", "v2.tar.gz")
+	g.Expect(s.Archive(&archived, dir, nil)).To(Succeed())
+	g.Expect(archived.Checksum).To(Equal(current.Checksum))
+	g.Expect(archived.ContentChecksum).To(Equal(current.ContentChecksum))
+
+	// Changed content is not reused
+	g.Expect(os.WriteFile(filepath.Join(dir, "README.md"), []byte("# Changed"), 0o600)).To(Succeed())
+	artifact = s.NewArtifactFor(sourcev1.OCIRepositoryKind, obj, "v3", "v3.tar.gz")
+	reused, err = s.ReuseArtifact(&artifact, &current, dir, nil)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(reused).To(BeFalse())
+	g.Expect(artifact.Path).ToNot(Equal(current.Path))
+
+	// An artifact of a different format is not reused
+	g.Expect(os.WriteFile(filepath.Join(dir, "README.md"), []byte("# README"), 0o600)).To(Succeed())
+	artifact = s.NewArtifactFor(sourcev1.OCIRepositoryKind, obj, "v3", "v3.tar")
+	reused, err = s.ReuseArtifact(&artifact, &current, dir, nil)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(reused).To(BeFalse())
+
+	// An artifact which no longer exists in storage is not reused
+	g.Expect(os.Remove(s.LocalPath(current))).To(Succeed())
+	artifact = s.NewArtifactFor(sourcev1.OCIRepositoryKind, obj, "v3", "v3.tar.gz")
+	reused, err = s.ReuseArtifact(&artifact, &current, dir, nil)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(reused).To(BeFalse())
+}
+
+func TestStorage_TarballContentChecksum(t *testing.T) {
+	g := NewWithT(t)
+
+	s, err := NewStorage(t.TempDir(), "hostname", time.Minute, 2)
+	g.Expect(err).ToNot(HaveOccurred())
+
+	write := func(modTime time.Time, content string) string {
+		p := filepath.Join(t.TempDir(), "chart.tgz")
+		f, err := os.Create(p)
+		g.Expect(err).ToNot(HaveOccurred())
+		defer f.Close()
+		gw := gzip.NewWriter(f)
+		tw := tar.NewWriter(gw)
+		g.Expect(tw.WriteHeader(&tar.Header{
+			Name:    "chart/Chart.yaml",
+			Mode:    0o644,
+			Size:    int64(len(content)),
+			ModTime: modTime,
+		})).To(Succeed())
+		_, err = tw.Write([]byte(content))
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(tw.Close()).To(Succeed())
+		g.Expect(gw.Close()).To(Succeed())
+		return p
+	}
+
+	checksum, err := s.TarballContentChecksum(write(time.Now(), "name: chart"))
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(s.TarballContentChecksum(write(time.Now().Add(time.Hour), "name: chart"))).To(Equal(checksum))
+	g.Expect(s.TarballContentChecksum(write(time.Now(), "name: other"))).ToNot(Equal(checksum))
+}
+
+func TestStorage_NormalizeFileModes(t *testing.T) {
+	g := NewWithT(t)
+
+	s, err := NewStorage(t.TempDir(), "hostname", time.Minute, 2)
+	g.Expect(err).ToNot(HaveOccurred())
+
+	createDir := func(fileMode, dirMode os.FileMode, mtime time.Time) string {
+		dir := t.TempDir()
+		g.Expect(os.Mkdir(filepath.Join(dir, "sub"), dirMode)).To(Succeed())
+		for _, name := range []string{"deploy.yaml", "sub/run.sh"} {
+			p := filepath.Join(dir, name)
+			g.Expect(os.WriteFile(p, []byte(name), 0o600)).To(Succeed())
+			mode := fileMode
+			if name == "sub/run.sh" {
+				mode |= 0o100
+			}
+			g.Expect(os.Chmod(p, mode)).To(Succeed())
+			g.Expect(os.Chtimes(p, mtime, mtime)).To(Succeed())
+		}
+		g.Expect(os.Chmod(filepath.Join(dir, "sub"), dirMode)).To(Succeed())
+		return dir
+	}
+	dir1 := createDir(0o600, 0o700, time.Now())
+	dir2 := createDir(0o664, 0o775, time.Now().Add(-time.Hour))
+
+	obj := &metav1.ObjectMeta{Name: "podinfo", Namespace: "default"}
+	archive := func(dir, revision string) sourcev1.Artifact {
+		artifact := s.NewArtifactFor(sourcev1.GitRepositoryKind, obj, revision, revision+".tar.gz")
+		g.Expect(s.MkdirAll(artifact)).To(Succeed())
+		g.Expect(s.Archive(&artifact, dir, nil)).To(Succeed())
+		return artifact
+	}
+
+	// The modes of the source are preserved by default
+	g.Expect(archive(dir1, "1").Checksum).ToNot(Equal(archive(dir2, "2").Checksum))
+
+	s.NormalizeFileModes = true
+	artifact1 := archive(dir1, "3")
+	artifact2 := archive(dir2, "4")
+	g.Expect(artifact1.Checksum).To(Equal(artifact2.Checksum))
+	g.Expect(artifact1.ContentChecksum).To(Equal(artifact2.ContentChecksum))
+
+	f, err := os.Open(s.LocalPath(artifact1))
+	g.Expect(err).ToNot(HaveOccurred())
+	defer f.Close()
+	gr, err := gzip.NewReader(f)
+	g.Expect(err).ToNot(HaveOccurred())
+	tr := tar.NewReader(gr)
+	modes := map[string]int64{}
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		g.Expect(err).ToNot(HaveOccurred())
+		modes[hdr.Name] = hdr.Mode
+		g.Expect(hdr.ModTime.IsZero() || hdr.ModTime.Unix() == 0).To(BeTrue())
+		g.Expect(hdr.Uid).To(BeZero())
+		g.Expect(hdr.Gid).To(BeZero())
+	}
+	g.Expect(modes).To(HaveKeyWithValue("deploy.yaml", int64(0o644)))
+	g.Expect(modes).To(HaveKeyWithValue("sub", int64(0o755)))
+	g.Expect(modes).To(HaveKeyWithValue("sub/run.sh", int64(0o755)))
+}
+
+func TestStorage_ChecksumAlgorithm(t *testing.T) {
+	g := NewWithT(t)
+
+	s, err := NewStorage(t.TempDir(), "hostname", time.Minute, 2)
+	g.Expect(err).ToNot(HaveOccurred())
+
+	dir := t.TempDir()
+	g.Expect(os.WriteFile(filepath.Join(dir, "deploy.yaml"), []byte("kind: Deployment"), 0o600)).To(Succeed())
+
+	obj := &metav1.ObjectMeta{Name: "podinfo", Namespace: "default"}
+	archive := func(revision string) sourcev1.Artifact {
+		artifact := s.NewArtifactFor(sourcev1.GitRepositoryKind, obj, revision, revision+".tar.gz")
+		g.Expect(s.MkdirAll(artifact)).To(Succeed())
+		g.Expect(s.Archive(&artifact, dir, nil)).To(Succeed())
+		return artifact
+	}
+
+	// SHA256 checksums are recorded without a prefix by default
+	sha256Artifact := archive("1")
+	g.Expect(sha256Artifact.Checksum).To(HaveLen(64))
+	g.Expect(checksumAlgorithm(sha256Artifact.Checksum)).To(Equal(ChecksumSHA256))
+
+	s.ChecksumAlgorithm = ChecksumSHA512
+	sha512Artifact := archive("2")
+	g.Expect(sha512Artifact.Checksum).To(HavePrefix(ChecksumSHA512 + ":"))
+	g.Expect(sha512Artifact.Checksum).To(HaveLen(len(ChecksumSHA512) + 1 + 128))
+	g.Expect(sha512Artifact.ContentChecksum).To(HavePrefix(ChecksumSHA512 + ":"))
+	g.Expect(s.Checksum(strings.NewReader("data"))).To(HavePrefix(ChecksumSHA512 + ":"))
+
+	// Artifacts are verified with the algorithm of their checksum, regardless
+	// of the algorithm of the storage
+	g.Expect(s.VerifyArtifact(sha256Artifact)).To(Succeed())
+	g.Expect(s.VerifyArtifact(sha512Artifact)).To(Succeed())
+	s.ChecksumAlgorithm = ""
+	g.Expect(s.VerifyArtifact(sha512Artifact)).To(Succeed())
+	sha512Artifact.Checksum = ChecksumSHA512 + ":" + strings.Repeat("0", 128)
+	g.Expect(s.VerifyArtifact(sha512Artifact)).To(MatchError(ContainSubstring("does not match recorded checksum")))
+
+	// An artifact of the same revision archived with another algorithm is
+	// not a new artifact
+	sha512Artifact.Revision = sha256Artifact.Revision
+	g.Expect(artifactChanged(&sha256Artifact, &sha512Artifact)).To(BeFalse())
+}
+
+func TestStorage_ReadyCheck(t *testing.T) {
+	g := NewWithT(t)
+
+	s, err := NewStorage(t.TempDir(), "hostname", time.Minute, 2)
+	g.Expect(err).ToNot(HaveOccurred())
+
+	g.Expect(s.ReadyCheck(nil)).To(Succeed())
+	g.Expect(filepath.Join(s.BasePath, storageProbePath)).To(BeARegularFile())
+	// The probe can be repeated
+	g.Expect(s.ReadyCheck(nil)).To(Succeed())
+
+	// A held probe lock is given up on when the request is done
+	unlock, err := s.Lock(sourcev1.Artifact{Path: storageProbePath})
+	g.Expect(err).ToNot(HaveOccurred())
+	ctx, cancel := context.WithCancel(context.TODO())
+	cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, "/readyz", nil)
+	g.Expect(err).ToNot(HaveOccurred())
+	err = s.ReadyCheck(req)
+	g.Expect(err).To(HaveOccurred())
+	g.Expect(err.Error()).To(ContainSubstring("failed to acquire lock for storage probe"))
+	unlock()
+
+	// A storage path which can not hold directories is not ready
+	g.Expect(os.RemoveAll(s.BasePath)).To(Succeed())
+	g.Expect(os.WriteFile(s.BasePath, []byte("file"), 0o600)).To(Succeed())
+	err = s.ReadyCheck(nil)
+	g.Expect(err).To(HaveOccurred())
+	g.Expect(err.Error()).To(ContainSubstring("failed to create storage probe directory"))
+}
+
+func TestStorage_CopyFromPath_fileCount(t *testing.T) {
+	g := NewWithT(t)
+
+	s, err := NewStorage(t.TempDir(), "hostname", time.Minute, 2)
+	g.Expect(err).ToNot(HaveOccurred())
+
+	dir := t.TempDir()
+	g.Expect(os.MkdirAll(filepath.Join(dir, "templates"), 0o750)).To(Succeed())
+	for _, name := range []string{"Chart.yaml", "values.yaml", filepath.Join("templates", "deployment.yaml")} {
+		g.Expect(os.WriteFile(filepath.Join(dir, name), []byte(name), 0o600)).To(Succeed())
+	}
+
+	archive := sourcev1.Artifact{Path: filepath.Join("helmchart", "default", "podinfo", "archive.tgz")}
+	g.Expect(s.MkdirAll(archive)).To(Succeed())
+	g.Expect(s.Archive(&archive, dir, nil)).To(Succeed())
+
+	// The files of a tarball are counted
+	artifact := sourcev1.Artifact{Path: filepath.Join("helmchart", "default", "podinfo", "podinfo-6.1.5.tgz")}
+	g.Expect(s.CopyFromPath(&artifact, s.LocalPath(archive))).To(Succeed())
+	g.Expect(artifact.FileCount).ToNot(BeNil())
+	g.Expect(*artifact.FileCount).To(Equal(int64(3)))
+
+	// Other files have no file count
+	g.Expect(s.CopyFromPath(&artifact, filepath.Join(dir, "values.yaml"))).To(Succeed())
+	g.Expect(artifact.FileCount).To(BeNil())
+}
+
+func TestStorage_LockWithContext(t *testing.T) {
+	g := NewWithT(t)
+
+	s, err := NewStorage(t.TempDir(), "hostname", time.Minute, 2)
+	g.Expect(err).ToNot(HaveOccurred())
+	s.LockAttempts = 3
+
+	artifact := sourcev1.Artifact{Path: filepath.Join("foo", "bar", "artifact.tar.gz")}
+
+	// Failures are retried up to the number of attempts
+	_, err = s.LockWithContext(context.TODO(), artifact)
+	g.Expect(err).To(HaveOccurred())
+	g.Expect(err.Error()).To(ContainSubstring("(3 attempt(s))"))
+
+	g.Expect(s.MkdirAll(artifact)).To(Succeed())
+	unlock, err := s.LockWithContext(context.TODO(), artifact)
+	g.Expect(err).ToNot(HaveOccurred())
+
+	// A held lock is given up on when the timeout expires
+	s.LockTimeout = 200 * time.Millisecond
+	_, err = s.LockWithContext(context.TODO(), artifact)
+	g.Expect(err).To(HaveOccurred())
+	g.Expect(err.Error()).To(ContainSubstring(context.DeadlineExceeded.Error()))
+
+	// Or when the context is done
+	s.LockTimeout = 0
+	ctx, cancel := context.WithCancel(context.TODO())
+	cancel()
+	_, err = s.LockWithContext(ctx, artifact)
+	g.Expect(err).To(HaveOccurred())
+	g.Expect(err.Error()).To(ContainSubstring(context.Canceled.Error()))
+
+	// The lock can be acquired again once released, as the attempts above
+	// given up on did not leave anything holding it
+	unlock()
+	s.LockTimeout = 5 * time.Second
+	unlock, err = s.LockWithContext(context.TODO(), artifact)
+	g.Expect(err).ToNot(HaveOccurred())
+	unlock()
+}
+
 func TestStorageCopyFromPath(t *testing.T) {
 	type File struct {
 		Name    string
@@ -616,6 +1270,33 @@ func TestStorage_getGarbageFiles(t *testing.T) {
 	}
 }
 
+func TestStorage_getGarbageFiles_equalModTimes(t *testing.T) {
+	g := NewWithT(t)
+	dir := t.TempDir()
+
+	s, err := NewStorage(dir, "hostname", time.Minute, 2)
+	g.Expect(err).ToNot(HaveOccurred(), "failed to create new storage")
+
+	artifactFolder := filepath.Join("foo", "bar")
+	g.Expect(os.MkdirAll(filepath.Join(dir, artifactFolder), 0o750)).To(Succeed())
+	// All artifacts share the same modification time.
+	modTime := time.Now().Add(-time.Second)
+	names := []string{"artifact1.tar.gz", "artifact2.tar.gz", "artifact3.tar.gz", "artifact4.tar.gz", "artifact5.tar.gz"}
+	for _, n := range names {
+		p := filepath.Join(dir, artifactFolder, n)
+		g.Expect(os.WriteFile(p, []byte(n), 0o600)).To(Succeed())
+		g.Expect(os.Chtimes(p, modTime, modTime)).To(Succeed())
+	}
+	artifact := sourcev1.Artifact{
+		Path: filepath.Join(artifactFolder, names[len(names)-1]),
+	}
+
+	deletedPaths, err := s.getGarbageFiles(artifact, 10, 2, time.Minute)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(deletedPaths).To(HaveLen(3))
+	g.Expect(deletedPaths).ToNot(ContainElement(s.LocalPath(artifact)))
+}
+
 func TestStorage_GarbageCollect(t *testing.T) {
 	artifactFolder := filepath.Join("foo", "bar")
 	tests := []struct {
@@ -711,3 +1392,239 @@ func TestStorage_GarbageCollect(t *testing.T) {
 		})
 	}
 }
+
+func TestStorage_GarbageCollectHistory(t *testing.T) {
+	artifactFolder := filepath.Join("foo", "bar")
+	tests := []struct {
+		name         string
+		historyLimit int
+		history      []string
+		wantRetained []string
+		wantDeleted  []string
+	}{
+		{
+			name: "history limit of 0 collects according to retention options",
+			history: []string{
+				"artifact1.tar.gz",
+				"artifact2.tar.gz",
+				"artifact3.tar.gz",
+				"artifact4.tar.gz",
+			},
+			wantRetained: []string{
+				"artifact4.tar.gz",
+				"artifact5.tar.gz",
+			},
+			wantDeleted: []string{
+				"artifact1.tar.gz",
+				"artifact2.tar.gz",
+				"artifact3.tar.gz",
+			},
+		},
+		{
+			name:         "retains history limit of artifacts next to current by last update time",
+			historyLimit: 3,
+			history: []string{
+				"artifact1.tar.gz",
+				"artifact2.tar.gz",
+				"artifact3.tar.gz",
+				"artifact4.tar.gz",
+			},
+			wantRetained: []string{
+				"artifact1.tar.gz",
+				"artifact2.tar.gz",
+				"artifact3.tar.gz",
+				"artifact5.tar.gz",
+			},
+			wantDeleted: []string{
+				"artifact4.tar.gz",
+			},
+		},
+		{
+			name:         "history limit exceeding artifacts retains all",
+			historyLimit: 10,
+			history: []string{
+				"artifact1.tar.gz",
+				"artifact2.tar.gz",
+				"artifact3.tar.gz",
+				"artifact4.tar.gz",
+			},
+			wantRetained: []string{
+				"artifact1.tar.gz",
+				"artifact2.tar.gz",
+				"artifact3.tar.gz",
+				"artifact4.tar.gz",
+				"artifact5.tar.gz",
+			},
+		},
+		{
+			name:         "artifacts not recorded in the history are collected",
+			historyLimit: 3,
+			history: []string{
+				"artifact3.tar.gz",
+			},
+			wantRetained: []string{
+				"artifact3.tar.gz",
+				"artifact5.tar.gz",
+			},
+			wantDeleted: []string{
+				"artifact1.tar.gz",
+				"artifact2.tar.gz",
+				"artifact4.tar.gz",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+			dir := t.TempDir()
+
+			s, err := NewStorage(dir, "hostname", time.Minute, 2)
+			g.Expect(err).ToNot(HaveOccurred(), "failed to create new storage")
+
+			g.Expect(os.MkdirAll(filepath.Join(dir, artifactFolder), 0o750)).To(Succeed())
+			// Artifacts are created with increasing modification times, the
+			// last one being the current. The last update times of the
+			// artifacts recorded in the history are in the opposite order.
+			names := []string{"artifact1.tar.gz", "artifact2.tar.gz", "artifact3.tar.gz", "artifact4.tar.gz", "artifact5.tar.gz"}
+			modTime := time.Now().Add(-time.Duration(len(names)) * time.Second)
+			for i, n := range names {
+				p := filepath.Join(dir, artifactFolder, n)
+				g.Expect(os.WriteFile(p, []byte(n), 0o600)).To(Succeed())
+				ts := modTime.Add(time.Duration(i) * time.Second)
+				g.Expect(os.Chtimes(p, ts, ts)).To(Succeed())
+			}
+			artifact := sourcev1.Artifact{
+				Path: filepath.Join(artifactFolder, names[len(names)-1]),
+			}
+			var history []sourcev1.Artifact
+			for i, n := range names {
+				if !stringInSlice(n, tt.history) {
+					continue
+				}
+				history = append(history, sourcev1.Artifact{
+					Path:           filepath.Join(artifactFolder, n),
+					LastUpdateTime: metav1.NewTime(modTime.Add(-time.Duration(i) * time.Second)),
+				})
+			}
+
+			deleted, retained, err := s.GarbageCollectHistory(context.TODO(), artifact, history, tt.historyLimit, time.Second)
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(deleted).To(HaveLen(len(tt.wantDeleted)))
+			g.Expect(retained).To(Equal(len(tt.wantRetained)))
+			for _, n := range tt.wantRetained {
+				g.Expect(filepath.Join(dir, artifactFolder, n)).To(BeAnExistingFile())
+			}
+			for _, n := range tt.wantDeleted {
+				g.Expect(filepath.Join(dir, artifactFolder, n)).ToNot(BeAnExistingFile())
+			}
+		})
+	}
+}
+
+func TestStorage_GarbageCollectWithOptions(t *testing.T) {
+	artifactFolder := filepath.Join("foo", "bar")
+	tests := []struct {
+		name         string
+		historyLimit int
+		opts         GarbageCollectOptions
+		wantRetained []string
+		wantDeleted  []string
+	}{
+		{
+			name: "empty options collect according to storage retention",
+			wantRetained: []string{
+				"artifact4.tar.gz",
+				"artifact5.tar.gz",
+			},
+			wantDeleted: []string{
+				"artifact1.tar.gz",
+				"artifact2.tar.gz",
+				"artifact3.tar.gz",
+			},
+		},
+		{
+			name: "retention records override storage retention",
+			opts: GarbageCollectOptions{RetentionRecords: 4},
+			wantRetained: []string{
+				"artifact2.tar.gz",
+				"artifact3.tar.gz",
+				"artifact4.tar.gz",
+				"artifact5.tar.gz",
+			},
+			wantDeleted: []string{
+				"artifact1.tar.gz",
+			},
+		},
+		{
+			name: "retention TTL overrides storage retention",
+			opts: GarbageCollectOptions{RetentionRecords: 4, RetentionTTL: 3500 * time.Millisecond},
+			wantRetained: []string{
+				"artifact3.tar.gz",
+				"artifact4.tar.gz",
+				"artifact5.tar.gz",
+			},
+			wantDeleted: []string{
+				"artifact1.tar.gz",
+				"artifact2.tar.gz",
+			},
+		},
+		{
+			name:         "history limit takes precedence over retention options",
+			historyLimit: 1,
+			opts:         GarbageCollectOptions{RetentionRecords: 4},
+			wantRetained: []string{
+				"artifact4.tar.gz",
+				"artifact5.tar.gz",
+			},
+			wantDeleted: []string{
+				"artifact1.tar.gz",
+				"artifact2.tar.gz",
+				"artifact3.tar.gz",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+			dir := t.TempDir()
+
+			s, err := NewStorage(dir, "hostname", time.Minute, 2)
+			g.Expect(err).ToNot(HaveOccurred(), "failed to create new storage")
+
+			g.Expect(os.MkdirAll(filepath.Join(dir, artifactFolder), 0o750)).To(Succeed())
+			// Artifacts are created one second apart, from five seconds ago,
+			// the last one being the current.
+			names := []string{"artifact1.tar.gz", "artifact2.tar.gz", "artifact3.tar.gz", "artifact4.tar.gz", "artifact5.tar.gz"}
+			modTime := time.Now().Add(-time.Duration(len(names)) * time.Second)
+			for i, n := range names {
+				p := filepath.Join(dir, artifactFolder, n)
+				g.Expect(os.WriteFile(p, []byte(n), 0o600)).To(Succeed())
+				ts := modTime.Add(time.Duration(i) * time.Second)
+				g.Expect(os.Chtimes(p, ts, ts)).To(Succeed())
+			}
+			artifact := sourcev1.Artifact{
+				Path: filepath.Join(artifactFolder, names[len(names)-1]),
+			}
+			var history []sourcev1.Artifact
+			for i, n := range names[:len(names)-1] {
+				history = append(history, sourcev1.Artifact{
+					Path:           filepath.Join(artifactFolder, n),
+					LastUpdateTime: metav1.NewTime(modTime.Add(time.Duration(i) * time.Second)),
+				})
+			}
+
+			deleted, retained, err := s.GarbageCollectWithOptions(context.TODO(), artifact, history, tt.historyLimit, tt.opts)
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(deleted).To(HaveLen(len(tt.wantDeleted)))
+			g.Expect(retained).To(Equal(len(tt.wantRetained)))
+			for _, n := range tt.wantRetained {
+				g.Expect(filepath.Join(dir, artifactFolder, n)).To(BeAnExistingFile())
+			}
+			for _, n := range tt.wantDeleted {
+				g.Expect(filepath.Join(dir, artifactFolder, n)).ToNot(BeAnExistingFile())
+			}
+		})
+	}
+}
@@ -0,0 +1,249 @@
+/*
+Copyright 2022 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package archive
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+
+	"github.com/fluxcd/pkg/untar"
+	"github.com/klauspost/compress/zstd"
+)
+
+// Compression is the compression algorithm of a tarball.
+type Compression string
+
+const (
+	// Gzip is the gzip compression algorithm.
+	Gzip Compression = "gzip"
+	// Zstd is the Zstandard compression algorithm.
+	Zstd Compression = "zstd"
+)
+
+// compressionSuffixRegexp matches the compression suffix of a tarball media
+// type, e.g. 'application/vnd.oci.image.layer.v1.tar+zstd' or
+// 'application/vnd.docker.image.rootfs.diff.tar.gzip'.
+var compressionSuffixRegexp = regexp.MustCompile(`\.tar(?:[+.]([0-9a-z]+))?$`)
+
+// CompressionForMediaType returns the Compression of a tarball with the given
+// media type. Media types which do not denote a tarball are assumed to be
+// gzip compressed. It returns an error for a tarball compressed with an
+// unsupported algorithm, or not compressed at all.
+func CompressionForMediaType(mediaType string) (Compression, error) {
+	m := compressionSuffixRegexp.FindStringSubmatch(mediaType)
+	if m == nil {
+		return Gzip, nil
+	}
+	switch m[1] {
+	case "gzip", "gz":
+		return Gzip, nil
+	case "zstd":
+		return Zstd, nil
+	case "":
+		return "", fmt.Errorf("unsupported media type '%s': the tarball is not compressed", mediaType)
+	default:
+		return "", fmt.Errorf("unsupported media type '%s': unsupported compression '%s'", mediaType, m[1])
+	}
+}
+
+// ErrTruncated is returned by Untar when the tarball ends unexpectedly, e.g.
+// because the download of the tarball was interrupted.
+var ErrTruncated = errors.New("truncated tarball")
+
+// LimitExceededError is returned when a configured limit is exceeded while
+// reading or extracting an archive.
+type LimitExceededError struct {
+	// Limit is the name of the exceeded limit.
+	Limit string
+	// Max is the configured maximum.
+	Max int64
+}
+
+// Error returns the error message of the LimitExceededError.
+func (e *LimitExceededError) Error() string {
+	return fmt.Sprintf("%s limit of %d exceeded", e.Limit, e.Max)
+}
+
+// Limits holds the limits enforced by Untar. A zero value disables the
+// respective limit.
+type Limits struct {
+	// MaxSize is the maximum total size in bytes of the extracted files.
+	MaxSize int64
+	// MaxFiles is the maximum number of extracted entries, including
+	// directories and links.
+	MaxFiles int
+}
+
+// LimitReadCloser returns an io.ReadCloser that reads from rc, but returns a
+// LimitExceededError once more than n bytes would have been read.
+func LimitReadCloser(rc io.ReadCloser, n int64) io.ReadCloser {
+	return &limitedReadCloser{rc: rc, max: n, remaining: n}
+}
+
+type limitedReadCloser struct {
+	rc        io.ReadCloser
+	max       int64
+	remaining int64
+}
+
+func (l *limitedReadCloser) Read(p []byte) (int, error) {
+	if l.remaining < 0 {
+		return 0, &LimitExceededError{Limit: "size", Max: l.max}
+	}
+	// Read at most one byte beyond the limit, to be able to detect
+	// the limit being exceeded.
+	if int64(len(p)) > l.remaining+1 {
+		p = p[:l.remaining+1]
+	}
+	n, err := l.rc.Read(p)
+	l.remaining -= int64(n)
+	if l.remaining < 0 {
+		return n - 1, &LimitExceededError{Limit: "size", Max: l.max}
+	}
+	return n, err
+}
+
+func (l *limitedReadCloser) Close() error {
+	return l.rc.Close()
+}
+
+// Untar extracts the gzip compressed tarball read from r to dir using
+// untar.Untar, while enforcing the given limits on the tarball content.
+// When a limit is exceeded, extraction is aborted and a LimitExceededError
+// is returned. When an entry would be extracted outside of dir, e.g. with a
+// path containing '..' or through an absolute symlink, extraction is aborted
+// and an UnsafePathError is returned. When the tarball is truncated, an error wrapping ErrTruncated
+// is returned. In both cases, the files extracted so far are left in dir,
+// and it is up to the caller to discard them.
+func Untar(r io.Reader, dir string, limits Limits) (string, error) {
+	return UntarCompressed(r, dir, Gzip, limits)
+}
+
+// UntarCompressed extracts the tarball compressed with the given Compression
+// read from r to dir, like Untar.
+func UntarCompressed(r io.Reader, dir string, compression Compression, limits Limits) (string, error) {
+	// Stream the (limited) tarball through a pipe to untar.Untar, so the
+	// content is never extracted beyond the limits, and a truncated tarball
+	// can be told apart from other errors.
+	validator, err := newPathValidator(dir)
+	if err != nil {
+		return "", err
+	}
+	pr, pw := io.Pipe()
+	errCh := make(chan error, 1)
+	go func() {
+		err := copyWithLimits(pw, r, compression, limits, validator)
+		pw.CloseWithError(err)
+		errCh <- err
+	}()
+
+	summary, err := untar.Untar(pr, dir)
+	// Unblock the writer in case untar returned before consuming all data.
+	pr.Close()
+	if limitErr := <-errCh; limitErr != nil && !errors.Is(limitErr, io.ErrClosedPipe) {
+		return "", limitErr
+	}
+	return summary, err
+}
+
+// copyWithLimits reads the tarball compressed with the given Compression
+// from r, and writes it as an uncompressed gzip stream to w while enforcing
+// the given limits, and validating the entries with the given pathValidator.
+func copyWithLimits(w io.Writer, r io.Reader, compression Compression, limits Limits, validator *pathValidator) error {
+	zr, err := decompress(r, compression)
+	if err != nil {
+		return err
+	}
+	defer zr.Close()
+
+	zw, err := gzip.NewWriterLevel(w, gzip.NoCompression)
+	if err != nil {
+		return err
+	}
+
+	tr := tar.NewReader(zr)
+	tw := tar.NewWriter(zw)
+	var size int64
+	var files int
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return truncatedOr(fmt.Errorf("tar error: %w", err))
+		}
+		if err := validator.validate(hdr); err != nil {
+			return err
+		}
+
+		files++
+		if limits.MaxFiles > 0 && files > limits.MaxFiles {
+			return &LimitExceededError{Limit: "file count", Max: int64(limits.MaxFiles)}
+		}
+		size += hdr.Size
+		if limits.MaxSize > 0 && size > limits.MaxSize {
+			return &LimitExceededError{Limit: "size", Max: limits.MaxSize}
+		}
+
+		if err := tw.WriteHeader(hdr); err != nil {
+			return err
+		}
+		if _, err := io.Copy(tw, tr); err != nil {
+			return truncatedOr(err)
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	return zw.Close()
+}
+
+// decompress returns a reader of the decompressed content of the given
+// reader, according to the given Compression.
+func decompress(r io.Reader, compression Compression) (io.ReadCloser, error) {
+	switch compression {
+	case Gzip:
+		zr, err := gzip.NewReader(r)
+		if err != nil {
+			return nil, truncatedOr(fmt.Errorf("requires gzip-compressed body: %w", err))
+		}
+		return zr, nil
+	case Zstd:
+		zr, err := zstd.NewReader(r)
+		if err != nil {
+			return nil, fmt.Errorf("requires zstd-compressed body: %w", err)
+		}
+		return zr.IOReadCloser(), nil
+	default:
+		return nil, fmt.Errorf("unsupported compression '%s'", compression)
+	}
+}
+
+// truncatedOr returns an error wrapping ErrTruncated if the given error
+// signals an unexpected end of the tarball, or the given error otherwise.
+func truncatedOr(err error) error {
+	if errors.Is(err, io.ErrUnexpectedEOF) {
+		return fmt.Errorf("%w: %s", ErrTruncated, err)
+	}
+	return err
+}
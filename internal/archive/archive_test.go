@@ -0,0 +1,272 @@
+/*
+Copyright 2022 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package archive
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/klauspost/compress/zstd"
+	. "github.com/onsi/gomega"
+)
+
+func TestLimitReadCloser(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		max     int64
+		wantErr bool
+	}{
+		{name: "below limit", data: "hello", max: 10},
+		{name: "at limit", data: "hello", max: 5},
+		{name: "exceeds limit", data: "hello world", max: 5, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			rc := LimitReadCloser(io.NopCloser(strings.NewReader(tt.data)), tt.max)
+			b, err := io.ReadAll(rc)
+			if tt.wantErr {
+				var limitErr *LimitExceededError
+				g.Expect(errors.As(err, &limitErr)).To(BeTrue())
+				g.Expect(int64(len(b))).To(BeNumerically("<=", tt.max))
+				return
+			}
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(string(b)).To(Equal(tt.data))
+		})
+	}
+}
+
+func TestUntar(t *testing.T) {
+	files := map[string]int{
+		"a.txt":     100,
+		"dir/b.txt": 200,
+	}
+
+	tests := []struct {
+		name    string
+		limits  Limits
+		wantErr string
+	}{
+		{name: "no limits"},
+		{name: "within size limit", limits: Limits{MaxSize: 300}},
+		{name: "exceeds size limit", limits: Limits{MaxSize: 250}, wantErr: "size limit of 250 exceeded"},
+		{name: "within file count limit", limits: Limits{MaxFiles: 2}},
+		{name: "exceeds file count limit", limits: Limits{MaxFiles: 1}, wantErr: "file count limit of 1 exceeded"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			dir := t.TempDir()
+			_, err := Untar(bytes.NewReader(createTarball(t, files)), dir, tt.limits)
+			if tt.wantErr != "" {
+				g.Expect(err).To(HaveOccurred())
+				g.Expect(err.Error()).To(ContainSubstring(tt.wantErr))
+				return
+			}
+			g.Expect(err).ToNot(HaveOccurred())
+			for name, size := range files {
+				fi, err := os.Stat(filepath.Join(dir, name))
+				g.Expect(err).ToNot(HaveOccurred())
+				g.Expect(fi.Size()).To(Equal(int64(size)))
+			}
+		})
+	}
+}
+
+func TestUntar_singleHugeFile(t *testing.T) {
+	g := NewWithT(t)
+
+	tarball := createTarball(t, map[string]int{"huge.bin": 10 << 20})
+	_, err := Untar(bytes.NewReader(tarball), t.TempDir(), Limits{MaxSize: 1 << 20, MaxFiles: 10})
+	g.Expect(err).To(HaveOccurred())
+
+	var limitErr *LimitExceededError
+	g.Expect(errors.As(err, &limitErr)).To(BeTrue())
+	g.Expect(limitErr.Limit).To(Equal("size"))
+}
+
+func TestUntar_manyTinyFiles(t *testing.T) {
+	g := NewWithT(t)
+
+	files := make(map[string]int, 1000)
+	for i := 0; i < 1000; i++ {
+		files[fmt.Sprintf("dir/file-%d", i)] = 1
+	}
+	dir := t.TempDir()
+	_, err := Untar(bytes.NewReader(createTarball(t, files)), dir, Limits{MaxSize: 1 << 20, MaxFiles: 100})
+	g.Expect(err).To(HaveOccurred())
+
+	var limitErr *LimitExceededError
+	g.Expect(errors.As(err, &limitErr)).To(BeTrue())
+	g.Expect(limitErr.Limit).To(Equal("file count"))
+
+	// Extraction is aborted before all files are written
+	entries, err := os.ReadDir(filepath.Join(dir, "dir"))
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(len(entries)).To(BeNumerically("<=", 100))
+}
+
+func TestUntar_truncated(t *testing.T) {
+	tarball := createTarball(t, map[string]int{
+		"a.txt":     1 << 10,
+		"dir/b.txt": 1 << 20,
+	})
+
+	tests := []struct {
+		name          string
+		data          []byte
+		limits        Limits
+		wantTruncated bool
+	}{
+		{
+			name:          "truncated tarball",
+			data:          tarball[:len(tarball)/2],
+			wantTruncated: true,
+		},
+		{
+			name:          "truncated tarball with limits",
+			data:          tarball[:len(tarball)/2],
+			limits:        Limits{MaxSize: 10 << 20, MaxFiles: 10},
+			wantTruncated: true,
+		},
+		{
+			name:          "truncated gzip header",
+			data:          tarball[:5],
+			wantTruncated: true,
+		},
+		{
+			name: "invalid tarball",
+			data: []byte("not a gzip compressed tarball"),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			_, err := Untar(bytes.NewReader(tt.data), t.TempDir(), tt.limits)
+			g.Expect(err).To(HaveOccurred())
+			g.Expect(errors.Is(err, ErrTruncated)).To(Equal(tt.wantTruncated))
+		})
+	}
+}
+
+func TestUntarCompressed_zstd(t *testing.T) {
+	g := NewWithT(t)
+
+	gzipped := createTarball(t, map[string]int{"a.txt": 100, "dir/b.txt": 200})
+	zr, err := gzip.NewReader(bytes.NewReader(gzipped))
+	g.Expect(err).ToNot(HaveOccurred())
+	var buf bytes.Buffer
+	zw, err := zstd.NewWriter(&buf)
+	g.Expect(err).ToNot(HaveOccurred())
+	_, err = io.Copy(zw, zr)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(zw.Close()).To(Succeed())
+	tarball := buf.Bytes()
+
+	dir := t.TempDir()
+	_, err = UntarCompressed(bytes.NewReader(tarball), dir, Zstd, Limits{MaxSize: 300})
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(filepath.Join(dir, "a.txt")).To(BeARegularFile())
+	g.Expect(filepath.Join(dir, "dir/b.txt")).To(BeARegularFile())
+
+	_, err = UntarCompressed(bytes.NewReader(tarball), t.TempDir(), Zstd, Limits{MaxSize: 250})
+	var limitErr *LimitExceededError
+	g.Expect(errors.As(err, &limitErr)).To(BeTrue())
+
+	_, err = UntarCompressed(bytes.NewReader(tarball[:len(tarball)/2]), t.TempDir(), Zstd, Limits{})
+	g.Expect(errors.Is(err, ErrTruncated)).To(BeTrue())
+
+	_, err = UntarCompressed(bytes.NewReader(gzipped), t.TempDir(), Zstd, Limits{})
+	g.Expect(err).To(HaveOccurred())
+}
+
+func TestCompressionForMediaType(t *testing.T) {
+	tests := []struct {
+		mediaType string
+		want      Compression
+		wantErr   string
+	}{
+		{mediaType: "application/vnd.cncf.flux.content.v1.tar+gzip", want: Gzip},
+		{mediaType: "application/vnd.oci.image.layer.v1.tar+gzip", want: Gzip},
+		{mediaType: "application/vnd.docker.image.rootfs.diff.tar.gzip", want: Gzip},
+		{mediaType: "application/vnd.oci.image.layer.v1.tar+zstd", want: Zstd},
+		{mediaType: "application/vnd.example.config", want: Gzip},
+		{
+			mediaType: "application/vnd.oci.image.layer.v1.tar",
+			wantErr:   "the tarball is not compressed",
+		},
+		{
+			mediaType: "application/vnd.example.layer.tar+bzip2",
+			wantErr:   "unsupported compression 'bzip2'",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.mediaType, func(t *testing.T) {
+			g := NewWithT(t)
+
+			got, err := CompressionForMediaType(tt.mediaType)
+			if tt.wantErr != "" {
+				g.Expect(err).To(HaveOccurred())
+				g.Expect(err.Error()).To(ContainSubstring(tt.wantErr))
+				return
+			}
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(got).To(Equal(tt.want))
+		})
+	}
+}
+
+func createTarball(t *testing.T, files map[string]int) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(zw)
+	for name, size := range files {
+		if err := tw.WriteHeader(&tar.Header{
+			Name:     name,
+			Mode:     0o644,
+			Size:     int64(size),
+			Typeflag: tar.TypeReg,
+		}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write(bytes.Repeat([]byte("a"), size)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
@@ -0,0 +1,106 @@
+/*
+Copyright 2022 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package archive
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+)
+
+const (
+	// ContentTypeTarGzip is the content type of a gzip compressed tarball.
+	ContentTypeTarGzip = "application/tar+gzip"
+	// ContentTypeTarZstd is the content type of a Zstandard compressed
+	// tarball.
+	ContentTypeTarZstd = "application/tar+zstd"
+	// ContentTypeTar is the content type of an uncompressed tarball.
+	ContentTypeTar = "application/x-tar"
+	// ContentTypeGzip is the content type of gzip compressed content which
+	// is not a tarball.
+	ContentTypeGzip = "application/gzip"
+	// ContentTypeZstd is the content type of Zstandard compressed content
+	// which is not a tarball.
+	ContentTypeZstd = "application/zstd"
+)
+
+// sniffLen is the number of bytes considered to detect the content type,
+// which covers the header of the first entry of a tarball.
+const sniffLen = 512
+
+var (
+	gzipMagic = []byte{0x1f, 0x8b}
+	zstdMagic = []byte{0x28, 0xb5, 0x2f, 0xfd}
+)
+
+// DetectContentType returns the content type of the content of the given
+// reader, determined from its first bytes. Tarballs, and gzip or Zstandard
+// compressed tarballs, are recognized by the magic of the first entry of the
+// tarball, other content types are detected with http.DetectContentType,
+// which defaults to "application/octet-stream".
+func DetectContentType(r io.Reader) (string, error) {
+	header, err := readHeader(r)
+	if err != nil {
+		return "", err
+	}
+
+	var compression Compression
+	switch {
+	case bytes.HasPrefix(header, gzipMagic):
+		compression = Gzip
+	case bytes.HasPrefix(header, zstdMagic):
+		compression = Zstd
+	case isTarHeader(header):
+		return ContentTypeTar, nil
+	default:
+		return http.DetectContentType(header), nil
+	}
+
+	isTar := false
+	if zr, err := decompress(io.MultiReader(bytes.NewReader(header), r), compression); err == nil {
+		decompressed, _ := readHeader(zr)
+		_ = zr.Close()
+		isTar = isTarHeader(decompressed)
+	}
+	switch {
+	case compression == Gzip && isTar:
+		return ContentTypeTarGzip, nil
+	case compression == Gzip:
+		return ContentTypeGzip, nil
+	case isTar:
+		return ContentTypeTarZstd, nil
+	default:
+		return ContentTypeZstd, nil
+	}
+}
+
+// readHeader reads up to sniffLen bytes from the given reader.
+func readHeader(r io.Reader) ([]byte, error) {
+	header := make([]byte, sniffLen)
+	n, err := io.ReadFull(r, header)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return nil, err
+	}
+	return header[:n], nil
+}
+
+// isTarHeader returns true if the given bytes start with a tar header in the
+// POSIX (ustar) or GNU format.
+func isTarHeader(header []byte) bool {
+	const magicOffset = 257
+	return len(header) >= magicOffset+5 && bytes.Equal(header[magicOffset:magicOffset+5], []byte("ustar"))
+}
@@ -0,0 +1,81 @@
+/*
+Copyright 2022 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package archive
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"testing"
+
+	"github.com/klauspost/compress/zstd"
+	. "github.com/onsi/gomega"
+)
+
+func TestDetectContentType(t *testing.T) {
+	tarGzip := createTarball(t, map[string]int{"a.txt": 100})
+	zr, err := gzip.NewReader(bytes.NewReader(tarGzip))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tarball, err := io.ReadAll(zr)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	compress := func(content []byte, compression Compression) []byte {
+		var buf bytes.Buffer
+		var w io.WriteCloser
+		if compression == Zstd {
+			w, _ = zstd.NewWriter(&buf)
+		} else {
+			w = gzip.NewWriter(&buf)
+		}
+		if _, err := w.Write(content); err != nil {
+			t.Fatal(err)
+		}
+		if err := w.Close(); err != nil {
+			t.Fatal(err)
+		}
+		return buf.Bytes()
+	}
+
+	tests := []struct {
+		name    string
+		content []byte
+		want    string
+	}{
+		{name: "gzip compressed tarball", content: tarGzip, want: ContentTypeTarGzip},
+		{name: "zstd compressed tarball", content: compress(tarball, Zstd), want: ContentTypeTarZstd},
+		{name: "tarball", content: tarball, want: ContentTypeTar},
+		{name: "gzip compressed file", content: compress([]byte("content"), Gzip), want: ContentTypeGzip},
+		{name: "zstd compressed file", content: compress([]byte("content"), Zstd), want: ContentTypeZstd},
+		{name: "zip", content: []byte("PK\x03\x04content"), want: "application/zip"},
+		{name: "text", content: []byte("apiVersion: v1\nkind: ConfigMap\n"), want: "text/plain; charset=utf-8"},
+		{name: "truncated gzip", content: tarGzip[:4], want: ContentTypeGzip},
+		{name: "empty", content: nil, want: "text/plain; charset=utf-8"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			got, err := DetectContentType(bytes.NewReader(tt.content))
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(got).To(Equal(tt.want))
+		})
+	}
+}
@@ -0,0 +1,180 @@
+/*
+Copyright 2022 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package archive
+
+import (
+	"archive/tar"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// maxLinkHops is the maximum number of symlinks followed while resolving a
+// single path, to protect against symlink loops.
+const maxLinkHops = 255
+
+// UnsafePathError is returned when an entry of a tarball would be extracted
+// outside of the target directory, through its name, its link target, or
+// the symlinks extracted before it.
+type UnsafePathError struct {
+	// Name is the name of the entry in the tarball.
+	Name string
+	// Reason describes why the entry is unsafe.
+	Reason string
+}
+
+// Error returns the error message of the UnsafePathError.
+func (e *UnsafePathError) Error() string {
+	return fmt.Sprintf("unsafe entry '%s' in tarball: %s", e.Name, e.Reason)
+}
+
+// pathValidator validates that the entries of a tarball resolve to a path
+// within the target directory. It keeps track of the symlinks in the target
+// directory, to resolve the paths traversing them like the file system would.
+type pathValidator struct {
+	// links maps the path of a symlink relative to the target directory to
+	// its target, relative to the target directory, or absolute.
+	links map[string]string
+}
+
+// newPathValidator returns a pathValidator for the given target directory,
+// aware of the symlinks which already exist in it, e.g. extracted from a
+// previous tarball.
+func newPathValidator(dir string) (*pathValidator, error) {
+	v := &pathValidator{links: make(map[string]string)}
+	err := filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) && p == dir {
+				return filepath.SkipDir
+			}
+			return err
+		}
+		if p == dir || d.Type()&fs.ModeSymlink == 0 {
+			return nil
+		}
+		target, err := os.Readlink(p)
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(dir, p)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		v.links[rel] = linkTarget(path.Dir(rel), filepath.ToSlash(target))
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan symlinks in '%s': %w", dir, err)
+	}
+	return v, nil
+}
+
+// validate returns an UnsafePathError if the entry with the given header
+// resolves to a path outside of the target directory, or is a link to such a
+// path. Symlinks are recorded, to validate the entries following them.
+func (v *pathValidator) validate(hdr *tar.Header) error {
+	if path.IsAbs(hdr.Name) {
+		return &UnsafePathError{Name: hdr.Name, Reason: "absolute path"}
+	}
+
+	switch hdr.Typeflag {
+	case tar.TypeSymlink:
+		name := strings.TrimSuffix(hdr.Name, "/")
+		parent, ok := v.resolve(path.Dir(name))
+		if !ok || path.Base(name) == ".." {
+			return &UnsafePathError{Name: hdr.Name, Reason: "path outside of the target directory"}
+		}
+		if path.IsAbs(hdr.Linkname) {
+			return &UnsafePathError{Name: hdr.Name, Reason: fmt.Sprintf("absolute symlink target '%s'", hdr.Linkname)}
+		}
+		target := linkTarget(parent, hdr.Linkname)
+		if _, ok := v.resolve(target); !ok {
+			return &UnsafePathError{Name: hdr.Name, Reason: fmt.Sprintf("symlink target '%s' outside of the target directory", hdr.Linkname)}
+		}
+		v.links[joinRel(parent, path.Base(name))] = target
+		return nil
+	case tar.TypeLink:
+		if _, ok := v.resolve(hdr.Linkname); !ok || path.IsAbs(hdr.Linkname) {
+			return &UnsafePathError{Name: hdr.Name, Reason: fmt.Sprintf("hard link target '%s' outside of the target directory", hdr.Linkname)}
+		}
+	}
+
+	if _, ok := v.resolve(hdr.Name); !ok {
+		return &UnsafePathError{Name: hdr.Name, Reason: "path outside of the target directory"}
+	}
+	return nil
+}
+
+// resolve returns the given slash separated path relative to the target
+// directory with the recorded symlinks followed, or false if it resolves to
+// a path outside of the target directory. The target directory itself
+// resolves to an empty string.
+func (v *pathValidator) resolve(p string) (string, bool) {
+	var resolved []string
+	pending := strings.Split(p, "/")
+	hops := 0
+	for len(pending) > 0 {
+		elem := pending[0]
+		pending = pending[1:]
+		switch elem {
+		case "", ".":
+			continue
+		case "..":
+			if len(resolved) == 0 {
+				return "", false
+			}
+			resolved = resolved[:len(resolved)-1]
+			continue
+		}
+
+		resolved = append(resolved, elem)
+		target, ok := v.links[strings.Join(resolved, "/")]
+		if !ok {
+			continue
+		}
+		if hops++; hops > maxLinkHops || path.IsAbs(target) {
+			return "", false
+		}
+		// The target is relative to the target directory, continue from there
+		resolved = resolved[:0]
+		pending = append(strings.Split(target, "/"), pending...)
+	}
+	return strings.Join(resolved, "/"), true
+}
+
+// linkTarget returns the target of a symlink in the given parent directory
+// relative to the target directory, or the target itself if it is absolute.
+// The target is not cleaned, as it may traverse other symlinks.
+func linkTarget(parent, target string) string {
+	if path.IsAbs(target) {
+		return target
+	}
+	return joinRel(parent, target)
+}
+
+// joinRel joins the given slash separated path to the given parent
+// directory relative to the target directory, without cleaning it.
+func joinRel(parent, p string) string {
+	if parent == "" || parent == "." {
+		return p
+	}
+	return parent + "/" + p
+}
@@ -0,0 +1,181 @@
+/*
+Copyright 2022 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package archive
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestUntar_unsafePaths(t *testing.T) {
+	file := func(name string) *tar.Header {
+		return &tar.Header{Name: name, Mode: 0o644, Typeflag: tar.TypeReg}
+	}
+	symlink := func(name, target string) *tar.Header {
+		return &tar.Header{Name: name, Linkname: target, Mode: 0o777, Typeflag: tar.TypeSymlink}
+	}
+	hardlink := func(name, target string) *tar.Header {
+		return &tar.Header{Name: name, Linkname: target, Mode: 0o644, Typeflag: tar.TypeLink}
+	}
+
+	tests := []struct {
+		name     string
+		existing map[string]string
+		headers  []*tar.Header
+		wantName string
+	}{
+		{
+			name:     "parent directory traversal",
+			headers:  []*tar.Header{file("a.txt"), file("../../etc/passwd")},
+			wantName: "../../etc/passwd",
+		},
+		{
+			name:     "parent directory traversal in subdirectory",
+			headers:  []*tar.Header{file("dir/../../passwd")},
+			wantName: "dir/../../passwd",
+		},
+		{
+			name:     "absolute path",
+			headers:  []*tar.Header{file("/etc/passwd")},
+			wantName: "/etc/passwd",
+		},
+		{
+			name:     "absolute symlink",
+			headers:  []*tar.Header{symlink("passwd", "/etc/passwd")},
+			wantName: "passwd",
+		},
+		{
+			name:     "relative symlink outside of directory",
+			headers:  []*tar.Header{symlink("dir/etc", "../../etc")},
+			wantName: "dir/etc",
+		},
+		{
+			name:     "hard link outside of directory",
+			headers:  []*tar.Header{hardlink("passwd", "../etc/passwd")},
+			wantName: "passwd",
+		},
+		{
+			name: "chained symlinks outside of directory",
+			headers: []*tar.Header{
+				symlink("y", "."),
+				symlink("x", "y/.."),
+			},
+			wantName: "x",
+		},
+		{
+			name: "symlink redirected by a later symlink",
+			headers: []*tar.Header{
+				symlink("x", "dir/.."),
+				symlink("dir", "."),
+				file("x/passwd"),
+			},
+			wantName: "x/passwd",
+		},
+		{
+			name: "symlinked directory traversal",
+			headers: []*tar.Header{
+				symlink("link", "dir/sub"),
+				file("link/../../../passwd"),
+			},
+			wantName: "link/../../../passwd",
+		},
+		{
+			name:     "existing symlink outside of directory",
+			existing: map[string]string{"etc": "/etc"},
+			headers:  []*tar.Header{file("etc/passwd")},
+			wantName: "etc/passwd",
+		},
+		{
+			name:     "traversal through existing symlink",
+			existing: map[string]string{"link": "."},
+			headers:  []*tar.Header{file("link/../passwd")},
+			wantName: "link/../passwd",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			dir := t.TempDir()
+			for name, target := range tt.existing {
+				g.Expect(os.Symlink(target, filepath.Join(dir, name))).To(Succeed())
+			}
+
+			_, err := Untar(bytes.NewReader(createTarballWithHeaders(t, tt.headers)), dir, Limits{})
+			g.Expect(err).To(HaveOccurred())
+			var unsafeErr *UnsafePathError
+			g.Expect(errors.As(err, &unsafeErr)).To(BeTrue())
+			g.Expect(unsafeErr.Name).To(Equal(tt.wantName))
+		})
+	}
+}
+
+func TestPathValidator_validate(t *testing.T) {
+	g := NewWithT(t)
+
+	v, err := newPathValidator(filepath.Join(t.TempDir(), "non-existing"))
+	g.Expect(err).ToNot(HaveOccurred())
+
+	for _, hdr := range []*tar.Header{
+		{Name: "dir/", Typeflag: tar.TypeDir},
+		{Name: "dir/a.txt", Typeflag: tar.TypeReg},
+		{Name: "./b.txt", Typeflag: tar.TypeReg},
+		{Name: "dir/../c.txt", Typeflag: tar.TypeReg},
+		{Name: "dir/sub/link", Linkname: "../a.txt", Typeflag: tar.TypeSymlink},
+		{Name: "root", Linkname: ".", Typeflag: tar.TypeSymlink},
+		{Name: "root/dir/d.txt", Typeflag: tar.TypeReg},
+		{Name: "dir/hardlink", Linkname: "dir/a.txt", Typeflag: tar.TypeLink},
+		{Name: "loop", Linkname: "loop", Typeflag: tar.TypeSymlink},
+	} {
+		g.Expect(v.validate(hdr)).To(Succeed(), hdr.Name)
+	}
+	g.Expect(v.links).To(Equal(map[string]string{
+		"dir/sub/link": "dir/sub/../a.txt",
+		"root":         ".",
+		"loop":         "loop",
+	}))
+
+	// Traversing a symlink loop does not resolve
+	g.Expect(v.validate(&tar.Header{Name: "loop/a.txt", Typeflag: tar.TypeReg})).To(HaveOccurred())
+}
+
+func createTarballWithHeaders(t *testing.T, headers []*tar.Header) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(zw)
+	for _, hdr := range headers {
+		if err := tw.WriteHeader(hdr); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
@@ -26,6 +26,9 @@ const (
 	CacheEventTypeMiss = "cache_miss"
 	// CacheEventTypeHit is the event type for cache hits.
 	CacheEventTypeHit = "cache_hit"
+	// CacheEventTypeEviction is the event type for evictions requested by the
+	// user.
+	CacheEventTypeEviction = "cache_eviction"
 )
 
 // CacheRecorder is a recorder for cache events.
@@ -40,6 +43,7 @@ type CacheRecorder struct {
 //   - "miss"
 //   - "hit"
 //   - "update"
+//   - "eviction"
 //
 // The name is the name of the reconciled resource.
 // The namespace is the namespace of the reconciled resource.
@@ -29,12 +29,23 @@ const (
 	// the last revision is still the same at the target repository,
 	// and if that is so, skips the reconciliation.
 	OptimizedGitClones = "OptimizedGitClones"
+
+	// ParallelOCILayerFetch decreases the time it takes to fetch OCI
+	// artifacts with many layers for OCIRepository reconciliations.
+	//
+	// When enabled, all the layers matching the media type of the layer
+	// selector are fetched concurrently, and extracted in the order of the
+	// artifact manifest.
+	ParallelOCILayerFetch = "ParallelOCILayerFetch"
 )
 
 var features = map[string]bool{
 	// OptimizedGitClones
 	// opt-out from v0.25
 	OptimizedGitClones: true,
+	// ParallelOCILayerFetch
+	// opt-in from v0.34
+	ParallelOCILayerFetch: false,
 }
 
 // DefaultFeatureGates contains a list of all supported feature gates and
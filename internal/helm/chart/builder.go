@@ -18,6 +18,7 @@ package chart
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -103,20 +104,55 @@ type BuildOptions struct {
 	// the spec, and is included during packaging.
 	// Ref: https://semver.org/#spec-item-10
 	VersionMetadata string
+	// AppVersion can be set to override the appVersion of the chart, and
+	// is included during packaging. Only supported by the local builder.
+	AppVersion string
 	// ValuesFiles can be set to a list of relative paths, used to compose
 	// and overwrite an alternative default "values.yaml" for the chart.
 	ValuesFiles []string
+	// IgnoreMissingValuesFiles can be set to skip the ValuesFiles which do
+	// not exist, instead of failing the build.
+	IgnoreMissingValuesFiles bool
+	// Values can be set to a map of values which is merged last into the
+	// default "values.yaml" of the chart, after the ValuesFiles.
+	Values map[string]interface{}
+	// ValuesFrom can be set to a list of descriptions of the sources of the
+	// Values, and is included in the Build result.
+	ValuesFrom []string
 	// CachedChart can be set to the absolute path of a chart stored on
 	// the local filesystem, and is used for simple validation by metadata
 	// comparisons.
 	CachedChart string
+	// CachedChartDigest can be set to the digest of the chart version the
+	// CachedChart was built from. If the digest of the resolved chart version
+	// differs, the CachedChart is not used.
+	CachedChartDigest string
 	// Force can be set to force the build of the chart, for example
 	// because the list of ValuesFiles has changed.
 	Force bool
+	// SkipDependencyUpdate can be set to package a chart directory with the
+	// dependencies present in its charts/ directory, without resolving the
+	// missing dependencies. Only supported by the local builder.
+	SkipDependencyUpdate bool
 	// Verifier can be set to the verification of the chart.
 	Verify bool
+	// Mutators can be set to a list of ChartMutator functions, which are
+	// invoked in order on the loaded chart right before it is packaged.
+	// Setting any requires the chart to be packaged. Nil by default, which
+	// leaves the chart untouched.
+	Mutators []ChartMutator
+	// MemoryBudget can be set to the maximum number of bytes of chart and
+	// values files loaded in memory to build the chart. When the files exceed
+	// it, the build fails with ErrMemoryBudget before they are loaded.
+	// Zero means no limit. Only supported by the local builder.
+	MemoryBudget int64
 }
 
+// ChartMutator mutates a loaded chart before it is packaged, for example to
+// inject a common label into its templates. It returns an error if the chart
+// could not be mutated.
+type ChartMutator func(chart *helmchart.Chart) error
+
 // GetValuesFiles returns BuildOptions.ValuesFiles, except if it equals
 // "values.yaml", which returns nil.
 func (o BuildOptions) GetValuesFiles() []string {
@@ -133,19 +169,48 @@ type Build struct {
 	Name string
 	// Version of the chart.
 	Version string
+	// AppVersion of the chart, set if overwritten by BuildOptions.AppVersion.
+	AppVersion string
+	// Digest of the chart version as published in the repository index.
+	// Can be empty if the repository does not provide digests.
+	Digest string
 	// Path is the absolute path to the packaged chart.
 	// Can be empty, in which case a failure should be assumed.
 	Path string
 	// ValuesFiles is the list of files used to compose the chart's
 	// default "values.yaml".
 	ValuesFiles []string
+	// MissingValuesFiles is the list of values files which were skipped
+	// because they do not exist, see BuildOptions.IgnoreMissingValuesFiles.
+	MissingValuesFiles []string
+	// ValuesFrom is the list of sources of the values merged last into the
+	// chart's default "values.yaml".
+	ValuesFrom []string
 	// ResolvedDependencies is the number of local and remote dependencies
 	// collected by the DependencyManager before building the chart.
 	ResolvedDependencies int
+	// PinnedDependencies is the list of remote dependencies which were
+	// pinned to an OCI digest, formatted as "<name>@<digest>".
+	PinnedDependencies []string
+	// Dependencies is the list of local and remote dependencies resolved
+	// by the DependencyManager, sorted by name.
+	Dependencies []ResolvedDependency
+	// DependencyRevisions maps the names of the repositories the remote
+	// dependencies were resolved from to the revision of their index. It is
+	// not set by the Builder, but by the caller providing the repositories to
+	// the DependencyManager.
+	DependencyRevisions map[string]string
 	// Packaged indicates if the Builder has packaged the chart.
 	// This can for example be false if ValuesFiles is empty and the chart
 	// source was already packaged.
 	Packaged bool
+	// Verified indicates if the signature of the chart was verified by the
+	// Builder, see BuildOptions.Verify.
+	Verified bool
+	// Warnings is the list of issues with the built chart which did not
+	// fail the build, e.g. the use of deprecated Kubernetes API versions.
+	// It is empty if the chart was not built, but taken from the cache.
+	Warnings []string
 }
 
 // Summary returns a human-readable summary of the Build.
@@ -156,22 +221,85 @@ func (b *Build) Summary() string {
 
 	var s strings.Builder
 
-	var action = "new"
-	if b.Path != "" {
-		action = "pulled"
-		if b.Packaged {
-			action = "packaged"
-		}
+	s.WriteString(fmt.Sprintf("%s '%s' chart with version '%s'", b.action(), b.Name, b.Version))
+
+	if b.AppVersion != "" {
+		s.WriteString(fmt.Sprintf(" and appVersion '%s'", b.AppVersion))
 	}
-	s.WriteString(fmt.Sprintf("%s '%s' chart with version '%s'", action, b.Name, b.Version))
 
 	if len(b.ValuesFiles) > 0 {
 		s.WriteString(fmt.Sprintf(" and merged values files %v", b.ValuesFiles))
 	}
 
+	if len(b.MissingValuesFiles) > 0 {
+		s.WriteString(fmt.Sprintf(" and skipped missing values files %v", b.MissingValuesFiles))
+	}
+
+	if len(b.ValuesFrom) > 0 {
+		s.WriteString(fmt.Sprintf(" and merged values from %v", b.ValuesFrom))
+	}
+
 	return s.String()
 }
 
+// BuildSummary is the structured summary of a Build, for consumption by
+// tooling.
+type BuildSummary struct {
+	// Action is the action taken to produce the chart: "new" if it was not
+	// pulled or packaged yet, "pulled" or "packaged".
+	Action string `json:"action"`
+	// Name of the chart.
+	Name string `json:"name"`
+	// Version of the chart.
+	Version string `json:"version"`
+	// AppVersion of the chart, set if overwritten by BuildOptions.AppVersion.
+	AppVersion string `json:"appVersion,omitempty"`
+	// ResolvedDependencies is the number of dependencies resolved before
+	// building the chart.
+	ResolvedDependencies int `json:"resolvedDependencies"`
+	// ValuesFiles is the list of values files merged into the chart.
+	ValuesFiles []string `json:"valuesFiles,omitempty"`
+	// MissingValuesFiles is the list of values files which were skipped.
+	MissingValuesFiles []string `json:"missingValuesFiles,omitempty"`
+	// ValuesFrom is the list of sources of the values merged into the chart.
+	ValuesFrom []string `json:"valuesFrom,omitempty"`
+	// Verified indicates if the signature of the chart was verified.
+	Verified bool `json:"verified"`
+}
+
+// SummaryJSON returns the BuildSummary of the Build encoded as JSON, or an
+// empty string if the Build has no metadata.
+func (b *Build) SummaryJSON() string {
+	if !b.HasMetadata() {
+		return ""
+	}
+	// Encoding the summary can not fail, as it consists of strings, numbers
+	// and booleans only
+	data, _ := json.Marshal(BuildSummary{
+		Action:               b.action(),
+		Name:                 b.Name,
+		Version:              b.Version,
+		AppVersion:           b.AppVersion,
+		ResolvedDependencies: b.ResolvedDependencies,
+		ValuesFiles:          b.ValuesFiles,
+		MissingValuesFiles:   b.MissingValuesFiles,
+		ValuesFrom:           b.ValuesFrom,
+		Verified:             b.Verified,
+	})
+	return string(data)
+}
+
+// action returns the action taken to produce the chart of the Build.
+func (b *Build) action() string {
+	if b.Path == "" {
+		return "new"
+	}
+	if b.Packaged {
+		return "packaged"
+	}
+	return "pulled"
+}
+
 // HasMetadata returns if the Build contains chart metadata.
 //
 // NOTE: This may return True while the build did not Complete successfully.
@@ -197,6 +325,16 @@ func (b *Build) String() string {
 	return b.Path
 }
 
+// mutateChart invokes the given ChartMutator functions in order on the chart.
+func mutateChart(chart *helmchart.Chart, mutators []ChartMutator) error {
+	for i, mutate := range mutators {
+		if err := mutate(chart); err != nil {
+			return fmt.Errorf("chart mutator %d failed: %w", i, err)
+		}
+	}
+	return nil
+}
+
 // packageToPath attempts to package the given chart to the out filepath.
 func packageToPath(chart *helmchart.Chart, out string) error {
 	o, err := os.MkdirTemp("", "chart-build-*")
@@ -18,17 +18,24 @@ package chart
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"net/url"
 	"os"
+	"path/filepath"
 	"strings"
 
 	"github.com/Masterminds/semver/v3"
 	securejoin "github.com/cyphar/filepath-securejoin"
+	"helm.sh/helm/v3/pkg/chartutil"
+	"helm.sh/helm/v3/pkg/repo"
 	"sigs.k8s.io/yaml"
 
 	"github.com/fluxcd/pkg/runtime/transform"
 
+	"github.com/fluxcd/source-controller/internal/helm"
 	"github.com/fluxcd/source-controller/internal/helm/chart/secureloader"
+	"github.com/fluxcd/source-controller/internal/helm/repository"
 )
 
 type localChartBuilder struct {
@@ -50,11 +57,12 @@ func NewLocalBuilder(dm *DependencyManager) Builder {
 // written to p, or a BuildError.
 //
 // The chart is loaded from the LocalReference.Path, and only packaged if the
-// version (including BuildOptions.VersionMetadata modifications) differs from
-// the current BuildOptions.CachedChart.
+// version (including BuildOptions.VersionMetadata modifications) or the
+// BuildOptions.AppVersion differs from the current BuildOptions.CachedChart.
 //
-// BuildOptions.ValuesFiles changes are in this case not taken into account,
-// and BuildOptions.Force should be used to enforce a rebuild.
+// BuildOptions.ValuesFiles and BuildOptions.Values changes are in this case
+// not taken into account, and BuildOptions.Force or a change of the
+// BuildOptions.VersionMetadata should be used to enforce a rebuild.
 //
 // If the LocalReference.Path refers to an already packaged chart, and no
 // packaging is required due to BuildOptions modifying the chart,
@@ -62,7 +70,21 @@ func NewLocalBuilder(dm *DependencyManager) Builder {
 //
 // If the LocalReference.Path refers to a chart directory, dependencies are
 // confirmed to be present using the DependencyManager, while attempting to
-// resolve any missing.
+// resolve any missing. If BuildOptions.SkipDependencyUpdate is set, missing
+// dependencies are not resolved but result in a BuildError. A dependency which
+// fails the verification of the DependencyManager results in a BuildError with
+// ErrChartVerification as reason.
+//
+// The BuildOptions.Mutators are invoked on the loaded chart after resolving
+// its dependencies, right before packaging it.
+//
+// If the LocalReference.Path is a glob pattern, it is resolved to the single
+// chart directory matching it.
+//
+// If BuildOptions.MemoryBudget is set, the total size of the values files and
+// the (uncompressed) chart files is checked against it before they are loaded
+// in memory, and a BuildError with ErrMemoryBudget as reason is returned if
+// it is exceeded. The chart is packaged by streaming it to p.
 func (b *localChartBuilder) Build(ctx context.Context, ref Reference, p string, opts BuildOptions) (*Build, error) {
 	localRef, ok := ref.(LocalReference)
 	if !ok {
@@ -74,6 +96,16 @@ func (b *localChartBuilder) Build(ctx context.Context, ref Reference, p string,
 		return nil, &BuildError{Reason: ErrChartReference, Err: err}
 	}
 
+	// Resolve a glob pattern to the chart directory it matches
+	if isGlob(localRef.Path) {
+		chartPath, err := resolveChartGlob(localRef.WorkDir, localRef.Path)
+		if err != nil {
+			return nil, &BuildError{Reason: ErrChartReference, Err: err}
+		}
+		localRef.Path = chartPath
+		ref = localRef
+	}
+
 	// Load the chart metadata from the LocalReference to ensure it points
 	// to a chart
 	securePath, err := securejoin.SecureJoin(localRef.WorkDir, localRef.Path)
@@ -106,21 +138,27 @@ func (b *localChartBuilder) Build(ctx context.Context, ref Reference, p string,
 		result.Version = ver.String()
 	}
 
+	result.AppVersion = opts.AppVersion
+
 	isChartDir := pathIsDir(securePath)
-	requiresPackaging := isChartDir || opts.VersionMetadata != "" || len(opts.GetValuesFiles()) != 0
+	requiresPackaging := isChartDir || opts.VersionMetadata != "" || opts.AppVersion != "" ||
+		len(opts.GetValuesFiles()) != 0 || len(opts.Values) != 0 || len(opts.Mutators) != 0
 
 	// If all the following is true, we do not need to package the chart:
 	// - Chart name from cached chart matches resolved name
 	// - Chart version from cached chart matches calculated version
+	// - Chart appVersion from cached chart matches BuildOptions.AppVersion, if set
 	// - BuildOptions.Force is False
 	if opts.CachedChart != "" && !opts.Force {
 		if curMeta, err = LoadChartMetadataFromArchive(opts.CachedChart); err == nil {
 			// If the cached metadata is corrupt, we ignore its existence
 			// and continue the build
 			if err = curMeta.Validate(); err == nil {
-				if result.Name == curMeta.Name && result.Version == curMeta.Version {
+				if result.Name == curMeta.Name && result.Version == curMeta.Version &&
+					(opts.AppVersion == "" || opts.AppVersion == curMeta.AppVersion) {
 					result.Path = opts.CachedChart
 					result.ValuesFiles = opts.GetValuesFiles()
+					result.ValuesFrom = opts.ValuesFrom
 					result.Packaged = requiresPackaging
 
 					return result, nil
@@ -136,13 +174,27 @@ func (b *localChartBuilder) Build(ctx context.Context, ref Reference, p string,
 			return result, &BuildError{Reason: ErrChartPull, Err: err}
 		}
 		result.Path = p
+		result.Warnings = archiveWarnings(p)
 		return result, nil
 	}
 
 	// Merge chart values, if instructed
 	var mergedValues map[string]interface{}
-	if len(opts.GetValuesFiles()) > 0 {
-		if mergedValues, err = mergeFileValues(localRef.WorkDir, opts.ValuesFiles); err != nil {
+	valuesFiles := opts.GetValuesFiles()
+	if opts.IgnoreMissingValuesFiles {
+		valuesFiles, result.MissingValuesFiles = splitMissingFileValues(localRef.WorkDir, valuesFiles)
+	}
+	chartBudget := opts.MemoryBudget
+	if len(valuesFiles) > 0 {
+		if opts.MemoryBudget > 0 {
+			size := valuesFilesSize(localRef.WorkDir, valuesFiles)
+			if size >= opts.MemoryBudget {
+				err = fmt.Errorf("total size of values files (%d) exceeds memory budget (%d)", size, opts.MemoryBudget)
+				return result, &BuildError{Reason: ErrMemoryBudget, Err: err}
+			}
+			chartBudget -= size
+		}
+		if mergedValues, err = mergeFileValues(localRef.WorkDir, valuesFiles); err != nil {
 			return result, &BuildError{Reason: ErrValuesFilesMerge, Err: err}
 		}
 	}
@@ -150,31 +202,63 @@ func (b *localChartBuilder) Build(ctx context.Context, ref Reference, p string,
 	// At this point we are certain we need to load the chart;
 	// either to package it because it originates from a directory,
 	// or because we have merged values and need to repackage
-	loadedChart, err := secureloader.Load(localRef.WorkDir, localRef.Path)
+	loadedChart, err := secureloader.LoadWithLimit(localRef.WorkDir, localRef.Path, chartBudget)
 	if err != nil {
+		if errors.Is(err, secureloader.ErrTotalSizeExceeded) {
+			err = fmt.Errorf("failed to load chart within memory budget (%d): %w", opts.MemoryBudget, err)
+			return result, &BuildError{Reason: ErrMemoryBudget, Err: err}
+		}
 		return result, &BuildError{Reason: ErrChartPackage, Err: err}
 	}
 
 	// Set earlier resolved version (with metadata)
 	loadedChart.Metadata.Version = result.Version
+	if opts.AppVersion != "" {
+		loadedChart.Metadata.AppVersion = opts.AppVersion
+	}
+
+	// Merge the values from the options last, on top of the chart's
+	// default values if no values files were merged
+	if len(opts.Values) > 0 {
+		if mergedValues == nil {
+			mergedValues = loadedChart.Values
+		}
+		mergedValues = transform.MergeMaps(mergedValues, opts.Values)
+	}
 
 	// Overwrite default values with merged values, if any
 	if ok, err = OverwriteChartDefaultValues(loadedChart, mergedValues); ok || err != nil {
 		if err != nil {
 			return result, &BuildError{Reason: ErrValuesFilesMerge, Err: err}
 		}
-		result.ValuesFiles = opts.GetValuesFiles()
+		result.ValuesFiles = valuesFiles
+		result.ValuesFrom = opts.ValuesFrom
 	}
 
 	// Ensure dependencies are fetched if building from a directory
-	if isChartDir {
+	if isChartDir && opts.SkipDependencyUpdate {
+		if err = checkDependencies(loadedChart); err != nil {
+			return result, &BuildError{Reason: ErrDependencyBuild, Err: err}
+		}
+	} else if isChartDir {
 		if b.dm == nil {
 			err = fmt.Errorf("local chart builder requires dependency manager for unpackaged charts")
 			return result, &BuildError{Reason: ErrDependencyBuild, Err: err}
 		}
 		if result.ResolvedDependencies, err = b.dm.Build(ctx, ref, loadedChart); err != nil {
+			var verifyErr *DependencyVerificationError
+			if errors.As(err, &verifyErr) {
+				return result, &BuildError{Reason: ErrChartVerification, Err: err}
+			}
 			return result, &BuildError{Reason: ErrDependencyBuild, Err: err}
 		}
+		result.PinnedDependencies = b.dm.PinnedDependencies()
+		result.Dependencies = b.dm.ResolvedDependencies()
+	}
+
+	// Mutate the chart, if instructed
+	if err = mutateChart(loadedChart, opts.Mutators); err != nil {
+		return result, &BuildError{Reason: ErrChartPackage, Err: err}
 	}
 
 	// Package the chart
@@ -183,9 +267,122 @@ func (b *localChartBuilder) Build(ctx context.Context, ref Reference, p string,
 	}
 	result.Path = p
 	result.Packaged = requiresPackaging
+	result.Warnings = chartWarnings(loadedChart)
 	return result, nil
 }
 
+// isGlob returns true if the given path contains any of the special
+// characters of a filepath.Match pattern.
+func isGlob(p string) bool {
+	return strings.ContainsAny(p, "*?[")
+}
+
+// resolveChartGlob returns the path, relative to baseDir, of the single chart
+// directory matching the given filepath.Match pattern. The pattern may not
+// traverse outside baseDir, and directories without a "Chart.yaml" file are
+// ignored. It returns an error if no or multiple chart directories match.
+func resolveChartGlob(baseDir, pattern string) (string, error) {
+	securePattern, err := securejoin.SecureJoin(baseDir, pattern)
+	if err != nil {
+		return "", err
+	}
+	matches, err := filepath.Glob(securePattern)
+	if err != nil {
+		return "", fmt.Errorf("invalid chart path pattern '%s': %w", pattern, err)
+	}
+
+	var charts []string
+	for _, m := range matches {
+		rel, err := filepath.Rel(baseDir, m)
+		if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			continue
+		}
+		secureP, err := securejoin.SecureJoin(baseDir, rel)
+		if err != nil || !pathIsDir(secureP) {
+			continue
+		}
+		if f, err := os.Stat(filepath.Join(secureP, chartutil.ChartfileName)); err != nil || !f.Mode().IsRegular() {
+			continue
+		}
+		charts = append(charts, filepath.ToSlash(rel))
+	}
+
+	switch len(charts) {
+	case 0:
+		return "", fmt.Errorf("no chart directory found matching '%s'", pattern)
+	case 1:
+		return charts[0], nil
+	default:
+		return "", fmt.Errorf("multiple chart directories found matching '%s': %s", pattern, strings.Join(charts, ", "))
+	}
+}
+
+// indexFileName is the name of the chart repository index file resolved by
+// ResolveIndexedChart.
+const indexFileName = "index.yaml"
+
+// ResolveIndexedChart returns the path, relative to workDir, of the packaged
+// chart with the given name and version (constraint) listed in the chart
+// repository index "index.yaml" in the root of workDir, e.g. a Bucket hosting
+// a chart repository. The URLs of the chart versions in the index must be
+// relative to the index, and may not traverse outside workDir.
+// It returns an empty path without an error if workDir has no index, or if the
+// name is a path in workDir, in which case the chart is not resolved from the
+// index.
+func ResolveIndexedChart(workDir, name, version string) (string, error) {
+	if isGlob(name) {
+		return "", nil
+	}
+	if secureP, err := securejoin.SecureJoin(workDir, name); err != nil {
+		return "", err
+	} else if _, err := os.Lstat(secureP); err == nil {
+		return "", nil
+	}
+	indexPath := filepath.Join(workDir, indexFileName)
+	f, err := os.Lstat(indexPath)
+	if err != nil || !f.Mode().IsRegular() {
+		return "", nil
+	}
+	if f.Size() > helm.MaxIndexSize {
+		return "", fmt.Errorf("size of index '%s' exceeds '%d' bytes limit", indexFileName, helm.MaxIndexSize)
+	}
+
+	index, err := repo.LoadIndexFile(indexPath)
+	if err != nil {
+		return "", fmt.Errorf("failed to load chart repository index '%s': %w", indexFileName, err)
+	}
+	cv, err := repository.FindChartVersion(index, name, version)
+	if err != nil {
+		return "", fmt.Errorf("failed to find chart '%s' with version '%s' in index '%s': %w",
+			name, version, indexFileName, err)
+	}
+	if len(cv.URLs) == 0 {
+		return "", fmt.Errorf("chart '%s' with version '%s' has no URLs in index '%s'", name, cv.Version, indexFileName)
+	}
+	u, err := url.Parse(cv.URLs[0])
+	if err != nil {
+		return "", fmt.Errorf("invalid chart URL format '%s': %w", cv.URLs[0], err)
+	}
+	if u.IsAbs() || u.Host != "" {
+		return "", fmt.Errorf("chart '%s' with version '%s' refers to '%s' outside the source, only URLs relative to the index are supported",
+			name, cv.Version, cv.URLs[0])
+	}
+
+	secureP, err := securejoin.SecureJoin(workDir, u.Path)
+	if err != nil {
+		return "", err
+	}
+	if f, err := os.Stat(secureP); err != nil || !f.Mode().IsRegular() {
+		return "", fmt.Errorf("chart '%s' with version '%s' refers to '%s' which does not exist in the source",
+			name, cv.Version, cv.URLs[0])
+	}
+	rel, err := filepath.Rel(workDir, secureP)
+	if err != nil {
+		return "", err
+	}
+	return filepath.ToSlash(rel), nil
+}
+
 // mergeFileValues merges the given value file paths into a single "values.yaml" map.
 // The provided (relative) paths may not traverse outside baseDir. It returns the merge
 // result, or an error.
@@ -214,6 +411,36 @@ func mergeFileValues(baseDir string, paths []string) (map[string]interface{}, er
 	return mergedValues, nil
 }
 
+// valuesFilesSize returns the total size of the given values file paths,
+// relative to baseDir. Paths which can not be resolved to a regular file are
+// ignored, and result in an error while merging them.
+func valuesFilesSize(baseDir string, paths []string) int64 {
+	var size int64
+	for _, p := range paths {
+		if secureP, err := securejoin.SecureJoin(baseDir, p); err == nil {
+			if f, err := os.Stat(secureP); err == nil && f.Mode().IsRegular() {
+				size += f.Size()
+			}
+		}
+	}
+	return size
+}
+
+// splitMissingFileValues splits the given values file paths into the paths of
+// the files which exist in baseDir, and the paths of the files which do not.
+func splitMissingFileValues(baseDir string, paths []string) (present, missing []string) {
+	for _, p := range paths {
+		if secureP, err := securejoin.SecureJoin(baseDir, p); err == nil {
+			if f, err := os.Stat(secureP); err == nil && f.Mode().IsRegular() {
+				present = append(present, p)
+				continue
+			}
+		}
+		missing = append(missing, p)
+	}
+	return present, missing
+}
+
 // copyFileToPath attempts to copy in to out. It returns an error if out already exists.
 func copyFileToPath(in, out string) error {
 	o, err := os.Create(out)
@@ -18,8 +18,10 @@ package chart
 
 import (
 	"context"
+	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 	"sync"
 	"testing"
 
@@ -71,7 +73,9 @@ func TestLocalBuilder_Build(t *testing.T) {
 		dependentChartPaths []string
 		wantValues          chartutil.Values
 		wantVersion         string
+		wantAppVersion      string
 		wantPackaged        bool
+		wantMissing         []string
 		wantErr             string
 	}{
 		{
@@ -108,6 +112,14 @@ func TestLocalBuilder_Build(t *testing.T) {
 			wantVersion:  "0.1.0",
 			wantPackaged: false,
 		},
+		{
+			name:           "with app version",
+			reference:      LocalReference{Path: "../testdata/charts/helmchart-0.1.0.tgz"},
+			buildOpts:      BuildOptions{AppVersion: "9f1c0a2b3d4e"},
+			wantVersion:    "0.1.0",
+			wantAppVersion: "9f1c0a2b3d4e",
+			wantPackaged:   true,
+		},
 		{
 			name:      "default values",
 			reference: LocalReference{Path: "../testdata/charts/helmchart"},
@@ -143,6 +155,80 @@ fullnameOverride: "full-foo-name-override"`),
 			wantVersion:  "0.1.0",
 			wantPackaged: true,
 		},
+		{
+			name:      "with missing values files",
+			reference: LocalReference{Path: "../testdata/charts/helmchart"},
+			buildOpts: BuildOptions{
+				ValuesFiles: []string{"custom-values1.yaml", "missing.yaml"},
+			},
+			valuesFiles: []helmchart.File{
+				{
+					Name: "custom-values1.yaml",
+					Data: []byte(`replicaCount: 11`),
+				},
+			},
+			wantErr: "no values file found at path '/missing.yaml'",
+		},
+		{
+			name:      "ignore missing values files",
+			reference: LocalReference{Path: "../testdata/charts/helmchart"},
+			buildOpts: BuildOptions{
+				ValuesFiles:              []string{"missing.yaml", "custom-values1.yaml"},
+				IgnoreMissingValuesFiles: true,
+			},
+			valuesFiles: []helmchart.File{
+				{
+					Name: "custom-values1.yaml",
+					Data: []byte(`replicaCount: 11`),
+				},
+			},
+			wantValues: chartutil.Values{
+				"replicaCount": float64(11),
+			},
+			wantVersion:  "0.1.0",
+			wantPackaged: true,
+			wantMissing:  []string{"missing.yaml"},
+		},
+		{
+			name:      "with values",
+			reference: LocalReference{Path: "../testdata/charts/helmchart-0.1.0.tgz"},
+			buildOpts: BuildOptions{
+				Values: map[string]interface{}{
+					"nameOverride": "foo-name-override",
+				},
+				ValuesFrom: []string{"ConfigMap/values"},
+			},
+			wantValues: chartutil.Values{
+				"replicaCount": float64(1),
+				"nameOverride": "foo-name-override",
+			},
+			wantVersion:  "0.1.0",
+			wantPackaged: true,
+		},
+		{
+			name:      "with values files and values",
+			reference: LocalReference{Path: "../testdata/charts/helmchart"},
+			buildOpts: BuildOptions{
+				ValuesFiles: []string{"custom-values1.yaml"},
+				Values: map[string]interface{}{
+					"replicaCount": float64(3),
+				},
+				ValuesFrom: []string{"Secret/values"},
+			},
+			valuesFiles: []helmchart.File{
+				{
+					Name: "custom-values1.yaml",
+					Data: []byte(`replicaCount: 11
+nameOverride: "foo-name-override"`),
+				},
+			},
+			wantValues: chartutil.Values{
+				"replicaCount": float64(3),
+				"nameOverride": "foo-name-override",
+			},
+			wantVersion:  "0.1.0",
+			wantPackaged: true,
+		},
 		{
 			name:      "chart with dependencies",
 			reference: LocalReference{Path: "../testdata/charts/helmchartwithdeps"},
@@ -233,10 +319,22 @@ fullnameOverride: "full-foo-name-override"`),
 			resultChart, err := secureloader.LoadFile(cb.Path)
 			g.Expect(err).ToNot(HaveOccurred())
 			g.Expect(resultChart.Metadata.Version).To(Equal(tt.wantVersion))
+			if tt.wantAppVersion != "" {
+				g.Expect(resultChart.Metadata.AppVersion).To(Equal(tt.wantAppVersion))
+				g.Expect(cb.Summary()).To(ContainSubstring(fmt.Sprintf("appVersion '%s'", tt.wantAppVersion)))
+			}
+
+			if len(tt.buildOpts.ValuesFrom) > 0 {
+				g.Expect(cb.ValuesFrom).To(Equal(tt.buildOpts.ValuesFrom))
+			}
 
 			for k, v := range tt.wantValues {
 				g.Expect(v).To(Equal(resultChart.Values[k]))
 			}
+			if tt.wantMissing != nil {
+				g.Expect(cb.MissingValuesFiles).To(Equal(tt.wantMissing))
+				g.Expect(cb.ValuesFiles).ToNot(ContainElements(tt.wantMissing))
+			}
 		})
 	}
 }
@@ -272,6 +370,19 @@ func TestLocalBuilder_Build_CachedChart(t *testing.T) {
 	g.Expect(err).ToNot(HaveOccurred())
 	g.Expect(cb.Path).To(Equal(targetPath))
 
+	// Rebuild with a different app version.
+	buildOpts.AppVersion = "9f1c0a2b3d4e"
+	cb, err = b.Build(context.TODO(), reference, targetPath2, buildOpts)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(cb.Path).To(Equal(targetPath2))
+
+	// Use the rebuild with the same app version.
+	buildOpts.CachedChart = cb.Path
+	targetPath3 := filepath.Join(tmpDir, "chart3.tgz")
+	cb, err = b.Build(context.TODO(), reference, targetPath3, buildOpts)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(cb.Path).To(Equal(targetPath2))
+
 	// Rebuild with build option Force.
 	buildOpts.Force = true
 	cb, err = b.Build(context.TODO(), reference, targetPath2, buildOpts)
@@ -343,6 +454,403 @@ func Test_mergeFileValues(t *testing.T) {
 	}
 }
 
+func TestLocalBuilder_Build_ChartGlob(t *testing.T) {
+	g := NewWithT(t)
+
+	workDir := t.TempDir()
+	g.Expect(copy.Copy("../testdata/charts/helmchart", filepath.Join(workDir, "charts", "app"))).To(Succeed())
+	g.Expect(os.MkdirAll(filepath.Join(workDir, "charts", "docs"), 0o700)).To(Succeed())
+
+	b := NewLocalBuilder(NewDependencyManager())
+	tmpDir := t.TempDir()
+	targetPath := filepath.Join(tmpDir, "chart.tgz")
+
+	cb, err := b.Build(context.TODO(), LocalReference{WorkDir: workDir, Path: "charts/*/"}, targetPath, BuildOptions{})
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(cb.Name).To(Equal("helmchart"))
+	g.Expect(cb.Path).To(Equal(targetPath))
+	g.Expect(cb.Packaged).To(BeTrue())
+
+	g.Expect(copy.Copy("../testdata/charts/helmchart", filepath.Join(workDir, "charts", "other"))).To(Succeed())
+	_, err = b.Build(context.TODO(), LocalReference{WorkDir: workDir, Path: "charts/*/"}, targetPath, BuildOptions{})
+	g.Expect(err).To(HaveOccurred())
+	g.Expect(err.Error()).To(ContainSubstring("multiple chart directories found matching 'charts/*/': charts/app, charts/other"))
+}
+
+func TestLocalBuilder_Build_SkipDependencyUpdate(t *testing.T) {
+	tests := []struct {
+		name     string
+		vendored []string
+		wantErr  string
+	}{
+		{
+			name:    "missing dependencies",
+			wantErr: "dependencies missing from the charts/ directory: grafana, helmchart",
+		},
+		{
+			name:     "partially vendored dependencies",
+			vendored: []string{"helmchart"},
+			wantErr:  "dependencies missing from the charts/ directory: grafana",
+		},
+		{
+			name:     "vendored dependencies",
+			vendored: []string{"helmchart", "grafana"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			workDir := t.TempDir()
+			chartDir := filepath.Join(workDir, "helmchartwithdeps")
+			g.Expect(copy.Copy("../testdata/charts/helmchartwithdeps", chartDir)).To(Succeed())
+			for _, dep := range tt.vendored {
+				depDir := filepath.Join(chartDir, "charts", dep)
+				g.Expect(copy.Copy("../testdata/charts/helmchart", depDir)).To(Succeed())
+				g.Expect(os.WriteFile(filepath.Join(depDir, "Chart.yaml"),
+					[]byte(fmt.Sprintf("apiVersion: v2\nname: %s\nversion: 0.1.0\n", dep)), 0o600)).To(Succeed())
+			}
+
+			// The dependency manager fails the build if it is used to
+			// resolve the dependencies
+			b := NewLocalBuilder(NewDependencyManager())
+			targetPath := filepath.Join(t.TempDir(), "chart.tgz")
+
+			cb, err := b.Build(context.TODO(), LocalReference{WorkDir: workDir, Path: "helmchartwithdeps"}, targetPath,
+				BuildOptions{SkipDependencyUpdate: true})
+			if tt.wantErr != "" {
+				g.Expect(err).To(HaveOccurred())
+				g.Expect(err.Error()).To(ContainSubstring(tt.wantErr))
+				return
+			}
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(cb.Path).To(Equal(targetPath))
+			g.Expect(cb.ResolvedDependencies).To(BeZero())
+
+			resultChart, err := secureloader.LoadFile(cb.Path)
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(resultChart.Dependencies()).To(HaveLen(len(tt.vendored)))
+		})
+	}
+}
+
+func TestLocalBuilder_Build_Mutators(t *testing.T) {
+	tests := []struct {
+		name     string
+		path     string
+		mutators []ChartMutator
+		wantErr  string
+	}{
+		{
+			name:     "chart directory",
+			path:     "helmchart",
+			mutators: []ChartMutator{addTemplateMutator("templates/configmap.yaml", "kind: ConfigMap")},
+		},
+		{
+			name:     "packaged chart",
+			path:     "helmchart-0.1.0.tgz",
+			mutators: []ChartMutator{addTemplateMutator("templates/configmap.yaml", "kind: ConfigMap")},
+		},
+		{
+			name: "mutator error",
+			path: "helmchart",
+			mutators: []ChartMutator{func(*helmchart.Chart) error {
+				return fmt.Errorf("mutation failed")
+			}},
+			wantErr: "chart mutator 0 failed: mutation failed",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			b := NewLocalBuilder(NewDependencyManager())
+			targetPath := filepath.Join(t.TempDir(), "chart.tgz")
+
+			cb, err := b.Build(context.TODO(), LocalReference{WorkDir: "../testdata/charts", Path: tt.path}, targetPath,
+				BuildOptions{Mutators: tt.mutators})
+			if tt.wantErr != "" {
+				g.Expect(err).To(HaveOccurred())
+				g.Expect(err.Error()).To(ContainSubstring(tt.wantErr))
+				return
+			}
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(cb.Path).To(Equal(targetPath))
+			g.Expect(cb.Packaged).To(BeTrue())
+
+			resultChart, err := secureloader.LoadFile(cb.Path)
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(resultChart.Templates).To(ContainElement(&helmchart.File{
+				Name: "templates/configmap.yaml",
+				Data: []byte("kind: ConfigMap"),
+			}))
+		})
+	}
+}
+
+func TestLocalBuilder_Build_MemoryBudget(t *testing.T) {
+	g := NewWithT(t)
+
+	// Generate a chart of 512 templates of 4KiB spread over nested
+	// directories, with a values file of 64KiB next to it
+	workDir := t.TempDir()
+	chartDir := filepath.Join(workDir, "large")
+	g.Expect(os.MkdirAll(chartDir, 0o700)).To(Succeed())
+	g.Expect(os.WriteFile(filepath.Join(chartDir, "Chart.yaml"),
+		[]byte("apiVersion: v2\nname: large\nversion: 0.1.0\n"), 0o600)).To(Succeed())
+	template := []byte("# " + strings.Repeat("x", 4093) + "\n")
+	for i := 0; i < 512; i++ {
+		dir := filepath.Join(chartDir, "templates", fmt.Sprintf("group-%d", i%16))
+		g.Expect(os.MkdirAll(dir, 0o700)).To(Succeed())
+		g.Expect(os.WriteFile(filepath.Join(dir, fmt.Sprintf("template-%d.yaml", i)), template, 0o600)).To(Succeed())
+	}
+	g.Expect(os.WriteFile(filepath.Join(workDir, "values.yaml"),
+		[]byte("padding: "+strings.Repeat("x", 64<<10)+"\n"), 0o600)).To(Succeed())
+
+	tests := []struct {
+		name        string
+		budget      int64
+		valuesFiles []string
+		wantErr     string
+	}{
+		{
+			name: "no budget",
+		},
+		{
+			name:   "chart within budget",
+			budget: 4 << 20,
+		},
+		{
+			name:    "chart exceeding budget",
+			budget:  1 << 20,
+			wantErr: "failed to load chart within memory budget (1048576)",
+		},
+		{
+			name:        "chart and values files exceeding budget",
+			budget:      2<<20 + 32<<10,
+			valuesFiles: []string{"values.yaml"},
+			wantErr:     "failed to load chart within memory budget",
+		},
+		{
+			name:        "values files exceeding budget",
+			budget:      32 << 10,
+			valuesFiles: []string{"values.yaml"},
+			wantErr:     "total size of values files (65546) exceeds memory budget (32768)",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			b := NewLocalBuilder(NewDependencyManager())
+			targetPath := filepath.Join(t.TempDir(), "chart.tgz")
+
+			cb, err := b.Build(context.TODO(), LocalReference{WorkDir: workDir, Path: "large"}, targetPath,
+				BuildOptions{MemoryBudget: tt.budget, ValuesFiles: tt.valuesFiles, SkipDependencyUpdate: true})
+			if tt.wantErr != "" {
+				g.Expect(err).To(HaveOccurred())
+				g.Expect(err.Error()).To(ContainSubstring(tt.wantErr))
+				g.Expect(err).To(MatchError(ErrMemoryBudget))
+				g.Expect(targetPath).ToNot(BeAnExistingFile())
+				return
+			}
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(cb.Path).To(Equal(targetPath))
+
+			resultChart, err := secureloader.LoadFile(cb.Path)
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(resultChart.Templates).To(HaveLen(512))
+		})
+	}
+}
+
+// addTemplateMutator returns a ChartMutator which adds a template with the
+// given name and data to the chart.
+func addTemplateMutator(name, data string) ChartMutator {
+	return func(chart *helmchart.Chart) error {
+		chart.Templates = append(chart.Templates, &helmchart.File{Name: name, Data: []byte(data)})
+		return nil
+	}
+}
+
+func Test_resolveChartGlob(t *testing.T) {
+	tests := []struct {
+		name    string
+		charts  []string
+		dirs    []string
+		pattern string
+		want    string
+		wantErr string
+	}{
+		{
+			name:    "single chart in charts directory",
+			charts:  []string{"charts/app"},
+			dirs:    []string{"charts/docs"},
+			pattern: "charts/*/",
+			want:    "charts/app",
+		},
+		{
+			name:    "nested chart",
+			charts:  []string{"services/api/chart", "services/web/chart"},
+			pattern: "services/a*/chart",
+			want:    "services/api/chart",
+		},
+		{
+			name:    "traversal is contained in base directory",
+			charts:  []string{"charts/app"},
+			pattern: "../../charts/*",
+			want:    "charts/app",
+		},
+		{
+			name:    "no chart matches",
+			dirs:    []string{"charts/docs"},
+			pattern: "charts/*/",
+			wantErr: "no chart directory found matching 'charts/*/'",
+		},
+		{
+			name:    "multiple charts match",
+			charts:  []string{"charts/app", "charts/db"},
+			pattern: "charts/*/",
+			wantErr: "multiple chart directories found matching 'charts/*/': charts/app, charts/db",
+		},
+		{
+			name:    "invalid pattern",
+			pattern: "charts/[",
+			wantErr: "invalid chart path pattern 'charts/['",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			baseDir := t.TempDir()
+			for _, c := range tt.charts {
+				g.Expect(os.MkdirAll(filepath.Join(baseDir, c), 0o700)).To(Succeed())
+				g.Expect(os.WriteFile(filepath.Join(baseDir, c, "Chart.yaml"), []byte("name: chart"), 0o640)).To(Succeed())
+			}
+			for _, d := range tt.dirs {
+				g.Expect(os.MkdirAll(filepath.Join(baseDir, d), 0o700)).To(Succeed())
+			}
+
+			got, err := resolveChartGlob(baseDir, tt.pattern)
+			if tt.wantErr != "" {
+				g.Expect(err).To(HaveOccurred())
+				g.Expect(err.Error()).To(ContainSubstring(tt.wantErr))
+				return
+			}
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(got).To(Equal(tt.want))
+		})
+	}
+}
+
+func TestResolveIndexedChart(t *testing.T) {
+	const index = `apiVersion: v1
+entries:
+  helmchart:
+  - name: helmchart
+    version: 0.2.0
+    urls:
+    - charts/missing-0.2.0.tgz
+  - name: helmchart
+    version: 0.1.0
+    urls:
+    - charts/helmchart-0.1.0.tgz
+  remote:
+  - name: remote
+    version: 1.0.0
+    urls:
+    - https://example.com/remote-1.0.0.tgz
+  escape:
+  - name: escape
+    version: 1.0.0
+    urls:
+    - ../../helmchart-0.1.0.tgz
+`
+
+	tests := []struct {
+		name    string
+		index   string
+		chart   string
+		version string
+		want    string
+		wantErr string
+	}{
+		{
+			name:    "chart version from index",
+			index:   index,
+			chart:   "helmchart",
+			version: "<0.2.0",
+			want:    "charts/helmchart-0.1.0.tgz",
+		},
+		{
+			name:  "chart path takes precedence over index",
+			index: index,
+			chart: "charts",
+		},
+		{
+			name:  "no index",
+			chart: "helmchart",
+		},
+		{
+			name:    "chart not in index",
+			index:   index,
+			chart:   "other",
+			version: "*",
+			wantErr: "failed to find chart 'other' with version '*' in index 'index.yaml'",
+		},
+		{
+			name:    "chart file missing in source",
+			index:   index,
+			chart:   "helmchart",
+			version: "*",
+			wantErr: "chart 'helmchart' with version '0.2.0' refers to 'charts/missing-0.2.0.tgz' which does not exist in the source",
+		},
+		{
+			name:    "absolute URL",
+			index:   index,
+			chart:   "remote",
+			version: "*",
+			wantErr: "only URLs relative to the index are supported",
+		},
+		{
+			name:    "traversal is contained in work directory",
+			index:   index,
+			chart:   "escape",
+			version: "*",
+			want:    "helmchart-0.1.0.tgz",
+		},
+		{
+			name:    "invalid index",
+			index:   "entries: {}\n",
+			chart:   "helmchart",
+			wantErr: "failed to load chart repository index 'index.yaml'",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			workDir := t.TempDir()
+			g.Expect(os.MkdirAll(filepath.Join(workDir, "charts"), 0o700)).To(Succeed())
+			g.Expect(copy.Copy("./../testdata/charts/helmchart-0.1.0.tgz", filepath.Join(workDir, "charts", "helmchart-0.1.0.tgz"))).To(Succeed())
+			g.Expect(copy.Copy("./../testdata/charts/helmchart-0.1.0.tgz", filepath.Join(workDir, "helmchart-0.1.0.tgz"))).To(Succeed())
+			if tt.index != "" {
+				g.Expect(os.WriteFile(filepath.Join(workDir, "index.yaml"), []byte(tt.index), 0o640)).To(Succeed())
+			}
+
+			got, err := ResolveIndexedChart(workDir, tt.chart, tt.version)
+			if tt.wantErr != "" {
+				g.Expect(err).To(HaveOccurred())
+				g.Expect(err.Error()).To(ContainSubstring(tt.wantErr))
+				return
+			}
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(got).To(Equal(tt.want))
+		})
+	}
+}
+
 func Test_copyFileToPath(t *testing.T) {
 	tests := []struct {
 		name    string
@@ -27,6 +27,7 @@ import (
 	"github.com/Masterminds/semver/v3"
 	helmchart "helm.sh/helm/v3/pkg/chart"
 	"helm.sh/helm/v3/pkg/chartutil"
+	"helm.sh/helm/v3/pkg/registry"
 	"helm.sh/helm/v3/pkg/repo"
 	"sigs.k8s.io/yaml"
 
@@ -49,6 +50,23 @@ func NewRemoteBuilder(repository repository.Downloader) Builder {
 	}
 }
 
+// ResolveVersion returns the version of the chart the given RemoteReference
+// resolves to in the given repository index, without downloading or building
+// the chart. A RemoteReference without a version resolves to the latest stable
+// version, and a semver range to the latest version within the range.
+func ResolveVersion(index *repo.IndexFile, ref RemoteReference) (string, error) {
+	if err := ref.Validate(); err != nil {
+		return "", &BuildError{Reason: ErrChartReference, Err: err}
+	}
+
+	cv, err := repository.FindChartVersion(index, ref.Name, ref.Version)
+	if err != nil {
+		err = fmt.Errorf("failed to get chart version for remote reference: %w", err)
+		return "", &BuildError{Reason: ErrChartReference, Err: err}
+	}
+	return cv.Version, nil
+}
+
 // Build attempts to build a Helm chart with the given RemoteReference and
 // BuildOptions, writing it to p.
 // It returns a Build describing the produced (or from cache observed) chart
@@ -57,12 +75,14 @@ func NewRemoteBuilder(repository repository.Downloader) Builder {
 // The latest version for the RemoteReference.Version is determined in the
 // repository.ChartRepository, only downloading it if the version (including
 // BuildOptions.VersionMetadata) differs from the current BuildOptions.CachedChart.
-// BuildOptions.ValuesFiles changes are in this case not taken into account,
-// and BuildOptions.Force should be used to enforce a rebuild.
+// BuildOptions.ValuesFiles and BuildOptions.Values changes are in this case
+// not taken into account, and BuildOptions.Force or a change of the
+// BuildOptions.VersionMetadata should be used to enforce a rebuild.
 //
 // After downloading the chart, it is only packaged if required due to BuildOptions
 // modifying the chart, otherwise the exact data as retrieved from the repository
-// is written to p, after validating it to be a chart.
+// is written to p, after validating it to be a chart. The BuildOptions.Mutators
+// are invoked on the downloaded chart right before packaging it.
 func (b *remoteChartBuilder) Build(ctx context.Context, ref Reference, p string, opts BuildOptions) (*Build, error) {
 	remoteRef, ok := ref.(RemoteReference)
 	if !ok {
@@ -74,6 +94,15 @@ func (b *remoteChartBuilder) Build(ctx context.Context, ref Reference, p string,
 		return nil, &BuildError{Reason: ErrChartReference, Err: err}
 	}
 
+	// A chart pinned to a digest is referenced by its exact version, which is
+	// checked against the downloaded chart
+	pinnedVersion, digest := repository.SplitVersionDigest(remoteRef.Version)
+	if digest != "" {
+		if err := validatePinnedReference(b.remote, pinnedVersion, digest); err != nil {
+			return nil, &BuildError{Reason: ErrChartReference, Err: err}
+		}
+	}
+
 	res, result, err := b.downloadFromRepository(ctx, b.remote, remoteRef, opts)
 	if err != nil {
 		return nil, err
@@ -82,7 +111,14 @@ func (b *remoteChartBuilder) Build(ctx context.Context, ref Reference, p string,
 		return result, nil
 	}
 
-	requiresPackaging := len(opts.GetValuesFiles()) != 0 || opts.VersionMetadata != ""
+	if digest != "" {
+		if err := checkPinnedVersion(res, pinnedVersion, digest); err != nil {
+			return nil, &BuildError{Reason: ErrChartReference, Err: err}
+		}
+	}
+
+	requiresPackaging := len(opts.GetValuesFiles()) != 0 || len(opts.Values) != 0 || opts.VersionMetadata != "" ||
+		len(opts.Mutators) != 0
 
 	// Use literal chart copy from remote if no custom values files options are
 	// set or version metadata isn't set.
@@ -91,6 +127,7 @@ func (b *remoteChartBuilder) Build(ctx context.Context, ref Reference, p string,
 			return nil, &BuildError{Reason: ErrChartPull, Err: err}
 		}
 		result.Path = p
+		result.Warnings = archiveWarnings(p)
 		return result, nil
 	}
 
@@ -102,17 +139,37 @@ func (b *remoteChartBuilder) Build(ctx context.Context, ref Reference, p string,
 	}
 	chart.Metadata.Version = result.Version
 
-	mergedValues, err := mergeChartValues(chart, opts.ValuesFiles)
-	if err != nil {
-		err = fmt.Errorf("failed to merge chart values: %w", err)
-		return result, &BuildError{Reason: ErrValuesFilesMerge, Err: err}
+	valuesFiles := opts.GetValuesFiles()
+	if opts.IgnoreMissingValuesFiles {
+		valuesFiles, result.MissingValuesFiles = splitMissingChartValues(chart, valuesFiles)
+	}
+	var mergedValues map[string]interface{}
+	if len(valuesFiles) > 0 {
+		if mergedValues, err = mergeChartValues(chart, valuesFiles); err != nil {
+			err = fmt.Errorf("failed to merge chart values: %w", err)
+			return result, &BuildError{Reason: ErrValuesFilesMerge, Err: err}
+		}
+	}
+	// Merge the values from the options last, on top of the chart's
+	// default values if no values files were merged
+	if len(opts.Values) > 0 {
+		if len(valuesFiles) == 0 {
+			mergedValues = chart.Values
+		}
+		mergedValues = transform.MergeMaps(mergedValues, opts.Values)
 	}
 	// Overwrite default values with merged values, if any
 	if ok, err = OverwriteChartDefaultValues(chart, mergedValues); ok || err != nil {
 		if err != nil {
 			return nil, &BuildError{Reason: ErrValuesFilesMerge, Err: err}
 		}
-		result.ValuesFiles = opts.GetValuesFiles()
+		result.ValuesFiles = valuesFiles
+		result.ValuesFrom = opts.ValuesFrom
+	}
+
+	// Mutate the chart, if instructed
+	if err = mutateChart(chart, opts.Mutators); err != nil {
+		return nil, &BuildError{Reason: ErrChartPackage, Err: err}
 	}
 
 	// Package the chart with the custom values
@@ -121,9 +178,17 @@ func (b *remoteChartBuilder) Build(ctx context.Context, ref Reference, p string,
 	}
 	result.Path = p
 	result.Packaged = true
+	result.Warnings = chartWarnings(chart)
 	return result, nil
 }
 
+// configMediaTypeGetter is implemented by repository.Downloader
+// implementations which can resolve the config media type of a chart before
+// downloading it, e.g. repository.OCIChartRepository.
+type configMediaTypeGetter interface {
+	GetChartConfigMediaType(chart *repo.ChartVersion) (string, error)
+}
+
 func (b *remoteChartBuilder) downloadFromRepository(ctx context.Context, remote repository.Downloader, remoteRef RemoteReference, opts BuildOptions) (*bytes.Buffer, *Build, error) {
 	// Get the current version for the RemoteReference
 	cv, err := remote.GetChartVersion(remoteRef.Name, remoteRef.Version)
@@ -152,11 +217,25 @@ func (b *remoteChartBuilder) downloadFromRepository(ctx context.Context, remote
 	if err != nil {
 		return nil, nil, err
 	}
+	result.Verified = opts.Verify
 
 	if shouldReturn {
 		return nil, result, nil
 	}
 
+	// Ensure the artifact is a Helm chart before downloading it
+	if r, ok := remote.(configMediaTypeGetter); ok {
+		mediaType, err := r.GetChartConfigMediaType(cv)
+		if err != nil {
+			return nil, nil, &BuildError{Reason: ErrChartPull, Err: err}
+		}
+		if mediaType != "" && mediaType != registry.ConfigMediaType {
+			err = fmt.Errorf("artifact '%s' is not a Helm chart: config media type is '%s' instead of '%s', "+
+				"make sure the chart reference points to a chart pushed with 'helm push'", cv.URLs[0], mediaType, registry.ConfigMediaType)
+			return nil, nil, &BuildError{Reason: ErrChartPackage, Err: err}
+		}
+	}
+
 	// Download the package for the resolved version
 	res, err := remote.DownloadChart(cv)
 	if err != nil {
@@ -173,6 +252,7 @@ func generateBuildResult(cv *repo.ChartVersion, opts BuildOptions) (*Build, bool
 	result := &Build{}
 	result.Version = cv.Version
 	result.Name = cv.Name
+	result.Digest = cv.Digest
 
 	// Set build specific metadata if instructed
 	if opts.VersionMetadata != "" {
@@ -183,13 +263,15 @@ func generateBuildResult(cv *repo.ChartVersion, opts BuildOptions) (*Build, bool
 		result.Version = ver.String()
 	}
 
-	requiresPackaging := len(opts.GetValuesFiles()) != 0 || opts.VersionMetadata != ""
+	requiresPackaging := len(opts.GetValuesFiles()) != 0 || len(opts.Values) != 0 || opts.VersionMetadata != "" ||
+		len(opts.Mutators) != 0
 
 	// If all the following is true, we do not need to download and/or build the chart:
 	// - Chart name from cached chart matches resolved name
 	// - Chart version from cached chart matches calculated version
+	// - Chart digest from the index matches the digest of the cached chart, if known
 	// - BuildOptions.Force is False
-	if opts.CachedChart != "" && !opts.Force {
+	if opts.CachedChart != "" && !opts.Force && !digestChanged(opts.CachedChartDigest, cv.Digest) {
 		if curMeta, err := LoadChartMetadataFromArchive(opts.CachedChart); err == nil {
 			// If the cached metadata is corrupt, we ignore its existence
 			// and continue the build
@@ -197,6 +279,7 @@ func generateBuildResult(cv *repo.ChartVersion, opts BuildOptions) (*Build, bool
 				if result.Name == curMeta.Name && result.Version == curMeta.Version {
 					result.Path = opts.CachedChart
 					result.ValuesFiles = opts.GetValuesFiles()
+					result.ValuesFrom = opts.ValuesFrom
 					result.Packaged = requiresPackaging
 					return result, true, nil
 				}
@@ -207,6 +290,41 @@ func generateBuildResult(cv *repo.ChartVersion, opts BuildOptions) (*Build, bool
 	return result, false, nil
 }
 
+// validatePinnedReference returns an error if a chart pinned to the given
+// digest is not referenced by an exact version, or the given
+// repository.Downloader is not an OCI repository.
+func validatePinnedReference(remote repository.Downloader, version, digest string) error {
+	if _, ok := remote.(*repository.OCIChartRepository); !ok {
+		return fmt.Errorf("digest '%s' is only supported for charts from an OCI repository", digest)
+	}
+	if _, err := semver.NewVersion(version); err != nil {
+		return fmt.Errorf("a chart pinned to digest '%s' must be referenced by its exact version, e.g. '1.0.0@%s'", digest, digest)
+	}
+	return nil
+}
+
+// checkPinnedVersion returns an error if the version of the chart in the
+// given package, which was downloaded by the given digest, differs from the
+// given version.
+func checkPinnedVersion(res *bytes.Buffer, version, digest string) error {
+	chart, err := secureloader.LoadArchive(bytes.NewReader(res.Bytes()))
+	if err != nil {
+		return fmt.Errorf("failed to load chart with digest '%s': %w", digest, err)
+	}
+	want, _ := semver.NewVersion(version)
+	got, err := semver.NewVersion(chart.Metadata.Version)
+	if err != nil || want == nil || !got.Equal(want) {
+		return fmt.Errorf("chart with digest '%s' has version '%s' instead of '%s'", digest, chart.Metadata.Version, version)
+	}
+	return nil
+}
+
+// digestChanged returns true if both the cached and resolved chart digests
+// are known, and they differ.
+func digestChanged(cached, resolved string) bool {
+	return cached != "" && resolved != "" && cached != resolved
+}
+
 func setBuildMetaData(version, versionMetadata string) (*semver.Version, error) {
 	ver, err := semver.NewVersion(version)
 	if err != nil {
@@ -248,6 +366,28 @@ func mergeChartValues(chart *helmchart.Chart, paths []string) (map[string]interf
 	return mergedValues, nil
 }
 
+// splitMissingChartValues splits the given values file paths into the paths of
+// the files which exist in the given chart.Chart, and the paths of the files
+// which do not.
+func splitMissingChartValues(chart *helmchart.Chart, paths []string) (present, missing []string) {
+	for _, p := range paths {
+		cfn := filepath.Clean(p)
+		found := cfn == chartutil.ValuesfileName
+		for _, f := range chart.Files {
+			if f.Name == cfn {
+				found = true
+				break
+			}
+		}
+		if found {
+			present = append(present, p)
+			continue
+		}
+		missing = append(missing, p)
+	}
+	return present, missing
+}
+
 // validatePackageAndWriteToPath atomically writes the packaged chart from reader
 // to out while validating it by loading the chart metadata from the archive.
 func validatePackageAndWriteToPath(reader io.Reader, out string) error {
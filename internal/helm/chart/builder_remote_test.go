@@ -19,7 +19,9 @@ package chart
 import (
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
+	"net/http/httptest"
 	"net/url"
 	"os"
 	"path/filepath"
@@ -27,11 +29,18 @@ import (
 	"sync"
 	"testing"
 
+	"github.com/google/go-containerregistry/pkg/crane"
+	gcrregistry "github.com/google/go-containerregistry/pkg/registry"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
+	"github.com/google/go-containerregistry/pkg/v1/random"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/google/go-containerregistry/pkg/v1/types"
 	. "github.com/onsi/gomega"
 	helmchart "helm.sh/helm/v3/pkg/chart"
 	"helm.sh/helm/v3/pkg/chartutil"
 	helmgetter "helm.sh/helm/v3/pkg/getter"
 	"helm.sh/helm/v3/pkg/registry"
+	"helm.sh/helm/v3/pkg/repo"
 
 	"github.com/fluxcd/source-controller/internal/helm/chart/secureloader"
 	"github.com/fluxcd/source-controller/internal/helm/repository"
@@ -122,6 +131,7 @@ entries:
 		wantValues   chartutil.Values
 		wantVersion  string
 		wantPackaged bool
+		wantMissing  []string
 		wantErr      string
 	}{
 		{
@@ -146,6 +156,12 @@ entries:
 			repository: mockRepo(),
 			wantErr:    "failed to get chart version for remote reference",
 		},
+		{
+			name:       "pinned to digest",
+			reference:  RemoteReference{Name: "grafana", Version: "6.17.4@sha256:" + strings.Repeat("a", 64)},
+			repository: mockRepo(),
+			wantErr:    "is only supported for charts from an OCI repository",
+		},
 		{
 			name:       "invalid version metadata",
 			reference:  RemoteReference{Name: "grafana"},
@@ -184,6 +200,31 @@ entries:
 			},
 			wantPackaged: true,
 		},
+		{
+			name:      "missing values file",
+			reference: RemoteReference{Name: "grafana"},
+			buildOpts: BuildOptions{
+				ValuesFiles: []string{"a.yaml", "missing.yaml"},
+			},
+			repository: mockRepo(),
+			wantErr:    "no values file found at path 'missing.yaml'",
+		},
+		{
+			name:      "ignore missing values files",
+			reference: RemoteReference{Name: "grafana"},
+			buildOpts: BuildOptions{
+				ValuesFiles:              []string{"a.yaml", "missing.yaml", "b.yaml", "c.yaml"},
+				IgnoreMissingValuesFiles: true,
+			},
+			repository:  mockRepo(),
+			wantVersion: "6.17.4",
+			wantValues: chartutil.Values{
+				"a": "b",
+				"b": "d",
+			},
+			wantPackaged: true,
+			wantMissing:  []string{"missing.yaml"},
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -221,6 +262,10 @@ entries:
 			for k, v := range tt.wantValues {
 				g.Expect(v).To(Equal(resultChart.Values[k]))
 			}
+			if tt.wantMissing != nil {
+				g.Expect(cb.MissingValuesFiles).To(Equal(tt.wantMissing))
+				g.Expect(cb.Summary()).To(ContainSubstring(fmt.Sprintf("skipped missing values files %v", tt.wantMissing)))
+			}
 		})
 	}
 }
@@ -253,6 +298,8 @@ func TestRemoteBuilder_BuildFromOCIChatRepository(t *testing.T) {
 			RegistryClient: registryClient,
 		}
 	}
+	digest := "sha256:" + strings.Repeat("a", 64)
+
 	mockRepoWithoutChart := func() *repository.OCIChartRepository {
 		return &repository.OCIChartRepository{
 			URL: *u,
@@ -295,6 +342,27 @@ func TestRemoteBuilder_BuildFromOCIChatRepository(t *testing.T) {
 			repository: mockRepoWithoutChart(),
 			wantErr:    "failed to download chart for remote reference",
 		},
+		{
+			name:        "pinned to digest",
+			reference:   RemoteReference{Name: "grafana", Version: "0.1.0@" + digest},
+			repository:  mockRepo(),
+			wantVersion: "0.1.0",
+			wantValues: chartutil.Values{
+				"replicaCount": float64(1),
+			},
+		},
+		{
+			name:       "pinned to digest without version",
+			reference:  RemoteReference{Name: "grafana", Version: "@" + digest},
+			repository: mockRepo(),
+			wantErr:    "must be referenced by its exact version",
+		},
+		{
+			name:       "pinned to digest of other version",
+			reference:  RemoteReference{Name: "grafana", Version: "6.17.4@" + digest},
+			repository: mockRepo(),
+			wantErr:    "has version '0.1.0' instead of '6.17.4'",
+		},
 		{
 			name:       "invalid version metadata",
 			reference:  RemoteReference{Name: "grafana"},
@@ -378,6 +446,69 @@ func TestRemoteBuilder_BuildFromOCIChatRepository(t *testing.T) {
 	}
 }
 
+func TestRemoteBuilder_BuildFromOCIChartRepository_configMediaType(t *testing.T) {
+	chartGrafana, err := os.ReadFile("./../testdata/charts/helmchart-0.1.0.tgz")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	srv := httptest.NewServer(gcrregistry.New())
+	t.Cleanup(srv.Close)
+	host := strings.TrimPrefix(srv.URL, "http://")
+
+	tests := []struct {
+		name            string
+		configMediaType types.MediaType
+		wantErr         string
+	}{
+		{
+			name:            "Helm chart",
+			configMediaType: registry.ConfigMediaType,
+		},
+		{
+			name:            "container image",
+			configMediaType: types.DockerConfigJSON,
+			wantErr:         "is not a Helm chart: config media type is 'application/vnd.docker.container.image.v1+json'",
+		},
+		{
+			name:            "Flux artifact",
+			configMediaType: "application/vnd.cncf.flux.config.v1+json",
+			wantErr:         "is not a Helm chart",
+		},
+	}
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			img, err := random.Image(1024, 1)
+			g.Expect(err).ToNot(HaveOccurred())
+			img = mutate.ConfigMediaType(img, tt.configMediaType)
+			repoName := fmt.Sprintf("%s/charts-%d", host, i)
+			g.Expect(crane.Push(img, repoName+"/helmchart:0.1.0")).To(Succeed())
+
+			chartRepo, err := repository.NewOCIChartRepository("oci://"+repoName,
+				repository.WithOCIRegistryClient(&mockRegistryClient{}),
+				repository.WithOCIRemoteOptions(remote.WithContext(context.TODO())))
+			g.Expect(err).ToNot(HaveOccurred())
+			chartRepo.Client = &mockIndexChartGetter{ChartResponse: chartGrafana}
+
+			b := NewRemoteBuilder(chartRepo)
+			cb, err := b.Build(context.TODO(), RemoteReference{Name: "helmchart", Version: "0.1.0"},
+				filepath.Join(t.TempDir(), "chart.tgz"), BuildOptions{})
+			if tt.wantErr != "" {
+				g.Expect(err).To(HaveOccurred())
+				g.Expect(err.Error()).To(ContainSubstring(tt.wantErr))
+				var buildErr *BuildError
+				g.Expect(errors.As(err, &buildErr)).To(BeTrue())
+				g.Expect(buildErr.Reason).To(Equal(ErrChartPackage))
+				return
+			}
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(cb.Path).ToNot(BeEmpty())
+		})
+	}
+}
+
 func TestRemoteBuilder_Build_CachedChart(t *testing.T) {
 	g := NewWithT(t)
 
@@ -394,6 +525,7 @@ entries:
       description: string
       version: 0.1.0
       name: helmchart
+      digest: sha256:abc
 `)
 
 	mockGetter := &mockIndexChartGetter{
@@ -425,9 +557,11 @@ entries:
 	buildOpts := BuildOptions{}
 	cb, err := b.Build(context.TODO(), reference, targetPath, buildOpts)
 	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(cb.Digest).To(Equal("sha256:abc"))
 
 	// Set the result as the CachedChart for second build.
 	buildOpts.CachedChart = cb.Path
+	buildOpts.CachedChartDigest = cb.Digest
 
 	// Rebuild with a new path.
 	targetPath2 := filepath.Join(tmpDir, "chart2.tgz")
@@ -435,6 +569,13 @@ entries:
 	g.Expect(err).ToNot(HaveOccurred())
 	g.Expect(cb.Path).To(Equal(targetPath))
 
+	// Rebuild with a different digest of the cached chart.
+	buildOpts.CachedChartDigest = "sha256:def"
+	cb, err = b.Build(context.TODO(), reference, targetPath2, buildOpts)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(cb.Path).To(Equal(targetPath2))
+	buildOpts.CachedChartDigest = ""
+
 	// Rebuild with build option Force.
 	buildOpts.Force = true
 	cb, err = b.Build(context.TODO(), reference, targetPath2, buildOpts)
@@ -442,6 +583,152 @@ entries:
 	g.Expect(cb.Path).To(Equal(targetPath2))
 }
 
+func TestRemoteBuilder_Build_Mutators(t *testing.T) {
+	g := NewWithT(t)
+
+	chartGrafana, err := os.ReadFile("./../testdata/charts/helmchart-0.1.0.tgz")
+	g.Expect(err).ToNot(HaveOccurred())
+
+	index := []byte(`
+apiVersion: v1
+entries:
+  helmchart:
+    - urls:
+        - https://example.com/helmchart-0.1.0.tgz
+      description: string
+      version: 0.1.0
+      name: helmchart
+`)
+
+	mockRepo := &repository.ChartRepository{
+		URL: "https://grafana.github.io/helm-charts/",
+		Client: &mockIndexChartGetter{
+			IndexResponse: index,
+			ChartResponse: chartGrafana,
+		},
+		RWMutex: &sync.RWMutex{},
+	}
+	_, err = mockRepo.CacheIndex()
+	g.Expect(err).ToNot(HaveOccurred())
+	defer os.Remove(mockRepo.CachePath)
+
+	b := NewRemoteBuilder(mockRepo)
+	targetPath := filepath.Join(t.TempDir(), "chart.tgz")
+
+	cb, err := b.Build(context.TODO(), RemoteReference{Name: "helmchart"}, targetPath, BuildOptions{
+		Mutators: []ChartMutator{addTemplateMutator("templates/configmap.yaml", "kind: ConfigMap")},
+	})
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(cb.Path).To(Equal(targetPath))
+	g.Expect(cb.Packaged).To(BeTrue())
+
+	resultChart, err := secureloader.LoadFile(cb.Path)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(resultChart.Templates).To(ContainElement(&helmchart.File{
+		Name: "templates/configmap.yaml",
+		Data: []byte("kind: ConfigMap"),
+	}))
+
+	_, err = b.Build(context.TODO(), RemoteReference{Name: "helmchart"}, targetPath, BuildOptions{
+		Mutators: []ChartMutator{func(*helmchart.Chart) error {
+			return errors.New("mutation failed")
+		}},
+	})
+	g.Expect(err).To(HaveOccurred())
+	g.Expect(err.Error()).To(ContainSubstring("chart mutator 0 failed: mutation failed"))
+}
+
+func TestResolveVersion(t *testing.T) {
+	chartVersion := func(ver string) *repo.ChartVersion {
+		return &repo.ChartVersion{Metadata: &helmchart.Metadata{Name: "podinfo", Version: ver}}
+	}
+	index := &repo.IndexFile{
+		Entries: map[string]repo.ChartVersions{
+			"podinfo": {
+				chartVersion("6.2.0-rc.2"),
+				chartVersion("6.2.0-rc.1"),
+				chartVersion("6.1.6"),
+				chartVersion("6.1.5"),
+				chartVersion("5.2.1"),
+			},
+		},
+	}
+
+	tests := []struct {
+		name    string
+		index   *repo.IndexFile
+		ref     RemoteReference
+		want    string
+		wantErr string
+	}{
+		{
+			name: "latest stable version",
+			ref:  RemoteReference{Name: "podinfo"},
+			want: "6.1.6",
+		},
+		{
+			name: "wildcard",
+			ref:  RemoteReference{Name: "podinfo", Version: "*"},
+			want: "6.1.6",
+		},
+		{
+			name: "semver range",
+			ref:  RemoteReference{Name: "podinfo", Version: "~5"},
+			want: "5.2.1",
+		},
+		{
+			name: "prerelease range",
+			ref:  RemoteReference{Name: "podinfo", Version: ">=6.2.0-rc.0"},
+			want: "6.2.0-rc.2",
+		},
+		{
+			name: "exact prerelease version",
+			ref:  RemoteReference{Name: "podinfo", Version: "6.2.0-rc.1"},
+			want: "6.2.0-rc.1",
+		},
+		{
+			name:    "no matching version",
+			ref:     RemoteReference{Name: "podinfo", Version: "7.x"},
+			wantErr: "no 'podinfo' chart with version matching '7.x' found",
+		},
+		{
+			name:    "chart not in index",
+			ref:     RemoteReference{Name: "grafana"},
+			wantErr: "no chart name found",
+		},
+		{
+			name:    "invalid reference",
+			ref:     RemoteReference{},
+			wantErr: "no name set for remote chart reference",
+		},
+		{
+			name:    "empty index",
+			index:   &repo.IndexFile{},
+			ref:     RemoteReference{Name: "podinfo"},
+			wantErr: "no chart name found",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			idx := index
+			if tt.index != nil {
+				idx = tt.index
+			}
+			got, err := ResolveVersion(idx, tt.ref)
+			if tt.wantErr != "" {
+				g.Expect(err).To(HaveOccurred())
+				g.Expect(err.Error()).To(ContainSubstring(tt.wantErr))
+				g.Expect(got).To(BeEmpty())
+				return
+			}
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(got).To(Equal(tt.want))
+		})
+	}
+}
+
 func Test_mergeChartValues(t *testing.T) {
 	tests := []struct {
 		name    string
@@ -201,6 +201,29 @@ func TestChartBuildResult_Summary(t *testing.T) {
 			},
 			want: "packaged 'chart' chart with version 'arbitrary-version' and merged values files [a.yaml b.yaml]",
 		},
+		{
+			name: "With app version",
+			build: &Build{
+				Name:       "chart",
+				Version:    "1.2.3",
+				AppVersion: "bd6bf40a1c2e",
+				Packaged:   true,
+				Path:       "chart.tgz",
+			},
+			want: "packaged 'chart' chart with version '1.2.3' and appVersion 'bd6bf40a1c2e'",
+		},
+		{
+			name: "With values from",
+			build: &Build{
+				Name:        "chart",
+				Version:     "1.2.3+a1b2c3d4e5f6",
+				Packaged:    true,
+				ValuesFiles: []string{"a.yaml"},
+				ValuesFrom:  []string{"ConfigMap/values", "Secret/values"},
+				Path:        "chart.tgz",
+			},
+			want: "packaged 'chart' chart with version '1.2.3+a1b2c3d4e5f6' and merged values files [a.yaml] and merged values from [ConfigMap/values Secret/values]",
+		},
 		{
 			name:  "Empty build",
 			build: &Build{},
@@ -221,6 +244,59 @@ func TestChartBuildResult_Summary(t *testing.T) {
 	}
 }
 
+func TestChartBuildResult_SummaryJSON(t *testing.T) {
+	tests := []struct {
+		name  string
+		build *Build
+		want  string
+	}{
+		{
+			name: "Pulled chart",
+			build: &Build{
+				Name:     "chart",
+				Version:  "1.2.3",
+				Path:     "chart.tgz",
+				Verified: true,
+			},
+			want: `{"action":"pulled","name":"chart","version":"1.2.3","resolvedDependencies":0,"verified":true}`,
+		},
+		{
+			name: "Packaged chart",
+			build: &Build{
+				Name:                 "chart",
+				Version:              "1.2.3+a1b2c3d4e5f6",
+				AppVersion:           "bd6bf40a1c2e",
+				Packaged:             true,
+				ResolvedDependencies: 2,
+				ValuesFiles:          []string{"a.yaml"},
+				MissingValuesFiles:   []string{"b.yaml"},
+				ValuesFrom:           []string{"ConfigMap/values"},
+				Path:                 "chart.tgz",
+			},
+			want: `{"action":"packaged","name":"chart","version":"1.2.3+a1b2c3d4e5f6","appVersion":"bd6bf40a1c2e",` +
+				`"resolvedDependencies":2,"valuesFiles":["a.yaml"],"missingValuesFiles":["b.yaml"],` +
+				`"valuesFrom":["ConfigMap/values"],"verified":false}`,
+		},
+		{
+			name:  "Empty build",
+			build: &Build{},
+			want:  "",
+		},
+		{
+			name:  "Nil build",
+			build: nil,
+			want:  "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			g.Expect(tt.build.SummaryJSON()).To(Equal(tt.want))
+		})
+	}
+}
+
 func TestChartBuildResult_String(t *testing.T) {
 	g := NewWithT(t)
 
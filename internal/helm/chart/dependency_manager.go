@@ -22,6 +22,7 @@ import (
 	"net/url"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 	"sync"
 
@@ -30,6 +31,7 @@ import (
 	"golang.org/x/sync/errgroup"
 	"golang.org/x/sync/semaphore"
 	helmchart "helm.sh/helm/v3/pkg/chart"
+	helmreg "helm.sh/helm/v3/pkg/registry"
 	"k8s.io/apimachinery/pkg/util/errors"
 
 	"github.com/fluxcd/source-controller/internal/helm/chart/secureloader"
@@ -40,6 +42,24 @@ import (
 // URL or an error describing why it could not be returned.
 type GetChartDownloaderCallback func(url string) (repository.Downloader, error)
 
+// ResolvedDependency describes a dependency resolved by the
+// DependencyManager.
+type ResolvedDependency struct {
+	// Name of the dependency, or its alias if set.
+	Name string
+	// Version of the dependency chart.
+	Version string
+	// Repository of the dependency as declared in the chart.
+	Repository string
+	// Digest the dependency was pinned to, if any.
+	Digest string
+}
+
+// String returns the dependency formatted as "<name>:<version>".
+func (d ResolvedDependency) String() string {
+	return fmt.Sprintf("%s:%s", d.Name, d.Version)
+}
+
 // DependencyManager manages dependencies for a Helm chart.
 type DependencyManager struct {
 	// downloaders contains a map of Downloader objects
@@ -56,6 +76,18 @@ type DependencyManager struct {
 	// Build. Defaults to 1 (non-concurrent).
 	concurrent int64
 
+	// pinned contains the digests of the remote dependencies which were
+	// pinned to an OCI digest, indexed by their (alias) name.
+	pinned map[string]string
+
+	// resolved contains the dependencies resolved by Build, indexed by their
+	// (alias) name.
+	resolved map[string]ResolvedDependency
+
+	// verify enables the verification of the signature of the remote
+	// dependencies with the Downloader they are resolved from.
+	verify bool
+
 	// mu contains the lock for chart writes.
 	mu sync.Mutex
 }
@@ -83,6 +115,38 @@ func (o WithConcurrent) applyToDependencyManager(dm *DependencyManager) {
 	dm.concurrent = int64(o)
 }
 
+// WithDependencyVerification enables the verification of the signature of
+// every remote dependency before it is downloaded, using the VerifyChart method
+// of the repository.Downloader of the dependency. A dependency which is
+// unsigned or fails the verification fails the Build with a
+// DependencyVerificationError.
+type WithDependencyVerification bool
+
+func (o WithDependencyVerification) applyToDependencyManager(dm *DependencyManager) {
+	dm.verify = bool(o)
+}
+
+// DependencyVerificationError is returned by DependencyManager.Build if the
+// signature of a remote dependency could not be verified.
+type DependencyVerificationError struct {
+	// Name of the dependency, or its alias if set.
+	Name string
+	// Repository of the dependency as declared in the chart.
+	Repository string
+	// Err is the verification error.
+	Err error
+}
+
+// Error returns the verification error, prefixed with the dependency.
+func (e *DependencyVerificationError) Error() string {
+	return fmt.Sprintf("failed to verify dependency '%s' from '%s': %s", e.Name, e.Repository, e.Err.Error())
+}
+
+// Unwrap returns the underlying Err.
+func (e *DependencyVerificationError) Unwrap() error {
+	return e.Err
+}
+
 // NewDependencyManager returns a new DependencyManager configured with the given
 // DependencyManagerOption list.
 func NewDependencyManager(opts ...DependencyManagerOption) *DependencyManager {
@@ -105,6 +169,58 @@ func (dm *DependencyManager) Clear() error {
 	return errors.NewAggregate(errs)
 }
 
+// PinnedDependencies returns the remote dependencies resolved by Build which
+// were pinned to an OCI digest, formatted as "<name>@<digest>" and sorted by
+// name.
+func (dm *DependencyManager) PinnedDependencies() []string {
+	dm.mu.Lock()
+	defer dm.mu.Unlock()
+
+	var pinned []string
+	for name, digest := range dm.pinned {
+		pinned = append(pinned, fmt.Sprintf("%s@%s", name, digest))
+	}
+	sort.Strings(pinned)
+	return pinned
+}
+
+// ResolvedDependencies returns the local and remote dependencies resolved by
+// Build, sorted by name.
+func (dm *DependencyManager) ResolvedDependencies() []ResolvedDependency {
+	dm.mu.Lock()
+	defer dm.mu.Unlock()
+
+	var resolved []ResolvedDependency
+	for _, dep := range dm.resolved {
+		resolved = append(resolved, dep)
+	}
+	sort.Slice(resolved, func(i, j int) bool {
+		return resolved[i].Name < resolved[j].Name
+	})
+	return resolved
+}
+
+// dependencyName returns the alias of the given dependency if set, or its
+// name. Dependencies are identified by this name in the chart and in the
+// resolved and pinned dependencies.
+func dependencyName(dep *helmchart.Dependency) string {
+	if dep.Alias != "" {
+		return dep.Alias
+	}
+	return dep.Name
+}
+
+// recordResolved records the given dependency as resolved.
+func (dm *DependencyManager) recordResolved(dep ResolvedDependency) {
+	dm.mu.Lock()
+	defer dm.mu.Unlock()
+
+	if dm.resolved == nil {
+		dm.resolved = map[string]ResolvedDependency{}
+	}
+	dm.resolved[dep.Name] = dep
+}
+
 // Build compiles a set of missing dependencies from chart.Chart, and attempts to
 // resolve and build them using the information from Reference.
 // It returns the number of resolved local and remote dependencies, or an error.
@@ -170,7 +286,7 @@ func (dm *DependencyManager) build(ctx context.Context, ref Reference, c *helmch
 					}
 					return
 				}
-				if err = dm.addRemoteDependency(c, dep); err != nil {
+				if err = dm.addRemoteDependency(groupCtx, c, dep); err != nil {
 					err = fmt.Errorf("failed to add remote dependency '%s': %w", name, err)
 				}
 				return
@@ -218,20 +334,37 @@ func (dm *DependencyManager) addLocalDependency(ref LocalReference, c *chartWith
 		return err
 	}
 
+	name := dependencyName(dep)
 	if dep.Alias != "" {
-		ch.Metadata.Name = dep.Alias
+		ch.Metadata.Name = name
 	}
 
 	c.mu.Lock()
 	c.AddDependency(ch)
 	c.mu.Unlock()
+
+	dm.recordResolved(ResolvedDependency{
+		Name:       name,
+		Version:    ch.Metadata.Version,
+		Repository: dep.Repository,
+	})
 	return nil
 }
 
 // addRemoteDependency attempts to resolve and add the given remote chart.Dependency
 // to the chart. It locks the chartWithLock before the downloaded dependency is
 // added to the chart.
-func (dm *DependencyManager) addRemoteDependency(chart *chartWithLock, dep *helmchart.Dependency) error {
+// A dependency of an OCI repository can be pinned to a digest by declaring its
+// version as "<version>@<digest>", in which case the chart is downloaded by
+// digest and its version is checked against the (optional) version constraint.
+// If verification is enabled, the signature of the resolved chart version is
+// verified before it is downloaded.
+func (dm *DependencyManager) addRemoteDependency(ctx context.Context, chart *chartWithLock, dep *helmchart.Dependency) error {
+	constraint, digest := repository.SplitVersionDigest(dep.Version)
+	if digest != "" && !helmreg.IsOCI(dep.Repository) {
+		return fmt.Errorf("digest '%s' is only supported for OCI repositories, got repository '%s'", digest, dep.Repository)
+	}
+
 	repo, err := dm.resolveRepository(dep.Repository)
 	if err != nil {
 		return err
@@ -241,8 +374,16 @@ func (dm *DependencyManager) addRemoteDependency(chart *chartWithLock, dep *helm
 	if err != nil {
 		return fmt.Errorf("failed to get chart '%s' version '%s' from '%s': %w", dep.Name, dep.Version, dep.Repository, err)
 	}
+	if dm.verify {
+		if err := repo.VerifyChart(ctx, ver); err != nil {
+			return &DependencyVerificationError{Name: dependencyName(dep), Repository: dep.Repository, Err: err}
+		}
+	}
 	res, err := repo.DownloadChart(ver)
 	if err != nil {
+		if digest != "" {
+			return fmt.Errorf("chart download of digest '%s' failed: %w", digest, err)
+		}
 		return fmt.Errorf("chart download of version '%s' failed: %w", ver.Version, err)
 	}
 	ch, err := secureloader.LoadArchive(res)
@@ -250,13 +391,36 @@ func (dm *DependencyManager) addRemoteDependency(chart *chartWithLock, dep *helm
 		return fmt.Errorf("failed to load downloaded archive of version '%s': %w", ver.Version, err)
 	}
 
+	name := dependencyName(dep)
 	if dep.Alias != "" {
-		ch.Metadata.Name = dep.Alias
+		ch.Metadata.Name = name
+	}
+
+	if digest != "" {
+		if constraint != "" {
+			if err := checkVersionConstraint(constraint, ch.Metadata.Version); err != nil {
+				return fmt.Errorf("chart with digest '%s': %w", digest, err)
+			}
+		}
+
+		dm.mu.Lock()
+		if dm.pinned == nil {
+			dm.pinned = map[string]string{}
+		}
+		dm.pinned[name] = digest
+		dm.mu.Unlock()
 	}
 
 	chart.mu.Lock()
 	chart.AddDependency(ch)
 	chart.mu.Unlock()
+
+	dm.recordResolved(ResolvedDependency{
+		Name:       name,
+		Version:    ch.Metadata.Version,
+		Repository: dep.Repository,
+		Digest:     digest,
+	})
 	return nil
 }
 
@@ -289,6 +453,23 @@ func (dm *DependencyManager) resolveRepository(url string) (repo repository.Down
 	return dm.downloaders[nUrl], nil
 }
 
+// checkVersionConstraint returns an error if the given version does not
+// satisfy the given semver constraint.
+func checkVersionConstraint(constraint, version string) error {
+	c, err := semver.NewConstraint(constraint)
+	if err != nil {
+		return fmt.Errorf("invalid version/constraint format '%s': %w", constraint, err)
+	}
+	v, err := semver.NewVersion(version)
+	if err != nil {
+		return err
+	}
+	if !c.Check(v) {
+		return fmt.Errorf("version '%s' does not satisfy constraint '%s'", version, constraint)
+	}
+	return nil
+}
+
 // secureLocalChartPath returns the secure absolute path of a local dependency.
 // It does not allow the dependency's path to be outside the scope of
 // LocalReference.WorkDir.
@@ -317,10 +498,7 @@ func collectMissing(current []*helmchart.Chart, reqs []*helmchart.Dependency) ma
 	// alias or name
 	var missing map[string]*helmchart.Dependency
 	for _, dep := range reqs {
-		name := dep.Name
-		if dep.Alias != "" {
-			name = dep.Alias
-		}
+		name := dependencyName(dep)
 		// Exclude existing dependencies
 		found := false
 		for _, existing := range current {
@@ -339,6 +517,37 @@ func collectMissing(current []*helmchart.Chart, reqs []*helmchart.Dependency) ma
 	return missing
 }
 
+// checkDependencies returns an error naming the dependencies of the chart
+// which are missing from its charts/ directory. Like Helm, a vendored
+// dependency is matched by its chart name, as aliases refer to the same chart.
+func checkDependencies(c *helmchart.Chart) error {
+	reqs := c.Metadata.Dependencies
+	if lock := c.Lock; lock != nil {
+		reqs = lock.Dependencies
+	}
+
+	present := make(map[string]struct{}, len(c.Dependencies()))
+	for _, dep := range c.Dependencies() {
+		present[dep.Name()] = struct{}{}
+	}
+	missing := make(map[string]struct{})
+	for _, dep := range reqs {
+		if _, ok := present[dep.Name]; !ok {
+			missing[dep.Name] = struct{}{}
+		}
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+
+	names := make([]string, 0, len(missing))
+	for name := range missing {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return fmt.Errorf("dependencies missing from the charts/ directory: %s", strings.Join(names, ", "))
+}
+
 // isLocalDep returns true if the given chart.Dependency contains a local (file) path reference.
 func isLocalDep(dep *helmchart.Dependency) bool {
 	return dep.Repository == "" || strings.HasPrefix(dep.Repository, "file://")
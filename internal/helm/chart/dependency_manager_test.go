@@ -24,6 +24,7 @@ import (
 	"net/url"
 	"os"
 	"path/filepath"
+	"strings"
 	"sync"
 	"testing"
 
@@ -65,10 +66,16 @@ func (m *mockTagsGetter) Logout(_ string, _ ...registry.LogoutOption) error {
 // mockGetter is a simple mocking getter.Getter implementation, returning
 // a byte response to any provided URL.
 type mockGetter struct {
-	Response []byte
+	Response      []byte
+	Err           error
+	LastCalledURL string
 }
 
-func (g *mockGetter) Get(_ string, _ ...helmgetter.Option) (*bytes.Buffer, error) {
+func (g *mockGetter) Get(u string, _ ...helmgetter.Option) (*bytes.Buffer, error) {
+	g.LastCalledURL = u
+	if g.Err != nil {
+		return nil, g.Err
+	}
 	r := g.Response
 	return bytes.NewBuffer(r), nil
 }
@@ -251,6 +258,7 @@ func build(t *testing.T, mockRepo repository.Downloader) {
 
 			g.Expect(err).ToNot(HaveOccurred())
 			g.Expect(got).To(Equal(tt.want))
+			g.Expect(dm.ResolvedDependencies()).To(HaveLen(tt.want))
 			if tt.wantChartFunc != nil {
 				tt.wantChartFunc(g, chart)
 			}
@@ -297,10 +305,11 @@ func TestDependencyManager_build(t *testing.T) {
 
 func TestDependencyManager_addLocalDependency(t *testing.T) {
 	tests := []struct {
-		name     string
-		dep      *helmchart.Dependency
-		wantErr  string
-		wantFunc func(g *WithT, c *helmchart.Chart)
+		name         string
+		dep          *helmchart.Dependency
+		wantErr      string
+		wantFunc     func(g *WithT, c *helmchart.Chart)
+		wantResolved []ResolvedDependency
 	}{
 		{
 			name: "local dependency",
@@ -312,6 +321,25 @@ func TestDependencyManager_addLocalDependency(t *testing.T) {
 			wantFunc: func(g *WithT, c *helmchart.Chart) {
 				g.Expect(c.Dependencies()).To(HaveLen(1))
 			},
+			wantResolved: []ResolvedDependency{
+				{Name: chartName, Version: chartVersion, Repository: "file://../helmchart"},
+			},
+		},
+		{
+			name: "aliased local dependency",
+			dep: &helmchart.Dependency{
+				Name:       chartName,
+				Alias:      "aliased",
+				Version:    chartVersion,
+				Repository: "file://../helmchart",
+			},
+			wantFunc: func(g *WithT, c *helmchart.Chart) {
+				g.Expect(c.Dependencies()).To(HaveLen(1))
+				g.Expect(c.Dependencies()[0].Name()).To(Equal("aliased"))
+			},
+			wantResolved: []ResolvedDependency{
+				{Name: "aliased", Version: chartVersion, Repository: "file://../helmchart"},
+			},
 		},
 		{
 			name: "version not matching constraint",
@@ -372,6 +400,7 @@ func TestDependencyManager_addLocalDependency(t *testing.T) {
 			if tt.wantFunc != nil {
 				tt.wantFunc(g, chart)
 			}
+			g.Expect(dm.ResolvedDependencies()).To(Equal(tt.wantResolved))
 		})
 	}
 }
@@ -552,7 +581,7 @@ func TestDependencyManager_addRemoteDependency(t *testing.T) {
 				downloaders: tt.downloaders,
 			}
 			chart := &helmchart.Chart{}
-			err := dm.addRemoteDependency(&chartWithLock{Chart: chart}, tt.dep)
+			err := dm.addRemoteDependency(context.TODO(), &chartWithLock{Chart: chart}, tt.dep)
 			if tt.wantErr != "" {
 				g.Expect(err).To(HaveOccurred())
 				g.Expect(err.Error()).To(ContainSubstring(tt.wantErr))
@@ -566,6 +595,221 @@ func TestDependencyManager_addRemoteDependency(t *testing.T) {
 	}
 }
 
+// mockVerifyDownloader is a repository.Downloader which records the verified
+// chart versions, and returns the configured verification error.
+type mockVerifyDownloader struct {
+	repository.Downloader
+	verifyErr error
+	verified  []string
+}
+
+func (d *mockVerifyDownloader) VerifyChart(_ context.Context, cv *repo.ChartVersion) error {
+	d.verified = append(d.verified, cv.Name)
+	return d.verifyErr
+}
+
+func TestDependencyManager_addRemoteDependency_verify(t *testing.T) {
+	g := NewWithT(t)
+
+	chartB, err := os.ReadFile("../testdata/charts/helmchart-0.1.0.tgz")
+	g.Expect(err).ToNot(HaveOccurred())
+
+	tests := []struct {
+		name      string
+		verify    bool
+		alias     string
+		verifyErr error
+		wantErr   string
+	}{
+		{
+			name:      "verification disabled",
+			verifyErr: errors.New("no matching signatures were found"),
+		},
+		{
+			name:   "verified dependency",
+			verify: true,
+		},
+		{
+			name:      "unverified dependency",
+			verify:    true,
+			verifyErr: errors.New("no matching signatures were found"),
+			wantErr:   fmt.Sprintf("failed to verify dependency '%s' from 'https://example.com': no matching signatures were found", chartName),
+		},
+		{
+			name:      "unverified aliased dependency",
+			verify:    true,
+			alias:     "aliased",
+			verifyErr: errors.New("no keyring available"),
+			wantErr:   "failed to verify dependency 'aliased' from 'https://example.com': no keyring available",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			downloader := &mockVerifyDownloader{
+				Downloader: &repository.ChartRepository{
+					Client: &mockGetter{
+						Response: chartB,
+					},
+					Index: &repo.IndexFile{
+						Entries: map[string]repo.ChartVersions{
+							chartName: {
+								&repo.ChartVersion{
+									Metadata: &helmchart.Metadata{
+										Name:    chartName,
+										Version: chartVersion,
+									},
+									URLs: []string{"https://example.com/foo.tgz"},
+								},
+							},
+						},
+					},
+					RWMutex: &sync.RWMutex{},
+				},
+				verifyErr: tt.verifyErr,
+			}
+			dm := NewDependencyManager(
+				WithRepositories{"https://example.com/": downloader},
+				WithDependencyVerification(tt.verify),
+			)
+
+			chart := &helmchart.Chart{}
+			err := dm.addRemoteDependency(context.TODO(), &chartWithLock{Chart: chart}, &helmchart.Dependency{
+				Name:       chartName,
+				Alias:      tt.alias,
+				Repository: "https://example.com",
+			})
+			if tt.wantErr != "" {
+				g.Expect(err).To(HaveOccurred())
+				g.Expect(err.Error()).To(Equal(tt.wantErr))
+				var verifyErr *DependencyVerificationError
+				g.Expect(errors.As(err, &verifyErr)).To(BeTrue())
+				g.Expect(chart.Dependencies()).To(BeEmpty())
+				return
+			}
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(chart.Dependencies()).To(HaveLen(1))
+			if tt.verify {
+				g.Expect(downloader.verified).To(Equal([]string{chartName}))
+			} else {
+				g.Expect(downloader.verified).To(BeEmpty())
+			}
+		})
+	}
+}
+
+func TestDependencyManager_addRemoteOCIDependency_digest(t *testing.T) {
+	g := NewWithT(t)
+
+	chartB, err := os.ReadFile("../testdata/charts/helmchart-0.1.0.tgz")
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(chartB).ToNot(BeEmpty())
+
+	digest := "sha256:" + strings.Repeat("a", 64)
+
+	tests := []struct {
+		name         string
+		getter       *mockGetter
+		dep          *helmchart.Dependency
+		wantURL      string
+		wantPinned   []string
+		wantResolved []ResolvedDependency
+		wantErr      string
+	}{
+		{
+			name:   "adds dependency pinned to digest",
+			getter: &mockGetter{Response: chartB},
+			dep: &helmchart.Dependency{
+				Name:       chartName,
+				Version:    "@" + digest,
+				Repository: "oci://example.com",
+			},
+			wantURL:    fmt.Sprintf("example.com/%s@%s", chartName, digest),
+			wantPinned: []string{fmt.Sprintf("%s@%s", chartName, digest)},
+			wantResolved: []ResolvedDependency{
+				{Name: chartName, Version: chartVersion, Repository: "oci://example.com", Digest: digest},
+			},
+		},
+		{
+			name:   "adds aliased dependency pinned to digest and version",
+			getter: &mockGetter{Response: chartB},
+			dep: &helmchart.Dependency{
+				Name:       chartName,
+				Alias:      "aliased",
+				Version:    chartVersion + "@" + digest,
+				Repository: "oci://example.com",
+			},
+			wantURL:    fmt.Sprintf("example.com/%s@%s", chartName, digest),
+			wantPinned: []string{"aliased@" + digest},
+			wantResolved: []ResolvedDependency{
+				{Name: "aliased", Version: chartVersion, Repository: "oci://example.com", Digest: digest},
+			},
+		},
+		{
+			name:   "digest does not match version constraint",
+			getter: &mockGetter{Response: chartB},
+			dep: &helmchart.Dependency{
+				Name:       chartName,
+				Version:    ">=1.0.0@" + digest,
+				Repository: "oci://example.com",
+			},
+			wantErr: "version '0.1.0' does not satisfy constraint '>=1.0.0'",
+		},
+		{
+			name:   "digest not found",
+			getter: &mockGetter{Err: errors.New("not found")},
+			dep: &helmchart.Dependency{
+				Name:       chartName,
+				Version:    "@" + digest,
+				Repository: "oci://example.com",
+			},
+			wantErr: fmt.Sprintf("chart download of digest '%s' failed: not found", digest),
+		},
+		{
+			name:   "digest for non OCI repository",
+			getter: &mockGetter{Response: chartB},
+			dep: &helmchart.Dependency{
+				Name:       chartName,
+				Version:    "@" + digest,
+				Repository: "https://example.com",
+			},
+			wantErr: "is only supported for OCI repositories",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			dm := &DependencyManager{
+				downloaders: map[string]repository.Downloader{
+					"oci://example.com": &repository.OCIChartRepository{
+						URL: url.URL{
+							Scheme: "oci",
+							Host:   "example.com",
+						},
+						Client: tt.getter,
+					},
+				},
+			}
+			chart := &helmchart.Chart{}
+			err := dm.addRemoteDependency(context.TODO(), &chartWithLock{Chart: chart}, tt.dep)
+			if tt.wantErr != "" {
+				g.Expect(err).To(HaveOccurred())
+				g.Expect(err.Error()).To(ContainSubstring(tt.wantErr))
+				g.Expect(dm.PinnedDependencies()).To(BeEmpty())
+				g.Expect(dm.ResolvedDependencies()).To(BeEmpty())
+				return
+			}
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(chart.Dependencies()).To(HaveLen(1))
+			g.Expect(tt.getter.LastCalledURL).To(Equal(tt.wantURL))
+			g.Expect(dm.PinnedDependencies()).To(Equal(tt.wantPinned))
+			g.Expect(dm.ResolvedDependencies()).To(Equal(tt.wantResolved))
+		})
+	}
+}
+
 func TestDependencyManager_addRemoteOCIDependency(t *testing.T) {
 	g := NewWithT(t)
 
@@ -682,7 +926,7 @@ func TestDependencyManager_addRemoteOCIDependency(t *testing.T) {
 				downloaders: tt.downloaders,
 			}
 			chart := &helmchart.Chart{}
-			err := dm.addRemoteDependency(&chartWithLock{Chart: chart}, tt.dep)
+			err := dm.addRemoteDependency(context.TODO(), &chartWithLock{Chart: chart}, tt.dep)
 			if tt.wantErr != "" {
 				g.Expect(err).To(HaveOccurred())
 				g.Expect(err.Error()).To(ContainSubstring(tt.wantErr))
@@ -70,7 +70,7 @@ func (e *BuildError) Unwrap() error {
 
 func IsPersistentBuildErrorReason(err error) bool {
 	switch err {
-	case ErrChartReference, ErrChartMetadataPatch, ErrValuesFilesMerge:
+	case ErrChartReference, ErrChartMetadataPatch, ErrValuesFilesMerge, ErrMemoryBudget:
 		return true
 	default:
 		return false
@@ -85,5 +85,6 @@ var (
 	ErrDependencyBuild    = BuildErrorReason{Reason: "DependencyBuildError", Summary: "dependency build error"}
 	ErrChartPackage       = BuildErrorReason{Reason: "ChartPackageError", Summary: "chart package error"}
 	ErrChartVerification  = BuildErrorReason{Reason: "ChartVerificationError", Summary: "chart verification error"}
+	ErrMemoryBudget       = BuildErrorReason{Reason: "MemoryBudgetExceeded", Summary: "build memory budget exceeded"}
 	ErrUnknown            = BuildErrorReason{Reason: "Unknown", Summary: "unknown build error"}
 )
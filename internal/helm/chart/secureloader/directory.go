@@ -49,9 +49,10 @@ var (
 // SecureDirLoader securely loads a chart from a directory while resolving
 // symlinks without including files outside root.
 type SecureDirLoader struct {
-	root    string
-	path    string
-	maxSize int64
+	root         string
+	path         string
+	maxSize      int64
+	maxTotalSize int64
 }
 
 // NewSecureDirLoader returns a new SecureDirLoader, configured to the scope of the
@@ -71,13 +72,21 @@ func NewSecureDirLoader(root string, path string, maxSize int64) SecureDirLoader
 
 // Load loads and returns the chart.Chart, or an error.
 func (l SecureDirLoader) Load() (*chart.Chart, error) {
-	return SecureLoadDir(l.root, l.path, l.maxSize)
+	return SecureLoadDirWithLimits(l.root, l.path, l.maxSize, l.maxTotalSize)
 }
 
 // SecureLoadDir securely loads a chart from the path relative to root, without
 // traversing outside root. When maxSize >= 0, files are not allowed to exceed
 // this size, or an error is returned.
 func SecureLoadDir(root, path string, maxSize int64) (*chart.Chart, error) {
+	return SecureLoadDirWithLimits(root, path, maxSize, 0)
+}
+
+// SecureLoadDirWithLimits is like SecureLoadDir, but in addition stops
+// loading files when their total size would exceed maxTotalSize, and returns
+// an error wrapping ErrTotalSizeExceeded. A maxTotalSize <= 0 disables this
+// limit.
+func SecureLoadDirWithLimits(root, path string, maxSize, maxTotalSize int64) (*chart.Chart, error) {
 	root, err := filepath.Abs(root)
 	if err != nil {
 		return nil, err
@@ -106,6 +115,7 @@ func SecureLoadDir(root, path string, maxSize int64) (*chart.Chart, error) {
 
 	// Lets go for a walk...
 	fileWalker := newSecureFileWalker(root, absChartName, maxSize, rules)
+	fileWalker.maxTotalSize = maxTotalSize
 	if err = sympath.Walk(fileWalker.absChartPath, fileWalker.walk); err != nil {
 		return nil, fmt.Errorf("failed to load files from %s: %w", strings.TrimPrefix(fileWalker.absChartPath, fileWalker.root), err)
 	}
@@ -150,6 +160,8 @@ type secureFileWalker struct {
 	root         string
 	absChartPath string
 	maxSize      int64
+	maxTotalSize int64
+	totalSize    int64
 	rules        *ignore.Rules
 	files        []*loader.BufferedFile
 }
@@ -216,6 +228,10 @@ func (w *secureFileWalker) walk(name, absName string, fi os.FileInfo, err error)
 	if fileSize := fi.Size(); w.maxSize > 0 && fileSize > w.maxSize {
 		return fmt.Errorf("cannot load file %s as file size (%d) exceeds limit (%d)", n, fileSize, w.maxSize)
 	}
+	if w.maxTotalSize > 0 && w.totalSize+fi.Size() > w.maxTotalSize {
+		return fmt.Errorf("cannot load file %s: %w (%d)", n, ErrTotalSizeExceeded, w.maxTotalSize)
+	}
+	w.totalSize += fi.Size()
 
 	data, err := os.ReadFile(absName)
 	if err != nil {
@@ -18,7 +18,11 @@ limitations under the License.
 package secureloader
 
 import (
+	"archive/tar"
+	"compress/gzip"
+	"fmt"
 	"io"
+	"os"
 
 	"helm.sh/helm/v3/pkg/chart"
 	"helm.sh/helm/v3/pkg/chart/loader"
@@ -45,3 +49,38 @@ func LoadArchiveFiles(in io.Reader) ([]*loader.BufferedFile, error) {
 func LoadArchive(in io.Reader) (*chart.Chart, error) {
 	return loader.LoadArchive(in)
 }
+
+// checkArchiveSize returns an error wrapping ErrTotalSizeExceeded if the total
+// size of the files in the gzip compressed tarball at the given path exceeds
+// maxTotalSize. The tarball is streamed, without keeping its files in memory.
+func checkArchiveSize(name string, maxTotalSize int64) error {
+	f, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		return fmt.Errorf("failed to read chart archive: %w", err)
+	}
+	defer gz.Close()
+
+	var totalSize int64
+	tr := tar.NewReader(gz)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read chart archive: %w", err)
+		}
+		if hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+		if totalSize += hdr.Size; totalSize > maxTotalSize {
+			return fmt.Errorf("cannot load file %s: %w (%d)", hdr.Name, ErrTotalSizeExceeded, maxTotalSize)
+		}
+	}
+}
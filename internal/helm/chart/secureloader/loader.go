@@ -31,6 +31,10 @@ import (
 	"github.com/fluxcd/source-controller/internal/helm"
 )
 
+// ErrTotalSizeExceeded is returned when the total size of the files of a
+// chart exceeds the limit given to LoadWithLimit.
+var ErrTotalSizeExceeded = errors.New("total size of chart files exceeds limit")
+
 // Loader returns a new loader.ChartLoader appropriate for the given chart
 // name. That being, SecureDirLoader when name is a directory, and
 // FileLoader when it's a file.
@@ -83,3 +87,27 @@ func Load(root, name string) (*chart.Chart, error) {
 	}
 	return l.Load()
 }
+
+// LoadWithLimit is like Load, but returns an error wrapping
+// ErrTotalSizeExceeded instead of loading a chart of which the total size of
+// the (uncompressed) files exceeds maxTotalSize. The size of a packaged chart
+// is determined by streaming the headers of its archive, before loading it.
+// A maxTotalSize <= 0 disables the limit.
+func LoadWithLimit(root, name string, maxTotalSize int64) (*chart.Chart, error) {
+	l, err := Loader(root, name)
+	if err != nil {
+		return nil, err
+	}
+	if maxTotalSize > 0 {
+		switch typedLoader := l.(type) {
+		case SecureDirLoader:
+			typedLoader.maxTotalSize = maxTotalSize
+			l = typedLoader
+		case FileLoader:
+			if err = checkArchiveSize(string(typedLoader), maxTotalSize); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return l.Load()
+}
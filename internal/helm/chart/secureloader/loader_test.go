@@ -17,6 +17,8 @@ limitations under the License.
 package secureloader
 
 import (
+	"errors"
+	"fmt"
 	"io/fs"
 	"os"
 	"path/filepath"
@@ -25,6 +27,7 @@ import (
 	. "github.com/onsi/gomega"
 	"helm.sh/helm/v3/pkg/chart"
 	"helm.sh/helm/v3/pkg/chart/loader"
+	"helm.sh/helm/v3/pkg/chartutil"
 	"sigs.k8s.io/yaml"
 
 	"github.com/fluxcd/source-controller/internal/helm"
@@ -90,3 +93,45 @@ func TestLoad(t *testing.T) {
 	g.Expect(got).ToNot(BeNil())
 	g.Expect(got.Name()).To(Equal(metadata.Name))
 }
+
+func TestLoadWithLimit(t *testing.T) {
+	g := NewWithT(t)
+
+	tmpDir := t.TempDir()
+	chartDir := filepath.Join(tmpDir, "test")
+	g.Expect(os.MkdirAll(filepath.Join(chartDir, "templates"), 0o700)).To(Succeed())
+	b, err := yaml.Marshal(&chart.Metadata{Name: "test", APIVersion: "v2", Version: "1.0", Type: "application"})
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(os.WriteFile(filepath.Join(chartDir, "Chart.yaml"), b, 0o640)).To(Succeed())
+	for i := 0; i < 10; i++ {
+		data := make([]byte, 1024)
+		for j := range data {
+			data[j] = '#'
+		}
+		g.Expect(os.WriteFile(filepath.Join(chartDir, "templates", fmt.Sprintf("%d.yaml", i)), data, 0o640)).To(Succeed())
+	}
+
+	loaded, err := Load(tmpDir, "test")
+	g.Expect(err).ToNot(HaveOccurred())
+	archive, err := chartutil.Save(loaded, tmpDir)
+	g.Expect(err).ToNot(HaveOccurred())
+
+	for _, name := range []string{"test", filepath.Base(archive)} {
+		t.Run(name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			got, err := LoadWithLimit(tmpDir, name, 4096)
+			g.Expect(err).To(HaveOccurred())
+			g.Expect(errors.Is(err, ErrTotalSizeExceeded)).To(BeTrue())
+			g.Expect(got).To(BeNil())
+
+			got, err = LoadWithLimit(tmpDir, name, 1<<20)
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(got.Templates).To(HaveLen(10))
+
+			got, err = LoadWithLimit(tmpDir, name, 0)
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(got.Templates).To(HaveLen(10))
+		})
+	}
+}
@@ -0,0 +1,130 @@
+/*
+Copyright 2022 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package chart
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"sort"
+
+	helmchart "helm.sh/helm/v3/pkg/chart"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apiserver/pkg/endpoints/deprecation"
+	kscheme "k8s.io/client-go/kubernetes/scheme"
+
+	"github.com/fluxcd/source-controller/internal/helm/chart/secureloader"
+)
+
+var (
+	// documentSeparatorRegexp matches the separator of the YAML documents in
+	// a template.
+	documentSeparatorRegexp = regexp.MustCompile(`(?m)^---\s*$`)
+	// apiVersionRegexp matches the literal apiVersion of a resource in a
+	// template, which may be indented within a template action.
+	apiVersionRegexp = regexp.MustCompile(`(?m)^\s*apiVersion:\s*["']?([\w.-]+/[\w.-]+)["']?\s*$`)
+	// kindRegexp matches the literal kind of a resource in a template.
+	kindRegexp = regexp.MustCompile(`(?m)^kind:\s*["']?(\w+)["']?\s*$`)
+)
+
+// chartWarnings returns the warnings about the given chart and its
+// dependencies which do not fail the build, but may cause it to break on
+// newer clusters or future versions of Helm. For example, the use of
+// deprecated Kubernetes API versions in its templates. The warnings are
+// sorted and deduplicated.
+func chartWarnings(chart *helmchart.Chart) []string {
+	seen := make(map[string]struct{})
+	collectChartWarnings(chart, "", seen)
+
+	warnings := make([]string, 0, len(seen))
+	for w := range seen {
+		warnings = append(warnings, w)
+	}
+	sort.Strings(warnings)
+	return warnings
+}
+
+// collectChartWarnings adds the warnings about the given chart and its
+// dependencies to the set, with the names of the templates prefixed with the
+// path of the chart.
+func collectChartWarnings(chart *helmchart.Chart, prefix string, seen map[string]struct{}) {
+	if chart == nil || chart.Metadata == nil {
+		return
+	}
+	name := chart.Metadata.Name
+	if prefix != "" {
+		name = path.Join(prefix, "charts", chart.Metadata.Name)
+	}
+
+	if chart.Metadata.Deprecated {
+		seen[fmt.Sprintf("chart '%s' is deprecated", name)] = struct{}{}
+	}
+	if chart.Metadata.APIVersion == helmchart.APIVersionV1 {
+		seen[fmt.Sprintf("chart '%s' uses apiVersion '%s', consider migrating to '%s'",
+			name, helmchart.APIVersionV1, helmchart.APIVersionV2)] = struct{}{}
+	}
+
+	for _, t := range chart.Templates {
+		if t == nil {
+			continue
+		}
+		for _, msg := range deprecatedAPIWarnings(t.Data) {
+			seen[fmt.Sprintf("template '%s': %s", path.Join(name, t.Name), msg)] = struct{}{}
+		}
+	}
+
+	for _, dep := range chart.Dependencies() {
+		collectChartWarnings(dep, name, seen)
+	}
+}
+
+// deprecatedAPIWarnings returns the deprecation warnings of the Kubernetes
+// API server for the literal apiVersion and kind of the resources in the
+// given template. As the template is not rendered, all the apiVersions of a
+// document are checked against its kind, e.g. to cover the apiVersions of
+// conditional blocks. Unknown kinds are ignored.
+func deprecatedAPIWarnings(data []byte) []string {
+	var warnings []string
+	for _, doc := range documentSeparatorRegexp.Split(string(data), -1) {
+		kinds := kindRegexp.FindAllStringSubmatch(doc, -1)
+		for _, v := range apiVersionRegexp.FindAllStringSubmatch(doc, -1) {
+			for _, k := range kinds {
+				gvk := schema.FromAPIVersionAndKind(v[1], k[1])
+				obj, err := kscheme.Scheme.New(gvk)
+				if err != nil {
+					continue
+				}
+				obj.GetObjectKind().SetGroupVersionKind(gvk)
+				if msg := deprecation.WarningMessage(obj); msg != "" {
+					warnings = append(warnings, msg)
+				}
+			}
+		}
+	}
+	return warnings
+}
+
+// archiveWarnings returns the chartWarnings of the chart archive at the given
+// path. As the warnings are informational, a chart which fails to load has
+// none.
+func archiveWarnings(p string) []string {
+	chart, err := secureloader.LoadFile(p)
+	if err != nil {
+		return nil
+	}
+	return chartWarnings(chart)
+}
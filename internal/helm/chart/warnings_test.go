@@ -0,0 +1,115 @@
+/*
+Copyright 2022 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package chart
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/types"
+	helmchart "helm.sh/helm/v3/pkg/chart"
+)
+
+func Test_chartWarnings(t *testing.T) {
+	template := func(name, data string) *helmchart.File {
+		return &helmchart.File{Name: name, Data: []byte(data)}
+	}
+
+	tests := []struct {
+		name  string
+		chart func() *helmchart.Chart
+		want  []types.GomegaMatcher
+	}{
+		{
+			name: "no warnings",
+			chart: func() *helmchart.Chart {
+				return &helmchart.Chart{
+					Metadata: &helmchart.Metadata{Name: "chart", APIVersion: helmchart.APIVersionV2},
+					Templates: []*helmchart.File{
+						template("templates/deployment.yaml", "apiVersion: apps/v1\nkind: Deployment\n"),
+					},
+				}
+			},
+			want: []types.GomegaMatcher{},
+		},
+		{
+			name: "deprecated chart with apiVersion v1",
+			chart: func() *helmchart.Chart {
+				return &helmchart.Chart{
+					Metadata: &helmchart.Metadata{Name: "chart", APIVersion: helmchart.APIVersionV1, Deprecated: true},
+				}
+			},
+			want: []string{
+				"chart 'chart' is deprecated",
+				"chart 'chart' uses apiVersion 'v1', consider migrating to 'v2'",
+			},
+		},
+		{
+			name: "deprecated API versions in templates",
+			chart: func() *helmchart.Chart {
+				return &helmchart.Chart{
+					Metadata: &helmchart.Metadata{Name: "chart", APIVersion: helmchart.APIVersionV2},
+					Templates: []*helmchart.File{
+						template("templates/cronjob.yaml", "apiVersion: \"batch/v1beta1\"\nkind: CronJob\n"),
+						template("templates/ingress.yaml", "{{- if .Values.legacy }}\n  apiVersion: extensions/v1beta1\n{{- else }}\napiVersion: networking.k8s.io/v1\n{{- end }}\nkind: Ingress\n"),
+						template("templates/pdb.yaml", "apiVersion: policy/v1beta1\nkind: PodDisruptionBudget\n---\napiVersion: policy/v1beta1\nkind: PodDisruptionBudget\n"),
+						template("templates/crd.yaml", "apiVersion: example.com/v1beta1\nkind: Example\n"),
+					},
+				}
+			},
+			want: []types.GomegaMatcher{
+				HavePrefix("template 'chart/templates/cronjob.yaml': batch/v1beta1 CronJob is deprecated in v1.21+, unavailable in v1.25+"),
+				HavePrefix("template 'chart/templates/ingress.yaml': extensions/v1beta1 Ingress is deprecated in v1.14+, unavailable in v1.22+"),
+				HavePrefix("template 'chart/templates/pdb.yaml': policy/v1beta1 PodDisruptionBudget is deprecated in v1.21+, unavailable in v1.25+"),
+			},
+		},
+		{
+			name: "warnings of dependencies",
+			chart: func() *helmchart.Chart {
+				c := &helmchart.Chart{
+					Metadata: &helmchart.Metadata{Name: "chart", APIVersion: helmchart.APIVersionV2},
+				}
+				c.AddDependency(&helmchart.Chart{
+					Metadata: &helmchart.Metadata{Name: "dep", APIVersion: helmchart.APIVersionV2, Deprecated: true},
+					Templates: []*helmchart.File{
+						template("templates/hpa.yaml", "apiVersion: autoscaling/v2beta2\nkind: HorizontalPodAutoscaler\n"),
+					},
+				})
+				return c
+			},
+			want: []types.GomegaMatcher{
+				Equal("chart 'chart/charts/dep' is deprecated"),
+				HavePrefix("template 'chart/charts/dep/templates/hpa.yaml': autoscaling/v2beta2 HorizontalPodAutoscaler is deprecated in v1.23+, unavailable in v1.26+"),
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			g.Expect(chartWarnings(tt.chart())).To(ConsistOf(tt.want))
+		})
+	}
+}
+
+func Test_archiveWarnings(t *testing.T) {
+	g := NewWithT(t)
+
+	g.Expect(archiveWarnings("../testdata/charts/helmchart-0.1.0.tgz")).To(ContainElement(
+		HavePrefix("template 'helmchart/templates/ingress.yaml': extensions/v1beta1 Ingress is deprecated")))
+	g.Expect(archiveWarnings("../testdata/charts/non-existing.tgz")).To(BeNil())
+}
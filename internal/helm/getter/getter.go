@@ -26,6 +26,14 @@ import (
 	corev1 "k8s.io/api/core/v1"
 )
 
+// CACertKey is the Secret key for a PEM-encoded CA certificate, as an
+// alternative to caFile.
+const CACertKey = "ca.crt"
+
+// BearerTokenKey is the Secret key for a token which is sent as bearer token
+// in the Authorization header of the requests to a Helm repository.
+const BearerTokenKey = "bearerToken"
+
 // ClientOptionsFromSecret constructs a getter.Option slice for the given secret.
 // It returns the slice, or an error.
 func ClientOptionsFromSecret(secret corev1.Secret) ([]getter.Option, error) {
@@ -56,19 +64,42 @@ func BasicAuthFromSecret(secret corev1.Secret) (getter.Option, error) {
 	return getter.WithBasicAuth(username, password), nil
 }
 
+// BearerTokenFromSecret returns the bearer token of the given v1.Secret, or an
+// empty string if the Secret has none.
+//
+// As both are sent in the Authorization header, it returns an error if the
+// Secret also contains a username or password.
+func BearerTokenFromSecret(secret corev1.Secret) (string, error) {
+	token := string(secret.Data[BearerTokenKey])
+	if token != "" && (len(secret.Data["username"]) > 0 || len(secret.Data["password"]) > 0) {
+		return "", fmt.Errorf("invalid '%s' secret data: field '%s' can not be combined with 'username' and 'password'",
+			secret.Name, BearerTokenKey)
+	}
+	return token, nil
+}
+
 // TLSClientConfigFromSecret attempts to construct a TLS client config
 // for the given v1.Secret. It returns the TLS client config or an error.
 //
+// The certificates are read from the certFile, keyFile and caFile fields.
+// If none of these are defined, the tls.crt, tls.key and ca.crt fields as
+// used by Secrets of type kubernetes.io/tls are read instead.
+//
 // Secrets with no certFile, keyFile, AND caFile are ignored, if only a
 // certBytes OR keyBytes is defined it returns an error.
 func TLSClientConfigFromSecret(secret corev1.Secret, repositoryUrl string) (*tls.Config, error) {
-	certBytes, keyBytes, caBytes := secret.Data["certFile"], secret.Data["keyFile"], secret.Data["caFile"]
+	certField, keyField, caField := "certFile", "keyFile", "caFile"
+	if len(secret.Data[certField])+len(secret.Data[keyField])+len(secret.Data[caField]) == 0 {
+		certField, keyField, caField = corev1.TLSCertKey, corev1.TLSPrivateKeyKey, CACertKey
+	}
+
+	certBytes, keyBytes, caBytes := secret.Data[certField], secret.Data[keyField], secret.Data[caField]
 	switch {
 	case len(certBytes)+len(keyBytes)+len(caBytes) == 0:
 		return nil, nil
 	case (len(certBytes) > 0 && len(keyBytes) == 0) || (len(keyBytes) > 0 && len(certBytes) == 0):
-		return nil, fmt.Errorf("invalid '%s' secret data: fields 'certFile' and 'keyFile' require each other's presence",
-			secret.Name)
+		return nil, fmt.Errorf("invalid '%s' secret data: fields '%s' and '%s' require each other's presence",
+			secret.Name, certField, keyField)
 	}
 
 	tlsConf := &tls.Config{}
@@ -86,7 +117,7 @@ func TLSClientConfigFromSecret(secret corev1.Secret, repositoryUrl string) (*tls
 			return nil, fmt.Errorf("cannot retrieve system certificate pool: %w", err)
 		}
 		if !cp.AppendCertsFromPEM(caBytes) {
-			return nil, fmt.Errorf("cannot append certificate into certificate pool: invalid caFile")
+			return nil, fmt.Errorf("cannot append certificate into certificate pool: invalid %s", caField)
 		}
 
 		tlsConf.RootCAs = cp
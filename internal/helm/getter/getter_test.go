@@ -65,6 +65,35 @@ func TestClientOptionsFromSecret(t *testing.T) {
 	}
 }
 
+func TestBearerTokenFromSecret(t *testing.T) {
+	tests := []struct {
+		name      string
+		data      map[string][]byte
+		wantToken string
+		wantErr   bool
+	}{
+		{"bearer token", map[string][]byte{BearerTokenKey: []byte("token")}, "token", false},
+		{"basic auth", basicAuthSecretFixture.Data, "", false},
+		{"bearer token and basic auth", map[string][]byte{
+			BearerTokenKey: []byte("token"),
+			"username":     []byte("user"),
+		}, "", true},
+		{"empty", nil, "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := BearerTokenFromSecret(corev1.Secret{Data: tt.data})
+			if (err != nil) != tt.wantErr {
+				t.Errorf("BearerTokenFromSecret() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.wantToken {
+				t.Errorf("BearerTokenFromSecret() = %q, want %q", got, tt.wantToken)
+			}
+		})
+	}
+}
+
 func TestBasicAuthFromSecret(t *testing.T) {
 	tests := []struct {
 		name    string
@@ -112,6 +141,11 @@ func TestTLSClientConfigFromSecret(t *testing.T) {
 		{"without keyFile", tlsSecretFixture, func(s *corev1.Secret) { delete(s.Data, "keyFile") }, true, true},
 		{"without caFile", tlsSecretFixture, func(s *corev1.Secret) { delete(s.Data, "caFile") }, false, false},
 		{"empty", corev1.Secret{}, nil, false, true},
+		{"tls.crt, tls.key and ca.crt", tlsSecretFixture, toTLSKeys, false, false},
+		{"without tls.crt", tlsSecretFixture, func(s *corev1.Secret) { toTLSKeys(s); delete(s.Data, "tls.crt") }, true, true},
+		{"without tls.key", tlsSecretFixture, func(s *corev1.Secret) { toTLSKeys(s); delete(s.Data, "tls.key") }, true, true},
+		{"without ca.crt", tlsSecretFixture, func(s *corev1.Secret) { toTLSKeys(s); delete(s.Data, "ca.crt") }, false, false},
+		{"invalid ca.crt", tlsSecretFixture, func(s *corev1.Secret) { toTLSKeys(s); s.Data["ca.crt"] = []byte("invalid") }, true, true},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -189,3 +223,12 @@ func validTlsSecret(t *testing.T) corev1.Secret {
 		},
 	}
 }
+
+// toTLSKeys moves the certFile, keyFile and caFile data of the given secret
+// to the tls.crt, tls.key and ca.crt keys.
+func toTLSKeys(s *corev1.Secret) {
+	for from, to := range map[string]string{"certFile": "tls.crt", "keyFile": "tls.key", "caFile": "ca.crt"} {
+		s.Data[to] = s.Data[from]
+		delete(s.Data, from)
+	}
+}
@@ -0,0 +1,231 @@
+/*
+Copyright 2022 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package registry
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/google/go-containerregistry/pkg/v1/remote/transport"
+	"helm.sh/helm/v3/pkg/getter"
+	"helm.sh/helm/v3/pkg/registry"
+)
+
+// RemoteClient is a client for Helm charts in OCI registries which connects
+// to the registry with a custom TLS client configuration, e.g. to trust a
+// private CA or to present a client certificate. The Helm registry client
+// does not allow configuring its transport, RemoteClient therefore talks to
+// the registry with go-containerregistry instead.
+//
+// It implements both the RegistryClient of an OCIChartRepository, to list
+// and login, and the getter.Getter to download the charts. The client is
+// meant to be used for a single reconciliation.
+type RemoteClient struct {
+	ctx       context.Context
+	transport http.RoundTripper
+	auth      authn.Authenticator
+	keychain  authn.Keychain
+}
+
+// NewRemoteClient returns a RemoteClient which connects to the registry with
+// the given TLS client configuration, and authenticates with the given
+// authn.Authenticator, or else the given authn.Keychain if not nil. Without
+// either, it connects anonymously.
+func NewRemoteClient(ctx context.Context, tlsConfig *tls.Config, auth authn.Authenticator, keychain authn.Keychain) *RemoteClient {
+	return &RemoteClient{
+		ctx:       ctx,
+		transport: NewTLSTransport(tlsConfig),
+		auth:      auth,
+		keychain:  keychain,
+	}
+}
+
+// Login verifies the credentials of the client against the registry at the
+// given host. The credentials are configured on construction of the client,
+// the given options are ignored.
+func (c *RemoteClient) Login(host string, _ ...registry.LoginOption) error {
+	reg, err := name.NewRegistry(host)
+	if err != nil {
+		return fmt.Errorf("invalid registry host '%s': %w", host, err)
+	}
+	auth, err := c.authenticator(reg)
+	if err != nil {
+		return err
+	}
+	if _, err := transport.NewWithContext(c.ctx, reg, auth, c.transport, nil); err != nil {
+		return fmt.Errorf("failed to authenticate to registry '%s': %w", host, err)
+	}
+	return nil
+}
+
+// Logout is a no-op, as the client does not store credentials.
+func (c *RemoteClient) Logout(_ string, _ ...registry.LogoutOption) error {
+	return nil
+}
+
+// Tags returns the semver tags of the given repository, sorted from the
+// highest to the lowest version. Like the Helm registry client, it changes
+// the underscores (_) in the tags back to plus signs (+).
+func (c *RemoteClient) Tags(url string) ([]string, error) {
+	repo, err := name.NewRepository(url)
+	if err != nil {
+		return nil, err
+	}
+	opts, err := c.remoteOptions(repo.Registry)
+	if err != nil {
+		return nil, err
+	}
+	tags, err := remote.List(repo, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	var versions []*semver.Version
+	for _, tag := range tags {
+		if v, err := semver.StrictNewVersion(strings.ReplaceAll(tag, "_", "+")); err == nil {
+			versions = append(versions, v)
+		}
+	}
+	sort.Sort(sort.Reverse(semver.Collection(versions)))
+
+	result := make([]string, len(versions))
+	for i, v := range versions {
+		result[i] = v.String()
+	}
+	return result, nil
+}
+
+// Get downloads the chart with the given reference, without the oci:// scheme,
+// and returns the content of its chart layer. Like the Helm registry client,
+// it changes the plus signs (+) in the tag of the reference to underscores (_).
+// The given options are ignored.
+func (c *RemoteClient) Get(url string, _ ...getter.Option) (*bytes.Buffer, error) {
+	ref, err := name.ParseReference(tagWithUnderscores(url))
+	if err != nil {
+		return nil, fmt.Errorf("invalid chart reference '%s': %w", url, err)
+	}
+	opts, err := c.remoteOptions(ref.Context().Registry)
+	if err != nil {
+		return nil, err
+	}
+	img, err := remote.Image(ref, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get manifest of '%s': %w", ref, err)
+	}
+	layers, err := img.Layers()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get layers of '%s': %w", ref, err)
+	}
+	for _, layer := range layers {
+		mediaType, err := layer.MediaType()
+		if err != nil {
+			return nil, err
+		}
+		if mediaType != registry.ChartLayerMediaType && mediaType != registry.LegacyChartLayerMediaType {
+			continue
+		}
+		rc, err := layer.Compressed()
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch chart layer of '%s': %w", ref, err)
+		}
+		defer rc.Close()
+		var b bytes.Buffer
+		if _, err := io.Copy(&b, rc); err != nil {
+			return nil, fmt.Errorf("failed to download chart layer of '%s': %w", ref, err)
+		}
+		return &b, nil
+	}
+	return nil, fmt.Errorf("manifest of '%s' does not contain a layer with media type '%s'", ref, registry.ChartLayerMediaType)
+}
+
+// authenticator returns the authn.Authenticator of the client for the given
+// registry.
+func (c *RemoteClient) authenticator(reg name.Registry) (authn.Authenticator, error) {
+	switch {
+	case c.auth != nil:
+		return c.auth, nil
+	case c.keychain != nil:
+		return c.keychain.Resolve(reg)
+	default:
+		return authn.Anonymous, nil
+	}
+}
+
+// remoteOptions returns the remote options to connect to the given registry.
+func (c *RemoteClient) remoteOptions(reg name.Registry) ([]remote.Option, error) {
+	auth, err := c.authenticator(reg)
+	if err != nil {
+		return nil, err
+	}
+	return []remote.Option{
+		remote.WithContext(c.ctx),
+		remote.WithTransport(c.transport),
+		remote.WithAuth(auth),
+	}, nil
+}
+
+// NewTLSTransport returns an http.RoundTripper for go-containerregistry which
+// connects to registries with the given TLS client configuration.
+//
+// go-containerregistry defaults to plain HTTP for registries on localhost or
+// a private network address, while a registry for which a TLS configuration
+// is given is expected to serve HTTPS. The returned transport therefore sends
+// all the requests over HTTPS.
+func NewTLSTransport(tlsConfig *tls.Config) http.RoundTripper {
+	t := remote.DefaultTransport.(*http.Transport).Clone()
+	t.TLSClientConfig = tlsConfig
+	return httpsTransport{t}
+}
+
+// httpsTransport is an http.RoundTripper which sends the requests made over
+// plain HTTP over HTTPS instead.
+type httpsTransport struct {
+	http.RoundTripper
+}
+
+// RoundTrip sends the given request over HTTPS.
+func (t httpsTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.URL.Scheme == "http" {
+		req = req.Clone(req.Context())
+		req.URL.Scheme = "https"
+	}
+	return t.RoundTripper.RoundTrip(req)
+}
+
+// tagWithUnderscores returns the given reference with the plus signs (+) in
+// its tag replaced with underscores (_), as plus signs are not allowed in OCI
+// tags. References by digest are returned as is.
+func tagWithUnderscores(ref string) string {
+	if strings.Contains(ref, "@") {
+		return ref
+	}
+	i := strings.LastIndex(ref, ":")
+	if i < 0 || strings.Contains(ref[i:], "/") {
+		return ref
+	}
+	return ref[:i] + strings.ReplaceAll(ref[i:], "+", "_")
+}
@@ -0,0 +1,116 @@
+/*
+Copyright 2022 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package registry
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io"
+	"log"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	gcrregistry "github.com/google/go-containerregistry/pkg/registry"
+	"github.com/google/go-containerregistry/pkg/v1/empty"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/google/go-containerregistry/pkg/v1/static"
+	gcrtypes "github.com/google/go-containerregistry/pkg/v1/types"
+	. "github.com/onsi/gomega"
+	"helm.sh/helm/v3/pkg/registry"
+)
+
+func TestRemoteClient(t *testing.T) {
+	g := NewWithT(t)
+
+	srv := httptest.NewTLSServer(gcrregistry.New(gcrregistry.Logger(log.New(io.Discard, "", 0))))
+	t.Cleanup(srv.Close)
+	u, err := url.Parse(srv.URL)
+	g.Expect(err).ToNot(HaveOccurred())
+	repo := fmt.Sprintf("%s/charts/podinfo", u.Host)
+
+	// Push a chart with the given content for each tag, next to a tag which
+	// is not a version
+	chart := []byte("chart")
+	img, err := mutate.Append(empty.Image, mutate.Addendum{
+		Layer: static.NewLayer(chart, gcrtypes.MediaType(registry.ChartLayerMediaType)),
+	})
+	g.Expect(err).ToNot(HaveOccurred())
+	img = mutate.ConfigMediaType(img, gcrtypes.MediaType(registry.ConfigMediaType))
+	for _, tag := range []string{"6.0.0", "6.1.0_abc", "latest"} {
+		ref, err := name.ParseReference(fmt.Sprintf("%s:%s", repo, tag))
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(remote.Write(ref, img, remote.WithTransport(httpsTransport{srv.Client().Transport}))).To(Succeed())
+	}
+
+	t.Run("with the CA of the registry", func(t *testing.T) {
+		g := NewWithT(t)
+
+		pool := x509.NewCertPool()
+		pool.AddCert(srv.Certificate())
+		c := NewRemoteClient(context.TODO(), &tls.Config{RootCAs: pool}, nil, nil)
+
+		g.Expect(c.Login(u.Host)).To(Succeed())
+
+		tags, err := c.Tags(repo)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(tags).To(Equal([]string{"6.1.0+abc", "6.0.0"}))
+
+		b, err := c.Get(fmt.Sprintf("%s:6.1.0+abc", repo))
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(b.Bytes()).To(Equal(chart))
+
+		_, err = c.Get(fmt.Sprintf("%s:7.0.0", repo))
+		g.Expect(err).To(HaveOccurred())
+	})
+
+	t.Run("without the CA of the registry", func(t *testing.T) {
+		g := NewWithT(t)
+
+		c := NewRemoteClient(context.TODO(), &tls.Config{}, nil, nil)
+
+		err := c.Login(u.Host)
+		g.Expect(err).To(HaveOccurred())
+		g.Expect(err.Error()).To(ContainSubstring("x509"))
+
+		_, err = c.Tags(repo)
+		g.Expect(err).To(HaveOccurred())
+		g.Expect(err.Error()).To(ContainSubstring("x509"))
+	})
+}
+
+func Test_tagWithUnderscores(t *testing.T) {
+	tests := []struct {
+		ref  string
+		want string
+	}{
+		{ref: "registry.example.com/charts/podinfo:6.1.0+abc", want: "registry.example.com/charts/podinfo:6.1.0_abc"},
+		{ref: "localhost:5000/charts/podinfo:6.1.0+abc", want: "localhost:5000/charts/podinfo:6.1.0_abc"},
+		{ref: "localhost:5000/charts/podinfo", want: "localhost:5000/charts/podinfo"},
+		{ref: "registry.example.com/charts/podinfo@sha256:abc", want: "registry.example.com/charts/podinfo@sha256:abc"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.ref, func(t *testing.T) {
+			g := NewWithT(t)
+			g.Expect(tagWithUnderscores(tt.ref)).To(Equal(tt.want))
+		})
+	}
+}
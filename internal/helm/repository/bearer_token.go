@@ -0,0 +1,89 @@
+/*
+Copyright 2022 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package repository
+
+import (
+	"net/http"
+	"net/url"
+)
+
+// WithBearerToken returns a ChartRepositoryOption that will make the
+// ChartRepository send the given token in the Authorization header of the
+// requests for the index and the charts. Unless passCredentialsAll is true,
+// the token is only sent to the scheme and host of the repository URL, in the
+// same way as the basic auth credentials of the Helm getter.
+func WithBearerToken(token string, passCredentialsAll bool) ChartRepositoryOption {
+	return func(r *ChartRepository) error {
+		r.bearerToken = token
+		r.passCredentialsAll = passCredentialsAll
+		return nil
+	}
+}
+
+// bearerTokenRoundTripper is an http.RoundTripper which sets the bearer token
+// as the Authorization header of the requests to the host of the repository
+// URL, or to all hosts if passCredentialsAll is true.
+type bearerTokenRoundTripper struct {
+	next               http.RoundTripper
+	url                string
+	token              string
+	passCredentialsAll bool
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *bearerTokenRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	if t.passCredentialsAll || t.sameHost(req.URL) {
+		req = req.Clone(req.Context())
+		req.Header.Set("Authorization", "Bearer "+t.token)
+	}
+	return t.next.RoundTrip(req)
+}
+
+// sameHost returns if the given URL has the scheme and host, including the
+// port, of the repository URL. This ensures the token is not passed to
+// another service, e.g. the storage the chart URLs of the index point to.
+func (t *bearerTokenRoundTripper) sameHost(u *url.URL) bool {
+	repoURL, err := url.Parse(t.url)
+	if err != nil {
+		return false
+	}
+	return repoURL.Scheme == u.Scheme && repoURL.Host == u.Host
+}
+
+// transport returns the given *http.Transport for the Helm getter, wrapped to
+// send the bearer token of the ChartRepository if set.
+func (r *ChartRepository) transport(t *http.Transport) *http.Transport {
+	if r.bearerToken == "" {
+		return t
+	}
+	return roundTripperTransport(&bearerTokenRoundTripper{
+		next:               t,
+		url:                r.URL,
+		token:              r.bearerToken,
+		passCredentialsAll: r.passCredentialsAll,
+	})
+}
+
+// roundTripperTransport returns an *http.Transport, as required by the Helm
+// getter, which hands the HTTP(S) requests over to the given
+// http.RoundTripper.
+func roundTripperTransport(rt http.RoundTripper) *http.Transport {
+	tr := &http.Transport{}
+	tr.RegisterProtocol("http", rt)
+	tr.RegisterProtocol("https", rt)
+	return tr
+}
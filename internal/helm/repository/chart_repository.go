@@ -28,6 +28,7 @@ import (
 	"net/url"
 	"os"
 	"path"
+	"path/filepath"
 	"sort"
 	"strings"
 	"sync"
@@ -35,6 +36,7 @@ import (
 
 	"github.com/Masterminds/semver/v3"
 	"helm.sh/helm/v3/pkg/getter"
+	"helm.sh/helm/v3/pkg/provenance"
 	"helm.sh/helm/v3/pkg/repo"
 	kerrors "k8s.io/apimachinery/pkg/util/errors"
 	"sigs.k8s.io/yaml"
@@ -44,6 +46,7 @@ import (
 	"github.com/fluxcd/source-controller/internal/cache"
 	"github.com/fluxcd/source-controller/internal/helm"
 	"github.com/fluxcd/source-controller/internal/transport"
+	"github.com/fluxcd/source-controller/internal/util"
 )
 
 var ErrNoChartIndex = errors.New("no chart index")
@@ -71,9 +74,30 @@ type ChartRepository struct {
 	// index bytes. This is different from the checksum of the CachePath, which
 	// may contain unordered entries.
 	Checksum string
+	// IndexValidators are sent in a conditional request for the index by
+	// DownloadIndex when set, and updated by CacheIndex to the validators
+	// of the index it downloaded.
+	IndexValidators IndexValidators
 
 	tlsConfig *tls.Config
 
+	// keyring is the PGP public keyring used by VerifyChart to verify the
+	// provenance of a chart.
+	keyring []byte
+	// verifiedCharts holds the data of the charts verified by VerifyChart,
+	// by chart URL, to not download them again.
+	verifiedCharts   map[string][]byte
+	verifiedChartsMu sync.Mutex
+
+	// ignorePrerelease filters out the prerelease versions which are not
+	// explicitly referenced by the version constraint in GetChartVersion.
+	ignorePrerelease bool
+
+	// bearerToken is sent in the Authorization header of the requests to
+	// the host of the URL, or to all hosts if passCredentialsAll is true.
+	bearerToken        string
+	passCredentialsAll bool
+
 	*sync.RWMutex
 
 	cacheInfo
@@ -116,6 +140,25 @@ func WithMemoryCache(key string, c *cache.Cache, ttl time.Duration, rec RecordMe
 	}
 }
 
+// WithProvenanceKeyring returns a ChartRepositoryOption that will set the PGP
+// public keyring used to verify the provenance files of the charts.
+func WithProvenanceKeyring(keyring []byte) ChartRepositoryOption {
+	return func(r *ChartRepository) error {
+		r.keyring = keyring
+		return nil
+	}
+}
+
+// WithIgnorePrerelease returns a ChartRepositoryOption that will make
+// GetChartVersion ignore the prerelease versions which are not explicitly
+// referenced by the version constraint, see util.PrereleaseFilter.
+func WithIgnorePrerelease() ChartRepositoryOption {
+	return func(r *ChartRepository) error {
+		r.ignorePrerelease = true
+		return nil
+	}
+}
+
 // NewChartRepository constructs and returns a new ChartRepository with
 // the ChartRepository.Client configured to the getter.Getter for the
 // repository URL scheme. It returns an error on URL parsing failures,
@@ -175,7 +218,25 @@ func (r *ChartRepository) getChartVersion(name, ver string) (*repo.ChartVersion,
 	if r.Index == nil {
 		return nil, ErrNoChartIndex
 	}
-	cvs, ok := r.Index.Entries[name]
+	return findChartVersion(r.Index, name, ver, r.ignorePrerelease)
+}
+
+// FindChartVersion returns the repo.ChartVersion for the given name from the
+// given index, the version is expected to be a semver.Constraints compatible
+// string. If version is empty, the latest stable version will be returned and
+// prerelease versions will be ignored.
+func FindChartVersion(index *repo.IndexFile, name, ver string) (*repo.ChartVersion, error) {
+	return findChartVersion(index, name, ver, false)
+}
+
+// findChartVersion implements FindChartVersion, ignoring the prerelease
+// versions which are not explicitly referenced by the version constraint if
+// ignorePrerelease is true.
+func findChartVersion(index *repo.IndexFile, name, ver string, ignorePrerelease bool) (*repo.ChartVersion, error) {
+	if index == nil {
+		return nil, ErrNoChartIndex
+	}
+	cvs, ok := index.Entries[name]
 	if !ok {
 		return nil, repo.ErrNoChartName
 	}
@@ -209,12 +270,17 @@ func (r *ChartRepository) getChartVersion(name, ver string) (*repo.ChartVersion,
 	// parse semver and build a lookup table
 	var matchedVersions semver.Collection
 	lookup := make(map[*semver.Version]*repo.ChartVersion)
+	filter := util.NewPrereleaseFilter(ver)
 	for _, cv := range cvs {
 		v, err := version.ParseVersion(cv.Version)
 		if err != nil {
 			continue
 		}
 
+		if ignorePrerelease && !filter.Allow(v) {
+			continue
+		}
+
 		if !verConstraint.Check(v) {
 			continue
 		}
@@ -253,6 +319,24 @@ func (r *ChartRepository) getChartVersion(name, ver string) (*repo.ChartVersion,
 // and then attempts to download the chart using the Client and Options of the
 // ChartRepository. It returns a bytes.Buffer containing the chart data.
 func (r *ChartRepository) DownloadChart(chart *repo.ChartVersion) (*bytes.Buffer, error) {
+	u, err := r.chartURL(chart)
+	if err != nil {
+		return nil, err
+	}
+
+	// Return the chart downloaded by VerifyChart, if any
+	r.verifiedChartsMu.Lock()
+	verified, ok := r.verifiedCharts[u.String()]
+	r.verifiedChartsMu.Unlock()
+	if ok {
+		return bytes.NewBuffer(verified), nil
+	}
+
+	return r.download(u)
+}
+
+// chartURL returns the absolute URL of the given repo.ChartVersion.
+func (r *ChartRepository) chartURL(chart *repo.ChartVersion) (*url.URL, error) {
 	if len(chart.URLs) == 0 {
 		return nil, fmt.Errorf("chart '%s' has no downloadable URLs", chart.Name)
 	}
@@ -280,9 +364,14 @@ func (r *ChartRepository) DownloadChart(chart *repo.ChartVersion) (*bytes.Buffer
 		u = repoURL.ResolveReference(u)
 		u.RawQuery = q.Encode()
 	}
+	return u, nil
+}
 
+// download downloads the file at the given URL using the Client and Options
+// of the ChartRepository.
+func (r *ChartRepository) download(u *url.URL) (*bytes.Buffer, error) {
 	t := transport.NewOrIdle(r.tlsConfig)
-	clientOpts := append(r.Options, getter.WithTransport(t))
+	clientOpts := append(r.Options, getter.WithTransport(r.transport(t)))
 	defer transport.Release(t)
 
 	return r.Client.Get(u.String(), clientOpts...)
@@ -327,8 +416,10 @@ func (r *ChartRepository) LoadFromFile(path string) error {
 }
 
 // CacheIndex attempts to write the index from the remote into a new temporary file
-// using DownloadIndex, and sets CachePath and Cached.
+// using DownloadIndex, and sets CachePath, Cached and IndexValidators.
 // It returns the SHA256 checksum of the downloaded index bytes, or an error.
+// If the index did not change since it was downloaded with the set
+// IndexValidators, it returns ErrIndexNotModified without setting CachePath.
 // The caller is expected to handle the garbage collection of CachePath, and to
 // load the Index separately using LoadFromCache if required.
 func (r *ChartRepository) CacheIndex() (string, error) {
@@ -339,7 +430,16 @@ func (r *ChartRepository) CacheIndex() (string, error) {
 
 	h := sha256.New()
 	mw := io.MultiWriter(f, h)
-	if err = r.DownloadIndex(mw); err != nil {
+	validators, err := r.downloadIndex(mw)
+	if errors.Is(err, ErrIndexNotModified) {
+		f.Close()
+		os.RemoveAll(f.Name())
+		r.Lock()
+		r.IndexValidators = validators
+		r.Unlock()
+		return "", err
+	}
+	if err != nil {
 		f.Close()
 		os.RemoveAll(f.Name())
 		return "", fmt.Errorf("failed to cache index to temporary file: %w", err)
@@ -352,6 +452,7 @@ func (r *ChartRepository) CacheIndex() (string, error) {
 	r.Lock()
 	r.CachePath = f.Name()
 	r.Cached = true
+	r.IndexValidators = validators
 	r.Unlock()
 	return hex.EncodeToString(h.Sum(nil)), nil
 }
@@ -435,28 +536,42 @@ func (r *ChartRepository) LoadFromCache() error {
 
 // DownloadIndex attempts to download the chart repository index using
 // the Client and set Options, and writes the index to the given io.Writer.
+// If IndexValidators are set, the request is conditional, and it returns
+// ErrIndexNotModified if the repository responds that the index did not
+// change.
 // It returns an url.Error if the URL failed to parse.
 func (r *ChartRepository) DownloadIndex(w io.Writer) (err error) {
+	_, err = r.downloadIndex(w)
+	return
+}
+
+// downloadIndex implements DownloadIndex, and returns the validators of the
+// response for the index.
+func (r *ChartRepository) downloadIndex(w io.Writer) (IndexValidators, error) {
 	u, err := url.Parse(r.URL)
 	if err != nil {
-		return err
+		return IndexValidators{}, err
 	}
 	u.RawPath = path.Join(u.RawPath, "index.yaml")
 	u.Path = path.Join(u.Path, "index.yaml")
 
 	t := transport.NewOrIdle(r.tlsConfig)
-	clientOpts := append(r.Options, getter.WithTransport(t))
+	rt := &conditionalRoundTripper{next: r.transport(t), validators: r.IndexValidators}
+	clientOpts := append(r.Options, getter.WithTransport(rt.transport()))
 	defer transport.Release(t)
 
-	var res *bytes.Buffer
-	res, err = r.Client.Get(u.String(), clientOpts...)
+	res, err := r.Client.Get(u.String(), clientOpts...)
+	notModified, validators := rt.result()
+	if notModified {
+		return validators, ErrIndexNotModified
+	}
 	if err != nil {
-		return err
+		return IndexValidators{}, err
 	}
 	if _, err = io.Copy(w, res); err != nil {
-		return err
+		return IndexValidators{}, err
 	}
-	return nil
+	return validators, nil
 }
 
 // HasIndex returns true if the Index is not nil.
@@ -530,9 +645,59 @@ func (r *ChartRepository) RemoveCache() error {
 	return nil
 }
 
-// VerifyChart verifies the chart against a signature.
-// It returns an error on failure.
-func (r *ChartRepository) VerifyChart(_ context.Context, _ *repo.ChartVersion) error {
-	// this is a no-op because this is not implemented yet.
-	return fmt.Errorf("not implemented")
+// VerifyChart verifies the chart against its provenance file, which is
+// expected next to the chart in the repository with a '.prov' suffix, and the
+// PGP public keyring of the ChartRepository.
+// It returns an error on failure. The verified chart is returned by subsequent
+// DownloadChart calls, instead of being downloaded again.
+func (r *ChartRepository) VerifyChart(_ context.Context, chart *repo.ChartVersion) error {
+	if len(r.keyring) == 0 {
+		return fmt.Errorf("no keyring available")
+	}
+
+	u, err := r.chartURL(chart)
+	if err != nil {
+		return err
+	}
+	res, err := r.download(u)
+	if err != nil {
+		return fmt.Errorf("failed to download chart '%s': %w", u.String(), err)
+	}
+	provURL := *u
+	provURL.Path += ".prov"
+	prov, err := r.download(&provURL)
+	if err != nil {
+		return fmt.Errorf("failed to download provenance file '%s': %w", provURL.String(), err)
+	}
+
+	// The provenance file records the digest of the chart by file name
+	tmpDir, err := os.MkdirTemp("", "helm-chart-provenance-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tmpDir)
+	chartPath := filepath.Join(tmpDir, path.Base(u.Path))
+	provPath := chartPath + ".prov"
+	keyringPath := filepath.Join(tmpDir, "pubring.gpg")
+	for p, data := range map[string][]byte{chartPath: res.Bytes(), provPath: prov.Bytes(), keyringPath: r.keyring} {
+		if err := os.WriteFile(p, data, 0o600); err != nil {
+			return err
+		}
+	}
+
+	signatory, err := provenance.NewFromKeyring(keyringPath, "")
+	if err != nil {
+		return fmt.Errorf("failed to load keyring: %w", err)
+	}
+	if _, err := signatory.Verify(chartPath, provPath); err != nil {
+		return fmt.Errorf("failed to verify the provenance of chart '%s': %w", u.String(), err)
+	}
+
+	r.verifiedChartsMu.Lock()
+	defer r.verifiedChartsMu.Unlock()
+	if r.verifiedCharts == nil {
+		r.verifiedCharts = make(map[string][]byte)
+	}
+	r.verifiedCharts[u.String()] = res.Bytes()
+	return nil
 }
@@ -18,8 +18,12 @@ package repository
 
 import (
 	"bytes"
+	"context"
 	"crypto/sha256"
+	"errors"
 	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"net/url"
 	"os"
 	"path/filepath"
@@ -129,11 +133,12 @@ func TestChartRepository_Get(t *testing.T) {
 	r.Index.SortEntries()
 
 	tests := []struct {
-		name         string
-		chartName    string
-		chartVersion string
-		wantVersion  string
-		wantErr      string
+		name             string
+		chartName        string
+		chartVersion     string
+		ignorePrerelease bool
+		wantVersion      string
+		wantErr          string
 	}{
 		{
 			name:         "exact match",
@@ -159,6 +164,26 @@ func TestChartRepository_Get(t *testing.T) {
 			chartVersion: "<1.0.0",
 			wantVersion:  "0.2.0",
 		},
+		{
+			name:         "prerelease range",
+			chartName:    "chart",
+			chartVersion: ">=0.1.0-0",
+			wantVersion:  "1.1.0-rc.1",
+		},
+		{
+			name:             "prerelease range ignoring prereleases",
+			chartName:        "chart",
+			chartVersion:     ">=0.1.0-0",
+			ignorePrerelease: true,
+			wantVersion:      "1.0.0",
+		},
+		{
+			name:             "referenced prerelease ignoring prereleases",
+			chartName:        "chart",
+			chartVersion:     ">=1.1.0-0",
+			ignorePrerelease: true,
+			wantVersion:      "1.1.0-rc.1",
+		},
 		{
 			name:         "unfulfilled range",
 			chartName:    "chart",
@@ -182,6 +207,7 @@ func TestChartRepository_Get(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			g := NewWithT(t)
 
+			r.ignorePrerelease = tt.ignorePrerelease
 			cv, err := r.GetChartVersion(tt.chartName, tt.chartVersion)
 			if tt.wantErr != "" {
 				g.Expect(err).To(HaveOccurred())
@@ -252,6 +278,125 @@ func TestChartRepository_DownloadChart(t *testing.T) {
 	}
 }
 
+// filesGetter is a getter.Getter implementation returning the content of
+// the local file mapped to the provided URL.
+type filesGetter struct {
+	Files      map[string]string
+	CalledURLs []string
+}
+
+func (g *filesGetter) Get(u string, _ ...helmgetter.Option) (*bytes.Buffer, error) {
+	g.CalledURLs = append(g.CalledURLs, u)
+	f, ok := g.Files[u]
+	if !ok {
+		return nil, fmt.Errorf("failed to fetch %s : 404 Not Found", u)
+	}
+	b, err := os.ReadFile(f)
+	if err != nil {
+		return nil, err
+	}
+	return bytes.NewBuffer(b), nil
+}
+
+func TestChartRepository_VerifyChart(t *testing.T) {
+	const (
+		chartURL = "https://example.com/charts/helmchart-0.1.0.tgz"
+		provURL  = chartURL + ".prov"
+	)
+
+	keyring, err := os.ReadFile("../testdata/pubring.gpg")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		keyring []byte
+		files   map[string]string
+		wantErr string
+	}{
+		{
+			name:    "valid provenance",
+			keyring: keyring,
+			files: map[string]string{
+				chartURL: "../testdata/charts/helmchart-0.1.0.tgz",
+				provURL:  "../testdata/charts/helmchart-0.1.0.tgz.prov",
+			},
+		},
+		{
+			name: "no keyring",
+			files: map[string]string{
+				chartURL: "../testdata/charts/helmchart-0.1.0.tgz",
+				provURL:  "../testdata/charts/helmchart-0.1.0.tgz.prov",
+			},
+			wantErr: "no keyring available",
+		},
+		{
+			name:    "missing provenance file",
+			keyring: keyring,
+			files: map[string]string{
+				chartURL: "../testdata/charts/helmchart-0.1.0.tgz",
+			},
+			wantErr: "failed to download provenance file",
+		},
+		{
+			name:    "chart digest mismatch",
+			keyring: keyring,
+			files: map[string]string{
+				chartURL: "../testdata/charts/helmchartwithdeps-v1-0.3.0.tgz",
+				provURL:  "../testdata/charts/helmchart-0.1.0.tgz.prov",
+			},
+			wantErr: "sha256 sum does not match",
+		},
+		{
+			name:    "invalid keyring",
+			keyring: []byte("invalid"),
+			files: map[string]string{
+				chartURL: "../testdata/charts/helmchart-0.1.0.tgz",
+				provURL:  "../testdata/charts/helmchart-0.1.0.tgz.prov",
+			},
+			wantErr: "failed to load keyring",
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			fg := &filesGetter{Files: tt.files}
+			r, err := NewChartRepository("https://example.com", "", helmgetter.Providers{
+				helmgetter.Provider{
+					Schemes: []string{"https"},
+					New: func(...helmgetter.Option) (helmgetter.Getter, error) {
+						return fg, nil
+					},
+				},
+			}, nil, nil, WithProvenanceKeyring(tt.keyring))
+			g.Expect(err).ToNot(HaveOccurred())
+
+			cv := &repo.ChartVersion{
+				Metadata: &chart.Metadata{Name: "helmchart", Version: "0.1.0"},
+				URLs:     []string{"charts/helmchart-0.1.0.tgz"},
+			}
+			err = r.VerifyChart(context.TODO(), cv)
+			if tt.wantErr != "" {
+				g.Expect(err).To(HaveOccurred())
+				g.Expect(err.Error()).To(ContainSubstring(tt.wantErr))
+				return
+			}
+			g.Expect(err).ToNot(HaveOccurred())
+
+			// The verified chart is not downloaded again
+			res, err := r.DownloadChart(cv)
+			g.Expect(err).ToNot(HaveOccurred())
+			want, err := os.ReadFile(tt.files[chartURL])
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(res.Bytes()).To(Equal(want))
+			g.Expect(fg.CalledURLs).To(Equal([]string{chartURL, provURL}))
+		})
+	}
+}
+
 func TestChartRepository_DownloadIndex(t *testing.T) {
 	g := NewWithT(t)
 
@@ -271,6 +416,119 @@ func TestChartRepository_DownloadIndex(t *testing.T) {
 	g.Expect(err).To(BeNil())
 }
 
+func TestChartRepository_CacheIndex_NotModified(t *testing.T) {
+	g := NewWithT(t)
+
+	b, err := os.ReadFile(chartmuseumTestFile)
+	g.Expect(err).ToNot(HaveOccurred())
+
+	const etag = `"index-v1"`
+	var ifNoneMatch []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ifNoneMatch = append(ifNoneMatch, r.Header.Get("If-None-Match"))
+		w.Header().Set("ETag", etag)
+		if r.Header.Get("If-None-Match") == etag {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		_, _ = w.Write(b)
+	}))
+	defer srv.Close()
+
+	providers := helmgetter.Providers{
+		{Schemes: []string{"http"}, New: helmgetter.NewHTTPGetter},
+	}
+
+	r, err := NewChartRepository(srv.URL, "", providers, nil, nil)
+	g.Expect(err).ToNot(HaveOccurred())
+	checksum, err := r.CacheIndex()
+	g.Expect(err).ToNot(HaveOccurred())
+	defer r.RemoveCache()
+	g.Expect(checksum).To(Equal(fmt.Sprintf("%x", sha256.Sum256(b))))
+	g.Expect(r.IndexValidators).To(Equal(IndexValidators{ETag: etag}))
+
+	// A conditional request does not download the unchanged index
+	r2, err := NewChartRepository(srv.URL, "", providers, nil, nil)
+	g.Expect(err).ToNot(HaveOccurred())
+	r2.IndexValidators = r.IndexValidators
+	_, err = r2.CacheIndex()
+	g.Expect(errors.Is(err, ErrIndexNotModified)).To(BeTrue())
+	g.Expect(r2.CachePath).To(BeEmpty())
+	g.Expect(r2.IndexValidators).To(Equal(IndexValidators{ETag: etag}))
+
+	g.Expect(ifNoneMatch).To(Equal([]string{"", etag}))
+}
+
+func TestChartRepository_DownloadIndex_BearerToken(t *testing.T) {
+	g := NewWithT(t)
+
+	b, err := os.ReadFile(chartmuseumTestFile)
+	g.Expect(err).ToNot(HaveOccurred())
+
+	var authorization string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authorization = r.Header.Get("Authorization")
+		_, _ = w.Write(b)
+	}))
+	defer srv.Close()
+
+	providers := helmgetter.Providers{
+		{Schemes: []string{"http"}, New: helmgetter.NewHTTPGetter},
+	}
+
+	r, err := NewChartRepository(srv.URL, "", providers, nil, nil, WithBearerToken("token", false))
+	g.Expect(err).ToNot(HaveOccurred())
+	buf := bytes.NewBuffer([]byte{})
+	g.Expect(r.DownloadIndex(buf)).To(Succeed())
+	g.Expect(buf.Bytes()).To(Equal(b))
+	g.Expect(authorization).To(Equal("Bearer token"))
+}
+
+func Test_bearerTokenRoundTripper(t *testing.T) {
+	tests := []struct {
+		name               string
+		url                string
+		passCredentialsAll bool
+		want               string
+	}{
+		{name: "repository host", url: "https://charts.example.com/stable/index.yaml", want: "Bearer token"},
+		{name: "other host", url: "https://storage.example.com/chart-1.0.0.tgz"},
+		{name: "other port", url: "https://charts.example.com:8443/chart-1.0.0.tgz"},
+		{name: "other scheme", url: "http://charts.example.com/chart-1.0.0.tgz"},
+		{name: "pass credentials to all hosts", url: "https://storage.example.com/chart-1.0.0.tgz", passCredentialsAll: true, want: "Bearer token"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			var got string
+			rt := &bearerTokenRoundTripper{
+				next: roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+					got = req.Header.Get("Authorization")
+					return &http.Response{StatusCode: http.StatusOK}, nil
+				}),
+				url:                "https://charts.example.com/stable",
+				token:              "token",
+				passCredentialsAll: tt.passCredentialsAll,
+			}
+			req, err := http.NewRequest(http.MethodGet, tt.url, nil)
+			g.Expect(err).ToNot(HaveOccurred())
+			_, err = rt.RoundTrip(req)
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(got).To(Equal(tt.want))
+			// The original request is not modified
+			g.Expect(req.Header.Get("Authorization")).To(BeEmpty())
+		})
+	}
+}
+
+// roundTripperFunc implements http.RoundTripper with a function.
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
 func TestChartRepository_LoadIndexFromBytes(t *testing.T) {
 	tests := []struct {
 		name        string
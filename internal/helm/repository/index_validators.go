@@ -0,0 +1,100 @@
+/*
+Copyright 2022 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package repository
+
+import (
+	"errors"
+	"net/http"
+	"sync"
+)
+
+// ErrIndexNotModified is returned by DownloadIndex when the repository
+// responds to a conditional request with 304 Not Modified.
+var ErrIndexNotModified = errors.New("index not modified")
+
+// IndexValidators are the validators of a downloaded chart repository index,
+// which are sent in a conditional request for the index to avoid downloading
+// it again if it did not change.
+type IndexValidators struct {
+	// ETag is the value of the ETag header of the index response.
+	ETag string
+	// LastModified is the value of the Last-Modified header of the index
+	// response.
+	LastModified string
+}
+
+// IsZero returns true if no validator is set.
+func (v IndexValidators) IsZero() bool {
+	return v.ETag == "" && v.LastModified == ""
+}
+
+// conditionalRoundTripper is an http.RoundTripper which sends the validators
+// of a previously downloaded index in the If-None-Match and If-Modified-Since
+// headers of the requests, and records the validators of the last response.
+type conditionalRoundTripper struct {
+	next       http.RoundTripper
+	validators IndexValidators
+
+	mu          sync.Mutex
+	notModified bool
+	response    IndexValidators
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *conditionalRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	if !t.validators.IsZero() {
+		req = req.Clone(req.Context())
+		if t.validators.ETag != "" {
+			req.Header.Set("If-None-Match", t.validators.ETag)
+		}
+		if t.validators.LastModified != "" {
+			req.Header.Set("If-Modified-Since", t.validators.LastModified)
+		}
+	}
+
+	resp, err := t.next.RoundTrip(req)
+	if err != nil {
+		return resp, err
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.notModified = resp.StatusCode == http.StatusNotModified
+	t.response = IndexValidators{
+		ETag:         resp.Header.Get("ETag"),
+		LastModified: resp.Header.Get("Last-Modified"),
+	}
+	// A 304 response is not required to repeat the validators
+	if t.notModified && t.response.IsZero() {
+		t.response = t.validators
+	}
+	return resp, nil
+}
+
+// result returns whether the index was not modified, and the validators of
+// the last response.
+func (t *conditionalRoundTripper) result() (bool, IndexValidators) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.notModified, t.response
+}
+
+// transport returns an *http.Transport, as required by the Helm getter, which
+// hands the HTTP(S) requests over to the conditionalRoundTripper.
+func (t *conditionalRoundTripper) transport() *http.Transport {
+	return roundTripperTransport(t)
+}
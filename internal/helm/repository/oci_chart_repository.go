@@ -26,6 +26,7 @@ import (
 	"path"
 	"sort"
 	"strings"
+	"time"
 
 	"helm.sh/helm/v3/pkg/chart"
 	"helm.sh/helm/v3/pkg/getter"
@@ -34,10 +35,12 @@ import (
 
 	"github.com/Masterminds/semver/v3"
 	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
 
 	"github.com/fluxcd/pkg/version"
 	"github.com/fluxcd/source-controller/internal/oci"
 	"github.com/fluxcd/source-controller/internal/transport"
+	"github.com/fluxcd/source-controller/internal/util"
 )
 
 // RegistryClient is an interface for interacting with OCI registries
@@ -70,12 +73,32 @@ type OCIChartRepository struct {
 
 	// verifiers is a list of verifiers to use when verifying a chart.
 	verifiers []oci.Verifier
+
+	// remoteOpts are the options to use when fetching the manifest of a chart.
+	remoteOpts []remote.Option
+
+	// recordPull records the duration and the size of the chart downloads.
+	recordPull RecordPullFunc
+
+	// ignorePrerelease filters out the prerelease versions which are not
+	// explicitly referenced by the version constraint in GetChartVersion.
+	ignorePrerelease bool
 }
 
 // OCIChartRepositoryOption is a function that can be passed to NewOCIChartRepository
 // to configure an OCIChartRepository.
 type OCIChartRepositoryOption func(*OCIChartRepository) error
 
+// WithOCIIgnorePrerelease returns a ChartRepositoryOption that will make
+// GetChartVersion ignore the prerelease tags which are not explicitly
+// referenced by the version constraint, see util.PrereleaseFilter.
+func WithOCIIgnorePrerelease() OCIChartRepositoryOption {
+	return func(r *OCIChartRepository) error {
+		r.ignorePrerelease = true
+		return nil
+	}
+}
+
 // WithVerifiers returns a ChartRepositoryOption that will set the chart verifiers
 func WithVerifiers(verifiers []oci.Verifier) OCIChartRepositoryOption {
 	return func(r *OCIChartRepository) error {
@@ -84,6 +107,29 @@ func WithVerifiers(verifiers []oci.Verifier) OCIChartRepositoryOption {
 	}
 }
 
+// WithOCIRemoteOptions returns a ChartRepositoryOption that will set the
+// options used to fetch the manifest of a chart, and enables resolving the
+// config media type of charts.
+func WithOCIRemoteOptions(opts ...remote.Option) OCIChartRepositoryOption {
+	return func(r *OCIChartRepository) error {
+		r.remoteOpts = opts
+		return nil
+	}
+}
+
+// RecordPullFunc is a function that records the duration since the given start
+// time and the number of bytes of a chart download.
+type RecordPullFunc func(start time.Time, bytes int64)
+
+// WithOCIPullRecorder returns a ChartRepositoryOption that will record the
+// duration and the size of the chart downloads with the given function.
+func WithOCIPullRecorder(rec RecordPullFunc) OCIChartRepositoryOption {
+	return func(r *OCIChartRepository) error {
+		r.recordPull = rec
+		return nil
+	}
+}
+
 // WithOCIRegistryClient returns a ChartRepositoryOption that will set the registry client
 func WithOCIRegistryClient(client RegistryClient) OCIChartRepositoryOption {
 	return func(r *OCIChartRepository) error {
@@ -104,6 +150,15 @@ func WithOCIGetter(providers getter.Providers) OCIChartRepositoryOption {
 	}
 }
 
+// WithOCIClient returns a ChartRepositoryOption that will set the
+// getter.Getter, instead of the one for the URL scheme of the getter.Providers.
+func WithOCIClient(client getter.Getter) OCIChartRepositoryOption {
+	return func(r *OCIChartRepository) error {
+		r.Client = client
+		return nil
+	}
+}
+
 // WithOCIGetterOptions returns a ChartRepositoryOption that will set the getter.Options
 func WithOCIGetterOptions(getterOpts []getter.Option) OCIChartRepositoryOption {
 	return func(r *OCIChartRepository) error {
@@ -144,6 +199,9 @@ func NewOCIChartRepository(repositoryURL string, chartRepoOpts ...OCIChartReposi
 // GetChartVersion returns the repo.ChartVersion for the given name, the version is expected
 // to be a semver.Constraints compatible string. If version is empty, the latest
 // stable version will be returned and prerelease versions will be ignored.
+// If the version is pinned to a digest ("<version>@<digest>"), the returned
+// repo.ChartVersion references the chart by its digest, and has it set as its
+// Digest.
 // adapted from https://github.com/helm/helm/blob/49819b4ef782e80b0c7f78c30bd76b51ebb56dc8/pkg/downloader/chart_downloader.go#L162
 func (r *OCIChartRepository) GetChartVersion(name, ver string) (*repo.ChartVersion, error) {
 	cv, err := r.getChartVersion(name, ver)
@@ -157,6 +215,23 @@ func (r *OCIChartRepository) getChartVersion(name, ver string) (*repo.ChartVersi
 	cpURL := r.URL
 	cpURL.Path = path.Join(cpURL.Path, name)
 
+	// if ver is pinned to a digest, reference the chart by its digest and skip
+	// the resolution of the version.
+	if v, digest := SplitVersionDigest(ver); digest != "" {
+		ref := fmt.Sprintf("%s@%s", cpURL.String(), digest)
+		if err := validateDigestReference(ref); err != nil {
+			return nil, err
+		}
+		return &repo.ChartVersion{
+			URLs:   []string{ref},
+			Digest: digest,
+			Metadata: &chart.Metadata{
+				Name:    name,
+				Version: v,
+			},
+		}, nil
+	}
+
 	// if ver is a valid semver version, take a shortcut here so we don't need to list all tags which can be an
 	// expensive operation.
 	if _, err := version.ParseVersion(ver); err == nil {
@@ -185,7 +260,7 @@ func (r *OCIChartRepository) getChartVersion(name, ver string) (*repo.ChartVersi
 	// If empty, try to get the highest available tag
 	// If exact version, try to find it
 	// If semver constraint string, try to find a match
-	tag, err := getLastMatchingVersionOrConstraint(cvs, ver)
+	tag, err := getLastMatchingVersionOrConstraint(cvs, ver, r.ignorePrerelease)
 	return &repo.ChartVersion{
 		URLs: []string{fmt.Sprintf("%s:%s", cpURL.String(), tag)},
 		Metadata: &chart.Metadata{
@@ -195,6 +270,15 @@ func (r *OCIChartRepository) getChartVersion(name, ver string) (*repo.ChartVersi
 	}, err
 }
 
+// validateDigestReference returns an error if the given OCI reference does not
+// contain a valid digest.
+func validateDigestReference(ref string) error {
+	if _, err := name.NewDigest(strings.TrimPrefix(ref, fmt.Sprintf("%s://", registry.OCIScheme))); err != nil {
+		return fmt.Errorf("invalid digest reference '%s': %w", ref, err)
+	}
+	return nil
+}
+
 // This function shall be called for OCI registries only
 // It assumes that the ref has been validated to be an OCI reference.
 func (r *OCIChartRepository) getTags(ref string) ([]string, error) {
@@ -231,7 +315,15 @@ func (r *OCIChartRepository) DownloadChart(chart *repo.ChartVersion) (*bytes.Buf
 	defer transport.Release(t)
 
 	// trim the oci scheme prefix if needed
-	return r.Client.Get(strings.TrimPrefix(u.String(), fmt.Sprintf("%s://", registry.OCIScheme)), clientOpts...)
+	start := time.Now()
+	b, err := r.Client.Get(strings.TrimPrefix(u.String(), fmt.Sprintf("%s://", registry.OCIScheme)), clientOpts...)
+	if err != nil {
+		return nil, err
+	}
+	if r.recordPull != nil {
+		r.recordPull(start, int64(b.Len()))
+	}
+	return b, nil
 }
 
 // Login attempts to login to the OCI registry.
@@ -273,7 +365,9 @@ func (r *OCIChartRepository) Clear() error {
 
 // getLastMatchingVersionOrConstraint returns the last version that matches the given version string.
 // If the version string is empty, the highest available version is returned.
-func getLastMatchingVersionOrConstraint(cvs []string, ver string) (string, error) {
+// If ignorePrerelease is true, the prerelease versions which are not explicitly
+// referenced by the version string are ignored.
+func getLastMatchingVersionOrConstraint(cvs []string, ver string, ignorePrerelease bool) (string, error) {
 	// Check for exact matches first
 	if ver != "" {
 		for _, cv := range cvs {
@@ -297,12 +391,17 @@ func getLastMatchingVersionOrConstraint(cvs []string, ver string) (string, error
 	}
 
 	matchingVersions := make([]*semver.Version, 0, len(cvs))
+	filter := util.NewPrereleaseFilter(ver)
 	for _, cv := range cvs {
 		v, err := version.ParseVersion(cv)
 		if err != nil {
 			continue
 		}
 
+		if ignorePrerelease && !filter.Allow(v) {
+			continue
+		}
+
 		if !verConstraint.Check(v) {
 			continue
 		}
@@ -319,6 +418,34 @@ func getLastMatchingVersionOrConstraint(cvs []string, ver string) (string, error
 	return matchingVersions[0].Original(), nil
 }
 
+// GetChartConfigMediaType fetches the manifest of the given chart, and
+// returns the media type of its config. It returns an empty string if the
+// repository is not configured with remote options.
+func (r *OCIChartRepository) GetChartConfigMediaType(chart *repo.ChartVersion) (string, error) {
+	if r.remoteOpts == nil {
+		return "", nil
+	}
+
+	if len(chart.URLs) == 0 {
+		return "", fmt.Errorf("chart '%s' has no downloadable URLs", chart.Name)
+	}
+
+	ref, err := name.ParseReference(strings.TrimPrefix(chart.URLs[0], fmt.Sprintf("%s://", registry.OCIScheme)))
+	if err != nil {
+		return "", fmt.Errorf("invalid chart reference: %s", err)
+	}
+
+	img, err := remote.Image(ref, r.remoteOpts...)
+	if err != nil {
+		return "", fmt.Errorf("failed to get manifest of '%s': %w", ref, err)
+	}
+	manifest, err := img.Manifest()
+	if err != nil {
+		return "", fmt.Errorf("failed to parse manifest of '%s': %w", ref, err)
+	}
+	return string(manifest.Config.MediaType), nil
+}
+
 // VerifyChart verifies the chart against a signature.
 // If no signature is provided, a keyless verification is performed.
 // It returns an error on failure.
@@ -18,11 +18,13 @@ package repository
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
 	"net/url"
 	"path"
 	"strings"
 	"testing"
+	"time"
 
 	. "github.com/onsi/gomega"
 	"helm.sh/helm/v3/pkg/chart"
@@ -33,12 +35,16 @@ import (
 
 type OCIMockGetter struct {
 	Response      []byte
+	Err           error
 	LastCalledURL string
 }
 
 func (g *OCIMockGetter) Get(u string, _ ...helmgetter.Option) (*bytes.Buffer, error) {
 	r := g.Response
 	g.LastCalledURL = u
+	if g.Err != nil {
+		return nil, g.Err
+	}
 	return bytes.NewBuffer(r), nil
 }
 
@@ -118,12 +124,13 @@ func TestOCIChartRepository_Get(t *testing.T) {
 	testURL := "oci://localhost:5000/my_repo"
 
 	testCases := []struct {
-		name           string
-		registryClient RegistryClient
-		url            string
-		version        string
-		expected       string
-		expectedErr    string
+		name             string
+		registryClient   RegistryClient
+		url              string
+		version          string
+		ignorePrerelease bool
+		expected         string
+		expectedErr      string
 	}{
 		{
 			name:           "should return latest stable version",
@@ -167,6 +174,29 @@ func TestOCIChartRepository_Get(t *testing.T) {
 			url:            testURL,
 			expected:       "0.10.0",
 		},
+		{
+			name:           "should return a prerelease (semver range)",
+			registryClient: registryClient,
+			version:        ">=0.1.0-0",
+			url:            testURL,
+			expected:       "1.1.0-rc.1",
+		},
+		{
+			name:             "should ignore a prerelease not referenced by the semver range",
+			registryClient:   registryClient,
+			version:          ">=0.1.0-0",
+			ignorePrerelease: true,
+			url:              testURL,
+			expected:         "1.0.0",
+		},
+		{
+			name:             "should return a prerelease referenced by the semver range",
+			registryClient:   registryClient,
+			version:          ">=1.1.0-0",
+			ignorePrerelease: true,
+			url:              testURL,
+			expected:         "1.1.0-rc.1",
+		},
 		{
 			name:           "should an error for unfulfilled range",
 			registryClient: registryClient,
@@ -187,7 +217,11 @@ func TestOCIChartRepository_Get(t *testing.T) {
 
 		t.Run(tc.name, func(t *testing.T) {
 			g := NewWithT(t)
-			r, err := NewOCIChartRepository(tc.url, WithOCIRegistryClient(tc.registryClient), WithOCIGetter(providers))
+			opts := []OCIChartRepositoryOption{WithOCIRegistryClient(tc.registryClient), WithOCIGetter(providers)}
+			if tc.ignorePrerelease {
+				opts = append(opts, WithOCIIgnorePrerelease())
+			}
+			r, err := NewOCIChartRepository(tc.url, opts...)
 			g.Expect(err).ToNot(HaveOccurred())
 			g.Expect(r).ToNot(BeNil())
 
@@ -209,6 +243,57 @@ func TestOCIChartRepository_Get(t *testing.T) {
 	}
 }
 
+func TestOCIChartRepository_GetDigest(t *testing.T) {
+	digest := "sha256:" + strings.Repeat("a", 64)
+
+	testCases := []struct {
+		name        string
+		version     string
+		expectedURL string
+		expectedVer string
+		expectedErr string
+	}{
+		{
+			name:        "should reference the chart by digest",
+			version:     "@" + digest,
+			expectedURL: "oci://localhost:5000/my_repo/podinfo@" + digest,
+		},
+		{
+			name:        "should keep the version of a pinned chart",
+			version:     "1.0.0@" + digest,
+			expectedURL: "oci://localhost:5000/my_repo/podinfo@" + digest,
+			expectedVer: "1.0.0",
+		},
+		{
+			name:        "should error on invalid digest",
+			version:     "1.0.0@sha256:invalid",
+			expectedErr: "invalid digest reference",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			// A nil registry client ensures no tags are listed
+			r, err := NewOCIChartRepository("oci://localhost:5000/my_repo")
+			g.Expect(err).ToNot(HaveOccurred())
+
+			cv, err := r.GetChartVersion("podinfo", tc.version)
+			if tc.expectedErr != "" {
+				g.Expect(err).To(HaveOccurred())
+				g.Expect(err.Error()).To(ContainSubstring(tc.expectedErr))
+				return
+			}
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(cv.URLs).To(Equal([]string{tc.expectedURL}))
+			g.Expect(cv.Name).To(Equal("podinfo"))
+			g.Expect(cv.Version).To(Equal(tc.expectedVer))
+			g.Expect(cv.Digest).To(Equal(digest))
+		})
+	}
+}
+
 func TestOCIChartRepository_DownloadChart(t *testing.T) {
 	client := &mockRegistryClient{}
 	testCases := []struct {
@@ -271,3 +356,41 @@ func TestOCIChartRepository_DownloadChart(t *testing.T) {
 		})
 	}
 }
+
+func TestOCIChartRepository_DownloadChart_recordPull(t *testing.T) {
+	g := NewWithT(t)
+
+	var pulled []int64
+	r, err := NewOCIChartRepository("oci://localhost:5000/my_repo",
+		WithOCIPullRecorder(func(_ time.Time, bytes int64) {
+			pulled = append(pulled, bytes)
+		}))
+	g.Expect(err).ToNot(HaveOccurred())
+	r.Client = &OCIMockGetter{Response: []byte("chart")}
+
+	_, err = r.DownloadChart(&repo.ChartVersion{
+		Metadata: &chart.Metadata{Name: "chart"},
+		URLs:     []string{"oci://localhost:5000/my_repo/podinfo:1.0.0"},
+	})
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(pulled).To(Equal([]int64{5}))
+}
+
+func TestOCIChartRepository_DownloadChart_recordPullFailure(t *testing.T) {
+	g := NewWithT(t)
+
+	var pulled []int64
+	r, err := NewOCIChartRepository("oci://localhost:5000/my_repo",
+		WithOCIPullRecorder(func(_ time.Time, bytes int64) {
+			pulled = append(pulled, bytes)
+		}))
+	g.Expect(err).ToNot(HaveOccurred())
+	r.Client = &OCIMockGetter{Err: errors.New("unauthorized")}
+
+	_, err = r.DownloadChart(&repo.ChartVersion{
+		Metadata: &chart.Metadata{Name: "chart"},
+		URLs:     []string{"oci://localhost:5000/my_repo/podinfo:1.0.0"},
+	})
+	g.Expect(err).To(HaveOccurred())
+	g.Expect(pulled).To(BeEmpty())
+}
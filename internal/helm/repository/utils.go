@@ -25,6 +25,9 @@ import (
 
 const (
 	alias = "@"
+	// digestSeparator separates the version constraint from the digest of
+	// a chart version pinned to an OCI digest, e.g. "1.0.0@sha256:<hex>".
+	digestSeparator = "@"
 )
 
 var (
@@ -35,12 +38,14 @@ var (
 )
 
 // NormalizeURL normalizes a ChartRepository URL by its scheme.
+// OCI repository URLs are returned without a trailing slash, while all other
+// URLs are returned with a single trailing slash.
 func NormalizeURL(repositoryURL string) string {
 	if repositoryURL == "" {
 		return ""
 	}
 
-	if strings.Contains(repositoryURL, helmreg.OCIScheme) {
+	if helmreg.IsOCI(repositoryURL) {
 		return strings.TrimRight(repositoryURL, "/")
 	}
 
@@ -63,3 +68,13 @@ func ValidateDepURL(repositoryURL string) error {
 		return fmt.Errorf("%w: %s", errInvalidDepURL, repositoryURL)
 	}
 }
+
+// SplitVersionDigest splits a chart version of the form "<version>@<digest>"
+// into the version and the digest. If the version is not pinned to a digest,
+// the returned digest is empty.
+func SplitVersionDigest(ver string) (string, string) {
+	if i := strings.LastIndex(ver, digestSeparator); i >= 0 {
+		return ver[:i], ver[i+len(digestSeparator):]
+	}
+	return ver, ""
+}
@@ -58,6 +58,16 @@ func TestNormalizeURL(t *testing.T) {
 			url:  "oci://example.com//",
 			want: "oci://example.com",
 		},
+		{
+			name: "oci with path",
+			url:  "oci://example.com/charts/",
+			want: "oci://example.com/charts",
+		},
+		{
+			name: "http with oci in path",
+			url:  "https://example.com/oci",
+			want: "https://example.com/oci/",
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -68,3 +78,41 @@ func TestNormalizeURL(t *testing.T) {
 		})
 	}
 }
+
+func TestSplitVersionDigest(t *testing.T) {
+	tests := []struct {
+		name        string
+		ver         string
+		wantVersion string
+		wantDigest  string
+	}{
+		{
+			name:        "version",
+			ver:         "1.0.0",
+			wantVersion: "1.0.0",
+		},
+		{
+			name:        "version with digest",
+			ver:         "1.0.0@sha256:0123456789abcdef",
+			wantVersion: "1.0.0",
+			wantDigest:  "sha256:0123456789abcdef",
+		},
+		{
+			name:       "digest only",
+			ver:        "@sha256:0123456789abcdef",
+			wantDigest: "sha256:0123456789abcdef",
+		},
+		{
+			name: "empty",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			ver, digest := SplitVersionDigest(tt.ver)
+			g.Expect(ver).To(Equal(tt.wantVersion))
+			g.Expect(digest).To(Equal(tt.wantDigest))
+		})
+	}
+}
@@ -98,6 +98,36 @@ func GetStatusObservedGeneration(obj runtime.Object) (int64, error) {
 	return og, nil
 }
 
+// GetStatusConsecutiveFailures returns the status.consecutiveFailures of a
+// given runtime object. Since it is an optional field, it's zero when not
+// found.
+func GetStatusConsecutiveFailures(obj runtime.Object) (int64, error) {
+	u, err := toUnstructured(obj)
+	if err != nil {
+		return 0, err
+	}
+	count, _, err := unstructured.NestedInt64(u.Object, "status", "consecutiveFailures")
+	if err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+// SetStatusConsecutiveFailures sets the status.consecutiveFailures value of a
+// given runtime object.
+func SetStatusConsecutiveFailures(obj runtime.Object, val int64) error {
+	content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+	if err != nil {
+		return err
+	}
+	u := unstructured.Unstructured{}
+	u.SetUnstructuredContent(content)
+	if err := unstructured.SetNestedField(u.Object, val, "status", "consecutiveFailures"); err != nil {
+		return err
+	}
+	return runtime.DefaultUnstructuredConverter.FromUnstructured(u.Object, obj)
+}
+
 // GetRequeueInterval returns the spec.interval of a given runtime object, if
 // present.
 func GetRequeueInterval(obj runtime.Object) (time.Duration, error) {
@@ -66,6 +66,33 @@ func TestGetStatusObservedGeneration(t *testing.T) {
 	g.Expect(og).To(Equal(int64(7)))
 }
 
+func TestGetStatusConsecutiveFailures(t *testing.T) {
+	g := NewWithT(t)
+
+	// Get unset status consecutiveFailures.
+	obj := &sourcev1.GitRepository{}
+	count, err := GetStatusConsecutiveFailures(obj)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(count).To(BeZero())
+
+	// Get set status consecutiveFailures.
+	obj.Status.ConsecutiveFailures = 3
+	count, err = GetStatusConsecutiveFailures(obj)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(count).To(Equal(int64(3)))
+}
+
+func TestSetStatusConsecutiveFailures(t *testing.T) {
+	g := NewWithT(t)
+
+	obj := &sourcev1.GitRepository{}
+	g.Expect(SetStatusConsecutiveFailures(obj, 2)).To(Succeed())
+	g.Expect(obj.Status.ConsecutiveFailures).To(Equal(int64(2)))
+
+	g.Expect(SetStatusConsecutiveFailures(obj, 0)).To(Succeed())
+	g.Expect(obj.Status.ConsecutiveFailures).To(BeZero())
+}
+
 func TestGetRequeueInterval(t *testing.T) {
 	g := NewWithT(t)
 
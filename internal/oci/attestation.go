@@ -0,0 +1,124 @@
+/*
+Copyright 2022 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package oci
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+
+	"github.com/sigstore/cosign/pkg/oci"
+)
+
+// AttestationPolicy is the policy a verified in-toto attestation of an OCI
+// artifact has to satisfy.
+type AttestationPolicy struct {
+	// PredicateType is the required in-toto predicate type.
+	PredicateType string
+	// BuilderID is the required ID of the builder in the SLSA provenance
+	// predicate. Any builder is accepted when empty.
+	BuilderID string
+}
+
+// dsseEnvelope is the DSSE envelope in which cosign stores an attestation.
+type dsseEnvelope struct {
+	PayloadType string `json:"payloadType"`
+	Payload     string `json:"payload"`
+}
+
+// intotoStatement is the subset of an in-toto statement evaluated by an
+// AttestationPolicy.
+type intotoStatement struct {
+	PredicateType string `json:"predicateType"`
+	Predicate     struct {
+		// Builder is the builder of a SLSA v0.2 provenance predicate.
+		Builder struct {
+			ID string `json:"id"`
+		} `json:"builder"`
+		// RunDetails holds the builder of a SLSA v1 provenance predicate.
+		RunDetails struct {
+			Builder struct {
+				ID string `json:"id"`
+			} `json:"builder"`
+		} `json:"runDetails"`
+	} `json:"predicate"`
+}
+
+// builderID returns the ID of the builder of the SLSA provenance predicate.
+func (s intotoStatement) builderID() string {
+	if s.Predicate.Builder.ID != "" {
+		return s.Predicate.Builder.ID
+	}
+	return s.Predicate.RunDetails.Builder.ID
+}
+
+// MatchAttestations returns nil if at least one of the given verified
+// attestations satisfies the policy, or an error describing why none did.
+func MatchAttestations(attestations []oci.Signature, policy AttestationPolicy) error {
+	payloads := make([][]byte, 0, len(attestations))
+	for _, att := range attestations {
+		payload, err := att.Payload()
+		if err != nil {
+			return fmt.Errorf("failed to read attestation payload: %w", err)
+		}
+		payloads = append(payloads, payload)
+	}
+	return matchAttestationPayloads(payloads, policy)
+}
+
+// matchAttestationPayloads returns nil if at least one of the given DSSE
+// envelopes holds an in-toto statement which satisfies the policy.
+func matchAttestationPayloads(payloads [][]byte, policy AttestationPolicy) error {
+	var builders []string
+	for _, payload := range payloads {
+		statement, err := decodeStatement(payload)
+		if err != nil {
+			return err
+		}
+		if statement.PredicateType != policy.PredicateType {
+			continue
+		}
+		if policy.BuilderID == "" || statement.builderID() == policy.BuilderID {
+			return nil
+		}
+		builders = append(builders, statement.builderID())
+	}
+
+	if len(builders) > 0 {
+		return fmt.Errorf("no '%s' attestation with builder ID '%s' found, got builder IDs %q",
+			policy.PredicateType, policy.BuilderID, builders)
+	}
+	return fmt.Errorf("no '%s' attestation found", policy.PredicateType)
+}
+
+// decodeStatement decodes the in-toto statement from the given DSSE envelope.
+func decodeStatement(payload []byte) (intotoStatement, error) {
+	var statement intotoStatement
+
+	var envelope dsseEnvelope
+	if err := json.Unmarshal(payload, &envelope); err != nil {
+		return statement, fmt.Errorf("failed to decode attestation envelope: %w", err)
+	}
+	data, err := base64.StdEncoding.DecodeString(envelope.Payload)
+	if err != nil {
+		return statement, fmt.Errorf("failed to decode attestation payload: %w", err)
+	}
+	if err := json.Unmarshal(data, &statement); err != nil {
+		return statement, fmt.Errorf("failed to decode in-toto statement: %w", err)
+	}
+	return statement, nil
+}
@@ -0,0 +1,112 @@
+/*
+Copyright 2022 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package oci
+
+import (
+	"encoding/base64"
+	"fmt"
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+const slsaProvenance = "https://slsa.dev/provenance/v0.2"
+
+func envelope(statement string) []byte {
+	return []byte(fmt.Sprintf(`{"payloadType":"application/vnd.in-toto+json","payload":"%s"}`,
+		base64.StdEncoding.EncodeToString([]byte(statement))))
+}
+
+func Test_matchAttestationPayloads(t *testing.T) {
+	provenance := envelope(`{"_type":"https://in-toto.io/Statement/v0.1","predicateType":"https://slsa.dev/provenance/v0.2","predicate":{"builder":{"id":"https://github.com/actions/runner"}}}`)
+	provenanceV1 := envelope(`{"_type":"https://in-toto.io/Statement/v1","predicateType":"https://slsa.dev/provenance/v1","predicate":{"runDetails":{"builder":{"id":"https://github.com/actions/runner"}}}}`)
+	vuln := envelope(`{"_type":"https://in-toto.io/Statement/v0.1","predicateType":"https://cosign.sigstore.dev/attestation/vuln/v1","predicate":{}}`)
+
+	tests := []struct {
+		name     string
+		payloads [][]byte
+		policy   AttestationPolicy
+		wantErr  string
+	}{
+		{
+			name:     "predicate type matches",
+			payloads: [][]byte{vuln, provenance},
+			policy:   AttestationPolicy{PredicateType: slsaProvenance},
+		},
+		{
+			name:     "builder ID matches",
+			payloads: [][]byte{provenance},
+			policy: AttestationPolicy{
+				PredicateType: slsaProvenance,
+				BuilderID:     "https://github.com/actions/runner",
+			},
+		},
+		{
+			name:     "SLSA v1 builder ID matches",
+			payloads: [][]byte{provenanceV1},
+			policy: AttestationPolicy{
+				PredicateType: "https://slsa.dev/provenance/v1",
+				BuilderID:     "https://github.com/actions/runner",
+			},
+		},
+		{
+			name:     "builder ID mismatch",
+			payloads: [][]byte{provenance},
+			policy: AttestationPolicy{
+				PredicateType: slsaProvenance,
+				BuilderID:     "https://example.com/builder",
+			},
+			wantErr: "no 'https://slsa.dev/provenance/v0.2' attestation with builder ID 'https://example.com/builder' found, got builder IDs [\"https://github.com/actions/runner\"]",
+		},
+		{
+			name:     "predicate type mismatch",
+			payloads: [][]byte{vuln},
+			policy:   AttestationPolicy{PredicateType: slsaProvenance},
+			wantErr:  "no 'https://slsa.dev/provenance/v0.2' attestation found",
+		},
+		{
+			name:    "no attestations",
+			policy:  AttestationPolicy{PredicateType: slsaProvenance},
+			wantErr: "no 'https://slsa.dev/provenance/v0.2' attestation found",
+		},
+		{
+			name:     "invalid envelope",
+			payloads: [][]byte{[]byte("invalid")},
+			policy:   AttestationPolicy{PredicateType: slsaProvenance},
+			wantErr:  "failed to decode attestation envelope",
+		},
+		{
+			name:     "invalid payload",
+			payloads: [][]byte{[]byte(`{"payload":"!"}`)},
+			policy:   AttestationPolicy{PredicateType: slsaProvenance},
+			wantErr:  "failed to decode attestation payload",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			err := matchAttestationPayloads(tt.payloads, tt.policy)
+			if tt.wantErr != "" {
+				g.Expect(err).To(HaveOccurred())
+				g.Expect(err.Error()).To(ContainSubstring(tt.wantErr))
+				return
+			}
+			g.Expect(err).ToNot(HaveOccurred())
+		})
+	}
+}
@@ -16,7 +16,19 @@ limitations under the License.
 
 package oci
 
-import "github.com/google/go-containerregistry/pkg/authn"
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/v1/remote/transport"
+)
 
 // Anonymous is an authn.AuthConfig that always returns an anonymous
 // authenticator. It is useful for registries that do not require authentication
@@ -28,3 +40,132 @@ type Anonymous authn.AuthConfig
 func (a Anonymous) Resolve(_ authn.Resource) (authn.Authenticator, error) {
 	return authn.Anonymous, nil
 }
+
+// RefreshingAuthenticator is an authn.Authenticator delegating to an
+// authenticator which can be replaced with freshly acquired credentials, e.g.
+// when a short-lived cloud provider token expired during a long-running
+// operation.
+type RefreshingAuthenticator struct {
+	mu      sync.RWMutex
+	auth    authn.Authenticator
+	refresh func() (authn.Authenticator, error)
+}
+
+// NewRefreshingAuthenticator returns a RefreshingAuthenticator delegating to
+// the given authenticator, until refreshed with the authenticator returned by
+// the given refresh function.
+func NewRefreshingAuthenticator(auth authn.Authenticator, refresh func() (authn.Authenticator, error)) *RefreshingAuthenticator {
+	return &RefreshingAuthenticator{auth: auth, refresh: refresh}
+}
+
+// Authorization implements authn.Authenticator.
+func (a *RefreshingAuthenticator) Authorization() (*authn.AuthConfig, error) {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	return a.auth.Authorization()
+}
+
+// Refresh acquires new credentials using the refresh function, and uses them
+// for subsequent authorizations.
+func (a *RefreshingAuthenticator) Refresh() error {
+	auth, err := a.refresh()
+	if err != nil {
+		return err
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.auth = auth
+	return nil
+}
+
+// IsUnauthorized returns if the error is caused by a registry responding
+// with 401 Unauthorized.
+func IsUnauthorized(err error) bool {
+	var terr *transport.Error
+	return errors.As(err, &terr) && terr.StatusCode == http.StatusUnauthorized
+}
+
+// RetryOnUnauthorized calls fn, and if it fails because the registry rejected
+// the credentials of the given RefreshingAuthenticator, refreshes them and
+// calls fn once more. For any other authenticator, the error of fn is
+// returned as is.
+func RetryOnUnauthorized(auth authn.Authenticator, fn func() error) error {
+	err := fn()
+	ra, ok := auth.(*RefreshingAuthenticator)
+	if err == nil || !ok || !IsUnauthorized(err) {
+		return err
+	}
+	if rerr := ra.Refresh(); rerr != nil {
+		return fmt.Errorf("%w, failed to refresh credentials: %s", err, rerr)
+	}
+	return fn()
+}
+
+// CredentialExpiry returns the expiry time of the credentials of the given
+// authenticator, as far as it can be determined from the credentials. It
+// supports JSON Web Tokens with an 'exp' claim (e.g. Azure Container Registry
+// refresh tokens), and AWS Elastic Container Registry authorization tokens.
+// The zero time is returned if the expiry is unknown.
+func CredentialExpiry(auth authn.Authenticator) (time.Time, error) {
+	cfg, err := auth.Authorization()
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	password := cfg.Password
+	if password == "" && cfg.Auth != "" {
+		if b, err := base64.StdEncoding.DecodeString(cfg.Auth); err == nil {
+			if _, p, ok := strings.Cut(string(b), ":"); ok {
+				password = p
+			}
+		}
+	}
+
+	for _, token := range []string{cfg.IdentityToken, cfg.RegistryToken, password} {
+		if token == "" {
+			continue
+		}
+		if exp, ok := jwtExpiry(token); ok {
+			return exp, nil
+		}
+		if exp, ok := ecrTokenExpiry(token); ok {
+			return exp, nil
+		}
+	}
+	return time.Time{}, nil
+}
+
+// jwtExpiry returns the time of the 'exp' claim of the given JSON Web Token.
+func jwtExpiry(token string) (time.Time, bool) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return time.Time{}, false
+	}
+	b, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(parts[1], "="))
+	if err != nil {
+		return time.Time{}, false
+	}
+	var claims struct {
+		Exp float64 `json:"exp"`
+	}
+	if err := json.Unmarshal(b, &claims); err != nil || claims.Exp <= 0 {
+		return time.Time{}, false
+	}
+	return time.Unix(int64(claims.Exp), 0), true
+}
+
+// ecrTokenExpiry returns the expiration of the given AWS ECR authorization
+// token password, which is a base64 encoded JSON document.
+func ecrTokenExpiry(token string) (time.Time, bool) {
+	b, err := base64.StdEncoding.DecodeString(token)
+	if err != nil {
+		return time.Time{}, false
+	}
+	var doc struct {
+		Expiration float64 `json:"expiration"`
+	}
+	if err := json.Unmarshal(b, &doc); err != nil || doc.Expiration <= 0 {
+		return time.Time{}, false
+	}
+	return time.Unix(int64(doc.Expiration), 0), true
+}
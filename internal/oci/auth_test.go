@@ -0,0 +1,226 @@
+/*
+Copyright 2022 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package oci
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/crane"
+	"github.com/google/go-containerregistry/pkg/registry"
+	gcrv1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/random"
+	. "github.com/onsi/gomega"
+)
+
+// expiringTokenRegistry is a registry which only accepts requests
+// authenticated with the current token, simulating the expiry of short-lived
+// cloud provider tokens.
+type expiringTokenRegistry struct {
+	mu      sync.Mutex
+	token   string
+	handler http.Handler
+}
+
+func (r *expiringTokenRegistry) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	r.mu.Lock()
+	token := r.token
+	r.mu.Unlock()
+
+	if _, password, ok := req.BasicAuth(); !ok || password != token {
+		w.Header().Set("WWW-Authenticate", `Basic realm="test"`)
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	r.handler.ServeHTTP(w, req)
+}
+
+// expire invalidates the current token in favour of the given one.
+func (r *expiringTokenRegistry) expire(token string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.token = token
+}
+
+func TestRetryOnUnauthorized(t *testing.T) {
+	tests := []struct {
+		name          string
+		refreshToken  string
+		refreshErr    error
+		plainAuth     bool
+		expireAfter   bool
+		wantRefreshes int
+		wantErr       string
+	}{
+		{
+			name:          "refreshes expired token and retries pull",
+			refreshToken:  "token-2",
+			wantRefreshes: 1,
+		},
+		{
+			name:          "refreshes token expired during pull and retries layer fetch",
+			refreshToken:  "token-2",
+			expireAfter:   true,
+			wantRefreshes: 1,
+		},
+		{
+			name:          "retries only once",
+			refreshToken:  "token-1",
+			wantRefreshes: 1,
+			wantErr:       "401 Unauthorized",
+		},
+		{
+			name:          "refresh failure",
+			refreshErr:    errors.New("token endpoint unavailable"),
+			wantRefreshes: 1,
+			wantErr:       "failed to refresh credentials: token endpoint unavailable",
+		},
+		{
+			name:      "does not refresh plain authenticator",
+			plainAuth: true,
+			wantErr:   "401 Unauthorized",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			reg := &expiringTokenRegistry{token: "token-1", handler: registry.New()}
+			srv := httptest.NewServer(reg)
+			t.Cleanup(srv.Close)
+
+			ref := fmt.Sprintf("%s/podinfo:latest", strings.TrimPrefix(srv.URL, "http://"))
+			img, err := random.Image(1024, 1)
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(crane.Push(img, ref, crane.Insecure,
+				crane.WithAuth(&authn.Basic{Username: "flux", Password: "token-1"}))).To(Succeed())
+
+			var refreshes int
+			var auth authn.Authenticator = NewRefreshingAuthenticator(&authn.Basic{Username: "flux", Password: "token-1"},
+				func() (authn.Authenticator, error) {
+					refreshes++
+					if tt.refreshErr != nil {
+						return nil, tt.refreshErr
+					}
+					return &authn.Basic{Username: "flux", Password: tt.refreshToken}, nil
+				})
+			if tt.plainAuth {
+				auth = &authn.Basic{Username: "flux", Password: "token-1"}
+			}
+			opts := []crane.Option{crane.Insecure, crane.WithAuth(auth)}
+
+			if !tt.expireAfter {
+				reg.expire("token-2")
+			}
+			var pulled gcrv1.Image
+			err = RetryOnUnauthorized(auth, func() (err error) {
+				pulled, err = crane.Pull(ref, opts...)
+				return
+			})
+			if err == nil {
+				if tt.expireAfter {
+					reg.expire("token-2")
+				}
+				err = RetryOnUnauthorized(auth, func() error {
+					layers, err := pulled.Layers()
+					if err != nil {
+						return err
+					}
+					rc, err := layers[0].Compressed()
+					if err != nil {
+						return err
+					}
+					defer rc.Close()
+					_, err = io.Copy(io.Discard, rc)
+					return err
+				})
+			}
+
+			g.Expect(refreshes).To(Equal(tt.wantRefreshes))
+			if tt.wantErr != "" {
+				g.Expect(err).To(HaveOccurred())
+				g.Expect(IsUnauthorized(err)).To(BeTrue())
+				g.Expect(err.Error()).To(ContainSubstring(tt.wantErr))
+				return
+			}
+			g.Expect(err).ToNot(HaveOccurred())
+		})
+	}
+}
+
+func TestCredentialExpiry(t *testing.T) {
+	exp := time.Unix(1700000000, 0)
+	jwt := strings.Join([]string{
+		base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"RS256","typ":"JWT"}`)),
+		base64.RawURLEncoding.EncodeToString([]byte(fmt.Sprintf(`{"sub":"flux","exp":%d}`, exp.Unix()))),
+		"signature",
+	}, ".")
+	ecrToken := base64.StdEncoding.EncodeToString([]byte(fmt.Sprintf(`{"payload":"p","datakey":"d","version":"2","type":"DATA_KEY","expiration":%d}`, exp.Unix())))
+
+	tests := []struct {
+		name string
+		auth authn.Authenticator
+		want time.Time
+	}{
+		{
+			name: "anonymous",
+			auth: authn.Anonymous,
+		},
+		{
+			name: "static password",
+			auth: &authn.Basic{Username: "flux", Password: "password"},
+		},
+		{
+			name: "JWT password",
+			auth: &authn.Basic{Username: "00000000-0000-0000-0000-000000000000", Password: jwt},
+			want: exp,
+		},
+		{
+			name: "JWT identity token",
+			auth: authn.FromConfig(authn.AuthConfig{IdentityToken: jwt}),
+			want: exp,
+		},
+		{
+			name: "JWT in encoded auth",
+			auth: authn.FromConfig(authn.AuthConfig{Auth: base64.StdEncoding.EncodeToString([]byte("flux:" + jwt))}),
+			want: exp,
+		},
+		{
+			name: "ECR authorization token",
+			auth: &authn.Basic{Username: "AWS", Password: ecrToken},
+			want: exp,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			got, err := CredentialExpiry(tt.auth)
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(got.Equal(tt.want)).To(BeTrue(), "got %s, want %s", got, tt.want)
+		})
+	}
+}
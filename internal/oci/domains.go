@@ -0,0 +1,67 @@
+/*
+Copyright 2022 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package oci
+
+import (
+	"net"
+	"strings"
+
+	"github.com/google/go-containerregistry/pkg/name"
+)
+
+// DomainAllowlist is the list of registry domains from which artifacts are
+// allowed to be pulled. An entry prefixed with "*." matches any subdomain of
+// the domain, and an entry with a port only matches the host with this port.
+// An empty list allows any domain.
+type DomainAllowlist []string
+
+// Allows returns true if the given registry host is allowed.
+func (l DomainAllowlist) Allows(host string) bool {
+	if len(l) == 0 {
+		return true
+	}
+
+	host = strings.ToLower(host)
+	hostname := host
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		hostname = h
+	}
+	// The registry of Docker Hub images is normalized to its API host
+	if hostname == name.DefaultRegistry {
+		hostname = "docker.io"
+	}
+
+	for _, domain := range l {
+		domain = strings.ToLower(strings.TrimSpace(domain))
+		switch {
+		case domain == "":
+			continue
+		case strings.HasPrefix(domain, "*."):
+			if strings.HasSuffix(hostname, domain[1:]) {
+				return true
+			}
+		case domain == host || domain == hostname:
+			return true
+		}
+	}
+	return false
+}
+
+// String returns the comma separated list of domains.
+func (l DomainAllowlist) String() string {
+	return strings.Join(l, ",")
+}
@@ -0,0 +1,106 @@
+/*
+Copyright 2022 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package oci
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestDomainAllowlist_Allows(t *testing.T) {
+	tests := []struct {
+		name    string
+		domains DomainAllowlist
+		host    string
+		want    bool
+	}{
+		{
+			name: "empty list allows all",
+			host: "registry.example.com",
+			want: true,
+		},
+		{
+			name:    "exact domain",
+			domains: DomainAllowlist{"ghcr.io", "registry.example.com"},
+			host:    "registry.example.com",
+			want:    true,
+		},
+		{
+			name:    "domain is case insensitive",
+			domains: DomainAllowlist{"GHCR.io"},
+			host:    "ghcr.io",
+			want:    true,
+		},
+		{
+			name:    "domain matches any port",
+			domains: DomainAllowlist{"registry.example.com"},
+			host:    "registry.example.com:5000",
+			want:    true,
+		},
+		{
+			name:    "domain with port",
+			domains: DomainAllowlist{"localhost:5000"},
+			host:    "localhost:5000",
+			want:    true,
+		},
+		{
+			name:    "domain with other port",
+			domains: DomainAllowlist{"localhost:5000"},
+			host:    "localhost:5001",
+		},
+		{
+			name:    "wildcard subdomain",
+			domains: DomainAllowlist{"*.example.com"},
+			host:    "registry.eu.example.com",
+			want:    true,
+		},
+		{
+			name:    "wildcard does not match the domain",
+			domains: DomainAllowlist{"*.example.com"},
+			host:    "example.com",
+		},
+		{
+			name:    "wildcard does not match a domain suffix",
+			domains: DomainAllowlist{"*.example.com"},
+			host:    "registry.badexample.com",
+		},
+		{
+			name:    "Docker Hub",
+			domains: DomainAllowlist{"docker.io"},
+			host:    "index.docker.io",
+			want:    true,
+		},
+		{
+			name:    "domain not allowed",
+			domains: DomainAllowlist{"ghcr.io"},
+			host:    "registry.example.com",
+		},
+		{
+			name:    "empty entries are ignored",
+			domains: DomainAllowlist{""},
+			host:    "registry.example.com",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			g.Expect(tt.domains.Allows(tt.host)).To(Equal(tt.want))
+		})
+	}
+}
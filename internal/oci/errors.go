@@ -0,0 +1,71 @@
+/*
+Copyright 2022 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package oci
+
+import (
+	"errors"
+	"net"
+	"net/http"
+
+	"github.com/google/go-containerregistry/pkg/v1/remote/transport"
+)
+
+// IsAuthenticationError returns if the error is caused by a registry
+// rejecting the credentials of a request, or the absence of them, by
+// responding with 401 Unauthorized or 403 Forbidden.
+func IsAuthenticationError(err error) bool {
+	return hasTransportError(err, []int{http.StatusUnauthorized, http.StatusForbidden},
+		transport.UnauthorizedErrorCode, transport.DeniedErrorCode)
+}
+
+// IsNotFound returns if the error is caused by a registry responding that
+// the requested repository, manifest or blob does not exist.
+func IsNotFound(err error) bool {
+	return hasTransportError(err, []int{http.StatusNotFound},
+		transport.NameUnknownErrorCode, transport.ManifestUnknownErrorCode, transport.BlobUnknownErrorCode)
+}
+
+// IsNetworkError returns if the error is caused by a failure to reach the
+// registry, e.g. the resolution of its host name or a refused connection.
+// Timeouts of requests which reached the registry are not network errors.
+func IsNetworkError(err error) bool {
+	var dnsErr *net.DNSError
+	var opErr *net.OpError
+	return errors.As(err, &dnsErr) || errors.As(err, &opErr)
+}
+
+// hasTransportError returns if the error is a transport.Error with one of the
+// given HTTP status codes, or with a diagnostic with one of the given codes.
+func hasTransportError(err error, statusCodes []int, codes ...transport.ErrorCode) bool {
+	var terr *transport.Error
+	if !errors.As(err, &terr) {
+		return false
+	}
+	for _, sc := range statusCodes {
+		if terr.StatusCode == sc {
+			return true
+		}
+	}
+	for _, d := range terr.Errors {
+		for _, c := range codes {
+			if d.Code == c {
+				return true
+			}
+		}
+	}
+	return false
+}
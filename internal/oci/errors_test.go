@@ -0,0 +1,98 @@
+/*
+Copyright 2022 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package oci
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/google/go-containerregistry/pkg/v1/remote/transport"
+	. "github.com/onsi/gomega"
+)
+
+func TestErrorClassification(t *testing.T) {
+	tests := []struct {
+		name             string
+		err              error
+		wantAuth         bool
+		wantNotFound     bool
+		wantNetworkError bool
+	}{
+		{
+			name:     "unauthorized",
+			err:      fmt.Errorf("failed: %w", &transport.Error{StatusCode: http.StatusUnauthorized}),
+			wantAuth: true,
+		},
+		{
+			name: "denied diagnostic",
+			err: &transport.Error{StatusCode: http.StatusBadRequest, Errors: []transport.Diagnostic{
+				{Code: transport.DeniedErrorCode},
+			}},
+			wantAuth: true,
+		},
+		{
+			name:     "forbidden",
+			err:      &transport.Error{StatusCode: http.StatusForbidden},
+			wantAuth: true,
+		},
+		{
+			name:         "not found",
+			err:          &transport.Error{StatusCode: http.StatusNotFound},
+			wantNotFound: true,
+		},
+		{
+			name: "manifest unknown diagnostic",
+			err: &transport.Error{StatusCode: http.StatusBadRequest, Errors: []transport.Diagnostic{
+				{Code: transport.ManifestUnknownErrorCode},
+			}},
+			wantNotFound: true,
+		},
+		{
+			name: "DNS error",
+			err: &url.Error{Op: "Get", URL: "https://registry.invalid/v2/", Err: &net.OpError{
+				Op: "dial", Net: "tcp", Err: &net.DNSError{Err: "no such host", Name: "registry.invalid"},
+			}},
+			wantNetworkError: true,
+		},
+		{
+			name:             "connection refused",
+			err:              &url.Error{Op: "Get", URL: "https://127.0.0.1:1/v2/", Err: &net.OpError{Op: "dial", Net: "tcp"}},
+			wantNetworkError: true,
+		},
+		{
+			name: "too many requests",
+			err:  &transport.Error{StatusCode: http.StatusTooManyRequests},
+		},
+		{
+			name: "other error",
+			err:  errors.New("failed"),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			g.Expect(IsAuthenticationError(tt.err)).To(Equal(tt.wantAuth))
+			g.Expect(IsNotFound(tt.err)).To(Equal(tt.wantNotFound))
+			g.Expect(IsNetworkError(tt.err)).To(Equal(tt.wantNetworkError))
+		})
+	}
+}
@@ -0,0 +1,159 @@
+/*
+Copyright 2022 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package oci
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+const (
+	// OperationPull is the operation type for pulling an artifact.
+	OperationPull = "pull"
+	// OperationVerify is the operation type for verifying the signature of
+	// an artifact.
+	OperationVerify = "verify"
+
+	// OtherHost is the host label value of the operations against a registry
+	// which is not tracked.
+	OtherHost = "other"
+)
+
+// RegistryRecorder is a recorder for operations against OCI registries.
+type RegistryRecorder struct {
+	// hosts is the allowlist of registry hosts to partition the metrics by.
+	hosts map[string]struct{}
+
+	// operationsCounter is a counter for operations.
+	operationsCounter *prometheus.CounterVec
+	// durationHistogram is a histogram of the duration of operations.
+	durationHistogram *prometheus.HistogramVec
+	// pullDurationHistogram is a histogram of the duration of the pulls of
+	// the artifacts of objects.
+	pullDurationHistogram *prometheus.HistogramVec
+	// pullBytesCounter is a counter for the bytes downloaded by the pulls of
+	// the artifacts of objects.
+	pullBytesCounter *prometheus.CounterVec
+}
+
+// NewRegistryRecorder returns a new RegistryRecorder, which partitions the
+// metrics by the given registry hosts.
+// The configured labels of the counter are: operation, host, success.
+// The configured labels of the histogram are: operation, host.
+// The configured labels of the pull duration histogram and the pull bytes
+// counter are: kind, name, namespace.
+// The operation is one of:
+//   - "pull"
+//   - "verify"
+//
+// The host is the registry host if it is one of the given hosts, "other" if
+// it is not, or empty if no hosts are given. In which case the metrics are
+// not partitioned by host.
+func NewRegistryRecorder(hosts []string) *RegistryRecorder {
+	r := &RegistryRecorder{
+		hosts: make(map[string]struct{}, len(hosts)),
+		operationsCounter: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "gotk_oci_operations_total",
+				Help: "Total number of operations against OCI registries.",
+			},
+			[]string{"operation", "host", "success"},
+		),
+		durationHistogram: prometheus.NewHistogramVec(
+			prometheus.HistogramOpts{
+				Name:    "gotk_oci_operation_duration_seconds",
+				Help:    "The duration in seconds of operations against OCI registries.",
+				Buckets: prometheus.ExponentialBuckets(10e-3, 2, 12),
+			},
+			[]string{"operation", "host"},
+		),
+		pullDurationHistogram: prometheus.NewHistogramVec(
+			prometheus.HistogramOpts{
+				Name:    "gotk_oci_pull_duration_seconds",
+				Help:    "The duration in seconds of the pulls of the artifacts of objects from OCI registries.",
+				Buckets: prometheus.ExponentialBuckets(10e-3, 2, 14),
+			},
+			[]string{"kind", "name", "namespace"},
+		),
+		pullBytesCounter: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "gotk_oci_pull_bytes_total",
+				Help: "Total number of bytes downloaded by the pulls of the artifacts of objects from OCI registries.",
+			},
+			[]string{"kind", "name", "namespace"},
+		),
+	}
+	for _, h := range hosts {
+		if h != "" {
+			r.hosts[h] = struct{}{}
+		}
+	}
+	return r
+}
+
+// Collectors returns the metrics.Collector objects for the RegistryRecorder.
+func (r *RegistryRecorder) Collectors() []prometheus.Collector {
+	return []prometheus.Collector{
+		r.operationsCounter,
+		r.durationHistogram,
+		r.pullDurationHistogram,
+		r.pullBytesCounter,
+	}
+}
+
+// HostLabel returns the host label value for the given registry host.
+func (r *RegistryRecorder) HostLabel(host string) string {
+	if len(r.hosts) == 0 {
+		return ""
+	}
+	if _, ok := r.hosts[host]; ok {
+		return host
+	}
+	return OtherHost
+}
+
+// RecordOperation records the outcome and the duration since the given start
+// time of the given operation against the given registry host.
+func (r *RegistryRecorder) RecordOperation(operation, host string, start time.Time, err error) {
+	label := r.HostLabel(host)
+	success := "true"
+	if err != nil {
+		success = "false"
+	}
+	r.operationsCounter.WithLabelValues(operation, label, success).Inc()
+	r.durationHistogram.WithLabelValues(operation, label).Observe(time.Since(start).Seconds())
+}
+
+// RecordPull records the duration since the given start time and the number
+// of bytes downloaded by the pull of the artifact of the object with the given
+// kind, name and namespace.
+func (r *RegistryRecorder) RecordPull(kind, name, namespace string, start time.Time, bytes int64) {
+	r.pullDurationHistogram.WithLabelValues(kind, name, namespace).Observe(time.Since(start).Seconds())
+	r.pullBytesCounter.WithLabelValues(kind, name, namespace).Add(float64(bytes))
+}
+
+// MustMakeRegistryMetrics creates a new RegistryRecorder for the given
+// registry hosts, and registers the metrics collectors in the
+// controller-runtime metrics registry.
+func MustMakeRegistryMetrics(hosts []string) *RegistryRecorder {
+	r := NewRegistryRecorder(hosts)
+	metrics.Registry.MustRegister(r.Collectors()...)
+
+	return r
+}
@@ -0,0 +1,82 @@
+/*
+Copyright 2022 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package oci
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestRegistryRecorder_RecordOperation(t *testing.T) {
+	tests := []struct {
+		name      string
+		hosts     []string
+		host      string
+		err       error
+		wantLabel string
+		wantOK    string
+	}{
+		{
+			name:      "not partitioned without hosts",
+			host:      "ghcr.io",
+			wantLabel: "",
+			wantOK:    "true",
+		},
+		{
+			name:      "tracked host",
+			hosts:     []string{"ghcr.io", "docker.io"},
+			host:      "ghcr.io",
+			wantLabel: "ghcr.io",
+			wantOK:    "true",
+		},
+		{
+			name:      "untracked host",
+			hosts:     []string{"ghcr.io"},
+			host:      "registry.example.com",
+			err:       errors.New("unauthorized"),
+			wantLabel: OtherHost,
+			wantOK:    "false",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			r := NewRegistryRecorder(tt.hosts)
+			g.Expect(r.HostLabel(tt.host)).To(Equal(tt.wantLabel))
+
+			r.RecordOperation(OperationPull, tt.host, time.Now(), tt.err)
+			g.Expect(testutil.ToFloat64(r.operationsCounter.WithLabelValues(OperationPull, tt.wantLabel, tt.wantOK))).To(Equal(float64(1)))
+			g.Expect(testutil.CollectAndCount(r.durationHistogram)).To(Equal(1))
+		})
+	}
+}
+
+func TestRegistryRecorder_RecordPull(t *testing.T) {
+	g := NewWithT(t)
+
+	r := NewRegistryRecorder(nil)
+	r.RecordPull("OCIRepository", "podinfo", "default", time.Now(), 1024)
+	r.RecordPull("OCIRepository", "podinfo", "default", time.Now(), 512)
+
+	g.Expect(testutil.ToFloat64(r.pullBytesCounter.WithLabelValues("OCIRepository", "podinfo", "default"))).To(Equal(float64(1536)))
+	g.Expect(testutil.CollectAndCount(r.pullDurationHistogram)).To(Equal(1))
+}
@@ -0,0 +1,93 @@
+/*
+Copyright 2022 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package oci
+
+import (
+	"fmt"
+
+	"github.com/google/go-containerregistry/pkg/name"
+)
+
+// Mirrors maps the hosts of registries to the hosts of their mirrors, for
+// example a pull-through cache. The zero value and nil have no mirrors
+// configured.
+type Mirrors struct {
+	// mirrors maps the upstream registry hosts to their mirror hosts.
+	mirrors map[string]string
+	// upstreams maps the mirror hosts to their upstream registry hosts, to
+	// detect a mirror configured for more than one registry.
+	upstreams map[string]string
+}
+
+// NewMirrors returns Mirrors for the given map of upstream registry hosts to
+// mirror hosts. The hosts are normalized, e.g. 'docker.io' equals
+// 'index.docker.io'. It returns an error if a host is invalid, or if a
+// registry or mirror is configured more than once.
+func NewMirrors(m map[string]string) (*Mirrors, error) {
+	mirrors := &Mirrors{
+		mirrors:   make(map[string]string, len(m)),
+		upstreams: make(map[string]string, len(m)),
+	}
+	for upstream, mirror := range m {
+		u, err := name.NewRegistry(upstream)
+		if err != nil {
+			return nil, fmt.Errorf("invalid registry host '%s': %w", upstream, err)
+		}
+		mr, err := name.NewRegistry(mirror)
+		if err != nil {
+			return nil, fmt.Errorf("invalid mirror host '%s' for registry '%s': %w", mirror, upstream, err)
+		}
+		if other, ok := mirrors.mirrors[u.RegistryStr()]; ok {
+			return nil, fmt.Errorf("registry '%s' is configured with both mirror '%s' and '%s'", u.RegistryStr(), other, mr.RegistryStr())
+		}
+		if other, ok := mirrors.upstreams[mr.RegistryStr()]; ok {
+			return nil, fmt.Errorf("mirror '%s' is configured for both registry '%s' and '%s'", mirror, other, u.RegistryStr())
+		}
+		mirrors.mirrors[u.RegistryStr()] = mr.RegistryStr()
+		mirrors.upstreams[mr.RegistryStr()] = u.RegistryStr()
+	}
+	return mirrors, nil
+}
+
+// Mirror returns the given artifact URL with the registry host replaced by
+// the host of its mirror, and true if a mirror is configured for the
+// registry.
+func (m *Mirrors) Mirror(url string) (string, bool) {
+	if m == nil {
+		return "", false
+	}
+	return rewriteRegistry(url, m.mirrors)
+}
+
+// rewriteRegistry replaces the registry host of the given artifact URL with
+// the host it maps to in hosts, if any.
+func rewriteRegistry(url string, hosts map[string]string) (string, bool) {
+	ref, err := name.ParseReference(url)
+	if err != nil {
+		return "", false
+	}
+	host, ok := hosts[ref.Context().RegistryStr()]
+	if !ok {
+		return "", false
+	}
+
+	repo := fmt.Sprintf("%s/%s", host, ref.Context().RepositoryStr())
+	if _, ok := ref.(name.Digest); ok {
+		return fmt.Sprintf("%s@%s", repo, ref.Identifier()), true
+	}
+	return fmt.Sprintf("%s:%s", repo, ref.Identifier()), true
+}
@@ -0,0 +1,128 @@
+/*
+Copyright 2022 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package oci
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestNewMirrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		mirrors map[string]string
+		wantErr string
+	}{
+		{
+			name:    "valid mirrors",
+			mirrors: map[string]string{"docker.io": "mirror.example.com", "ghcr.io": "mirror.example.com:5000"},
+		},
+		{
+			name:    "invalid registry host",
+			mirrors: map[string]string{"ghcr.io/org": "mirror.example.com"},
+			wantErr: "invalid registry host 'ghcr.io/org'",
+		},
+		{
+			name:    "invalid mirror host",
+			mirrors: map[string]string{"ghcr.io": "https://mirror.example.com"},
+			wantErr: "invalid mirror host 'https://mirror.example.com'",
+		},
+		{
+			name:    "mirror of the same registry configured twice",
+			mirrors: map[string]string{"docker.io": "mirror.example.com", "index.docker.io": "other.example.com"},
+			wantErr: "registry 'index.docker.io' is configured with both mirror",
+		},
+		{
+			name:    "mirror configured for multiple registries",
+			mirrors: map[string]string{"docker.io": "mirror.example.com", "ghcr.io": "mirror.example.com"},
+			wantErr: "is configured for both registry",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			_, err := NewMirrors(tt.mirrors)
+			if tt.wantErr != "" {
+				g.Expect(err).To(HaveOccurred())
+				g.Expect(err.Error()).To(ContainSubstring(tt.wantErr))
+				return
+			}
+			g.Expect(err).ToNot(HaveOccurred())
+		})
+	}
+}
+
+func TestMirrors_Mirror(t *testing.T) {
+	m, err := NewMirrors(map[string]string{
+		"docker.io": "mirror.example.com",
+		"ghcr.io":   "localhost:5000",
+	})
+	NewWithT(t).Expect(err).ToNot(HaveOccurred())
+
+	tests := []struct {
+		name       string
+		url        string
+		wantMirror string
+		wantOK     bool
+	}{
+		{
+			name:       "tag",
+			url:        "ghcr.io/stefanprodan/manifests/podinfo:6.1.6",
+			wantMirror: "localhost:5000/stefanprodan/manifests/podinfo:6.1.6",
+			wantOK:     true,
+		},
+		{
+			name:       "digest",
+			url:        "ghcr.io/stefanprodan/manifests/podinfo@sha256:4e5cbb7b97d00a8039b8810b90b922f4256fd3bd8f78b934b4892dae13f7ca87",
+			wantMirror: "localhost:5000/stefanprodan/manifests/podinfo@sha256:4e5cbb7b97d00a8039b8810b90b922f4256fd3bd8f78b934b4892dae13f7ca87",
+			wantOK:     true,
+		},
+		{
+			name:       "normalized Docker Hub host",
+			url:        "index.docker.io/library/podinfo:6.1.6",
+			wantMirror: "mirror.example.com/library/podinfo:6.1.6",
+			wantOK:     true,
+		},
+		{
+			name: "registry without mirror",
+			url:  "quay.io/stefanprodan/podinfo:6.1.6",
+		},
+		{
+			name: "invalid URL",
+			url:  "ghcr.io/StefanProdan/podinfo:6.1.6",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			mirror, ok := m.Mirror(tt.url)
+			g.Expect(ok).To(Equal(tt.wantOK))
+			g.Expect(mirror).To(Equal(tt.wantMirror))
+		})
+	}
+
+	t.Run("nil mirrors", func(t *testing.T) {
+		g := NewWithT(t)
+
+		var m *Mirrors
+		_, ok := m.Mirror("ghcr.io/stefanprodan/podinfo:6.1.6")
+		g.Expect(ok).To(BeFalse())
+	})
+}
@@ -0,0 +1,137 @@
+/*
+Copyright 2022 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package oci
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fluxcd/pkg/runtime/logger"
+	"github.com/go-logr/logr"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+)
+
+// MaxRetryAfter is the maximum delay honored from the Retry-After header of a
+// rate limited response, to protect against registries asking to back off
+// for an unreasonable amount of time.
+const MaxRetryAfter = time.Hour
+
+// ThrottlingTransport is an http.RoundTripper which records the delay
+// requested in the Retry-After header of the responses of a registry which
+// rate limits the requests with a 429 Too Many Requests status.
+type ThrottlingTransport struct {
+	transport http.RoundTripper
+	log       logr.Logger
+
+	mu         sync.Mutex
+	retryAfter time.Duration
+}
+
+// NewThrottlingTransport returns a ThrottlingTransport which wraps the given
+// transport, or the default transport of remote if nil. Rate limited
+// responses are logged to the given logger at trace level.
+func NewThrottlingTransport(transport http.RoundTripper, log logr.Logger) *ThrottlingTransport {
+	if transport == nil {
+		transport = remote.DefaultTransport
+	}
+	return &ThrottlingTransport{
+		transport: transport,
+		log:       log,
+	}
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *ThrottlingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.transport.RoundTrip(req)
+	if err != nil || resp.StatusCode != http.StatusTooManyRequests {
+		return resp, err
+	}
+
+	delay, ok := ParseRetryAfter(resp.Header.Get("Retry-After"), time.Now())
+	t.log.V(logger.TraceLevel).Info("registry rate limit exceeded",
+		"host", req.URL.Host, "path", req.URL.Path, "retryAfter", delay.String())
+	if ok {
+		t.mu.Lock()
+		if delay > t.retryAfter {
+			t.retryAfter = delay
+		}
+		t.mu.Unlock()
+	}
+	return resp, err
+}
+
+// RetryAfter returns the longest delay requested by the registry in a rate
+// limited response since the transport was created, or zero if the requests
+// were not rate limited.
+func (t *ThrottlingTransport) RetryAfter() time.Duration {
+	if t == nil {
+		return 0
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.retryAfter
+}
+
+// TakeRetryAfter returns the delay like RetryAfter, and resets it so that
+// the next remote operations are not throttled unless rate limited again.
+func (t *ThrottlingTransport) TakeRetryAfter() time.Duration {
+	if t == nil {
+		return 0
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	delay := t.retryAfter
+	t.retryAfter = 0
+	return delay
+}
+
+// ParseRetryAfter parses the value of a Retry-After header, which is either
+// a number of seconds or an HTTP date, into the delay from the given time.
+// The delay is capped at MaxRetryAfter. It returns false if the value is
+// empty or invalid.
+func ParseRetryAfter(value string, now time.Time) (time.Duration, bool) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0, false
+	}
+
+	var delay time.Duration
+	if seconds, err := strconv.ParseInt(value, 10, 64); err == nil {
+		if seconds < 0 {
+			return 0, false
+		}
+		if seconds > int64(MaxRetryAfter/time.Second) {
+			return MaxRetryAfter, true
+		}
+		delay = time.Duration(seconds) * time.Second
+	} else if date, err := http.ParseTime(value); err == nil {
+		delay = date.Sub(now)
+		if delay < 0 {
+			delay = 0
+		}
+	} else {
+		return 0, false
+	}
+
+	if delay > MaxRetryAfter {
+		delay = MaxRetryAfter
+	}
+	return delay, true
+}
@@ -0,0 +1,133 @@
+/*
+Copyright 2022 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package oci
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	. "github.com/onsi/gomega"
+)
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2022, 9, 13, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name   string
+		value  string
+		want   time.Duration
+		wantOK bool
+	}{
+		{
+			name:   "seconds",
+			value:  "120",
+			want:   2 * time.Minute,
+			wantOK: true,
+		},
+		{
+			name:   "HTTP date",
+			value:  now.Add(30 * time.Second).Format(http.TimeFormat),
+			want:   30 * time.Second,
+			wantOK: true,
+		},
+		{
+			name:   "HTTP date in the past",
+			value:  now.Add(-time.Minute).Format(http.TimeFormat),
+			want:   0,
+			wantOK: true,
+		},
+		{
+			name:   "capped seconds",
+			value:  "86400",
+			want:   MaxRetryAfter,
+			wantOK: true,
+		},
+		{
+			name:   "capped HTTP date",
+			value:  now.Add(24 * time.Hour).Format(http.TimeFormat),
+			want:   MaxRetryAfter,
+			wantOK: true,
+		},
+		{
+			name: "empty",
+		},
+		{
+			name:  "negative seconds",
+			value: "-1",
+		},
+		{
+			name:  "invalid",
+			value: "soon",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			got, ok := ParseRetryAfter(tt.value, now)
+			g.Expect(ok).To(Equal(tt.wantOK))
+			g.Expect(got).To(Equal(tt.want))
+		})
+	}
+}
+
+func TestThrottlingTransport(t *testing.T) {
+	g := NewWithT(t)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if retryAfter := r.URL.Query().Get("retry-after"); retryAfter != "" {
+			w.Header().Set("Retry-After", retryAfter)
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	t.Cleanup(srv.Close)
+
+	tr := NewThrottlingTransport(nil, logr.Discard())
+	c := &http.Client{Transport: tr}
+	get := func(query string) {
+		resp, err := c.Get(srv.URL + "/v2/?" + query)
+		g.Expect(err).ToNot(HaveOccurred())
+		resp.Body.Close()
+	}
+
+	get("")
+	g.Expect(tr.RetryAfter()).To(BeZero())
+
+	get("retry-after=10")
+	g.Expect(tr.RetryAfter()).To(Equal(10 * time.Second))
+
+	// The longest delay is retained
+	get("retry-after=5")
+	get("retry-after=invalid")
+	get("")
+	g.Expect(tr.RetryAfter()).To(Equal(10 * time.Second))
+
+	// Taking the delay resets it
+	g.Expect(tr.TakeRetryAfter()).To(Equal(10 * time.Second))
+	g.Expect(tr.RetryAfter()).To(BeZero())
+	get("retry-after=5")
+	g.Expect(tr.TakeRetryAfter()).To(Equal(5 * time.Second))
+
+	var nilTransport *ThrottlingTransport
+	g.Expect(nilTransport.RetryAfter()).To(BeZero())
+	g.Expect(nilTransport.TakeRetryAfter()).To(BeZero())
+}
@@ -0,0 +1,102 @@
+/*
+Copyright 2022 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package oci
+
+import (
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/fluxcd/pkg/runtime/logger"
+	"github.com/go-logr/logr"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+)
+
+// maxDiscardedBody is the maximum number of bytes read from the body of a
+// response which is retried, to allow reusing the connection.
+const maxDiscardedBody = 4 << 10
+
+// RetryingTransport is an http.RoundTripper which retries the idempotent
+// requests to a registry which fail with a server error, with an exponential
+// backoff between the attempts. Client errors, like rejected credentials or
+// missing artifacts, are not retried.
+type RetryingTransport struct {
+	transport http.RoundTripper
+	retries   int
+	backoff   time.Duration
+	log       logr.Logger
+}
+
+// NewRetryingTransport returns a RetryingTransport which wraps the given
+// transport, or the default transport of remote if nil. A request is retried
+// up to the given number of retries, the first retry after the given backoff,
+// which is doubled for every further retry. Retries are logged to the given
+// logger at trace level.
+func NewRetryingTransport(transport http.RoundTripper, retries int, backoff time.Duration, log logr.Logger) *RetryingTransport {
+	if transport == nil {
+		transport = remote.DefaultTransport
+	}
+	return &RetryingTransport{
+		transport: transport,
+		retries:   retries,
+		backoff:   backoff,
+		log:       log,
+	}
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *RetryingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if t.retries <= 0 || !isIdempotent(req) {
+		return t.transport.RoundTrip(req)
+	}
+
+	delay := t.backoff
+	for attempt := 0; ; attempt++ {
+		resp, err := t.transport.RoundTrip(req)
+		if err != nil || !isRetryableStatus(resp.StatusCode) || attempt >= t.retries {
+			return resp, err
+		}
+
+		t.log.V(logger.TraceLevel).Info("registry server error, retrying request",
+			"host", req.URL.Host, "path", req.URL.Path, "status", resp.StatusCode, "retryAfter", delay.String())
+		_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, maxDiscardedBody))
+		_ = resp.Body.Close()
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-req.Context().Done():
+			timer.Stop()
+			return nil, req.Context().Err()
+		case <-timer.C:
+		}
+		delay *= 2
+	}
+}
+
+// isIdempotent returns true if the given request can be sent again without
+// side effects, i.e. a GET or HEAD request without a body.
+func isIdempotent(req *http.Request) bool {
+	return (req.Method == http.MethodGet || req.Method == http.MethodHead) &&
+		(req.Body == nil || req.Body == http.NoBody)
+}
+
+// isRetryableStatus returns true if the given status code is a server error
+// which may be transient. 501 Not Implemented is not, as the registry does not
+// support the request.
+func isRetryableStatus(code int) bool {
+	return code >= http.StatusInternalServerError && code != http.StatusNotImplemented
+}
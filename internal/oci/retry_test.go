@@ -0,0 +1,142 @@
+/*
+Copyright 2022 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package oci
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	. "github.com/onsi/gomega"
+)
+
+func TestRetryingTransport(t *testing.T) {
+	var requests int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&requests, 1)
+		// Fail the first requests with the given status
+		failures, _ := strconv.Atoi(r.URL.Query().Get("failures"))
+		if int(n) <= failures {
+			status, _ := strconv.Atoi(r.URL.Query().Get("status"))
+			w.WriteHeader(status)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	t.Cleanup(srv.Close)
+
+	tests := []struct {
+		name         string
+		method       string
+		retries      int
+		query        string
+		wantStatus   int
+		wantRequests int32
+	}{
+		{
+			name:         "server error is retried",
+			method:       http.MethodGet,
+			retries:      2,
+			query:        "failures=2&status=503",
+			wantStatus:   http.StatusOK,
+			wantRequests: 3,
+		},
+		{
+			name:         "retries are exhausted",
+			method:       http.MethodHead,
+			retries:      2,
+			query:        "failures=5&status=502",
+			wantStatus:   http.StatusBadGateway,
+			wantRequests: 3,
+		},
+		{
+			name:         "client error is not retried",
+			method:       http.MethodGet,
+			retries:      2,
+			query:        "failures=1&status=401",
+			wantStatus:   http.StatusUnauthorized,
+			wantRequests: 1,
+		},
+		{
+			name:         "not implemented is not retried",
+			method:       http.MethodGet,
+			retries:      2,
+			query:        "failures=1&status=501",
+			wantStatus:   http.StatusNotImplemented,
+			wantRequests: 1,
+		},
+		{
+			name:         "non idempotent request is not retried",
+			method:       http.MethodPost,
+			retries:      2,
+			query:        "failures=1&status=500",
+			wantStatus:   http.StatusInternalServerError,
+			wantRequests: 1,
+		},
+		{
+			name:         "retries disabled",
+			method:       http.MethodGet,
+			query:        "failures=1&status=500",
+			wantStatus:   http.StatusInternalServerError,
+			wantRequests: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+			atomic.StoreInt32(&requests, 0)
+
+			c := &http.Client{Transport: NewRetryingTransport(nil, tt.retries, time.Millisecond, logr.Discard())}
+			body := ""
+			if tt.method == http.MethodPost {
+				body = "{}"
+			}
+			req, err := http.NewRequest(tt.method, srv.URL+"/v2/?"+tt.query, strings.NewReader(body))
+			g.Expect(err).ToNot(HaveOccurred())
+
+			resp, err := c.Do(req)
+			g.Expect(err).ToNot(HaveOccurred())
+			resp.Body.Close()
+			g.Expect(resp.StatusCode).To(Equal(tt.wantStatus))
+			g.Expect(atomic.LoadInt32(&requests)).To(Equal(tt.wantRequests))
+		})
+	}
+}
+
+func TestRetryingTransport_contextCancelled(t *testing.T) {
+	g := NewWithT(t)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	t.Cleanup(srv.Close)
+
+	ctx, cancel := context.WithTimeout(context.TODO(), 50*time.Millisecond)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL+"/v2/", nil)
+	g.Expect(err).ToNot(HaveOccurred())
+
+	c := &http.Client{Transport: NewRetryingTransport(nil, 10, time.Minute, logr.Discard())}
+	_, err = c.Do(req)
+	g.Expect(err).To(MatchError(ContainSubstring(context.DeadlineExceeded.Error())))
+}
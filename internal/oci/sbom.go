@@ -0,0 +1,210 @@
+/*
+Copyright 2022 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package oci
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/google/go-containerregistry/pkg/crane"
+	"github.com/google/go-containerregistry/pkg/name"
+	gcrv1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/remote/transport"
+)
+
+const (
+	// SPDXFormat is the format of an SPDX SBOM document.
+	SPDXFormat = "spdx"
+	// CycloneDXFormat is the format of a CycloneDX SBOM document.
+	CycloneDXFormat = "cyclonedx"
+
+	// SPDXMediaType is the media type of an SPDX JSON SBOM document.
+	SPDXMediaType = "application/spdx+json"
+	// CycloneDXMediaType is the media type of a CycloneDX JSON SBOM document.
+	CycloneDXMediaType = "application/vnd.cyclonedx+json"
+
+	// MaxSBOMSize is the maximum size in bytes of an SBOM document.
+	MaxSBOMSize int64 = 10 << 20
+)
+
+// ErrSBOMNotFound is returned when no SBOM of the requested format refers to
+// an artifact.
+var ErrSBOMNotFound = errors.New("no SBOM found")
+
+// SBOM is a Software Bill of Materials referring to an OCI artifact.
+type SBOM struct {
+	// Digest is the digest of the manifest of the SBOM.
+	Digest gcrv1.Hash
+	// Document is the decoded JSON document of the SBOM.
+	Document map[string]interface{}
+}
+
+// SBOMMediaType returns the media type of the JSON document of the given SBOM
+// format.
+func SBOMMediaType(format string) (string, error) {
+	switch format {
+	case SPDXFormat:
+		return SPDXMediaType, nil
+	case CycloneDXFormat:
+		return CycloneDXMediaType, nil
+	default:
+		return "", fmt.Errorf("unsupported SBOM format '%s'", format)
+	}
+}
+
+// FetchSBOM discovers the SBOM of the given format referring to the subject
+// digest, and returns its decoded document. The referrers of the subject are
+// looked up using the referrers tag schema of the OCI distribution
+// specification, i.e. the image index tagged with the algorithm and hex of
+// the subject digest. ErrSBOMNotFound is returned if no SBOM of the format
+// refers to the subject.
+func FetchSBOM(subject name.Digest, format string, opts ...crane.Option) (*SBOM, error) {
+	mediaType, err := SBOMMediaType(format)
+	if err != nil {
+		return nil, err
+	}
+
+	hash, err := gcrv1.NewHash(subject.DigestStr())
+	if err != nil {
+		return nil, err
+	}
+	repo := subject.Context()
+	referrersTag := repo.Tag(fmt.Sprintf("%s-%s", hash.Algorithm, hash.Hex))
+
+	raw, err := crane.Manifest(referrersTag.String(), opts...)
+	if err != nil {
+		var terr *transport.Error
+		if errors.As(err, &terr) && terr.StatusCode == http.StatusNotFound {
+			return nil, ErrSBOMNotFound
+		}
+		return nil, fmt.Errorf("failed to get referrers of '%s': %w", subject, err)
+	}
+	index, err := gcrv1.ParseIndexManifest(bytes.NewReader(raw))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse referrers of '%s': %w", subject, err)
+	}
+
+	for _, desc := range index.Manifests {
+		raw, err := crane.Manifest(repo.Digest(desc.Digest.String()).String(), opts...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get referrer '%s': %w", desc.Digest, err)
+		}
+		manifest, err := gcrv1.ParseManifest(bytes.NewReader(raw))
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse referrer '%s': %w", desc.Digest, err)
+		}
+
+		for _, layer := range manifest.Layers {
+			if string(layer.MediaType) != mediaType {
+				continue
+			}
+			if layer.Size > MaxSBOMSize {
+				return nil, fmt.Errorf("SBOM '%s' exceeds the maximum size of %d bytes", desc.Digest, MaxSBOMSize)
+			}
+			doc, err := fetchSBOMDocument(repo.Digest(layer.Digest.String()), opts...)
+			if err != nil {
+				return nil, fmt.Errorf("failed to fetch SBOM '%s': %w", desc.Digest, err)
+			}
+			return &SBOM{Digest: desc.Digest, Document: doc}, nil
+		}
+	}
+	return nil, ErrSBOMNotFound
+}
+
+// fetchSBOMDocument pulls the given blob, and decodes it as a JSON document.
+func fetchSBOMDocument(ref name.Digest, opts ...crane.Option) (map[string]interface{}, error) {
+	layer, err := crane.PullLayer(ref.String(), opts...)
+	if err != nil {
+		return nil, err
+	}
+	// The SBOM document is stored as is, the compressed content of the layer
+	// is the raw blob.
+	rc, err := layer.Compressed()
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+
+	b, err := io.ReadAll(io.LimitReader(rc, MaxSBOMSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(b)) > MaxSBOMSize {
+		return nil, fmt.Errorf("exceeds the maximum size of %d bytes", MaxSBOMSize)
+	}
+
+	var doc map[string]interface{}
+	if err := json.Unmarshal(b, &doc); err != nil {
+		return nil, fmt.Errorf("malformed JSON document: %w", err)
+	}
+	return doc, nil
+}
+
+// ValidateSBOM validates the given SBOM document against the given format,
+// and ensures the required top level fields are present and not empty.
+func ValidateSBOM(doc map[string]interface{}, format string, requiredFields []string) error {
+	switch format {
+	case SPDXFormat:
+		if v, _ := doc["spdxVersion"].(string); !strings.HasPrefix(v, "SPDX-") {
+			return fmt.Errorf("invalid SPDX document: missing or invalid 'spdxVersion'")
+		}
+		if v, _ := doc["SPDXID"].(string); v == "" {
+			return fmt.Errorf("invalid SPDX document: missing 'SPDXID'")
+		}
+	case CycloneDXFormat:
+		if v, _ := doc["bomFormat"].(string); v != "CycloneDX" {
+			return fmt.Errorf("invalid CycloneDX document: missing or invalid 'bomFormat'")
+		}
+		if v, _ := doc["specVersion"].(string); v == "" {
+			return fmt.Errorf("invalid CycloneDX document: missing 'specVersion'")
+		}
+	default:
+		return fmt.Errorf("unsupported SBOM format '%s'", format)
+	}
+
+	var missing []string
+	for _, f := range requiredFields {
+		if isEmptyField(doc[f]) {
+			missing = append(missing, f)
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("missing required fields: %s", strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+// isEmptyField returns if the given decoded JSON value is absent or empty.
+func isEmptyField(v interface{}) bool {
+	switch t := v.(type) {
+	case nil:
+		return true
+	case string:
+		return t == ""
+	case []interface{}:
+		return len(t) == 0
+	case map[string]interface{}:
+		return len(t) == 0
+	default:
+		return false
+	}
+}
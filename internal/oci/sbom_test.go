@@ -0,0 +1,233 @@
+/*
+Copyright 2022 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package oci
+
+import (
+	"errors"
+	"fmt"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/go-containerregistry/pkg/crane"
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/registry"
+	gcrv1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/empty"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
+	"github.com/google/go-containerregistry/pkg/v1/random"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/google/go-containerregistry/pkg/v1/static"
+	"github.com/google/go-containerregistry/pkg/v1/types"
+	. "github.com/onsi/gomega"
+)
+
+const (
+	testSPDXDocument      = `{"spdxVersion": "SPDX-2.3", "SPDXID": "SPDXRef-DOCUMENT", "name": "podinfo", "packages": [{"name": "podinfo"}]}`
+	testCycloneDXDocument = `{"bomFormat": "CycloneDX", "specVersion": "1.4", "components": []}`
+)
+
+func TestFetchSBOM(t *testing.T) {
+	srv := httptest.NewServer(registry.New())
+	t.Cleanup(srv.Close)
+	host := strings.TrimPrefix(srv.URL, "http://")
+
+	type referrer struct {
+		mediaType string
+		document  string
+	}
+
+	tests := []struct {
+		name      string
+		referrers []referrer
+		format    string
+		wantDoc   bool
+		wantErr   string
+	}{
+		{
+			name:    "no referrers",
+			format:  SPDXFormat,
+			wantErr: ErrSBOMNotFound.Error(),
+		},
+		{
+			name:      "SPDX referrer",
+			referrers: []referrer{{mediaType: SPDXMediaType, document: testSPDXDocument}},
+			format:    SPDXFormat,
+			wantDoc:   true,
+		},
+		{
+			name: "CycloneDX referrer next to SPDX referrer",
+			referrers: []referrer{
+				{mediaType: SPDXMediaType, document: testSPDXDocument},
+				{mediaType: CycloneDXMediaType, document: testCycloneDXDocument},
+			},
+			format:  CycloneDXFormat,
+			wantDoc: true,
+		},
+		{
+			name:      "referrer of other format",
+			referrers: []referrer{{mediaType: CycloneDXMediaType, document: testCycloneDXDocument}},
+			format:    SPDXFormat,
+			wantErr:   ErrSBOMNotFound.Error(),
+		},
+		{
+			name:      "malformed document",
+			referrers: []referrer{{mediaType: SPDXMediaType, document: "{not json"}},
+			format:    SPDXFormat,
+			wantErr:   "malformed JSON document",
+		},
+		{
+			name:    "unsupported format",
+			format:  "swid",
+			wantErr: "unsupported SBOM format 'swid'",
+		},
+	}
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			repo, err := name.NewRepository(fmt.Sprintf("%s/sbom-%d", host, i), name.Insecure)
+			g.Expect(err).ToNot(HaveOccurred())
+
+			subject, err := random.Image(1024, 1)
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(crane.Push(subject, repo.Tag("latest").String(), crane.Insecure)).To(Succeed())
+			subjectDigest, err := subject.Digest()
+			g.Expect(err).ToNot(HaveOccurred())
+
+			var wantDigest gcrv1.Hash
+			if len(tt.referrers) > 0 {
+				var idx gcrv1.ImageIndex = empty.Index
+				for _, r := range tt.referrers {
+					img, err := mutate.AppendLayers(empty.Image, static.NewLayer([]byte(r.document), types.MediaType(r.mediaType)))
+					g.Expect(err).ToNot(HaveOccurred())
+					idx = mutate.AppendManifests(idx, mutate.IndexAddendum{Add: img})
+
+					if mt, _ := SBOMMediaType(tt.format); mt == r.mediaType {
+						wantDigest, err = img.Digest()
+						g.Expect(err).ToNot(HaveOccurred())
+					}
+				}
+				referrersTag := repo.Tag(fmt.Sprintf("%s-%s", subjectDigest.Algorithm, subjectDigest.Hex))
+				g.Expect(remote.WriteIndex(referrersTag, idx)).To(Succeed())
+			}
+
+			sbom, err := FetchSBOM(repo.Digest(subjectDigest.String()), tt.format, crane.Insecure)
+			if tt.wantErr != "" {
+				g.Expect(err).To(HaveOccurred())
+				g.Expect(err.Error()).To(ContainSubstring(tt.wantErr))
+				return
+			}
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(sbom.Digest).To(Equal(wantDigest))
+			g.Expect(sbom.Document).ToNot(BeEmpty())
+		})
+	}
+}
+
+func TestFetchSBOM_notFound(t *testing.T) {
+	g := NewWithT(t)
+
+	srv := httptest.NewServer(registry.New())
+	t.Cleanup(srv.Close)
+
+	repo, err := name.NewRepository(fmt.Sprintf("%s/missing", strings.TrimPrefix(srv.URL, "http://")), name.Insecure)
+	g.Expect(err).ToNot(HaveOccurred())
+
+	_, err = FetchSBOM(repo.Digest("sha256:"+strings.Repeat("a", 64)), SPDXFormat, crane.Insecure)
+	g.Expect(errors.Is(err, ErrSBOMNotFound)).To(BeTrue())
+}
+
+func TestValidateSBOM(t *testing.T) {
+	tests := []struct {
+		name           string
+		doc            map[string]interface{}
+		format         string
+		requiredFields []string
+		wantErr        string
+	}{
+		{
+			name:   "valid SPDX document",
+			doc:    map[string]interface{}{"spdxVersion": "SPDX-2.3", "SPDXID": "SPDXRef-DOCUMENT"},
+			format: SPDXFormat,
+		},
+		{
+			name:    "SPDX document without version",
+			doc:     map[string]interface{}{"SPDXID": "SPDXRef-DOCUMENT"},
+			format:  SPDXFormat,
+			wantErr: "missing or invalid 'spdxVersion'",
+		},
+		{
+			name:    "SPDX document without SPDXID",
+			doc:     map[string]interface{}{"spdxVersion": "SPDX-2.3"},
+			format:  SPDXFormat,
+			wantErr: "missing 'SPDXID'",
+		},
+		{
+			name:    "CycloneDX document as SPDX",
+			doc:     map[string]interface{}{"bomFormat": "CycloneDX", "specVersion": "1.4"},
+			format:  SPDXFormat,
+			wantErr: "invalid SPDX document",
+		},
+		{
+			name:   "valid CycloneDX document",
+			doc:    map[string]interface{}{"bomFormat": "CycloneDX", "specVersion": "1.4"},
+			format: CycloneDXFormat,
+		},
+		{
+			name:    "CycloneDX document without spec version",
+			doc:     map[string]interface{}{"bomFormat": "CycloneDX"},
+			format:  CycloneDXFormat,
+			wantErr: "missing 'specVersion'",
+		},
+		{
+			name: "required fields present",
+			doc: map[string]interface{}{
+				"bomFormat":   "CycloneDX",
+				"specVersion": "1.4",
+				"metadata":    map[string]interface{}{"timestamp": "2022-01-01T00:00:00Z"},
+				"components":  []interface{}{map[string]interface{}{"name": "podinfo"}},
+			},
+			format:         CycloneDXFormat,
+			requiredFields: []string{"metadata", "components"},
+		},
+		{
+			name: "required fields missing or empty",
+			doc: map[string]interface{}{
+				"bomFormat":   "CycloneDX",
+				"specVersion": "1.4",
+				"components":  []interface{}{},
+			},
+			format:         CycloneDXFormat,
+			requiredFields: []string{"metadata", "components"},
+			wantErr:        "missing required fields: metadata, components",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			err := ValidateSBOM(tt.doc, tt.format, tt.requiredFields)
+			if tt.wantErr != "" {
+				g.Expect(err).To(HaveOccurred())
+				g.Expect(err.Error()).To(ContainSubstring(tt.wantErr))
+				return
+			}
+			g.Expect(err).ToNot(HaveOccurred())
+		})
+	}
+}
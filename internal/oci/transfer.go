@@ -0,0 +1,72 @@
+/*
+Copyright 2022 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package oci
+
+import (
+	"io"
+	"net/http"
+	"sync/atomic"
+)
+
+// CountingTransport is an http.RoundTripper which counts the bytes read from
+// the bodies of the responses, to measure the data downloaded from a
+// registry.
+type CountingTransport struct {
+	transport http.RoundTripper
+	bytesRead int64
+}
+
+// NewCountingTransport returns a CountingTransport which wraps the given
+// transport, or the default transport of http if nil.
+func NewCountingTransport(transport http.RoundTripper) *CountingTransport {
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+	return &CountingTransport{transport: transport}
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *CountingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.transport.RoundTrip(req)
+	if err != nil || resp.Body == nil {
+		return resp, err
+	}
+	resp.Body = &countingReadCloser{ReadCloser: resp.Body, count: &t.bytesRead}
+	return resp, nil
+}
+
+// BytesRead returns the number of bytes read from the bodies of the responses
+// since the transport was created.
+func (t *CountingTransport) BytesRead() int64 {
+	if t == nil {
+		return 0
+	}
+	return atomic.LoadInt64(&t.bytesRead)
+}
+
+// countingReadCloser adds the number of bytes read from the wrapped
+// io.ReadCloser to the count.
+type countingReadCloser struct {
+	io.ReadCloser
+	count *int64
+}
+
+func (r *countingReadCloser) Read(p []byte) (int, error) {
+	n, err := r.ReadCloser.Read(p)
+	atomic.AddInt64(r.count, int64(n))
+	return n, err
+}
@@ -0,0 +1,55 @@
+/*
+Copyright 2022 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package oci
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestCountingTransport(t *testing.T) {
+	g := NewWithT(t)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(strings.Repeat("a", 1024)))
+	}))
+	t.Cleanup(srv.Close)
+
+	tr := NewCountingTransport(nil)
+	c := &http.Client{Transport: tr}
+	get := func() {
+		resp, err := c.Get(srv.URL)
+		g.Expect(err).ToNot(HaveOccurred())
+		_, err = io.Copy(io.Discard, resp.Body)
+		g.Expect(err).ToNot(HaveOccurred())
+		resp.Body.Close()
+	}
+
+	g.Expect(tr.BytesRead()).To(BeZero())
+	get()
+	g.Expect(tr.BytesRead()).To(Equal(int64(1024)))
+	get()
+	g.Expect(tr.BytesRead()).To(Equal(int64(2048)))
+
+	var nilTransport *CountingTransport
+	g.Expect(nilTransport.BytesRead()).To(BeZero())
+}
@@ -19,7 +19,14 @@ package oci
 import (
 	"context"
 	"crypto"
+	"crypto/x509"
 	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/google/go-containerregistry/pkg/v1/remote"
 	"github.com/sigstore/cosign/cmd/cosign/cli/fulcio"
@@ -41,8 +48,15 @@ type Verifier interface {
 
 // options is a struct that holds options for verifier.
 type options struct {
-	PublicKey []byte
-	ROpt      []remote.Option
+	PublicKey   []byte
+	ROpt        []remote.Option
+	RekorURL    string
+	FulcioURL   string
+	FulcioRoots []byte
+	// FulcioTransport is the transport used to fetch the certificates from
+	// FulcioURL, which defaults to remote.DefaultTransport.
+	FulcioTransport http.RoundTripper
+	Offline         bool
 }
 
 // Options is a function that configures the options applied to a Verifier.
@@ -63,9 +77,52 @@ func WithRemoteOptions(opts ...remote.Option) Options {
 	}
 }
 
+// WithRekorURL sets the URL of the Rekor transparency log used by the keyless
+// verification, instead of the public Rekor instance.
+func WithRekorURL(url string) Options {
+	return func(o *options) {
+		o.RekorURL = url
+	}
+}
+
+// WithFulcioURL sets the URL of the Fulcio certificate authority from which
+// the root and intermediate certificates of the keyless verification are
+// fetched, unless they are set with WithFulcioRoots.
+func WithFulcioURL(url string) Options {
+	return func(o *options) {
+		o.FulcioURL = url
+	}
+}
+
+// WithFulcioTransport sets the transport used to fetch the root and
+// intermediate certificates from the URL set with WithFulcioURL.
+func WithFulcioTransport(transport http.RoundTripper) Options {
+	return func(o *options) {
+		o.FulcioTransport = transport
+	}
+}
+
+// WithFulcioRoots sets the PEM encoded root and intermediate certificates of
+// the Fulcio certificate authority trusted by the keyless verification.
+func WithFulcioRoots(roots []byte) Options {
+	return func(o *options) {
+		o.FulcioRoots = roots
+	}
+}
+
+// WithOffline disables the lookups in the Rekor transparency log of the
+// keyless verification. The inclusion of the signatures in the log is then
+// verified with the bundles attached to them.
+func WithOffline(offline bool) Options {
+	return func(o *options) {
+		o.Offline = offline
+	}
+}
+
 // CosignVerifier is a struct which is responsible for executing verification logic.
 type CosignVerifier struct {
-	opts *cosign.CheckOpts
+	opts     *cosign.CheckOpts
+	rekorURL string
 }
 
 // NewCosignVerifier initializes a new CosignVerifier.
@@ -103,33 +160,180 @@ func NewCosignVerifier(ctx context.Context, opts ...Options) (*CosignVerifier, e
 			return nil, err
 		}
 	} else {
-		rcerts, err := fulcio.GetRoots()
-		if err != nil {
-			return nil, fmt.Errorf("unable to get Fulcio root certs: %w", err)
+		roots := o.FulcioRoots
+		if len(roots) == 0 && o.FulcioURL != "" {
+			transport := o.FulcioTransport
+			if transport == nil {
+				transport = remote.DefaultTransport
+			}
+			if roots, err = fulcioRoots.get(ctx, transport, o.FulcioURL); err != nil {
+				return nil, err
+			}
 		}
-		checkOpts.RootCerts = rcerts
 
-		icerts, err := fulcio.GetIntermediates()
-		if err != nil {
-			return nil, fmt.Errorf("unable to get Fulcio intermediate certs: %w", err)
+		if len(roots) > 0 {
+			checkOpts.RootCerts, checkOpts.IntermediateCerts, err = splitCertificates(roots)
+			if err != nil {
+				return nil, fmt.Errorf("invalid Fulcio root certs: %w", err)
+			}
+		} else {
+			rcerts, err := fulcio.GetRoots()
+			if err != nil {
+				return nil, fmt.Errorf("unable to get Fulcio root certs: %w", err)
+			}
+			checkOpts.RootCerts = rcerts
+
+			icerts, err := fulcio.GetIntermediates()
+			if err != nil {
+				return nil, fmt.Errorf("unable to get Fulcio intermediate certs: %w", err)
+			}
+			checkOpts.IntermediateCerts = icerts
 		}
-		checkOpts.IntermediateCerts = icerts
 
-		rc, err := rekor.NewClient(coptions.DefaultRekorURL)
-		if err != nil {
-			return nil, fmt.Errorf("unable to create Rekor client: %w", err)
+		// Without a Rekor client, the inclusion in the transparency log is
+		// verified with the bundles attached to the signatures
+		if !o.Offline {
+			rekorURL := coptions.DefaultRekorURL
+			if o.RekorURL != "" {
+				rekorURL = o.RekorURL
+			}
+			rc, err := rekor.NewClient(rekorURL)
+			if err != nil {
+				return nil, fmt.Errorf("unable to create Rekor client for '%s': %w", rekorURL, err)
+			}
+			checkOpts.RekorClient = rc
 		}
-		checkOpts.RekorClient = rc
 	}
 
-	return &CosignVerifier{
+	v := &CosignVerifier{
 		opts: checkOpts,
-	}, nil
+	}
+	if checkOpts.RekorClient != nil {
+		v.rekorURL = o.RekorURL
+	}
+	return v, nil
+}
+
+// fulcioRootsTTL is the duration for which the certificates fetched from a
+// Fulcio instance are cached.
+const fulcioRootsTTL = time.Hour
+
+// fulcioRoots caches the certificates fetched from the Fulcio instances,
+// which are shared by all the verifications using the same instance.
+var fulcioRoots = &fulcioRootsCache{entries: map[string]fulcioRootsEntry{}}
+
+// fulcioRootsCache caches the PEM encoded root and intermediate certificates
+// of Fulcio instances by their URL, for fulcioRootsTTL.
+type fulcioRootsCache struct {
+	mu      sync.Mutex
+	entries map[string]fulcioRootsEntry
+}
+
+type fulcioRootsEntry struct {
+	roots   []byte
+	expires time.Time
+}
+
+// get returns the cached certificates of the Fulcio instance at the given
+// URL, or fetches them with the given transport if they are not cached or
+// expired.
+func (c *fulcioRootsCache) get(ctx context.Context, transport http.RoundTripper, url string) ([]byte, error) {
+	c.mu.Lock()
+	entry, ok := c.entries[url]
+	c.mu.Unlock()
+	if ok && time.Now().Before(entry.expires) {
+		return entry.roots, nil
+	}
+
+	roots, err := fetchFulcioRoots(ctx, transport, url)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	c.entries[url] = fulcioRootsEntry{roots: roots, expires: time.Now().Add(fulcioRootsTTL)}
+	c.mu.Unlock()
+	return roots, nil
+}
+
+// fetchFulcioRoots fetches the PEM encoded root and intermediate certificates
+// from the Fulcio instance at the given URL, using the given transport. The
+// URL must use HTTPS, as the certificates are the trust anchor of the keyless
+// verification.
+func fetchFulcioRoots(ctx context.Context, transport http.RoundTripper, fulcioURL string) ([]byte, error) {
+	u, err := url.Parse(fulcioURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid Fulcio URL '%s': %w", fulcioURL, err)
+	}
+	if u.Scheme != "https" {
+		return nil, fmt.Errorf("insecure Fulcio URL '%s': https is required, or the trusted root certificates must be provided in a Secret", fulcioURL)
+	}
+
+	endpoint := strings.TrimSuffix(fulcioURL, "/") + "/api/v1/rootCert"
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, fmt.Errorf("unable to get Fulcio root certs from '%s': %w", fulcioURL, err)
+	}
+	resp, err := (&http.Client{Transport: transport}).Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("unable to get Fulcio root certs from '%s': %w", fulcioURL, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unable to get Fulcio root certs from '%s': unexpected status code %d", fulcioURL, resp.StatusCode)
+	}
+	roots, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, fmt.Errorf("unable to get Fulcio root certs from '%s': %w", fulcioURL, err)
+	}
+	return roots, nil
+}
+
+// splitCertificates parses the given PEM encoded certificates, and returns
+// the self-signed ones as roots and the others as intermediates.
+func splitCertificates(data []byte) (roots *x509.CertPool, intermediates *x509.CertPool, err error) {
+	certs, err := cryptoutils.UnmarshalCertificatesFromPEM(data)
+	if err != nil {
+		return nil, nil, err
+	}
+	roots, intermediates = x509.NewCertPool(), x509.NewCertPool()
+	var rootCount int
+	for _, cert := range certs {
+		if cert.CheckSignatureFrom(cert) == nil {
+			roots.AddCert(cert)
+			rootCount++
+			continue
+		}
+		intermediates.AddCert(cert)
+	}
+	if rootCount == 0 {
+		return nil, nil, fmt.Errorf("no self-signed root certificate found")
+	}
+	return roots, intermediates, nil
 }
 
 // VerifyImageSignatures verify the authenticity of the given ref OCI image.
 func (v *CosignVerifier) VerifyImageSignatures(ctx context.Context, ref name.Reference) ([]oci.Signature, bool, error) {
-	return cosign.VerifyImageSignatures(ctx, ref, v.opts)
+	signatures, bundleVerified, err := cosign.VerifyImageSignatures(ctx, ref, v.opts)
+	return signatures, bundleVerified, v.wrapErr(err)
+}
+
+// VerifyImageAttestations verifies the in-toto attestations of the given ref
+// OCI image, and that their subject matches the image digest.
+func (v *CosignVerifier) VerifyImageAttestations(ctx context.Context, ref name.Reference) ([]oci.Signature, bool, error) {
+	opts := *v.opts
+	opts.ClaimVerifier = cosign.IntotoSubjectClaimVerifier
+	attestations, bundleVerified, err := cosign.VerifyImageAttestations(ctx, ref, &opts)
+	return attestations, bundleVerified, v.wrapErr(err)
+}
+
+// wrapErr names the configured Rekor instance in the given verification
+// error, to tell misconfigurations of private instances apart.
+func (v *CosignVerifier) wrapErr(err error) error {
+	if err == nil || v.rekorURL == "" {
+		return err
+	}
+	return fmt.Errorf("verification with Rekor at '%s' failed: %w", v.rekorURL, err)
 }
 
 // Verify verifies the authenticity of the given ref OCI image.
@@ -17,12 +17,24 @@ limitations under the License.
 package oci
 
 import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"math/big"
 	"net/http"
+	"net/http/httptest"
 	"reflect"
 	"testing"
+	"time"
 
 	"github.com/google/go-containerregistry/pkg/authn"
 	"github.com/google/go-containerregistry/pkg/v1/remote"
+	. "github.com/onsi/gomega"
 )
 
 func TestOptions(t *testing.T) {
@@ -75,6 +87,22 @@ func TestOptions(t *testing.T) {
 				remote.WithTransport(http.DefaultTransport),
 			},
 		},
+	}, {
+		name: "private sigstore options",
+		opts: []Options{
+			WithRekorURL("https://rekor.example.com"),
+			WithFulcioURL("https://fulcio.example.com"),
+			WithFulcioRoots([]byte("roots")),
+			WithFulcioTransport(http.DefaultTransport),
+			WithOffline(true),
+		},
+		want: &options{
+			RekorURL:        "https://rekor.example.com",
+			FulcioURL:       "https://fulcio.example.com",
+			FulcioRoots:     []byte("roots"),
+			FulcioTransport: http.DefaultTransport,
+			Offline:         true,
+		},
 	},
 	}
 
@@ -87,6 +115,11 @@ func TestOptions(t *testing.T) {
 			if !reflect.DeepEqual(o.PublicKey, test.want.PublicKey) {
 				t.Errorf("got %#v, want %#v", &o.PublicKey, test.want.PublicKey)
 			}
+			if o.RekorURL != test.want.RekorURL || o.FulcioURL != test.want.FulcioURL ||
+				!reflect.DeepEqual(o.FulcioRoots, test.want.FulcioRoots) || o.FulcioTransport != test.want.FulcioTransport ||
+				o.Offline != test.want.Offline {
+				t.Errorf("got %#v, want %#v", o, test.want)
+			}
 
 			if test.want.ROpt != nil {
 				if len(o.ROpt) != len(test.want.ROpt) {
@@ -103,3 +136,112 @@ func TestOptions(t *testing.T) {
 		})
 	}
 }
+
+func TestSplitCertificates(t *testing.T) {
+	g := NewWithT(t)
+
+	rootPEM, intermediatePEM := generateCertificateChain(t)
+
+	roots, intermediates, err := splitCertificates(append(rootPEM, intermediatePEM...))
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(roots.Subjects()).To(HaveLen(1))
+	g.Expect(intermediates.Subjects()).To(HaveLen(1))
+
+	_, _, err = splitCertificates(intermediatePEM)
+	g.Expect(err).To(MatchError("no self-signed root certificate found"))
+
+	_, _, err = splitCertificates([]byte("invalid"))
+	g.Expect(err).To(HaveOccurred())
+}
+
+func TestFetchFulcioRoots(t *testing.T) {
+	g := NewWithT(t)
+
+	rootPEM, intermediatePEM := generateCertificateChain(t)
+	chain := append(intermediatePEM, rootPEM...)
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1/rootCert" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = w.Write(chain)
+	}))
+	defer server.Close()
+	transport := server.Client().Transport
+
+	got, err := fetchFulcioRoots(context.TODO(), transport, server.URL+"/")
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(got).To(Equal(chain))
+
+	_, err = fetchFulcioRoots(context.TODO(), transport, server.URL+"/invalid")
+	g.Expect(err).To(HaveOccurred())
+	g.Expect(err.Error()).To(ContainSubstring(fmt.Sprintf("unable to get Fulcio root certs from '%s/invalid': unexpected status code 404", server.URL)))
+
+	// The certificates are not trusted without the transport of the server
+	_, err = fetchFulcioRoots(context.TODO(), remote.DefaultTransport, server.URL)
+	g.Expect(err).To(HaveOccurred())
+
+	_, err = fetchFulcioRoots(context.TODO(), transport, "http://fulcio.example.com")
+	g.Expect(err).To(MatchError("insecure Fulcio URL 'http://fulcio.example.com': https is required, " +
+		"or the trusted root certificates must be provided in a Secret"))
+}
+
+func TestFulcioRootsCache(t *testing.T) {
+	g := NewWithT(t)
+
+	rootPEM, _ := generateCertificateChain(t)
+	var requests int
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		_, _ = w.Write(rootPEM)
+	}))
+	defer server.Close()
+	transport := server.Client().Transport
+
+	c := &fulcioRootsCache{entries: map[string]fulcioRootsEntry{}}
+	for i := 0; i < 2; i++ {
+		got, err := c.get(context.TODO(), transport, server.URL)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(got).To(Equal(rootPEM))
+	}
+	g.Expect(requests).To(Equal(1))
+
+	// Expired certificates are fetched again
+	c.entries[server.URL] = fulcioRootsEntry{roots: rootPEM, expires: time.Now().Add(-time.Second)}
+	_, err := c.get(context.TODO(), transport, server.URL)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(requests).To(Equal(2))
+}
+
+// generateCertificateChain returns the PEM encoded certificates of a
+// self-signed root CA and an intermediate CA issued by it.
+func generateCertificateChain(t *testing.T) (rootPEM, intermediatePEM []byte) {
+	t.Helper()
+	g := NewWithT(t)
+
+	newCert := func(cn string, serial int64, parent *x509.Certificate, parentKey *ecdsa.PrivateKey) (*x509.Certificate, *ecdsa.PrivateKey, []byte) {
+		key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		g.Expect(err).ToNot(HaveOccurred())
+		tmpl := &x509.Certificate{
+			SerialNumber:          big.NewInt(serial),
+			Subject:               pkix.Name{CommonName: cn},
+			NotBefore:             time.Now().Add(-time.Hour),
+			NotAfter:              time.Now().Add(time.Hour),
+			KeyUsage:              x509.KeyUsageCertSign,
+			BasicConstraintsValid: true,
+			IsCA:                  true,
+		}
+		if parent == nil {
+			parent, parentKey = tmpl, key
+		}
+		der, err := x509.CreateCertificate(rand.Reader, tmpl, parent, &key.PublicKey, parentKey)
+		g.Expect(err).ToNot(HaveOccurred())
+		cert, err := x509.ParseCertificate(der)
+		g.Expect(err).ToNot(HaveOccurred())
+		return cert, key, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	}
+
+	root, rootKey, rootPEM := newCert("root", 1, nil, nil)
+	_, _, intermediatePEM = newCert("intermediate", 2, root, rootKey)
+	return rootPEM, intermediatePEM
+}
@@ -0,0 +1,324 @@
+/*
+Copyright 2022 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package oci
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+	"github.com/Azure/azure-sdk-for-go/sdk/azidentity"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/credentials/stscreds"
+	"github.com/aws/aws-sdk-go-v2/service/ecr"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/name"
+	corev1 "k8s.io/api/core/v1"
+)
+
+const (
+	// AWSRoleARNAnnotation is the annotation of a Kubernetes ServiceAccount
+	// holding the ARN of the AWS IAM role it assumes.
+	AWSRoleARNAnnotation = "eks.amazonaws.com/role-arn"
+	// AzureClientIDAnnotation is the annotation of a Kubernetes
+	// ServiceAccount holding the client ID of the Azure AD application or
+	// managed identity it federates with.
+	AzureClientIDAnnotation = "azure.workload.identity/client-id"
+	// AzureTenantIDAnnotation is the annotation of a Kubernetes
+	// ServiceAccount holding the Azure AD tenant ID of its identity. The
+	// AZURE_TENANT_ID environment variable is used when it is not set.
+	AzureTenantIDAnnotation = "azure.workload.identity/tenant-id"
+	// GCPWorkloadIdentityProviderAnnotation is the annotation of a Kubernetes
+	// ServiceAccount holding the full resource name of the GCP workload
+	// identity pool provider which trusts its tokens, e.g.
+	// '//iam.googleapis.com/projects/<number>/locations/global/workloadIdentityPools/<pool>/providers/<provider>'.
+	GCPWorkloadIdentityProviderAnnotation = "source.toolkit.fluxcd.io/gcp-workload-identity-provider"
+	// GCPServiceAccountAnnotation is the annotation of a Kubernetes
+	// ServiceAccount holding the email of the GCP service account it
+	// impersonates. The federated identity is used as is when it is not set.
+	GCPServiceAccountAnnotation = "iam.gke.io/gcp-service-account"
+)
+
+// WorkloadIdentity exchanges the tokens of a Kubernetes ServiceAccount for
+// the registry credentials of a cloud provider.
+type WorkloadIdentity interface {
+	// Audience returns the audience of the ServiceAccount token accepted by
+	// the cloud provider, and false if the ServiceAccount is not configured
+	// for the workload identity of the provider.
+	Audience(serviceAccount corev1.ServiceAccount) (string, bool)
+	// Login exchanges the given ServiceAccount token for the credentials of
+	// the registry of the given reference.
+	Login(ctx context.Context, ref name.Reference, serviceAccount corev1.ServiceAccount, token string) (authn.Authenticator, error)
+}
+
+// NewWorkloadIdentity returns the WorkloadIdentity of the given cloud
+// provider, which is one of 'aws', 'azure' or 'gcp'. The token exchanges are
+// sent with the given transport, or http.DefaultTransport if nil.
+func NewWorkloadIdentity(provider string, transport http.RoundTripper) (WorkloadIdentity, error) {
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+	client := &http.Client{Transport: transport}
+
+	switch provider {
+	case "aws":
+		return awsWorkloadIdentity{client: client}, nil
+	case "azure":
+		return azureWorkloadIdentity{client: client}, nil
+	case "gcp":
+		return gcpWorkloadIdentity{
+			client:            client,
+			stsURL:            "https://sts.googleapis.com/v1/token",
+			iamCredentialsURL: "https://iamcredentials.googleapis.com/v1",
+		}, nil
+	default:
+		return nil, fmt.Errorf("workload identity is not supported for provider '%s'", provider)
+	}
+}
+
+// ecrHostRegexp matches the host of an AWS ECR registry, capturing its
+// region.
+var ecrHostRegexp = regexp.MustCompile(`^\d{12}\.dkr\.ecr(?:-fips)?\.([a-z0-9-]+)\.amazonaws\.com(?:\.cn)?$`)
+
+// awsWorkloadIdentity assumes the IAM role of a ServiceAccount with its
+// token, and requests an ECR authorization token with the role credentials.
+type awsWorkloadIdentity struct {
+	client *http.Client
+}
+
+// Audience implements WorkloadIdentity.
+func (awsWorkloadIdentity) Audience(serviceAccount corev1.ServiceAccount) (string, bool) {
+	if serviceAccount.Annotations[AWSRoleARNAnnotation] == "" {
+		return "", false
+	}
+	return "sts.amazonaws.com", true
+}
+
+// Login implements WorkloadIdentity.
+func (a awsWorkloadIdentity) Login(ctx context.Context, ref name.Reference, serviceAccount corev1.ServiceAccount, token string) (authn.Authenticator, error) {
+	m := ecrHostRegexp.FindStringSubmatch(ref.Context().RegistryStr())
+	if m == nil {
+		return nil, fmt.Errorf("'%s' is not an ECR registry", ref.Context().RegistryStr())
+	}
+	region := m[1]
+
+	credentials := stscreds.NewWebIdentityRoleProvider(sts.New(sts.Options{Region: region, HTTPClient: a.client}),
+		serviceAccount.Annotations[AWSRoleARNAnnotation], identityToken(token))
+	client := ecr.New(ecr.Options{
+		Region:      region,
+		Credentials: aws.NewCredentialsCache(credentials),
+		HTTPClient:  a.client,
+	})
+	out, err := client.GetAuthorizationToken(ctx, &ecr.GetAuthorizationTokenInput{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get ECR authorization token: %w", err)
+	}
+	if len(out.AuthorizationData) == 0 || out.AuthorizationData[0].AuthorizationToken == nil {
+		return nil, fmt.Errorf("no ECR authorization token returned")
+	}
+	return basicFromAuthorizationToken(*out.AuthorizationData[0].AuthorizationToken)
+}
+
+// identityToken is a stscreds.IdentityTokenRetriever of a ServiceAccount
+// token.
+type identityToken string
+
+// GetIdentityToken implements stscreds.IdentityTokenRetriever.
+func (t identityToken) GetIdentityToken() ([]byte, error) {
+	return []byte(t), nil
+}
+
+// basicFromAuthorizationToken returns the basic authenticator of the given
+// base64 encoded '<username>:<password>' authorization token.
+func basicFromAuthorizationToken(token string) (authn.Authenticator, error) {
+	b, err := base64.StdEncoding.DecodeString(token)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode authorization token: %w", err)
+	}
+	username, password, ok := strings.Cut(string(b), ":")
+	if !ok {
+		return nil, fmt.Errorf("invalid authorization token")
+	}
+	return &authn.Basic{Username: username, Password: password}, nil
+}
+
+// azureWorkloadIdentity requests an Azure AD token for the identity of a
+// ServiceAccount with its token, and exchanges it for an ACR refresh token.
+type azureWorkloadIdentity struct {
+	client *http.Client
+}
+
+// acrRefreshTokenUsername is the username of the basic credentials holding
+// an ACR refresh token.
+const acrRefreshTokenUsername = "00000000-0000-0000-0000-000000000000"
+
+// Audience implements WorkloadIdentity.
+func (azureWorkloadIdentity) Audience(serviceAccount corev1.ServiceAccount) (string, bool) {
+	if serviceAccount.Annotations[AzureClientIDAnnotation] == "" {
+		return "", false
+	}
+	return "api://AzureADTokenExchange", true
+}
+
+// Login implements WorkloadIdentity.
+func (a azureWorkloadIdentity) Login(ctx context.Context, ref name.Reference, serviceAccount corev1.ServiceAccount, token string) (authn.Authenticator, error) {
+	tenantID := serviceAccount.Annotations[AzureTenantIDAnnotation]
+	if tenantID == "" {
+		tenantID = os.Getenv("AZURE_TENANT_ID")
+	}
+	if tenantID == "" {
+		return nil, fmt.Errorf("no Azure tenant ID configured, set the '%s' annotation", AzureTenantIDAnnotation)
+	}
+
+	cred, err := azidentity.NewClientAssertionCredential(tenantID, serviceAccount.Annotations[AzureClientIDAnnotation],
+		func(context.Context) (string, error) { return token, nil },
+		&azidentity.ClientAssertionCredentialOptions{ClientOptions: policy.ClientOptions{Transport: a.client}})
+	if err != nil {
+		return nil, err
+	}
+	aadToken, err := cred.GetToken(ctx, policy.TokenRequestOptions{Scopes: []string{"https://management.azure.com/.default"}})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get Azure AD token: %w", err)
+	}
+
+	registry := ref.Context().RegistryStr()
+	refreshToken, err := a.exchange(ctx, "https://"+registry, registry, tenantID, aadToken.Token)
+	if err != nil {
+		return nil, err
+	}
+	return &authn.Basic{Username: acrRefreshTokenUsername, Password: refreshToken}, nil
+}
+
+// exchange exchanges the given Azure AD access token for a refresh token of
+// the ACR registry at the given URL.
+func (a azureWorkloadIdentity) exchange(ctx context.Context, registryURL, registry, tenantID, accessToken string) (string, error) {
+	form := url.Values{
+		"grant_type":   {"access_token"},
+		"service":      {registry},
+		"tenant":       {tenantID},
+		"access_token": {accessToken},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, registryURL+"/oauth2/exchange", strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	var out struct {
+		RefreshToken string `json:"refresh_token"`
+	}
+	if err := doJSON(a.client, req, &out); err != nil {
+		return "", fmt.Errorf("failed to exchange Azure AD token for ACR refresh token: %w", err)
+	}
+	return out.RefreshToken, nil
+}
+
+// gcpWorkloadIdentity exchanges the token of a ServiceAccount for a federated
+// access token with the GCP Security Token Service, optionally impersonating
+// a GCP service account.
+type gcpWorkloadIdentity struct {
+	client            *http.Client
+	stsURL            string
+	iamCredentialsURL string
+}
+
+// gcpAccessTokenUsername is the username of the basic credentials holding a
+// GCP access token.
+const gcpAccessTokenUsername = "oauth2accesstoken"
+
+// gcpCloudPlatformScope is the OAuth scope of the GCP access tokens.
+const gcpCloudPlatformScope = "https://www.googleapis.com/auth/cloud-platform"
+
+// Audience implements WorkloadIdentity.
+func (gcpWorkloadIdentity) Audience(serviceAccount corev1.ServiceAccount) (string, bool) {
+	audience := serviceAccount.Annotations[GCPWorkloadIdentityProviderAnnotation]
+	return audience, audience != ""
+}
+
+// Login implements WorkloadIdentity.
+func (g gcpWorkloadIdentity) Login(ctx context.Context, _ name.Reference, serviceAccount corev1.ServiceAccount, token string) (authn.Authenticator, error) {
+	form := url.Values{
+		"grant_type":           {"urn:ietf:params:oauth:grant-type:token-exchange"},
+		"audience":             {serviceAccount.Annotations[GCPWorkloadIdentityProviderAnnotation]},
+		"scope":                {gcpCloudPlatformScope},
+		"requested_token_type": {"urn:ietf:params:oauth:token-type:access_token"},
+		"subject_token":        {token},
+		"subject_token_type":   {"urn:ietf:params:oauth:token-type:jwt"},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, g.stsURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	var federated struct {
+		AccessToken string `json:"access_token"`
+	}
+	if err := doJSON(g.client, req, &federated); err != nil {
+		return nil, fmt.Errorf("failed to exchange token with GCP STS: %w", err)
+	}
+
+	email := serviceAccount.Annotations[GCPServiceAccountAnnotation]
+	if email == "" {
+		return &authn.Basic{Username: gcpAccessTokenUsername, Password: federated.AccessToken}, nil
+	}
+
+	body, err := json.Marshal(map[string][]string{"scope": {gcpCloudPlatformScope}})
+	if err != nil {
+		return nil, err
+	}
+	req, err = http.NewRequestWithContext(ctx, http.MethodPost,
+		fmt.Sprintf("%s/projects/-/serviceAccounts/%s:generateAccessToken", g.iamCredentialsURL, url.PathEscape(email)),
+		bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+federated.AccessToken)
+
+	var impersonated struct {
+		AccessToken string `json:"accessToken"`
+	}
+	if err := doJSON(g.client, req, &impersonated); err != nil {
+		return nil, fmt.Errorf("failed to impersonate GCP service account '%s': %w", email, err)
+	}
+	return &authn.Basic{Username: gcpAccessTokenUsername, Password: impersonated.AccessToken}, nil
+}
+
+// doJSON sends the given request and decodes the JSON body of a successful
+// response into v.
+func doJSON(client *http.Client, req *http.Request, v interface{}) error {
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status code %d", resp.StatusCode)
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
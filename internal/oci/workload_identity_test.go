@@ -0,0 +1,195 @@
+/*
+Copyright 2022 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package oci
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/name"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestWorkloadIdentity_Audience(t *testing.T) {
+	tests := []struct {
+		name         string
+		provider     string
+		annotations  map[string]string
+		wantAudience string
+		wantOK       bool
+	}{
+		{
+			name:         "aws role",
+			provider:     "aws",
+			annotations:  map[string]string{AWSRoleARNAnnotation: "arn:aws:iam::123456789012:role/flux"},
+			wantAudience: "sts.amazonaws.com",
+			wantOK:       true,
+		},
+		{
+			name:         "azure client ID",
+			provider:     "azure",
+			annotations:  map[string]string{AzureClientIDAnnotation: "client-id"},
+			wantAudience: "api://AzureADTokenExchange",
+			wantOK:       true,
+		},
+		{
+			name:     "gcp workload identity provider",
+			provider: "gcp",
+			annotations: map[string]string{
+				GCPWorkloadIdentityProviderAnnotation: "//iam.googleapis.com/projects/1/locations/global/workloadIdentityPools/p/providers/k8s",
+			},
+			wantAudience: "//iam.googleapis.com/projects/1/locations/global/workloadIdentityPools/p/providers/k8s",
+			wantOK:       true,
+		},
+		{
+			name:        "unconfigured service account",
+			provider:    "gcp",
+			annotations: map[string]string{GCPServiceAccountAnnotation: "flux@project.iam.gserviceaccount.com"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			wi, err := NewWorkloadIdentity(tt.provider, nil)
+			g.Expect(err).ToNot(HaveOccurred())
+
+			audience, ok := wi.Audience(corev1.ServiceAccount{ObjectMeta: metav1.ObjectMeta{Annotations: tt.annotations}})
+			g.Expect(ok).To(Equal(tt.wantOK))
+			g.Expect(audience).To(Equal(tt.wantAudience))
+		})
+	}
+
+	_, err := NewWorkloadIdentity("generic", nil)
+	NewWithT(t).Expect(err).To(HaveOccurred())
+}
+
+func TestGCPWorkloadIdentity_Login(t *testing.T) {
+	const (
+		audience = "//iam.googleapis.com/projects/1/locations/global/workloadIdentityPools/p/providers/k8s"
+		email    = "flux@project.iam.gserviceaccount.com"
+	)
+
+	tests := []struct {
+		name        string
+		annotations map[string]string
+		stsStatus   int
+		wantToken   string
+		wantErr     string
+	}{
+		{
+			name:        "federated token",
+			annotations: map[string]string{GCPWorkloadIdentityProviderAnnotation: audience},
+			wantToken:   "federated-token",
+		},
+		{
+			name: "impersonated service account",
+			annotations: map[string]string{
+				GCPWorkloadIdentityProviderAnnotation: audience,
+				GCPServiceAccountAnnotation:           email,
+			},
+			wantToken: "impersonated-token",
+		},
+		{
+			name:        "rejected token",
+			annotations: map[string]string{GCPWorkloadIdentityProviderAnnotation: audience},
+			stsStatus:   http.StatusUnauthorized,
+			wantErr:     "failed to exchange token with GCP STS: unexpected status code 401",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			mux := http.NewServeMux()
+			mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+				g.Expect(r.ParseForm()).To(Succeed())
+				g.Expect(r.PostForm.Get("audience")).To(Equal(audience))
+				g.Expect(r.PostForm.Get("subject_token")).To(Equal("sa-token"))
+				if tt.stsStatus != 0 {
+					w.WriteHeader(tt.stsStatus)
+					return
+				}
+				_ = json.NewEncoder(w).Encode(map[string]string{"access_token": "federated-token"})
+			})
+			mux.HandleFunc("/projects/-/serviceAccounts/"+email+":generateAccessToken", func(w http.ResponseWriter, r *http.Request) {
+				g.Expect(r.Header.Get("Authorization")).To(Equal("Bearer federated-token"))
+				_ = json.NewEncoder(w).Encode(map[string]string{"accessToken": "impersonated-token"})
+			})
+			// The TLS server is only trusted by its own transport, which
+			// the token exchanges must be sent with.
+			srv := httptest.NewTLSServer(mux)
+			defer srv.Close()
+
+			provider, err := NewWorkloadIdentity("gcp", srv.Client().Transport)
+			g.Expect(err).ToNot(HaveOccurred())
+			wi := provider.(gcpWorkloadIdentity)
+			wi.stsURL = srv.URL + "/token"
+			wi.iamCredentialsURL = srv.URL
+
+			ref, err := name.ParseReference("europe-docker.pkg.dev/project/repo/app:v1")
+			g.Expect(err).ToNot(HaveOccurred())
+
+			auth, err := wi.Login(context.TODO(), ref, corev1.ServiceAccount{ObjectMeta: metav1.ObjectMeta{Annotations: tt.annotations}}, "sa-token")
+			if tt.wantErr != "" {
+				g.Expect(err).To(HaveOccurred())
+				g.Expect(err.Error()).To(ContainSubstring(tt.wantErr))
+				return
+			}
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(auth).To(Equal(&authn.Basic{Username: gcpAccessTokenUsername, Password: tt.wantToken}))
+		})
+	}
+}
+
+func TestAzureWorkloadIdentity_exchange(t *testing.T) {
+	g := NewWithT(t)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		g.Expect(r.URL.Path).To(Equal("/oauth2/exchange"))
+		g.Expect(r.ParseForm()).To(Succeed())
+		g.Expect(r.PostForm.Get("grant_type")).To(Equal("access_token"))
+		g.Expect(r.PostForm.Get("service")).To(Equal("flux.azurecr.io"))
+		g.Expect(r.PostForm.Get("tenant")).To(Equal("tenant-id"))
+		g.Expect(r.PostForm.Get("access_token")).To(Equal("aad-token"))
+		_ = json.NewEncoder(w).Encode(map[string]string{"refresh_token": "refresh-token"})
+	}))
+	defer srv.Close()
+
+	wi := azureWorkloadIdentity{client: srv.Client()}
+	token, err := wi.exchange(context.TODO(), srv.URL, "flux.azurecr.io", "tenant-id", "aad-token")
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(token).To(Equal("refresh-token"))
+}
+
+func Test_basicFromAuthorizationToken(t *testing.T) {
+	g := NewWithT(t)
+
+	auth, err := basicFromAuthorizationToken(base64.StdEncoding.EncodeToString([]byte("AWS:password")))
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(auth).To(Equal(&authn.Basic{Username: "AWS", Password: "password"}))
+
+	_, err = basicFromAuthorizationToken(base64.StdEncoding.EncodeToString([]byte("invalid")))
+	g.Expect(err).To(HaveOccurred())
+}
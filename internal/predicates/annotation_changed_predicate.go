@@ -0,0 +1,43 @@
+/*
+Copyright 2022 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package predicates
+
+import (
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+)
+
+// AnnotationChangedPredicate is a predicate that passes the Update events in
+// which the value of the given annotation changed to a non-empty value.
+type AnnotationChangedPredicate struct {
+	Annotation string
+	predicate.Funcs
+}
+
+// Update returns true if the value of the annotation of the new object is
+// set, and differs from the value of the old object.
+func (a AnnotationChangedPredicate) Update(e event.UpdateEvent) bool {
+	if e.ObjectOld == nil || e.ObjectNew == nil {
+		return false
+	}
+
+	val, ok := e.ObjectNew.GetAnnotations()[a.Annotation]
+	if !ok || val == "" {
+		return false
+	}
+	return val != e.ObjectOld.GetAnnotations()[a.Annotation]
+}
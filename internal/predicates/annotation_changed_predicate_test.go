@@ -0,0 +1,61 @@
+/*
+Copyright 2022 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package predicates
+
+import (
+	"testing"
+
+	"github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+
+	sourcev1 "github.com/fluxcd/source-controller/api/v1beta2"
+)
+
+func TestAnnotationChangedPredicate_Update(t *testing.T) {
+	const annotation = "example.com/annotation"
+	withAnnotation := func(val string) client.Object {
+		obj := &sourcev1.HelmRepository{}
+		if val != "" {
+			obj.ObjectMeta = metav1.ObjectMeta{Annotations: map[string]string{annotation: val}}
+		}
+		return obj
+	}
+
+	tests := []struct {
+		name string
+		old  client.Object
+		new  client.Object
+		want bool
+	}{
+		{name: "set", old: withAnnotation(""), new: withAnnotation("a"), want: true},
+		{name: "changed", old: withAnnotation("a"), new: withAnnotation("b"), want: true},
+		{name: "unchanged", old: withAnnotation("a"), new: withAnnotation("a"), want: false},
+		{name: "removed", old: withAnnotation("a"), new: withAnnotation(""), want: false},
+		{name: "not set", old: withAnnotation(""), new: withAnnotation(""), want: false},
+		{name: "no old object", old: nil, new: withAnnotation("a"), want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := gomega.NewWithT(t)
+
+			p := AnnotationChangedPredicate{Annotation: annotation}
+			g.Expect(p.Update(event.UpdateEvent{ObjectOld: tt.old, ObjectNew: tt.new})).To(gomega.Equal(tt.want))
+		})
+	}
+}
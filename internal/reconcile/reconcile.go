@@ -17,6 +17,8 @@ limitations under the License.
 package reconcile
 
 import (
+	"hash/fnv"
+	"math"
 	"time"
 
 	ctrl "sigs.k8s.io/controller-runtime"
@@ -104,6 +106,31 @@ func (r AlwaysRequeueResultBuilder) IsSuccess(result ctrl.Result) bool {
 	return result.RequeueAfter == r.RequeueAfter
 }
 
+// MaxIntervalJitterPercentage is the maximum percentage of an interval
+// accepted by JitterInterval.
+const MaxIntervalJitterPercentage = 50
+
+// JitterInterval returns the given interval, increased or decreased by a
+// jitter of at most the given percentage of the interval. The jitter is
+// derived from the given seed, e.g. the UID of an object, so that an object
+// is always requeued after the same interval, while objects with the same
+// interval are spread over time. A percentage of zero disables the jitter,
+// and a percentage above MaxIntervalJitterPercentage is capped.
+func JitterInterval(interval time.Duration, percentage int, seed string) time.Duration {
+	if percentage <= 0 || interval <= 0 {
+		return interval
+	}
+	if percentage > MaxIntervalJitterPercentage {
+		percentage = MaxIntervalJitterPercentage
+	}
+
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(seed))
+	// Map the hash to a factor in the range [-1, 1]
+	factor := float64(h.Sum64())/float64(math.MaxUint64)*2 - 1
+	return interval + time.Duration(factor*float64(percentage)/100*float64(interval))
+}
+
 // ComputeReconcileResult analyzes the reconcile results (result + error),
 // updates the status conditions of the object with any corrections and returns
 // object patch configuration, runtime result and runtime error. The caller is
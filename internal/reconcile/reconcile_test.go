@@ -297,6 +297,68 @@ func TestAlwaysRequeueResultBuilder_IsSuccess(t *testing.T) {
 	}
 }
 
+func TestJitterInterval(t *testing.T) {
+	const interval = 10 * time.Minute
+
+	tests := []struct {
+		name       string
+		interval   time.Duration
+		percentage int
+		min        time.Duration
+		max        time.Duration
+	}{
+		{
+			name:       "disabled",
+			interval:   interval,
+			percentage: 0,
+			min:        interval,
+			max:        interval,
+		},
+		{
+			name:       "within percentage",
+			interval:   interval,
+			percentage: 10,
+			min:        9 * time.Minute,
+			max:        11 * time.Minute,
+		},
+		{
+			name:       "capped percentage",
+			interval:   interval,
+			percentage: 200,
+			min:        5 * time.Minute,
+			max:        15 * time.Minute,
+		},
+		{
+			name:       "zero interval",
+			percentage: 10,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			for i := 0; i < 100; i++ {
+				seed := fmt.Sprintf("uid-%d", i)
+				got := JitterInterval(tt.interval, tt.percentage, seed)
+				g.Expect(got).To(BeNumerically(">=", tt.min))
+				g.Expect(got).To(BeNumerically("<=", tt.max))
+				// The jitter is deterministic for a seed
+				g.Expect(JitterInterval(tt.interval, tt.percentage, seed)).To(Equal(got))
+			}
+		})
+	}
+
+	t.Run("spreads the intervals of different seeds", func(t *testing.T) {
+		g := NewWithT(t)
+
+		intervals := map[time.Duration]struct{}{}
+		for i := 0; i < 100; i++ {
+			intervals[JitterInterval(interval, 10, fmt.Sprintf("uid-%d", i))] = struct{}{}
+		}
+		g.Expect(len(intervals)).To(BeNumerically(">", 90))
+	})
+}
+
 func TestFailureRecovery(t *testing.T) {
 	failCondns := []string{
 		"FooFailed",
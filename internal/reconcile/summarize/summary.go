@@ -19,6 +19,7 @@ package summarize
 import (
 	"context"
 	"errors"
+	"fmt"
 
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	kerrors "k8s.io/apimachinery/pkg/util/errors"
@@ -29,6 +30,8 @@ import (
 	"github.com/fluxcd/pkg/runtime/conditions"
 	"github.com/fluxcd/pkg/runtime/patch"
 
+	serror "github.com/fluxcd/source-controller/internal/error"
+	"github.com/fluxcd/source-controller/internal/object"
 	"github.com/fluxcd/source-controller/internal/reconcile"
 )
 
@@ -93,6 +96,13 @@ type HelperOptions struct {
 	// BiPolarityConditionTypes is a list of bipolar conditions in the order
 	// of priority.
 	BiPolarityConditionTypes []string
+	// FailureThreshold is the number of consecutive failed reconciliations
+	// with the FailureCondition set to True, after which the reconciliation
+	// error is promoted to a stalling error. Zero disables the promotion.
+	FailureThreshold int64
+	// FailureCondition is the condition type of the failures counted
+	// towards the FailureThreshold.
+	FailureCondition string
 }
 
 // Option is configuration that modifies SummarizeAndPatch.
@@ -160,6 +170,18 @@ func WithBiPolarityConditionTypes(types ...string) Option {
 	}
 }
 
+// WithFailureThreshold sets the number of consecutive failed reconciliations
+// with the given condition set to True, after which the reconciliation error
+// is promoted to a stalling error in SummarizeAndPatch. The number of
+// consecutive failures is tracked in the status of the object, regardless of
+// the threshold. A threshold of zero disables the promotion.
+func WithFailureThreshold(threshold int64, conditionType string) Option {
+	return func(s *HelperOptions) {
+		s.FailureThreshold = threshold
+		s.FailureCondition = conditionType
+	}
+}
+
 // SummarizeAndPatch summarizes and patches the result to the target object.
 // When used at the very end of a reconciliation, the result builder must be
 // specified using the Option WithResultBuilder(). The returned result and error
@@ -188,6 +210,14 @@ func (h *Helper) SummarizeAndPatch(ctx context.Context, obj conditions.Setter, o
 		patchOpts = append(patchOpts, patch.WithFieldOwner(opts.PatchFieldOwner))
 	}
 
+	// Track the consecutive failures and promote persistent failures to
+	// stalling. This must be performed only at the end of a reconciliation,
+	// and before processing the results, so that the promoted error is
+	// processed.
+	if opts.FailureCondition != "" && opts.ResultBuilder != nil {
+		opts.ReconcileResult, opts.ReconcileError = trackConsecutiveFailures(obj, opts)
+	}
+
 	// Process the results of reconciliation.
 	for _, processor := range opts.Processors {
 		processor(ctx, h.recorder, obj, opts.ReconcileResult, opts.ReconcileError)
@@ -273,3 +303,45 @@ func isNonStalledSuccess(obj conditions.Setter, rb reconcile.RuntimeResultBuilde
 	}
 	return false
 }
+
+// trackConsecutiveFailures updates the number of consecutive failures in the
+// status of the object based on the reconcile result and error. Once a
+// non-zero failure threshold is reached, it returns a stalling error with an
+// empty result. Otherwise, it returns the reconcile result and error as is.
+// Waiting and stalling errors do not affect the number of failures.
+func trackConsecutiveFailures(obj conditions.Setter, opts *HelperOptions) (reconcile.Result, error) {
+	res, recErr := opts.ReconcileResult, opts.ReconcileError
+
+	failed := recErr != nil && conditions.IsTrue(obj, opts.FailureCondition)
+	switch e := recErr.(type) {
+	case *serror.Waiting, *serror.Stalling:
+		return res, recErr
+	case *serror.Generic:
+		if e.Ignore {
+			failed = false
+		}
+	}
+
+	count, err := object.GetStatusConsecutiveFailures(obj)
+	if err != nil {
+		return res, recErr
+	}
+	if !failed {
+		if count > 0 {
+			_ = object.SetStatusConsecutiveFailures(obj, 0)
+		}
+		return res, recErr
+	}
+
+	count++
+	if err := object.SetStatusConsecutiveFailures(obj, count); err != nil {
+		return res, recErr
+	}
+	if opts.FailureThreshold <= 0 || count < opts.FailureThreshold {
+		return res, recErr
+	}
+	return reconcile.ResultEmpty, serror.NewStalling(
+		fmt.Errorf("reconciliation stalled after %d consecutive failures: %w", count, recErr),
+		conditions.GetReason(obj, opts.FailureCondition),
+	)
+}
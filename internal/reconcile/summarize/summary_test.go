@@ -485,6 +485,171 @@ func TestSummarizeAndPatch_Intermediate(t *testing.T) {
 	}
 }
 
+func TestSummarizeAndPatch_FailureThreshold(t *testing.T) {
+	interval := 5 * time.Second
+
+	var testReadyConditions = Conditions{
+		Target:           meta.ReadyCondition,
+		Owned:            []string{sourcev1.FetchFailedCondition, meta.ReadyCondition, meta.ReconcilingCondition, meta.StalledCondition},
+		Summarize:        []string{sourcev1.FetchFailedCondition, meta.StalledCondition, meta.ReconcilingCondition},
+		NegativePolarity: []string{sourcev1.FetchFailedCondition, meta.StalledCondition, meta.ReconcilingCondition},
+	}
+
+	tests := []struct {
+		name             string
+		failures         int64
+		noThreshold      bool
+		beforeFunc       func(obj conditions.Setter)
+		result           reconcile.Result
+		reconcileErr     error
+		wantErr          bool
+		wantFailures     int64
+		assertConditions []metav1.Condition
+	}{
+		{
+			name: "fetch failure below threshold",
+			beforeFunc: func(obj conditions.Setter) {
+				conditions.MarkTrue(obj, sourcev1.FetchFailedCondition, "GitOperationFailed", "failed to checkout")
+			},
+			result:       reconcile.ResultEmpty,
+			reconcileErr: errors.New("failed to checkout"),
+			wantErr:      true,
+			wantFailures: 1,
+			assertConditions: []metav1.Condition{
+				*conditions.TrueCondition(sourcev1.FetchFailedCondition, "GitOperationFailed", "failed to checkout"),
+				*conditions.FalseCondition(meta.ReadyCondition, "GitOperationFailed", "failed to checkout"),
+			},
+		},
+		{
+			name:     "fetch failure reaching threshold stalls",
+			failures: 2,
+			beforeFunc: func(obj conditions.Setter) {
+				conditions.MarkTrue(obj, sourcev1.FetchFailedCondition, "GitOperationFailed", "failed to checkout")
+			},
+			result:       reconcile.ResultEmpty,
+			reconcileErr: errors.New("failed to checkout"),
+			wantErr:      false,
+			wantFailures: 3,
+			assertConditions: []metav1.Condition{
+				*conditions.TrueCondition(sourcev1.FetchFailedCondition, "GitOperationFailed", "failed to checkout"),
+				*conditions.TrueCondition(meta.StalledCondition, "GitOperationFailed", "reconciliation stalled after 3 consecutive failures: failed to checkout"),
+				*conditions.FalseCondition(meta.ReadyCondition, "GitOperationFailed", "failed to checkout"),
+			},
+		},
+		{
+			name:        "fetch failure without threshold is counted but does not stall",
+			failures:    5,
+			noThreshold: true,
+			beforeFunc: func(obj conditions.Setter) {
+				conditions.MarkTrue(obj, sourcev1.FetchFailedCondition, "GitOperationFailed", "failed to checkout")
+			},
+			result:       reconcile.ResultEmpty,
+			reconcileErr: errors.New("failed to checkout"),
+			wantErr:      true,
+			wantFailures: 6,
+			assertConditions: []metav1.Condition{
+				*conditions.TrueCondition(sourcev1.FetchFailedCondition, "GitOperationFailed", "failed to checkout"),
+				*conditions.FalseCondition(meta.ReadyCondition, "GitOperationFailed", "failed to checkout"),
+			},
+		},
+		{
+			name:     "failure without fetch failure resets count",
+			failures: 2,
+			beforeFunc: func(obj conditions.Setter) {
+				conditions.MarkFalse(obj, meta.ReadyCondition, "StorageOperationFailed", "failed to write")
+			},
+			result:       reconcile.ResultEmpty,
+			reconcileErr: errors.New("failed to write"),
+			wantErr:      true,
+			wantFailures: 0,
+			assertConditions: []metav1.Condition{
+				*conditions.FalseCondition(meta.ReadyCondition, "StorageOperationFailed", "failed to write"),
+			},
+		},
+		{
+			name:     "waiting error does not change count",
+			failures: 2,
+			beforeFunc: func(obj conditions.Setter) {
+				conditions.MarkTrue(obj, sourcev1.FetchFailedCondition, "GitOperationFailed", "failed to checkout")
+			},
+			result:       reconcile.ResultEmpty,
+			reconcileErr: serror.NewWaiting(errors.New("waiting"), "Waiting"),
+			wantErr:      true,
+			wantFailures: 2,
+			assertConditions: []metav1.Condition{
+				*conditions.TrueCondition(sourcev1.FetchFailedCondition, "GitOperationFailed", "failed to checkout"),
+				*conditions.FalseCondition(meta.ReadyCondition, "GitOperationFailed", "failed to checkout"),
+			},
+		},
+		{
+			name:     "success resets count",
+			failures: 2,
+			beforeFunc: func(obj conditions.Setter) {
+				conditions.MarkTrue(obj, meta.ReadyCondition, meta.SucceededReason, "stored artifact")
+			},
+			result:       reconcile.ResultSuccess,
+			wantErr:      false,
+			wantFailures: 0,
+			assertConditions: []metav1.Condition{
+				*conditions.TrueCondition(meta.ReadyCondition, meta.SucceededReason, "stored artifact"),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			scheme := runtime.NewScheme()
+			g.Expect(sourcev1.AddToScheme(scheme))
+
+			builder := fakeclient.NewClientBuilder().WithScheme(scheme)
+			kclient := builder.Build()
+
+			obj := &sourcev1.GitRepository{
+				ObjectMeta: metav1.ObjectMeta{
+					GenerateName: "test-",
+					Generation:   1,
+				},
+				Spec: sourcev1.GitRepositorySpec{
+					Interval: metav1.Duration{Duration: interval},
+				},
+				Status: sourcev1.GitRepositoryStatus{
+					ConsecutiveFailures: tt.failures,
+				},
+			}
+
+			if tt.beforeFunc != nil {
+				tt.beforeFunc(obj)
+			}
+
+			ctx := context.TODO()
+			g.Expect(kclient.Create(ctx, obj)).To(Succeed())
+			serialPatcher := patch.NewSerialPatcher(obj, kclient)
+
+			threshold := int64(3)
+			if tt.noThreshold {
+				threshold = 0
+			}
+
+			summaryHelper := NewHelper(record.NewFakeRecorder(32), serialPatcher)
+			summaryOpts := []Option{
+				WithReconcileResult(tt.result),
+				WithReconcileError(tt.reconcileErr),
+				WithConditions(testReadyConditions),
+				WithProcessors(ErrorActionHandler),
+				WithResultBuilder(reconcile.AlwaysRequeueResultBuilder{RequeueAfter: interval}),
+				WithFailureThreshold(threshold, sourcev1.FetchFailedCondition),
+			}
+			_, gotErr := summaryHelper.SummarizeAndPatch(ctx, obj, summaryOpts...)
+			g.Expect(gotErr != nil).To(Equal(tt.wantErr))
+
+			g.Expect(obj.Status.ConsecutiveFailures).To(Equal(tt.wantFailures))
+			g.Expect(obj.Status.Conditions).To(conditions.MatchConditions(tt.assertConditions))
+		})
+	}
+}
+
 func TestIsNonStalledSuccess(t *testing.T) {
 	interval := 5 * time.Second
 
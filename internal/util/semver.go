@@ -0,0 +1,104 @@
+/*
+Copyright 2022 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package util
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/Masterminds/semver/v3"
+)
+
+// prereleaseRegexp matches a version with a prerelease in a semver constraint
+// expression, e.g. '1.2.3-rc.1', 'v1.2-0' or '1.x-0'.
+var prereleaseRegexp = regexp.MustCompile(`^v?(\d+|[xX*])(?:\.(\d+|[xX*]))?(?:\.(\d+|[xX*]))?-[0-9A-Za-z.-]+(?:\+[0-9A-Za-z.-]+)?$`)
+
+// PrereleaseFilter filters out the prerelease versions which are not
+// explicitly referenced by a semver constraint expression.
+//
+// A semver constraint which references a prerelease, e.g. '>=1.0.0-0',
+// matches the prereleases of all the versions within its range, e.g.
+// '2.0.0-rc.1'. A PrereleaseFilter only allows the prereleases of a version
+// with the same major, minor and patch version as one of the prereleases
+// referenced by the constraint, e.g. '1.0.0-rc.1'.
+type PrereleaseFilter struct {
+	// referenced are the major, minor and patch versions of the prereleases
+	// referenced by the constraint, with an empty string as wildcard.
+	referenced [][3]string
+}
+
+// NewPrereleaseFilter returns a PrereleaseFilter for the given semver
+// constraint expression.
+func NewPrereleaseFilter(constraint string) *PrereleaseFilter {
+	f := &PrereleaseFilter{}
+	fields := strings.FieldsFunc(constraint, func(r rune) bool {
+		return r == ' ' || r == ',' || r == '|'
+	})
+	for _, field := range fields {
+		field = strings.TrimLeft(field, "=!<>~^")
+		m := prereleaseRegexp.FindStringSubmatch(field)
+		if m == nil {
+			continue
+		}
+		var ref [3]string
+		for i, part := range m[1:4] {
+			switch part {
+			case "x", "X", "*":
+			case "":
+				ref[i] = "0"
+			default:
+				ref[i] = part
+			}
+		}
+		f.referenced = append(f.referenced, ref)
+	}
+	return f
+}
+
+// Allow returns true if the given version is not a prerelease, or if it is a
+// prerelease of a version referenced by the constraint.
+func (f *PrereleaseFilter) Allow(v *semver.Version) bool {
+	if v.Prerelease() == "" {
+		return true
+	}
+	core := [3]string{
+		strconv.FormatUint(v.Major(), 10),
+		strconv.FormatUint(v.Minor(), 10),
+		strconv.FormatUint(v.Patch(), 10),
+	}
+	for _, ref := range f.referenced {
+		if matchVersionCore(ref, core) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchVersionCore returns true if the given major, minor and patch versions
+// match the referenced ones.
+func matchVersionCore(ref, core [3]string) bool {
+	for i := range ref {
+		if ref[i] == "" {
+			return true
+		}
+		if ref[i] != core[i] {
+			return false
+		}
+	}
+	return true
+}
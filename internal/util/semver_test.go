@@ -0,0 +1,94 @@
+/*
+Copyright 2022 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package util
+
+import (
+	"testing"
+
+	"github.com/Masterminds/semver/v3"
+	. "github.com/onsi/gomega"
+)
+
+func TestPrereleaseFilter_Allow(t *testing.T) {
+	tests := []struct {
+		name       string
+		constraint string
+		version    string
+		want       bool
+	}{
+		{
+			name:       "stable version",
+			constraint: ">=1.0.0",
+			version:    "2.0.0",
+			want:       true,
+		},
+		{
+			name:       "prerelease without prerelease constraint",
+			constraint: ">=1.0.0",
+			version:    "1.1.0-rc.1",
+		},
+		{
+			name:       "prerelease of referenced version",
+			constraint: ">=1.0.0-0",
+			version:    "1.0.0-rc.1",
+			want:       true,
+		},
+		{
+			name:       "prerelease of other version",
+			constraint: ">=1.0.0-0",
+			version:    "2.0.0-rc.1",
+		},
+		{
+			name:       "prerelease of version referenced in an OR expression",
+			constraint: "~1.0.0 || >= 2.0.0-rc.0, < 3.0.0",
+			version:    "2.0.0-rc.1",
+			want:       true,
+		},
+		{
+			name:       "prerelease of version referenced with a v prefix",
+			constraint: "^v1.2-0",
+			version:    "1.2.0-beta.2",
+			want:       true,
+		},
+		{
+			name:       "prerelease of version referenced with a wildcard",
+			constraint: "1.x-0",
+			version:    "1.4.0-alpha.1",
+			want:       true,
+		},
+		{
+			name:       "prerelease of version outside of wildcard",
+			constraint: "1.x-0",
+			version:    "2.0.0-alpha.1",
+		},
+		{
+			name:       "prerelease in hyphen range",
+			constraint: "1.0.0-rc.1 - 2.0.0",
+			version:    "1.0.0-rc.2",
+			want:       true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			v, err := semver.NewVersion(tt.version)
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(NewPrereleaseFilter(tt.constraint).Allow(v)).To(Equal(tt.want))
+		})
+	}
+}
@@ -22,13 +22,17 @@ import (
 	"net/http"
 	"os"
 	"path/filepath"
+	"strings"
 	"time"
 
 	"github.com/go-logr/logr"
 	flag "github.com/spf13/pflag"
+	"golang.org/x/time/rate"
 	"helm.sh/helm/v3/pkg/getter"
 	"k8s.io/apimachinery/pkg/runtime"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/client-go/kubernetes"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
 	_ "k8s.io/client-go/plugin/pkg/client/auth/gcp"
 	ctrl "sigs.k8s.io/controller-runtime"
@@ -49,6 +53,8 @@ import (
 	"github.com/fluxcd/source-controller/controllers"
 	"github.com/fluxcd/source-controller/internal/cache"
 	"github.com/fluxcd/source-controller/internal/helm"
+	soci "github.com/fluxcd/source-controller/internal/oci"
+	sreconcile "github.com/fluxcd/source-controller/internal/reconcile"
 	// +kubebuilder:scaffold:imports
 )
 
@@ -85,11 +91,15 @@ func main() {
 		storageAddr              string
 		storageAdvAddr           string
 		concurrent               int
+		concurrentPerNamespace   int
 		requeueDependency        time.Duration
 		watchAllNamespaces       bool
 		helmIndexLimit           int64
 		helmChartLimit           int64
 		helmChartFileLimit       int64
+		helmChartSourceMaxSize   int64
+		helmChartSourceMaxFiles  int
+		helmChartBuildMemory     int64
 		clientOptions            client.Options
 		logOptions               logger.Options
 		leaderElectionOptions    leaderelection.Options
@@ -100,6 +110,34 @@ func main() {
 		helmCachePurgeInterval   string
 		artifactRetentionTTL     time.Duration
 		artifactRetentionRecords int
+		failureThreshold         int64
+		verificationRetryBase    time.Duration
+		verificationRetryMax     time.Duration
+		storageArtifactPrefix    string
+		storageShardArtifacts    bool
+		storageLockAttempts      int
+		storageLockTimeout       time.Duration
+		storageNormalizeModes    bool
+		storageChecksumAlgorithm string
+		credentialExpiryWindow   time.Duration
+		ociMetricsHosts          []string
+		allowedRegistryDomains   []string
+		defaultSAPullSecrets     bool
+		ociRegistryMirrors       map[string]string
+		userAgent                string
+		ociRegistryRetries       int
+		ociRegistryRetryBackoff  time.Duration
+		ociMirrorRateLimit       float64
+		ociMirrorBurst           int
+		disableRekorLookups      bool
+		disableKeylessVerify     bool
+		intervalJitterPercentage int
+		storageIntegrityInterval time.Duration
+		artifactGCTimeout        time.Duration
+		ociRetentionTTL          time.Duration
+		ociRetentionRecords      int
+		helmRetentionTTL         time.Duration
+		helmRetentionRecords     int
 	)
 
 	flag.StringVar(&metricsAddr, "metrics-addr", envOrDefault("METRICS_ADDR", ":8080"),
@@ -113,7 +151,21 @@ func main() {
 		"The address the static file server binds to.")
 	flag.StringVar(&storageAdvAddr, "storage-adv-addr", envOrDefault("STORAGE_ADV_ADDR", ""),
 		"The advertised address of the static file server.")
+	flag.StringVar(&storageArtifactPrefix, "storage-artifact-prefix", envOrDefault("STORAGE_ARTIFACT_PREFIX", ""),
+		"The cluster or tenant identifier prepended to the artifact paths, to keep them unique when the storage is shared across clusters. Artifacts stored without the prefix are garbage collected once they exceed the retention TTL.")
+	flag.BoolVar(&storageShardArtifacts, "storage-shard-artifacts", false,
+		"Store new artifacts under a hash prefix directory, to limit the number of entries per directory of the storage.")
+	flag.IntVar(&storageLockAttempts, "storage-lock-attempts", 3,
+		"The number of attempts to create the lock of an artifact when it fails, with an exponential backoff between them.")
+	flag.DurationVar(&storageLockTimeout, "storage-lock-timeout", time.Minute,
+		"The maximum duration to wait for the lock of an artifact over all attempts, zero means no timeout.")
+	flag.BoolVar(&storageNormalizeModes, "storage-normalize-file-modes", false,
+		"Normalize the modes of the archived files to 0644, or 0755 for directories and executables, for reproducible artifact checksums.")
+	flag.StringVar(&storageChecksumAlgorithm, "storage-checksum-algorithm", controllers.ChecksumSHA256,
+		fmt.Sprintf("The algorithm of the checksums of new artifacts, one of: %s, %s.", controllers.ChecksumSHA256, controllers.ChecksumSHA512))
 	flag.IntVar(&concurrent, "concurrent", 2, "The number of concurrent reconciles per controller.")
+	flag.IntVar(&concurrentPerNamespace, "concurrent-per-namespace", 0,
+		"The maximum number of concurrent reconciles of the objects in a namespace per controller, zero means no limit.")
 	flag.BoolVar(&watchAllNamespaces, "watch-all-namespaces", true,
 		"Watch for custom resources in all namespaces, if set to false it will only watch the runtime namespace.")
 	flag.Int64Var(&helmIndexLimit, "helm-index-max-size", helm.MaxIndexSize,
@@ -122,8 +174,18 @@ func main() {
 		"The max allowed size in bytes of a Helm chart file.")
 	flag.Int64Var(&helmChartFileLimit, "helm-chart-file-max-size", helm.MaxChartFileSize,
 		"The max allowed size in bytes of a file in a Helm chart.")
+	flag.Int64Var(&helmChartSourceMaxSize, "helm-chart-source-max-size", 1<<30,
+		"The max allowed total size in bytes of the files extracted from a source artifact to build a Helm chart from, zero means no limit.")
+	flag.IntVar(&helmChartSourceMaxFiles, "helm-chart-source-max-files", 100000,
+		"The max allowed number of files extracted from a source artifact to build a Helm chart from, zero means no limit.")
+	flag.Int64Var(&helmChartBuildMemory, "helm-chart-build-memory-budget", 0,
+		"The max allowed total size in bytes of the chart and values files loaded in memory to build a Helm chart from a source artifact, zero means no limit.")
 	flag.DurationVar(&requeueDependency, "requeue-dependency", 30*time.Second,
 		"The interval at which failing dependencies are reevaluated.")
+	flag.DurationVar(&verificationRetryBase, "verification-retry-base", 0,
+		"The delay before retrying a failed OCI artifact verification, doubled on every consecutive failure. Zero retries at the object interval.")
+	flag.DurationVar(&verificationRetryMax, "verification-retry-max", 0,
+		"The maximum delay before retrying a failed OCI artifact verification, zero means no maximum.")
 	flag.IntVar(&helmCacheMaxSize, "helm-cache-max-size", 0,
 		"The maximum size of the cache in number of indexes.")
 	flag.StringVar(&helmCacheTTL, "helm-cache-ttl", "15m",
@@ -138,6 +200,47 @@ func main() {
 		"The duration of time that artifacts from previous reconcilations will be kept in storage before being garbage collected.")
 	flag.IntVar(&artifactRetentionRecords, "artifact-retention-records", 2,
 		"The maximum number of artifacts to be kept in storage after a garbage collection.")
+	flag.DurationVar(&artifactGCTimeout, "artifact-gc-timeout", controllers.DefaultGarbageCollectTimeout,
+		"The timeout of the garbage collection of the artifacts of OCIRepository and HelmChart objects.")
+	flag.DurationVar(&ociRetentionTTL, "ocirepository-artifact-retention-ttl", 0,
+		"The retention TTL of the artifacts of OCIRepository objects, zero defaults to --artifact-retention-ttl.")
+	flag.IntVar(&ociRetentionRecords, "ocirepository-artifact-retention-records", 0,
+		"The number of artifacts of OCIRepository objects to be kept after a garbage collection, zero defaults to --artifact-retention-records.")
+	flag.DurationVar(&helmRetentionTTL, "helmchart-artifact-retention-ttl", 0,
+		"The retention TTL of the artifacts of HelmChart objects, zero defaults to --artifact-retention-ttl.")
+	flag.IntVar(&helmRetentionRecords, "helmchart-artifact-retention-records", 0,
+		"The number of artifacts of HelmChart objects to be kept after a garbage collection, zero defaults to --artifact-retention-records.")
+	flag.Int64Var(&failureThreshold, "failure-threshold", 0,
+		"The number of consecutive reconciliations failing to fetch, after which an object is marked as stalled, zero disables it.")
+	flag.DurationVar(&credentialExpiryWindow, "credential-expiry-window", 0,
+		"The duration before the expiry of OCI registry credentials within which a warning event is emitted, zero disables it.")
+	flag.StringSliceVar(&ociMetricsHosts, "oci-metrics-hosts", []string{},
+		"The list of OCI registry hosts to partition the OCI operation metrics by, other hosts are recorded as 'other'. Empty disables the partitioning.")
+	flag.StringSliceVar(&allowedRegistryDomains, "allowed-registry-domains", []string{},
+		"The list of registry domains from which OCIRepository and OCI HelmRepository artifacts are allowed to be pulled, '*.<domain>' matches any subdomain. Empty allows any domain.")
+	flag.BoolVar(&defaultSAPullSecrets, "default-service-account-pull-secrets", false,
+		"Use the image pull secrets of the default service account of the namespace for OCIRepositories which do not configure any credentials.")
+	flag.StringToStringVar(&ociRegistryMirrors, "oci-registry-mirrors", map[string]string{},
+		"The mirrors of OCI registries in the format '<registry-host>=<mirror-host>', from which OCIRepository artifacts are pulled before falling back to the registry.")
+	flag.StringVar(&userAgent, "user-agent", "",
+		"The User-Agent of the requests to OCI registries and Helm repositories. When empty, the default User-Agent of the clients is used.")
+	flag.IntVar(&ociRegistryRetries, "oci-registry-retries", 2,
+		"The number of times the requests of OCIRepositories to a registry failing with a server error are retried within a reconciliation, zero disables the retries.")
+	flag.DurationVar(&ociRegistryRetryBackoff, "oci-registry-retry-backoff", 500*time.Millisecond,
+		"The delay before the first retry of a request to a registry, which is doubled for every further retry.")
+	flag.Float64Var(&ociMirrorRateLimit, "oci-mirror-rate-limit", 1,
+		"The maximum number of artifacts per second copied to the mirror registries of OCIRepositories, zero means no limit.")
+	flag.IntVar(&ociMirrorBurst, "oci-mirror-burst", 5,
+		"The maximum number of artifacts copied to the mirror registries of OCIRepositories in a burst above the rate limit.")
+	flag.BoolVar(&disableRekorLookups, "disable-rekor-lookups", false,
+		"Verify the transparency log inclusion of keyless cosign signatures with the bundles attached to the signatures, without contacting Rekor. For air-gapped environments.")
+	flag.BoolVar(&disableKeylessVerify, "disable-keyless-verification", false,
+		"Reject the cosign verification of OCIRepositories and HelmCharts without a secret reference with public keys, instead of verifying them with the keyless method.")
+
+	flag.IntVar(&intervalJitterPercentage, "interval-jitter-percentage", 0,
+		fmt.Sprintf("The maximum percentage of the interval of OCIRepositories and HelmCharts by which their reconciliation is spread, deterministically per object, to avoid reconciling objects with the same interval at once. Between 0 and %d, zero disables it.", sreconcile.MaxIntervalJitterPercentage))
+	flag.DurationVar(&storageIntegrityInterval, "storage-integrity-scan-interval", 0,
+		"The interval at which the checksums of the artifacts in storage are verified, rebuilding the artifacts which do not match. Zero disables the scan.")
 
 	clientOptions.BindFlags(flag.CommandLine)
 	logOptions.BindFlags(flag.CommandLine)
@@ -157,6 +260,24 @@ func main() {
 		os.Exit(1)
 	}
 
+	if intervalJitterPercentage < 0 || intervalJitterPercentage > sreconcile.MaxIntervalJitterPercentage {
+		setupLog.Error(fmt.Errorf("must be between 0 and %d", sreconcile.MaxIntervalJitterPercentage),
+			"invalid interval jitter percentage", "percentage", intervalJitterPercentage)
+		os.Exit(1)
+	}
+
+	var ociMirrors *soci.Mirrors
+	if len(ociRegistryMirrors) > 0 {
+		if ociMirrors, err = soci.NewMirrors(ociRegistryMirrors); err != nil {
+			setupLog.Error(err, "unable to configure OCI registry mirrors")
+			os.Exit(1)
+		}
+	}
+
+	if userAgent != "" {
+		setupLog.Info("overriding the User-Agent of outbound requests", "userAgent", userAgent)
+	}
+
 	// Set upper bound file size limits Helm
 	helm.MaxIndexSize = helmIndexLimit
 	helm.MaxChartSize = helmChartLimit
@@ -201,18 +322,34 @@ func main() {
 	if storageAdvAddr == "" {
 		storageAdvAddr = determineAdvStorageAddr(storageAddr, setupLog)
 	}
-	storage := mustInitStorage(storagePath, storageAdvAddr, artifactRetentionTTL, artifactRetentionRecords, setupLog)
+	storage := mustInitStorage(storagePath, storageAdvAddr, storageArtifactPrefix, storageShardArtifacts, artifactRetentionTTL, artifactRetentionRecords, setupLog)
+	storage.LockAttempts = storageLockAttempts
+	storage.LockTimeout = storageLockTimeout
+	storage.NormalizeFileModes = storageNormalizeModes
+	switch storageChecksumAlgorithm {
+	case controllers.ChecksumSHA256, controllers.ChecksumSHA512:
+		storage.ChecksumAlgorithm = storageChecksumAlgorithm
+	default:
+		setupLog.Error(fmt.Errorf("unsupported algorithm '%s'", storageChecksumAlgorithm), "invalid storage checksum algorithm")
+		os.Exit(1)
+	}
+	if err = mgr.AddReadyzCheck("storage", storage.ReadyCheck); err != nil {
+		setupLog.Error(err, "unable to create storage ready check")
+		os.Exit(1)
+	}
 
 	if err = (&controllers.GitRepositoryReconciler{
-		Client:         mgr.GetClient(),
-		EventRecorder:  eventRecorder,
-		Metrics:        metricsH,
-		Storage:        storage,
-		ControllerName: controllerName,
+		Client:           mgr.GetClient(),
+		EventRecorder:    eventRecorder,
+		Metrics:          metricsH,
+		Storage:          storage,
+		ControllerName:   controllerName,
+		FailureThreshold: failureThreshold,
 	}).SetupWithManagerAndOptions(mgr, controllers.GitRepositoryReconcilerOptions{
-		MaxConcurrentReconciles:   concurrent,
-		DependencyRequeueInterval: requeueDependency,
-		RateLimiter:               helper.GetRateLimiter(rateLimiterOptions),
+		MaxConcurrentReconciles:             concurrent,
+		MaxConcurrentReconcilesPerNamespace: concurrentPerNamespace,
+		DependencyRequeueInterval:           requeueDependency,
+		RateLimiter:                         helper.GetRateLimiter(rateLimiterOptions),
 	}); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", sourcev1.GitRepositoryKind)
 		os.Exit(1)
@@ -225,9 +362,12 @@ func main() {
 		Getters:                 getters,
 		ControllerName:          controllerName,
 		RegistryClientGenerator: registry.ClientGenerator,
+		CredentialExpiryWindow:  credentialExpiryWindow,
+		AllowedRegistryDomains:  allowedRegistryDomains,
 	}).SetupWithManagerAndOptions(mgr, controllers.HelmRepositoryReconcilerOptions{
-		MaxConcurrentReconciles: concurrent,
-		RateLimiter:             helper.GetRateLimiter(rateLimiterOptions),
+		MaxConcurrentReconciles:             concurrent,
+		MaxConcurrentReconcilesPerNamespace: concurrentPerNamespace,
+		RateLimiter:                         helper.GetRateLimiter(rateLimiterOptions),
 	}); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", sourcev1.HelmRepositoryKind, "type", "OCI")
 		os.Exit(1)
@@ -252,69 +392,137 @@ func main() {
 	}
 
 	cacheRecorder := cache.MustMakeMetrics()
+	registryRecorder := soci.MustMakeRegistryMetrics(ociMetricsHosts)
 
 	if err = (&controllers.HelmRepositoryReconciler{
-		Client:         mgr.GetClient(),
-		EventRecorder:  eventRecorder,
-		Metrics:        metricsH,
-		Storage:        storage,
-		Getters:        getters,
-		ControllerName: controllerName,
-		Cache:          c,
-		TTL:            ttl,
-		CacheRecorder:  cacheRecorder,
+		Client:           mgr.GetClient(),
+		EventRecorder:    eventRecorder,
+		Metrics:          metricsH,
+		Storage:          storage,
+		Getters:          getters,
+		ControllerName:   controllerName,
+		Cache:            c,
+		TTL:              ttl,
+		CacheRecorder:    cacheRecorder,
+		FailureThreshold: failureThreshold,
+		UserAgent:        userAgent,
 	}).SetupWithManagerAndOptions(mgr, controllers.HelmRepositoryReconcilerOptions{
-		MaxConcurrentReconciles: concurrent,
-		RateLimiter:             helper.GetRateLimiter(rateLimiterOptions),
+		MaxConcurrentReconciles:             concurrent,
+		MaxConcurrentReconcilesPerNamespace: concurrentPerNamespace,
+		RateLimiter:                         helper.GetRateLimiter(rateLimiterOptions),
 	}); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", sourcev1.HelmRepositoryKind)
 		os.Exit(1)
 	}
 
 	if err = (&controllers.HelmChartReconciler{
-		Client:                  mgr.GetClient(),
-		RegistryClientGenerator: registry.ClientGenerator,
-		Storage:                 storage,
-		Getters:                 getters,
-		EventRecorder:           eventRecorder,
-		Metrics:                 metricsH,
-		ControllerName:          controllerName,
-		Cache:                   c,
-		TTL:                     ttl,
-		CacheRecorder:           cacheRecorder,
+		Client:                     mgr.GetClient(),
+		RegistryClientGenerator:    registry.ClientGenerator,
+		Storage:                    storage,
+		Getters:                    getters,
+		EventRecorder:              eventRecorder,
+		Metrics:                    metricsH,
+		ControllerName:             controllerName,
+		Cache:                      c,
+		TTL:                        ttl,
+		CacheRecorder:              cacheRecorder,
+		SourceMaxSize:              helmChartSourceMaxSize,
+		SourceMaxFiles:             helmChartSourceMaxFiles,
+		BuildMemoryBudget:          helmChartBuildMemory,
+		FailureThreshold:           failureThreshold,
+		IntervalJitterPercentage:   intervalJitterPercentage,
+		UserAgent:                  userAgent,
+		RegistryRecorder:           registryRecorder,
+		AllowedRegistryDomains:     allowedRegistryDomains,
+		DisableRekorLookups:        disableRekorLookups,
+		DisableKeylessVerification: disableKeylessVerify,
 	}).SetupWithManagerAndOptions(mgr, controllers.HelmChartReconcilerOptions{
-		MaxConcurrentReconciles: concurrent,
-		RateLimiter:             helper.GetRateLimiter(rateLimiterOptions),
+		MaxConcurrentReconciles:             concurrent,
+		MaxConcurrentReconcilesPerNamespace: concurrentPerNamespace,
+		RateLimiter:                         helper.GetRateLimiter(rateLimiterOptions),
+		GarbageCollection: controllers.GarbageCollectOptions{
+			Timeout:          artifactGCTimeout,
+			RetentionTTL:     helmRetentionTTL,
+			RetentionRecords: helmRetentionRecords,
+		},
 	}); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", sourcev1.HelmChartKind)
 		os.Exit(1)
 	}
 	if err = (&controllers.BucketReconciler{
-		Client:         mgr.GetClient(),
-		EventRecorder:  eventRecorder,
-		Metrics:        metricsH,
-		Storage:        storage,
-		ControllerName: controllerName,
+		Client:           mgr.GetClient(),
+		EventRecorder:    eventRecorder,
+		Metrics:          metricsH,
+		Storage:          storage,
+		ControllerName:   controllerName,
+		FailureThreshold: failureThreshold,
 	}).SetupWithManagerAndOptions(mgr, controllers.BucketReconcilerOptions{
-		MaxConcurrentReconciles: concurrent,
-		RateLimiter:             helper.GetRateLimiter(rateLimiterOptions),
+		MaxConcurrentReconciles:             concurrent,
+		MaxConcurrentReconcilesPerNamespace: concurrentPerNamespace,
+		RateLimiter:                         helper.GetRateLimiter(rateLimiterOptions),
 	}); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "Bucket")
 		os.Exit(1)
 	}
+	tokenClient, err := kubernetes.NewForConfig(restConfig)
+	if err != nil {
+		setupLog.Error(err, "unable to create service account token client")
+		os.Exit(1)
+	}
+	var mirrorRateLimiter *rate.Limiter
+	if ociMirrorRateLimit > 0 {
+		if ociMirrorBurst < 1 {
+			ociMirrorBurst = 1
+		}
+		mirrorRateLimiter = rate.NewLimiter(rate.Limit(ociMirrorRateLimit), ociMirrorBurst)
+	}
 	if err = (&controllers.OCIRepositoryReconciler{
-		Client:         mgr.GetClient(),
-		Storage:        storage,
-		EventRecorder:  eventRecorder,
-		ControllerName: controllerName,
-		Metrics:        metricsH,
+		Client:                           mgr.GetClient(),
+		Storage:                          storage,
+		EventRecorder:                    eventRecorder,
+		ControllerName:                   controllerName,
+		Metrics:                          metricsH,
+		FailureThreshold:                 failureThreshold,
+		IntervalJitterPercentage:         intervalJitterPercentage,
+		CredentialExpiryWindow:           credentialExpiryWindow,
+		RegistryRecorder:                 registryRecorder,
+		Mirrors:                          ociMirrors,
+		AllowedRegistryDomains:           allowedRegistryDomains,
+		DefaultServiceAccountPullSecrets: defaultSAPullSecrets,
+		UserAgent:                        userAgent,
+		RegistryRetries:                  ociRegistryRetries,
+		RegistryRetryBackoff:             ociRegistryRetryBackoff,
+		TokenClient:                      tokenClient,
+		DisableRekorLookups:              disableRekorLookups,
+		DisableKeylessVerification:       disableKeylessVerify,
+		MirrorRateLimiter:                mirrorRateLimiter,
 	}).SetupWithManagerAndOptions(mgr, controllers.OCIRepositoryReconcilerOptions{
-		MaxConcurrentReconciles: concurrent,
-		RateLimiter:             helper.GetRateLimiter(rateLimiterOptions),
+		MaxConcurrentReconciles:             concurrent,
+		MaxConcurrentReconcilesPerNamespace: concurrentPerNamespace,
+		RateLimiter:                         helper.GetRateLimiter(rateLimiterOptions),
+		VerificationRetryBase:               verificationRetryBase,
+		VerificationRetryMax:                verificationRetryMax,
+		GarbageCollection: controllers.GarbageCollectOptions{
+			Timeout:          artifactGCTimeout,
+			RetentionTTL:     ociRetentionTTL,
+			RetentionRecords: ociRetentionRecords,
+		},
 	}); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "OCIRepository")
 		os.Exit(1)
 	}
+
+	if storageIntegrityInterval > 0 {
+		if err = mgr.Add(&controllers.StorageIntegrityScanner{
+			Client:        mgr.GetClient(),
+			EventRecorder: eventRecorder,
+			Storage:       storage,
+			Interval:      storageIntegrityInterval,
+		}); err != nil {
+			setupLog.Error(err, "unable to add storage integrity scanner")
+			os.Exit(1)
+		}
+	}
 	// +kubebuilder:scaffold:builder
 
 	go func() {
@@ -344,7 +552,7 @@ func startFileServer(path string, address string, l logr.Logger) {
 	}
 }
 
-func mustInitStorage(path string, storageAdvAddr string, artifactRetentionTTL time.Duration, artifactRetentionRecords int, l logr.Logger) *controllers.Storage {
+func mustInitStorage(path string, storageAdvAddr string, artifactPrefix string, shardArtifacts bool, artifactRetentionTTL time.Duration, artifactRetentionRecords int, l logr.Logger) *controllers.Storage {
 	if path == "" {
 		p, _ := os.Getwd()
 		path = filepath.Join(p, "bin")
@@ -357,6 +565,15 @@ func mustInitStorage(path string, storageAdvAddr string, artifactRetentionTTL ti
 		os.Exit(1)
 	}
 
+	if artifactPrefix != "" {
+		if errs := validation.IsDNS1123Label(artifactPrefix); len(errs) > 0 {
+			l.Error(fmt.Errorf("%s", strings.Join(errs, ", ")), "invalid storage artifact prefix", "prefix", artifactPrefix)
+			os.Exit(1)
+		}
+		storage.ArtifactPrefix = artifactPrefix
+	}
+	storage.ShardArtifacts = shardArtifacts
+
 	return storage
 }
 